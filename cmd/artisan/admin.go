@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/mail"
+	"os"
+	"strings"
+
+	"flex-service/config"
+	"flex-service/internal/entity"
+	"flex-service/internal/rbac"
+	"flex-service/internal/user_auth"
+	"flex-service/pkg/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// adminDefaults are used for the names when neither a flag nor an answer
+// gives one
+var adminDefaults = struct{ FirstName, LastName string }{"Admin", "User"}
+
+// adminAccount is the user user:create-admin creates
+type adminAccount struct {
+	Email     string
+	Username  string
+	Password  string
+	FirstName string
+	LastName  string
+	Generated bool // the password was generated rather than given
+}
+
+// createAdmin creates an active user with the given role, creating the role
+// with every permission when it doesn't exist, and prints the credentials.
+// Values missing from the flags are asked for on a terminal; a missing
+// password is generated.
+func createAdmin(roleName string) {
+	account, err := adminAccountFromInput()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		fmt.Println("Usage: go run cmd/artisan/main.go -action=user:create-admin -email=admin@example.com [-username=admin] [-password=...] [-role=super_admin]")
+		os.Exit(1)
+	}
+
+	hashedPassword, err := utils.HashPassword(account.Password)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg := config.Load()
+	db := openDatabase(cfg)
+	defer db.Close()
+	memberNo, err := user_auth.GenerateMemberNo()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	user := &entity.User{
+		UUID:      uuid.New(),
+		MemberNo:  memberNo,
+		Username:  account.Username,
+		Password:  &hashedPassword,
+		FirstName: account.FirstName,
+		LastName:  account.LastName,
+		Email:     &account.Email,
+		Active:    entity.UserActive,
+	}
+
+	var roleCreated bool
+	err = db.GetDB().Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Unscoped().Model(&entity.User{}).
+			Where("email = ? OR username = ?", account.Email, account.Username).
+			Count(&existing).Error; err != nil {
+			return fmt.Errorf("failed to look up users: %w", err)
+		}
+		if existing > 0 {
+			return fmt.Errorf("a user with email %s or username %s already exists", account.Email, account.Username)
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		ctx := context.Background()
+		repo := rbac.NewRBACRepository(tx)
+		var role entity.Role
+		if err := tx.Where("name = ?", roleName).Limit(1).Find(&role).Error; err != nil {
+			return fmt.Errorf("failed to look up role %s: %w", roleName, err)
+		}
+		if role.ID == 0 {
+			role = entity.Role{Name: roleName, Description: "Full access"}
+			if err := repo.CreateRole(ctx, &role, []string{"*"}); err != nil {
+				return err
+			}
+			roleCreated = true
+		}
+		return repo.AssignRole(ctx, user.ID, role.ID)
+	})
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	if roleCreated {
+		fmt.Printf("🛡️  Role created: %s (every permission)\n", roleName)
+		fmt.Println("   Running instances load it on restart or their next RBAC change")
+	}
+	fmt.Printf("✅ Admin created on %s with the %s role\n", databaseTarget(cfg), roleName)
+	fmt.Printf("   UUID:     %s\n", user.UUID)
+	fmt.Printf("   Email:    %s\n", account.Email)
+	fmt.Printf("   Username: %s\n", account.Username)
+	if account.Generated {
+		fmt.Printf("   Password: %s\n", account.Password)
+		fmt.Println("🔑 The password is generated and shown only once; change it after signing in")
+	} else {
+		fmt.Println("   Password: (as given)")
+	}
+}
+
+// adminAccountFromInput reads the account from the flags, asking for missing
+// values when stdin is a terminal
+func adminAccountFromInput() (*adminAccount, error) {
+	account := &adminAccount{
+		Email:     strings.TrimSpace(*email),
+		Username:  strings.TrimSpace(*username),
+		Password:  *password,
+		FirstName: strings.TrimSpace(*firstName),
+		LastName:  strings.TrimSpace(*lastName),
+	}
+
+	if stdinIsTerminal() {
+		reader := bufio.NewReader(os.Stdin)
+		if account.Email == "" {
+			account.Email = prompt(reader, "Email", "")
+		}
+		if account.Username == "" {
+			account.Username = prompt(reader, "Username", account.Email)
+		}
+		if account.FirstName == "" {
+			account.FirstName = prompt(reader, "First name", adminDefaults.FirstName)
+		}
+		if account.LastName == "" {
+			account.LastName = prompt(reader, "Last name", adminDefaults.LastName)
+		}
+	}
+
+	if account.Email == "" {
+		return nil, fmt.Errorf("email is required")
+	}
+	if _, err := mail.ParseAddress(account.Email); err != nil {
+		return nil, fmt.Errorf("invalid email %q", account.Email)
+	}
+	if account.Username == "" {
+		account.Username = account.Email
+	}
+	if account.FirstName == "" {
+		account.FirstName = adminDefaults.FirstName
+	}
+	if account.LastName == "" {
+		account.LastName = adminDefaults.LastName
+	}
+
+	// The password isn't asked for, since a terminal would echo it
+	if account.Password == "" {
+		generated, err := utils.GenerateRandomString(12)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate password: %w", err)
+		}
+		account.Password = generated
+		account.Generated = true
+	}
+	return account, nil
+}
+
+// prompt asks for a value on stdout, returning fallback for an empty answer
+func prompt(reader *bufio.Reader, label, fallback string) string {
+	if fallback != "" {
+		fmt.Printf("%s [%s]: ", label, fallback)
+	} else {
+		fmt.Printf("%s: ", label)
+	}
+	answer, _ := reader.ReadString('\n')
+	if answer = strings.TrimSpace(answer); answer != "" {
+		return answer
+	}
+	return fallback
+}
+
+// stdinIsTerminal reports whether stdin is an interactive terminal rather
+// than a pipe or file, as in CI and provisioning scripts
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"flex-service/config"
+	"flex-service/pkg/cache"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// bumpCacheVersion abandons every key in the versioned namespaces by bumping
+// the shared cache generation
+func bumpCacheVersion() {
+	cfg := config.Load()
+	if len(cfg.Cache.Versioned) == 0 {
+		fmt.Println("⚠️  CACHE_VERSIONED_NAMESPACES is empty, so no key carries the version")
+	}
+
+	client, err := cache.NewRedisClient(&cfg.Redis)
+	if err != nil {
+		fmt.Printf("❌ Failed to connect to Redis: %v\n", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	cacheConfig := cache.NewCacheConfig(&cfg.Cache)
+	previous, err := cache.CurrentVersion(ctx, client, cacheConfig)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := cache.BumpVersion(ctx, client, cacheConfig); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	current, err := cache.CurrentVersion(ctx, client, cacheConfig)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Cache version bumped: %s → %s\n", previous, current)
+	if len(cfg.Cache.Versioned) > 0 {
+		fmt.Printf("🗂️  Namespaces: %s\n", strings.Join(cfg.Cache.Versioned, ", "))
+	}
+	fmt.Printf("⏱️  Every instance switches within %s; old entries expire on their own\n", cache.VersionSyncInterval)
+}
+
+// openCache connects to the Redis server behind the cache
+func openCache(cfg *config.Config) (*redis.Client, *cache.CacheConfig) {
+	client, err := cache.NewRedisClient(&cfg.Redis)
+	if err != nil {
+		fmt.Printf("❌ Failed to connect to Redis: %v\n", err)
+		os.Exit(1)
+	}
+	return client, cache.NewCacheConfig(&cfg.Cache)
+}
+
+// clearCache deletes every key under the cache prefix. Sessions, locks and
+// the maintenance state live outside it and are kept.
+func clearCache() {
+	cfg := config.Load()
+	if cfg.Env == "production" && !*force {
+		fmt.Println("❌ cache:clear drops every cached value, rate limit counter and idempotency record in production")
+		fmt.Println("   Re-run with -force to clear anyway, or use cache:forget for specific keys")
+		os.Exit(1)
+	}
+
+	client, cacheConfig := openCache(cfg)
+	defer client.Close()
+
+	deleted, err := cache.Clear(context.Background(), client, cacheConfig)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Cache cleared: %d keys under %s deleted\n", deleted, cacheConfig.KeyPrefix)
+}
+
+// forgetCache deletes keys and glob patterns, given without the cache prefix
+func forgetCache(keyList string) {
+	var keys []string
+	for _, key := range strings.Split(keyList, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		fmt.Println("❌ Keys are required: -keys=user:42,product:*")
+		os.Exit(1)
+	}
+
+	cfg := config.Load()
+	client, cacheConfig := openCache(cfg)
+	defer client.Close()
+
+	deleted, err := cache.Forget(context.Background(), client, cacheConfig, keys...)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ %d keys deleted for %s\n", deleted, strings.Join(keys, ", "))
+}
+
+// cacheStatsScanLimit bounds the keys cache:stats scans
+const cacheStatsScanLimit = 100000
+
+// showCacheStats prints hit/miss, memory and keyspace stats from Redis INFO
+// and the cached keys per namespace
+func showCacheStats() {
+	cfg := config.Load()
+	client, cacheConfig := openCache(cfg)
+	defer client.Close()
+
+	ctx := context.Background()
+	stats, err := cache.ReadStats(ctx, client)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	maxMemory := stats.MaxMemory
+	if maxMemory == "" || maxMemory == "0B" {
+		maxMemory = "unlimited"
+	}
+	fmt.Printf("📊 Redis %s, up %s, %d clients\n", stats.Version, time.Duration(stats.UptimeSeconds)*time.Second, stats.ConnectedClients)
+	fmt.Printf("🎯 Hits: %d  Misses: %d  Hit ratio: %.1f%% (server-wide)\n", stats.Hits, stats.Misses, stats.HitRatio()*100)
+	fmt.Printf("💾 Memory: %s of %s, policy %s\n", stats.UsedMemory, maxMemory, stats.EvictionPolicy)
+	fmt.Printf("🗝️  Keys: %d (%d with TTL), evicted %d, expired %d\n", stats.Keys, stats.Expires, stats.EvictedKeys, stats.ExpiredKeys)
+
+	counts, truncated, err := cache.CountKeys(ctx, cache.NewRedisCache(client, cacheConfig), cacheStatsScanLimit)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	namespaces := make([]string, 0, len(counts))
+	total := 0
+	for namespace, n := range counts {
+		namespaces = append(namespaces, namespace)
+		total += n
+	}
+	sort.Slice(namespaces, func(i, j int) bool { return counts[namespaces[i]] > counts[namespaces[j]] })
+
+	fmt.Printf("\n📂 Keys under %s by namespace:\n", cacheConfig.KeyPrefix)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, namespace := range namespaces {
+		fmt.Fprintf(w, "  %s\t%d\n", namespace, counts[namespace])
+	}
+	w.Flush()
+	if truncated {
+		fmt.Printf("⚠️  Stopped counting after %d keys\n", cacheStatsScanLimit)
+	} else {
+		fmt.Printf("   %d keys in %d namespaces\n", total, len(namespaces))
+	}
+}
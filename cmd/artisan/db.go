@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"flex-service/config"
+	pkgDatabase "flex-service/pkg/database"
+	"flex-service/pkg/logger"
+)
+
+// wipeDatabase drops every table, including the migrations table, so the
+// next migrate starts from an empty database
+func wipeDatabase() {
+	cfg := config.Load()
+	if cfg.Env == "production" && !*force {
+		fmt.Println("❌ db:wipe drops every table and its data in production")
+		fmt.Println("   Re-run with -force to wipe anyway")
+		os.Exit(1)
+	}
+
+	db := openDatabase(cfg)
+	defer db.Close()
+
+	// Wiping under a running migrate or db:seed would break it halfway
+	acquireCommandLock(cfg, "migrate")
+	defer releaseCommandLocks()
+
+	fmt.Printf("🗑️  Dropping every table of %s...\n", databaseTarget(cfg))
+	dropped, err := pkgDatabase.DropAllTables(db.GetDB())
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(1)
+	}
+	if len(dropped) == 0 {
+		fmt.Println("ℹ️  Database has no tables")
+		return
+	}
+	fmt.Printf("✅ %d tables dropped: %s\n", len(dropped), strings.Join(dropped, ", "))
+	fmt.Println("⬆️  Run -action=migrate to recreate them")
+}
+
+// showDatabase prints the connection, version, size and tables
+func showDatabase() {
+	cfg := config.Load()
+	db := openDatabase(cfg)
+	defer db.Close()
+
+	overview, err := pkgDatabase.Inspect(db.GetDB())
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("🗄️  %s %s\n", cfg.Database.Type, overview.Version)
+	fmt.Printf("🔌 Connection: %s\n", databaseTarget(cfg))
+	if overview.Size > 0 {
+		fmt.Printf("💾 Size: %s\n", formatBytes(overview.Size))
+	}
+	if sqlDB, err := db.GetDB().DB(); err == nil {
+		stats := sqlDB.Stats()
+		fmt.Printf("🔗 Pool: %d open, %d max\n", stats.OpenConnections, stats.MaxOpenConnections)
+	}
+	fmt.Printf("📋 Tables: %d\n", len(overview.Tables))
+	if len(overview.Tables) == 0 {
+		return
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  TABLE\tROWS\tSIZE")
+	for _, table := range overview.Tables {
+		size := "-"
+		if table.Size > 0 {
+			size = formatBytes(table.Size)
+		}
+		fmt.Fprintf(w, "  %s\t%d\t%s\n", table.Name, table.Rows, size)
+	}
+	w.Flush()
+	if cfg.Database.Type != pkgDatabase.DBTypeSQLite {
+		fmt.Println("   Rows are estimates from the database statistics")
+	}
+}
+
+// describeTable prints the columns, indexes and foreign keys of a table
+func describeTable(tableName string) {
+	if tableName == "" {
+		fmt.Println("❌ Table name is required")
+		fmt.Println("Usage: go run cmd/artisan/main.go -action=db:table -table=users")
+		os.Exit(1)
+	}
+
+	cfg := config.Load()
+	db := openDatabase(cfg)
+	defer db.Close()
+
+	description, err := pkgDatabase.DescribeTable(db.GetDB(), tableName)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("📋 %s (%s)\n\n", description.Name, cfg.Database.Type)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  COLUMN\tTYPE\tNULL\tDEFAULT\tKEY")
+	for _, column := range description.Columns {
+		nullable := "NO"
+		if column.Nullable {
+			nullable = "YES"
+		}
+		defaultValue := "-"
+		if column.HasDefault {
+			defaultValue = column.Default
+		}
+		var key []string
+		if column.PrimaryKey {
+			key = append(key, "PK")
+		}
+		if column.AutoIncrement {
+			key = append(key, "AUTO")
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", column.Name, column.Type, nullable, defaultValue, strings.Join(key, ","))
+	}
+	w.Flush()
+
+	fmt.Printf("\n🔎 Indexes: %d\n", len(description.Indexes))
+	if len(description.Indexes) > 0 {
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, index := range description.Indexes {
+			kind := "INDEX"
+			switch {
+			case index.PrimaryKey:
+				kind = "PRIMARY"
+			case index.Unique:
+				kind = "UNIQUE"
+			}
+			fmt.Fprintf(w, "  %s\t%s\t(%s)\n", index.Name, kind, strings.Join(index.Columns, ", "))
+		}
+		w.Flush()
+	}
+
+	fmt.Printf("\n🔗 Foreign keys: %d\n", len(description.ForeignKeys))
+	if len(description.ForeignKeys) > 0 {
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, key := range description.ForeignKeys {
+			fmt.Fprintf(w, "  %s\t(%s) → %s(%s)\tON UPDATE %s\tON DELETE %s\n", key.Name,
+				strings.Join(key.Columns, ", "), key.RefTable, strings.Join(key.RefColumns, ", "), key.OnUpdate, key.OnDelete)
+		}
+		w.Flush()
+	}
+}
+
+// openDatabase initializes the logger and connects to the configured database
+func openDatabase(cfg *config.Config) pkgDatabase.Database {
+	if err := logger.Init(cfg.Log.Level, cfg.Log.Format); err != nil {
+		fmt.Printf("❌ Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+
+	db, err := pkgDatabase.NewDatabaseFactory().CreateDatabase(cfg.GetDatabaseConfig())
+	if err != nil {
+		fmt.Printf("❌ Failed to connect to %s database: %v\n", cfg.Database.Type, err)
+		os.Exit(1)
+	}
+	return db
+}
+
+// databaseTarget describes the configured connection without the password
+func databaseTarget(cfg *config.Config) string {
+	switch cfg.Database.Type {
+	case pkgDatabase.DBTypeMySQL:
+		c := cfg.Database.MySQL
+		return fmt.Sprintf("%s@%s:%d/%s", c.User, c.Host, c.Port, c.Name)
+	case pkgDatabase.DBTypePostgreSQL:
+		c := cfg.Database.PostgreSQL
+		return fmt.Sprintf("%s@%s:%d/%s", c.User, c.Host, c.Port, c.Name)
+	case pkgDatabase.DBTypeSQLite:
+		if cfg.Database.SQLite.InMemory {
+			return ":memory:"
+		}
+		return cfg.Database.SQLite.FilePath
+	}
+	return string(cfg.Database.Type)
+}
+
+// formatBytes prints a byte count with a binary unit
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
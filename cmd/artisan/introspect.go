@@ -0,0 +1,595 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"flex-service/config"
+	pkgDatabase "flex-service/pkg/database"
+	"flex-service/pkg/migration"
+)
+
+// introspectedTable is an existing table described as generator input
+type introspectedTable struct {
+	Name        string
+	Strategy    string
+	Fields      []Field
+	Versioned   bool
+	Indexes     []CompositeIndex
+	Missing     []string // timestamp columns the table lacks
+	Notes       []string // what the generated files don't reproduce
+	foreignKeys []pkgDatabase.ForeignKey
+}
+
+// timestampColumns are the columns every generated entity reads
+var timestampColumns = []string{"created_at", "updated_at", "deleted_at"}
+
+// declaredTypePattern finds the top-level type declarations of a Go file
+var declaredTypePattern = regexp.MustCompile(`(?m)^type\s+(\w+)\s`)
+
+// introspectDatabase generates an entity and a create table migration for each
+// table of the connected database that has none, and records the migrations as
+// applied there, since their tables exist already. Tables without the
+// timestamp columns get a pending migration that adds them.
+func introspectDatabase(tableList string) {
+	cfg := config.Load()
+	dbType := string(cfg.Database.Type)
+	db := openDatabase(cfg)
+	defer db.Close()
+
+	fmt.Printf("🔍 Reading tables of %s...\n", databaseTarget(cfg))
+	names, err := introspectTableNames(db, tableList)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	migrationTypes := declaredTypes("internal/migrations")
+	entityTypes := declaredTypes("internal/entity")
+
+	var tables []*introspectedTable
+	generated := make(map[string]*introspectedTable)
+	for _, name := range names {
+		structName := getStructName(name)
+		if migrationTypes[structName] || entityTypes[structName] {
+			fmt.Printf("⚠️  %s: %s is declared already, skipping\n", name, structName)
+			continue
+		}
+		if _, exists := generated[structName]; exists {
+			fmt.Printf("⚠️  %s: %s is generated for another table, skipping\n", name, structName)
+			continue
+		}
+
+		description, err := pkgDatabase.DescribeTable(db.GetDB(), name)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		table, err := introspectTable(description, dbType)
+		if err != nil {
+			fmt.Printf("⚠️  %s: %v, skipping\n", name, err)
+			continue
+		}
+		tables = append(tables, table)
+		generated[structName] = table
+	}
+	if len(tables) == 0 {
+		fmt.Println("ℹ️  No tables to generate")
+		return
+	}
+
+	for _, table := range tables {
+		resolveForeignKeys(table, generated, migrationTypes, entityTypes)
+	}
+	tables = sortByDependencies(tables)
+
+	// Baselines are recorded under the migrate lock so a running migrate
+	// doesn't see them half written
+	acquireCommandLock(cfg, "migrate")
+	defer releaseCommandLocks()
+	manager := migration.NewManager(db.GetDB(), nil)
+
+	// One second apart, so the migrations run in dependency order
+	now := time.Now()
+	var pending []string
+	for i, table := range tables {
+		timestamp := now.Add(time.Duration(i) * time.Second).Format("2006_01_02_150405")
+		data, err := writeBaselineMigration(table, timestamp, dbType)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", table.Name, err)
+			exit(1)
+		}
+		if err := writeIntrospectedEntity(table, dbType); err != nil {
+			fmt.Printf("❌ %s: %v\n", table.Name, err)
+			exit(1)
+		}
+		if _, err := manager.MarkApplied(data.Version, "Create "+table.Name+" table"); err != nil {
+			fmt.Printf("❌ Failed to record %s as applied: %v\n", data.Version, err)
+			exit(1)
+		}
+
+		if len(table.Missing) > 0 {
+			timestamp := now.Add(time.Duration(len(tables)+len(pending)) * time.Second).Format("2006_01_02_150405")
+			path, err := writeTimestampsMigration(table, timestamp)
+			if err != nil {
+				fmt.Printf("❌ %s: %v\n", table.Name, err)
+				exit(1)
+			}
+			pending = append(pending, path)
+		}
+
+		for _, note := range table.Notes {
+			fmt.Printf("   ⚠️  %s\n", note)
+		}
+	}
+
+	fmt.Printf("\n✅ %d tables generated; their create migrations are recorded as applied\n", len(tables))
+	if len(pending) > 0 {
+		fmt.Printf("⬆️  Run -action=migrate to add the missing timestamp columns (%d migrations)\n", len(pending))
+	}
+	fmt.Println("📝 Review the generated files: validation tags and column sizes follow the generator defaults")
+}
+
+// introspectTableNames returns the tables to generate: the -table list, or
+// every table but the migrations table
+func introspectTableNames(db pkgDatabase.Database, tableList string) ([]string, error) {
+	tables, err := pkgDatabase.Tables(db.GetDB())
+	if err != nil {
+		return nil, err
+	}
+	if tableList == "" {
+		names := make([]string, 0, len(tables))
+		for _, name := range tables {
+			if name != (migration.MigrationRecord{}).TableName() {
+				names = append(names, name)
+			}
+		}
+		return names, nil
+	}
+
+	exists := make(map[string]bool, len(tables))
+	for _, name := range tables {
+		exists[name] = true
+	}
+	var names []string
+	for _, name := range strings.Split(tableList, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !exists[name] {
+			return nil, fmt.Errorf("table %s does not exist", name)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// declaredTypes returns the type names declared in the Go files of dir
+func declaredTypes(dir string) map[string]bool {
+	types := make(map[string]bool)
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, file := range files {
+		source, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for _, match := range declaredTypePattern.FindAllSubmatch(source, -1) {
+			types[string(match[1])] = true
+		}
+	}
+	return types
+}
+
+// introspectTable maps a table description to generator fields. The key
+// strategy follows the primary key: id is int, uuid is uuid, and id with a
+// uuid column is dual. Columns the templates add themselves are left out.
+func introspectTable(description *pkgDatabase.TableDescription, dbType string) (*introspectedTable, error) {
+	table := &introspectedTable{Name: description.Name, foreignKeys: description.ForeignKeys}
+
+	columns := make(map[string]pkgDatabase.Column, len(description.Columns))
+	var primaryKey []string
+	for _, column := range description.Columns {
+		columns[column.Name] = column
+		if column.PrimaryKey {
+			primaryKey = append(primaryKey, column.Name)
+		}
+	}
+
+	_, hasUUID := columns["uuid"]
+	switch {
+	case len(primaryKey) == 1 && primaryKey[0] == "id" && hasUUID:
+		table.Strategy = "dual"
+	case len(primaryKey) == 1 && primaryKey[0] == "id":
+		table.Strategy = "int"
+	case len(primaryKey) == 1 && primaryKey[0] == "uuid":
+		table.Strategy = "uuid"
+	default:
+		return nil, fmt.Errorf("primary key (%s) is not id or uuid", strings.Join(primaryKey, ", "))
+	}
+	if table.Strategy != "uuid" {
+		if keyType, _ := introspectFieldType(columns["id"], table.Strategy); keyType != "int" && keyType != "int64" {
+			return nil, fmt.Errorf("primary key id is %s, not an integer", columns["id"].Type)
+		}
+	}
+
+	_, hasNotDeleted := columns[notDeletedColumn]
+	indexed := make(map[string]bool)
+	unique := make(map[string]bool)
+	for _, index := range description.Indexes {
+		if index.PrimaryKey {
+			continue
+		}
+		// MySQL unique indexes of soft-deleted tables include not_deleted
+		indexColumns := make([]string, 0, len(index.Columns))
+		for _, column := range index.Columns {
+			if column != notDeletedColumn {
+				indexColumns = append(indexColumns, column)
+			}
+		}
+		switch {
+		case len(indexColumns) > 1 && introspectIndexable(indexColumns):
+			composite := CompositeIndex{Name: index.Name, Columns: indexColumns, Unique: index.Unique}
+			if index.Unique && strings.EqualFold(dbType, "mysql") {
+				composite.Unique = hasNotDeleted
+				composite.MySQL = hasNotDeleted
+				if !hasNotDeleted {
+					table.Notes = append(table.Notes, fmt.Sprintf("%s: unique index %s is generated as an index; soft-deleted MySQL tables need the %s column to be unique",
+						table.Name, index.Name, notDeletedColumn))
+				}
+			}
+			table.Indexes = append(table.Indexes, composite)
+		case len(indexColumns) != 1:
+			table.Notes = append(table.Notes, fmt.Sprintf("%s: composite index %s (%s) is not generated",
+				table.Name, index.Name, strings.Join(index.Columns, ", ")))
+		case index.Unique && strings.EqualFold(dbType, "mysql") && !hasNotDeleted:
+			indexed[indexColumns[0]] = true
+			table.Notes = append(table.Notes, fmt.Sprintf("%s: unique index %s is generated as an index; soft-deleted MySQL tables need the %s column to be unique",
+				table.Name, index.Name, notDeletedColumn))
+		case index.Unique:
+			unique[indexColumns[0]] = true
+		default:
+			indexed[indexColumns[0]] = true
+		}
+	}
+
+	for _, column := range description.Columns {
+		switch column.Name {
+		case "id", "uuid", "created_at", "updated_at", "deleted_at", notDeletedColumn:
+			continue
+		}
+
+		fieldType, note := introspectFieldType(column, table.Strategy)
+		if note != "" {
+			table.Notes = append(table.Notes, table.Name+": "+note)
+		}
+		if column.Name == versionField.Name && (fieldType == "int" || fieldType == "int64") {
+			table.Versioned = true
+			continue
+		}
+
+		// A nil map already reads NULL json
+		field := Field{
+			Name:     column.Name,
+			Type:     fieldType,
+			HasIndex: indexed[column.Name],
+			IsUnique: unique[column.Name],
+			Nullable: column.Nullable && !isJSONType(fieldType),
+		}
+		if fieldType == "enum" {
+			field.Enum = columnEnum(column.Type)
+		}
+		table.Fields = append(table.Fields, field)
+	}
+
+	for _, column := range timestampColumns {
+		if _, exists := columns[column]; !exists {
+			table.Missing = append(table.Missing, column)
+		}
+	}
+	return table, nil
+}
+
+// introspectIndexable reports whether generated files can declare an index
+// on columns, which the key and version columns' fixed tags can't join
+func introspectIndexable(columns []string) bool {
+	for _, column := range columns {
+		if column == "id" || column == "uuid" || column == versionField.Name {
+			return false
+		}
+	}
+	return true
+}
+
+// introspectFieldType maps a database column type to a -fields type, with a
+// note when the generated column differs
+func introspectFieldType(column pkgDatabase.Column, strategy string) (string, string) {
+	dataType := strings.ToLower(strings.TrimSpace(column.Type))
+	base, size, _ := strings.Cut(dataType, "(")
+	size, _, _ = strings.Cut(size, ")")
+	base = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(base), "unsigned"))
+
+	switch base {
+	case "bool", "boolean":
+		return "bool", ""
+	case "tinyint", "bit":
+		if size == "1" {
+			return "bool", ""
+		}
+		return "int", ""
+	case "int", "integer", "smallint", "mediumint", "int2", "int4", "serial", "smallserial":
+		return "int", ""
+	case "bigint", "int8", "bigserial":
+		return "int64", ""
+	case "float", "real", "float4":
+		return "float", ""
+	case "double", "double precision", "float8":
+		return "float64", ""
+	case "decimal", "numeric":
+		if size != "" && strings.ReplaceAll(size, " ", "") != "10,2" {
+			return "decimal", fmt.Sprintf("%s is %s, generated as decimal(10,2)", column.Name, column.Type)
+		}
+		return "decimal", ""
+	case "char", "varchar", "character", "character varying", "nchar", "nvarchar":
+		if n, err := strconv.Atoi(size); err == nil && n > 255 {
+			return "text", fmt.Sprintf("%s is %s, generated as text", column.Name, column.Type)
+		}
+		return "string", ""
+	case "text", "tinytext", "mediumtext", "longtext", "clob", "citext":
+		return "text", ""
+	case "uuid":
+		// uuid.UUID is only imported for uuid and dual tables
+		if strategy == "int" {
+			return "string", fmt.Sprintf("%s is %s, generated as a string", column.Name, column.Type)
+		}
+		return "uuid", ""
+	case "timestamp", "timestamptz", "timestamp with time zone", "timestamp without time zone", "datetime", "date", "time":
+		return "timestamp", ""
+	case "json":
+		return "json", ""
+	case "jsonb":
+		return "jsonb", ""
+	case "enum":
+		if columnEnum(column.Type) != nil {
+			return "enum", ""
+		}
+	}
+	return "string", fmt.Sprintf("%s is %s, generated as a string", column.Name, column.Type)
+}
+
+// columnEnum returns the values of a MySQL enum('a','b') column type, nil
+// when they aren't valid -fields enum values
+func columnEnum(dataType string) []string {
+	_, values, _ := strings.Cut(dataType, "(")
+	values = strings.TrimSuffix(strings.TrimSpace(values), ")")
+	enum, err := parseEnum(strings.ReplaceAll(values, "'", ""))
+	if err != nil {
+		return nil
+	}
+	return enum
+}
+
+// resolveForeignKeys turns single column foreign keys to an id into -fields
+// foreign keys when the referenced struct exists for both the migration and
+// the entity association
+func resolveForeignKeys(table *introspectedTable, generated map[string]*introspectedTable, migrationTypes, entityTypes map[string]bool) {
+	for _, key := range table.foreignKeys {
+		reference := getStructName(key.RefTable)
+		target, isGenerated := generated[reference]
+		// A struct can't hold an association to itself by value
+		resolvable := len(key.Columns) == 1 && len(key.RefColumns) == 1 && key.RefColumns[0] == "id" &&
+			key.RefTable != table.Name && ((isGenerated && target.Strategy != "uuid") || (migrationTypes[reference] && entityTypes[reference]))
+
+		for i := range table.Fields {
+			if len(key.Columns) != 1 || table.Fields[i].Name != key.Columns[0] {
+				continue
+			}
+			table.Fields[i].HasIndex = true
+			if resolvable {
+				table.Fields[i].IsForeignKey = true
+				table.Fields[i].FKReference = key.RefTable
+			}
+		}
+		if !resolvable {
+			table.Notes = append(table.Notes, fmt.Sprintf("%s: foreign key (%s) → %s(%s) is not generated",
+				table.Name, strings.Join(key.Columns, ", "), key.RefTable, strings.Join(key.RefColumns, ", ")))
+		}
+	}
+}
+
+// sortByDependencies orders tables so referenced tables come first; tables in
+// a reference cycle keep their name order
+func sortByDependencies(tables []*introspectedTable) []*introspectedTable {
+	byName := make(map[string]*introspectedTable, len(tables))
+	for _, table := range tables {
+		byName[table.Name] = table
+	}
+
+	sorted := make([]*introspectedTable, 0, len(tables))
+	state := make(map[string]int) // 1 visiting, 2 done
+	var visit func(table *introspectedTable)
+	visit = func(table *introspectedTable) {
+		if state[table.Name] != 0 {
+			return
+		}
+		state[table.Name] = 1
+		for _, field := range table.Fields {
+			if reference, ok := byName[field.FKReference]; ok && field.IsForeignKey {
+				visit(reference)
+			}
+		}
+		state[table.Name] = 2
+		sorted = append(sorted, table)
+	}
+
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	for _, table := range tables {
+		visit(table)
+	}
+	return sorted
+}
+
+// writeBaselineMigration writes the create table migration of an existing table
+func writeBaselineMigration(table *introspectedTable, timestamp, dbType string) (MigrationData, error) {
+	migrationName := "create_" + table.Name + "_table"
+	columns := table.Fields
+	if table.Versioned {
+		columns = append(columns[:len(columns):len(columns)], versionField)
+	}
+
+	data := MigrationData{
+		ClassName:    toPascalCase(migrationName),
+		TableName:    table.Name,
+		Timestamp:    timestamp,
+		Description:  migrationName,
+		Fields:       withUniqueIndexes(table.Name, dbType, withEnums(getStructName(table.Name), table.Name, dbType, columns)),
+		Indexes:      table.Indexes,
+		Version:      fmt.Sprintf("%s_%s", timestamp, migrationName),
+		DatabaseType: dbType,
+		Strategy:     table.Strategy,
+	}
+
+	filePath := filepath.Join("internal/migrations", fmt.Sprintf("%s_%s.go", timestamp, toSnakeCase(migrationName)))
+	if err := createFileFromTemplate(filePath, getCreateTableTemplate(dbType), data); err != nil {
+		return data, fmt.Errorf("failed to generate migration file: %w", err)
+	}
+	fmt.Printf("✅ Migration created: %s (%s, %d fields)\n", filePath, table.Strategy, len(table.Fields))
+	return data, nil
+}
+
+// writeIntrospectedEntity writes the entity of an existing table
+func writeIntrospectedEntity(table *introspectedTable, dbType string) error {
+	entityName := getStructName(table.Name)
+	fields := make([]Field, len(table.Fields))
+	copy(fields, table.Fields)
+
+	data := EntityData{
+		EntityName:   entityName,
+		TableName:    table.Name,
+		Fields:       withUniqueIndexes(table.Name, dbType, withEnums(entityName, table.Name, dbType, withJSONTypes(entityName, fields))),
+		Indexes:      table.Indexes,
+		DatabaseType: dbType,
+		Strategy:     table.Strategy,
+		Versioned:    table.Versioned,
+	}
+
+	filePath := filepath.Join("internal/entity", toSnakeCase(entityName)+".go")
+	if err := createFileFromTemplate(filePath, entityTemplate, data); err != nil {
+		return fmt.Errorf("failed to generate entity file: %w", err)
+	}
+	fmt.Printf("✅ Entity created: %s\n", filePath)
+	if err := addEntityModel(filepath.Join("internal/entity", "models.go"), entityName); err != nil {
+		fmt.Printf("⚠️  Failed to register %s for migrate:verify: %v\n", entityName, err)
+	}
+	return nil
+}
+
+// TimestampsMigrationData is the input of timestampsMigrationTemplate
+type TimestampsMigrationData struct {
+	ClassName  string
+	StructName string
+	TableName  string
+	Columns    []string
+	Version    string
+}
+
+// writeTimestampsMigration writes a migration adding the timestamp columns an
+// existing table lacks
+func writeTimestampsMigration(table *introspectedTable, timestamp string) (string, error) {
+	migrationName := "add_timestamps_to_" + table.Name + "_table"
+	data := TimestampsMigrationData{
+		ClassName:  toPascalCase(migrationName),
+		StructName: toCamelCase(getStructName(table.Name)) + "Timestamps",
+		TableName:  table.Name,
+		Columns:    table.Missing,
+		Version:    fmt.Sprintf("%s_%s", timestamp, migrationName),
+	}
+
+	filePath := filepath.Join("internal/migrations", fmt.Sprintf("%s_%s.go", timestamp, migrationName))
+	if err := createFileFromTemplate(filePath, timestampsMigrationTemplate, data); err != nil {
+		return "", fmt.Errorf("failed to generate migration file: %w", err)
+	}
+	fmt.Printf("🕒 Migration created: %s (adds %s)\n", filePath, strings.Join(table.Missing, ", "))
+	return filePath, nil
+}
+
+// timestampsMigrationTemplate adds created_at, updated_at and deleted_at to an
+// existing table. The timestamps are added nullable and set on existing rows,
+// which every database allows without a table rewrite.
+const timestampsMigrationTemplate = `package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// {{.StructName}} holds the timestamp columns generated entities read
+type {{.StructName}} struct {
+	CreatedAt *time.Time
+	UpdatedAt *time.Time
+	DeletedAt gorm.DeletedAt ` + "`gorm:\"index\"`" + `
+}
+
+// TableName returns the table name for GORM
+func ({{.StructName}}) TableName() string {
+	return "{{.TableName}}"
+}
+
+// {{.ClassName}} migration - Add the missing timestamp columns to {{.TableName}}
+type {{.ClassName}} struct{}
+
+// Up adds the columns; existing rows get the current time as created_at and updated_at
+func (m *{{.ClassName}}) Up(db *gorm.DB) error {
+	now := time.Now()
+	for _, column := range []string{ {{- range $i, $c := .Columns}}{{if $i}}, {{end}}"{{$c}}"{{end -}} } {
+		if db.Migrator().HasColumn(&{{.StructName}}{}, column) {
+			continue
+		}
+		if err := db.Migrator().AddColumn(&{{.StructName}}{}, column); err != nil {
+			return err
+		}
+		if column == "deleted_at" {
+			continue
+		}
+		if err := db.Table("{{.TableName}}").Where(column+" IS NULL").Update(column, now).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down drops the added columns
+func (m *{{.ClassName}}) Down(db *gorm.DB) error {
+	for _, column := range []string{ {{- range $i, $c := .Columns}}{{if $i}}, {{end}}"{{$c}}"{{end -}} } {
+		if !db.Migrator().HasColumn(&{{.StructName}}{}, column) {
+			continue
+		}
+		if err := db.Migrator().DropColumn(&{{.StructName}}{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Description returns migration description
+func (m *{{.ClassName}}) Description() string {
+	return "Add timestamps to {{.TableName}} table"
+}
+
+// Version returns migration version
+func (m *{{.ClassName}}) Version() string {
+	return "{{.Version}}"
+}
+
+// Auto-register migration
+func init() {
+	Register(&{{.ClassName}}{})
+}
+`
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"flex-service/config"
+	"flex-service/pkg/lock"
+)
+
+// commandLockTTL is how long a command lock lives without being extended
+const commandLockTTL = 5 * time.Minute
+
+var (
+	commandLocksMu sync.Mutex
+	commandLocks   []func()
+)
+
+// acquireCommandLock takes a global lock for commands that must not run concurrently.
+// Locking is soft: when Redis is unavailable the command still runs with a warning.
+func acquireCommandLock(cfg *config.Config, name string) {
+	lockConfig := lock.DefaultLockConfig()
+	lockConfig.KeyPrefix = cfg.AppName + ":artisan:lock:"
+
+	locker, err := lock.NewLocker(&cfg.Redis, lockConfig)
+	if err != nil {
+		fmt.Printf("⚠️  Lock backend unavailable, running %s without concurrency protection: %v\n", name, err)
+		return
+	}
+
+	ctx := context.Background()
+	acquired, err := locker.Acquire(ctx, name, commandLockTTL)
+	if err != nil {
+		if !errors.Is(err, lock.ErrLockHeld) {
+			fmt.Printf("⚠️  Failed to acquire %s lock, running without concurrency protection: %v\n", name, err)
+			locker.Close()
+			return
+		}
+
+		holderInfo := "unknown holder"
+		if holder, herr := locker.Holder(ctx, name); herr == nil {
+			holderInfo = fmt.Sprintf("host %s (pid %d) since %s", holder.Host, holder.PID, holder.AcquiredAt.Local().Format(time.RFC3339))
+		}
+
+		if *force {
+			fmt.Printf("⚠️  -force given, ignoring %s lock held by %s\n", name, holderInfo)
+			locker.Close()
+			return
+		}
+
+		fmt.Printf("🔒 Another %s is already running on %s\n", name, holderInfo)
+		fmt.Println("   Wait for it to finish or re-run with -force if the lock is stale")
+		locker.Close()
+		os.Exit(1)
+	}
+
+	fmt.Printf("🔒 Acquired %s lock\n", name)
+
+	// Keep extending the lock while the command is running
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(commandLockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := locker.Extend(ctx, acquired, commandLockTTL); err != nil {
+					fmt.Printf("⚠️  Failed to extend %s lock: %v\n", name, err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	commandLocksMu.Lock()
+	commandLocks = append(commandLocks, func() {
+		close(stop)
+		if err := locker.Release(ctx, acquired); err != nil {
+			fmt.Printf("⚠️  Failed to release %s lock: %v\n", name, err)
+		}
+		locker.Close()
+	})
+	commandLocksMu.Unlock()
+}
+
+// releaseCommandLocks releases every lock taken by acquireCommandLock
+func releaseCommandLocks() {
+	commandLocksMu.Lock()
+	defer commandLocksMu.Unlock()
+
+	for _, release := range commandLocks {
+		release()
+	}
+	commandLocks = nil
+}
+
+// exit releases command locks before terminating the process
+func exit(code int) {
+	releaseCommandLocks()
+	os.Exit(code)
+}
@@ -2,21 +2,27 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
 	"flex-service/config"
 	pkgDatabase "flex-service/pkg/database"
 	"flex-service/pkg/logger"
+	"flex-service/pkg/migration"
 
 	// Dynamic import for migrations - will be included when migrations exist
+	_ "flex-service/internal/entity"
 	_ "flex-service/internal/migrations"
 	_ "flex-service/internal/seeders"
 
@@ -25,15 +31,40 @@ import (
 )
 
 var (
-	action     = flag.String("action", "", "Action: make:migration, make:seeder, make:model, make:package, migrate, migrate:rollback, migrate:status")
+	action     = flag.String("action", "", "Action: make:migration, make:seeder, make:model, make:package, make:notification, make:policy, migrate, migrate:rollback, migrate:status, migrate:verify, db:seed, db:wipe, db:show, db:table, db:introspect, user:create-admin, cache:version, cache:clear, cache:forget, cache:stats, secure:rotate-key, route:list, search:reindex, down, up")
 	name       = flag.String("name", "", "Migration/Seeder/Model/Package name")
-	table      = flag.String("table", "", "Table name for migration")
+	table      = flag.String("table", "", "Table name for migration or model (make:model defaults to DB_TABLE_PREFIX and DB_SINGULAR_TABLES)")
 	create     = flag.Bool("create", false, "Create table migration")
 	fields     = flag.String("fields", "", "Fields for migration (name:type,email:string)")
 	deps       = flag.String("deps", "", "Dependencies for seeder (UserSeeder,CategorySeeder)")
 	strategy   = flag.String("strategy", "int", "Primary key strategy: int, uuid, dual (default: int)")
 	count      = flag.String("count", "1", "Number of migrations to rollback")
 	skipEntity = flag.Bool("skip-entity", false, "Skip auto-creating entity in migration")
+	versioned  = flag.Bool("versioned", false, "make:model/make:migration: add a version column for optimistic locking")
+	searchable = flag.Bool("searchable", false, "make:model/make:migration: keep the entity in a full-text search index")
+	change     = flag.Bool("change", false, "make:migration: change the values of enum -fields of -table")
+	indexSpec  = flag.String("indexes", "", "make:migration/make:model: composite indexes (org_id+email:unique,created_at+status)")
+	columns    = flag.String("columns", "", "secure:rotate-key: encrypted columns of -table (phone,address)")
+	primaryKey = flag.String("primary-key", "id", "secure:rotate-key: primary key column of -table")
+	force      = flag.Bool("force", false, "Run even if another process holds the command lock; cache:clear and db:wipe in production")
+	public     = flag.Bool("public", false, "make:package: routes need no authentication (IP rate limited)")
+	authRoutes = flag.Bool("auth", false, "make:package: routes need a signed-in user (default)")
+	admin      = flag.Bool("admin", false, "make:package: routes need a signed-in user with the <package>.manage permission")
+	preset     = flag.String("preset", presetRESTCrud, "make:package: rest-crud, readonly-api or event-consumer")
+	format     = flag.String("format", "table", "route:list: table or json")
+	pathFilter = flag.String("path", "", "route:list: only routes whose path contains this")
+	index      = flag.String("index", "", "search:reindex: index to rebuild (default: every registered index)")
+	keys       = flag.String("keys", "", "cache:forget: keys or glob patterns without the cache prefix (user:42,product:*)")
+	message    = flag.String("message", "", "down: message returned with the 503")
+	retryAfter = flag.Int("retry", 0, "down: Retry-After seconds sent with the 503")
+	secret     = flag.String("secret", "", "down: secret that bypasses maintenance mode")
+	allow      = flag.String("allow", "", "down: IPs/CIDRs that bypass maintenance mode (10.0.0.0/8,203.0.113.7)")
+	email      = flag.String("email", "", "user:create-admin: email of the admin")
+	username   = flag.String("username", "", "user:create-admin: username (default: the email)")
+	password   = flag.String("password", "", "user:create-admin: password, at least 8 characters (default: generated)")
+	firstName  = flag.String("first-name", "", "user:create-admin: first name (default: Admin)")
+	lastName   = flag.String("last-name", "", "user:create-admin: last name (default: User)")
+	role       = flag.String("role", "super_admin", "user:create-admin: role to assign, created with every permission if missing")
 	help       = flag.Bool("help", false, "Show help")
 )
 
@@ -45,6 +76,8 @@ func main() {
 		return
 	}
 
+	enterProjectRoot()
+
 	switch *action {
 	case "make:migration":
 		if *name == "" || *table == "" {
@@ -63,9 +96,9 @@ func main() {
 		createSeeder(*name, *table, *deps)
 
 	case "make:model":
-		if *name == "" || *table == "" {
+		if *name == "" {
 			fmt.Println("❌ Model name is required")
-			fmt.Println("Usage: go run cmd/artisan/main.go -action=make:model -name=model_name -table=table_name")
+			fmt.Println("Usage: go run cmd/artisan/main.go -action=make:model -name=model_name [-table=table_name]")
 			os.Exit(1)
 		}
 		createModel(*name, *table, *fields)
@@ -73,10 +106,39 @@ func main() {
 	case "make:package":
 		if *name == "" {
 			fmt.Println("❌ Package name is required")
-			fmt.Println("Usage: go run cmd/artisan/main.go -action=make:package -name=package_name")
+			fmt.Println("Usage: go run cmd/artisan/main.go -action=make:package -name=package_name [-preset=rest-crud|readonly-api|event-consumer] [-public|-auth|-admin]")
+			os.Exit(1)
+		}
+		access, err := routeAccess(*public, *authRoutes, *admin)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		createPackage(*name, access, *preset)
+
+	case "make:storage-driver":
+		if *name == "" {
+			fmt.Println("❌ Driver name is required")
+			fmt.Println("Usage: go run cmd/artisan/main.go -action=make:storage-driver -name=driver_name")
+			os.Exit(1)
+		}
+		createStorageDriver(*name)
+
+	case "make:notification":
+		if *name == "" {
+			fmt.Println("❌ Notification name is required")
+			fmt.Println("Usage: go run cmd/artisan/main.go -action=make:notification -name=NotificationName")
+			os.Exit(1)
+		}
+		createNotification(*name)
+
+	case "make:policy":
+		if *name == "" {
+			fmt.Println("❌ Entity name is required")
+			fmt.Println("Usage: go run cmd/artisan/main.go -action=make:policy -name=EntityName")
 			os.Exit(1)
 		}
-		createPackage(*name)
+		createPolicy(*name)
 
 	case "migrate":
 		runMigrations()
@@ -87,9 +149,58 @@ func main() {
 	case "migrate:status":
 		showMigrationStatus()
 
+	case "migrate:verify":
+		verifyMigrations()
+
 	case "db:seed":
 		runSeeders(*name)
 
+	case "db:wipe":
+		wipeDatabase()
+
+	case "db:show":
+		showDatabase()
+
+	case "db:table":
+		tableName := *table
+		if tableName == "" {
+			tableName = flag.Arg(0)
+		}
+		describeTable(tableName)
+
+	case "db:introspect":
+		introspectDatabase(*table)
+
+	case "user:create-admin":
+		createAdmin(*role)
+
+	case "cache:version":
+		bumpCacheVersion()
+
+	case "cache:clear":
+		clearCache()
+
+	case "cache:forget":
+		forgetCache(*keys)
+
+	case "cache:stats":
+		showCacheStats()
+
+	case "secure:rotate-key":
+		rotateEncryptionKey(*table, *columns, *primaryKey)
+
+	case "route:list":
+		listRoutes(*format, *pathFilter)
+
+	case "search:reindex":
+		reindexSearch(*index)
+
+	case "down":
+		maintenanceDown(*message, *retryAfter, *secret, *allow)
+
+	case "up":
+		maintenanceUp()
+
 	default:
 		fmt.Printf("❌ Unknown action: %s\n", *action)
 		showHelp()
@@ -122,8 +233,48 @@ func createMigration(migrationName, tableName string, isCreate bool, fieldList s
 	dbType := string(cfg.Database.Type)
 	fmt.Printf("🗂️  Detected database: %s\n", dbType)
 
-	// Use the new parseFields function
-	parsedFields := parseFields(fieldList)
+	// Relations are entity fields; only many-to-many ones need a (pivot) table
+	ownerKey := entityKeyField(getStructName(tableName))
+	if isCreate {
+		ownerKey = strategyKeyField(*strategy)
+	}
+	parsedFields, relations, err := splitRelations(tableName, ownerKey, parseFields(fieldList))
+	// and morph fields a pair of indexed columns
+	parsedFields, indexes := withMorphs(tableName, dbType, parsedFields)
+
+	if err == nil {
+		var composite []CompositeIndex
+		composite, err = parseIndexes(tableName, dbType, *indexSpec)
+		indexes = append(indexes, composite...)
+	}
+	if err == nil && isCreate {
+		err = checkIndexColumns(indexes, parsedFields)
+	}
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	// -change migrations take the values enum fields have now from the entity
+	entityPath := filepath.Join("internal/entity", toSnakeCase(getStructName(tableName))+".go")
+	if *change {
+		if isCreate || *versioned || len(parsedFields) == 0 || len(indexes) > 0 || len(relations) > 0 {
+			fmt.Println("❌ -change takes the enum -fields of an existing table with their new values")
+			os.Exit(1)
+		}
+		previous, err := withPreviousEnums(entityPath, parsedFields)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		parsedFields = previous
+	}
+
+	// The version column belongs to the table; the entity adds its own
+	columns := parsedFields
+	if *versioned {
+		columns = append(columns[:len(columns):len(columns)], versionField)
+	}
 
 	// Create migration data with database type
 	data := MigrationData{
@@ -131,7 +282,9 @@ func createMigration(migrationName, tableName string, isCreate bool, fieldList s
 		TableName:    tableName,
 		Timestamp:    timestamp,
 		Description:  migrationName,
-		Fields:       parsedFields,
+		Fields:       withUniqueIndexes(tableName, dbType, withEnums(getStructName(tableName), tableName, dbType, columns)),
+		Indexes:      indexes,
+		Relations:    relations,
 		Version:      fmt.Sprintf("%s_%s", timestamp, migrationName),
 		DatabaseType: dbType,
 		Strategy:     *strategy,
@@ -150,6 +303,8 @@ func createMigration(migrationName, tableName string, isCreate bool, fieldList s
 	if isCreate && tableName != "" {
 		templateContent := getCreateTableTemplate(dbType)
 		tmpl = template.Must(template.New("create_table").Funcs(templateFuncs).Parse(templateContent))
+	} else if *change {
+		tmpl = template.Must(template.New("change_enum").Funcs(templateFuncs).Parse(changeEnumTemplate))
 	} else if tableName != "" {
 		templateContent := getAlterTableTemplate(dbType)
 		tmpl = template.Must(template.New("alter_table").Funcs(templateFuncs).Parse(templateContent))
@@ -170,9 +325,9 @@ func createMigration(migrationName, tableName string, isCreate bool, fieldList s
 	}
 
 	// Show field summary
-	if len(parsedFields) > 0 {
+	if len(columns) > 0 {
 		fmt.Printf("📋 Fields:\n")
-		for _, field := range parsedFields {
+		for _, field := range columns {
 			extras := []string{}
 			if field.HasIndex {
 				extras = append(extras, "indexed")
@@ -180,6 +335,18 @@ func createMigration(migrationName, tableName string, isCreate bool, fieldList s
 			if field.IsForeignKey {
 				extras = append(extras, fmt.Sprintf("FK->%s", field.FKReference))
 			}
+			if field.Nullable {
+				extras = append(extras, "nullable")
+			}
+			if field.HasDefault {
+				extras = append(extras, "default "+getDefaultValue(field))
+			}
+			if field.IsVersion {
+				extras = append(extras, "optimistic lock")
+			}
+			if len(field.EnumPrevious) > 0 {
+				extras = append(extras, "was "+strings.Join(field.EnumPrevious, ","))
+			}
 
 			extraStr := ""
 			if len(extras) > 0 {
@@ -189,17 +356,33 @@ func createMigration(migrationName, tableName string, isCreate bool, fieldList s
 			fmt.Printf("  - %s: %s%s\n", field.Name, field.Type, extraStr)
 		}
 	}
+	printIndexes(indexes)
+	printRelations(relations)
+	if !isCreate && len(relations) > 0 {
+		fmt.Printf("💡 Add the relations to %s:\n", entityPath)
+		for _, relation := range relations {
+			fmt.Printf("  %s []%s `json:\"%s,omitempty\" gorm:\"%s\"`\n", toPascalCase(relation.Name), relation.Entity, relation.Name, getRelationTag(relation))
+		}
+	}
+
+	if *change {
+		if err := updateEntityEnums(entityPath, data.Fields); err != nil {
+			fmt.Printf("⚠️  Failed to update %s, change its enum values by hand: %v\n", entityPath, err)
+		} else {
+			fmt.Printf("✅ Entity updated: %s\n", entityPath)
+		}
+	}
 
 	// Auto-create entity if this is a create table migration and not skipped
 	if isCreate && tableName != "" && !skipEntity {
 		fmt.Printf("\n🚀 Auto-creating entity...\n")
-		if err := autoCreateEntity(tableName, parsedFields); err != nil {
+		if err := autoCreateEntity(tableName, parsedFields, indexes, relations); err != nil {
 			fmt.Printf("⚠️  Warning: Failed to create entity: %v\n", err)
 		}
 	}
 }
 
-func autoCreateEntity(tableName string, fields []Field) error {
+func autoCreateEntity(tableName string, fields []Field, indexes []CompositeIndex, relations []Relation) error {
 	// Generate entity name from table name
 	entityName := getStructName(tableName)
 	// fileName := fmt.Sprintf("%s.go", strings.ToLower(entityName))
@@ -227,9 +410,13 @@ func autoCreateEntity(tableName string, fields []Field) error {
 	data := EntityData{
 		EntityName:   entityName,
 		TableName:    tableName,
-		Fields:       fields,
+		Fields:       withUniqueIndexes(tableName, dbType, withEnums(entityName, tableName, dbType, withJSONTypes(entityName, fields))),
+		Indexes:      indexes,
+		Relations:    relations,
 		DatabaseType: dbType,
 		Strategy:     *strategy,
+		Versioned:    *versioned,
+		Searchable:   *searchable,
 	}
 
 	// Create file
@@ -246,6 +433,9 @@ func autoCreateEntity(tableName string, fields []Field) error {
 	}
 
 	fmt.Printf("✅ Entity created: %s\n", filePath)
+	if err := addEntityModel(filepath.Join(entityDir, "models.go"), entityName); err != nil {
+		fmt.Printf("⚠️  Failed to register %s for migrate:verify: %v\n", entityName, err)
+	}
 	fmt.Printf("📝 Entity: %s\n", entityName)
 	fmt.Printf("🗂️  Table: %s\n", tableName)
 
@@ -277,6 +467,9 @@ func autoCreateEntity(tableName string, fields []Field) error {
 		}
 
 		fmt.Printf("  - Soft deletes enabled\n")
+		if *versioned {
+			fmt.Printf("  - Optimistic locking (version column)\n")
+		}
 		fmt.Printf("  - JSON serialization ready\n")
 		fmt.Printf("  - Validation tags included\n")
 	}
@@ -363,7 +556,8 @@ func createModel(modelName, table, fieldList string) {
 		tableName = table // Use provided table name
 		fmt.Printf("📋 Using specified table: %s\n", tableName)
 	} else {
-		tableName = strings.ToLower(toSnakeCase(entityName)) + "s" // Auto-generate: posts, users, etc.
+		// Auto-generate the same name GORM would: DB_TABLE_PREFIX, DB_SINGULAR_TABLES
+		tableName = namingConfig().TableName(entityName)
 		fmt.Printf("📋 Auto-generated table: %s\n", tableName)
 	}
 
@@ -385,19 +579,38 @@ func createModel(modelName, table, fieldList string) {
 	}
 
 	// Use enhanced parseFields function (same as migration)
-	parsedFields := parseFields(fieldList)
+	// Relations are named after the table, like in its migration
+	parsedFields, relations, err := splitRelations(tableName, strategyKeyField(*strategy), parseFields(fieldList))
 
 	// Detect database type for entity template
 	cfg := config.Load()
 	dbType := string(cfg.Database.Type)
+	parsedFields, indexes := withMorphs(tableName, dbType, parsedFields)
+
+	if err == nil {
+		var composite []CompositeIndex
+		composite, err = parseIndexes(tableName, dbType, *indexSpec)
+		indexes = append(indexes, composite...)
+	}
+	if err == nil {
+		err = checkIndexColumns(indexes, parsedFields)
+	}
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
 
 	// Create entity data with database type
 	data := EntityData{
 		EntityName:   entityName,
 		TableName:    tableName, // Use specified or auto-generated table name
-		Fields:       parsedFields,
+		Fields:       withUniqueIndexes(tableName, dbType, withEnums(entityName, tableName, dbType, withJSONTypes(entityName, parsedFields))),
+		Indexes:      indexes,
+		Relations:    relations,
 		DatabaseType: dbType,
 		Strategy:     *strategy,
+		Versioned:    *versioned,
+		Searchable:   *searchable,
 	}
 
 	// Create file
@@ -430,6 +643,12 @@ func createModel(modelName, table, fieldList string) {
 			if field.IsForeignKey {
 				extras = append(extras, fmt.Sprintf("FK->%s", field.FKReference))
 			}
+			if field.Nullable {
+				extras = append(extras, "nullable")
+			}
+			if field.HasDefault {
+				extras = append(extras, "default "+getDefaultValue(field))
+			}
 
 			extraStr := ""
 			if len(extras) > 0 {
@@ -466,15 +685,150 @@ func createModel(modelName, table, fieldList string) {
 		}
 
 		fmt.Printf("  - Soft deletes enabled\n")
+		if *versioned {
+			fmt.Printf("  - Optimistic locking (version column)\n")
+		}
 		fmt.Printf("  - JSON serialization ready\n")
 		fmt.Printf("  - Validation tags included\n")
 	}
+	printIndexes(indexes)
+	printRelations(relations)
+}
+
+// entityKeyColumn returns the column behind an entity's public "id": uuid for
+// the uuid and dual strategies of make:model, id otherwise
+func entityKeyColumn(source string) string {
+	if regexp.MustCompile("UUID\\s+uuid\\.UUID\\s+`json:\"id\"").MatchString(source) {
+		return "uuid"
+	}
+	return "id"
+}
+
+// entityVersioned reports whether an entity has the version column of
+// make:model -versioned
+func entityVersioned(source string) bool {
+	return regexp.MustCompile("Version\\s+int\\s+`json:\"version\"").MatchString(source)
+}
+
+// entitySearchable reports whether an entity implements search.Searchable,
+// e.g. from make:model -searchable
+func entitySearchable(source string) bool {
+	return regexp.MustCompile(`\)\s+SearchIndex\(\)\s+string`).MatchString(source)
+}
+
+// entityRelations returns the many-to-many associations of an entity, e.g.
+// from make:model's m2m fields, with the key column of each related entity
+func entityRelations(source string) []PackageRelation {
+	var relations []PackageRelation
+	for _, match := range regexp.MustCompile("(?m)^\\s+(\\w+)\\s+\\[\\](\\w+)\\s+`[^`]*gorm:\"many2many:").FindAllStringSubmatch(source, -1) {
+		relation := PackageRelation{Field: match[1], Entity: match[2], KeyColumn: "id"}
+		if related, err := os.ReadFile(filepath.Join("internal/entity", toSnakeCase(match[2])+".go")); err == nil {
+			relation.KeyColumn = entityKeyColumn(string(related))
+		}
+		relations = append(relations, relation)
+	}
+	return relations
+}
+
+// entityUniqueColumns returns the columns of an entity with a unique index of
+// their own, e.g. from make:model's "unique" fields, whose JSON and column
+// names match. Composite unique indexes from -indexes are left out.
+func entityUniqueColumns(source string) []string {
+	var names []string
+	indexColumns := make(map[string][]string)
+	for _, match := range regexp.MustCompile("`json:\"(\\w+)\"([^`]*)").FindAllStringSubmatch(source, -1) {
+		for _, index := range regexp.MustCompile(`uniqueIndex:(\w+)`).FindAllStringSubmatch(match[2], -1) {
+			if _, seen := indexColumns[index[1]]; !seen {
+				names = append(names, index[1])
+			}
+			indexColumns[index[1]] = append(indexColumns[index[1]], match[1])
+		}
+	}
+
+	var columns []string
+	for _, name := range names {
+		if len(indexColumns[name]) == 1 {
+			columns = append(columns, indexColumns[name][0])
+		}
+	}
+	return columns
+}
+
+// entityOwned reports whether an entity belongs to a user through an int
+// UserID field, which make:policy turns into an ownership rule
+func entityOwned(source string) bool {
+	return regexp.MustCompile(`(?m)^\s+UserID\s+int\s`).MatchString(source)
+}
+
+// Route groups make:package can generate a manifest for
+const (
+	accessPublic = "public"
+	accessAuth   = "auth"
+	accessAdmin  = "admin"
+)
+
+// routeAccess picks the route group from the -public, -auth and -admin flags.
+// Without a flag routes require a signed-in user, so a new package never ships
+// unauthenticated by accident.
+func routeAccess(public, auth, admin bool) (string, error) {
+	access := ""
+	for _, flag := range []struct {
+		access string
+		set    bool
+	}{{accessPublic, public}, {accessAuth, auth}, {accessAdmin, admin}} {
+		if !flag.set {
+			continue
+		}
+		if access != "" {
+			return "", fmt.Errorf("use only one of -public, -auth and -admin")
+		}
+		access = flag.access
+	}
+	if access == "" {
+		access = accessAuth
+	}
+	return access, nil
+}
+
+// routeMiddleware returns the manifest middleware of a route group
+func routeMiddleware(access string) []string {
+	switch access {
+	case accessPublic:
+		return []string{"rate.ip:60,1m"}
+	case accessAdmin:
+		return []string{"auth", "rate.user:60,1m"}
+	}
+	return []string{"auth", "rate.user:120,1m"}
 }
-func createPackage(packageName string) {
+
+func createPackage(packageName, access, presetName string) {
+	preset, err := lookupPreset(presetName)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	if presetName == "" {
+		presetName = presetRESTCrud
+	}
+
 	// Convert to lowercase for package name
 	pkgName := toSnakeCase(packageName)
 	entityName := toPascalCase(packageName)
 
+	// Generate list methods only when the entity already exists, and bulk
+	// methods only when it has the requests make:model generates
+	entityFile := filepath.Join("internal", "entity", toSnakeCase(entityName)+".go")
+	entitySource, entityErr := os.ReadFile(entityFile)
+	hasRequests := entityErr == nil &&
+		strings.Contains(string(entitySource), "type Create"+entityName+"Request struct") &&
+		strings.Contains(string(entitySource), "type Update"+entityName+"Request struct")
+
+	if preset.needsEntity && !strings.Contains(string(entitySource), "var "+entityName+"QueryOptions") {
+		fmt.Printf("❌ The %s preset needs the %s entity from make:model (%s with %sQueryOptions)\n", presetName, entityName, entityFile, entityName)
+		fmt.Printf("Run: go run cmd/artisan/main.go -action=make:model -name=%s -fields=...\n", entityName)
+		os.Exit(1)
+	}
+
 	// Create package directory
 	packageDir := filepath.Join("internal", pkgName)
 	if err := os.MkdirAll(packageDir, 0755); err != nil {
@@ -483,8 +837,7 @@ func createPackage(packageName string) {
 	}
 
 	// Check if package already exists
-	files := []string{"handler.go", "port.go", "repository.go", "usecase.go"}
-	for _, file := range files {
+	for _, file := range []string{"handler.go", "module.go", "port.go", "repository.go", "routes.go", "usecase.go", "consumer.go"} {
 		if _, err := os.Stat(filepath.Join(packageDir, file)); err == nil {
 			fmt.Printf("❌ Package '%s' already exists (found %s)\n", pkgName, file)
 			os.Exit(1)
@@ -492,57 +845,317 @@ func createPackage(packageName string) {
 	}
 
 	packageData := PackageData{
-		PackageName: pkgName,
-		EntityName:  entityName,
+		PackageName:   pkgName,
+		EntityName:    entityName,
+		HasEntity:     entityErr == nil,
+		HasRequests:   hasRequests,
+		Versioned:     entityVersioned(string(entitySource)),
+		Searchable:    entitySearchable(string(entitySource)),
+		KeyColumn:     entityKeyColumn(string(entitySource)),
+		UniqueColumns: entityUniqueColumns(string(entitySource)),
+		Relations:     entityRelations(string(entitySource)),
+		Access:        access,
+		Prefix:        "/" + strings.ReplaceAll(pluralize(pkgName), "_", "-"),
+		Middleware:    routeMiddleware(access),
+	}
+
+	for _, file := range preset.files {
+		if err := createFileFromTemplate(filepath.Join(packageDir, file.name), file.template, packageData); err != nil {
+			fmt.Printf("❌ Failed to create %s: %v\n", file.name, err)
+			os.Exit(1)
+		}
+	}
+
+	// Compile the module in; it registers its bindings and routes itself
+	modulesFile := filepath.Join("internal", "modules", "modules.go")
+	if err := addModuleImport(modulesFile, pkgName); err != nil {
+		fmt.Printf("⚠️  Failed to update %s: %v\n", modulesFile, err)
+		fmt.Printf("   Add _ \"%s/internal/%s\" to its imports by hand\n", projectModule, pkgName)
+	}
+
+	// Add default English messages for the handler's translation keys
+	messages := map[string]string{}
+	if preset.messages != nil {
+		messages = preset.messages(entityName)
+	}
+	if len(messages) > 0 {
+		if err := addTranslations(filepath.Join("locales", "en.json"), pkgName, messages); err != nil {
+			fmt.Printf("⚠️  Failed to update locales/en.json: %v\n", err)
+		}
+	}
+
+	fmt.Printf("✅ Package created: internal/%s/ (%s preset)\n", pkgName, presetName)
+	fmt.Printf("📁 Files created:\n")
+	for _, file := range preset.files {
+		if file.name == "routes.go" {
+			fmt.Printf("  - internal/%s/routes.go (%s routes under %s)\n", pkgName, access, packageData.Prefix)
+			continue
+		}
+		fmt.Printf("  - internal/%s/%s\n", pkgName, file.name)
+	}
+	fmt.Printf("  - %s (imports %s)\n", modulesFile, pkgName)
+	if len(messages) > 0 {
+		fmt.Printf("  - locales/en.json (%s.* keys)\n", pkgName)
+	}
+	fmt.Printf("🎯 Entity: %s\n", entityName)
+
+	if !preset.routes {
+		fmt.Printf("🔌 The consumer subscribes to container.Events on startup (internal/%s/module.go)\n", pkgName)
+		fmt.Printf("   Queue workers process its jobs with worker.RegisterHandler(%s.JobType%s, consumer.JobHandler())\n", pkgName, entityName)
+		return
+	}
+	fmt.Printf("🔌 Routes mount under /api/v1%s on startup (internal/%s/module.go)\n", packageData.Prefix, pkgName)
+	if presetName == presetReadonlyAPI {
+		fmt.Printf("🧹 Add cache.invalidate:%s to the routes that write %s, so cached pages expire\n", pkgName, pkgName)
+	}
+	if access == accessAdmin || (presetName == presetRESTCrud && packageData.HasEntity) {
+		fmt.Printf("🔐 Grant %s.manage to the roles that administer %s\n", pkgName, pkgName)
+	}
+}
+
+// addModuleImport adds a blank import of internal/<pkgName> to the modules
+// file, creating it when missing, so the package's init registers the module
+func addModuleImport(path, pkgName string) error {
+	importLine := fmt.Sprintf("\t_ \"%s/internal/%s\"", projectModule, pkgName)
+
+	source, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		source = []byte(modulesFileTemplate)
+	} else if err != nil {
+		return err
+	}
+
+	content := string(source)
+	if strings.Contains(content, importLine[1:]) {
+		return nil
+	}
+	start := strings.Index(content, "import (")
+	if start < 0 {
+		return fmt.Errorf("no import block")
+	}
+	end := strings.Index(content[start:], "\n)")
+	if end < 0 {
+		return fmt.Errorf("unterminated import block")
+	}
+	end += start
+
+	lines := strings.Split(content[start+len("import ("):end], "\n")
+	var comments, imports []string
+	for _, line := range lines {
+		switch trimmed := strings.TrimSpace(line); {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "//"):
+			comments = append(comments, "\t"+trimmed)
+		default:
+			imports = append(imports, "\t"+trimmed)
+		}
+	}
+	imports = append(imports, importLine)
+	sort.Strings(imports)
+
+	block := "import (\n" + strings.Join(append(comments, imports...), "\n")
+	return os.WriteFile(path, []byte(content[:start]+block+content[end:]), 0644)
+}
+
+// addEntityModel adds the entity to the models the entity package registers,
+// creating the file when missing, so migrate:verify checks its table
+func addEntityModel(path, entityName string) error {
+	source, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		source = []byte(entityModelsFileTemplate)
+	} else if err != nil {
+		return err
+	}
+
+	content := string(source)
+	line := "\t\t&" + entityName + "{},\n"
+	if strings.Contains(content, line) {
+		return nil
+	}
+	end := strings.LastIndex(content, "\t)\n}")
+	if end < 0 {
+		return fmt.Errorf("no migration.RegisterModels call")
+	}
+	return os.WriteFile(path, []byte(content[:end]+line+content[end:]), 0644)
+}
+
+const entityModelsFileTemplate = `package entity
+
+import "flex-service/pkg/migration"
+
+// The models migrate:verify compares with the live schema. make:migration
+// -create and db:introspect add the entities they generate.
+func init() {
+	migration.RegisterModels(
+	)
+}
+`
+
+const modulesFileTemplate = `// Package modules compiles in the modules that register themselves with
+// container.Register. make:package adds a blank import for each package it
+// generates; delete the import to unplug a module.
+package modules
+
+import (
+// make:package adds module imports here
+)
+`
+
+// addTranslations merges messages under a top-level section of a JSON locale file,
+// keeping any translation that already exists
+func addTranslations(path, section string, messages map[string]string) error {
+	bundle := map[string]interface{}{}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &bundle); err != nil {
+			return fmt.Errorf("invalid JSON in %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	group, _ := bundle[section].(map[string]interface{})
+	if group == nil {
+		group = map[string]interface{}{}
+	}
+	for key, message := range messages {
+		if _, exists := group[key]; !exists {
+			group[key] = message
+		}
+	}
+	bundle[section] = group
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+func createStorageDriver(driverName string) {
+	data := StorageDriverData{
+		DriverName: toSnakeCase(driverName),
+		StructName: toPascalCase(driverName),
+		EnvPrefix:  "STORAGE_" + strings.ToUpper(toSnakeCase(driverName)),
+	}
+
+	filePath := filepath.Join("pkg", "storage", data.DriverName+".go")
+	if _, err := os.Stat(filePath); err == nil {
+		fmt.Printf("❌ Storage driver already exists: %s\n", filePath)
+		os.Exit(1)
 	}
 
-	// Create handler.go
-	if err := createFileFromTemplate(
-		filepath.Join(packageDir, "handler.go"),
-		handlerTemplate,
-		packageData,
-	); err != nil {
-		fmt.Printf("❌ Failed to create handler.go: %v\n", err)
+	if err := createFileFromTemplate(filePath, storageDriverTemplate, data); err != nil {
+		fmt.Printf("❌ Failed to create storage driver: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Create port.go
-	if err := createFileFromTemplate(
-		filepath.Join(packageDir, "port.go"),
-		portTemplate,
-		packageData,
-	); err != nil {
-		fmt.Printf("❌ Failed to create port.go: %v\n", err)
+	fmt.Printf("✅ Storage driver created: %s\n", filePath)
+	fmt.Printf("🔌 Enable with: STORAGE_DRIVER=%s\n", data.DriverName)
+	fmt.Printf("⚙️  Configure with: %s_* environment variables\n", data.EnvPrefix)
+}
+
+func createNotification(notificationName string) {
+	data := NotificationData{
+		StructName: toPascalCase(notificationName),
+		FileName:   toSnakeCase(notificationName),
+	}
+
+	filePath := filepath.Join("internal", "notifications", data.FileName+".go")
+	if _, err := os.Stat(filePath); err == nil {
+		fmt.Printf("❌ Notification already exists: %s\n", filePath)
 		os.Exit(1)
 	}
 
-	// Create repository.go
-	if err := createFileFromTemplate(
-		filepath.Join(packageDir, "repository.go"),
-		repositoryTemplate,
-		packageData,
-	); err != nil {
-		fmt.Printf("❌ Failed to create repository.go: %v\n", err)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		fmt.Printf("❌ Failed to create notifications directory: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Create usecase.go
-	if err := createFileFromTemplate(
-		filepath.Join(packageDir, "usecase.go"),
-		usecaseTemplate,
-		packageData,
-	); err != nil {
-		fmt.Printf("❌ Failed to create usecase.go: %v\n", err)
+	if err := createFileFromTemplate(filePath, notificationTemplate, data); err != nil {
+		fmt.Printf("❌ Failed to create notification: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("✅ Package created: internal/%s/\n", pkgName)
-	fmt.Printf("📁 Files created:\n")
-	fmt.Printf("  - internal/%s/handler.go\n", pkgName)
-	fmt.Printf("  - internal/%s/port.go\n", pkgName)
-	fmt.Printf("  - internal/%s/repository.go\n", pkgName)
-	fmt.Printf("  - internal/%s/usecase.go\n", pkgName)
-	fmt.Printf("🎯 Entity: %s\n", entityName)
+	fmt.Printf("✅ Notification created: %s\n", filePath)
+	fmt.Printf("📨 Send with: container.Notifier.SendTo(ctx, user, notifications.%s{})\n", data.StructName)
+}
+
+func createPolicy(entityName string) {
+	entityName = toPascalCase(entityName)
+	entityFile := filepath.Join("internal", "entity", toSnakeCase(entityName)+".go")
+	entitySource, err := os.ReadFile(entityFile)
+	if err != nil {
+		fmt.Printf("❌ A policy needs the %s entity (%s)\n", entityName, entityFile)
+		fmt.Printf("Run: go run cmd/artisan/main.go -action=make:model -name=%s -fields=...\n", entityName)
+		os.Exit(1)
+	}
+
+	data := PolicyData{
+		EntityName: entityName,
+		VarName:    toCamelCase(entityName),
+		Permission: toSnakeCase(entityName),
+		Plural:     strings.ReplaceAll(pluralize(toSnakeCase(entityName)), "_", " "),
+		Owned:      entityOwned(string(entitySource)),
+	}
+
+	filePath := filepath.Join("internal", "policies", data.Permission+"_policy.go")
+	if _, err := os.Stat(filePath); err == nil {
+		fmt.Printf("❌ Policy already exists: %s\n", filePath)
+		os.Exit(1)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		fmt.Printf("❌ Failed to create policies directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := createFileFromTemplate(filePath, policyTemplate, data); err != nil {
+		fmt.Printf("❌ Failed to create policy: %v\n", err)
+		os.Exit(1)
+	}
+
+	registerFile := filepath.Join("internal", "policies", "policies.go")
+	if err := addPolicy(registerFile, entityName); err != nil {
+		fmt.Printf("⚠️  Failed to update %s: %v\n", registerFile, err)
+		fmt.Printf("   Add auth.RegisterPolicy[entity.%s](p, New%sPolicy(permissions)) to Register by hand\n", entityName, entityName)
+	}
+
+	fmt.Printf("✅ Policy created: %s\n", filePath)
+	if data.Owned {
+		fmt.Printf("👤 Users may update and delete their own %s (UserID)\n", data.Plural)
+	}
+	fmt.Printf("🛡️  Check with: authorize.Check(ctx, \"update\", %s)\n", data.VarName)
+}
+
+// addPolicy registers an entity's policy in the Register function of
+// internal/policies, importing the entity package when needed
+func addPolicy(path, entityName string) error {
+	source, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		source = []byte(policiesFileTemplate)
+	} else if err != nil {
+		return err
+	}
+
+	content := string(source)
+	line := "\tauth.RegisterPolicy[entity." + entityName + "](p, New" + entityName + "Policy(permissions))\n"
+	if strings.Contains(content, line) {
+		return nil
+	}
+	marker := strings.Index(content, "\t// make:policy adds policies here\n")
+	if marker < 0 {
+		return fmt.Errorf("no make:policy marker in Register")
+	}
+	content = content[:marker] + line + content[marker:]
+
+	const entityImport = "\"" + projectModule + "/internal/entity\""
+	if !strings.Contains(content, entityImport) {
+		content = strings.Replace(content, "import (\n", "import (\n\t"+entityImport+"\n", 1)
+	}
+	return os.WriteFile(path, []byte(content), 0644)
 }
 
 func createFileFromTemplate(filePath, templateContent string, data interface{}) error {
@@ -565,10 +1178,14 @@ func runMigrations() {
 	// Initialize logger
 	if err := logger.Init(cfg.Log.Level, cfg.Log.Format); err != nil {
 		fmt.Printf("❌ Failed to initialize logger: %v\n", err)
-		os.Exit(1)
+		exit(1)
 	}
 	defer logger.Sync()
 
+	// Prevent concurrent runs across hosts
+	acquireCommandLock(cfg, "migrate")
+	defer releaseCommandLocks()
+
 	// Initialize database using factory
 	factory := pkgDatabase.NewDatabaseFactory()
 	dbConfig := cfg.GetDatabaseConfig()
@@ -576,7 +1193,7 @@ func runMigrations() {
 	db, err := factory.CreateDatabase(dbConfig)
 	if err != nil {
 		fmt.Printf("❌ Failed to connect to %s database: %v\n", cfg.Database.Type, err)
-		os.Exit(1)
+		exit(1)
 	}
 
 	fmt.Printf("📊 Using %s database\n", cfg.Database.Type)
@@ -584,13 +1201,13 @@ func runMigrations() {
 	// Generate and load dynamic migrations registry
 	if err := generateDynamicMigrationsRegistry(); err != nil {
 		fmt.Printf("❌ Failed to generate migrations registry: %v\n", err)
-		os.Exit(1)
+		exit(1)
 	}
 
 	// Run migrations
 	if err := db.RunMigrations(); err != nil {
 		fmt.Printf("❌ Migration failed: %v\n", err)
-		os.Exit(1)
+		exit(1)
 	}
 
 	fmt.Println("✅ Migrations completed successfully")
@@ -605,10 +1222,14 @@ func rollbackMigrations(count string) {
 	// Initialize logger
 	if err := logger.Init(cfg.Log.Level, cfg.Log.Format); err != nil {
 		fmt.Printf("❌ Failed to initialize logger: %v\n", err)
-		os.Exit(1)
+		exit(1)
 	}
 	defer logger.Sync()
 
+	// Prevent concurrent runs across hosts
+	acquireCommandLock(cfg, "migrate")
+	defer releaseCommandLocks()
+
 	// Initialize database using factory
 	factory := pkgDatabase.NewDatabaseFactory()
 	dbConfig := cfg.GetDatabaseConfig()
@@ -616,7 +1237,7 @@ func rollbackMigrations(count string) {
 	db, err := factory.CreateDatabase(dbConfig)
 	if err != nil {
 		fmt.Printf("❌ Failed to connect to %s database: %v\n", cfg.Database.Type, err)
-		os.Exit(1)
+		exit(1)
 	}
 
 	fmt.Printf("📊 Using %s database\n", cfg.Database.Type)
@@ -624,7 +1245,7 @@ func rollbackMigrations(count string) {
 	// Rollback migrations
 	if err := db.RollbackMigrations(count); err != nil {
 		fmt.Printf("❌ Rollback failed: %v\n", err)
-		os.Exit(1)
+		exit(1)
 	}
 
 	fmt.Println("✅ Rollback completed successfully")
@@ -662,29 +1283,80 @@ func showMigrationStatus() {
 	}
 }
 
-func runSeeders(seederName string) {
-	// Load configuration
+// verifyMigrations checks applied migrations against their checksums and the
+// registered models against the live schema, exiting 1 on any difference so
+// deploys can run it first
+func verifyMigrations() {
 	cfg := config.Load()
+	db := openDatabase(cfg)
+	defer db.Close()
 
-	// Initialize logger
-	if err := logger.Init(cfg.Log.Level, cfg.Log.Format); err != nil {
-		fmt.Printf("❌ Failed to initialize logger: %v\n", err)
-		os.Exit(1)
-	}
-	defer logger.Sync()
-
-	// Initialize database using factory
-	factory := pkgDatabase.NewDatabaseFactory()
-	dbConfig := cfg.GetDatabaseConfig()
-
-	db, err := factory.CreateDatabase(dbConfig)
+	fmt.Printf("🔎 Verifying migrations on %s...\n", databaseTarget(cfg))
+	manager := migration.NewManagerWithGlobalMigrations(db.GetDB(), nil)
+	verification, err := manager.Verify(migration.GetRegisteredModels())
 	if err != nil {
-		fmt.Printf("❌ Failed to connect to %s database: %v\n", cfg.Database.Type, err)
+		fmt.Printf("❌ %v\n", err)
 		os.Exit(1)
 	}
 
-	if seederName == "list" {
-		fmt.Println("📋 Listing seeders...")
+	printVersions := func(header string, versions []string) {
+		if len(versions) == 0 {
+			return
+		}
+		fmt.Printf("%s: %d\n", header, len(versions))
+		for _, version := range versions {
+			fmt.Printf("   - %s\n", version)
+		}
+	}
+	printVersions("❌ Edited after they were applied", verification.Modified)
+	printVersions("❌ Applied but missing from the code", verification.Missing)
+	printVersions("⚠️  Applied without a checksum", verification.Unverified)
+	printVersions("⏳ Pending", verification.Pending)
+
+	if len(verification.Drift) > 0 {
+		fmt.Printf("❌ Schema drift: %d\n", len(verification.Drift))
+		for _, drift := range verification.Drift {
+			target := drift.Table
+			if drift.Column != "" {
+				target += "." + drift.Column
+			}
+			fmt.Printf("   - %s: %s\n", target, drift.Problem)
+		}
+		if len(verification.Pending) > 0 {
+			fmt.Println("   Pending migrations may account for it; verify again after -action=migrate")
+		}
+	}
+
+	if !verification.OK() {
+		fmt.Println("❌ Migrations don't match the database")
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Migrations and %d models match the database\n", len(migration.GetRegisteredModels()))
+}
+
+func runSeeders(seederName string) {
+	// Load configuration
+	cfg := config.Load()
+
+	// Initialize logger
+	if err := logger.Init(cfg.Log.Level, cfg.Log.Format); err != nil {
+		fmt.Printf("❌ Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	// Initialize database using factory
+	factory := pkgDatabase.NewDatabaseFactory()
+	dbConfig := cfg.GetDatabaseConfig()
+
+	db, err := factory.CreateDatabase(dbConfig)
+	if err != nil {
+		fmt.Printf("❌ Failed to connect to %s database: %v\n", cfg.Database.Type, err)
+		os.Exit(1)
+	}
+
+	if seederName == "list" {
+		fmt.Println("📋 Listing seeders...")
 		fmt.Printf("📊 Using %s database\n", cfg.Database.Type)
 
 		if err := db.ListSeeders(); err != nil {
@@ -694,13 +1366,17 @@ func runSeeders(seederName string) {
 		return
 	}
 
+	// Prevent concurrent runs across hosts
+	acquireCommandLock(cfg, "db:seed")
+	defer releaseCommandLocks()
+
 	fmt.Println("🌱 Running seeders...")
 	fmt.Printf("📊 Using %s database\n", cfg.Database.Type)
 
 	// Run seeders
 	if err := db.SeedData(seederName); err != nil {
 		fmt.Printf("❌ Seeding failed: %v\n", err)
-		os.Exit(1)
+		exit(1)
 	}
 
 	fmt.Println("✅ Seeding completed successfully")
@@ -716,20 +1392,66 @@ func showHelp() {
 	fmt.Println("  make:migration     Create a new migration file")
 	fmt.Println("  make:seeder        Create a new seeder file")
 	fmt.Println("  make:model         Create a new entity model file")
-	fmt.Println("  make:package       Create a new package with handler, usecase, repository, port")
+	fmt.Println("  make:package       Create a self-registering package with handler, usecase, repository, port, routes, module")
+	fmt.Println("  make:storage-driver Create a storage driver stub in pkg/storage")
+	fmt.Println("  make:notification  Create a notification in internal/notifications")
+	fmt.Println("  make:policy        Create the authorization policy of an entity in internal/policies")
 	fmt.Println("  migrate            Run pending migrations")
 	fmt.Println("  migrate:rollback   Rollback migrations")
 	fmt.Println("  migrate:status     Show migration status")
+	fmt.Println("  migrate:verify     Check applied migrations against their checksums and models against the schema")
 	fmt.Println("  db:seed            Run database seeders")
+	fmt.Println("  db:wipe            Drop every table, including migrations (-force in production)")
+	fmt.Println("  db:show            Show the connection, version, size and tables with row counts")
+	fmt.Println("  db:table           Show the columns, indexes and foreign keys of -table")
+	fmt.Println("  db:introspect      Generate entities and baseline migrations from the tables of an existing database (-table=a,b)")
+	fmt.Println("  user:create-admin  Create an active user with the super_admin role and print the credentials")
+	fmt.Println("  cache:version      Bump the cache version, dropping keys in CACHE_VERSIONED_NAMESPACES")
+	fmt.Println("  cache:clear        Delete every key under the cache prefix (sessions and locks are kept)")
+	fmt.Println("  cache:forget       Delete cache keys or glob patterns")
+	fmt.Println("  cache:stats        Show Redis hit/miss, memory and keyspace stats and keys per namespace")
+	fmt.Println("  secure:rotate-key  Re-encrypt encrypted columns with the current ENCRYPTION_KEY")
+	fmt.Println("  route:list         List every route with its handler, middleware, authentication and permission")
+	fmt.Println("  search:reindex     Rebuild search indexes from the database (-index=products for one)")
+	fmt.Println("  down               Put the application into maintenance mode (503 for every request)")
+	fmt.Println("  up                 Bring the application out of maintenance mode")
 	fmt.Println("")
 	fmt.Println("Options:")
 	fmt.Println("  -name string       Migration/Seeder/Model/Package name")
-	fmt.Println("  -table string      Table name")
+	fmt.Println("  -table string      Table name (db:table also takes it as an argument; db:introspect takes a comma list)")
 	fmt.Println("  -create            Create table migration")
-	fmt.Println("  -fields string     Fields (name:string,email:string)")
+	fmt.Println("  -fields string     Fields (name:string,email:string|unique|visible:admin,status:enum(pending,active),settings:json|shape:theme=string;notify=bool)")
+	fmt.Println("                     Options: |index |unique |nullable |default:value |comment:text |fk:table |visible:roles")
+	fmt.Println("                     Relations: tags|m2m:tags (pivot table), comments|hasmany:comments")
+	fmt.Println("                     Polymorphic: commentable:int|morph on the child, comments|hasmany:comments|morph:commentable on owners")
 	fmt.Println("  -strategy string   Primary key strategy: int, uuid, dual (default: int)")
 	fmt.Println("  -count int         Number of migrations to rollback (default: 1)")
 	fmt.Println("  -skip-entity       Skip auto-creating entity in migration (used internally)")
+	fmt.Println("  -versioned         make:model/make:migration: add a version column; make:package updates then reject stale writes")
+	fmt.Println("  -searchable        make:model/make:migration: index the entity for ?search on make:package list endpoints")
+	fmt.Println("  -change            make:migration: give the enum -fields of -table new values and update its entity")
+	fmt.Println("  -indexes string    make:migration/make:model: composite indexes (org_id+email:unique,created_at+status)")
+	fmt.Println("  -columns string    secure:rotate-key: encrypted columns of -table (phone,address)")
+	fmt.Println("  -primary-key string secure:rotate-key: primary key column of -table (default: id)")
+	fmt.Println("  -format string     route:list: table (default) or json")
+	fmt.Println("  -path string       route:list: only routes whose path contains this")
+	fmt.Println("  -index string      search:reindex: index to rebuild (default: every registered index)")
+	fmt.Println("  -message string    down: message returned with the 503")
+	fmt.Println("  -retry int         down: Retry-After seconds sent with the 503")
+	fmt.Println("  -secret string     down: secret that bypasses maintenance mode (header, cookie or ?maintenance_bypass=)")
+	fmt.Println("  -allow string      down: IPs/CIDRs that keep access (10.0.0.0/8,203.0.113.7)")
+	fmt.Println("  -force             Ignore the lock held by another migrate/db:seed run; allow cache:clear and db:wipe in production")
+	fmt.Println("  -keys string       cache:forget: keys or glob patterns without the cache prefix (user:42,product:*)")
+	fmt.Println("  -public            make:package routes without authentication (IP rate limited)")
+	fmt.Println("  -auth              make:package routes for signed-in users (default)")
+	fmt.Println("  -admin             make:package routes requiring the <package>.manage permission")
+	fmt.Println("  -preset string     make:package: rest-crud (default), readonly-api or event-consumer")
+	fmt.Println("  -email string      user:create-admin: email of the admin (asked for on a terminal)")
+	fmt.Println("  -username string   user:create-admin: username (default: the email)")
+	fmt.Println("  -password string   user:create-admin: password (default: generated and printed)")
+	fmt.Println("  -first-name string user:create-admin: first name (default: Admin)")
+	fmt.Println("  -last-name string  user:create-admin: last name (default: User)")
+	fmt.Println("  -role string       user:create-admin: role to assign (default: super_admin, created with every permission)")
 	fmt.Println("")
 	fmt.Println("Examples:")
 	fmt.Println("  # Create table migration")
@@ -738,20 +1460,80 @@ func showHelp() {
 	fmt.Println("  # Create entity model with default strategy (int)")
 	fmt.Println("  go run cmd/artisan/main.go -action=make:model -name=User -fields=\"name:string,email:string,age:int\"")
 	fmt.Println("")
+	fmt.Println("  # Create table migration with composite indexes")
+	fmt.Println("  go run cmd/artisan/main.go -action=make:migration -name=create_members_table -create -table=members -fields=\"org_id:int,email:string,status:string\" -indexes=\"org_id+email:unique,created_at+status\"")
+	fmt.Println("")
+	fmt.Println("  # Create entity model with a typed JSON column (ProductAttributes struct)")
+	fmt.Println("  go run cmd/artisan/main.go -action=make:model -name=Product -fields=\"name:string,attributes:jsonb|shape:color=string;weight=float;fragile=bool\"")
+	fmt.Println("")
+	fmt.Println("  # Create entity model with a nullable column, a default and a column comment")
+	fmt.Println("  go run cmd/artisan/main.go -action=make:model -name=Product -fields=\"name:string,note:text|nullable,stock:int|default:0,sku:string|unique|comment:Stock keeping unit\"")
+	fmt.Println("")
+	fmt.Println("  # Create entity model with an enum column (OrderStatus constants), then add a value")
+	fmt.Println("  go run cmd/artisan/main.go -action=make:model -name=Order -fields=\"title:string,status:enum(pending,active,closed)\"")
+	fmt.Println("  go run cmd/artisan/main.go -action=make:migration -name=add_archived_to_order_status -table=orders -change -fields=\"status:enum(pending,active,closed,archived)\"")
+	fmt.Println("")
 	fmt.Println("  # Create entity model with UUID strategy")
 	fmt.Println("  go run cmd/artisan/main.go -action=make:model -name=Product -strategy=uuid -fields=\"name:string,price:decimal\"")
 	fmt.Println("")
 	fmt.Println("  # Create entity model with dual strategy (int + UUID)")
 	fmt.Println("  go run cmd/artisan/main.go -action=make:model -name=Order -strategy=dual -fields=\"total:decimal,status:string\"")
 	fmt.Println("")
-	fmt.Println("  # Create package (handler, usecase, repository, port)")
+	fmt.Println("  # Create entity model with optimistic locking (updates must send the version they read)")
+	fmt.Println("  go run cmd/artisan/main.go -action=make:model -name=Invoice -versioned -fields=\"number:string,total:decimal\"")
+	fmt.Println("")
+	fmt.Println("  # Create entity model kept in the search index (GET /products?search=...), then fill the index")
+	fmt.Println("  go run cmd/artisan/main.go -action=make:model -name=Product -searchable -fields=\"name:string,description:text\"")
+	fmt.Println("  go run cmd/artisan/main.go -action=search:reindex -index=products")
+	fmt.Println("")
+	fmt.Println("  # Create package (handler, usecase, repository, port, routes, module)")
 	fmt.Println("  go run cmd/artisan/main.go -action=make:package -name=Product")
 	fmt.Println("")
+	fmt.Println("  # Create package whose routes need the product.manage permission")
+	fmt.Println("  go run cmd/artisan/main.go -action=make:package -name=Product -admin")
+	fmt.Println("")
+	fmt.Println("  # Create cached list/get endpoints for an existing make:model entity")
+	fmt.Println("  go run cmd/artisan/main.go -action=make:package -name=Report -preset=readonly-api")
+	fmt.Println("")
+	fmt.Println("  # Create an event and queue consumer without HTTP routes")
+	fmt.Println("  go run cmd/artisan/main.go -action=make:package -name=Invoicing -preset=event-consumer")
+	fmt.Println("")
+	fmt.Println("  # Create storage driver stub (pkg/storage/gcs.go, STORAGE_DRIVER=gcs)")
+	fmt.Println("  go run cmd/artisan/main.go -action=make:storage-driver -name=gcs")
+	fmt.Println("")
+	fmt.Println("  # Create notification (internal/notifications/order_shipped.go)")
+	fmt.Println("  go run cmd/artisan/main.go -action=make:notification -name=OrderShipped")
+	fmt.Println("")
+	fmt.Println("  # Create policy for the Post entity (internal/policies/post_policy.go)")
+	fmt.Println("  go run cmd/artisan/main.go -action=make:policy -name=Post")
+	fmt.Println("")
 	fmt.Println("  # Add column migration")
 	fmt.Println("  go run cmd/artisan/main.go -action=make:migration -name=add_phone_to_users -table=users -fields=\"phone:string\"")
 	fmt.Println("")
 	fmt.Println("  # Run migrations")
 	fmt.Println("  go run cmd/artisan/main.go -action=migrate")
+	fmt.Println("  go run cmd/artisan/main.go -action=migrate:verify && go run cmd/artisan/main.go -action=migrate")
+	fmt.Println("")
+	fmt.Println("  # Inspect the users table, then start over from an empty database")
+	fmt.Println("  go run cmd/artisan/main.go -action=db:table -table=users")
+	fmt.Println("  go run cmd/artisan/main.go -action=db:introspect -table=orders,order_items")
+	fmt.Println("  go run cmd/artisan/main.go -action=db:wipe && go run cmd/artisan/main.go -action=migrate")
+	fmt.Println("")
+	fmt.Println("  # Bootstrap the first admin of a fresh environment")
+	fmt.Println("  go run cmd/artisan/main.go -action=user:create-admin -email=admin@example.com")
+	fmt.Println("")
+	fmt.Println("  # Re-encrypt tb_user.phone after bumping ENCRYPTION_KEY_VERSION")
+	fmt.Println("  go run cmd/artisan/main.go -action=secure:rotate-key -table=tb_user -columns=phone")
+	fmt.Println("")
+	fmt.Println("  # Drop every cached product after fixing bad data by hand")
+	fmt.Println("  go run cmd/artisan/main.go -action=cache:forget -keys=\"product:*\"")
+	fmt.Println("")
+	fmt.Println("  # Audit which /api/v1 routes are public")
+	fmt.Println("  go run cmd/artisan/main.go -action=route:list -path=/api/v1")
+	fmt.Println("")
+	fmt.Println("  # Drain traffic for a deploy, keeping access for the office network")
+	fmt.Println("  go run cmd/artisan/main.go -action=down -retry=300 -secret=deploy-2024 -allow=203.0.113.0/24")
+	fmt.Println("  go run cmd/artisan/main.go -action=up")
 	fmt.Println("")
 	fmt.Println("  # Rollback last 2 migrations")
 	fmt.Println("  go run cmd/artisan/main.go -action=migrate:rollback -count=2")
@@ -777,6 +1559,8 @@ type MigrationData struct {
 	Version      string
 	DatabaseType string
 	Strategy     string
+	Indexes      []CompositeIndex
+	Relations    []Relation
 }
 
 type Field struct {
@@ -785,9 +1569,37 @@ type Field struct {
 	Type         string
 	HasIndex     bool
 	IsForeignKey bool
-	FKReference  string // table name that reference
+	FKReference  string   // table name that reference
+	Shape        []Field  // sub-fields of a typed json/jsonb column (shape:key=type;...)
+	StructType   string   // Go type of a shaped column, set by withJSONTypes
+	IsUnique     bool     // unique among rows that aren't soft-deleted
+	UniqueIndex  string   // name of that index, set by withUniqueIndexes
+	UniqueMySQL  bool     // the index is (column, not_deleted) since MySQL has no partial indexes
+	IsVersion    bool     // optimistic lock counter, added by -versioned
+	Visible      []string // roles that may see the field in responses (visible:admin;support)
+	Enum         []string // allowed values of an enum column (status:enum(pending,active))
+	EnumType     string   // Go string type of an enum column, set by withEnums
+	EnumCheck    string   // CHECK constraint of an enum column, set by withEnums; MySQL uses a native ENUM
+	EnumPrevious []string // values before a -change migration
+	Nullable     bool     // the column allows NULL and the entity field is a pointer
+	Default      string   // column default (default:0), when HasDefault
+	HasDefault   bool
+	Comment      string // column comment (comment:Shown to customers), also on the entity field
+	Relation     string // hasmany or m2m: an association to RelatedTable, not a column
+	RelatedTable string
+	// Morph names the <Morph>_id and <Morph>_type columns of a polymorphic
+	// relation: a morph field's own (commentable:int|morph), or on a hasmany
+	// relation the ones of RelatedTable it goes by (|morph:commentable)
+	Morph string
+	// ColumnType is the column type of a field whose type depends on the
+	// database, set by withMorphs for uuid ids
+	ColumnType string
 }
 
+// versionField is the column -versioned adds: every update must name the
+// version it read and bumps it, so a stale write matches no row
+var versionField = Field{Name: "version", Type: "int", IsVersion: true}
+
 type SeederData struct {
 	ClassName    string
 	TableName    string
@@ -800,11 +1612,54 @@ type EntityData struct {
 	Fields       []Field
 	DatabaseType string
 	Strategy     string
+	Versioned    bool // add a version column for optimistic locking
+	Searchable   bool // implement search.Searchable, for ?search on list endpoints
+	Indexes      []CompositeIndex
+	Relations    []Relation
 }
 
 type PackageData struct {
 	PackageName string
 	EntityName  string
+	HasEntity   bool   // internal/entity has a matching entity, so list methods are generated
+	HasRequests bool   // the entity has make:model's Create/Update requests, so bulk methods are generated
+	Versioned   bool   // the entity has make:model -versioned's version column, so updates compare-and-swap it
+	Searchable  bool   // the entity implements search.Searchable, so the module registers it and lists take ?search
+	KeyColumn   string // column matched by the "id" of bulk update/delete items
+	// UniqueColumns are checked with integrity.CheckUnique before bulk writes
+	UniqueColumns []string
+	// Relations are the entity's many-to-many associations, which get Attach,
+	// Detach and Sync repository methods
+	Relations  []PackageRelation
+	Access     string   // public, auth or admin route group
+	Prefix     string   // route prefix, e.g. /products
+	Middleware []string // manifest middleware refs of the route group
+}
+
+// PackageRelation is a many-to-many association of a make:package entity
+type PackageRelation struct {
+	Field     string // association field, e.g. Tags
+	Entity    string // related entity, e.g. Tag
+	KeyColumn string // column of the related entity matched by the ids
+}
+
+type StorageDriverData struct {
+	DriverName string // STORAGE_DRIVER value and file name
+	StructName string
+	EnvPrefix  string
+}
+
+type NotificationData struct {
+	StructName string
+	FileName   string
+}
+
+type PolicyData struct {
+	EntityName string
+	VarName    string
+	Permission string // resource of the role grants, e.g. "post" in "post:update"
+	Plural     string
+	Owned      bool // the entity has a UserID owner column
 }
 
 func parseFields(fieldList string) []Field {
@@ -813,11 +1668,16 @@ func parseFields(fieldList string) []Field {
 		return parsedFields
 	}
 
-	fieldPairs := strings.Split(fieldList, ",")
+	fieldPairs := splitFields(fieldList)
 
 	for _, pair := range fieldPairs {
 		// split field_name:type|options - use SplitN to split only the first ":"
 		mainParts := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+
+		// Relations have no type: tags|m2m:tags, comments|hasmany:comments
+		if name, options, found := strings.Cut(strings.TrimSpace(pair), "|"); found && !strings.Contains(name, ":") {
+			mainParts = []string{name, "|" + options}
+		}
 		if len(mainParts) < 2 {
 			continue
 		}
@@ -825,7 +1685,9 @@ func parseFields(fieldList string) []Field {
 		fieldName := strings.TrimSpace(mainParts[0])
 		typeAndOptions := strings.TrimSpace(mainParts[1])
 
-		// split type and options (type|index or type|fk:table)
+		// split type and options (type|index, type|unique, type|nullable, type|default:value,
+		// type|comment:text, type|fk:table, type|visible:role;..., json|shape:key=type;...,
+		// type|morph, or a relation's |m2m:table, |hasmany:table or |morph:name)
 		typeParts := strings.Split(typeAndOptions, "|")
 		fieldType := strings.TrimSpace(typeParts[0])
 
@@ -837,6 +1699,17 @@ func parseFields(fieldList string) []Field {
 			FKReference:  "",
 		}
 
+		// enum(pending,active,closed)
+		if len(fieldType) > len("enum(") && strings.EqualFold(fieldType[:len("enum(")], "enum(") {
+			enum, err := parseEnum(strings.TrimSuffix(fieldType[len("enum("):], ")"))
+			if err != nil {
+				fmt.Printf("❌ %s: %v\n", fieldName, err)
+				os.Exit(1)
+			}
+			field.Type = "enum"
+			field.Enum = enum
+		}
+
 		// check options
 		if len(typeParts) > 1 {
 			for i := 1; i < len(typeParts); i++ {
@@ -844,189 +1717,966 @@ func parseFields(fieldList string) []Field {
 
 				if option == "index" {
 					field.HasIndex = true
+				} else if option == "unique" {
+					field.IsUnique = true
 				} else if strings.HasPrefix(option, "fk:") {
 					field.IsForeignKey = true
 					field.FKReference = strings.TrimPrefix(option, "fk:")
 					field.HasIndex = true
+				} else if strings.HasPrefix(option, "shape:") {
+					field.Shape = parseShape(strings.TrimPrefix(option, "shape:"))
+				} else if strings.HasPrefix(option, "visible:") {
+					field.Visible = parseRoles(strings.TrimPrefix(option, "visible:"))
+				} else if option == "nullable" {
+					field.Nullable = true
+				} else if strings.HasPrefix(option, "default:") {
+					field.Default = strings.TrimPrefix(option, "default:")
+					field.HasDefault = true
+				} else if strings.HasPrefix(option, "comment:") {
+					field.Comment = strings.TrimPrefix(option, "comment:")
+				} else if strings.HasPrefix(option, "m2m:") || strings.HasPrefix(option, "hasmany:") {
+					field.Relation, field.RelatedTable, _ = strings.Cut(option, ":")
+				} else if option == "morph" {
+					field.Morph = fieldName
+				} else if strings.HasPrefix(option, "morph:") {
+					field.Morph = strings.TrimPrefix(option, "morph:")
 				}
 			}
 		}
 
+		if err := checkFieldOptions(field); err != nil {
+			fmt.Printf("❌ %s: %v\n", fieldName, err)
+			os.Exit(1)
+		}
+
 		parsedFields = append(parsedFields, field)
 	}
 
 	return parsedFields
 }
 
-// Template functions
-var templateFuncs = template.FuncMap{
-	"toGoType":                     toGoType,
-	"toPascalCase":                 toPascalCase,
-	"toCamelCase":                  toCamelCase,
-	"getGormTag":                   getGormTag,
-	"getValidationTag":             getValidationTag,
-	"hasDecimalField":              hasDecimalField,
-	"getStructName":                getStructName,
-	"hasIndexField":                hasIndexField,
-	"hasFKField":                   hasFKField,
-	"toLowerFirst":                 toLowerFirst,
-	"getCreatedAtTag":              getCreatedAtTag,
-	"getUpdatedAtTag":              getUpdatedAtTag,
-	"getPrimaryKeyFields":          getPrimaryKeyFields,
-	"getImportsForStrategy":        getImportsForStrategy,
-	"getBeforeCreateHook":          getBeforeCreateHook,
-	"getMigrationPrimaryKeyFields": getMigrationPrimaryKeyFields,
-}
-
-func toPascalCase(s string) string {
-	// First, split by common separators
-	words := strings.FieldsFunc(s, func(c rune) bool {
-		return c == '_' || c == '-' || c == ' '
-	})
+// tagUnsafe are the characters a default or comment can't hold, since they
+// end up in a GORM struct tag
+const tagUnsafe = ";\"`"
 
-	// If we have a single word and it's camelCase, split it by capital letters
-	if len(words) == 1 && words[0] == s {
-		// Split camelCase into words
-		var camelWords []string
-		var current strings.Builder
+// checkFieldOptions rejects a default its column can't hold and defaults or
+// comments that would break the struct tag
+func checkFieldOptions(field Field) error {
+	if field.Relation != "" {
+		if field.Type != "" {
+			return fmt.Errorf("a relation takes no type, e.g. %s|%s:%s", field.Name, field.Relation, field.RelatedTable)
+		}
+		if !indexColumnPattern.MatchString(field.Name) || !indexColumnPattern.MatchString(field.RelatedTable) {
+			return fmt.Errorf("invalid relation %s|%s:%s", field.Name, field.Relation, field.RelatedTable)
+		}
+		if field.Morph != "" && (field.Relation != "hasmany" || !indexColumnPattern.MatchString(field.Morph)) {
+			return fmt.Errorf("morph:%s takes a hasmany relation to a table with %s_id and %s_type, e.g. %s|hasmany:%s|morph:%s",
+				field.Morph, field.Morph, field.Morph, field.Name, field.RelatedTable, field.Morph)
+		}
+		return nil
+	}
+	if field.Morph != "" {
+		switch {
+		case field.Morph != field.Name:
+			return fmt.Errorf("a morph field is named after its columns, e.g. %s:%s|morph", field.Morph, field.Type)
+		case !indexColumnPattern.MatchString(field.Name):
+			return fmt.Errorf("invalid morph field %s", field.Name)
+		case field.IsUnique || field.IsForeignKey || field.HasDefault:
+			return fmt.Errorf("a morph field can't be unique, a fk or have a default")
+		}
+		switch strings.ToLower(field.Type) {
+		case "int", "integer", "int64", "bigint", "uuid":
+		default:
+			return fmt.Errorf("a morph field has the type of its owners' key (int, bigint or uuid), not %s", field.Type)
+		}
+	}
+	if strings.ContainsAny(field.Comment, tagUnsafe) {
+		return fmt.Errorf("comment can't contain %s", tagUnsafe)
+	}
+	if !field.HasDefault {
+		return nil
+	}
+	if strings.ContainsAny(field.Default, tagUnsafe) {
+		return fmt.Errorf("default can't contain %s", tagUnsafe)
+	}
 
-		for i, r := range s {
-			if i > 0 && 'A' <= r && r <= 'Z' {
-				if current.Len() > 0 {
-					camelWords = append(camelWords, current.String())
-					current.Reset()
-				}
+	var err error
+	switch strings.ToLower(field.Type) {
+	case "int", "integer", "int64", "bigint":
+		_, err = strconv.ParseInt(field.Default, 10, 64)
+	case "float", "float64", "decimal":
+		_, err = strconv.ParseFloat(field.Default, 64)
+	case "bool", "boolean":
+		_, err = strconv.ParseBool(field.Default)
+	case "enum":
+		err = fmt.Errorf("not one of %s", strings.Join(field.Enum, ", "))
+		for _, value := range field.Enum {
+			if value == field.Default {
+				err = nil
 			}
-			current.WriteRune(r)
 		}
-		if current.Len() > 0 {
-			camelWords = append(camelWords, current.String())
+	case "json", "jsonb":
+		err = fmt.Errorf("json columns take no default")
+	}
+	if err != nil {
+		return fmt.Errorf("invalid default %q: %v", field.Default, err)
+	}
+	return nil
+}
+
+// splitFields splits a -fields list on the commas outside parentheses, so
+// enum values stay with their field
+func splitFields(fieldList string) []string {
+	var pairs []string
+	depth, start := 0, 0
+	for i, r := range fieldList {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				pairs = append(pairs, fieldList[start:i])
+				start = i + 1
+			}
 		}
+	}
+	return append(pairs, fieldList[start:])
+}
 
-		words = camelWords
+// enumValuePattern is what an enum value may hold: it is written into SQL,
+// a oneof validation tag and a Go constant name as is
+var enumValuePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// parseEnum parses the values of an enum type ("pending,active,closed")
+func parseEnum(values string) ([]string, error) {
+	var enum []string
+	seen := make(map[string]bool)
+	for _, value := range strings.Split(values, ",") {
+		value = strings.TrimSpace(value)
+		if !enumValuePattern.MatchString(value) {
+			return nil, fmt.Errorf("enum value %q must be lowercase letters, digits and underscores, starting with a letter", value)
+		}
+		if seen[value] {
+			return nil, fmt.Errorf("enum value %q is listed twice", value)
+		}
+		seen[value] = true
+		enum = append(enum, value)
 	}
+	return enum, nil
+}
 
-	caser := cases.Title(language.English)
-	for i, word := range words {
-		// Special handling for "id" and "uuid" at the end of words
-		lowerWord := strings.ToLower(word)
-		if lowerWord == "id" || lowerWord == "uuid" {
-			words[i] = strings.ToUpper(lowerWord)
-		} else {
-			words[i] = caser.String(strings.ToLower(word))
+// parseShape parses the sub-fields of a json column ("theme=string;notify=bool")
+func parseShape(shape string) []Field {
+	var fields []Field
+	for _, pair := range strings.Split(shape, ";") {
+		name, fieldType, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			continue
 		}
+		fields = append(fields, Field{Name: strings.TrimSpace(name), Type: strings.TrimSpace(fieldType)})
 	}
+	return fields
+}
 
-	return strings.Join(words, "")
+// parseRoles parses the roles of a visible option ("admin;support")
+func parseRoles(roles string) []string {
+	var parsed []string
+	for _, role := range strings.Split(roles, ";") {
+		if role = strings.TrimSpace(role); role != "" {
+			parsed = append(parsed, role)
+		}
+	}
+	return parsed
 }
 
-func toSnakeCase(s string) string {
-	var result strings.Builder
-	for i, r := range s {
-		if i > 0 && 'A' <= r && r <= 'Z' {
-			result.WriteRune('_')
+// searchAttributes returns the quoted text fields ?search matches, leaving
+// out fields only some roles may see
+func searchAttributes(fields []Field) string {
+	var attributes []string
+	for _, field := range fields {
+		switch strings.ToLower(field.Type) {
+		case "string", "text":
+			if len(field.Visible) == 0 {
+				attributes = append(attributes, strconv.Quote(field.Name))
+			}
 		}
-		result.WriteRune(r)
 	}
-	return strings.ToLower(result.String())
+	return strings.Join(attributes, ", ")
 }
 
-func toCamelCase(s string) string {
-	// First convert to PascalCase
-	pascalCase := toPascalCase(s)
-	// Then convert first letter to lowercase
-	if len(pascalCase) > 0 {
-		return strings.ToLower(pascalCase[:1]) + pascalCase[1:]
+// getVisibleTag returns the serializer tag hiding a field from principals
+// without one of its roles, or nothing for fields everyone sees
+func getVisibleTag(field Field) string {
+	if len(field.Visible) == 0 {
+		return ""
 	}
-	return pascalCase
+	return ` visible:"` + strings.Join(field.Visible, ",") + `"`
 }
 
-func toGoType(fieldType string) string {
+// withJSONTypes names the struct of each shaped json column after the entity,
+// e.g. ProductSettings for Product.settings
+func withJSONTypes(entityName string, fields []Field) []Field {
+	for i := range fields {
+		if isJSONType(fields[i].Type) && len(fields[i].Shape) > 0 {
+			fields[i].StructType = entityName + toPascalCase(fields[i].Name)
+		}
+	}
+	return fields
+}
+
+func isJSONType(fieldType string) bool {
 	switch strings.ToLower(fieldType) {
-	case "string":
-		return "string"
-	case "text":
-		return "string"
-	case "int", "integer":
-		return "int"
-	case "int64", "bigint":
-		return "int64"
-	case "float", "float64":
-		return "float64"
-	case "decimal":
-		return "decimal.Decimal"
-	case "bool", "boolean":
-		return "bool"
-	case "uuid":
-		return "uuid.UUID"
-	case "timestamp", "time", "date", "datetime":
-		return "time.Time"
 	case "json", "jsonb":
-		return "map[string]interface{}"
-	default:
-		return "string"
+		return true
 	}
+	return false
 }
 
-func getGormTag(field Field) string {
-	tags := []string{}
-
-	// Basic type tags
-	switch strings.ToLower(field.Type) {
-	case "string":
-		tags = append(tags, "type:varchar(255)", "not null")
-	case "text":
-		tags = append(tags, "type:text")
-	case "int", "integer":
-		tags = append(tags, "type:integer", "not null")
-	case "int64", "bigint":
-		tags = append(tags, "type:bigint", "not null")
-	case "float":
-		tags = append(tags, "type:float", "not null")
-	case "float64":
-		tags = append(tags, "type:double", "not null")
-	case "decimal":
-		tags = append(tags, "type:decimal(10,2)", "not null")
-		// case "bool", "boolean":
-		// 	tags = append(tags, "default:false")
-		// case "uuid":
-		// 	tags = append(tags, "type:varchar(36)", "not null")
-		// case "time":
-		// 	tags = append(tags, "type:time")
-		// case "date":
-		// 	tags = append(tags, "type:date")
-	case "json":
-		tags = append(tags, "type:json")
-	case "jsonb":
-		tags = append(tags, "type:jsonb")
-	default:
-		tags = append(tags, "not null")
+// fieldGoType is the entity and request type of a field: its struct for
+// shaped json columns, its string type for enums, toGoType otherwise
+func fieldGoType(field Field) string {
+	if field.StructType != "" {
+		return field.StructType
+	}
+	if field.EnumType != "" {
+		return field.EnumType
 	}
+	return toGoType(field.Type)
+}
 
-	// Add index tag
-	if field.HasIndex || field.IsForeignKey {
-		tags = append(tags, "index")
+// getShapeValidationTag validates a json sub-field; booleans are optional
+// since false is their zero value
+func getShapeValidationTag(fieldType string) string {
+	switch strings.ToLower(fieldType) {
+	case "bool", "boolean":
+		return "omitempty"
 	}
+	return getValidationTag(fieldType)
+}
 
-	// Delete type:integer and type:bigint from tags if field is foreign key
-	if field.IsForeignKey {
-		for i, tag := range tags {
-			if strings.Contains(tag, "type:integer") || strings.Contains(tag, "type:bigint") {
-				tags = append(tags[:i], tags[i+1:]...)
-			}
+// notDeletedColumn is the MySQL generated column unique indexes of soft-deleted
+// tables include: 1 while the row is live and NULL once deleted, and since
+// NULLs never collide, deleted rows don't block re-creating a value
+const notDeletedColumn = "not_deleted"
+
+// withUniqueIndexes names the unique index of each unique field. PostgreSQL
+// and SQLite get a partial index (WHERE deleted_at IS NULL); MySQL gets a
+// composite with not_deleted.
+func withUniqueIndexes(tableName, dbType string, fields []Field) []Field {
+	for i := range fields {
+		if fields[i].IsUnique {
+			fields[i].UniqueIndex = "uidx_" + tableName + "_" + fields[i].Name
+			fields[i].UniqueMySQL = strings.ToLower(dbType) == "mysql"
 		}
 	}
+	return fields
+}
 
-	fmt.Println("tags", tags)
+// getUniqueIndexTag returns the GORM index tag of a unique field
+func getUniqueIndexTag(field Field) string {
+	if field.UniqueMySQL {
+		return "uniqueIndex:" + field.UniqueIndex + ",priority:1"
+	}
+	return "uniqueIndex:" + field.UniqueIndex + ",where:deleted_at IS NULL"
+}
 
-	return strings.Join(tags, ";")
+// getNotDeletedField returns the not_deleted column of MySQL tables with
+// unique fields or composite indexes, read-only so GORM never writes it
+func getNotDeletedField(fields []Field, composite []CompositeIndex) string {
+	var indexes []string
+	for _, field := range fields {
+		if field.UniqueMySQL {
+			indexes = append(indexes, "uniqueIndex:"+field.UniqueIndex+",priority:2")
+		}
+	}
+	for _, index := range composite {
+		if index.MySQL {
+			indexes = append(indexes, "uniqueIndex:"+index.Name+",priority:"+strconv.Itoa(len(index.Columns)+1))
+		}
+	}
+	if len(indexes) == 0 {
+		return ""
+	}
+	tag := "->;column:" + notDeletedColumn + ";type:tinyint(1) GENERATED ALWAYS AS (IF(deleted_at IS NULL, 1, NULL)) VIRTUAL;" + strings.Join(indexes, ";")
+	return "\n\tNotDeleted *bool `json:\"-\" gorm:\"" + tag + "\"`"
 }
 
-func getValidationTag(fieldType string) string {
-	switch strings.ToLower(fieldType) {
-	case "string":
-		return "required,min=1,max=255"
-	case "text":
+// CompositeIndex is an index over several columns, from -indexes
+type CompositeIndex struct {
+	Name    string
+	Columns []string
+	Unique  bool // among rows that aren't soft-deleted, like unique fields
+	MySQL   bool // the unique index ends with not_deleted since MySQL has no partial indexes
+}
+
+// indexColumnPattern is what a column of -indexes may be named
+var indexColumnPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// parseIndexes parses -indexes ("org_id+email:unique,created_at+status") and
+// names each index after its table and columns
+func parseIndexes(tableName, dbType, indexList string) ([]CompositeIndex, error) {
+	var indexes []CompositeIndex
+	if indexList == "" {
+		return indexes, nil
+	}
+
+	for _, definition := range strings.Split(indexList, ",") {
+		columnList, kind, _ := strings.Cut(strings.TrimSpace(definition), ":")
+		if kind != "" && kind != "unique" {
+			return nil, fmt.Errorf("index %s: unknown option %q, only unique", definition, kind)
+		}
+
+		var columns []string
+		for _, column := range strings.Split(columnList, "+") {
+			column = strings.TrimSpace(column)
+			if !indexColumnPattern.MatchString(column) {
+				return nil, fmt.Errorf("index %s: invalid column %q", definition, column)
+			}
+			columns = append(columns, column)
+		}
+
+		index := CompositeIndex{Name: "idx_" + tableName + "_" + strings.Join(columns, "_"), Columns: columns}
+		if kind == "unique" {
+			index.Name = "u" + index.Name
+			index.Unique = true
+			index.MySQL = strings.ToLower(dbType) == "mysql"
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
+
+// printIndexes lists composite indexes in the summary of a generated file
+func printIndexes(indexes []CompositeIndex) {
+	if len(indexes) == 0 {
+		return
+	}
+	fmt.Printf("📇 Indexes:\n")
+	for _, index := range indexes {
+		kind := ""
+		if index.Unique {
+			kind = " (unique)"
+		}
+		fmt.Printf("  - %s: %s%s\n", index.Name, strings.Join(index.Columns, ", "), kind)
+	}
+}
+
+// printRelations lists relations in the summary of a generated file, warning
+// about has-many tables without the owner's column
+func printRelations(relations []Relation) {
+	if len(relations) == 0 {
+		return
+	}
+	fmt.Printf("🔗 Relations:\n")
+	for _, relation := range relations {
+		if relation.Kind == "m2m" {
+			fmt.Printf("  - %s: many-to-many %s (pivot table %s)\n", relation.Name, relation.Entity, relation.JoinTable)
+			continue
+		}
+		if relation.Morph != "" {
+			fmt.Printf("  - %s: has many %s (%s.%s, %s_type %s)\n", relation.Name, relation.Entity, relation.Table, relation.ForeignKey, relation.Morph, relation.MorphType)
+		} else {
+			fmt.Printf("  - %s: has many %s (%s.%s)\n", relation.Name, relation.Entity, relation.Table, relation.ForeignKey)
+		}
+		source, err := os.ReadFile(filepath.Join("internal/entity", toSnakeCase(relation.Entity)+".go"))
+		if err == nil && !strings.Contains(string(source), `json:"`+relation.ForeignKey+`"`) {
+			keyType := "int"
+			if relation.OwnerKey == "UUID" {
+				keyType = "uuid"
+			}
+			if relation.Morph != "" {
+				fmt.Printf("⚠️  %s has no %s field yet; add a %s:%s|morph field to %s\n", relation.Entity, relation.ForeignKey, relation.Morph, keyType, relation.Table)
+				continue
+			}
+			fmt.Printf("⚠️  %s has no %s field yet; add a %s:%s|fk column to %s\n", relation.Entity, relation.ForeignKey, relation.ForeignKey, keyType, relation.Table)
+		}
+	}
+}
+
+// checkIndexColumns rejects composite indexes on columns a new table won't
+// have. The key columns are left out since their tags are fixed.
+func checkIndexColumns(indexes []CompositeIndex, fields []Field) error {
+	columns := map[string]bool{"created_at": true, "updated_at": true, "deleted_at": true}
+	for _, field := range fields {
+		columns[field.Name] = true
+	}
+	for _, index := range indexes {
+		for _, column := range index.Columns {
+			if !columns[column] {
+				return fmt.Errorf("index %s: %s is not one of the -fields or a timestamp column", index.Name, column)
+			}
+		}
+	}
+	return nil
+}
+
+// getIndexTags returns the GORM tags putting a column in its composite
+// indexes, each starting with ";"
+func getIndexTags(indexes []CompositeIndex, column string) string {
+	var tags string
+	for _, index := range indexes {
+		for i, indexColumn := range index.Columns {
+			if indexColumn != column {
+				continue
+			}
+			priority := ",priority:" + strconv.Itoa(i+1)
+			switch {
+			case index.MySQL:
+				tags += ";uniqueIndex:" + index.Name + priority
+			case index.Unique:
+				tags += ";uniqueIndex:" + index.Name + priority + ",where:deleted_at IS NULL"
+			default:
+				tags += ";index:" + index.Name + priority
+			}
+		}
+	}
+	return tags
+}
+
+// Relation is a has-many or many-to-many association from -fields
+// (comments|hasmany:comments, tags|m2m:tags), named after its JSON field
+type Relation struct {
+	Name          string
+	Kind          string // hasmany or m2m
+	Table         string // table of the related entity
+	Entity        string // related entity, e.g. Tag
+	OwnerKey      string // key field of the owner the relation references, ID or UUID
+	RelatedKey    string // m2m: key field of the related entity, ID or UUID
+	ForeignKey    string // column holding the owner's key: in Table for hasmany, JoinTable for m2m
+	JoinTable     string // m2m: the pivot table, e.g. post_tags
+	JoinReference string // m2m: pivot column holding the related entity's key
+	Morph         string // polymorphic hasmany: the <Morph>_id/<Morph>_type columns of Table
+	MorphType     string // polymorphic hasmany: the owner's <Morph>_type value, its table
+}
+
+// splitRelations separates the relations of -fields from its columns. The
+// owner table is referenced by its key field (ID or UUID) in a column named
+// after its entity (post_id), which has-many tables must have, or in the
+// morph columns of polymorphic ones with the table as type; pivot tables
+// are named after the entity too (post_tags). Related entities that don't
+// exist yet are assumed to have an int ID.
+func splitRelations(tableName, ownerKey string, fields []Field) ([]Field, []Relation, error) {
+	entityName := getStructName(tableName)
+	var columns []Field
+	var relations []Relation
+	for _, field := range fields {
+		if field.Relation == "" {
+			columns = append(columns, field)
+			continue
+		}
+
+		relation := Relation{
+			Name:       field.Name,
+			Kind:       field.Relation,
+			Table:      field.RelatedTable,
+			Entity:     getStructName(field.RelatedTable),
+			OwnerKey:   ownerKey,
+			ForeignKey: toSnakeCase(entityName) + "_id",
+		}
+		if field.Morph != "" {
+			relation.Morph = field.Morph
+			relation.MorphType = tableName
+			relation.ForeignKey = field.Morph + "_id"
+		}
+		if relation.Kind == "m2m" {
+			relation.RelatedKey = entityKeyField(relation.Entity)
+			relation.JoinTable = toSnakeCase(entityName) + "_" + strings.TrimPrefix(strings.TrimPrefix(field.RelatedTable, namingConfig().TablePrefix), "tb_")
+			relation.JoinReference = toSnakeCase(getStructName(field.Name)) + "_id"
+			if relation.JoinReference == relation.ForeignKey {
+				return nil, nil, fmt.Errorf("%s: name a relation of %s to itself after its role, e.g. related_%s", field.Name, entityName, field.Name)
+			}
+		}
+		relations = append(relations, relation)
+	}
+	return columns, relations, nil
+}
+
+// strategyKeyField returns the key field of a new entity with the strategy
+func strategyKeyField(strategy string) string {
+	if strategy == "uuid" {
+		return "UUID"
+	}
+	return "ID"
+}
+
+// entityKeyField returns the key field other tables reference an entity
+// by: UUID for the uuid strategy, ID otherwise or when it doesn't exist yet
+func entityKeyField(entityName string) string {
+	source, err := os.ReadFile(filepath.Join("internal/entity", toSnakeCase(entityName)+".go"))
+	if err != nil || regexp.MustCompile("(?m)^\\s+ID\\s+int\\s+`").Match(source) {
+		return "ID"
+	}
+	return "UUID"
+}
+
+// getRelationTag returns the GORM tag of a relation's entity field
+func getRelationTag(relation Relation) string {
+	if relation.Kind == "m2m" {
+		return "many2many:" + relation.JoinTable + ";foreignKey:" + relation.OwnerKey + ";joinForeignKey:" + toPascalCase(relation.ForeignKey) +
+			";references:" + relation.RelatedKey + ";joinReferences:" + toPascalCase(relation.JoinReference)
+	}
+	if relation.Morph != "" {
+		// A polymorphic foreignKey is the owner's key field, needed when it
+		// isn't the primary key
+		tag := "polymorphic:" + toPascalCase(relation.Morph) + ";polymorphicValue:" + relation.MorphType
+		if relation.OwnerKey != "ID" {
+			tag += ";foreignKey:" + relation.OwnerKey
+		}
+		return tag
+	}
+	return "foreignKey:" + toPascalCase(relation.ForeignKey) + ";references:" + relation.OwnerKey
+}
+
+// withMorphs replaces each morph field (commentable:int|morph) with the
+// columns of a polymorphic relation, commentable_type and commentable_id,
+// and indexes them together in that order, the way owners look them up
+func withMorphs(tableName, dbType string, fields []Field) ([]Field, []CompositeIndex) {
+	var columns []Field
+	var indexes []CompositeIndex
+	for _, field := range fields {
+		if field.Morph == "" || field.Relation != "" {
+			columns = append(columns, field)
+			continue
+		}
+
+		morphType := Field{Name: field.Morph + "_type", Type: "string", Nullable: field.Nullable, Visible: field.Visible}
+		morphID := field
+		morphID.Name = field.Morph + "_id"
+		morphID.Morph = ""
+		morphID.HasIndex = false
+		if strings.EqualFold(field.Type, "uuid") {
+			morphID.ColumnType = uuidColumnType(dbType)
+		}
+		columns = append(columns, morphType, morphID)
+		indexes = append(indexes, CompositeIndex{
+			Name:    "idx_" + tableName + "_" + field.Morph,
+			Columns: []string{morphType.Name, morphID.Name},
+		})
+	}
+	return columns, indexes
+}
+
+// getIncludable returns the ?include names of an entity's associations
+// mapped to their fields, for query.Options
+func getIncludable(fields []Field, relations []Relation) string {
+	var entries []string
+	for _, field := range fields {
+		if field.IsForeignKey {
+			association := getStructName(field.FKReference)
+			entries = append(entries, fmt.Sprintf("%q: %q", toLowerFirst(association), association))
+		}
+	}
+	for _, relation := range relations {
+		entries = append(entries, fmt.Sprintf("%q: %q", relation.Name, toPascalCase(relation.Name)))
+	}
+	return strings.Join(entries, ", ")
+}
+
+// getPivotKeyTag returns the GORM tag of a pivot table column holding key
+func getPivotKeyTag(dbType, key string) string {
+	if key != "UUID" {
+		return "primaryKey;autoIncrement:false"
+	}
+	return "type:" + uuidColumnType(dbType) + ";primaryKey"
+}
+
+// uuidColumnType returns the column type of a uuid.UUID that references a
+// key, which GORM would otherwise make text
+func uuidColumnType(dbType string) string {
+	if strings.ToLower(dbType) == "postgresql" || strings.ToLower(dbType) == "postgres" {
+		return "uuid"
+	}
+	return "varchar(36)"
+}
+
+// hasPivotTables reports whether relations need a pivot table, and with
+// uuid.UUID columns the uuid import
+func hasPivotTables(relations []Relation, uuidKeys bool) bool {
+	for _, relation := range relations {
+		if relation.Kind == "m2m" && (!uuidKeys || relation.OwnerKey == "UUID" || relation.RelatedKey == "UUID") {
+			return true
+		}
+	}
+	return false
+}
+
+// withEnums names the Go type of each enum column after the entity, e.g.
+// OrderStatus for Order.status, and its CHECK constraint. MySQL columns are a
+// native ENUM instead; PostgreSQL enum types can't drop or reorder values, so
+// a constraint is easier to change there.
+func withEnums(entityName, tableName, dbType string, fields []Field) []Field {
+	for i := range fields {
+		if len(fields[i].Enum) == 0 {
+			continue
+		}
+		fields[i].EnumType = entityName + toPascalCase(fields[i].Name)
+		if strings.ToLower(dbType) != "mysql" {
+			fields[i].EnumCheck = "chk_" + tableName + "_" + fields[i].Name
+		}
+	}
+	return fields
+}
+
+// getEnumTag returns the GORM type of an enum column: a varchar with a CHECK
+// constraint, or a MySQL ENUM
+func getEnumTag(field Field) string {
+	values := "'" + strings.Join(field.Enum, "','") + "'"
+	if field.EnumCheck == "" {
+		return "type:enum(" + values + ")"
+	}
+	return "type:varchar(255);check:" + field.EnumCheck + "," + field.Name + " IN (" + values + ")"
+}
+
+// enumConstant returns the name of the Go constant of an enum value, e.g.
+// OrderStatusPending
+func enumConstant(field Field, value string) string {
+	return field.EnumType + toPascalCase(value)
+}
+
+// previousEnum returns an enum field with the values it had before a -change
+// migration
+func previousEnum(field Field) Field {
+	field.Enum = field.EnumPrevious
+	return field
+}
+
+// getDefaultValue returns the GORM default of a field, quoting an empty
+// string so GORM doesn't take it for no default
+func getDefaultValue(field Field) string {
+	if field.Default == "" {
+		return "''"
+	}
+	return field.Default
+}
+
+// getFieldValidationTag is getValidationTag limited to the values of enum
+// fields. Nullable fields and fields with a default may be left out.
+func getFieldValidationTag(field Field) string {
+	tag := getValidationTag(field.Type)
+	if len(field.Enum) > 0 {
+		tag += ",oneof=" + strings.Join(field.Enum, " ")
+	}
+	if field.Nullable || field.HasDefault {
+		tag = "omitempty" + strings.TrimPrefix(tag, "required")
+	}
+	return tag
+}
+
+// getUpdateValidationTag is getFieldValidationTag for the pointer fields of
+// update requests, which may always be left out
+func getUpdateValidationTag(field Field) string {
+	tag := getFieldValidationTag(field)
+	if strings.HasPrefix(tag, "omitempty") {
+		return tag
+	}
+	return "omitempty," + tag
+}
+
+// fieldEntityType is fieldGoType as a pointer for nullable columns, so NULL
+// reads as nil
+func fieldEntityType(field Field) string {
+	if field.Nullable {
+		return "*" + fieldGoType(field)
+	}
+	return fieldGoType(field)
+}
+
+// getFieldComment returns the column comment as a Go comment after the field
+func getFieldComment(field Field) string {
+	if field.Comment == "" {
+		return ""
+	}
+	return " // " + field.Comment
+}
+
+// enumTagPattern finds the values in the GORM tag of an enum field
+var enumTagPattern = regexp.MustCompile(`(?:enum|IN) \(([^)]*)\)`)
+
+// entityFieldLines returns the pattern of the lines of an entity declaring a
+// field: the entity's own and those of its requests
+func entityFieldLines(field Field) *regexp.Regexp {
+	return regexp.MustCompile(`(?m)^\s+` + toPascalCase(field.Name) + `\s+\*?\w+\s+` + "`" + `json:"` + field.Name + `[",].*$`)
+}
+
+// withPreviousEnums returns the enum fields of a -change migration with the
+// values the entity at path gives them now
+func withPreviousEnums(path string, fields []Field) ([]Field, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the entity of the table: %w", err)
+	}
+
+	changed := make([]Field, len(fields))
+	for i, field := range fields {
+		if len(field.Enum) == 0 {
+			return nil, fmt.Errorf("%s is not an enum field; -change only changes enum values", field.Name)
+		}
+		var match []string
+		for _, line := range entityFieldLines(field).FindAllString(string(source), -1) {
+			if match = enumTagPattern.FindStringSubmatch(line); match != nil {
+				break
+			}
+		}
+		if match == nil {
+			return nil, fmt.Errorf("%s has no enum field %s", path, field.Name)
+		}
+		previous, err := parseEnum(strings.ReplaceAll(match[1], "'", ""))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, field.Name, err)
+		}
+		changed[i] = Field{Name: field.Name, Type: field.Type, Enum: field.Enum, EnumPrevious: previous}
+	}
+	return changed, nil
+}
+
+// updateEntityEnums gives the enum fields of the entity at path their new
+// values: in the GORM tag, the request validation and the constants
+func updateEntityEnums(path string, fields []Field) error {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	content := string(source)
+	for _, field := range fields {
+		replacer := strings.NewReplacer(
+			"'"+strings.Join(field.EnumPrevious, "','")+"'", "'"+strings.Join(field.Enum, "','")+"'",
+			"oneof="+strings.Join(field.EnumPrevious, " "), "oneof="+strings.Join(field.Enum, " "),
+		)
+		content = entityFieldLines(field).ReplaceAllStringFunc(content, replacer.Replace)
+
+		constants := regexp.MustCompile(`(?m)^const \(\n(\s+` + field.EnumType + `\w*\s+` + field.EnumType + `\s*=\s*"[^"]*"\n)+\)`)
+		if !constants.MatchString(content) {
+			return fmt.Errorf("no constants of %s", field.EnumType)
+		}
+		block := "const ("
+		for _, value := range field.Enum {
+			block += "\n\t" + enumConstant(field, value) + " " + field.EnumType + ` = "` + value + `"`
+		}
+		content = constants.ReplaceAllLiteralString(content, block+"\n)")
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// Template functions
+var templateFuncs = template.FuncMap{
+	"toGoType":                     toGoType,
+	"fieldGoType":                  fieldGoType,
+	"getShapeValidationTag":        getShapeValidationTag,
+	"getNotDeletedField":           getNotDeletedField,
+	"getIndexTags":                 getIndexTags,
+	"indexList":                    func(index CompositeIndex) []CompositeIndex { return []CompositeIndex{index} },
+	"getRelationTag":               getRelationTag,
+	"getPivotKeyTag":               getPivotKeyTag,
+	"hasPivotTables":               hasPivotTables,
+	"getIncludable":                getIncludable,
+	"fieldList":                    func(field Field) []Field { return []Field{field} },
+	"toPascalCase":                 toPascalCase,
+	"toCamelCase":                  toCamelCase,
+	"getGormTag":                   getGormTag,
+	"getVisibleTag":                getVisibleTag,
+	"getValidationTag":             getValidationTag,
+	"getFieldValidationTag":        getFieldValidationTag,
+	"getUpdateValidationTag":       getUpdateValidationTag,
+	"fieldEntityType":              fieldEntityType,
+	"getFieldComment":              getFieldComment,
+	"enumConstant":                 enumConstant,
+	"previousEnum":                 previousEnum,
+	"hasDecimalField":              hasDecimalField,
+	"getStructName":                getStructName,
+	"hasIndexField":                hasIndexField,
+	"hasFKField":                   hasFKField,
+	"toLowerFirst":                 toLowerFirst,
+	"getCreatedAtTag":              getCreatedAtTag,
+	"getUpdatedAtTag":              getUpdatedAtTag,
+	"getPrimaryKeyFields":          getPrimaryKeyFields,
+	"getImportsForStrategy":        getImportsForStrategy,
+	"hasUUIDField":                 hasUUIDField,
+	"searchAttributes":             searchAttributes,
+	"getBeforeCreateHook":          getBeforeCreateHook,
+	"getMigrationPrimaryKeyFields": getMigrationPrimaryKeyFields,
+}
+
+func toPascalCase(s string) string {
+	// First, split by common separators
+	words := strings.FieldsFunc(s, func(c rune) bool {
+		return c == '_' || c == '-' || c == ' '
+	})
+
+	// If we have a single word and it's camelCase, split it by capital letters
+	if len(words) == 1 && words[0] == s {
+		// Split camelCase into words
+		var camelWords []string
+		var current strings.Builder
+
+		for i, r := range s {
+			if i > 0 && 'A' <= r && r <= 'Z' {
+				if current.Len() > 0 {
+					camelWords = append(camelWords, current.String())
+					current.Reset()
+				}
+			}
+			current.WriteRune(r)
+		}
+		if current.Len() > 0 {
+			camelWords = append(camelWords, current.String())
+		}
+
+		words = camelWords
+	}
+
+	caser := cases.Title(language.English)
+	for i, word := range words {
+		// Special handling for "id" and "uuid" at the end of words
+		lowerWord := strings.ToLower(word)
+		if lowerWord == "id" || lowerWord == "uuid" {
+			words[i] = strings.ToUpper(lowerWord)
+		} else {
+			words[i] = caser.String(strings.ToLower(word))
+		}
+	}
+
+	return strings.Join(words, "")
+}
+
+func toSnakeCase(s string) string {
+	var result strings.Builder
+	for i, r := range s {
+		if i > 0 && 'A' <= r && r <= 'Z' {
+			result.WriteRune('_')
+		}
+		result.WriteRune(r)
+	}
+	return strings.ToLower(result.String())
+}
+
+func toCamelCase(s string) string {
+	// First convert to PascalCase
+	pascalCase := toPascalCase(s)
+	// Then convert first letter to lowercase
+	if len(pascalCase) > 0 {
+		return strings.ToLower(pascalCase[:1]) + pascalCase[1:]
+	}
+	return pascalCase
+}
+
+func toGoType(fieldType string) string {
+	switch strings.ToLower(fieldType) {
+	case "string":
+		return "string"
+	case "text":
+		return "string"
+	case "int", "integer":
+		return "int"
+	case "int64", "bigint":
+		return "int64"
+	case "float", "float64":
+		return "float64"
+	case "decimal":
+		return "decimal.Decimal"
+	case "bool", "boolean":
+		return "bool"
+	case "uuid":
+		return "uuid.UUID"
+	case "timestamp", "time", "date", "datetime":
+		return "time.Time"
+	case "json", "jsonb":
+		return "map[string]interface{}"
+	default:
+		return "string"
+	}
+}
+
+func getGormTag(field Field) string {
+	// Existing rows start at version 1 when the column is added later
+	if field.IsVersion {
+		return "not null;default:1"
+	}
+
+	tags := []string{}
+
+	// Basic type tags
+	switch strings.ToLower(field.Type) {
+	case "string":
+		tags = append(tags, "type:varchar(255)", "not null")
+	case "text":
+		tags = append(tags, "type:text")
+	case "int", "integer":
+		tags = append(tags, "type:integer", "not null")
+	case "int64", "bigint":
+		tags = append(tags, "type:bigint", "not null")
+	case "float":
+		tags = append(tags, "type:float", "not null")
+	case "float64":
+		tags = append(tags, "type:double", "not null")
+	case "decimal":
+		tags = append(tags, "type:decimal(10,2)", "not null")
+		// case "bool", "boolean":
+		// 	tags = append(tags, "default:false")
+		// case "uuid":
+		// 	tags = append(tags, "type:varchar(36)", "not null")
+		// case "time":
+		// 	tags = append(tags, "type:time")
+		// case "date":
+		// 	tags = append(tags, "type:date")
+	case "json":
+		tags = append(tags, "type:json", "serializer:json")
+	case "jsonb":
+		tags = append(tags, "type:jsonb", "serializer:json")
+	case "enum":
+		tags = append(tags, getEnumTag(field), "not null")
+	case "uuid":
+		if field.ColumnType != "" {
+			tags = append(tags, "type:"+field.ColumnType)
+		}
+		tags = append(tags, "not null")
+	default:
+		tags = append(tags, "not null")
+	}
+
+	if field.Nullable {
+		for i, tag := range tags {
+			if tag == "not null" {
+				tags = append(tags[:i], tags[i+1:]...)
+				break
+			}
+		}
+	}
+	if field.HasDefault {
+		tags = append(tags, "default:"+getDefaultValue(field))
+	}
+	if field.Comment != "" {
+		tags = append(tags, "comment:"+field.Comment)
+	}
+
+	// Add index tag; a unique index covers the column already
+	if field.UniqueIndex != "" {
+		tags = append(tags, getUniqueIndexTag(field))
+	} else if field.HasIndex || field.IsForeignKey {
+		tags = append(tags, "index")
+	}
+
+	// Delete type:integer and type:bigint from tags if field is foreign key
+	if field.IsForeignKey {
+		for i, tag := range tags {
+			if strings.Contains(tag, "type:integer") || strings.Contains(tag, "type:bigint") {
+				tags = append(tags[:i], tags[i+1:]...)
+			}
+		}
+	}
+
+	fmt.Println("tags", tags)
+
+	return strings.Join(tags, ";")
+}
+
+func getValidationTag(fieldType string) string {
+	switch strings.ToLower(fieldType) {
+	case "string":
+		return "required,min=1,max=255"
+	case "text":
 		return "required"
 	case "int", "integer":
 		return "required,min=0"
@@ -1052,8 +2702,17 @@ func hasDecimalField(fields []Field) bool {
 	return false
 }
 
+// namingConfig is the runtime GORM naming strategy, loaded once so the
+// generators name tables exactly as the application will
+var namingConfig = sync.OnceValue(func() pkgDatabase.NamingConfig {
+	return config.Load().Database.Naming
+})
+
 func getStructName(tableName string) string {
-	// if table name start with tb_ then remove it
+	// strip the configured table prefix, and the repo's own tb_ convention
+	if prefix := namingConfig().TablePrefix; prefix != "" {
+		tableName = strings.TrimPrefix(tableName, prefix)
+	}
 	tableName = strings.TrimPrefix(tableName, "tb_")
 
 	tableName = singularize(tableName)
@@ -1062,6 +2721,19 @@ func getStructName(tableName string) string {
 	return toPascalCase(tableName)
 }
 
+// pluralize converts singular to plural (simple format)
+func pluralize(word string) string {
+	switch {
+	case strings.HasSuffix(word, "s"), strings.HasSuffix(word, "x"), strings.HasSuffix(word, "sh"), strings.HasSuffix(word, "ch"):
+		// box -> boxes, dish -> dishes
+		return word + "es"
+	case strings.HasSuffix(word, "y") && len(word) > 1 && !strings.ContainsRune("aeiou", rune(word[len(word)-2])):
+		// category -> categories
+		return strings.TrimSuffix(word, "y") + "ies"
+	}
+	return word + "s"
+}
+
 // singularize convert plural to singular (simple format)
 func singularize(word string) string {
 	// basic rules for English pluralization
@@ -1082,6 +2754,17 @@ func singularize(word string) string {
 	return word
 }
 
+// hasUUIDField reports whether fields hold a uuid.UUID, e.g. the id of a
+// morph field with uuid owners
+func hasUUIDField(fields []Field) bool {
+	for _, field := range fields {
+		if strings.EqualFold(field.Type, "uuid") {
+			return true
+		}
+	}
+	return false
+}
+
 func hasIndexField(fields []Field) bool {
 	for _, field := range fields {
 		if field.HasIndex {
@@ -1128,6 +2811,7 @@ func generateDynamicMigrationsRegistry() error {
 		if !d.IsDir() && strings.HasSuffix(path, ".go") &&
 			!strings.HasSuffix(path, "_test.go") &&
 			!strings.HasSuffix(path, "manager.go") &&
+			!strings.HasSuffix(path, "sources.go") &&
 			!strings.HasSuffix(path, "_generated.go") {
 			migrationFiles = append(migrationFiles, path)
 		}
@@ -1303,11 +2987,14 @@ func getPrimaryKeyFields(data interface{}) string {
 
 func getImportsForStrategy(data interface{}, hasDecimalField bool) string {
 	var strategy string
+	var fields []Field
 	switch d := data.(type) {
 	case EntityData:
 		strategy = d.Strategy
+		fields = d.Fields
 	case MigrationData:
 		strategy = d.Strategy
+		fields = d.Fields
 	default:
 		strategy = "int"
 	}
@@ -1315,7 +3002,25 @@ func getImportsForStrategy(data interface{}, hasDecimalField bool) string {
 	imports := `import (
 	"time"`
 
-	if strategy == "uuid" || strategy == "dual" {
+	// Entity filters embed pagination params and expose query allowlists
+	if entity, ok := data.(EntityData); ok {
+		imports += `
+
+	"flex-service/pkg/pagination"
+	"flex-service/pkg/query"`
+		if entity.Searchable {
+			imports += `
+	"flex-service/pkg/search"`
+		}
+	}
+
+	// Pivot tables hold the keys of both entities
+	pivotUUID := false
+	if migration, ok := data.(MigrationData); ok {
+		pivotUUID = hasPivotTables(migration.Relations, true)
+	}
+
+	if strategy == "uuid" || strategy == "dual" || pivotUUID || hasUUIDField(fields) {
 		imports += `
 
 	"github.com/google/uuid"`
@@ -1433,6 +3138,26 @@ func init() {
 }
 `
 
+// pivotTablesTemplate declares the pivot table of each many-to-many relation
+// of a migration. Deleting either row deletes their pivot rows.
+const pivotTablesTemplate = `
+{{- range .Relations}}
+{{- if eq .Kind "m2m"}}
+
+// {{$.ClassName}}{{getStructName .JoinTable}} is the pivot table of {{getStructName $.TableName}}.{{toPascalCase .Name}}
+type {{$.ClassName}}{{getStructName .JoinTable}} struct {
+	{{toPascalCase .ForeignKey}} {{if eq .OwnerKey "UUID"}}uuid.UUID{{else}}int{{end}} ` + "`gorm:\"{{getPivotKeyTag $.DatabaseType .OwnerKey}}\"`" + `
+	{{toPascalCase .JoinReference}} {{if eq .RelatedKey "UUID"}}uuid.UUID{{else}}int{{end}} ` + "`gorm:\"{{getPivotKeyTag $.DatabaseType .RelatedKey}};index\"`" + `
+	{{getStructName $.TableName}} {{getStructName $.TableName}} ` + "`gorm:\"foreignKey:{{toPascalCase .ForeignKey}};references:{{.OwnerKey}};constraint:OnDelete:CASCADE\"`" + `
+	{{getStructName .Name}} {{.Entity}} ` + "`gorm:\"foreignKey:{{toPascalCase .JoinReference}};references:{{.RelatedKey}};constraint:OnDelete:CASCADE\"`" + `
+}
+
+func ({{$.ClassName}}{{getStructName .JoinTable}}) TableName() string {
+	return "{{.JoinTable}}"
+}
+{{- end}}
+{{- end}}`
+
 // Default create table template
 const createTableTemplate = `package migrations
 
@@ -1442,16 +3167,16 @@ const createTableTemplate = `package migrations
 type {{getStructName .TableName}} struct {
 	{{getMigrationPrimaryKeyFields .}}
 	{{- range .Fields}}
-	{{toPascalCase .Name}} {{toGoType .Type}} ` + "`gorm:\"{{getGormTag .}}\"`" + `
+	{{toPascalCase .Name}} {{toGoType .Type}} ` + "`gorm:\"{{getGormTag .}}{{getIndexTags $.Indexes .Name}}\"`" + `
 	{{- end}}
 	{{- range .Fields}}
 	{{- if .IsForeignKey}}
 	{{getStructName .FKReference}} {{getStructName .FKReference}} ` + "`json:\"{{getStructName .FKReference | toLowerFirst}},omitempty\" gorm:\"foreignKey:{{toPascalCase .Name}};references:ID\"`" + `
 	{{- end}}
 	{{- end}}
-	CreatedAt time.Time      ` + "`gorm:\"autoCreateTime\"`" + `
-	UpdatedAt time.Time      ` + "`gorm:\"autoUpdateTime\"`" + `
-	DeletedAt gorm.DeletedAt ` + "`gorm:\"index\"`" + `
+	CreatedAt time.Time      ` + "`gorm:\"autoCreateTime{{getIndexTags .Indexes \"created_at\"}}\"`" + `
+	UpdatedAt time.Time      ` + "`gorm:\"autoUpdateTime{{getIndexTags .Indexes \"updated_at\"}}\"`" + `
+	DeletedAt gorm.DeletedAt ` + "`gorm:\"index{{getIndexTags .Indexes \"deleted_at\"}}\"`" + `
 }
 
 // TableName returns the table name for GORM
@@ -1459,17 +3184,19 @@ func ({{getStructName .TableName}}) TableName() string {
 	return "{{.TableName}}"
 }
 
+` + pivotTablesTemplate + `
+
 // {{.ClassName}} migration - Create {{.TableName}} table (SQLite)
 type {{.ClassName}} struct{}
 
-// Up creates the {{.TableName}} table using the {{getStructName .TableName}} struct
+// Up creates the {{.TableName}} table using the {{getStructName .TableName}} struct{{if hasPivotTables .Relations false}}, and its pivot tables{{end}}
 func (m *{{.ClassName}}) Up(db *gorm.DB) error {
-	return db.AutoMigrate(&{{getStructName .TableName}}{})
+	return db.AutoMigrate(&{{getStructName .TableName}}{}{{range .Relations}}{{if eq .Kind "m2m"}}, &{{$.ClassName}}{{getStructName .JoinTable}}{}{{end}}{{end}})
 }
 
-// Down drops the {{.TableName}} table
+// Down drops the {{.TableName}} table{{if hasPivotTables .Relations false}} and its pivot tables{{end}}
 func (m *{{.ClassName}}) Down(db *gorm.DB) error {
-	return db.Migrator().DropTable(&{{getStructName .TableName}}{})
+	return db.Migrator().DropTable({{range .Relations}}{{if eq .Kind "m2m"}}&{{$.ClassName}}{{getStructName .JoinTable}}{}, {{end}}{{end}}&{{getStructName .TableName}}{})
 }
 
 // Description returns migration description
@@ -1497,16 +3224,16 @@ const createTableTemplateMySQL = `package migrations
 type {{getStructName .TableName}} struct {
 	{{getMigrationPrimaryKeyFields .}}
 	{{- range .Fields}}
-	{{toPascalCase .Name}} {{toGoType .Type}} ` + "`gorm:\"{{getGormTag .}}\"`" + `
+	{{toPascalCase .Name}} {{toGoType .Type}} ` + "`gorm:\"{{getGormTag .}}{{getIndexTags $.Indexes .Name}}\"`" + `
 	{{- end}}
 	{{- range .Fields}}
 	{{- if .IsForeignKey}}
 	{{getStructName .FKReference}} {{getStructName .FKReference}} ` + "`json:\"{{getStructName .FKReference | toLowerFirst}},omitempty\" gorm:\"foreignKey:{{toPascalCase .Name}};references:ID\"`" + `
 	{{- end}}
 	{{- end}}
-	CreatedAt time.Time      ` + "`gorm:\"autoCreateTime\"`" + `
-	UpdatedAt time.Time      ` + "`gorm:\"autoUpdateTime\"`" + `
-	DeletedAt gorm.DeletedAt ` + "`gorm:\"index\"`" + `
+	CreatedAt time.Time      ` + "`gorm:\"autoCreateTime{{getIndexTags .Indexes \"created_at\"}}\"`" + `
+	UpdatedAt time.Time      ` + "`gorm:\"autoUpdateTime{{getIndexTags .Indexes \"updated_at\"}}\"`" + `
+	DeletedAt gorm.DeletedAt ` + "`gorm:\"index{{getIndexTags .Indexes \"deleted_at\"}}\"`" + `{{getNotDeletedField .Fields .Indexes}}
 }
 
 // TableName returns the table name for GORM
@@ -1514,17 +3241,19 @@ func ({{getStructName .TableName}}) TableName() string {
 	return "{{.TableName}}"
 }
 
+` + pivotTablesTemplate + `
+
 // {{.ClassName}} migration - Create {{.TableName}} table (MySQL)
 type {{.ClassName}} struct{}
 
-// Up creates the {{.TableName}} table using the {{getStructName .TableName}} struct
+// Up creates the {{.TableName}} table using the {{getStructName .TableName}} struct{{if hasPivotTables .Relations false}}, and its pivot tables{{end}}
 func (m *{{.ClassName}}) Up(db *gorm.DB) error {
-	return db.AutoMigrate(&{{getStructName .TableName}}{})
+	return db.AutoMigrate(&{{getStructName .TableName}}{}{{range .Relations}}{{if eq .Kind "m2m"}}, &{{$.ClassName}}{{getStructName .JoinTable}}{}{{end}}{{end}})
 }
 
-// Down drops the {{.TableName}} table
+// Down drops the {{.TableName}} table{{if hasPivotTables .Relations false}} and its pivot tables{{end}}
 func (m *{{.ClassName}}) Down(db *gorm.DB) error {
-	return db.Migrator().DropTable(&{{getStructName .TableName}}{})
+	return db.Migrator().DropTable({{range .Relations}}{{if eq .Kind "m2m"}}&{{$.ClassName}}{{getStructName .JoinTable}}{}, {{end}}{{end}}&{{getStructName .TableName}}{})
 }
 
 // Description returns migration description
@@ -1552,39 +3281,186 @@ const createTableTemplatePostgreSQL = `package migrations
 type {{getStructName .TableName}} struct {
 	{{getMigrationPrimaryKeyFields .}}
 	{{- range .Fields}}
-	{{toPascalCase .Name}} {{toGoType .Type}} ` + "`gorm:\"{{getGormTag .}}\"`" + `
+	{{toPascalCase .Name}} {{toGoType .Type}} ` + "`gorm:\"{{getGormTag .}}{{getIndexTags $.Indexes .Name}}\"`" + `
+	{{- end}}
+	{{- range .Fields}}
+	{{- if .IsForeignKey}}
+	{{getStructName .FKReference}} {{getStructName .FKReference}} ` + "`json:\"{{getStructName .FKReference | toLowerFirst}},omitempty\" gorm:\"foreignKey:{{toPascalCase .Name}};references:ID\"`" + `
+	{{- end}}
+	{{- end}}
+	CreatedAt time.Time      ` + "`gorm:\"autoCreateTime{{getIndexTags .Indexes \"created_at\"}}\"`" + `
+	UpdatedAt time.Time      ` + "`gorm:\"autoUpdateTime{{getIndexTags .Indexes \"updated_at\"}}\"`" + `
+	DeletedAt gorm.DeletedAt ` + "`gorm:\"index{{getIndexTags .Indexes \"deleted_at\"}}\"`" + `
+}
+
+// TableName returns the table name for GORM
+func ({{getStructName .TableName}}) TableName() string {
+	return "{{.TableName}}"
+}
+
+` + pivotTablesTemplate + `
+
+// {{.ClassName}} migration - Create {{.TableName}} table (PostgreSQL)
+type {{.ClassName}} struct{}
+
+// Up creates the {{.TableName}} table using the {{getStructName .TableName}} struct{{if hasPivotTables .Relations false}}, and its pivot tables{{end}}
+func (m *{{.ClassName}}) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&{{getStructName .TableName}}{}{{range .Relations}}{{if eq .Kind "m2m"}}, &{{$.ClassName}}{{getStructName .JoinTable}}{}{{end}}{{end}})
+}
+
+// Down drops the {{.TableName}} table{{if hasPivotTables .Relations false}} and its pivot tables{{end}}
+func (m *{{.ClassName}}) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable({{range .Relations}}{{if eq .Kind "m2m"}}&{{$.ClassName}}{{getStructName .JoinTable}}{}, {{end}}{{end}}&{{getStructName .TableName}}{})
+}
+
+// Description returns migration description
+func (m *{{.ClassName}}) Description() string {
+	return "Create {{.TableName}} table"
+}
+
+// Version returns migration version
+func (m *{{.ClassName}}) Version() string {
+	return "{{.Version}}"
+}
+
+// Auto-register migration
+func init() {
+	Register(&{{.ClassName}}{})
+}
+`
+
+// Default alter table template
+const alterTableTemplate = `package migrations
+
+import (
+	"gorm.io/gorm"
+	{{- if or (hasPivotTables .Relations true) (hasUUIDField .Fields)}}
+	"github.com/google/uuid"
+	{{- end}}
+	{{- if hasDecimalField .Fields}}
+	"github.com/shopspring/decimal"
+	{{- end}}
+)
+
+// {{.ClassName}} migration - Modify {{.TableName}} table (SQLite)
+type {{.ClassName}} struct{}
+` + pivotTablesTemplate + `
+
+{{- range .Fields}}
+// {{.ClassName}}{{toPascalCase .Name}} represents the new column structure
+type {{$.ClassName}}{{toPascalCase .Name}} struct {
+	{{toPascalCase .Name}} {{toGoType .Type}} ` + "`gorm:\"{{getGormTag .}}\"`" + `{{getNotDeletedField (fieldList .) nil}}
+}
+
+func ({{$.ClassName}}{{toPascalCase .Name}}) TableName() string {
+	return "{{$.TableName}}"
+}
+{{- end}}
+{{- range .Indexes}}
+
+// {{$.ClassName}}{{toPascalCase .Name}} declares the {{.Name}} index; CreateIndex only reads its column names
+type {{$.ClassName}}{{toPascalCase .Name}} struct {
+	{{- $index := .}}
+	{{- range .Columns}}
+	{{toPascalCase .}} string ` + "`gorm:\"column:{{.}}{{getIndexTags (indexList $index) .}}\"`" + `
+	{{- end}}{{getNotDeletedField nil (indexList .)}}
+}
+
+func ({{$.ClassName}}{{toPascalCase .Name}}) TableName() string {
+	return "{{$.TableName}}"
+}
+{{- end}}
+
+// Up adds columns to the {{.TableName}} table
+func (m *{{.ClassName}}) Up(db *gorm.DB) error {
+	{{- range .Fields}}
+	// Add {{.Name}} column
+	if err := db.Migrator().AddColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.Name}}"); err != nil {
+		return err
+	}
+	{{- if .EnumCheck}}
+	if err := db.Migrator().CreateConstraint(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.EnumCheck}}"); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- if .UniqueIndex}}
+	{{- if .UniqueMySQL}}
+	if !db.Migrator().HasColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "not_deleted") {
+		if err := db.Migrator().AddColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "NotDeleted"); err != nil {
+			return err
+		}
+	}
+	{{- end}}
+	// Unique among rows that aren't soft-deleted
+	if err := db.Migrator().CreateIndex(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.UniqueIndex}}"); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- end}}
+	{{- range .Indexes}}
+	{{- if .MySQL}}
+	if !db.Migrator().HasColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "not_deleted") {
+		if err := db.Migrator().AddColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "NotDeleted"); err != nil {
+			return err
+		}
+	}
+	{{- end}}
+	// Add {{.Name}} index
+	if err := db.Migrator().CreateIndex(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.Name}}"); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- range .Relations}}
+	{{- if eq .Kind "m2m"}}
+	// Create the {{.JoinTable}} pivot table
+	if err := db.Migrator().CreateTable(&{{$.ClassName}}{{getStructName .JoinTable}}{}); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- end}}
+	
+	return nil
+}
+
+// Down removes columns from the {{.TableName}} table
+func (m *{{.ClassName}}) Down(db *gorm.DB) error {
+	{{- range .Relations}}
+	{{- if eq .Kind "m2m"}}
+	// Drop the {{.JoinTable}} pivot table
+	if err := db.Migrator().DropTable(&{{$.ClassName}}{{getStructName .JoinTable}}{}); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- end}}
+	{{- range .Indexes}}
+	// Drop {{.Name}} index
+	if err := db.Migrator().DropIndex(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.Name}}"); err != nil {
+		return err
+	}
 	{{- end}}
 	{{- range .Fields}}
-	{{- if .IsForeignKey}}
-	{{getStructName .FKReference}} {{getStructName .FKReference}} ` + "`json:\"{{getStructName .FKReference | toLowerFirst}},omitempty\" gorm:\"foreignKey:{{toPascalCase .Name}};references:ID\"`" + `
+	{{- if .UniqueIndex}}
+	if err := db.Migrator().DropIndex(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.UniqueIndex}}"); err != nil {
+		return err
+	}
 	{{- end}}
+	{{- if .EnumCheck}}
+	if err := db.Migrator().DropConstraint(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.EnumCheck}}"); err != nil {
+		return err
+	}
 	{{- end}}
-	CreatedAt time.Time      ` + "`gorm:\"autoCreateTime\"`" + `
-	UpdatedAt time.Time      ` + "`gorm:\"autoUpdateTime\"`" + `
-	DeletedAt gorm.DeletedAt ` + "`gorm:\"index\"`" + `
-}
-
-// TableName returns the table name for GORM
-func ({{getStructName .TableName}}) TableName() string {
-	return "{{.TableName}}"
-}
-
-// {{.ClassName}} migration - Create {{.TableName}} table (PostgreSQL)
-type {{.ClassName}} struct{}
-
-// Up creates the {{.TableName}} table using the {{getStructName .TableName}} struct
-func (m *{{.ClassName}}) Up(db *gorm.DB) error {
-	return db.AutoMigrate(&{{getStructName .TableName}}{})
-}
-
-// Down drops the {{.TableName}} table
-func (m *{{.ClassName}}) Down(db *gorm.DB) error {
-	return db.Migrator().DropTable(&{{getStructName .TableName}}{})
+	// Drop {{.Name}} column
+	if err := db.Migrator().DropColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.Name}}"); err != nil {
+		return err
+	}
+	{{- end}}
+	
+	return nil
 }
 
 // Description returns migration description
 func (m *{{.ClassName}}) Description() string {
-	return "Create {{.TableName}} table"
+	return "{{.Description}}"
 }
 
 // Version returns migration version
@@ -1598,23 +3474,41 @@ func init() {
 }
 `
 
-// Default alter table template
-const alterTableTemplate = `package migrations
+// MySQL-specific alter table template
+const alterTableTemplateMySQL = `package migrations
 
 import (
 	"gorm.io/gorm"
+	{{- if or (hasPivotTables .Relations true) (hasUUIDField .Fields)}}
+	"github.com/google/uuid"
+	{{- end}}
 	{{- if hasDecimalField .Fields}}
 	"github.com/shopspring/decimal"
 	{{- end}}
 )
 
-// {{.ClassName}} migration - Modify {{.TableName}} table (SQLite)
+// {{.ClassName}} migration - Modify {{.TableName}} table (MySQL)
 type {{.ClassName}} struct{}
+` + pivotTablesTemplate + `
 
 {{- range .Fields}}
 // {{.ClassName}}{{toPascalCase .Name}} represents the new column structure
 type {{$.ClassName}}{{toPascalCase .Name}} struct {
-	{{toPascalCase .Name}} {{toGoType .Type}} ` + "`gorm:\"{{getGormTag .}}\"`" + `
+	{{toPascalCase .Name}} {{toGoType .Type}} ` + "`gorm:\"{{getGormTag .}}\"`" + `{{getNotDeletedField (fieldList .) nil}}
+}
+
+func ({{$.ClassName}}{{toPascalCase .Name}}) TableName() string {
+	return "{{$.TableName}}"
+}
+{{- end}}
+{{- range .Indexes}}
+
+// {{$.ClassName}}{{toPascalCase .Name}} declares the {{.Name}} index; CreateIndex only reads its column names
+type {{$.ClassName}}{{toPascalCase .Name}} struct {
+	{{- $index := .}}
+	{{- range .Columns}}
+	{{toPascalCase .}} string ` + "`gorm:\"column:{{.}}{{getIndexTags (indexList $index) .}}\"`" + `
+	{{- end}}{{getNotDeletedField nil (indexList .)}}
 }
 
 func ({{$.ClassName}}{{toPascalCase .Name}}) TableName() string {
@@ -1629,6 +3523,40 @@ func (m *{{.ClassName}}) Up(db *gorm.DB) error {
 	if err := db.Migrator().AddColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.Name}}"); err != nil {
 		return err
 	}
+	{{- if .UniqueIndex}}
+	{{- if .UniqueMySQL}}
+	if !db.Migrator().HasColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "not_deleted") {
+		if err := db.Migrator().AddColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "NotDeleted"); err != nil {
+			return err
+		}
+	}
+	{{- end}}
+	// Unique among rows that aren't soft-deleted
+	if err := db.Migrator().CreateIndex(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.UniqueIndex}}"); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- end}}
+	{{- range .Indexes}}
+	{{- if .MySQL}}
+	if !db.Migrator().HasColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "not_deleted") {
+		if err := db.Migrator().AddColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "NotDeleted"); err != nil {
+			return err
+		}
+	}
+	{{- end}}
+	// Add {{.Name}} index
+	if err := db.Migrator().CreateIndex(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.Name}}"); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- range .Relations}}
+	{{- if eq .Kind "m2m"}}
+	// Create the {{.JoinTable}} pivot table
+	if err := db.Migrator().CreateTable(&{{$.ClassName}}{{getStructName .JoinTable}}{}); err != nil {
+		return err
+	}
+	{{- end}}
 	{{- end}}
 	
 	return nil
@@ -1636,7 +3564,26 @@ func (m *{{.ClassName}}) Up(db *gorm.DB) error {
 
 // Down removes columns from the {{.TableName}} table
 func (m *{{.ClassName}}) Down(db *gorm.DB) error {
+	{{- range .Relations}}
+	{{- if eq .Kind "m2m"}}
+	// Drop the {{.JoinTable}} pivot table
+	if err := db.Migrator().DropTable(&{{$.ClassName}}{{getStructName .JoinTable}}{}); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- end}}
+	{{- range .Indexes}}
+	// Drop {{.Name}} index
+	if err := db.Migrator().DropIndex(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.Name}}"); err != nil {
+		return err
+	}
+	{{- end}}
 	{{- range .Fields}}
+	{{- if .UniqueIndex}}
+	if err := db.Migrator().DropIndex(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.UniqueIndex}}"); err != nil {
+		return err
+	}
+	{{- end}}
 	// Drop {{.Name}} column
 	if err := db.Migrator().DropColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.Name}}"); err != nil {
 		return err
@@ -1662,51 +3609,76 @@ func init() {
 }
 `
 
-// MySQL-specific alter table template
-const alterTableTemplateMySQL = `package migrations
+// Enum change template: gives enum columns other values, e.g. to add one
+const changeEnumTemplate = `package migrations
 
 import (
 	"gorm.io/gorm"
-	{{- if hasDecimalField .Fields}}
-	"github.com/shopspring/decimal"
-	{{- end}}
 )
 
-// {{.ClassName}} migration - Modify {{.TableName}} table (MySQL)
+// {{.ClassName}} migration - Change enum values of the {{.TableName}} table
 type {{.ClassName}} struct{}
 
 {{- range .Fields}}
-// {{.ClassName}}{{toPascalCase .Name}} represents the new column structure
+
+// {{$.ClassName}}{{toPascalCase .Name}} is the {{.Name}} column with its new values
 type {{$.ClassName}}{{toPascalCase .Name}} struct {
-	{{toPascalCase .Name}} {{toGoType .Type}} ` + "`gorm:\"{{getGormTag .}}\"`" + `
+	{{toPascalCase .Name}} string ` + "`gorm:\"{{getGormTag .}}\"`" + `
 }
 
 func ({{$.ClassName}}{{toPascalCase .Name}}) TableName() string {
 	return "{{$.TableName}}"
 }
+
+// {{$.ClassName}}{{toPascalCase .Name}}Previous is the {{.Name}} column with its previous values
+type {{$.ClassName}}{{toPascalCase .Name}}Previous struct {
+	{{toPascalCase .Name}} string ` + "`gorm:\"{{getGormTag (previousEnum .)}}\"`" + `
+}
+
+func ({{$.ClassName}}{{toPascalCase .Name}}Previous) TableName() string {
+	return "{{$.TableName}}"
+}
 {{- end}}
 
-// Up adds columns to the {{.TableName}} table
+// Up gives the columns their new values; rows holding a removed value make it fail
 func (m *{{.ClassName}}) Up(db *gorm.DB) error {
 	{{- range .Fields}}
-	// Add {{.Name}} column
-	if err := db.Migrator().AddColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.Name}}"); err != nil {
+	// Change the values of {{.Name}}
+	{{- if .EnumCheck}}
+	if err := db.Migrator().DropConstraint(&{{$.ClassName}}{{toPascalCase .Name}}Previous{}, "{{.EnumCheck}}"); err != nil {
+		return err
+	}
+	if err := db.Migrator().CreateConstraint(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.EnumCheck}}"); err != nil {
+		return err
+	}
+	{{- else}}
+	if err := db.Migrator().AlterColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{toPascalCase .Name}}"); err != nil {
 		return err
 	}
 	{{- end}}
-	
+	{{- end}}
+
 	return nil
 }
 
-// Down removes columns from the {{.TableName}} table
+// Down restores the previous values; rows holding an added value make it fail
 func (m *{{.ClassName}}) Down(db *gorm.DB) error {
 	{{- range .Fields}}
-	// Drop {{.Name}} column
-	if err := db.Migrator().DropColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.Name}}"); err != nil {
+	// Restore the values of {{.Name}}
+	{{- if .EnumCheck}}
+	if err := db.Migrator().DropConstraint(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.EnumCheck}}"); err != nil {
+		return err
+	}
+	if err := db.Migrator().CreateConstraint(&{{$.ClassName}}{{toPascalCase .Name}}Previous{}, "{{.EnumCheck}}"); err != nil {
+		return err
+	}
+	{{- else}}
+	if err := db.Migrator().AlterColumn(&{{$.ClassName}}{{toPascalCase .Name}}Previous{}, "{{toPascalCase .Name}}"); err != nil {
 		return err
 	}
 	{{- end}}
-	
+	{{- end}}
+
 	return nil
 }
 
@@ -1731,6 +3703,9 @@ const alterTableTemplatePostgreSQL = `package migrations
 
 import (
 	"gorm.io/gorm"
+	{{- if or (hasPivotTables .Relations true) (hasUUIDField .Fields)}}
+	"github.com/google/uuid"
+	{{- end}}
 	{{- if hasDecimalField .Fields}}
 	"github.com/shopspring/decimal"
 	{{- end}}
@@ -1738,11 +3713,26 @@ import (
 
 // {{.ClassName}} migration - Modify {{.TableName}} table (PostgreSQL)
 type {{.ClassName}} struct{}
+` + pivotTablesTemplate + `
 
 {{- range .Fields}}
 // {{.ClassName}}{{toPascalCase .Name}} represents the new column structure
 type {{$.ClassName}}{{toPascalCase .Name}} struct {
-	{{toPascalCase .Name}} {{toGoType .Type}} ` + "`gorm:\"{{getGormTag .}}\"`" + `
+	{{toPascalCase .Name}} {{toGoType .Type}} ` + "`gorm:\"{{getGormTag .}}\"`" + `{{getNotDeletedField (fieldList .) nil}}
+}
+
+func ({{$.ClassName}}{{toPascalCase .Name}}) TableName() string {
+	return "{{$.TableName}}"
+}
+{{- end}}
+{{- range .Indexes}}
+
+// {{$.ClassName}}{{toPascalCase .Name}} declares the {{.Name}} index; CreateIndex only reads its column names
+type {{$.ClassName}}{{toPascalCase .Name}} struct {
+	{{- $index := .}}
+	{{- range .Columns}}
+	{{toPascalCase .}} string ` + "`gorm:\"column:{{.}}{{getIndexTags (indexList $index) .}}\"`" + `
+	{{- end}}{{getNotDeletedField nil (indexList .)}}
 }
 
 func ({{$.ClassName}}{{toPascalCase .Name}}) TableName() string {
@@ -1757,6 +3747,45 @@ func (m *{{.ClassName}}) Up(db *gorm.DB) error {
 	if err := db.Migrator().AddColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.Name}}"); err != nil {
 		return err
 	}
+	{{- if .EnumCheck}}
+	if err := db.Migrator().CreateConstraint(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.EnumCheck}}"); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- if .UniqueIndex}}
+	{{- if .UniqueMySQL}}
+	if !db.Migrator().HasColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "not_deleted") {
+		if err := db.Migrator().AddColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "NotDeleted"); err != nil {
+			return err
+		}
+	}
+	{{- end}}
+	// Unique among rows that aren't soft-deleted
+	if err := db.Migrator().CreateIndex(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.UniqueIndex}}"); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- end}}
+	{{- range .Indexes}}
+	{{- if .MySQL}}
+	if !db.Migrator().HasColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "not_deleted") {
+		if err := db.Migrator().AddColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "NotDeleted"); err != nil {
+			return err
+		}
+	}
+	{{- end}}
+	// Add {{.Name}} index
+	if err := db.Migrator().CreateIndex(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.Name}}"); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- range .Relations}}
+	{{- if eq .Kind "m2m"}}
+	// Create the {{.JoinTable}} pivot table
+	if err := db.Migrator().CreateTable(&{{$.ClassName}}{{getStructName .JoinTable}}{}); err != nil {
+		return err
+	}
+	{{- end}}
 	{{- end}}
 	
 	return nil
@@ -1764,7 +3793,31 @@ func (m *{{.ClassName}}) Up(db *gorm.DB) error {
 
 // Down removes columns from the {{.TableName}} table
 func (m *{{.ClassName}}) Down(db *gorm.DB) error {
+	{{- range .Relations}}
+	{{- if eq .Kind "m2m"}}
+	// Drop the {{.JoinTable}} pivot table
+	if err := db.Migrator().DropTable(&{{$.ClassName}}{{getStructName .JoinTable}}{}); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- end}}
+	{{- range .Indexes}}
+	// Drop {{.Name}} index
+	if err := db.Migrator().DropIndex(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.Name}}"); err != nil {
+		return err
+	}
+	{{- end}}
 	{{- range .Fields}}
+	{{- if .UniqueIndex}}
+	if err := db.Migrator().DropIndex(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.UniqueIndex}}"); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- if .EnumCheck}}
+	if err := db.Migrator().DropConstraint(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.EnumCheck}}"); err != nil {
+		return err
+	}
+	{{- end}}
 	// Drop {{.Name}} column
 	if err := db.Migrator().DropColumn(&{{$.ClassName}}{{toPascalCase .Name}}{}, "{{.Name}}"); err != nil {
 		return err
@@ -1871,64 +3924,354 @@ const entityTemplate = `package entity
 type {{.EntityName}} struct {
 	{{getPrimaryKeyFields .}}
 	{{- range .Fields}}
-	{{toPascalCase .Name}} {{toGoType .Type}} ` + "`json:\"{{.Name}}\" gorm:\"{{getGormTag .}}\"`" + `
+	{{toPascalCase .Name}} {{fieldEntityType .}} ` + "`json:\"{{.Name}}\" gorm:\"{{getGormTag .}}{{getIndexTags $.Indexes .Name}}\"{{getVisibleTag .}}`" + `{{getFieldComment .}}
 	{{- end}}
 	{{- range .Fields}}
 	{{- if .IsForeignKey}}
 	{{getStructName .FKReference}} {{getStructName .FKReference}} ` + "`json:\"{{getStructName .FKReference | toLowerFirst}},omitempty\" gorm:\"foreignKey:{{toPascalCase .Name}};references:ID\"`" + `
 	{{- end}}
 	{{- end}}
-	CreatedAt time.Time      ` + "`json:\"created_at\" gorm:\"{{getCreatedAtTag .}}\"`" + `
-	UpdatedAt time.Time      ` + "`json:\"updated_at\" gorm:\"{{getUpdatedAtTag .}}\"`" + `
-	DeletedAt gorm.DeletedAt ` + "`json:\"-\" gorm:\"index\"`" + `
+	{{- range .Relations}}
+	{{toPascalCase .Name}} []{{.Entity}} ` + "`json:\"{{.Name}},omitempty\" gorm:\"{{getRelationTag .}}\"`" + `
+	{{- end}}
+	{{- if .Versioned}}
+	// Version counts updates; an update naming an older one is rejected
+	Version int ` + "`json:\"version\" gorm:\"not null;default:1\"`" + `
+	{{- end}}
+	CreatedAt time.Time      ` + "`json:\"created_at\" gorm:\"{{getCreatedAtTag .}}{{getIndexTags .Indexes \"created_at\"}}\"`" + `
+	UpdatedAt time.Time      ` + "`json:\"updated_at\" gorm:\"{{getUpdatedAtTag .}}{{getIndexTags .Indexes \"updated_at\"}}\"`" + `
+	DeletedAt gorm.DeletedAt ` + "`json:\"-\" gorm:\"index{{getIndexTags .Indexes \"deleted_at\"}}\"`" + `{{getNotDeletedField .Fields .Indexes}}
 }
 
 // TableName returns the table name for GORM
 func ({{.EntityName}}) TableName() string {
 	return "{{.TableName}}"
 }{{getBeforeCreateHook .}}
+{{- range .Fields}}
+{{- if .StructType}}
+
+// {{.StructType}} is the typed value of the {{.Name}} column, stored as JSON.
+// Requests containing it validate its fields too.
+type {{.StructType}} struct {
+	{{- range .Shape}}
+	{{toPascalCase .Name}} {{toGoType .Type}} ` + "`json:\"{{.Name}}\" validate:\"{{getShapeValidationTag .Type}}\"`" + `
+	{{- end}}
+}
+{{- end}}
+{{- if .EnumType}}
+
+// {{.EnumType}} is a value of the {{.Name}} column. Requests only accept
+// these; change them with make:migration -change.
+type {{.EnumType}} string
+
+const (
+	{{- $field := .}}
+	{{- range .Enum}}
+	{{enumConstant $field .}} {{$field.EnumType}} = "{{.}}"
+	{{- end}}
+)
+{{- end}}
+{{- end}}
 
 // Create{{.EntityName}}Request represents a request to create a {{.EntityName}}
 type Create{{.EntityName}}Request struct {
 	{{- range .Fields}}
-	{{toPascalCase .Name}} {{toGoType .Type}} ` + "`json:\"{{.Name}}\" validate:\"{{getValidationTag .Type}}\"`" + `
+	{{toPascalCase .Name}} {{fieldEntityType .}} ` + "`json:\"{{.Name}}\" validate:\"{{getFieldValidationTag .}}\"`" + `
 	{{- end}}
 }
 
 // Update{{.EntityName}}Request represents a request to update a {{.EntityName}}
 type Update{{.EntityName}}Request struct {
 	{{- range .Fields}}
-	{{toPascalCase .Name}} *{{toGoType .Type}} ` + "`json:\"{{.Name}},omitempty\" validate:\"omitempty,{{getValidationTag .Type}}\"`" + `
+	{{toPascalCase .Name}} *{{fieldGoType .}} ` + "`json:\"{{.Name}},omitempty\" validate:\"{{getUpdateValidationTag .}}\"`" + `
+	{{- end}}
+	{{- if .Versioned}}
+	// Version is the version the client read; the update fails with a 409 if
+	// the {{.EntityName}} has changed since
+	Version int ` + "`json:\"version\" validate:\"required,min=1\"`" + `
+	{{- end}}
+}
+
+// {{.EntityName}}Filter represents filters for {{.EntityName}} queries
+type {{.EntityName}}Filter struct {
+	{{- range .Fields}}
+	{{- if or (eq .Type "string") (eq .Type "enum")}}
+	{{toPascalCase .Name}} string ` + "`form:\"{{.Name}}\"`" + `
+	{{- end}}
+	{{- end}}
+	Search string ` + "`form:\"search\"`" + `
+	pagination.Params
+}
+
+// {{.EntityName}}QueryOptions lists the columns list endpoints may filter, sort and select
+var {{.EntityName}}QueryOptions = query.Options{
+	Filterable:  []string{"id", {{range .Fields}}"{{.Name}}", {{end}}"created_at"},
+	Sortable:    []string{"id", {{range .Fields}}"{{.Name}}", {{end}}"created_at", "updated_at"},
+	Selectable:  []string{"id", {{range .Fields}}"{{.Name}}", {{end}}{{if .Versioned}}"version", {{end}}"created_at", "updated_at"},
+	DefaultSort: "-created_at",
+	MaxFilters:  10,
+	Trashable:   true,
+	{{- if .Searchable}}
+	Searchable:  true,
+	{{- end}}
+	{{- if or (hasFKField .Fields) .Relations}}
+	Includable:  map[string]string{ {{- getIncludable .Fields .Relations -}} },
+	{{- end}}
+}
+{{- if .Searchable}}
+
+// SearchIndex implements search.Searchable
+func ({{.EntityName}}) SearchIndex() string {
+	return "{{.TableName}}"
+}
+
+// SearchDocument returns what the search index holds of a {{.EntityName}}.
+// Fields only some roles may see stay out, since anyone listing can match them.
+func (e {{.EntityName}}) SearchDocument() search.Document {
+	return search.Document{
+		{{- range .Fields}}
+		{{- if not .Visible}}
+		"{{.Name}}": e.{{toPascalCase .Name}},
+		{{- end}}
+		{{- end}}
+		"created_at": e.CreatedAt.Unix(),
+	}
+}
+
+// {{.EntityName}}SearchSettings lists the attributes ?search matches, most
+// important first; filters and sorts are applied in SQL
+var {{.EntityName}}SearchSettings = search.Settings{
+	{{- if ne .Strategy "int"}}
+	Key:        "uuid",
 	{{- end}}
+	Searchable: []string{ {{- searchAttributes .Fields -}} },
+}
+{{- end}}
+
+`
+
+const storageDriverTemplate = `package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// errNotImplemented{{.StructName}} is returned by the stub until its methods are written
+var errNotImplemented{{.StructName}} = errors.New("{{.DriverName}} storage driver is not implemented")
+
+func init() {
+	RegisterDriver("{{.DriverName}}", func(cfg Config) (Filesystem, error) {
+		return New{{.StructName}}({{.StructName}}Config{
+			Bucket:   os.Getenv("{{.EnvPrefix}}_BUCKET"),
+			Endpoint: os.Getenv("{{.EnvPrefix}}_ENDPOINT"),
+		})
+	})
+}
+
+// {{.StructName}}Config configures the {{.DriverName}} driver
+type {{.StructName}}Config struct {
+	Bucket   string
+	Endpoint string
+}
+
+// {{.StructName}} stores files in {{.DriverName}}
+type {{.StructName}} struct {
+	config {{.StructName}}Config
+}
+
+// New{{.StructName}} creates the {{.DriverName}} driver
+func New{{.StructName}}(cfg {{.StructName}}Config) (*{{.StructName}}, error) {
+	// TODO: validate config and create the client
+	return &{{.StructName}}{config: cfg}, nil
+}
+
+// Put stores the content at path
+func (d *{{.StructName}}) Put(ctx context.Context, path string, body io.Reader, opts *PutOptions) error {
+	// TODO: implement
+	return errNotImplemented{{.StructName}}
+}
+
+// Get opens the file at path
+func (d *{{.StructName}}) Get(ctx context.Context, path string) (io.ReadCloser, error) {
+	// TODO: implement, returning ErrNotFound for missing files
+	return nil, errNotImplemented{{.StructName}}
+}
+
+// Delete removes the file at path
+func (d *{{.StructName}}) Delete(ctx context.Context, path string) error {
+	// TODO: implement
+	return errNotImplemented{{.StructName}}
+}
+
+// Exists reports whether a file exists at path
+func (d *{{.StructName}}) Exists(ctx context.Context, path string) (bool, error) {
+	// TODO: implement
+	return false, errNotImplemented{{.StructName}}
+}
+
+// URL returns the public URL of path
+func (d *{{.StructName}}) URL(path string) string {
+	// TODO: implement
+	return d.config.Endpoint + "/" + path
+}
+
+// TemporaryURL returns a signed URL for path that expires after expiry
+func (d *{{.StructName}}) TemporaryURL(ctx context.Context, path string, expiry time.Duration) (string, error) {
+	// TODO: implement
+	return "", errNotImplemented{{.StructName}}
+}
+`
+
+const notificationTemplate = `package notifications
+
+import (
+	"flex-service/pkg/email"
+	"flex-service/pkg/notification"
+)
+
+// {{.StructName}} is sent with container.Notifier:
+//
+//	container.Notifier.SendTo(ctx, user, notifications.{{.StructName}}{}, notification.Queued())
+type {{.StructName}} struct {
+	// TODO: add the data the notification needs
+}
+
+// Via returns the channels {{.StructName}} is sent through
+func (n {{.StructName}}) Via(notifiable notification.Notifiable) []string {
+	return []string{notification.ChannelEmail, notification.ChannelSlack}
+}
+
+// ToEmail builds the email; To defaults to the notifiable's email route
+func (n {{.StructName}}) ToEmail(notifiable notification.Notifiable) (*email.Message, error) {
+	// TODO: use Template("{{.FileName}}", n) for a template in EMAIL_TEMPLATE_DIR
+	return email.NewMessage().
+		SetSubject("{{.StructName}}").
+		SetText("TODO: write the {{.FileName}} email"), nil
+}
+
+// ToSlack builds the Slack message
+func (n {{.StructName}}) ToSlack(notifiable notification.Notifiable) (*notification.SlackMessage, error) {
+	return &notification.SlackMessage{Text: "TODO: write the {{.FileName}} Slack message"}, nil
+}
+`
+
+const policiesFileTemplate = `// Package policies holds the authorization policies of the entities, checked
+// with authorize.Can. make:policy generates a policy and adds it to Register.
+package policies
+
+import (
+	"flex-service/pkg/auth"
+)
+
+// Register sets the entity policies on p; the container calls it at startup.
+// permissions lets policies honour role grants such as "post:update".
+func Register(p *auth.Policies, permissions *auth.PermissionChecker) {
+	// make:policy adds policies here
+}
+`
+
+const policyTemplate = `package policies
+
+import (
+	"context"
+{{- if .Owned}}
+	"strconv"
+{{- end}}
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/auth"
+	"flex-service/pkg/requestctx"
+)
+
+// {{.EntityName}}Policy decides what a principal may do with a {{.EntityName}}.
+// Handlers and usecases check it with authorize:
+//
+//	if err := authorize.Check(ctx, "update", {{.VarName}}); err != nil {
+//		return err
+//	}
+type {{.EntityName}}Policy struct {
+	permissions *auth.PermissionChecker
+}
+
+// New{{.EntityName}}Policy creates the {{.EntityName}} policy
+func New{{.EntityName}}Policy(permissions *auth.PermissionChecker) *{{.EntityName}}Policy {
+	return &{{.EntityName}}Policy{permissions: permissions}
 }
 
-// {{.EntityName}}Filter represents filters for {{.EntityName}} queries
-type {{.EntityName}}Filter struct {
-	{{- range .Fields}}
-	{{- if eq .Type "string"}}
-	{{toPascalCase .Name}} string ` + "`form:\"{{.Name}}\"`" + `
-	{{- end}}
-	{{- end}}
-	Search string ` + "`form:\"search\"`" + `
-	Page   int    ` + "`form:\"page\" validate:\"min=1\"`" + `
-	Limit  int    ` + "`form:\"limit\" validate:\"min=1,max=100\"`" + `
+// Can implements auth.Policy. {{.VarName}} is nil for "viewAny" and "create".
+func (p *{{.EntityName}}Policy) Can(ctx context.Context, principal requestctx.Principal, action string, {{.VarName}} *entity.{{.EntityName}}) bool {
+	// Role grants such as "{{.Permission}}:update"{{if .Owned}} or "{{.Permission}}:update:own"{{end}} allow the action
+	if p.permissions != nil && p.permissions.Can(principal, "{{.Permission}}:"+action, {{if .Owned}}{{.VarName}}Owner({{.VarName}}){{else}}nil{{end}}) {
+		return true
+	}
+
+	switch action {
+	case "viewAny", "view":
+		// TODO: restrict who may read {{.Plural}}
+		return true
+	case "create":
+		return principal.IsUser()
+{{- if .Owned}}
+	case "update", "delete":
+		// Users may change their own {{.Plural}}
+		return {{.VarName}} != nil && principal.IsUser() && {{.VarName}}.UserID == principal.ID
+{{- end}}
+	}
+	return false
 }
+{{- if .Owned}}
 
+// {{.VarName}}Owner returns the owner of a {{.EntityName}} for ":own" grants
+func {{.VarName}}Owner({{.VarName}} *entity.{{.EntityName}}) auth.Owned {
+	if {{.VarName}} == nil {
+		return nil
+	}
+	return auth.OwnedBy(strconv.Itoa({{.VarName}}.UserID))
+}
+{{- end}}
 `
 
 // Package templates - Simple structure without CRUD
 const handlerTemplate = `package {{.PackageName}}
 
 import (
+{{- if .HasEntity}}
+	"net/http"
+{{end}}
+{{- if .HasRequests}}
+	"flex-service/pkg/bulk"
+{{- end}}
 	"flex-service/pkg/errors"
+	"flex-service/pkg/i18n"
 	"flex-service/pkg/logger"
+	"flex-service/pkg/request"
+	"flex-service/pkg/requestctx"
 	"flex-service/pkg/response"
-	"flex-service/pkg/validator"
+	"flex-service/pkg/serializer"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+// Translation keys for {{.PackageName}} responses, defined in locales/<lang>.json
+const (
+	msg{{.EntityName}}Retrieved = "{{.PackageName}}.retrieved"
+	msg{{.EntityName}}Created   = "{{.PackageName}}.created"
+	msg{{.EntityName}}Updated   = "{{.PackageName}}.updated"
+	msg{{.EntityName}}Deleted   = "{{.PackageName}}.deleted"
+	msg{{.EntityName}}NotFound  = "{{.PackageName}}.not_found"
+{{- if .HasEntity}}
+	msg{{.EntityName}}Restored  = "{{.PackageName}}.restored"
+{{- end}}
+{{- if .HasRequests}}
+	msg{{.EntityName}}Bulk      = "{{.PackageName}}.bulk"
+{{- end}}
+)
+
 type {{.EntityName}}Handler struct {
 	usecase {{.EntityName}}Usecase
 }
@@ -1938,12 +4281,74 @@ func New{{.EntityName}}Handler(usecase {{.EntityName}}Usecase) *{{.EntityName}}H
 		usecase: usecase,
 	}
 }
+{{- if .HasRequests}}
+
+// Bulk creates, updates or deletes many records (POST {{.Prefix}}/bulk).
+// Creates are inserted in batches; updates and deletes take items or ids
+// (with data for updates). Every item gets its own result, answered with a
+// 207 on partial failure; large requests are queued when the repository's
+// bulk processor has a queue.
+func (h *{{.EntityName}}Handler) Bulk(c *gin.Context) {
+	req, err := request.Bind[bulk.Request](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	result, err := h.usecase.Bulk(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, result.StatusCode(), i18n.T(i18n.Locale(c), msg{{.EntityName}}Bulk, nil), serializer.Serialize(c.Request.Context(), result))
+}
+{{- end}}
+{{- if .HasEntity}}
+
+// Restore undeletes a soft-deleted record (POST {{.Prefix}}/:id/restore).
+// List deleted records with ?only_trashed=1.
+func (h *{{.EntityName}}Handler) Restore(c *gin.Context) {
+	item, err := h.usecase.Restore(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, i18n.T(i18n.Locale(c), msg{{.EntityName}}Restored, nil), serializer.Serialize(c.Request.Context(), item, serializer.Fields(c)...))
+}
+
+// ForceDelete permanently deletes a record, soft-deleted or not
+// (DELETE {{.Prefix}}/:id/force)
+func (h *{{.EntityName}}Handler) ForceDelete(c *gin.Context) {
+	if err := h.usecase.ForceDelete(c.Request.Context(), c.Param("id")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, i18n.T(i18n.Locale(c), msg{{.EntityName}}Deleted, nil), nil)
+}
+{{- end}}
 
 // TODO: Add your handler methods here
 // Example:
 // func (h *{{.EntityName}}Handler) SomeMethod(c *gin.Context) {
-//     // Implementation here
-//     // h.usecase.SomeMethod(ctx)
+//     // Binds uri/query/JSON, validates, and returns an AppError on failure
+//     req, err := request.Bind[SomeRequest](c)
+//     if err != nil {
+//         c.Error(err)
+//         return
+//     }
+//     // Set by any auth middleware (user JWT or service signature)
+//     principal, _ := requestctx.CurrentPrincipal(c)
+//     result, err := h.usecase.SomeMethod(c.Request.Context(), req, principal)
+//     if err != nil {
+//         c.Error(err)
+//         return
+//     }
+//     // Hides fields the principal's roles may not see and applies ?fields
+//     data := serializer.Serialize(c.Request.Context(), result, serializer.Fields(c)...)
+//     response.Success(c, http.StatusOK, i18n.T(i18n.Locale(c), msg{{.EntityName}}Retrieved, nil), data)
 // }
 `
 
@@ -1951,10 +4356,33 @@ const portTemplate = `package {{.PackageName}}
 
 import (
 	"context"
+{{- if .HasEntity}}
+
+	"flex-service/internal/entity"
+{{- if .HasRequests}}
+	"flex-service/pkg/bulk"
+{{- end}}
+	"flex-service/pkg/pagination"
+	"flex-service/pkg/query"
+{{- end}}
 )
 
 // {{.EntityName}}Usecase defines the business logic interface for {{.PackageName}}
 type {{.EntityName}}Usecase interface {
+{{- if .HasEntity}}
+	// Restore undeletes a soft-deleted record
+	Restore(ctx context.Context, id string) (*entity.{{.EntityName}}, error)
+
+	// ForceDelete permanently removes a record, soft-deleted or not
+	ForceDelete(ctx context.Context, id string) error
+{{end}}
+{{- if .HasRequests}}
+	// Update applies the request's set fields{{if .Versioned}}; a stale req.Version is a 409 CONFLICT{{end}}
+	Update(ctx context.Context, id string, req *entity.Update{{.EntityName}}Request) (*entity.{{.EntityName}}, error)
+
+	// Bulk creates, updates or deletes many records
+	Bulk(ctx context.Context, req *bulk.Request) (*bulk.Result, error)
+{{end}}
 	// TODO: Add your usecase methods here
 	// Example:
 	// SomeMethod(ctx context.Context) error
@@ -1962,6 +4390,42 @@ type {{.EntityName}}Usecase interface {
 
 // {{.EntityName}}Repository defines the data access interface for {{.PackageName}}
 type {{.EntityName}}Repository interface {
+{{- if .HasEntity}}
+	// List returns a page of records matching the parsed query and the total count
+	List(ctx context.Context, q *query.Query, p *pagination.Params) ([]entity.{{.EntityName}}, int64, error)
+
+	// Delete removes a record, or returns DELETE_RESTRICTED while other records reference it
+	Delete(ctx context.Context, item *entity.{{.EntityName}}) error
+
+	// Restore undeletes a soft-deleted record by its id
+	Restore(ctx context.Context, id string) (*entity.{{.EntityName}}, error)
+
+	// ForceDelete permanently removes a record by its id, soft-deleted or not
+	ForceDelete(ctx context.Context, id string) error
+{{- range .Relations}}
+
+	// Attach{{.Field}} adds {{.Field}} to a record by their ids, keeping the ones it has
+	Attach{{.Field}}(ctx context.Context, id string, {{toCamelCase .Entity}}IDs []string) error
+
+	// Detach{{.Field}} removes {{.Field}} from a record by their ids
+	Detach{{.Field}}(ctx context.Context, id string, {{toCamelCase .Entity}}IDs []string) error
+
+	// Sync{{.Field}} makes a record's {{.Field}} exactly the ones with the ids
+	Sync{{.Field}}(ctx context.Context, id string, {{toCamelCase .Entity}}IDs []string) error
+{{- end}}
+{{- if .HasRequests}}
+
+	// Update applies the request's set fields to a record by its id{{if .Versioned}},
+	// provided req.Version is still its version{{end}}
+	Update(ctx context.Context, id string, req *entity.Update{{.EntityName}}Request) (*entity.{{.EntityName}}, error)
+
+	// Bulk creates, updates or deletes many records
+	Bulk(ctx context.Context, req *bulk.Request) (*bulk.Result, error)
+
+	// BulkProcessor exposes the bulk processor, e.g. to offload large requests to a queue
+	BulkProcessor() *bulk.Processor
+{{- end}}
+{{end}}
 	// TODO: Add your repository methods here
 	// Example:
 	// SomeMethod(ctx context.Context) error
@@ -1972,19 +4436,408 @@ const repositoryTemplate = `package {{.PackageName}}
 
 import (
 	"context"
+{{- if .HasRequests}}
+	"encoding/json"
+{{- end}}
+{{- if .HasEntity}}
+
+	"flex-service/internal/entity"
+{{- if .HasRequests}}
+	"flex-service/pkg/bulk"
+{{- end}}
+	"flex-service/pkg/database"
+	"flex-service/pkg/errors"
+	"flex-service/pkg/integrity"
+	"flex-service/pkg/pagination"
+	"flex-service/pkg/query"
+{{- if .Searchable}}
+	"flex-service/pkg/search"
+{{- end}}
+{{- end}}
 
 	"gorm.io/gorm"
 )
 
 type {{toCamelCase .EntityName}}Repository struct {
 	db *gorm.DB
+{{- if .HasRequests}}
+	bulk *bulk.Processor
+{{- end}}
 }
 
 func New{{.EntityName}}Repository(db *gorm.DB) {{.EntityName}}Repository {
+{{- if .HasRequests}}
+	r := &{{toCamelCase .EntityName}}Repository{
+		db: db,
+	}
+
+	r.bulk = bulk.NewProcessor("{{.PackageName}}", db, nil)
+	bulk.Handle(r.bulk, bulk.Create, r.bulkCreate)
+	bulk.HandleBatch(r.bulk, bulk.Create, r.bulkCreateBatch)
+	bulk.Handle(r.bulk, bulk.Update, r.bulkUpdate)
+	bulk.Handle(r.bulk, bulk.Delete, r.bulkDelete)
+	return r
+{{- else}}
 	return &{{toCamelCase .EntityName}}Repository{
 		db: db,
 	}
+{{- end}}
+}
+
+{{- if .HasEntity}}
+
+// List returns a page of records matching the parsed query and the total count
+{{- if .Searchable}}.
+// With ?search, only records the search index matches are listed, by
+// relevance unless the query sorts them.
+{{- end}}
+func (r *{{toCamelCase .EntityName}}Repository) List(ctx context.Context, q *query.Query, p *pagination.Params) ([]entity.{{.EntityName}}, int64, error) {
+	var items []entity.{{.EntityName}}
+{{- if .Searchable}}
+
+	matches, rank := noScope, noScope
+	if q.Search != "" {
+		result, err := r.search(ctx, q.Search)
+		if err != nil {
+			return nil, 0, err
+		}
+		matches, rank = result.Filter("{{.KeyColumn}}"), result.Scope("{{.KeyColumn}}")
+	}
+
+	total, err := pagination.Count(q.ApplyFilters(r.db.WithContext(ctx).Scopes(matches)), &entity.{{.EntityName}}{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := r.db.WithContext(ctx).Scopes(q.Scope(), rank, pagination.Paginate(p)).Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+{{- else}}
+
+	total, err := pagination.Count(q.ApplyFilters(r.db.WithContext(ctx)), &entity.{{.EntityName}}{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := r.db.WithContext(ctx).Scopes(q.Scope(), pagination.Paginate(p)).Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+{{- end}}
+
+	return items, total, nil
+}
+{{- if .Searchable}}
+
+// search returns up to search.MaxHits records matching text; filters, sorts
+// and pages are applied to them in SQL
+func (r *{{toCamelCase .EntityName}}Repository) search(ctx context.Context, text string) (*search.Result, error) {
+	engine := search.Default()
+	if engine == nil {
+		return nil, errors.BadRequest("Search is not available")
+	}
+
+	result, err := engine.Search(ctx, entity.{{.EntityName}}{}.SearchIndex(), search.Query{Text: text, Limit: search.MaxHits})
+	if err != nil {
+		return nil, errors.WrapInternal(err, "Failed to search {{.PackageName}}")
+	}
+	return result, nil
+}
+
+func noScope(db *gorm.DB) *gorm.DB {
+	return db
+}
+{{- end}}
+
+// Delete removes a record after checking the relations registered with
+// integrity.Register, so references come back as a 409 listing them instead
+// of a foreign key constraint error
+func (r *{{toCamelCase .EntityName}}Repository) Delete(ctx context.Context, item *entity.{{.EntityName}}) error {
+	if err := integrity.CheckDelete(ctx, r.db, item); err != nil {
+		return err
+	}
+
+	if err := r.db.WithContext(ctx).Delete(item).Error; err != nil {
+		return errors.WrapDatabase(err, "Failed to delete {{.PackageName}}")
+	}
+	return nil
+}
+
+// Restore undeletes a soft-deleted record. A unique value a live record took
+// since the delete comes back as a 409 instead.
+func (r *{{toCamelCase .EntityName}}Repository) Restore(ctx context.Context, id string) (*entity.{{.EntityName}}, error) {
+	var record entity.{{.EntityName}}
+	if err := r.db.WithContext(ctx).Unscoped().Where("{{.KeyColumn}} = ? AND deleted_at IS NOT NULL", id).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.NotFound("Deleted {{.EntityName}} not found")
+		}
+		return nil, errors.WrapDatabase(err, "Failed to get {{.PackageName}}")
+	}
+{{- if .UniqueColumns}}
+
+	if err := integrity.CheckUnique(ctx, r.db, &record{{range .UniqueColumns}}, "{{.}}"{{end}}); err != nil {
+		return nil, err
+	}
+{{- end}}
+
+	if err := r.db.WithContext(ctx).Unscoped().Model(&record).Update("deleted_at", nil).Error; err != nil {
+		return nil, database.WrapError(err, "Failed to restore {{.PackageName}}")
+	}
+	record.DeletedAt = gorm.DeletedAt{}
+	return &record, nil
+}
+
+// ForceDelete permanently removes a record, soft-deleted or not, after the
+// same reference checks as Delete
+func (r *{{toCamelCase .EntityName}}Repository) ForceDelete(ctx context.Context, id string) error {
+	var record entity.{{.EntityName}}
+	if err := r.db.WithContext(ctx).Unscoped().Where("{{.KeyColumn}} = ?", id).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.NotFound("{{.EntityName}} not found")
+		}
+		return errors.WrapDatabase(err, "Failed to get {{.PackageName}}")
+	}
+
+	if err := integrity.CheckDelete(ctx, r.db, &record); err != nil {
+		return err
+	}
+	if err := r.db.WithContext(ctx).Unscoped().Delete(&record).Error; err != nil {
+		return errors.WrapDatabase(err, "Failed to delete {{.PackageName}}")
+	}
+	return nil
+}
+{{- range .Relations}}
+
+// Attach{{.Field}} adds {{.Field}} to a record by their ids, keeping the ones it has
+func (r *{{toCamelCase $.EntityName}}Repository) Attach{{.Field}}(ctx context.Context, id string, {{toCamelCase .Entity}}IDs []string) error {
+	return r.change{{.Field}}(ctx, id, {{toCamelCase .Entity}}IDs, func(association *gorm.Association, values []entity.{{.Entity}}) error {
+		if len(values) == 0 {
+			return nil
+		}
+		return association.Append(values)
+	})
+}
+
+// Detach{{.Field}} removes {{.Field}} from a record by their ids; the {{.Field}}
+// themselves stay
+func (r *{{toCamelCase $.EntityName}}Repository) Detach{{.Field}}(ctx context.Context, id string, {{toCamelCase .Entity}}IDs []string) error {
+	return r.change{{.Field}}(ctx, id, {{toCamelCase .Entity}}IDs, func(association *gorm.Association, values []entity.{{.Entity}}) error {
+		if len(values) == 0 {
+			return nil
+		}
+		return association.Delete(values)
+	})
+}
+
+// Sync{{.Field}} makes a record's {{.Field}} exactly the ones with the ids;
+// no ids detaches them all
+func (r *{{toCamelCase $.EntityName}}Repository) Sync{{.Field}}(ctx context.Context, id string, {{toCamelCase .Entity}}IDs []string) error {
+	return r.change{{.Field}}(ctx, id, {{toCamelCase .Entity}}IDs, func(association *gorm.Association, values []entity.{{.Entity}}) error {
+		return association.Replace(values)
+	})
+}
+
+// change{{.Field}} runs change on the {{.Field}} association of a record with
+// the {{.Field}} of the ids, in a transaction. An unknown id is a 404.
+func (r *{{toCamelCase $.EntityName}}Repository) change{{.Field}}(ctx context.Context, id string, ids []string, change func(*gorm.Association, []entity.{{.Entity}}) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var record entity.{{$.EntityName}}
+		if err := tx.Where("{{$.KeyColumn}} = ?", id).First(&record).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.NotFound("{{$.EntityName}} not found")
+			}
+			return errors.WrapDatabase(err, "Failed to get {{$.PackageName}}")
+		}
+
+		requested := make(map[string]bool, len(ids))
+		for _, key := range ids {
+			requested[key] = true
+		}
+		values := []entity.{{.Entity}}{}
+		if len(requested) > 0 {
+			if err := tx.Where("{{.KeyColumn}} IN ?", ids).Find(&values).Error; err != nil {
+				return errors.WrapDatabase(err, "Failed to get {{toLowerFirst .Field}}")
+			}
+		}
+		if len(values) != len(requested) {
+			return errors.NotFound("{{.Entity}} not found")
+		}
+
+		// Without hooks the record itself isn't saved, which needs a primary key
+		association := tx.Session(&gorm.Session{SkipHooks: true}).Model(&record).Association("{{.Field}}")
+		if err := change(association, values); err != nil {
+			return errors.WrapDatabase(err, "Failed to update {{$.PackageName}} {{toLowerFirst .Field}}")
+		}
+		return nil
+	})
+}
+{{- end}}
+{{- if .HasRequests}}
+
+// Update applies the request's set fields to a record in a transaction
+{{- if .Versioned}}. The
+// version is compared and swapped: of two clients that read the same version,
+// the second to write gets a 409 CONFLICT instead of overwriting the first.
+{{- end}}
+func (r *{{toCamelCase .EntityName}}Repository) Update(ctx context.Context, id string, req *entity.Update{{.EntityName}}Request) (*entity.{{.EntityName}}, error) {
+	var item *entity.{{.EntityName}}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		item, err = r.update(ctx, tx, id, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Bulk creates, updates or deletes many records
+func (r *{{toCamelCase .EntityName}}Repository) Bulk(ctx context.Context, req *bulk.Request) (*bulk.Result, error) {
+	return r.bulk.Run(ctx, req)
+}
+
+// BulkProcessor exposes the bulk processor, e.g. to offload large requests to a queue
+func (r *{{toCamelCase .EntityName}}Repository) BulkProcessor() *bulk.Processor {
+	return r.bulk
+}
+
+func (r *{{toCamelCase .EntityName}}Repository) bulkCreate(ctx context.Context, tx *gorm.DB, item *bulk.Item[entity.Create{{.EntityName}}Request]) (interface{}, error) {
+	record, err := newRecord(item.Data)
+	if err != nil {
+		return nil, err
+	}
+
+{{- if .UniqueColumns}}
+	if err := integrity.CheckUnique(ctx, tx, &record{{range .UniqueColumns}}, "{{.}}"{{end}}); err != nil {
+		return nil, err
+	}
+{{- end}}
+	if err := tx.Create(&record).Error; err != nil {
+		return nil, errors.WrapDatabase(err, "Failed to create {{.PackageName}}")
+	}
+	return record, nil
+}
+
+// bulkCreateBatch inserts every item with multi-row INSERTs of BatchSize rows.
+// Should one fail, say on a unique value, the processor creates the items one
+// by one with bulkCreate to report which.
+func (r *{{toCamelCase .EntityName}}Repository) bulkCreateBatch(ctx context.Context, tx *gorm.DB, items []*bulk.Item[entity.Create{{.EntityName}}Request]) ([]interface{}, error) {
+	records := make([]entity.{{.EntityName}}, len(items))
+	for i, item := range items {
+		record, err := newRecord(item.Data)
+		if err != nil {
+			return nil, err
+		}
+		records[i] = record
+	}
+
+	if err := tx.Session(&gorm.Session{CreateBatchSize: r.bulk.BatchSize()}).Create(&records).Error; err != nil {
+		return nil, errors.WrapDatabase(err, "Failed to create {{.PackageName}}")
+	}
+
+	data := make([]interface{}, len(records))
+	for i, record := range records {
+		data[i] = record
+	}
+	return data, nil
+}
+
+// newRecord maps a create request to the entity; they share JSON field names
+func newRecord(req *entity.Create{{.EntityName}}Request) (entity.{{.EntityName}}, error) {
+	var record entity.{{.EntityName}}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return record, errors.WrapInternal(err, "Failed to map {{.PackageName}}")
+	}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return record, errors.WrapInternal(err, "Failed to map {{.PackageName}}")
+	}
+	return record, nil
+}
+
+func (r *{{toCamelCase .EntityName}}Repository) bulkUpdate(ctx context.Context, tx *gorm.DB, item *bulk.Item[entity.Update{{.EntityName}}Request]) (interface{}, error) {
+	return r.update(ctx, tx, item.ID, item.Data)
+}
+
+func (r *{{toCamelCase .EntityName}}Repository) update(ctx context.Context, tx *gorm.DB, id interface{}, req *entity.Update{{.EntityName}}Request) (*entity.{{.EntityName}}, error) {
+	record, err := r.find(tx, id)
+	if err != nil {
+		return nil, err
+	}
+{{- if .UniqueColumns}}
+
+	// Check the record as it will be: the request's set fields over the current ones
+	updated := *record
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.WrapInternal(err, "Failed to map {{.PackageName}}")
+	}
+	if err := json.Unmarshal(data, &updated); err != nil {
+		return nil, errors.WrapInternal(err, "Failed to map {{.PackageName}}")
+	}
+	if err := integrity.CheckUnique(ctx, tx, &updated{{range .UniqueColumns}}, "{{.}}"{{end}}); err != nil {
+		return nil, err
+	}
+{{- end}}
+{{- if .Versioned}}
+
+	// Bump the version only if it is the one the client read. The row stays
+	// locked until the transaction ends, so a concurrent writer with the same
+	// version blocks here and then matches nothing.
+	result := tx.Model(record).Where("version = ?", req.Version).UpdateColumn("version", gorm.Expr("version + 1"))
+	if result.Error != nil {
+		return nil, errors.WrapDatabase(result.Error, "Failed to update {{.PackageName}}")
+	}
+	if result.RowsAffected == 0 {
+		current, err := r.find(tx, id)
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.Conflict("{{.EntityName}} was changed by someone else; reload it and try again").
+			WithDetails(map[string]interface{}{"version": current.Version})
+	}
+
+	// Nil fields of the request are left unchanged
+	if err := tx.Model(record).Omit("version").Updates(req).Error; err != nil {
+		return nil, database.WrapError(err, "Failed to update {{.PackageName}}")
+	}
+{{- else}}
+
+	// Nil fields of the request are left unchanged
+	if err := tx.Model(record).Updates(req).Error; err != nil {
+		return nil, database.WrapError(err, "Failed to update {{.PackageName}}")
+	}
+{{- end}}
+	return r.find(tx, id)
+}
+
+func (r *{{toCamelCase .EntityName}}Repository) bulkDelete(ctx context.Context, tx *gorm.DB, item *bulk.Item[struct{}]) (interface{}, error) {
+	record, err := r.find(tx, item.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := integrity.CheckDelete(ctx, tx, record); err != nil {
+		return nil, err
+	}
+	if err := tx.Delete(record).Error; err != nil {
+		return nil, errors.WrapDatabase(err, "Failed to delete {{.PackageName}}")
+	}
+	return nil, nil
+}
+
+func (r *{{toCamelCase .EntityName}}Repository) find(tx *gorm.DB, id interface{}) (*entity.{{.EntityName}}, error) {
+	var record entity.{{.EntityName}}
+	if err := tx.Where("{{.KeyColumn}} = ?", id).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.NotFound("{{.EntityName}} not found")
+		}
+		return nil, errors.WrapDatabase(err, "Failed to get {{.PackageName}}")
+	}
+	return &record, nil
 }
+{{- end}}
+{{- end}}
 
 // TODO: Add your repository methods here
 // Example:
@@ -1997,6 +4850,12 @@ const usecaseTemplate = `package {{.PackageName}}
 
 import (
 	"context"
+{{- if .HasEntity}}
+	"flex-service/internal/entity"
+{{- end}}
+{{- if .HasRequests}}
+	"flex-service/pkg/bulk"
+{{- end}}
 	"flex-service/pkg/errors"
 	"flex-service/pkg/logger"
 
@@ -2012,6 +4871,47 @@ func New{{.EntityName}}Usecase(repo {{.EntityName}}Repository) {{.EntityName}}Us
 		repo: repo,
 	}
 }
+{{- if .HasEntity}}
+
+// Restore undeletes a soft-deleted record
+func (u *{{toCamelCase .EntityName}}Usecase) Restore(ctx context.Context, id string) (*entity.{{.EntityName}}, error) {
+	item, err := u.repo.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("{{.EntityName}} restored", zap.String("id", id))
+	return item, nil
+}
+
+// ForceDelete permanently removes a record, soft-deleted or not
+func (u *{{toCamelCase .EntityName}}Usecase) ForceDelete(ctx context.Context, id string) error {
+	if err := u.repo.ForceDelete(ctx, id); err != nil {
+		return err
+	}
+
+	logger.Info("{{.EntityName}} permanently deleted", zap.String("id", id))
+	return nil
+}
+{{- end}}
+{{- if .HasRequests}}
+
+// Update applies the request's set fields{{if .Versioned}}; a stale req.Version is a 409 CONFLICT{{end}}
+func (u *{{toCamelCase .EntityName}}Usecase) Update(ctx context.Context, id string, req *entity.Update{{.EntityName}}Request) (*entity.{{.EntityName}}, error) {
+	item, err := u.repo.Update(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("{{.EntityName}} updated", zap.String("id", id))
+	return item, nil
+}
+
+// Bulk creates, updates or deletes many records
+func (u *{{toCamelCase .EntityName}}Usecase) Bulk(ctx context.Context, req *bulk.Request) (*bulk.Result, error) {
+	return u.repo.Bulk(ctx, req)
+}
+{{- end}}
 
 // TODO: Add your usecase methods here
 // Example:
@@ -2026,3 +4926,61 @@ func New{{.EntityName}}Usecase(repo {{.EntityName}}Repository) {{.EntityName}}Us
 //     return nil
 // }
 `
+
+const routesTemplate = `package {{.PackageName}}
+
+import (
+{{- if .HasEntity}}
+	"net/http"
+{{end}}
+	"flex-service/pkg/routes"
+)
+{{- if eq .Access "admin"}}
+
+// ManagePermission is required by every {{.PackageName}} route
+const ManagePermission = "{{.PackageName}}.manage"
+{{- else if .HasEntity}}
+
+// ManagePermission is required to restore and permanently delete records
+const ManagePermission = "{{.PackageName}}.manage"
+{{- end}}
+
+// Manifest declares the {{.PackageName}} routes.
+{{- if eq .Access "public"}} They are public: anyone can call
+// them, limited per IP. Use -auth or -admin for anything user-specific.
+{{- else if eq .Access "admin"}} Every route requires a signed-in user
+// with ManagePermission.
+{{- else}} Every route requires a signed-in user.
+{{- end}}
+var Manifest = routes.Manifest{
+	Module:     "{{.PackageName}}",
+	Prefix:     "{{.Prefix}}",
+	Middleware: []string{ {{- range $i, $m := .Middleware}}{{if $i}}, {{end}}"{{$m}}"{{end -}} },
+{{- if eq .Access "admin"}}
+	Permission: ManagePermission,
+{{- end}}
+	Routes: []routes.Route{
+{{- if .HasRequests}}
+		{Method: http.MethodPost, Path: "/bulk", Handler: "Bulk", Summary: "Create, update or delete many {{.PackageName}} records"},
+{{- end}}
+{{- if .HasEntity}}
+{{- if eq .Access "admin"}}
+		{Method: http.MethodPost, Path: "/:id/restore", Handler: "Restore", Summary: "Restore a deleted {{.PackageName}}"},
+		{Method: http.MethodDelete, Path: "/:id/force", Handler: "ForceDelete", Summary: "Permanently delete a {{.PackageName}}"},
+{{- else if eq .Access "public"}}
+		{Method: http.MethodPost, Path: "/:id/restore", Handler: "Restore", Middleware: []string{"auth"}, Permission: ManagePermission, Summary: "Restore a deleted {{.PackageName}}"},
+		{Method: http.MethodDelete, Path: "/:id/force", Handler: "ForceDelete", Middleware: []string{"auth"}, Permission: ManagePermission, Summary: "Permanently delete a {{.PackageName}}"},
+{{- else}}
+		{Method: http.MethodPost, Path: "/:id/restore", Handler: "Restore", Permission: ManagePermission, Summary: "Restore a deleted {{.PackageName}}"},
+		{Method: http.MethodDelete, Path: "/:id/force", Handler: "ForceDelete", Permission: ManagePermission, Summary: "Permanently delete a {{.PackageName}}"},
+{{- end}}
+{{- end}}
+{{- if .HasEntity}}
+{{end}}
+		// TODO: Declare a route per handler method
+		// Example:
+		// {Method: http.MethodGet, Path: "", Handler: "List", Summary: "List {{.PackageName}}"},
+		// {Method: http.MethodPost, Path: "", Handler: "Create", Summary: "Create a {{.PackageName}}"},
+	},
+}
+`
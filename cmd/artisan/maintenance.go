@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"flex-service/config"
+	"flex-service/pkg/cache"
+	"flex-service/pkg/maintenance"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// maintenanceDown stores the maintenance state, so every instance answers
+// 503 within MAINTENANCE_REFRESH
+func maintenanceDown(message string, retryAfter int, secret, allow string) {
+	cfg := config.Load()
+	store, closeStore := openMaintenanceStore(cfg)
+	defer closeStore()
+
+	state := &maintenance.State{
+		Message:    message,
+		RetryAfter: retryAfter,
+		Since:      time.Now().UTC(),
+	}
+	if secret != "" {
+		state.SecretHash = maintenance.HashSecret(secret)
+	}
+	for _, entry := range strings.Split(allow, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(entry); err != nil && net.ParseIP(entry) == nil {
+			fmt.Printf("❌ -allow has %q, which is neither an IP nor a CIDR\n", entry)
+			os.Exit(1)
+		}
+		state.AllowedIPs = append(state.AllowedIPs, entry)
+	}
+
+	ctx := context.Background()
+	if previous, err := store.Get(ctx); err == nil && previous != nil {
+		state.Since = previous.Since
+		fmt.Printf("⚠️  Already down since %s, updating the maintenance settings\n", previous.Since.Local().Format(time.RFC3339))
+	}
+	if err := store.Down(ctx, state); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("🚧 Application is down for maintenance (%s driver)\n", cfg.Maintenance.Driver)
+	if retryAfter > 0 {
+		fmt.Printf("⏱️  Clients are told to retry after %ds\n", retryAfter)
+	}
+	if secret != "" {
+		fmt.Printf("🔑 Bypass with the %s: %s header, or open any page with ?%s=%s once\n",
+			maintenance.BypassHeader, secret, maintenance.BypassCookie, secret)
+	}
+	if len(state.AllowedIPs) > 0 {
+		fmt.Printf("🌐 Allowed IPs: %s\n", strings.Join(state.AllowedIPs, ", "))
+	}
+	fmt.Printf("🔄 Every instance follows within %s; run -action=up to resume\n", cfg.Maintenance.Refresh)
+}
+
+// maintenanceUp removes the maintenance state
+func maintenanceUp() {
+	cfg := config.Load()
+	store, closeStore := openMaintenanceStore(cfg)
+	defer closeStore()
+
+	ctx := context.Background()
+	previous, err := store.Get(ctx)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	if previous == nil {
+		fmt.Println("ℹ️  Application is not in maintenance mode")
+		return
+	}
+	if err := store.Up(ctx); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Application is up after %s of maintenance\n", time.Since(previous.Since).Round(time.Second))
+	fmt.Printf("🔄 Every instance follows within %s\n", cfg.Maintenance.Refresh)
+}
+
+// openMaintenanceStore opens the MAINTENANCE_DRIVER store the instances read
+func openMaintenanceStore(cfg *config.Config) (maintenance.Store, func()) {
+	var client *redis.Client
+	if cfg.Maintenance.Driver == "redis" {
+		var err error
+		client, err = cache.NewRedisClient(&cfg.Redis)
+		if err != nil {
+			fmt.Printf("❌ Failed to connect to Redis: %v\n", err)
+			fmt.Println("   Set MAINTENANCE_DRIVER=file to use MAINTENANCE_FILE instead")
+			os.Exit(1)
+		}
+	}
+
+	store, err := maintenance.NewStore(cfg.Maintenance.Driver, cfg.Maintenance.File, client)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	return store, func() {
+		if client != nil {
+			client.Close()
+		}
+	}
+}
@@ -0,0 +1,624 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// make:package presets
+const (
+	presetRESTCrud      = "rest-crud"
+	presetReadonlyAPI   = "readonly-api"
+	presetEventConsumer = "event-consumer"
+)
+
+// packagePreset selects the files make:package generates for a kind of module
+type packagePreset struct {
+	description string
+	files       []packageFile
+	// routes presets generate a manifest and take -public, -auth or -admin
+	routes bool
+	// needsEntity presets query the entity make:model generates in
+	// internal/entity/<name>.go
+	needsEntity bool
+	// messages returns the locales/en.json messages under the package's section
+	messages func(entityName string) map[string]string
+}
+
+// packageFile is one generated file of a preset
+type packageFile struct {
+	name     string
+	template string
+}
+
+var packagePresets = map[string]packagePreset{
+	presetRESTCrud: {
+		description: "CRUD module: handler, usecase, repository and routes with bulk, restore and force delete",
+		files: []packageFile{
+			{"handler.go", handlerTemplate},
+			{"module.go", moduleTemplate},
+			{"port.go", portTemplate},
+			{"repository.go", repositoryTemplate},
+			{"routes.go", routesTemplate},
+			{"usecase.go", usecaseTemplate},
+		},
+		routes: true,
+		messages: func(entityName string) map[string]string {
+			return map[string]string{
+				"retrieved": entityName + " retrieved successfully",
+				"created":   entityName + " created successfully",
+				"updated":   entityName + " updated successfully",
+				"deleted":   entityName + " deleted successfully",
+				"restored":  entityName + " restored successfully",
+				"not_found": entityName + " not found",
+				"bulk":      "Bulk " + entityName + " request processed",
+			}
+		},
+	},
+	presetReadonlyAPI: {
+		description: "Reporting or lookup module: cached list and get endpoints, no writes",
+		files: []packageFile{
+			{"handler.go", readonlyHandlerTemplate},
+			{"module.go", moduleTemplate},
+			{"port.go", readonlyPortTemplate},
+			{"repository.go", readonlyRepositoryTemplate},
+			{"routes.go", readonlyRoutesTemplate},
+			{"usecase.go", readonlyUsecaseTemplate},
+		},
+		routes:      true,
+		needsEntity: true,
+		messages: func(entityName string) map[string]string {
+			return map[string]string{
+				"listed":    pluralize(entityName) + " retrieved successfully",
+				"retrieved": entityName + " retrieved successfully",
+				"not_found": entityName + " not found",
+			}
+		},
+	},
+	presetEventConsumer: {
+		description: "Consumer-only module: event bus and queue handlers, no HTTP routes",
+		files: []packageFile{
+			{"consumer.go", consumerTemplate},
+			{"module.go", consumerModuleTemplate},
+			{"port.go", consumerPortTemplate},
+			{"repository.go", consumerRepositoryTemplate},
+			{"usecase.go", consumerUsecaseTemplate},
+		},
+	},
+}
+
+// lookupPreset returns the named preset, rest-crud when name is empty
+func lookupPreset(name string) (packagePreset, error) {
+	if name == "" {
+		name = presetRESTCrud
+	}
+	preset, ok := packagePresets[name]
+	if !ok {
+		return packagePreset{}, fmt.Errorf("unknown preset %q, use one of: %s", name, strings.Join(presetNames(), ", "))
+	}
+	return preset, nil
+}
+
+func presetNames() []string {
+	names := make([]string, 0, len(packagePresets))
+	for name := range packagePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+const readonlyHandlerTemplate = `package {{.PackageName}}
+
+import (
+	"net/http"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/i18n"
+	"flex-service/pkg/pagination"
+	"flex-service/pkg/query"
+	"flex-service/pkg/response"
+	"flex-service/pkg/serializer"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Translation keys for {{.PackageName}} responses, defined in locales/<lang>.json
+const (
+	msg{{.EntityName}}Listed    = "{{.PackageName}}.listed"
+	msg{{.EntityName}}Retrieved = "{{.PackageName}}.retrieved"
+	msg{{.EntityName}}NotFound  = "{{.PackageName}}.not_found"
+)
+
+// {{.EntityName}}Handler serves read-only {{.PackageName}} endpoints
+type {{.EntityName}}Handler struct {
+	usecase {{.EntityName}}Usecase
+}
+
+func New{{.EntityName}}Handler(usecase {{.EntityName}}Usecase) *{{.EntityName}}Handler {
+	return &{{.EntityName}}Handler{
+		usecase: usecase,
+	}
+}
+
+// List returns a page of records (GET {{.Prefix}}), filtered, sorted and
+// selected with ?filter[...], ?sort and ?fields. Fields the principal's roles
+// may not see are left out.
+func (h *{{.EntityName}}Handler) List(c *gin.Context) {
+	q, err := query.Bind(c, entity.{{.EntityName}}QueryOptions)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	p, err := pagination.Bind(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	items, total, err := h.usecase.List(c.Request.Context(), q, p)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	data := serializer.Serialize(c.Request.Context(), items, q.Fields...)
+	response.Paginated(c, i18n.T(i18n.Locale(c), msg{{.EntityName}}Listed, nil), data, pagination.NewOffsetMeta(p, total).ToResponseMeta())
+}
+
+// Get returns one record (GET {{.Prefix}}/:id), trimmed with ?fields
+func (h *{{.EntityName}}Handler) Get(c *gin.Context) {
+	item, err := h.usecase.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, i18n.T(i18n.Locale(c), msg{{.EntityName}}Retrieved, nil), serializer.Serialize(c.Request.Context(), item, serializer.Fields(c)...))
+}
+`
+
+const readonlyPortTemplate = `package {{.PackageName}}
+
+import (
+	"context"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/pagination"
+	"flex-service/pkg/query"
+)
+
+// {{.EntityName}}Usecase defines the read-only business logic for {{.PackageName}}
+type {{.EntityName}}Usecase interface {
+	// List returns a page of records matching the parsed query and the total count
+	List(ctx context.Context, q *query.Query, p *pagination.Params) ([]entity.{{.EntityName}}, int64, error)
+
+	// Get returns one record, or NOT_FOUND
+	Get(ctx context.Context, id string) (*entity.{{.EntityName}}, error)
+}
+
+// {{.EntityName}}Repository defines the read-only data access for {{.PackageName}}
+type {{.EntityName}}Repository interface {
+	// List returns a page of records matching the parsed query and the total count
+	List(ctx context.Context, q *query.Query, p *pagination.Params) ([]entity.{{.EntityName}}, int64, error)
+
+	// Find returns a record by its id, or NOT_FOUND
+	Find(ctx context.Context, id string) (*entity.{{.EntityName}}, error)
+}
+`
+
+const readonlyRepositoryTemplate = `package {{.PackageName}}
+
+import (
+	"context"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/errors"
+	"flex-service/pkg/pagination"
+	"flex-service/pkg/query"
+{{- if .Searchable}}
+	"flex-service/pkg/search"
+{{- end}}
+
+	"gorm.io/gorm"
+)
+
+type {{toCamelCase .EntityName}}Repository struct {
+	db *gorm.DB
+}
+
+// New{{.EntityName}}Repository creates the repository. Pass a read replica
+// connection when one is available; it never writes.
+func New{{.EntityName}}Repository(db *gorm.DB) {{.EntityName}}Repository {
+	return &{{toCamelCase .EntityName}}Repository{
+		db: db,
+	}
+}
+
+// List returns a page of records matching the parsed query and the total count
+{{- if .Searchable}}.
+// With ?search, only records the search index matches are listed, by
+// relevance unless the query sorts them.
+{{- end}}
+func (r *{{toCamelCase .EntityName}}Repository) List(ctx context.Context, q *query.Query, p *pagination.Params) ([]entity.{{.EntityName}}, int64, error) {
+	var items []entity.{{.EntityName}}
+{{- if .Searchable}}
+
+	matches, rank := noScope, noScope
+	if q.Search != "" {
+		result, err := r.search(ctx, q.Search)
+		if err != nil {
+			return nil, 0, err
+		}
+		matches, rank = result.Filter("{{.KeyColumn}}"), result.Scope("{{.KeyColumn}}")
+	}
+
+	total, err := pagination.Count(q.ApplyFilters(r.db.WithContext(ctx).Scopes(matches)), &entity.{{.EntityName}}{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := r.db.WithContext(ctx).Scopes(q.Scope(), rank, pagination.Paginate(p)).Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+{{- else}}
+
+	total, err := pagination.Count(q.ApplyFilters(r.db.WithContext(ctx)), &entity.{{.EntityName}}{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := r.db.WithContext(ctx).Scopes(q.Scope(), pagination.Paginate(p)).Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+{{- end}}
+
+	return items, total, nil
+}
+{{- if .Searchable}}
+
+// search returns up to search.MaxHits records matching text; filters, sorts
+// and pages are applied to them in SQL
+func (r *{{toCamelCase .EntityName}}Repository) search(ctx context.Context, text string) (*search.Result, error) {
+	engine := search.Default()
+	if engine == nil {
+		return nil, errors.BadRequest("Search is not available")
+	}
+
+	result, err := engine.Search(ctx, entity.{{.EntityName}}{}.SearchIndex(), search.Query{Text: text, Limit: search.MaxHits})
+	if err != nil {
+		return nil, errors.WrapInternal(err, "Failed to search {{.PackageName}}")
+	}
+	return result, nil
+}
+
+func noScope(db *gorm.DB) *gorm.DB {
+	return db
+}
+{{- end}}
+
+// Find returns a record by its id
+func (r *{{toCamelCase .EntityName}}Repository) Find(ctx context.Context, id string) (*entity.{{.EntityName}}, error) {
+	var record entity.{{.EntityName}}
+	if err := r.db.WithContext(ctx).Where("{{.KeyColumn}} = ?", id).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.NotFound("{{.EntityName}} not found")
+		}
+		return nil, errors.WrapDatabase(err, "Failed to get {{.PackageName}}")
+	}
+	return &record, nil
+}
+`
+
+const readonlyUsecaseTemplate = `package {{.PackageName}}
+
+import (
+	"context"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/pagination"
+	"flex-service/pkg/query"
+)
+
+type {{toCamelCase .EntityName}}Usecase struct {
+	repo {{.EntityName}}Repository
+}
+
+func New{{.EntityName}}Usecase(repo {{.EntityName}}Repository) {{.EntityName}}Usecase {
+	return &{{toCamelCase .EntityName}}Usecase{
+		repo: repo,
+	}
+}
+
+// List returns a page of records matching the parsed query and the total count
+func (u *{{toCamelCase .EntityName}}Usecase) List(ctx context.Context, q *query.Query, p *pagination.Params) ([]entity.{{.EntityName}}, int64, error) {
+	return u.repo.List(ctx, q, p)
+}
+
+// Get returns one record
+func (u *{{toCamelCase .EntityName}}Usecase) Get(ctx context.Context, id string) (*entity.{{.EntityName}}, error) {
+	return u.repo.Find(ctx, id)
+}
+`
+
+const readonlyRoutesTemplate = `package {{.PackageName}}
+
+import (
+	"net/http"
+
+	"flex-service/pkg/routes"
+)
+{{- if eq .Access "admin"}}
+
+// ManagePermission is required by every {{.PackageName}} route
+const ManagePermission = "{{.PackageName}}.manage"
+{{- end}}
+
+// Manifest declares the read-only {{.PackageName}} routes. Responses are cached
+// for a minute under the "{{.PackageName}}" tag; invalidate it wherever the
+// records are written (cache.invalidate:{{.PackageName}}).
+{{- if eq .Access "public"}} They are public:
+// anyone can call them, limited per IP.
+{{- else if eq .Access "admin"}} Every route
+// requires a signed-in user with ManagePermission.
+{{- else}} Every route
+// requires a signed-in user.
+{{- end}}
+var Manifest = routes.Manifest{
+	Module:     "{{.PackageName}}",
+	Prefix:     "{{.Prefix}}",
+	Middleware: []string{ {{- range $i, $m := .Middleware}}{{if $i}}, {{end}}"{{$m}}"{{end -}} },
+{{- if eq .Access "admin"}}
+	Permission: ManagePermission,
+{{- end}}
+	Routes: []routes.Route{
+		{Method: http.MethodGet, Path: "", Handler: "List", Middleware: []string{"cache:1m,{{.PackageName}}"}, Summary: "List {{.PackageName}}"},
+		{Method: http.MethodGet, Path: "/:id", Handler: "Get", Middleware: []string{"cache:1m,{{.PackageName}}"}, Summary: "Get a {{.PackageName}}"},
+	},
+}
+`
+
+const consumerTemplate = `package {{.PackageName}}
+
+import (
+	"context"
+	"fmt"
+
+	"flex-service/pkg/event"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/queue"
+
+	"go.uber.org/zap"
+)
+
+// Events consumed by {{.PackageName}}, published with container.Events.Publish
+const (
+	// TODO: Name the events this module reacts to
+	Event{{.EntityName}}Example = "{{.PackageName}}.example"
+)
+
+// JobType{{.EntityName}} is the queue job type handled by {{.PackageName}}
+const JobType{{.EntityName}} = "{{.PackageName}}"
+
+// {{.EntityName}}Consumer handles {{.PackageName}} events from the bus and jobs
+// from the queue; it serves no HTTP routes
+type {{.EntityName}}Consumer struct {
+	usecase {{.EntityName}}Usecase
+}
+
+func New{{.EntityName}}Consumer(usecase {{.EntityName}}Usecase) *{{.EntityName}}Consumer {
+	return &{{.EntityName}}Consumer{
+		usecase: usecase,
+	}
+}
+
+// Subscribe registers the event handlers on the bus
+func (c *{{.EntityName}}Consumer) Subscribe(bus event.Bus) {
+	bus.Subscribe(Event{{.EntityName}}Example, c.handleExample)
+}
+
+// JobHandler processes queued {{.PackageName}} jobs:
+//
+//	worker.RegisterHandler({{.PackageName}}.JobType{{.EntityName}}, consumer.JobHandler())
+func (c *{{.EntityName}}Consumer) JobHandler() queue.Handler {
+	return queue.HandlerFunc(func(ctx context.Context, job *queue.Job) *queue.JobResult {
+		if err := c.usecase.Process(ctx, job.Payload); err != nil {
+			logger.Error("{{.EntityName}} job failed", zap.String("job_id", job.ID), zap.Error(err))
+			return &queue.JobResult{Success: false, Error: err.Error()}
+		}
+		return &queue.JobResult{Success: true}
+	})
+}
+
+func (c *{{.EntityName}}Consumer) handleExample(ctx context.Context, e event.Event) error {
+	payload, ok := e.Payload.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected %s payload %T", e.Name, e.Payload)
+	}
+	return c.usecase.Process(ctx, payload)
+}
+`
+
+const consumerPortTemplate = `package {{.PackageName}}
+
+import (
+	"context"
+)
+
+// {{.EntityName}}Usecase defines the business logic run by the {{.PackageName}} consumer
+type {{.EntityName}}Usecase interface {
+	// Process handles one event or job payload. Returning an error fails the
+	// job, which the queue retries.
+	Process(ctx context.Context, payload map[string]interface{}) error
+}
+
+// {{.EntityName}}Repository defines the data access interface for {{.PackageName}}
+type {{.EntityName}}Repository interface {
+	// TODO: Add your repository methods here
+	// Example:
+	// SomeMethod(ctx context.Context) error
+}
+`
+
+const consumerRepositoryTemplate = `package {{.PackageName}}
+
+import (
+	"gorm.io/gorm"
+)
+
+type {{toCamelCase .EntityName}}Repository struct {
+	db *gorm.DB
+}
+
+func New{{.EntityName}}Repository(db *gorm.DB) {{.EntityName}}Repository {
+	return &{{toCamelCase .EntityName}}Repository{
+		db: db,
+	}
+}
+
+// TODO: Add your repository methods here
+// Example:
+// func (r *{{toCamelCase .EntityName}}Repository) SomeMethod(ctx context.Context) error {
+//     return r.db.WithContext(ctx).Error
+// }
+`
+
+const consumerUsecaseTemplate = `package {{.PackageName}}
+
+import (
+	"context"
+
+	"flex-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+type {{toCamelCase .EntityName}}Usecase struct {
+	repo {{.EntityName}}Repository
+}
+
+func New{{.EntityName}}Usecase(repo {{.EntityName}}Repository) {{.EntityName}}Usecase {
+	return &{{toCamelCase .EntityName}}Usecase{
+		repo: repo,
+	}
+}
+
+// Process handles one event or job payload. Handlers may see the same payload
+// twice (queue retries), so make the work idempotent.
+func (u *{{toCamelCase .EntityName}}Usecase) Process(ctx context.Context, payload map[string]interface{}) error {
+	// TODO: Implement the consumer's work
+	logger.Info("Processing {{.PackageName}} payload", zap.Any("payload", payload))
+	return nil
+}
+`
+
+const moduleTemplate = `package {{.PackageName}}
+
+import (
+	"flex-service/internal/container"
+{{- if .Searchable}}
+	"flex-service/internal/entity"
+{{- end}}
+	"flex-service/pkg/routes"
+{{- if .Searchable}}
+	"flex-service/pkg/search"
+{{- end}}
+)
+
+// Bindings of the {{.PackageName}} module, resolvable by other modules with
+// container.ResolveAs
+const (
+	BindingRepository = "{{.PackageName}}.repository"
+	BindingUsecase    = "{{.PackageName}}.usecase"
+	BindingHandler    = "{{.PackageName}}.handler"
+)
+
+// Registers the module's bindings and mounts Manifest under /api/v1. The blank
+// import in internal/modules compiles it in.
+func init() {
+	container.Register(container.Module{
+		Name: "{{.PackageName}}",
+		Providers: map[string]container.Provider{
+			BindingRepository: func(r *container.Resolver) (interface{}, error) {
+				return New{{.EntityName}}Repository(r.DB), nil
+			},
+			BindingUsecase: func(r *container.Resolver) (interface{}, error) {
+				repo, err := container.ResolveAs[{{.EntityName}}Repository](r, BindingRepository)
+				if err != nil {
+					return nil, err
+				}
+				return New{{.EntityName}}Usecase(repo), nil
+			},
+			BindingHandler: func(r *container.Resolver) (interface{}, error) {
+				usecase, err := container.ResolveAs[{{.EntityName}}Usecase](r, BindingUsecase)
+				if err != nil {
+					return nil, err
+				}
+				return New{{.EntityName}}Handler(usecase), nil
+			},
+		},
+		Manifests: []routes.Manifest{Manifest},
+		Handlers:  map[string]string{"{{.PackageName}}": BindingHandler},
+		{{- if .Searchable}}
+		// Keeps the {{.EntityName}} search index in sync with every write
+		Boot: func(r *container.Resolver) error {
+			if r.Search == nil {
+				return nil
+			}
+			return search.Register[entity.{{.EntityName}}](r.Search, r.Observers, entity.{{.EntityName}}SearchSettings)
+		},
+		{{- end}}
+	})
+}
+`
+
+const consumerModuleTemplate = `package {{.PackageName}}
+
+import (
+	"flex-service/internal/container"
+)
+
+// Bindings of the {{.PackageName}} module, resolvable by other modules with
+// container.ResolveAs
+const (
+	BindingRepository = "{{.PackageName}}.repository"
+	BindingUsecase    = "{{.PackageName}}.usecase"
+	BindingConsumer   = "{{.PackageName}}.consumer"
+)
+
+// Registers the module's bindings and subscribes the consumer to the event
+// bus. The blank import in internal/modules compiles it in.
+func init() {
+	container.Register(container.Module{
+		Name: "{{.PackageName}}",
+		Providers: map[string]container.Provider{
+			BindingRepository: func(r *container.Resolver) (interface{}, error) {
+				return New{{.EntityName}}Repository(r.DB), nil
+			},
+			BindingUsecase: func(r *container.Resolver) (interface{}, error) {
+				repo, err := container.ResolveAs[{{.EntityName}}Repository](r, BindingRepository)
+				if err != nil {
+					return nil, err
+				}
+				return New{{.EntityName}}Usecase(repo), nil
+			},
+			BindingConsumer: func(r *container.Resolver) (interface{}, error) {
+				usecase, err := container.ResolveAs[{{.EntityName}}Usecase](r, BindingUsecase)
+				if err != nil {
+					return nil, err
+				}
+				return New{{.EntityName}}Consumer(usecase), nil
+			},
+		},
+		Boot: func(r *container.Resolver) error {
+			consumer, err := container.ResolveAs[*{{.EntityName}}Consumer](r, BindingConsumer)
+			if err != nil {
+				return err
+			}
+			consumer.Subscribe(r.Events)
+			return nil
+		},
+	})
+}
+`
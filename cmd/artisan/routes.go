@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"flex-service/config"
+	"flex-service/internal/container"
+	"flex-service/internal/router"
+	"flex-service/pkg/logger"
+
+	// Import to mount the routes of self-registering modules
+	_ "flex-service/internal/modules"
+)
+
+// listRoutes builds the container and router without serving and prints every
+// route with its middleware and authentication, as a table or JSON
+func listRoutes(format, filter string) {
+	if format != "table" && format != "json" {
+		fmt.Printf("❌ Unknown format %q, use table or json\n", format)
+		os.Exit(1)
+	}
+
+	cfg := config.Load()
+
+	// Startup logs would mix with the listing
+	level := "warn"
+	if format == "json" {
+		level = "error"
+	}
+	if err := logger.Init(level, cfg.Log.Format); err != nil {
+		fmt.Printf("❌ Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	c, err := container.NewContainer(cfg)
+	if err != nil {
+		fmt.Printf("❌ Failed to create container: %v\n", err)
+		os.Exit(1)
+	}
+	defer c.Close()
+
+	table := router.Inspect(c)
+	if filter != "" {
+		var matched []router.RouteInfo
+		for _, route := range table.Routes {
+			if strings.Contains(route.Path, filter) {
+				matched = append(matched, route)
+			}
+		}
+		table.Routes = matched
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(table); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Printf("🌐 Global middleware: %s\n\n", strings.Join(table.Global, " → "))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATH\tHANDLER\tAUTH\tPERMISSION\tMIDDLEWARE")
+	public := 0
+	for _, route := range table.Routes {
+		middleware := strings.Join(route.Middleware, ", ")
+		if middleware == "" {
+			middleware = "-"
+		}
+		// Routes mounted before the last global middleware skip it
+		if route.GlobalMiddleware < len(table.Global) {
+			middleware = fmt.Sprintf("(%d/%d global) %s", route.GlobalMiddleware, len(table.Global), middleware)
+		}
+		permission := route.Permission
+		if permission == "" {
+			permission = "-"
+		}
+		if route.Auth[0] == "public" {
+			public++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			route.Method, route.Path, route.Handler, strings.Join(route.Auth, "+"), permission, middleware)
+	}
+	w.Flush()
+
+	fmt.Printf("\n📋 %d routes, %d public\n", len(table.Routes), public)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"flex-service/config"
+	"flex-service/internal/container"
+	"flex-service/pkg/logger"
+)
+
+// reindexSearch rebuilds a search index, or every registered one, from the
+// database. Searches find fewer results while an index is rebuilt.
+func reindexSearch(index string) {
+	cfg := config.Load()
+	if err := logger.Init("warn", cfg.Log.Format); err != nil {
+		fmt.Printf("❌ Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	c, err := container.NewContainer(cfg)
+	if err != nil {
+		fmt.Printf("❌ Failed to create container: %v\n", err)
+		os.Exit(1)
+	}
+	defer c.Close()
+
+	if c.Search == nil {
+		fmt.Println("❌ Search is disabled, set SEARCH_DRIVER and SEARCH_URL")
+		os.Exit(1)
+	}
+
+	indexes := c.Search.Indexes()
+	if index != "" {
+		indexes = []string{index}
+	}
+	if len(indexes) == 0 {
+		fmt.Println("⚠️  No searchable entities are registered (make:model -searchable, then make:package)")
+		return
+	}
+
+	failed := false
+	for _, name := range indexes {
+		start := time.Now()
+		indexed, err := c.Search.Reindex(context.Background(), name)
+		if err != nil {
+			fmt.Printf("❌ %s: %v (%d documents indexed)\n", name, err, indexed)
+			failed = true
+			continue
+		}
+		fmt.Printf("✅ %s: %d documents indexed in %s\n", name, indexed, time.Since(start).Round(time.Millisecond))
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"flex-service/config"
+	pkgDatabase "flex-service/pkg/database"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/secure"
+)
+
+// rotateEncryptionKey re-encrypts the encrypted columns of a table with the
+// current ENCRYPTION_KEY, including plaintext rows from before the columns
+// were encrypted
+func rotateEncryptionKey(tableName, columnList, primaryKey string) {
+	var columns []string
+	for _, column := range strings.Split(columnList, ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			columns = append(columns, column)
+		}
+	}
+	if tableName == "" || len(columns) == 0 {
+		fmt.Println("❌ Table and columns are required")
+		fmt.Println("Usage: go run cmd/artisan/main.go -action=secure:rotate-key -table=tb_user -columns=phone,address [-primary-key=id]")
+		os.Exit(1)
+	}
+
+	cfg := config.Load()
+	if err := logger.Init(cfg.Log.Level, cfg.Log.Format); err != nil {
+		fmt.Printf("❌ Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	fields, err := secure.NewFieldCipher(&cfg.Secure)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	db, err := pkgDatabase.NewDatabaseFactory().CreateDatabase(cfg.GetDatabaseConfig())
+	if err != nil {
+		fmt.Printf("❌ Failed to connect to %s database: %v\n", cfg.Database.Type, err)
+		os.Exit(1)
+	}
+
+	// Prevent two runs from re-encrypting the same rows
+	acquireCommandLock(cfg, "secure:rotate-key:"+tableName)
+	defer releaseCommandLocks()
+
+	fmt.Printf("🔐 Re-encrypting %s (%s) with key version %d...\n", tableName, strings.Join(columns, ", "), fields.Version())
+	updated, err := fields.Rotate(context.Background(), db.GetDB(), secure.RotateOptions{
+		Table:      tableName,
+		PrimaryKey: primaryKey,
+		Columns:    columns,
+	})
+	if err != nil {
+		fmt.Printf("❌ Rotation stopped after %d rows: %v\n", updated, err)
+		exit(1)
+	}
+
+	fmt.Printf("✅ %d rows re-encrypted\n", updated)
+	if len(cfg.Secure.PreviousKeys) > 0 {
+		fmt.Println("🗝️  Remove a key from ENCRYPTION_PREVIOUS_KEYS once every table encrypted with it is rotated")
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// projectModule is the module path in the project's go.mod
+const projectModule = "flex-service"
+
+// enterProjectRoot changes to the project root, so every relative path
+// (internal/migrations, internal/entity, .env, config/) resolves the same
+// wherever artisan is run from. Outside the project it exits rather than
+// writing files into the wrong directory.
+func enterProjectRoot() {
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Printf("❌ Failed to get the working directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	root, err := findProjectRoot(cwd)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		fmt.Println("   Run artisan from the project directory or one of its subdirectories")
+		os.Exit(1)
+	}
+	if root == cwd {
+		return
+	}
+
+	if err := os.Chdir(root); err != nil {
+		fmt.Printf("❌ Failed to change to the project root %s: %v\n", root, err)
+		os.Exit(1)
+	}
+	fmt.Printf("📂 Project root: %s\n", root)
+}
+
+// findProjectRoot walks up from dir to the directory whose go.mod declares
+// projectModule. go.mod files of other modules on the way, e.g. a nested
+// tools module, are skipped.
+func findProjectRoot(dir string) (string, error) {
+	for current := dir; ; {
+		module, err := goModModule(filepath.Join(current, "go.mod"))
+		if err == nil && module == projectModule {
+			return current, nil
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", fmt.Errorf("no go.mod for module %s found in %s or its parents", projectModule, dir)
+		}
+		current = parent
+	}
+}
+
+// goModModule returns the module path declared in a go.mod file
+func goModModule(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if module, ok := strings.CutPrefix(line, "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`), nil
+		}
+	}
+	return "", scanner.Err()
+}
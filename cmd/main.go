@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"os"
@@ -12,12 +13,17 @@ import (
 	"flex-service/config"
 	"flex-service/internal/container"
 	"flex-service/internal/router"
+	"flex-service/pkg/errors"
+	"flex-service/pkg/i18n"
+	"flex-service/pkg/listener"
 	"flex-service/pkg/logger"
+	"flex-service/pkg/response"
 
 	appTime "flex-service/pkg/time"
 
-	// Import to register migrations and seeders
+	// Import to register migrations, seeders and modules
 	_ "flex-service/internal/migrations"
+	_ "flex-service/internal/modules"
 	_ "flex-service/internal/seeders"
 
 	"go.uber.org/zap"
@@ -46,6 +52,25 @@ func main() {
 		logger.Fatal("Failed to initialize timezone", zap.Error(err))
 	}
 
+	// Capture stack traces on AppErrors in development only
+	errors.EnableStackTraces(cfg.Env == "development")
+
+	// Configure response rendering
+	response.Configure(response.Config{
+		ErrorFormat:        response.ErrorFormat(cfg.Response.ErrorFormat),
+		ProblemTypeBaseURL: cfg.Response.ProblemTypeBaseURL,
+	})
+
+	// Load translations; files in I18N_DIR override the built-in en/th messages
+	i18n.Default().SetDefaultLocale(cfg.I18n.DefaultLocale)
+	if _, err := os.Stat(cfg.I18n.Dir); err == nil {
+		if err := i18n.Default().LoadDir(cfg.I18n.Dir); err != nil {
+			logger.Fatal("Failed to load translations",
+				zap.String("dir", cfg.I18n.Dir),
+				zap.Error(err))
+		}
+	}
+
 	// Initialize dependency injection container (includes database setup)
 	containerInstance, err := container.NewContainer(cfg)
 	if err != nil {
@@ -77,40 +102,199 @@ func main() {
 		WriteTimeout: cfg.Server.WriteTimeout,
 	}
 
-	// Start server in a goroutine
-	go func() {
-		logger.Info("Server starting",
-			zap.String("address", server.Addr),
-			zap.String("database", string(containerInstance.GetDatabaseType())),
-		)
+	// Serve HTTPS from certificate files or Let's Encrypt (and verify client
+	// certificates when a client CA bundle is set)
+	var redirect *http.Server
+	if cfg.Server.TLSEnabled() {
+		tlsConfig, manager, err := serverTLSConfig(cfg.Server)
+		if err != nil {
+			logger.Fatal("Failed to configure TLS", zap.Error(err))
+		}
+		server.TLSConfig = tlsConfig
+
+		if cfg.Server.RedirectPort > 0 {
+			redirect = redirectServer(cfg.Server, manager)
+		} else if manager != nil {
+			logger.Warn("SERVER_REDIRECT_PORT is disabled; autocert can only use TLS-ALPN challenges")
+		}
+	}
+
+	// HTTP/2 is negotiated automatically over TLS; an empty TLSNextProto turns it off
+	if !cfg.Server.HTTP2 {
+		server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+
+	// Listen on TCP, a Unix socket or a systemd-activated socket. After a graceful
+	// restart the listeners are inherited from the previous process instead.
+	upgrader, err := listener.NewUpgrader(listener.UpgradeConfig{
+		PIDFile:      cfg.Server.PIDFile,
+		ReadyTimeout: cfg.Server.UpgradeTimeout,
+	})
+	if err != nil {
+		logger.Fatal("Failed to inherit listeners", zap.Error(err))
+	}
+
+	listenConfig := cfg.Server.Listener()
+	ln, err := upgrader.Listen("http", listenConfig)
+	if err != nil {
+		logger.Fatal("Failed to listen",
+			zap.String("address", listenConfig.Describe()),
+			zap.Error(err))
+	}
+
+	// The servers are appended last so they stop first, before the workers
+	// and connections their handlers use
+	containerInstance.Lifecycle.Append(container.Hook{
+		Name: "http server",
+		OnStart: func(context.Context) error {
+			go func() {
+				logger.Info("Server starting",
+					zap.String("address", listenConfig.Describe()),
+					zap.String("database", string(containerInstance.GetDatabaseType())),
+					zap.Bool("tls", server.TLSConfig != nil),
+					zap.Bool("http2", server.TLSConfig != nil && cfg.Server.HTTP2),
+				)
 
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Fatal("Failed to start server", zap.Error(err))
+				var err error
+				if server.TLSConfig != nil {
+					// Certificates come from TLSConfig (loaded files or autocert)
+					err = server.ServeTLS(ln, "", "")
+				} else {
+					err = server.Serve(ln)
+				}
+				if err != nil && err != http.ErrServerClosed {
+					logger.Fatal("Failed to start server", zap.Error(err))
+				}
+			}()
+			return nil
+		},
+		OnStop:  server.Shutdown,
+		Timeout: cfg.Server.ShutdownTimeout,
+	})
+
+	// Redirect plain HTTP to HTTPS
+	if redirect != nil {
+		redirectLn, err := upgrader.Listen("redirect", listener.Config{Address: redirect.Addr})
+		if err != nil {
+			logger.Fatal("Failed to listen", zap.String("address", redirect.Addr), zap.Error(err))
 		}
-	}()
+
+		containerInstance.Lifecycle.Append(container.Hook{
+			Name: "http redirect server",
+			OnStart: func(context.Context) error {
+				go func() {
+					logger.Info("HTTP redirect server starting", zap.String("address", redirect.Addr))
+					if err := redirect.Serve(redirectLn); err != nil && err != http.ErrServerClosed {
+						logger.Fatal("Failed to start redirect server", zap.Error(err))
+					}
+				}()
+				return nil
+			},
+			OnStop:  redirect.Shutdown,
+			Timeout: cfg.Server.ShutdownTimeout,
+		})
+	}
+
+	// Reload the YAML config layers (CONFIG_WATCH_INTERVAL) and re-read
+	// secret:// values (SECRETS_REFRESH_INTERVAL); components that can change
+	// at runtime subscribe to the watcher
+	if cfg.WatchInterval > 0 || cfg.SecretsRefresh > 0 {
+		watcher := config.NewWatcher(cfg)
+		watcher.Subscribe(func(old, new *config.Config) {
+			if new.Log.Level != old.Log.Level {
+				logger.SetLevel(new.Log.Level)
+			}
+			logger.Info("Configuration reloaded", zap.String("log_level", new.Log.Level))
+		})
+
+		// A rotated secret reloads the configuration with the new value
+		for _, ref := range config.Secrets().Refs() {
+			config.Secrets().OnChange(ref, func(ref, _ string) {
+				logger.Info("Secret rotated", zap.String("ref", ref))
+				if err := watcher.Reload(); err != nil {
+					logger.Error("Failed to reload configuration", zap.Error(err))
+				}
+			})
+		}
+
+		watchCtx, stopWatching := context.WithCancel(context.Background())
+		containerInstance.Lifecycle.Append(container.Hook{
+			Name: "config watcher",
+			OnStart: func(context.Context) error {
+				if cfg.WatchInterval > 0 {
+					go watcher.Watch(watchCtx, cfg.WatchInterval)
+				}
+				if cfg.SecretsRefresh > 0 {
+					go config.Secrets().Watch(watchCtx, cfg.SecretsRefresh, func(err error) {
+						logger.Warn("Failed to refresh secrets", zap.Error(err))
+					})
+				}
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				stopWatching()
+				return nil
+			},
+		})
+	}
+
+	// Appended after the servers so it stops first: /readyz fails while
+	// load balancers take the instance out, then the server drains
+	containerInstance.Lifecycle.Append(container.Hook{
+		Name: "readiness",
+		OnStop: func(ctx context.Context) error {
+			containerInstance.Probes.Drain()
+			select {
+			case <-time.After(cfg.Health.DrainDelay):
+			case <-ctx.Done():
+			}
+			return nil
+		},
+		Timeout: cfg.Health.DrainDelay + time.Second,
+	})
+
+	// Start background tasks, workers and the servers
+	if err := containerInstance.Lifecycle.Start(context.Background()); err != nil {
+		logger.Fatal("Failed to start", zap.Error(err))
+	}
+
+	// Tell the previous process (if any) that it can drain and exit
+	if err := upgrader.Ready(); err != nil {
+		logger.Fatal("Failed to signal readiness", zap.Error(err))
+	}
 
 	logger.Info("Server started successfully",
-		zap.String("address", server.Addr),
+		zap.String("address", listenConfig.Describe()),
 		zap.String("database_type", string(containerInstance.GetDatabaseType())))
 
-	// Wait for interrupt signal to gracefully shutdown the server
+	// Graceful restart: SIGHUP starts the new binary on the same listeners
+	if cfg.Server.GracefulRestart {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			for range hup {
+				logger.Info("Graceful restart requested, starting new process")
+				if err := upgrader.Upgrade(); err != nil {
+					logger.Error("Graceful restart failed, keeping current process", zap.Error(err))
+				}
+			}
+		}()
+	}
+
+	// Wait for interrupt signal (or a completed restart) to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	logger.Info("Shutting down server...")
-
-	// Graceful shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	if err := server.Shutdown(ctx); err != nil {
-		logger.Fatal("Server forced to shutdown", zap.Error(err))
+	select {
+	case <-quit:
+		logger.Info("Shutting down server...")
+	case <-upgrader.Exit():
+		logger.Info("New process is serving, draining connections...")
 	}
 
-	// Close container resources (includes database)
+	// Stop the servers, then workers and background tasks, then connections,
+	// each within its own timeout
 	if err := containerInstance.Close(); err != nil {
-		logger.Error("Failed to close container resources", zap.Error(err))
+		logger.Error("Failed to shut down cleanly", zap.Error(err))
 	}
 
 	logger.Info("Server exited")
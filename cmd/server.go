@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+
+	"flex-service/config"
+	"flex-service/pkg/mtls"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+var errAmbiguousTLS = errors.New("configure either SERVER_TLS_CERT_FILE or SERVER_AUTOCERT_DOMAINS, not both")
+
+// serverTLSConfig builds the HTTPS config from certificate files or Let's Encrypt.
+// The autocert manager is returned so the redirect server can answer HTTP-01 challenges.
+func serverTLSConfig(cfg config.ServerConfig) (*tls.Config, *autocert.Manager, error) {
+	if len(cfg.AutoCert.Domains) == 0 {
+		tlsConfig, err := mtls.ServerConfig(cfg.TLS)
+		return tlsConfig, nil, err
+	}
+
+	if cfg.TLS.Enabled() {
+		return nil, nil, errAmbiguousTLS
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.AutoCert.Domains...),
+		Email:      cfg.AutoCert.Email,
+		Cache:      autocert.DirCache(cfg.AutoCert.CacheDir),
+	}
+
+	// Includes the acme-tls/1 protocol for TLS-ALPN-01 challenges
+	tlsConfig := manager.TLSConfig()
+	if err := mtls.ConfigureClientAuth(tlsConfig, cfg.TLS); err != nil {
+		return nil, nil, err
+	}
+
+	return tlsConfig, manager, nil
+}
+
+// redirectServer serves plain HTTP on the redirect port, sending every request to HTTPS
+// (and answering ACME HTTP-01 challenges when autocert is enabled)
+func redirectServer(cfg config.ServerConfig, manager *autocert.Manager) *http.Server {
+	var handler http.Handler = httpsRedirect(cfg.Port)
+	if manager != nil {
+		handler = manager.HTTPHandler(handler)
+	}
+
+	return &http.Server{
+		Addr:         net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.RedirectPort)),
+		Handler:      handler,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+	}
+}
+
+// httpsRedirect permanently redirects to the same host and path on the HTTPS port
+func httpsRedirect(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		}
+
+		// 308 keeps the method and body for non-GET requests
+		status := http.StatusMovedPermanently
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			status = http.StatusPermanentRedirect
+		}
+
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+	})
+}
@@ -2,28 +2,60 @@ package config
 
 import (
 	"log"
-	"os"
+	"net"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"flex-service/pkg/cors"
 	"flex-service/pkg/database"
+	"flex-service/pkg/listener"
+	"flex-service/pkg/mtls"
+	"flex-service/pkg/storage"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Database  MultiDatabaseConfig
-	Server    ServerConfig
-	JWT       JWTConfig
-	Log       LogConfig
-	Email     EmailConfig
-	Secure    SecureConfig
-	Redis     RedisConfig
-	Env       string
-	AppName   string
-	Timezone  string
-	Ratelimit RatelimitConfig
+	Database MultiDatabaseConfig
+	Server   ServerConfig
+	JWT      JWTConfig
+	OAuth    OAuthConfig
+	SAML     SAMLConfig
+	Log      LogConfig
+	Email    EmailConfig
+	Secure   SecureConfig
+	Redis    RedisConfig
+	Cache    CacheConfig
+	Counter  CounterConfig
+	Health   HealthConfig
+	Audit    AuditConfig
+	Search   SearchConfig
+	Env      string
+	AppName  string
+	Timezone string
+	// WatchInterval is how often the YAML layers are checked for changes
+	// (see Watcher); 0 disables reloading
+	WatchInterval time.Duration
+	// SecretsRefresh is how often secret:// values are read again to pick up
+	// rotations; 0 disables refreshing
+	SecretsRefresh time.Duration
+	Ratelimit      RatelimitConfig
+	Response       ResponseConfig
+	Feature        FeatureConfig
+	Auth           AuthConfig
+	I18n           I18nConfig
+	Signing        SigningConfig
+	Storage        storage.Config
+	Imaging        ImagingConfig
+	Notification   NotificationConfig
+	Webhook        WebhookConfig
+	Export         ExportConfig
+	Session        SessionConfig
+	CORS           cors.Config
+	Security       SecurityConfig
+	Maintenance    MaintenanceConfig
 }
 
 // MultiDatabaseConfig supports multiple database configurations
@@ -32,6 +64,8 @@ type MultiDatabaseConfig struct {
 	MySQL      MySQLDatabaseConfig
 	PostgreSQL PostgreSQLDatabaseConfig
 	SQLite     SQLiteDatabaseConfig
+	Naming     database.NamingConfig // shared by every driver and the generators
+	SlowQuery  database.SlowQueryConfig
 }
 
 // MySQLDatabaseConfig for MySQL specific settings
@@ -90,6 +124,45 @@ type ServerConfig struct {
 	Port         int
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	TLS          mtls.Config // HTTPS and optional client certificate verification (mTLS)
+	AutoCert     AutoCertConfig
+	HTTP2        bool // Serve HTTP/2 over TLS
+	RedirectPort int  // Plain HTTP port redirecting to HTTPS (0 = disabled)
+
+	Socket            string // Unix domain socket path instead of Host:Port
+	SocketMode        string // Octal socket permissions
+	SystemdActivation bool   // Inherit the listener from systemd socket activation
+	SystemdSocketName string // FileDescriptorName= to use when several sockets are passed
+
+	GracefulRestart bool          // Hand listeners to a new binary on SIGHUP
+	PIDFile         string        // Updated by whichever process is serving
+	UpgradeTimeout  time.Duration // How long the new process has to become ready
+	ShutdownTimeout time.Duration // How long in-flight requests have to finish on shutdown
+
+	RouteProfiles []string // Route profiles to mount (admin, debug, experimental); empty uses the defaults for ENV
+}
+
+// Listener returns where the main server listens
+func (s ServerConfig) Listener() listener.Config {
+	return listener.Config{
+		Address:     net.JoinHostPort(s.Host, strconv.Itoa(s.Port)),
+		Socket:      s.Socket,
+		SocketMode:  s.SocketMode,
+		Systemd:     s.SystemdActivation,
+		SystemdName: s.SystemdSocketName,
+	}
+}
+
+// AutoCertConfig obtains certificates from Let's Encrypt instead of SERVER_TLS_CERT_FILE
+type AutoCertConfig struct {
+	Domains  []string // Hostnames allowed to request certificates
+	Email    string   // ACME account contact
+	CacheDir string   // Where issued certificates are kept between restarts
+}
+
+// TLSEnabled reports whether the server should serve HTTPS
+func (s ServerConfig) TLSEnabled() bool {
+	return s.TLS.Enabled() || len(s.AutoCert.Domains) > 0
 }
 
 type JWTConfig struct {
@@ -97,6 +170,45 @@ type JWTConfig struct {
 	ExpirationHours        int
 	RefreshExpirationHours int
 	Algorithm              string
+	ScopedTokenTTL         time.Duration // Default lifetime of scoped tokens
+	ScopedTokenMaxTTL      time.Duration // Longest lifetime a client may request
+	KeysDir                string        // Signing keys for RS256/EdDSA, one <kid>.pem per key
+	KeyRotation            time.Duration // How often a new signing key replaces the active one; 0 disables rotation
+}
+
+// OAuthConfig configures server-side social login; providers without a client ID are disabled
+type OAuthConfig struct {
+	Google   OAuthProviderConfig
+	Facebook OAuthProviderConfig
+	LINE     OAuthProviderConfig
+	Apple    AppleOAuthConfig
+}
+
+type OAuthProviderConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string // Must point at /api/v1/user-auth/oauth/<provider>/callback
+}
+
+type AppleOAuthConfig struct {
+	OAuthProviderConfig        // ClientID is the Services ID; ClientSecret is generated
+	TeamID              string // Apple developer team ID
+	KeyID               string // ID of the Sign in with Apple key
+	PrivateKeyFile      string // .p8 key file
+}
+
+// SAMLConfig configures SAML 2.0 single sign-on against one IdP
+type SAMLConfig struct {
+	Enabled            bool
+	EntityID           string // SP entity ID, usually the metadata URL
+	ACSURL             string // Must point at /api/v1/user-auth/saml/acs
+	IdPMetadataFile    string // Metadata XML downloaded from the IdP
+	AllowIdPInitiated  bool
+	EmailAttribute     string
+	FirstNameAttribute string
+	LastNameAttribute  string
+	RolesAttribute     string
+	RoleMap            []string // "idp-group=role" pairs
 }
 
 type LogConfig struct {
@@ -115,10 +227,145 @@ type EmailConfig struct {
 	MaxRetries         int
 	RetryDelay         time.Duration
 	InsecureSkipVerify bool
+
+	RateLimit        int            // messages per RateWindow across all domains; 0 is unlimited
+	DomainRateLimit  int            // messages per RateWindow to one recipient domain; 0 is unlimited
+	DomainRateLimits map[string]int // per-domain overrides, e.g. gmail.com:20
+	RateWindow       time.Duration
+	ThrottleMaxWait  time.Duration // longer waits are deferred to the queue
 }
 
 type SecureConfig struct {
-	Key string
+	Key          string
+	KeyVersion   int               // version of Key recorded in encrypted model fields
+	PreviousKeys map[string]string // earlier keys by version, still accepted when decrypting fields
+}
+
+// SigningConfig holds keys for signing internal service-to-service requests
+type SigningConfig struct {
+	KeyID       string        // ID of this service's outbound key
+	Algorithm   string        // hmac-sha256 or ed25519
+	Key         string        // base64 HMAC secret or ed25519 private key/seed
+	TrustedKeys string        // inbound keys: "id:algorithm:base64key,..."
+	MaxSkew     time.Duration // allowed clock drift for signature timestamps
+}
+
+type ImagingConfig struct {
+	Variants  string // "name:WxH[:mode[:format[:quality]]],..." (see pkg/imaging)
+	Quality   int    // default JPEG quality
+	MaxPixels int    // reject larger source images
+}
+
+// NotificationConfig holds credentials for the pkg/notification channel drivers
+type NotificationConfig struct {
+	SMSAccountSID      string        // Twilio-compatible account SID
+	SMSAuthToken       string        // Twilio-compatible auth token
+	SMSFrom            string        // default sender number
+	SMSBaseURL         string        // API base URL, for Twilio-compatible providers
+	SlackWebhookURL    string        // default incoming webhook when the notifiable has no route
+	FCMCredentialsFile string        // Firebase service account JSON
+	Timeout            time.Duration // per-request timeout for the HTTP drivers
+}
+
+// WebhookConfig configures outgoing webhook delivery (see pkg/webhook)
+type WebhookConfig struct {
+	MaxAttempts int           // attempts per delivery, including the first
+	RetryBase   time.Duration // delay before the first retry, doubled after each one
+	RetryMax    time.Duration // upper bound for the retry delay
+	Timeout     time.Duration // per-request timeout
+}
+
+// ExportConfig configures data exports and imports (see pkg/export)
+type ExportConfig struct {
+	ChunkSize     int           // valid import rows written at a time
+	MaxImportSize int64         // largest accepted import file in bytes
+	JobTTL        time.Duration // how long job status is kept
+	URLTTL        time.Duration // how long a download URL stays valid
+	Workers       int           // jobs run at a time per instance without a queue
+}
+
+// SessionConfig configures cookie sessions for browser clients (see pkg/session)
+type SessionConfig struct {
+	Driver   string        // redis, database or memory
+	Cookie   string        // cookie name
+	Lifetime time.Duration // idle lifetime, extended on every request
+	Domain   string        // cookie domain; empty means the request host
+	Secure   bool          // send the cookie over HTTPS only
+	SameSite string        // lax, strict or none
+}
+
+// SecurityConfig holds the response headers, request body limits and trusted
+// proxies applied to every request (see middleware.Secure)
+type SecurityConfig struct {
+	HSTSMaxAge            time.Duration // Strict-Transport-Security max-age, sent over HTTPS only; 0 disables
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+	FrameOptions          string // X-Frame-Options: DENY or SAMEORIGIN; empty omits the header
+	ContentSecurityPolicy string
+	CSPReportOnly         bool // send the policy as Content-Security-Policy-Report-Only
+	ReferrerPolicy        string
+	PermissionsPolicy     string
+	MaxBodySize           int64 // bytes accepted in a request body; 0 disables the limit
+	MaxMultipartSize      int64 // bytes accepted in a multipart/form-data body (uploads)
+	// TrustedProxies are the IPs and CIDRs whose forwarding headers ClientIP
+	// believes; empty trusts none and uses the connection's address
+	TrustedProxies  []string
+	RemoteIPHeaders []string // headers proxies put the client IP in, first match wins
+	// TrustedPlatform is a header set by the hosting platform that holds the
+	// client IP, e.g. CF-Connecting-IP behind Cloudflare; it takes precedence
+	TrustedPlatform string
+}
+
+// MaintenanceConfig selects where artisan down stores the maintenance state
+// (see pkg/maintenance)
+type MaintenanceConfig struct {
+	Driver  string        // redis (every instance) or file (one host or a shared volume)
+	File    string        // state file of the file driver
+	Refresh time.Duration // how often each instance reads the state
+}
+
+type CacheConfig struct {
+	Driver    string        // redis or tiered (in-process LRU in front of Redis)
+	LocalSize int           // keys kept in process by the tiered driver
+	LocalTTL  time.Duration // longest a value is served from process memory
+	Version   string        // deploy version embedded in versioned keys, e.g. the git SHA
+	Versioned []string      // key prefixes whose keys carry the version, e.g. "user:"
+	HotKeys   int           // most read keys tracked for /debug/cache; 0 disables tracking
+}
+
+type CounterConfig struct {
+	FlushInterval time.Duration // how often changed counters are written to the database
+	KeyTTL        time.Duration // how long an idle counter stays in Redis
+}
+
+// HealthConfig configures /livez, /readyz and /healthz
+type HealthConfig struct {
+	CheckTimeout  time.Duration // per check, unless the check sets its own
+	DegradedCode  int           // HTTP code when a non-critical check fails
+	UnhealthyCode int           // HTTP code when a critical check fails
+	DrainDelay    time.Duration // how long /readyz fails before the server stops on shutdown
+
+	DiskPath            string        // filesystem checked by the disk check
+	DiskWarningPercent  int           // disk usage reported as degraded, 0 disables
+	DiskCriticalPercent int           // disk usage reported as unhealthy, 0 disables
+	SystemInterval      time.Duration // how often CPU, load and disk metrics are sampled
+}
+
+// AuditConfig configures the audit log
+type AuditConfig struct {
+	Enabled bool     // record entries; audit.Record does nothing when disabled
+	Methods []string // HTTP methods the audit middleware records
+}
+
+// SearchConfig configures full-text search (pkg/search)
+type SearchConfig struct {
+	Driver      string        // meilisearch or elasticsearch; empty disables search
+	URL         string        // server address, e.g. http://localhost:7700
+	APIKey      string        // Meilisearch key or Elasticsearch API key
+	IndexPrefix string        // prepended to index names, so environments can share a server
+	Timeout     time.Duration // per request to the server
+	BatchSize   int           // records per request when reindexing
+	QueueDelay  time.Duration // how long queued index updates wait for the write to commit
 }
 
 type RedisConfig struct {
@@ -135,19 +382,114 @@ type RedisConfig struct {
 }
 
 type RatelimitConfig struct {
-	Limit  int
-	Window time.Duration
+	Limit         int
+	Window        time.Duration
+	PoliciesFile  string            // JSON file with rate limit policies per route and plan
+	PolicyRefresh time.Duration     // how often policies stored in the database are reloaded
+	Quotas        map[string]string // limit multiplier per plan or role, or "unlimited"
+	QuotaDefault  float64           // multiplier for principals without a quota tier
+	QuotaCacheTTL time.Duration     // how long looked up plans are cached
+}
+
+type FeatureConfig struct {
+	FlagsFile       string // JSON file with feature flag definitions
+	ExperimentsFile string // JSON file with A/B experiment definitions
 }
 
+type AuthConfig struct {
+	RolesFile string // JSON file with role definitions and inheritance
+	Lockout   LockoutConfig
+	Password  PasswordConfig
+}
+
+// PasswordConfig is the policy new passwords must satisfy at registration
+// and reset (see pkg/password)
+type PasswordConfig struct {
+	MinLength       int
+	RequireUpper    bool
+	RequireLower    bool
+	RequireDigit    bool
+	RequireSymbol   bool
+	DenyList        []string      // Refused in addition to the built-in common passwords
+	BreachCheck     bool          // Refuse passwords found by Have I Been Pwned (k-anonymity range API)
+	BreachThreshold int           // Breaches a password must appear in to be refused
+	BreachAPIURL    string        // Range API base URL
+	BreachTimeout   time.Duration // Of a breach check, retries included; a failing check lets the password through
+}
+
+// LockoutConfig locks password login for a user from one IP after repeated
+// failures; it needs a cache to count them
+type LockoutConfig struct {
+	Enabled     bool
+	MaxAttempts int           // Failed logins per user and IP before a lockout
+	Window      time.Duration // How long a failed login counts towards MaxAttempts
+	Duration    time.Duration // First lockout; each further one doubles it
+	MaxDuration time.Duration // Longest lockout
+	UnlockURL   string        // Page the unlock email links to, with ?token= appended
+}
+
+type I18nConfig struct {
+	DefaultLocale string
+	Dir           string // Directory with <locale>.json / <locale>.toml bundles overriding the built-in messages
+}
+
+type ResponseConfig struct {
+	ErrorFormat        string // envelope or problem (RFC 7807)
+	ProblemTypeBaseURL string
+}
+
+// Load reads the configuration and exits when it fails validation. Settings
+// come from, highest first: environment variables, .env, config/config.<ENV>.yaml,
+// config/config.yaml and the defaults below.
 func Load() *Config {
-	// Load .env file
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using environment variables")
+	cfg, err := Read()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	return cfg
+}
+
+// loadDotEnv loads .env once; its values become environment variables, so
+// they do not change on Reload
+var loadDotEnv sync.Once
+
+// Read loads the configuration layers and validates the result
+func Read() (*Config, error) {
+	loadDotEnv.Do(func() {
+		if err := godotenv.Load(); err != nil {
+			log.Println("No .env file found, using environment variables")
+		}
+	})
+	if err := loadLayers(); err != nil {
+		return nil, err
+	}
+
+	cfg := build()
+	if err := takeSecretErrors(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
+	cfg.applyDevelopmentDefaults()
+	return cfg, nil
+}
 
+func build() *Config {
 	return &Config{
 		Database: MultiDatabaseConfig{
 			Type: database.DatabaseType(getEnv("DB_DRIVER", "mysql")),
+			Naming: database.NamingConfig{
+				TablePrefix:    getEnv("DB_TABLE_PREFIX", ""),
+				SingularTables: getEnvAsBool("DB_SINGULAR_TABLES", false),
+				Replacements:   getEnvAsMap("DB_NAME_REPLACEMENTS"),
+			},
+			SlowQuery: database.SlowQueryConfig{
+				Threshold: getEnvAsDuration("DB_SLOW_QUERY_THRESHOLD", 500*time.Millisecond),
+				// EXPLAIN re-plans every slow query, so never in production
+				Explain: getEnvAsBool("DB_SLOW_QUERY_EXPLAIN", false) && getEnv("ENV", "development") != "production",
+				LogFile: getEnv("DB_SLOW_QUERY_LOG", ""),
+			},
 			MySQL: MySQLDatabaseConfig{
 				Host:            getEnv("DB_MYSQL_HOST", "localhost"),
 				Port:            getEnvAsInt("DB_MYSQL_PORT", 3306),
@@ -199,12 +541,94 @@ func Load() *Config {
 			Port:         getEnvAsInt("SERVER_PORT", 8080),
 			ReadTimeout:  getEnvAsDuration("SERVER_READ_TIMEOUT", 30*time.Second),
 			WriteTimeout: getEnvAsDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
+			TLS: mtls.Config{
+				CertFile:      getEnv("SERVER_TLS_CERT_FILE", ""),
+				KeyFile:       getEnv("SERVER_TLS_KEY_FILE", ""),
+				ClientCAFiles: getEnvAsSlice("SERVER_TLS_CLIENT_CA_FILES", nil),
+				ClientAuth:    getEnv("SERVER_TLS_CLIENT_AUTH", ""),
+				MinVersion:    getEnv("SERVER_TLS_MIN_VERSION", "1.2"),
+			},
+			AutoCert: AutoCertConfig{
+				Domains:  getEnvAsSlice("SERVER_AUTOCERT_DOMAINS", nil),
+				Email:    getEnv("SERVER_AUTOCERT_EMAIL", ""),
+				CacheDir: getEnv("SERVER_AUTOCERT_CACHE_DIR", "./certs"),
+			},
+			HTTP2:        getEnvAsBool("SERVER_HTTP2", true),
+			RedirectPort: getEnvAsInt("SERVER_REDIRECT_PORT", 0),
+
+			Socket:            getEnv("SERVER_SOCKET", ""),
+			SocketMode:        getEnv("SERVER_SOCKET_MODE", "0660"),
+			SystemdActivation: getEnvAsBool("SERVER_SYSTEMD_ACTIVATION", false),
+			SystemdSocketName: getEnv("SERVER_SYSTEMD_SOCKET_NAME", ""),
+
+			GracefulRestart: getEnvAsBool("SERVER_GRACEFUL_RESTART", false),
+			PIDFile:         getEnv("SERVER_PID_FILE", ""),
+			UpgradeTimeout:  getEnvAsDuration("SERVER_UPGRADE_TIMEOUT", 30*time.Second),
+			ShutdownTimeout: getEnvAsDuration("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
+
+			RouteProfiles: getEnvAsSlice("SERVER_ROUTE_PROFILES", nil),
+		},
+		CORS: cors.Config{
+			AllowedOrigins:   getEnvAsSlice("CORS_ALLOWED_ORIGINS", defaultCORSOrigins()),
+			AllowedMethods:   getEnvAsSlice("CORS_ALLOWED_METHODS", cors.DefaultMethods),
+			AllowedHeaders:   getEnvAsSlice("CORS_ALLOWED_HEADERS", cors.DefaultHeaders),
+			ExposedHeaders:   getEnvAsSlice("CORS_EXPOSED_HEADERS", []string{"Content-Length"}),
+			AllowCredentials: getEnvAsBool("CORS_ALLOW_CREDENTIALS", true),
+			MaxAge:           getEnvAsDuration("CORS_MAX_AGE", 12*time.Hour),
+			Routes:           cors.ParseRoutes(getEnv("CORS_ROUTES", "")),
+		},
+		Security: SecurityConfig{
+			HSTSMaxAge:            getEnvAsDuration("SECURITY_HSTS_MAX_AGE", 365*24*time.Hour),
+			HSTSIncludeSubdomains: getEnvAsBool("SECURITY_HSTS_INCLUDE_SUBDOMAINS", true),
+			HSTSPreload:           getEnvAsBool("SECURITY_HSTS_PRELOAD", false),
+			FrameOptions:          getEnv("SECURITY_FRAME_OPTIONS", "DENY"),
+			ContentSecurityPolicy: getEnv("SECURITY_CSP", "default-src 'none'; frame-ancestors 'none'"),
+			CSPReportOnly:         getEnvAsBool("SECURITY_CSP_REPORT_ONLY", false),
+			ReferrerPolicy:        getEnv("SECURITY_REFERRER_POLICY", "strict-origin-when-cross-origin"),
+			PermissionsPolicy:     getEnv("SECURITY_PERMISSIONS_POLICY", ""),
+			MaxBodySize:           getEnvAsBytes("SECURITY_MAX_BODY_SIZE", 2<<20),
+			MaxMultipartSize:      getEnvAsBytes("SECURITY_MAX_MULTIPART_SIZE", 32<<20),
+			TrustedProxies:        getEnvAsSlice("SECURITY_TRUSTED_PROXIES", nil),
+			RemoteIPHeaders:       getEnvAsSlice("SECURITY_REMOTE_IP_HEADERS", []string{"X-Forwarded-For", "X-Real-IP"}),
+			TrustedPlatform:       getEnv("SECURITY_TRUSTED_PLATFORM", ""),
+		},
+		Maintenance: MaintenanceConfig{
+			Driver:  getEnv("MAINTENANCE_DRIVER", "redis"),
+			File:    getEnv("MAINTENANCE_FILE", "./maintenance.json"),
+			Refresh: getEnvAsDuration("MAINTENANCE_REFRESH", 2*time.Second),
 		},
 		JWT: JWTConfig{
-			Secret:                 getEnv("JWT_SECRET", "your-super-secret-jwt-key"),
+			Secret:                 getEnv("JWT_SECRET", ""),
 			ExpirationHours:        getEnvAsInt("JWT_EXPIRATION_HOURS", 24),
 			RefreshExpirationHours: getEnvAsInt("JWT_REFRESH_EXPIRATION_HOURS", 720),
 			Algorithm:              getEnv("JWT_ALGORITHM", "HS256"),
+			ScopedTokenTTL:         getEnvAsDuration("JWT_SCOPED_TOKEN_TTL", 5*time.Minute),
+			ScopedTokenMaxTTL:      getEnvAsDuration("JWT_SCOPED_TOKEN_MAX_TTL", time.Hour),
+			KeysDir:                getEnv("JWT_KEYS_DIR", "./storage/keys/jwt"),
+			KeyRotation:            getEnvAsDuration("JWT_KEY_ROTATION", 0),
+		},
+		OAuth: OAuthConfig{
+			Google:   getOAuthProviderConfig("GOOGLE"),
+			Facebook: getOAuthProviderConfig("FACEBOOK"),
+			LINE:     getOAuthProviderConfig("LINE"),
+			Apple: AppleOAuthConfig{
+				OAuthProviderConfig: getOAuthProviderConfig("APPLE"),
+				TeamID:              getEnv("OAUTH_APPLE_TEAM_ID", ""),
+				KeyID:               getEnv("OAUTH_APPLE_KEY_ID", ""),
+				PrivateKeyFile:      getEnv("OAUTH_APPLE_PRIVATE_KEY_FILE", ""),
+			},
+		},
+		SAML: SAMLConfig{
+			Enabled:            getEnvAsBool("SAML_ENABLED", false),
+			EntityID:           getEnv("SAML_ENTITY_ID", ""),
+			ACSURL:             getEnv("SAML_ACS_URL", ""),
+			IdPMetadataFile:    getEnv("SAML_IDP_METADATA_FILE", ""),
+			AllowIdPInitiated:  getEnvAsBool("SAML_ALLOW_IDP_INITIATED", false),
+			EmailAttribute:     getEnv("SAML_ATTR_EMAIL", "email"),
+			FirstNameAttribute: getEnv("SAML_ATTR_FIRST_NAME", "firstName"),
+			LastNameAttribute:  getEnv("SAML_ATTR_LAST_NAME", "lastName"),
+			RolesAttribute:     getEnv("SAML_ATTR_ROLES", ""),
+			RoleMap:            getEnvAsSlice("SAML_ROLE_MAP", nil),
 		},
 		Log: LogConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
@@ -221,9 +645,17 @@ func Load() *Config {
 			MaxRetries:         getEnvAsInt("EMAIL_MAX_RETRIES", 3),
 			RetryDelay:         getEnvAsDuration("EMAIL_RETRY_DELAY", 1*time.Second),
 			InsecureSkipVerify: getEnvAsBool("EMAIL_INSECURE_SKIP_VERIFY", false),
+
+			RateLimit:        getEnvAsInt("EMAIL_RATE_LIMIT", 0),
+			DomainRateLimit:  getEnvAsInt("EMAIL_DOMAIN_RATE_LIMIT", 0),
+			DomainRateLimits: getEnvAsIntMap("EMAIL_DOMAIN_RATE_LIMITS"),
+			RateWindow:       getEnvAsDuration("EMAIL_RATE_WINDOW", time.Minute),
+			ThrottleMaxWait:  getEnvAsDuration("EMAIL_THROTTLE_MAX_WAIT", 10*time.Second),
 		},
 		Secure: SecureConfig{
-			Key: getEnv("ENCRYPTION_KEY", ""),
+			Key:          getEnv("ENCRYPTION_KEY", ""),
+			KeyVersion:   getEnvAsInt("ENCRYPTION_KEY_VERSION", 1),
+			PreviousKeys: getEnvAsMap("ENCRYPTION_PREVIOUS_KEYS"),
 		},
 		Redis: RedisConfig{
 			Host:         getEnv("REDIS_HOST", "localhost"),
@@ -238,17 +670,181 @@ func Load() *Config {
 			WriteTimeout: getEnvAsDuration("REDIS_WRITE_TIMEOUT", 3*time.Second),
 		},
 
+		Cache: CacheConfig{
+			Driver:    getEnv("CACHE_DRIVER", "redis"),
+			LocalSize: getEnvAsInt("CACHE_LOCAL_SIZE", 10000),
+			LocalTTL:  getEnvAsDuration("CACHE_LOCAL_TTL", time.Minute),
+			Version:   getEnv("CACHE_VERSION", ""),
+			Versioned: getEnvAsSlice("CACHE_VERSIONED_NAMESPACES", nil),
+			HotKeys:   getEnvAsInt("CACHE_HOT_KEYS", 0),
+		},
+
+		Counter: CounterConfig{
+			FlushInterval: getEnvAsDuration("COUNTER_FLUSH_INTERVAL", 10*time.Second),
+			KeyTTL:        getEnvAsDuration("COUNTER_KEY_TTL", 24*time.Hour),
+		},
+
+		Health: HealthConfig{
+			CheckTimeout:  getEnvAsDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+			DegradedCode:  getEnvAsInt("HEALTH_DEGRADED_STATUS", 200),
+			UnhealthyCode: getEnvAsInt("HEALTH_UNHEALTHY_STATUS", 503),
+			DrainDelay:    getEnvAsDuration("HEALTH_DRAIN_DELAY", 0),
+
+			DiskPath:            getEnv("HEALTH_DISK_PATH", "."),
+			DiskWarningPercent:  getEnvAsInt("HEALTH_DISK_WARNING_PERCENT", 80),
+			DiskCriticalPercent: getEnvAsInt("HEALTH_DISK_CRITICAL_PERCENT", 95),
+			SystemInterval:      getEnvAsDuration("HEALTH_SYSTEM_INTERVAL", 15*time.Second),
+		},
+
+		Audit: AuditConfig{
+			Enabled: getEnvAsBool("AUDIT_ENABLED", true),
+			Methods: getEnvAsSlice("AUDIT_HTTP_METHODS", []string{"POST", "PUT", "PATCH", "DELETE"}),
+		},
+
+		Search: SearchConfig{
+			Driver:      getEnv("SEARCH_DRIVER", ""),
+			URL:         getEnv("SEARCH_URL", ""),
+			APIKey:      getEnv("SEARCH_API_KEY", ""),
+			IndexPrefix: getEnv("SEARCH_INDEX_PREFIX", ""),
+			Timeout:     getEnvAsDuration("SEARCH_TIMEOUT", 5*time.Second),
+			BatchSize:   getEnvAsInt("SEARCH_BATCH_SIZE", 500),
+			QueueDelay:  getEnvAsDuration("SEARCH_QUEUE_DELAY", 2*time.Second),
+		},
+
 		Ratelimit: RatelimitConfig{
-			Limit:  getEnvAsInt("RATELIMIT_LIMIT", 100),
-			Window: getEnvAsDuration("RATELIMIT_WINDOW", 1*time.Minute),
+			Limit:         getEnvAsInt("RATELIMIT_LIMIT", 100),
+			Window:        getEnvAsDuration("RATELIMIT_WINDOW", 1*time.Minute),
+			PoliciesFile:  getEnv("RATELIMIT_POLICIES_FILE", ""),
+			PolicyRefresh: getEnvAsDuration("RATELIMIT_POLICY_REFRESH", time.Minute),
+			Quotas:        getEnvAsMap("RATELIMIT_QUOTAS"),
+			QuotaDefault:  getEnvAsFloat("RATELIMIT_QUOTA_DEFAULT", 1),
+			QuotaCacheTTL: getEnvAsDuration("RATELIMIT_QUOTA_CACHE_TTL", 5*time.Minute),
+		},
+
+		Response: ResponseConfig{
+			ErrorFormat:        getEnv("RESPONSE_ERROR_FORMAT", "envelope"),
+			ProblemTypeBaseURL: getEnv("RESPONSE_PROBLEM_TYPE_BASE_URL", ""),
+		},
+
+		Feature: FeatureConfig{
+			FlagsFile:       getEnv("FEATURE_FLAGS_FILE", ""),
+			ExperimentsFile: getEnv("EXPERIMENTS_FILE", ""),
+		},
+
+		Auth: AuthConfig{
+			RolesFile: getEnv("AUTH_ROLES_FILE", ""),
+			Lockout: LockoutConfig{
+				Enabled:     getEnvAsBool("AUTH_LOCKOUT_ENABLED", true),
+				MaxAttempts: getEnvAsInt("AUTH_LOCKOUT_MAX_ATTEMPTS", 5),
+				Window:      getEnvAsDuration("AUTH_LOCKOUT_WINDOW", 15*time.Minute),
+				Duration:    getEnvAsDuration("AUTH_LOCKOUT_DURATION", time.Minute),
+				MaxDuration: getEnvAsDuration("AUTH_LOCKOUT_MAX_DURATION", 24*time.Hour),
+				UnlockURL:   getEnv("AUTH_LOCKOUT_UNLOCK_URL", ""),
+			},
+			Password: PasswordConfig{
+				MinLength:       getEnvAsInt("AUTH_PASSWORD_MIN_LENGTH", 8),
+				RequireUpper:    getEnvAsBool("AUTH_PASSWORD_REQUIRE_UPPER", true),
+				RequireLower:    getEnvAsBool("AUTH_PASSWORD_REQUIRE_LOWER", true),
+				RequireDigit:    getEnvAsBool("AUTH_PASSWORD_REQUIRE_DIGIT", true),
+				RequireSymbol:   getEnvAsBool("AUTH_PASSWORD_REQUIRE_SYMBOL", false),
+				DenyList:        getEnvAsSlice("AUTH_PASSWORD_DENY_LIST", nil),
+				BreachCheck:     getEnvAsBool("AUTH_PASSWORD_BREACH_CHECK", false),
+				BreachThreshold: getEnvAsInt("AUTH_PASSWORD_BREACH_THRESHOLD", 1),
+				BreachAPIURL:    getEnv("AUTH_PASSWORD_BREACH_API_URL", "https://api.pwnedpasswords.com/range/"),
+				BreachTimeout:   getEnvAsDuration("AUTH_PASSWORD_BREACH_TIMEOUT", 3*time.Second),
+			},
+		},
+
+		Storage: storage.Config{
+			Driver: getEnv("STORAGE_DRIVER", "local"),
+			Local: storage.LocalConfig{
+				Root:       getEnv("STORAGE_LOCAL_ROOT", "./storage"),
+				BaseURL:    getEnv("STORAGE_LOCAL_URL", "http://localhost:8080/storage"),
+				SigningKey: getEnv("STORAGE_SIGNING_KEY", getEnv("ENCRYPTION_KEY", "")),
+				Public:     getEnvAsBool("STORAGE_LOCAL_PUBLIC", false),
+			},
+			S3: storage.S3Config{
+				Endpoint:  getEnv("STORAGE_S3_ENDPOINT", ""),
+				Region:    getEnv("STORAGE_S3_REGION", "ap-southeast-1"),
+				Bucket:    getEnv("STORAGE_S3_BUCKET", ""),
+				AccessKey: getEnv("STORAGE_S3_ACCESS_KEY", ""),
+				SecretKey: getEnv("STORAGE_S3_SECRET_KEY", ""),
+				PathStyle: getEnvAsBool("STORAGE_S3_PATH_STYLE", false),
+				PublicURL: getEnv("STORAGE_S3_PUBLIC_URL", ""),
+				Timeout:   getEnvAsDuration("STORAGE_S3_TIMEOUT", 60*time.Second),
+			},
+		},
+
+		Imaging: ImagingConfig{
+			Variants:  getEnv("IMAGE_VARIANTS", "thumb:150x150:fill,medium:800x800:fit"),
+			Quality:   getEnvAsInt("IMAGE_QUALITY", 85),
+			MaxPixels: getEnvAsInt("IMAGE_MAX_PIXELS", 40000000),
+		},
+
+		Notification: NotificationConfig{
+			SMSAccountSID:      getEnv("SMS_ACCOUNT_SID", ""),
+			SMSAuthToken:       getEnv("SMS_AUTH_TOKEN", ""),
+			SMSFrom:            getEnv("SMS_FROM", ""),
+			SMSBaseURL:         getEnv("SMS_BASE_URL", "https://api.twilio.com"),
+			SlackWebhookURL:    getEnv("SLACK_WEBHOOK_URL", ""),
+			FCMCredentialsFile: getEnv("FCM_CREDENTIALS_FILE", ""),
+			Timeout:            getEnvAsDuration("NOTIFICATION_TIMEOUT", 10*time.Second),
+		},
+
+		Webhook: WebhookConfig{
+			MaxAttempts: getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 6),
+			RetryBase:   getEnvAsDuration("WEBHOOK_RETRY_BASE", 30*time.Second),
+			RetryMax:    getEnvAsDuration("WEBHOOK_RETRY_MAX", 6*time.Hour),
+			Timeout:     getEnvAsDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		},
+
+		Export: ExportConfig{
+			ChunkSize:     getEnvAsInt("EXPORT_CHUNK_SIZE", 500),
+			MaxImportSize: getEnvAsBytes("EXPORT_MAX_IMPORT_SIZE", 20<<20),
+			JobTTL:        getEnvAsDuration("EXPORT_JOB_TTL", 24*time.Hour),
+			URLTTL:        getEnvAsDuration("EXPORT_URL_TTL", 15*time.Minute),
+			Workers:       getEnvAsInt("EXPORT_WORKERS", 2),
+		},
+		Session: SessionConfig{
+			Driver:   getEnv("SESSION_DRIVER", "redis"),
+			Cookie:   getEnv("SESSION_COOKIE", "flex_session"),
+			Lifetime: getEnvAsDuration("SESSION_LIFETIME", 2*time.Hour),
+			Domain:   getEnv("SESSION_DOMAIN", ""),
+			Secure:   getEnvAsBool("SESSION_SECURE_COOKIE", true),
+			SameSite: getEnv("SESSION_SAME_SITE", "lax"),
+		},
+
+		Signing: SigningConfig{
+			KeyID:       getEnv("SIGNING_KEY_ID", ""),
+			Algorithm:   getEnv("SIGNING_ALGORITHM", "hmac-sha256"),
+			Key:         getEnv("SIGNING_KEY", ""),
+			TrustedKeys: getEnv("SIGNING_TRUSTED_KEYS", ""),
+			MaxSkew:     getEnvAsDuration("SIGNING_MAX_SKEW", 5*time.Minute),
+		},
+
+		I18n: I18nConfig{
+			DefaultLocale: getEnv("I18N_DEFAULT_LOCALE", "en"),
+			Dir:           getEnv("I18N_DIR", "./locales"),
 		},
 
 		Env:      getEnv("ENV", "development"),
 		AppName:  getEnv("APP_NAME", "flex-service"),
 		Timezone: getEnv("TIMEZONE", "Asia/Bangkok"),
+
+		WatchInterval:  getEnvAsDuration("CONFIG_WATCH_INTERVAL", 0),
+		SecretsRefresh: getEnvAsDuration("SECRETS_REFRESH_INTERVAL", 0),
 	}
 }
 
+// defaultCORSOrigins allows any origin in development only; other
+// environments allow none until CORS_ALLOWED_ORIGINS lists them
+func defaultCORSOrigins() []string {
+	if getEnv("ENV", "development") == "development" {
+		return []string{"*"}
+	}
+	return nil
+}
+
 // GetDatabaseConfig returns the appropriate database configuration based on the selected type
 func (c *Config) GetDatabaseConfig() database.DatabaseConfig {
 	switch c.Database.Type {
@@ -282,6 +878,8 @@ func (c *Config) convertToMySQLConfig() *database.MySQLConfig {
 				MaxOpenConns:    mysql.MaxOpenConns,
 				ConnMaxLifetime: mysql.ConnMaxLifetime,
 			},
+			Naming:    c.Database.Naming,
+			SlowQuery: c.Database.SlowQuery,
 		},
 		ClientCert: mysql.ClientCert,
 		ClientKey:  mysql.ClientKey,
@@ -307,6 +905,8 @@ func (c *Config) convertToPostgreSQLConfig() *database.PostgreSQLConfig {
 				MaxOpenConns:    postgres.MaxOpenConns,
 				ConnMaxLifetime: postgres.ConnMaxLifetime,
 			},
+			Naming:    c.Database.Naming,
+			SlowQuery: c.Database.SlowQuery,
 		},
 		SSLMode:          postgres.SSLMode,
 		TimeZone:         postgres.TimeZone,
@@ -331,18 +931,20 @@ func (c *Config) convertToSQLiteConfig() *database.SQLiteConfig {
 			MaxOpenConns:    sqlite.MaxOpenConns,
 			ConnMaxLifetime: sqlite.ConnMaxLifetime,
 		},
+		Naming:    c.Database.Naming,
+		SlowQuery: c.Database.SlowQuery,
 	}
 }
 
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
 func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
 			return intValue
 		}
@@ -350,8 +952,17 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := lookupEnv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
 			return duration
 		}
@@ -359,9 +970,81 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	value := lookupEnv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvAsMap parses "key:value,key:value" pairs, skipping malformed entries
+func getEnvAsMap(key string) map[string]string {
+	items := make(map[string]string)
+	for _, item := range getEnvAsSlice(key, nil) {
+		k, v, ok := strings.Cut(item, ":")
+		if k = strings.TrimSpace(k); ok && k != "" {
+			items[k] = strings.TrimSpace(v)
+		}
+	}
+	return items
+}
+
+// getEnvAsIntMap parses "key:number,key:number" pairs, skipping entries that
+// are not numbers
+func getEnvAsIntMap(key string) map[string]int {
+	items := make(map[string]int)
+	for k, v := range getEnvAsMap(key) {
+		if n, err := strconv.Atoi(v); err == nil {
+			items[k] = n
+		}
+	}
+	return items
+}
+
+// getEnvAsBytes parses a size in bytes with an optional KB, MB or GB suffix
+// (powers of 1024), e.g. 512KB or 10MB
+func getEnvAsBytes(key string, defaultValue int64) int64 {
+	value := strings.ToUpper(strings.TrimSpace(lookupEnv(key)))
+	if value == "" {
+		return defaultValue
+	}
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if number, ok := strings.CutSuffix(value, unit.suffix); ok {
+			value, multiplier = strings.TrimSpace(number), unit.size
+			break
+		}
+	}
+	if size, err := strconv.ParseInt(value, 10, 64); err == nil && size >= 0 {
+		return size * multiplier
+	}
+	return defaultValue
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		return strings.ToLower(value) == "true"
 	}
 	return defaultValue
 }
+
+// getOAuthProviderConfig reads OAUTH_<PROVIDER>_CLIENT_ID, _CLIENT_SECRET and _REDIRECT_URL
+func getOAuthProviderConfig(provider string) OAuthProviderConfig {
+	prefix := "OAUTH_" + provider + "_"
+	return OAuthProviderConfig{
+		ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+		ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+		RedirectURL:  getEnv(prefix+"REDIRECT_URL", ""),
+	}
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"flex-service/pkg/secrets"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileValues holds the settings read from the YAML layers, keyed like the
+// environment variables they stand in for
+var (
+	fileMu     sync.RWMutex
+	fileValues map[string]string
+)
+
+// lookupEnv returns the environment variable key or, when it is unset, the
+// value from the YAML layers. secret:// references are resolved (see Secrets).
+func lookupEnv(key string) string {
+	value := rawEnv(key)
+	if secrets.IsRef(value) {
+		return resolveSecret(key, value)
+	}
+	return value
+}
+
+// rawEnv is lookupEnv without resolving secret:// references
+func rawEnv(key string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	fileMu.RLock()
+	defer fileMu.RUnlock()
+	return fileValues[key]
+}
+
+// configDir is where config.yaml and config.<ENV>.yaml are read from
+func configDir() string {
+	if dir := os.Getenv("CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	return "config"
+}
+
+// layerFiles returns the YAML layers for env, base first. Missing files are
+// skipped when read.
+func layerFiles(env string) []string {
+	dir := configDir()
+	return []string{
+		filepath.Join(dir, "config.yaml"),
+		filepath.Join(dir, "config."+env+".yaml"),
+	}
+}
+
+// layerEnv returns ENV from the environment, else from the base layer, else
+// "development"
+func layerEnv(base map[string]string) string {
+	if env := os.Getenv("ENV"); env != "" {
+		return env
+	}
+	if env := base["ENV"]; env != "" {
+		return env
+	}
+	return "development"
+}
+
+// loadLayers reads config.yaml and then config.<ENV>.yaml over it. ENV comes
+// from the environment, else from config.yaml, else "development".
+func loadLayers() error {
+	values := make(map[string]string)
+	dir := configDir()
+	if err := readLayer(filepath.Join(dir, "config.yaml"), values); err != nil {
+		return err
+	}
+	if err := readLayer(layerFiles(layerEnv(values))[1], values); err != nil {
+		return err
+	}
+
+	fileMu.Lock()
+	fileValues = values
+	fileMu.Unlock()
+	return nil
+}
+
+// readLayer merges a YAML file into values. Nested keys are joined with "_"
+// and upper-cased, so db: {mysql: {host: x}} sets DB_MYSQL_HOST; flat keys
+// such as DB_MYSQL_HOST: x work too. A null value unsets a key set by an
+// earlier layer.
+func readLayer(path string, values map[string]string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+
+	var tree map[string]interface{}
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	flatten("", tree, values)
+	return nil
+}
+
+func flatten(prefix string, tree map[string]interface{}, values map[string]string) {
+	for key, value := range tree {
+		name := strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flatten(name, v, values)
+		case []interface{}:
+			// Lists become the comma-separated form getEnvAsSlice reads
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, scalar(item))
+			}
+			values[name] = strings.Join(items, ",")
+		case nil:
+			delete(values, name)
+		default:
+			values[name] = scalar(v)
+		}
+	}
+}
+
+func scalar(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// layerModTime returns the latest modification time of the YAML layers
+func layerModTime(env string) time.Time {
+	var latest time.Time
+	for _, path := range layerFiles(env) {
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"flex-service/pkg/secrets"
+)
+
+// secretTimeout bounds resolving one secret:// value while loading
+const secretTimeout = 10 * time.Second
+
+var (
+	secretResolver *secrets.Resolver
+	secretOnce     sync.Once
+
+	// secretErrs collects the references that failed during one Read
+	secretMu   sync.Mutex
+	secretErrs []error
+)
+
+// Secrets returns the resolver of secret:// values. Components that hold a
+// secret can subscribe to its rotation with OnChange.
+func Secrets() *secrets.Resolver {
+	secretOnce.Do(func() {
+		secretResolver = newSecretResolver()
+	})
+	return secretResolver
+}
+
+// newSecretResolver registers the providers configured in the environment or
+// the YAML layers. Their own settings are read as plain values.
+func newSecretResolver() *secrets.Resolver {
+	ttl, err := time.ParseDuration(rawEnv("SECRETS_CACHE_TTL"))
+	if err != nil {
+		ttl = 5 * time.Minute
+	}
+	resolver := secrets.NewResolver(ttl)
+
+	if address := rawEnv("VAULT_ADDR"); address != "" {
+		vault, err := secrets.NewVault(secrets.VaultConfig{
+			Address:   address,
+			Token:     rawEnv("VAULT_TOKEN"),
+			Mount:     rawEnv("VAULT_MOUNT"),
+			Namespace: rawEnv("VAULT_NAMESPACE"),
+		})
+		if err != nil {
+			recordSecretError(fmt.Errorf("vault provider: %w", err))
+		} else {
+			resolver.Register("vault", vault)
+		}
+	}
+
+	if region := rawEnv("SECRETS_AWS_REGION"); region != "" {
+		aws, err := secrets.NewAWS(secrets.AWSConfig{
+			Region:       region,
+			AccessKey:    rawEnv("AWS_ACCESS_KEY_ID"),
+			SecretKey:    rawEnv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken: rawEnv("AWS_SESSION_TOKEN"),
+			Endpoint:     rawEnv("SECRETS_AWS_ENDPOINT"),
+		})
+		if err != nil {
+			recordSecretError(fmt.Errorf("aws provider: %w", err))
+		} else {
+			resolver.Register("aws", aws)
+		}
+	}
+	return resolver
+}
+
+// resolveSecret resolves a secret:// value of key, recording failures so Read
+// can report them
+func resolveSecret(key, value string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), secretTimeout)
+	defer cancel()
+
+	secret, err := Secrets().Resolve(ctx, value)
+	if err != nil {
+		recordSecretError(fmt.Errorf("%s: %w", key, err))
+		return ""
+	}
+	return secret
+}
+
+func recordSecretError(err error) {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	secretErrs = append(secretErrs, err)
+}
+
+// takeSecretErrors returns and clears the recorded failures
+func takeSecretErrors() error {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+
+	err := errors.Join(secretErrs...)
+	secretErrs = nil
+	return err
+}
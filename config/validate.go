@@ -0,0 +1,126 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+
+	"flex-service/pkg/cors"
+	"flex-service/pkg/database"
+)
+
+// minSecretLength is the shortest HMAC secret accepted in production
+const minSecretLength = 32
+
+// developmentJWTSecret is used outside production when JWT_SECRET is unset
+const developmentJWTSecret = "insecure-development-jwt-secret"
+
+// placeholderSecrets are sample values from docs and env.example
+var placeholderSecrets = map[string]bool{
+	developmentJWTSecret:                                  true,
+	"your-super-secret-jwt-key":                           true,
+	"your-super-secret-jwt-key-change-this-in-production": true,
+}
+
+// Validate reports every invalid setting at once. Production also requires
+// the secrets that have no safe default: JWT_SECRET (for HMAC algorithms)
+// and ENCRYPTION_KEY.
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	switch c.Database.Type {
+	case database.DBTypeMySQL, database.DBTypePostgreSQL, database.DBTypeSQLite:
+	default:
+		add("DB_DRIVER must be mysql, postgresql or sqlite, got %q", c.Database.Type)
+	}
+	if c.Server.Port < 0 || c.Server.Port > 65535 {
+		add("SERVER_PORT must be between 0 and 65535, got %d", c.Server.Port)
+	}
+	if c.JWT.ExpirationHours <= 0 {
+		add("JWT_EXPIRATION_HOURS must be positive")
+	}
+	if c.Response.ErrorFormat != "envelope" && c.Response.ErrorFormat != "problem" {
+		add("RESPONSE_ERROR_FORMAT must be envelope or problem, got %q", c.Response.ErrorFormat)
+	}
+	if lockout := c.Auth.Lockout; lockout.Enabled {
+		if lockout.MaxAttempts <= 0 {
+			add("AUTH_LOCKOUT_MAX_ATTEMPTS must be positive")
+		}
+		if lockout.Window <= 0 || lockout.Duration <= 0 {
+			add("AUTH_LOCKOUT_WINDOW and AUTH_LOCKOUT_DURATION must be positive")
+		}
+		if lockout.MaxDuration < lockout.Duration {
+			add("AUTH_LOCKOUT_MAX_DURATION must not be shorter than AUTH_LOCKOUT_DURATION")
+		}
+	}
+	if c.Auth.Password.MinLength < 0 || c.Auth.Password.MinLength > 72 {
+		add("AUTH_PASSWORD_MIN_LENGTH must be between 0 and 72, bcrypt's limit")
+	}
+
+	switch c.Search.Driver {
+	case "":
+	case "meilisearch", "elasticsearch":
+		if c.Search.URL == "" {
+			add("SEARCH_URL is required with SEARCH_DRIVER=%s", c.Search.Driver)
+		}
+	default:
+		add("SEARCH_DRIVER must be meilisearch, elasticsearch or empty, got %q", c.Search.Driver)
+	}
+
+	for _, proxy := range c.Security.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				add("SECURITY_TRUSTED_PROXIES has %q, which is neither an IP nor a CIDR", proxy)
+			}
+		}
+	}
+	switch strings.ToUpper(c.Security.FrameOptions) {
+	case "", "DENY", "SAMEORIGIN":
+	default:
+		add("SECURITY_FRAME_OPTIONS must be DENY, SAMEORIGIN or empty, got %q", c.Security.FrameOptions)
+	}
+
+	if c.Env == "production" {
+		if c.JWT.usesHMAC() {
+			switch {
+			case c.JWT.Secret == "":
+				add("JWT_SECRET is required in production")
+			case placeholderSecrets[c.JWT.Secret]:
+				add("JWT_SECRET must not be a sample value in production")
+			case len(c.JWT.Secret) < minSecretLength:
+				add("JWT_SECRET must be at least %d bytes in production", minSecretLength)
+			}
+		}
+		if c.Secure.Key == "" {
+			add("ENCRYPTION_KEY is required in production")
+		}
+		if c.CORS.AllowCredentials && cors.AllowsAnyOrigin(c.CORS.AllowedOrigins) {
+			add("CORS_ALLOWED_ORIGINS must not be * with CORS_ALLOW_CREDENTIALS in production")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// applyDevelopmentDefaults fills secrets left empty outside production, with
+// a warning, so a fresh checkout runs without setup
+func (c *Config) applyDevelopmentDefaults() {
+	if c.Env == "production" {
+		return
+	}
+	if c.JWT.Secret == "" && c.JWT.usesHMAC() {
+		log.Println("Warning: JWT_SECRET is not set, using an insecure development secret")
+		c.JWT.Secret = developmentJWTSecret
+	}
+}
+
+// usesHMAC reports whether tokens are signed with JWT_SECRET rather than a
+// generated key pair
+func (j JWTConfig) usesHMAC() bool {
+	return strings.HasPrefix(strings.ToUpper(j.Algorithm), "HS")
+}
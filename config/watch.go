@@ -0,0 +1,82 @@
+package config
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Watcher holds the current configuration and notifies subscribers when the
+// YAML layers change. Environment variables and .env are read once at start,
+// so only settings that come from the YAML files can be reloaded.
+type Watcher struct {
+	mu          sync.RWMutex
+	current     *Config
+	subscribers []func(old, new *Config)
+}
+
+// NewWatcher creates a watcher starting from cfg
+func NewWatcher(cfg *Config) *Watcher {
+	return &Watcher{current: cfg}
+}
+
+// Current returns the latest valid configuration
+func (w *Watcher) Current() *Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Subscribe calls fn after every successful reload. Subscribers compare the
+// settings they use, and apply only what can change at runtime.
+func (w *Watcher) Subscribe(fn func(old, new *Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Reload reads the configuration again. An invalid configuration is returned
+// as an error and the current one is kept.
+func (w *Watcher) Reload() error {
+	cfg, err := Read()
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	old := w.current
+	w.current = cfg
+	subscribers := append([]func(old, new *Config){}, w.subscribers...)
+	w.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(old, cfg)
+	}
+	return nil
+}
+
+// Watch reloads whenever a YAML layer is modified, checking every interval
+// until ctx is done
+func (w *Watcher) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	env := w.Current().Env
+	seen := layerModTime(env)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			modified := layerModTime(env)
+			if !modified.After(seen) {
+				continue
+			}
+			seen = modified
+			if err := w.Reload(); err != nil {
+				log.Printf("Configuration not reloaded: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package api_key
+
+import (
+	"net/http"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/errors"
+	"flex-service/pkg/request"
+	"flex-service/pkg/requestctx"
+	"flex-service/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type APIKeyHandler struct {
+	usecase APIKeyUsecase
+}
+
+func NewAPIKeyHandler(usecase APIKeyUsecase) *APIKeyHandler {
+	return &APIKeyHandler{
+		usecase: usecase,
+	}
+}
+
+func (h *APIKeyHandler) List(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	keys, err := h.usecase.List(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "API keys retrieved successfully", keys)
+}
+
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	req, err := request.Bind[entity.CreateAPIKeyRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	key, err := h.usecase.Create(c.Request.Context(), userID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "API key created successfully. Store the key now, it won't be shown again", key)
+}
+
+func (h *APIKeyHandler) Rotate(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(errors.BadRequest("Invalid API key ID"))
+		return
+	}
+
+	key, err := h.usecase.Rotate(c.Request.Context(), userID, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "API key rotated successfully. Store the key now, it won't be shown again", key)
+}
+
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(errors.BadRequest("Invalid API key ID"))
+		return
+	}
+
+	if err := h.usecase.Revoke(c.Request.Context(), userID, id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "API key revoked successfully", nil)
+}
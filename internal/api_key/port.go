@@ -0,0 +1,44 @@
+package api_key
+
+import (
+	"context"
+	"time"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/requestctx"
+
+	"github.com/google/uuid"
+)
+
+// Header is the request header carrying the API key
+const Header = "X-API-Key"
+
+// CacheTTL is how long a key lookup is cached. Revoking or rotating a key clears
+// its entry; a disabled owner keeps working until the entry expires.
+const CacheTTL = 5 * time.Minute
+
+// IssuedAPIKey is returned when a key is created or rotated. Key is only shown once.
+type IssuedAPIKey struct {
+	*entity.APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyUsecase defines the business logic interface for API keys
+type APIKeyUsecase interface {
+	Create(ctx context.Context, userID int, req *entity.CreateAPIKeyRequest) (*IssuedAPIKey, error)
+	List(ctx context.Context, userID int) ([]entity.APIKey, error)
+	Rotate(ctx context.Context, userID int, id uuid.UUID) (*IssuedAPIKey, error)
+	Revoke(ctx context.Context, userID int, id uuid.UUID) error
+	// Authenticate returns the principal of a key: its owner, limited to the key's scopes
+	Authenticate(ctx context.Context, key string) (requestctx.Principal, error)
+}
+
+// APIKeyRepository defines the data access interface for API keys
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *entity.APIKey) error
+	ListByUser(ctx context.Context, userID int) ([]entity.APIKey, error)
+	GetByUUID(ctx context.Context, userID int, id uuid.UUID) (*entity.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*entity.APIKey, error)
+	Save(ctx context.Context, key *entity.APIKey) error
+	TouchLastUsed(ctx context.Context, id int, at time.Time) error
+}
@@ -0,0 +1,77 @@
+package api_key
+
+import (
+	"context"
+	"time"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/database"
+	"flex-service/pkg/errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{
+		db: db,
+	}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *entity.APIKey) error {
+	if err := r.db.WithContext(ctx).Omit("User").Create(key).Error; err != nil {
+		return database.WrapError(err, "failed to create API key")
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) ListByUser(ctx context.Context, userID int) ([]entity.APIKey, error) {
+	var keys []entity.APIKey
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, errors.WrapDatabase(err, "failed to list API keys")
+	}
+	return keys, nil
+}
+
+func (r *apiKeyRepository) GetByUUID(ctx context.Context, userID int, id uuid.UUID) (*entity.APIKey, error) {
+	var key entity.APIKey
+	err := r.db.WithContext(ctx).Where("uuid = ? AND user_id = ?", id, userID).First(&key).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NotFound("API key not found")
+		}
+		return nil, errors.WrapDatabase(err, "failed to get API key")
+	}
+	return &key, nil
+}
+
+// GetByPrefix loads a key with its owner for authentication
+func (r *apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*entity.APIKey, error) {
+	var key entity.APIKey
+	err := r.db.WithContext(ctx).Preload("User").Where("prefix = ?", prefix).First(&key).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NotFound("API key not found")
+		}
+		return nil, errors.WrapDatabase(err, "failed to get API key")
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) Save(ctx context.Context, key *entity.APIKey) error {
+	if err := r.db.WithContext(ctx).Omit("User").Save(key).Error; err != nil {
+		return database.WrapError(err, "failed to save API key")
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id int, at time.Time) error {
+	if err := r.db.WithContext(ctx).Model(&entity.APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error; err != nil {
+		return errors.WrapDatabase(err, "failed to update API key")
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package api_key
+
+import (
+	"net/http"
+
+	"flex-service/pkg/routes"
+)
+
+// Manifest declares the API key management routes for the signed-in user. The
+// keys themselves authenticate with middleware.APIKeyAuthenticate.
+var Manifest = routes.Manifest{
+	Module:     "api_key",
+	Prefix:     "/api-keys",
+	Middleware: []string{"auth"},
+	Routes: []routes.Route{
+		{Method: http.MethodGet, Path: "", Handler: "List", Summary: "List your API keys"},
+		{Method: http.MethodPost, Path: "", Handler: "Create", Middleware: []string{"rate.user:10,1m"}, Summary: "Create an API key"},
+		{Method: http.MethodPost, Path: "/:id/rotate", Handler: "Rotate", Middleware: []string{"rate.user:10,1m"}, Summary: "Replace the secret of an API key"},
+		{Method: http.MethodDelete, Path: "/:id", Handler: "Revoke", Summary: "Revoke an API key"},
+	},
+}
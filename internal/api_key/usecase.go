@@ -0,0 +1,259 @@
+package api_key
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/auth"
+	"flex-service/pkg/cache"
+	"flex-service/pkg/errors"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/requestctx"
+	"flex-service/pkg/utils"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// KeyPrefix starts every key, so leaked keys are easy to recognize and scan for
+const KeyPrefix = "ak_"
+
+type apiKeyUsecase struct {
+	repo  APIKeyRepository
+	cache cache.Cache
+	roles auth.RoleResolver
+}
+
+// NewAPIKeyUsecase creates the API key usecase. roles fills the owner's roles
+// on the principal and may be nil.
+func NewAPIKeyUsecase(repo APIKeyRepository, cache cache.Cache, roles auth.RoleResolver) APIKeyUsecase {
+	return &apiKeyUsecase{
+		repo:  repo,
+		cache: cache,
+		roles: roles,
+	}
+}
+
+// cachedKey is what Authenticate needs, cached under api_key:<prefix>
+type cachedKey struct {
+	ID        int        `json:"id"`
+	UUID      string     `json:"uuid"`
+	KeyHash   string     `json:"key_hash"`
+	Scopes    string     `json:"scopes"`
+	Plan      string     `json:"plan"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	UserID    int        `json:"user_id"`
+	UserUUID  string     `json:"user_uuid"`
+	Email     string     `json:"email"`
+}
+
+func cacheKey(prefix string) string {
+	return "api_key:" + prefix
+}
+
+func (u *apiKeyUsecase) Create(ctx context.Context, userID int, req *entity.CreateAPIKeyRequest) (*IssuedAPIKey, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.BadRequest("expires_at must be in the future")
+	}
+
+	key := &entity.APIKey{
+		UserID:    userID,
+		Name:      req.Name,
+		Scopes:    strings.Join(req.Scopes, " "),
+		ExpiresAt: req.ExpiresAt,
+	}
+	plain, err := newKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.repo.Create(ctx, key); err != nil {
+		return nil, err
+	}
+
+	logger.Info("API key created", zap.Int("user_id", userID), zap.String("prefix", key.Prefix))
+	return &IssuedAPIKey{APIKey: key, Key: plain}, nil
+}
+
+func (u *apiKeyUsecase) List(ctx context.Context, userID int) ([]entity.APIKey, error) {
+	return u.repo.ListByUser(ctx, userID)
+}
+
+// Rotate replaces the secret of a key, keeping its ID, name, scopes and expiry.
+// The old key stops working immediately.
+func (u *apiKeyUsecase) Rotate(ctx context.Context, userID int, id uuid.UUID) (*IssuedAPIKey, error) {
+	key, err := u.repo.GetByUUID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !key.IsUsable(time.Now()) {
+		return nil, errors.BadRequest("API key is revoked or expired")
+	}
+
+	oldPrefix := key.Prefix
+	plain, err := newKey(key)
+	if err != nil {
+		return nil, err
+	}
+	key.LastUsedAt = nil
+
+	if err := u.repo.Save(ctx, key); err != nil {
+		return nil, err
+	}
+	u.forget(ctx, oldPrefix)
+
+	logger.Info("API key rotated", zap.Int("user_id", userID), zap.String("prefix", key.Prefix))
+	return &IssuedAPIKey{APIKey: key, Key: plain}, nil
+}
+
+func (u *apiKeyUsecase) Revoke(ctx context.Context, userID int, id uuid.UUID) error {
+	key, err := u.repo.GetByUUID(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	key.RevokedAt = &now
+	if err := u.repo.Save(ctx, key); err != nil {
+		return err
+	}
+	u.forget(ctx, key.Prefix)
+
+	logger.Info("API key revoked", zap.Int("user_id", userID), zap.String("prefix", key.Prefix))
+	return nil
+}
+
+func (u *apiKeyUsecase) Authenticate(ctx context.Context, plain string) (requestctx.Principal, error) {
+	prefix, ok := parsePrefix(plain)
+	if !ok {
+		return requestctx.Principal{}, errors.Unauthorized("Invalid API key")
+	}
+
+	cached, err := u.lookup(ctx, prefix)
+	if err != nil {
+		return requestctx.Principal{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashKey(plain)), []byte(cached.KeyHash)) != 1 {
+		return requestctx.Principal{}, errors.Unauthorized("Invalid API key")
+	}
+	if cached.ExpiresAt != nil && !time.Now().Before(*cached.ExpiresAt) {
+		return requestctx.Principal{}, errors.Unauthorized("API key has expired")
+	}
+
+	principal := requestctx.NewUserPrincipal(cached.UserID, cached.UserUUID, cached.Email)
+	principal.Scopes = strings.Fields(cached.Scopes)
+	principal.APIKeyID = cached.UUID
+	principal.Plan = cached.Plan
+	if u.roles != nil {
+		roles, err := u.roles.UserRoles(ctx, cached.UserID)
+		if err != nil {
+			return requestctx.Principal{}, err
+		}
+		principal.Roles = roles
+	}
+	return principal, nil
+}
+
+// lookup returns the usable key for prefix from the cache or the database. Cache
+// misses also refresh the key's last used time, so it is accurate to CacheTTL.
+func (u *apiKeyUsecase) lookup(ctx context.Context, prefix string) (*cachedKey, error) {
+	if u.cache == nil {
+		return u.load(ctx, prefix)
+	}
+	return cache.Remember(ctx, u.cache, cacheKey(prefix), CacheTTL, func() (*cachedKey, error) {
+		return u.load(ctx, prefix)
+	})
+}
+
+// load reads the key for prefix from the database and checks it is usable
+func (u *apiKeyUsecase) load(ctx context.Context, prefix string) (*cachedKey, error) {
+	key, err := u.repo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.HasCode(err, errors.ErrNotFound) {
+			return nil, errors.Unauthorized("Invalid API key")
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if !key.IsUsable(now) {
+		return nil, errors.Unauthorized("API key is revoked or expired")
+	}
+	if !key.User.IsActive() {
+		return nil, errors.AccountDisabled()
+	}
+
+	cached := cachedKey{
+		ID:        key.ID,
+		UUID:      key.UUID.String(),
+		KeyHash:   key.KeyHash,
+		Scopes:    key.Scopes,
+		Plan:      key.Plan,
+		ExpiresAt: key.ExpiresAt,
+		UserID:    key.UserID,
+		UserUUID:  key.User.UUID.String(),
+	}
+	if key.User.Email != nil {
+		cached.Email = *key.User.Email
+	}
+
+	if err := u.repo.TouchLastUsed(ctx, key.ID, now); err != nil {
+		logger.Warn("Failed to update API key last used time", zap.String("prefix", prefix), zap.Error(err))
+	}
+
+	return &cached, nil
+}
+
+func (u *apiKeyUsecase) forget(ctx context.Context, prefix string) {
+	if u.cache == nil {
+		return
+	}
+	if err := u.cache.Del(ctx, cacheKey(prefix)); err != nil {
+		logger.Warn("Failed to clear cached API key", zap.String("prefix", prefix), zap.Error(err))
+	}
+}
+
+// newKey generates a key of the form ak_<12 hex>_<48 hex>, setting the prefix and
+// hash on key. The prefix is the public part used for lookups.
+func newKey(key *entity.APIKey) (string, error) {
+	id, err := utils.GenerateRandomString(6)
+	if err != nil {
+		return "", errors.WrapInternal(err, "failed to generate API key")
+	}
+	secret, err := utils.GenerateRandomString(24)
+	if err != nil {
+		return "", errors.WrapInternal(err, "failed to generate API key")
+	}
+
+	key.Prefix = KeyPrefix + id
+	plain := key.Prefix + "_" + secret
+	key.KeyHash = hashKey(plain)
+	return plain, nil
+}
+
+// parsePrefix returns the lookup prefix of a key
+func parsePrefix(plain string) (string, bool) {
+	if !strings.HasPrefix(plain, KeyPrefix) {
+		return "", false
+	}
+	i := strings.LastIndex(plain, "_")
+	if i <= len(KeyPrefix) {
+		return "", false
+	}
+	return plain[:i], true
+}
+
+// hashKey hashes a key for storage. Keys are random, so SHA-256 is enough.
+func hashKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
@@ -3,14 +3,40 @@ package container
 import (
 	"context"
 	"flex-service/config"
+	"flex-service/internal/api_key"
+	"flex-service/internal/draft"
+	"flex-service/internal/message_template"
+	"flex-service/internal/rbac"
+	"flex-service/internal/report"
 	"flex-service/internal/user_auth"
+	"flex-service/pkg/audit"
 
+	"flex-service/pkg/auth"
 	"flex-service/pkg/cache"
+	"flex-service/pkg/counter"
 	"flex-service/pkg/database"
+	"flex-service/pkg/email"
+	"flex-service/pkg/event"
+	"flex-service/pkg/experiment"
+	"flex-service/pkg/export"
+	"flex-service/pkg/feature"
+	"flex-service/pkg/httpcache"
+	"flex-service/pkg/idempotency"
+	"flex-service/pkg/imaging"
 	"flex-service/pkg/logger"
 	"flex-service/pkg/mail"
+	"flex-service/pkg/maintenance"
+	"flex-service/pkg/metrics"
+	"flex-service/pkg/model"
+	"flex-service/pkg/notification"
 	"flex-service/pkg/rate_limit"
+	"flex-service/pkg/routes"
+	"flex-service/pkg/search"
 	"flex-service/pkg/secure"
+	"flex-service/pkg/session"
+	"flex-service/pkg/signing"
+	"flex-service/pkg/storage"
+	"flex-service/pkg/webhook"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -20,20 +46,99 @@ import (
 type Container struct {
 	Config *config.Config
 
+	// Lifecycle starts and stops the background subsystems; append the HTTP
+	// server, queue workers and other long-running parts to it
+	Lifecycle *Lifecycle
+
 	// Core infrastructure
-	Database  database.Database
-	Cache     cache.Cache
-	Mail      *mail.Mailer
-	Secure    *secure.Secure
-	RateLimit rate_limit.RateLimit
+	Database    database.Database
+	Cache       cache.Cache
+	Mail        *mail.Mailer
+	Email       *email.Mailer
+	Secure      *secure.Secure
+	Sessions    *session.Manager
+	Maintenance *maintenance.Mode
+	RateLimit   rate_limit.RateLimit
+	Quotas      *rate_limit.Quotas // rate limit multipliers per plan and role
+	Feature     *feature.Manager
+	Permissions *auth.PermissionChecker
+	Policies    *auth.Policies // entity policies for authorize.Can, see internal/policies
+	Events      event.Bus
+	Signer      *signing.Signer
+	Verifier    *signing.Verifier
+	Storage     storage.Filesystem
+	Notifier    *notification.Notifier
+	// Observers calls per-entity created/updated/deleted callbacks on every
+	// write through DB; register them with model.Observe
+	Observers *model.Observers
+	// ResponseCache caches GET responses of routes using the "cache" ref;
+	// call Invalidate from usecases that change the data outside a route
+	ResponseCache *httpcache.ResponseCache
+	// Idempotency stores the responses of routes using the "idempotent" ref
+	// for retries with the same Idempotency-Key
+	Idempotency *idempotency.Store
 
 	// Backward compatibility (deprecated, use Database interface instead)
 	DB *gorm.DB
 
 	// Application services (registered via ServiceRegistry)
+	RBACRepo    rbac.RBACRepository
+	RBACUsecase rbac.RBACUsecase
+	RBACHandler *rbac.RBACHandler
+
 	UserAuthRepo    user_auth.UserAuthRepository
 	UserAuthUsecase user_auth.UserAuthUsecase
 	UserAuthHandler *user_auth.UserAuthHandler
+	JWTKeys         *auth.KeySet // nil when tokens are signed with the HS256 secret
+
+	SessionAdminUsecase user_auth.SessionAdminUsecase
+	SessionAdminHandler *user_auth.SessionAdminHandler
+
+	APIKeyRepo    api_key.APIKeyRepository
+	APIKeyUsecase api_key.APIKeyUsecase
+	APIKeyHandler *api_key.APIKeyHandler
+
+	DraftRepo    draft.DraftRepository
+	DraftUsecase draft.DraftUsecase // Register forms here to enable their drafts
+	DraftHandler *draft.DraftHandler
+
+	ReportRepo    report.ReportRepository
+	ReportUsecase report.ReportUsecase // Register report types here
+	ReportHandler *report.ReportHandler
+
+	Export        *export.Manager // Register datasets here
+	ExportHandler *export.Handler
+
+	MessageTemplateRepo    message_template.MessageTemplateRepository
+	MessageTemplateUsecase message_template.MessageTemplateUsecase
+	MessageTemplateHandler *message_template.MessageTemplateHandler
+
+	Experiment        *experiment.Manager
+	ExperimentHandler *experiment.Handler
+
+	ImageProcessor *imaging.Processor
+
+	Webhooks       *webhook.Manager
+	WebhookHandler *webhook.Handler
+
+	Counters *counter.Service // nil without Redis
+
+	RateLimitPolicies *rate_limit.Policies
+
+	Health *metrics.Health // register further checks here
+	Probes *metrics.Probes
+	System *metrics.SystemCollector // CPU, load, memory and disk samples
+
+	Audit *audit.Recorder // nil when auditing is disabled
+
+	// Search indexes entities registered with search.Register; nil when
+	// SEARCH_DRIVER is unset
+	Search *search.Engine
+
+	// Bindings of the modules registered with Register (see module.go)
+	bindings        *bindings
+	moduleManifests []routes.Manifest
+	moduleHandlers  map[string]interface{}
 }
 
 // NewContainer creates a new container with all dependencies using the factory pattern
@@ -50,13 +155,53 @@ func NewContainer(cfg *config.Config) (*Container, error) {
 
 	// Create container with core dependencies
 	container := &Container{
-		Config:    cfg,
-		Database:  deps.Database,
-		Cache:     deps.Cache,
-		Mail:      deps.Mail,
-		Secure:    deps.Secure,
-		DB:        deps.Database.GetDB(), // Backward compatibility
-		RateLimit: deps.RateLimit,
+		Config:      cfg,
+		Lifecycle:   NewLifecycle(),
+		Database:    deps.Database,
+		Cache:       deps.Cache,
+		Mail:        deps.Mail,
+		Email:       deps.Email,
+		Secure:      deps.Secure,
+		Sessions:    deps.Sessions,
+		Maintenance: deps.Maintenance,
+		DB:          deps.Database.GetDB(), // Backward compatibility
+		RateLimit:   deps.RateLimit,
+		Quotas:      deps.Quotas,
+		Feature:     deps.Feature,
+		Permissions: deps.Permissions,
+		Policies:    auth.NewPolicies(),
+		Events:      event.NewBus(),
+		Observers:   model.NewObservers(),
+		Signer:      deps.Signer,
+		Verifier:    deps.Verifier,
+		Storage:     deps.Storage,
+		Notifier:    deps.Notifier,
+
+		ResponseCache: httpcache.New(deps.Cache),
+		Idempotency:   idempotency.New(deps.Cache),
+
+		bindings:       newBindings(),
+		moduleHandlers: make(map[string]interface{}),
+	}
+
+	// Connections are appended first so they are closed last
+	container.Lifecycle.Append(Hook{Name: "database", OnStop: func(context.Context) error {
+		return container.Database.Close()
+	}})
+	if container.Cache != nil {
+		container.Lifecycle.Append(Hook{Name: "cache", OnStop: func(context.Context) error {
+			return container.Cache.Close()
+		}})
+	}
+
+	// Open email digests are sent before the connections they may use close
+	if container.Email != nil {
+		container.Lifecycle.Append(Hook{Name: "email digests", OnStop: container.Email.Flush})
+	}
+
+	if err := container.DB.Use(container.Observers); err != nil {
+		logger.Error("Failed to install model observers", zap.Error(err))
+		return nil, err
 	}
 
 	// Register application services
@@ -66,6 +211,12 @@ func NewContainer(cfg *config.Config) (*Container, error) {
 		return nil, err
 	}
 
+	// Then the self-registering modules, which may use the services above
+	if err := container.loadModules(); err != nil {
+		logger.Error("Failed to load modules", zap.Error(err))
+		return nil, err
+	}
+
 	logger.Info("Container created successfully")
 	return container, nil
 }
@@ -148,31 +299,16 @@ func (c *Container) SeedData(seederName string) error {
 	return c.Database.SeedData(seederName)
 }
 
-// Close closes all container resources gracefully
+// Close stops every lifecycle hook in reverse order: the HTTP server and
+// workers first, then background tasks, then the cache and database
 func (c *Container) Close() error {
 	logger.Info("Closing container resources")
 
-	var lastError error
-
-	// Close cache connection if available
-	if c.Cache != nil {
-		if err := c.Cache.Close(); err != nil {
-			logger.Error("Failed to close cache", zap.Error(err))
-			lastError = err
-		}
-	}
-
-	// Close database connection
-	if err := c.Database.Close(); err != nil {
-		logger.Error("Failed to close database", zap.Error(err))
-		lastError = err
-	}
-
-	if lastError == nil {
-		logger.Info("Container resources closed successfully")
-	} else {
-		logger.Error("Container resources closed with errors", zap.Error(lastError))
+	if err := c.Lifecycle.Stop(context.Background()); err != nil {
+		logger.Error("Container resources closed with errors", zap.Error(err))
+		return err
 	}
 
-	return lastError
+	logger.Info("Container resources closed successfully")
+	return nil
 }
@@ -1,15 +1,27 @@
 package container
 
 import (
+	"context"
 	"flex-service/config"
+	"flex-service/pkg/auth"
 	"flex-service/pkg/cache"
 	"flex-service/pkg/database"
+	"flex-service/pkg/email"
+	"flex-service/pkg/feature"
 	"flex-service/pkg/logger"
 	"flex-service/pkg/mail"
+	"flex-service/pkg/maintenance"
+	"flex-service/pkg/notification"
 	"flex-service/pkg/rate_limit"
 	"flex-service/pkg/secure"
+	"flex-service/pkg/session"
+	"flex-service/pkg/signing"
+	"flex-service/pkg/storage"
+	"fmt"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"go.uber.org/zap"
 )
 
@@ -61,7 +73,11 @@ func (f *ContainerFactory) CreateCache() (cache.Cache, error) {
 		return nil, nil // This is expected, not an error
 	}
 
-	cacheInstance, err := cache.NewCache(&f.config.Redis)
+	if f.config.Cache.Driver != "redis" && f.config.Cache.Driver != "tiered" {
+		return nil, fmt.Errorf("unsupported CACHE_DRIVER %q", f.config.Cache.Driver)
+	}
+
+	client, err := cache.NewRedisClient(&f.config.Redis)
 	if err != nil {
 		logger.Warn("Failed to initialize Redis cache",
 			zap.Error(err),
@@ -77,7 +93,22 @@ func (f *ContainerFactory) CreateCache() (cache.Cache, error) {
 		return nil, nil
 	}
 
+	cacheConfig := cache.NewCacheConfig(&f.config.Cache)
+
+	var cacheInstance cache.Cache
+	if f.config.Cache.Driver == "tiered" {
+		cacheInstance = cache.NewTieredCache(client, cacheConfig, &cache.TieredConfig{
+			Size: f.config.Cache.LocalSize,
+			TTL:  f.config.Cache.LocalTTL,
+		})
+	} else {
+		cacheInstance = cache.NewRedisCache(client, cacheConfig)
+	}
+	cache.TrackHotKeys(f.config.Cache.HotKeys)
+
 	logger.Info("Redis cache connected successfully",
+		zap.String("driver", f.config.Cache.Driver),
+		zap.Strings("versioned", cacheConfig.Versioned),
 		zap.String("host", f.config.Redis.Host),
 		zap.Int("port", f.config.Redis.Port))
 
@@ -101,8 +132,34 @@ func (f *ContainerFactory) CreateMailer() (*mail.Mailer, error) {
 	return mailer, nil
 }
 
-// CreateSecure creates secure instance
+// CreateEmail creates the templated email mailer. Call WithQueue on it where a
+// queue dispatcher is available to enable email.Queued.
+func (f *ContainerFactory) CreateEmail() (*email.Mailer, error) {
+	mailer, err := email.New(&f.config.Email)
+	if err != nil {
+		logger.Error("Failed to create email mailer", zap.Error(err))
+		return nil, err
+	}
+
+	// Re-read templates on every send in development
+	if f.config.Env == "development" {
+		mailer.WithRenderer(email.NewRenderer(f.config.Email.TemplateDir, false))
+	}
+
+	logger.Info("Email mailer created successfully", zap.String("template_dir", f.config.Email.TemplateDir))
+	return mailer, nil
+}
+
+// CreateSecure creates secure instance and registers the encrypted field
+// serializer with the same key
 func (f *ContainerFactory) CreateSecure() (*secure.Secure, error) {
+	fields, err := secure.NewFieldCipher(&f.config.Secure)
+	if err != nil {
+		logger.Error("Failed to create field cipher", zap.Error(err))
+		return nil, err
+	}
+	secure.RegisterSerializer(fields)
+
 	secure, err := secure.NewSecure(&f.config.Secure)
 	if err != nil {
 		logger.Error("Failed to create secure instance", zap.Error(err))
@@ -113,15 +170,93 @@ func (f *ContainerFactory) CreateSecure() (*secure.Secure, error) {
 	return secure, nil
 }
 
+// CreateSessions creates the cookie session manager with the SESSION_DRIVER store
+func (f *ContainerFactory) CreateSessions(cacheInstance cache.Cache, db database.Database, codec *secure.Secure) (*session.Manager, error) {
+	cfg := f.config.Session
+
+	var store session.Store
+	switch cfg.Driver {
+	case "redis":
+		redisCache, ok := cacheInstance.(cache.ClientProvider)
+		if !ok {
+			if f.config.Env == "production" {
+				return nil, fmt.Errorf("SESSION_DRIVER=redis requires Redis")
+			}
+			logger.Warn("Redis not available, sessions kept in memory")
+			store = session.NewMemoryStore()
+			break
+		}
+		store = session.NewRedisStore(redisCache.Client(), "")
+	case "database":
+		gormStore := session.NewGormStore(db.GetDB())
+		go purgeSessions(gormStore)
+		store = gormStore
+	case "memory":
+		store = session.NewMemoryStore()
+	default:
+		return nil, fmt.Errorf("unsupported SESSION_DRIVER %q", cfg.Driver)
+	}
+
+	manager := session.NewManager(store, codec, session.Config{
+		CookieName: cfg.Cookie,
+		Domain:     cfg.Domain,
+		Lifetime:   cfg.Lifetime,
+		Secure:     cfg.Secure,
+		SameSite:   session.ParseSameSite(cfg.SameSite),
+	})
+
+	logger.Info("Session manager created successfully",
+		zap.String("driver", cfg.Driver),
+		zap.String("cookie", cfg.Cookie))
+	return manager, nil
+}
+
+// purgeSessions deletes expired database sessions every hour
+func purgeSessions(store *session.GormStore) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for range ticker.C {
+		if _, err := store.Purge(context.Background()); err != nil {
+			logger.Warn("Failed to purge expired sessions", zap.Error(err))
+		}
+	}
+}
+
+// CreateMaintenance creates the maintenance mode that artisan down and up
+// switch. Outside production the file driver stands in for a missing Redis.
+func (f *ContainerFactory) CreateMaintenance(cacheInstance cache.Cache) (*maintenance.Mode, error) {
+	cfg := f.config.Maintenance
+
+	var client *redis.Client
+	if redisCache, ok := cacheInstance.(cache.ClientProvider); ok {
+		client = redisCache.Client()
+	}
+	driver := cfg.Driver
+	if driver == "redis" && client == nil && f.config.Env != "production" {
+		logger.Warn("Redis not available, maintenance state read from file", zap.String("file", cfg.File))
+		driver = "file"
+	}
+
+	store, err := maintenance.NewStore(driver, cfg.File, client)
+	if err != nil {
+		logger.Error("Failed to create maintenance mode", zap.Error(err))
+		return nil, err
+	}
+
+	logger.Info("Maintenance mode created successfully", zap.String("driver", driver))
+	return maintenance.New(store, cfg.Refresh), nil
+}
+
 // CreateRateLimit creates rate limit instance
 
-func (f *ContainerFactory) CreateRateLimit(cache cache.Cache) (rate_limit.RateLimit, error) {
+func (f *ContainerFactory) CreateRateLimit(cache cache.Cache, quotas *rate_limit.Quotas) (rate_limit.RateLimit, error) {
 	rateLimitConfig := &rate_limit.RateLimitConfig{
 		Limit:  f.config.Ratelimit.Limit,
 		Window: f.config.Ratelimit.Window,
 		Skip: func(c *gin.Context) bool {
 			return f.config.Env == "development"
 		},
+		Quotas: quotas,
 	}
 
 	rateLimit, err := rate_limit.NewRateLimit(cache, rateLimitConfig)
@@ -134,6 +269,148 @@ func (f *ContainerFactory) CreateRateLimit(cache cache.Cache) (rate_limit.RateLi
 	return rateLimit, nil
 }
 
+// CreateQuotas creates the rate limit multipliers per plan and role. Invalid
+// tiers fail startup, since they would silently leave customers on the
+// default limits.
+func (f *ContainerFactory) CreateQuotas(cache cache.Cache) (*rate_limit.Quotas, error) {
+	cfg := f.config.Ratelimit
+	tiers, err := rate_limit.ParseTiers(cfg.Quotas)
+	if err != nil {
+		logger.Error("Failed to create rate limit quotas", zap.Error(err))
+		return nil, err
+	}
+
+	quotas := rate_limit.NewQuotas(cache, &rate_limit.QuotaConfig{
+		Tiers:    tiers,
+		Default:  cfg.QuotaDefault,
+		CacheTTL: cfg.QuotaCacheTTL,
+	})
+	logger.Info("Rate limit quotas created successfully", zap.Int("tiers", len(tiers)))
+	return quotas, nil
+}
+
+// CreateFeature creates the feature flag manager. A missing or invalid flags file
+// leaves every flag off rather than failing startup.
+func (f *ContainerFactory) CreateFeature() (*feature.Manager, error) {
+	store, _ := feature.NewMemoryStore()
+
+	if f.config.Feature.FlagsFile != "" {
+		loaded, err := feature.LoadFile(f.config.Feature.FlagsFile)
+		if err != nil {
+			logger.Warn("Failed to load feature flags, all flags disabled",
+				zap.String("file", f.config.Feature.FlagsFile),
+				zap.Error(err))
+		} else {
+			store = loaded
+		}
+	}
+
+	logger.Info("Feature flag manager created successfully")
+	return feature.NewManager(store, feature.NewExpvarRecorder()), nil
+}
+
+// CreatePermissions creates the permission checker. A missing or invalid roles file
+// leaves no roles defined rather than failing startup.
+func (f *ContainerFactory) CreatePermissions() (*auth.PermissionChecker, error) {
+	checker, _ := auth.NewPermissionChecker()
+
+	if f.config.Auth.RolesFile != "" {
+		loaded, err := auth.LoadRolesFile(f.config.Auth.RolesFile)
+		if err != nil {
+			logger.Warn("Failed to load roles, no roles defined",
+				zap.String("file", f.config.Auth.RolesFile),
+				zap.Error(err))
+		} else {
+			checker = loaded
+		}
+	}
+
+	logger.Info("Permission checker created successfully")
+	return checker, nil
+}
+
+// CreateSigning creates the outbound request signer and inbound verifier.
+// The signer is nil when SIGNING_KEY_ID is unset; a verifier without trusted keys rejects every request.
+func (f *ContainerFactory) CreateSigning(cache cache.Cache) (*signing.Signer, *signing.Verifier, error) {
+	cfg := f.config.Signing
+
+	trusted, err := signing.ParseKeyList(cfg.TrustedKeys)
+	if err != nil {
+		logger.Error("Failed to parse trusted signing keys", zap.Error(err))
+		return nil, nil, err
+	}
+
+	verifier := signing.NewVerifier(cfg.MaxSkew, trusted...)
+	if cache != nil {
+		verifier.WithNonceCache(cache)
+	}
+
+	if cfg.KeyID == "" {
+		logger.Info("Request signing disabled - no signing key configured")
+		return nil, verifier, nil
+	}
+
+	key, err := signing.ParseKey(cfg.KeyID, cfg.Algorithm, cfg.Key, true)
+	if err != nil {
+		logger.Error("Failed to parse signing key", zap.Error(err))
+		return nil, nil, err
+	}
+
+	signer, err := signing.NewSigner(key)
+	if err != nil {
+		logger.Error("Failed to create request signer", zap.Error(err))
+		return nil, nil, err
+	}
+
+	logger.Info("Request signer created successfully", zap.String("key_id", cfg.KeyID))
+	return signer, verifier, nil
+}
+
+// CreateNotifier creates the notifier with the email and webhook channels, plus
+// SMS, Slack and push when their credentials are configured. Call WithQueue on
+// it where a queue dispatcher is available to enable notification.Queued.
+func (f *ContainerFactory) CreateNotifier(mailer *email.Mailer, signer *signing.Signer) (*notification.Notifier, error) {
+	cfg := &f.config.Notification
+
+	notifier := notification.NewNotifier().
+		Register(notification.ChannelEmail, notification.NewEmailChannel(mailer)).
+		Register(notification.ChannelWebhook, notification.NewWebhookChannel(cfg, signer)).
+		Register(notification.ChannelSlack, notification.NewSlackChannel(cfg))
+
+	if cfg.SMSAccountSID != "" {
+		sms, err := notification.NewSMSChannel(cfg)
+		if err != nil {
+			logger.Error("Failed to create SMS channel", zap.Error(err))
+			return nil, err
+		}
+		notifier.Register(notification.ChannelSMS, sms)
+	}
+
+	if cfg.FCMCredentialsFile != "" {
+		push, err := notification.NewPushChannel(cfg)
+		if err != nil {
+			logger.Error("Failed to create push channel", zap.Error(err))
+			return nil, err
+		}
+		notifier.Register(notification.ChannelPush, push)
+	}
+
+	logger.Info("Notifier created successfully", zap.Strings("channels", notifier.Channels()))
+	return notifier, nil
+}
+
+// CreateStorage creates the filesystem driver selected by STORAGE_DRIVER
+func (f *ContainerFactory) CreateStorage() (storage.Filesystem, error) {
+	fs, err := storage.New(f.config.Storage)
+	if err != nil {
+		logger.Error("Failed to create storage", zap.String("driver", f.config.Storage.Driver), zap.Error(err))
+		return nil, err
+	}
+
+	logger.Info("Storage created successfully", zap.String("driver", f.config.Storage.Driver))
+	return fs, nil
+}
+
 // CreateAll creates all dependencies at once
 func (f *ContainerFactory) CreateAll() (*AllDependencies, error) {
 	deps := &AllDependencies{}
@@ -157,14 +434,68 @@ func (f *ContainerFactory) CreateAll() (*AllDependencies, error) {
 		return nil, err
 	}
 
+	// Create email mailer (required)
+	deps.Email, err = f.CreateEmail()
+	if err != nil {
+		return nil, err
+	}
+
 	// Create secure (required)
 	deps.Secure, err = f.CreateSecure()
 	if err != nil {
 		return nil, err
 	}
 
+	// Create sessions (required)
+	deps.Sessions, err = f.CreateSessions(deps.Cache, deps.Database, deps.Secure)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create maintenance mode (required)
+	deps.Maintenance, err = f.CreateMaintenance(deps.Cache)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create rate limit quotas (optional tiers)
+	deps.Quotas, err = f.CreateQuotas(deps.Cache)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create rate limit (required)
-	deps.RateLimit, err = f.CreateRateLimit(deps.Cache)
+	deps.RateLimit, err = f.CreateRateLimit(deps.Cache, deps.Quotas)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create feature flags (optional flags file)
+	deps.Feature, err = f.CreateFeature()
+	if err != nil {
+		return nil, err
+	}
+
+	// Create permission checker (optional roles file)
+	deps.Permissions, err = f.CreatePermissions()
+	if err != nil {
+		return nil, err
+	}
+
+	// Create storage (required)
+	deps.Storage, err = f.CreateStorage()
+	if err != nil {
+		return nil, err
+	}
+
+	// Create request signing (optional signing key)
+	deps.Signer, deps.Verifier, err = f.CreateSigning(deps.Cache)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create notifier (SMS and push only with credentials)
+	deps.Notifier, err = f.CreateNotifier(deps.Email, deps.Signer)
 	if err != nil {
 		return nil, err
 	}
@@ -174,9 +505,19 @@ func (f *ContainerFactory) CreateAll() (*AllDependencies, error) {
 
 // AllDependencies holds all created dependencies
 type AllDependencies struct {
-	Database  database.Database
-	Cache     cache.Cache
-	Mail      *mail.Mailer
-	Secure    *secure.Secure
-	RateLimit rate_limit.RateLimit
+	Database    database.Database
+	Cache       cache.Cache
+	Mail        *mail.Mailer
+	Email       *email.Mailer
+	Secure      *secure.Secure
+	Sessions    *session.Manager
+	Maintenance *maintenance.Mode
+	RateLimit   rate_limit.RateLimit
+	Quotas      *rate_limit.Quotas
+	Feature     *feature.Manager
+	Permissions *auth.PermissionChecker
+	Signer      *signing.Signer
+	Verifier    *signing.Verifier
+	Storage     storage.Filesystem
+	Notifier    *notification.Notifier
 }
@@ -0,0 +1,152 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"flex-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// DefaultStopTimeout bounds a hook's OnStop when it sets no Timeout
+const DefaultStopTimeout = 10 * time.Second
+
+// Hook is a subsystem started and stopped with the application, e.g. the
+// HTTP server, queue workers or a connection pool
+type Hook struct {
+	Name    string
+	OnStart func(ctx context.Context) error // optional
+	OnStop  func(ctx context.Context) error // optional
+	// Timeout bounds OnStop; the next hook is stopped when it runs out
+	Timeout time.Duration
+}
+
+// Lifecycle starts hooks in the order they were appended and stops them in
+// reverse, so a subsystem is stopped before the ones it depends on. Append
+// connections first and the HTTP server last.
+type Lifecycle struct {
+	mu      sync.Mutex
+	hooks   []Hook
+	started []bool
+}
+
+// NewLifecycle creates a lifecycle without hooks
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// Append adds a hook. Hooks appended after Start are not started.
+func (l *Lifecycle) Append(hook Hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, hook)
+	l.started = append(l.started, false)
+}
+
+// Background appends a hook running run in a goroutine from Start. Stop
+// cancels its context and waits for it to return, so run can finish the work
+// in hand first.
+func (l *Lifecycle) Background(name string, run func(ctx context.Context)) {
+	var (
+		cancel context.CancelFunc
+		done   chan struct{}
+	)
+	l.Append(Hook{
+		Name: name,
+		OnStart: func(ctx context.Context) error {
+			// Outlives the startup context but keeps its values
+			var runCtx context.Context
+			runCtx, cancel = context.WithCancel(context.WithoutCancel(ctx))
+			done = make(chan struct{})
+			go func() {
+				defer close(done)
+				run(runCtx)
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+}
+
+// Start runs the OnStart of every hook in order. When one fails the hooks
+// already started are stopped again.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := l.hooks
+	l.mu.Unlock()
+
+	for i, hook := range hooks {
+		if hook.OnStart != nil {
+			if err := hook.OnStart(ctx); err != nil {
+				stopErr := l.Stop(ctx)
+				return errors.Join(fmt.Errorf("failed to start %s: %w", hook.Name, err), stopErr)
+			}
+			logger.Debug("Started", zap.String("hook", hook.Name))
+		}
+		l.mu.Lock()
+		l.started[i] = true
+		l.mu.Unlock()
+	}
+	return nil
+}
+
+// Stop runs the OnStop of every started hook in reverse order, each within its
+// timeout. Hooks without OnStart, such as connections, are stopped even when
+// Start never ran. Every hook is stopped even when an earlier one fails.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := l.hooks
+	started := l.started
+	l.hooks, l.started = nil, nil
+	l.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		if hook.OnStop == nil || (hook.OnStart != nil && !started[i]) {
+			continue
+		}
+		if err := stopHook(ctx, hook); err != nil {
+			logger.Error("Failed to stop", zap.String("hook", hook.Name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("%s: %w", hook.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stopHook runs OnStop, giving up when the timeout runs out even if the hook
+// ignores its context
+func stopHook(ctx context.Context, hook Hook) error {
+	timeout := hook.Timeout
+	if timeout <= 0 {
+		timeout = DefaultStopTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- hook.OnStop(ctx) }()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			logger.Info("Stopped", zap.String("hook", hook.Name), zap.Duration(logger.FieldDuration, time.Since(start)))
+		}
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("stop timed out after %s: %w", timeout, ctx.Err())
+	}
+}
@@ -0,0 +1,230 @@
+package container
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"flex-service/pkg/logger"
+	"flex-service/pkg/routes"
+
+	"go.uber.org/zap"
+)
+
+// Binding errors
+var (
+	ErrUnknownBinding     = errors.New("unknown binding")
+	ErrDuplicateBinding   = errors.New("binding already provided")
+	ErrCircularDependency = errors.New("circular dependency")
+)
+
+// Provider constructs a binding, resolving its dependencies through r. It runs
+// once, the first time the binding is resolved.
+type Provider func(r *Resolver) (interface{}, error)
+
+// Module is a feature package that registers itself from its module.go:
+//
+//	func init() {
+//		container.Register(container.Module{
+//			Name:      "product",
+//			Providers: map[string]container.Provider{"product.handler": ...},
+//			Manifests: []routes.Manifest{Manifest},
+//			Handlers:  map[string]string{"product": "product.handler"},
+//		})
+//	}
+//
+// and is compiled in by a blank import in internal/modules, which make:package
+// adds.
+type Module struct {
+	Name string
+	// Providers are keyed by binding name, "<module>.<kind>" by convention,
+	// e.g. "product.usecase". Other modules may resolve them too.
+	Providers map[string]Provider
+	// Manifests are mounted under /api/v1 next to the built-in ones
+	Manifests []routes.Manifest
+	// Handlers maps each manifest's Module to the binding serving its routes
+	Handlers map[string]string
+	// Boot runs once every module's providers are known, e.g. to append
+	// lifecycle hooks or subscribe to events. Optional.
+	Boot func(r *Resolver) error
+}
+
+var (
+	modulesMu sync.Mutex
+	modules   []Module
+)
+
+// Register adds a module to every container created afterwards. It is meant
+// to be called from init and panics when the name is taken, like
+// database/sql.Register.
+func Register(module Module) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+
+	if module.Name == "" {
+		panic("container: Register of a module without a name")
+	}
+	for _, registered := range modules {
+		if registered.Name == module.Name {
+			panic("container: Register called twice for module " + module.Name)
+		}
+	}
+	modules = append(modules, module)
+}
+
+// Modules returns the registered modules in registration order
+func Modules() []Module {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	return append([]Module(nil), modules...)
+}
+
+// bindings holds the providers and the instances they constructed. mu is held
+// for a whole top-level resolution, so a binding is only constructed once.
+type bindings struct {
+	mu        sync.Mutex
+	providers map[string]Provider
+	instances map[string]interface{}
+}
+
+func newBindings() *bindings {
+	return &bindings{
+		providers: make(map[string]Provider),
+		instances: make(map[string]interface{}),
+	}
+}
+
+// Provide adds a binding constructed lazily by provider
+func (c *Container) Provide(name string, provider Provider) error {
+	c.bindings.mu.Lock()
+	defer c.bindings.mu.Unlock()
+
+	if _, exists := c.bindings.providers[name]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicateBinding, name)
+	}
+	c.bindings.providers[name] = provider
+	return nil
+}
+
+// Resolve returns the binding, constructing it and its dependencies on first use
+func (c *Container) Resolve(name string) (interface{}, error) {
+	c.bindings.mu.Lock()
+	defer c.bindings.mu.Unlock()
+	return (&Resolver{Container: c}).Resolve(name)
+}
+
+// Resolver resolves bindings for a provider and tracks the chain being
+// constructed to detect cycles. Providers resolve through it, never through
+// the container, which is locked while they run.
+type Resolver struct {
+	*Container
+	chain []string
+}
+
+// Resolve returns the binding, constructing it on first use
+func (r *Resolver) Resolve(name string) (interface{}, error) {
+	b := r.bindings
+	if instance, ok := b.instances[name]; ok {
+		return instance, nil
+	}
+	for _, pending := range r.chain {
+		if pending == name {
+			return nil, fmt.Errorf("%w: %s -> %s", ErrCircularDependency, strings.Join(r.chain, " -> "), name)
+		}
+	}
+
+	provider, ok := b.providers[name]
+	if !ok {
+		if len(r.chain) > 0 {
+			return nil, fmt.Errorf("%w %s, required by %s", ErrUnknownBinding, name, r.chain[len(r.chain)-1])
+		}
+		return nil, fmt.Errorf("%w %s", ErrUnknownBinding, name)
+	}
+
+	r.chain = append(r.chain, name)
+	instance, err := provider(r)
+	r.chain = r.chain[:len(r.chain)-1]
+	if err != nil {
+		// Errors of dependencies are already wrapped with their name
+		if errors.Is(err, ErrCircularDependency) || errors.Is(err, ErrUnknownBinding) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("provide %s: %w", name, err)
+	}
+
+	b.instances[name] = instance
+	return instance, nil
+}
+
+// binder is implemented by Container and Resolver
+type binder interface {
+	Resolve(name string) (interface{}, error)
+}
+
+// ResolveAs resolves a binding and asserts its type:
+//
+//	repo, err := container.ResolveAs[ProductRepository](r, "product.repository")
+func ResolveAs[T any](b binder, name string) (T, error) {
+	var zero T
+	instance, err := b.Resolve(name)
+	if err != nil {
+		return zero, err
+	}
+	typed, ok := instance.(T)
+	if !ok {
+		return zero, fmt.Errorf("binding %s is %T, not %T", name, instance, zero)
+	}
+	return typed, nil
+}
+
+// loadModules provides the bindings of every registered module, boots them
+// and resolves their route handlers, so a broken module fails startup rather
+// than its first request
+func (c *Container) loadModules() error {
+	registered := Modules()
+	for _, module := range registered {
+		for name, provider := range module.Providers {
+			if err := c.Provide(name, provider); err != nil {
+				return fmt.Errorf("module %s: %w", module.Name, err)
+			}
+		}
+	}
+
+	for _, module := range registered {
+		if module.Boot != nil {
+			c.bindings.mu.Lock()
+			err := module.Boot(&Resolver{Container: c})
+			c.bindings.mu.Unlock()
+			if err != nil {
+				return fmt.Errorf("module %s: %w", module.Name, err)
+			}
+		}
+
+		for manifest, binding := range module.Handlers {
+			handler, err := c.Resolve(binding)
+			if err != nil {
+				return fmt.Errorf("module %s: %w", module.Name, err)
+			}
+			c.moduleHandlers[manifest] = handler
+		}
+		c.moduleManifests = append(c.moduleManifests, module.Manifests...)
+
+		logger.Info("Module registered",
+			zap.String("module", module.Name),
+			zap.Int("bindings", len(module.Providers)),
+			zap.Int("manifests", len(module.Manifests)))
+	}
+	return nil
+}
+
+// ModuleManifests returns the route manifests of the registered modules
+func (c *Container) ModuleManifests() []routes.Manifest {
+	return c.moduleManifests
+}
+
+// ModuleHandlers returns the route handlers of the registered modules, keyed
+// by manifest module
+func (c *Container) ModuleHandlers() map[string]interface{} {
+	return c.moduleHandlers
+}
@@ -1,10 +1,41 @@
 package container
 
 import (
+	"context"
 	"errors"
+	"flex-service/config"
+	"flex-service/internal/api_key"
+	"flex-service/internal/draft"
+	"flex-service/internal/entity"
+	"flex-service/internal/message_template"
+	"flex-service/internal/policies"
+	"flex-service/internal/rbac"
+	"flex-service/internal/report"
 	"flex-service/internal/user_auth"
+	"flex-service/pkg/audit"
+	"flex-service/pkg/auth"
+	"flex-service/pkg/authorize"
+	"flex-service/pkg/cache"
+	"flex-service/pkg/counter"
+	"flex-service/pkg/experiment"
+	"flex-service/pkg/export"
+	"flex-service/pkg/imaging"
 	"flex-service/pkg/logger"
+	"flex-service/pkg/metrics"
+	"flex-service/pkg/model"
+	"flex-service/pkg/password"
+	"flex-service/pkg/rate_limit"
+	"flex-service/pkg/saml"
+	"flex-service/pkg/search"
+	"flex-service/pkg/webhook"
+	"fmt"
+	"os"
+	"strings"
 	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 // ServiceRegistry manages application service registration
@@ -19,6 +50,40 @@ func NewServiceRegistry(container *Container) *ServiceRegistry {
 	}
 }
 
+// RegisterRBAC registers role and permission management. Database roles are
+// loaded into the permission checker now and kept in sync afterwards.
+func (r *ServiceRegistry) RegisterRBAC() error {
+	if r.container.Database == nil {
+		return errors.New("database dependency not available")
+	}
+
+	rbacRepo := rbac.NewRBACRepository(r.container.Database.GetDB())
+	rbacUsecase := rbac.NewRBACUsecase(rbacRepo, r.container.Cache, r.container.Permissions)
+	rbacHandler := rbac.NewRBACHandler(rbacUsecase)
+
+	if err := rbacUsecase.Load(context.Background()); err != nil {
+		logger.Warn("Failed to load roles from the database", zap.Error(err))
+	}
+
+	// Register in container
+	r.container.RBACRepo = rbacRepo
+	r.container.RBACUsecase = rbacUsecase
+	r.container.RBACHandler = rbacHandler
+
+	logger.Info("RBAC services registered successfully")
+	return nil
+}
+
+// RegisterPolicies registers the entity policies of internal/policies and
+// makes them the default for authorize.Can
+func (r *ServiceRegistry) RegisterPolicies() error {
+	policies.Register(r.container.Policies, r.container.Permissions)
+	authorize.SetDefault(r.container.Policies)
+
+	logger.Info("Policies registered successfully")
+	return nil
+}
+
 // RegisterAuth registers authentication-related services
 func (r *ServiceRegistry) RegisterUserAuth() error {
 
@@ -42,28 +107,665 @@ func (r *ServiceRegistry) RegisterUserAuth() error {
 
 	issuer := r.container.Config.AppName
 
-	authJWT := user_auth.NewUserJWT(jwtConfig.Secret, accessTTL, refreshTTL, issuer)
+	authJWT := user_auth.NewUserJWT(jwtConfig.Secret, accessTTL, refreshTTL, issuer).
+		WithScopedTokenTTL(jwtConfig.ScopedTokenTTL, jwtConfig.ScopedTokenMaxTTL)
+
+	// Old keys must verify as long as the refresh tokens they signed
+	keys, err := r.jwtKeys(refreshTTL)
+	if err != nil {
+		return err
+	}
+	if keys != nil {
+		authJWT.WithKeySet(keys)
+	}
 
 	db := r.container.Database.GetDB()
 
 	// Create auth dependencies
 	authRepo := user_auth.NewUserAuthRepository(db)
-	authUsecase := user_auth.NewUserAuthUsecase(authRepo, authJWT, r.container.Cache)
+	authUsecase := user_auth.NewUserAuthUsecase(authRepo, authJWT, r.container.Cache, r.userAuthOptions()...)
 	authHandler := user_auth.NewUserAuthHandler(authUsecase)
+	sessionAdminUsecase := user_auth.NewSessionAdminUsecase(authRepo, authJWT, r.container.Cache)
+	sessionAdminHandler := user_auth.NewSessionAdminHandler(sessionAdminUsecase)
+
+	// Drop the cached profile whenever a user row changes, whatever changed it
+	invalidateUser := func(tx *gorm.DB, user *entity.User) error {
+		if user.ID == 0 { // updated by condition; the cache expires on its own
+			return nil
+		}
+		if err := authUsecase.InvalidateUserCache(tx.Statement.Context, user.ID); err != nil {
+			logger.Warn("Failed to invalidate user cache", zap.Int("user_id", user.ID), zap.Error(err))
+		}
+		return nil
+	}
+	model.Observe(r.container.Observers, model.Updated, invalidateUser)
+	model.Observe(r.container.Observers, model.Deleted, invalidateUser)
 
 	// Register in container
 	r.container.UserAuthRepo = authRepo
+	r.container.JWTKeys = keys
 	r.container.UserAuthUsecase = authUsecase
 	r.container.UserAuthHandler = authHandler
+	r.container.SessionAdminUsecase = sessionAdminUsecase
+	r.container.SessionAdminHandler = sessionAdminHandler
 
 	logger.Info("User auth services registered successfully")
 	return nil
 }
 
+// jwtKeys loads the RS256/EdDSA signing keys from JWT_KEYS_DIR, creating the
+// first one if needed, and keeps them rotated. It returns nil for HS256.
+func (r *ServiceRegistry) jwtKeys(retain time.Duration) (*auth.KeySet, error) {
+	cfg := r.container.Config.JWT
+	switch cfg.Algorithm {
+	case "", "HS256":
+		return nil, nil
+	case auth.AlgorithmRS256, auth.AlgorithmEdDSA:
+	default:
+		return nil, fmt.Errorf("unsupported JWT_ALGORITHM %q", cfg.Algorithm)
+	}
+
+	store, err := auth.NewDirKeyStore(cfg.KeysDir)
+	if err != nil {
+		return nil, err
+	}
+	keys, _ := auth.NewKeySet()
+	rotator := &auth.KeyRotator{
+		Store:     store,
+		Set:       keys,
+		Algorithm: cfg.Algorithm,
+		Period:    cfg.KeyRotation,
+		Retain:    retain,
+	}
+	if err := rotator.Rotate(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to load JWT signing keys: %w", err)
+	}
+	// Picks up keys rotated by other instances sharing the directory
+	r.container.Lifecycle.Background("jwt key rotation", func(ctx context.Context) {
+		rotator.Start(ctx, time.Minute)
+	})
+
+	active, _ := keys.Active()
+	logger.Info("JWT signing keys loaded",
+		zap.String("alg", active.Algorithm),
+		zap.String("kid", active.ID),
+		zap.Int("keys", len(keys.Keys())))
+	return keys, nil
+}
+
+// userAuthOptions enables TOTP two-factor, the configured OAuth providers,
+// the password policy, the login lockout and SAML login
+func (r *ServiceRegistry) userAuthOptions() []user_auth.UsecaseOption {
+	opts := []user_auth.UsecaseOption{
+		user_auth.WithOAuthProviders(r.oauthProviders()...),
+		user_auth.WithTwoFactor(r.container.Secure, r.container.Config.AppName),
+		user_auth.WithRoles(r.container.RBACUsecase),
+		user_auth.WithPasswordPolicy(r.passwordPolicy()),
+	}
+
+	if lockout := r.container.Config.Auth.Lockout; lockout.Enabled {
+		if r.container.Cache == nil {
+			logger.Warn("Login lockout disabled: it requires a cache")
+		} else {
+			opts = append(opts, user_auth.WithLockout(user_auth.LockoutPolicy{
+				MaxAttempts: lockout.MaxAttempts,
+				Window:      lockout.Window,
+				Duration:    lockout.Duration,
+				MaxDuration: lockout.MaxDuration,
+				UnlockURL:   lockout.UnlockURL,
+			}, r.container.Events, r.container.Email))
+		}
+	}
+
+	if r.container.Config.SAML.Enabled {
+		samlLogin, err := r.samlLogin()
+		if err != nil {
+			logger.Warn("SAML login disabled", zap.Error(err))
+		} else {
+			opts = append(opts, user_auth.WithSAML(samlLogin))
+			logger.Info("SAML login enabled", zap.String("entity_id", r.container.Config.SAML.EntityID))
+		}
+	}
+	return opts
+}
+
+// passwordPolicy builds the policy for new passwords, with the breach check
+// when AUTH_PASSWORD_BREACH_CHECK is on
+func (r *ServiceRegistry) passwordPolicy() *password.Policy {
+	cfg := r.container.Config.Auth.Password
+	policy := &password.Policy{
+		MinLength:       cfg.MinLength,
+		RequireUpper:    cfg.RequireUpper,
+		RequireLower:    cfg.RequireLower,
+		RequireDigit:    cfg.RequireDigit,
+		RequireSymbol:   cfg.RequireSymbol,
+		DenyList:        cfg.DenyList,
+		BreachThreshold: cfg.BreachThreshold,
+	}
+	if cfg.BreachCheck {
+		policy.Breaches = password.NewPwnedChecker(cfg.BreachAPIURL, cfg.BreachTimeout)
+	}
+	return policy
+}
+
+// samlLogin creates the SAML service provider from the IdP metadata file
+func (r *ServiceRegistry) samlLogin() (*user_auth.SAMLLogin, error) {
+	cfg := r.container.Config.SAML
+
+	idp, err := saml.LoadIdPMetadata(cfg.IdPMetadataFile)
+	if err != nil {
+		return nil, err
+	}
+	sp, err := saml.NewServiceProvider(saml.Config{
+		EntityID:          cfg.EntityID,
+		ACSURL:            cfg.ACSURL,
+		IdP:               idp,
+		AllowIdPInitiated: cfg.AllowIdPInitiated,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	roleMap := make(map[string]string, len(cfg.RoleMap))
+	for _, pair := range cfg.RoleMap {
+		group, role, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid SAML_ROLE_MAP entry %q", pair)
+		}
+		roleMap[strings.TrimSpace(group)] = strings.TrimSpace(role)
+	}
+
+	return &user_auth.SAMLLogin{
+		SP:                 sp,
+		EmailAttribute:     cfg.EmailAttribute,
+		FirstNameAttribute: cfg.FirstNameAttribute,
+		LastNameAttribute:  cfg.LastNameAttribute,
+		RolesAttribute:     cfg.RolesAttribute,
+		RoleMap:            roleMap,
+	}, nil
+}
+
+// oauthProviders creates the OAuth providers that have a client ID configured.
+// A misconfigured Apple key only disables Apple.
+func (r *ServiceRegistry) oauthProviders() []user_auth.OAuthProvider {
+	cfg := r.container.Config.OAuth
+	var providers []user_auth.OAuthProvider
+
+	clientConfig := func(c config.OAuthProviderConfig) user_auth.OAuthClientConfig {
+		return user_auth.OAuthClientConfig{ClientID: c.ClientID, ClientSecret: c.ClientSecret, RedirectURL: c.RedirectURL}
+	}
+
+	if cfg.Google.ClientID != "" {
+		providers = append(providers, user_auth.NewGoogleProvider(clientConfig(cfg.Google)))
+	}
+	if cfg.Facebook.ClientID != "" {
+		providers = append(providers, user_auth.NewFacebookProvider(clientConfig(cfg.Facebook)))
+	}
+	if cfg.LINE.ClientID != "" {
+		providers = append(providers, user_auth.NewLINEProvider(clientConfig(cfg.LINE)))
+	}
+	if cfg.Apple.ClientID != "" {
+		key, err := os.ReadFile(cfg.Apple.PrivateKeyFile)
+		if err == nil {
+			var apple *user_auth.AppleProvider
+			if apple, err = user_auth.NewAppleProvider(clientConfig(cfg.Apple.OAuthProviderConfig), cfg.Apple.TeamID, cfg.Apple.KeyID, key); err == nil {
+				providers = append(providers, apple)
+			}
+		}
+		if err != nil {
+			logger.Warn("Apple sign in disabled", zap.Error(err))
+		}
+	}
+
+	for _, provider := range providers {
+		logger.Info("OAuth provider enabled", zap.String("provider", provider.Name()))
+	}
+	return providers
+}
+
+// RegisterAPIKey registers API key management and authentication services
+func (r *ServiceRegistry) RegisterAPIKey() error {
+	if r.container.Database == nil {
+		return errors.New("database dependency not available")
+	}
+
+	apiKeyRepo := api_key.NewAPIKeyRepository(r.container.Database.GetDB())
+	apiKeyUsecase := api_key.NewAPIKeyUsecase(apiKeyRepo, r.container.Cache, r.container.RBACUsecase)
+	apiKeyHandler := api_key.NewAPIKeyHandler(apiKeyUsecase)
+
+	// Register in container
+	r.container.APIKeyRepo = apiKeyRepo
+	r.container.APIKeyUsecase = apiKeyUsecase
+	r.container.APIKeyHandler = apiKeyHandler
+
+	logger.Info("API key services registered successfully")
+	return nil
+}
+
+// RegisterDrafts registers multi-step form drafts and purges expired ones every
+// draft.PurgeInterval. Forms are registered on DraftUsecase by the modules that
+// own them.
+func (r *ServiceRegistry) RegisterDrafts() error {
+	if r.container.Database == nil {
+		return errors.New("database dependency not available")
+	}
+
+	draftRepo := draft.NewDraftRepository(r.container.Database.GetDB())
+	draftUsecase := draft.NewDraftUsecase(draftRepo)
+	draftHandler := draft.NewDraftHandler(draftUsecase)
+
+	r.container.Lifecycle.Background("draft purge", func(ctx context.Context) {
+		ticker := time.NewTicker(draft.PurgeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			purged, err := draftUsecase.Purge(ctx)
+			if err != nil {
+				logger.Warn("Failed to purge expired drafts", zap.Error(err))
+			} else if purged > 0 {
+				logger.Info("Expired drafts purged", zap.Int64("count", purged))
+			}
+		}
+	})
+
+	// Register in container
+	r.container.DraftRepo = draftRepo
+	r.container.DraftUsecase = draftUsecase
+	r.container.DraftHandler = draftHandler
+
+	logger.Info("Draft services registered successfully")
+	return nil
+}
+
+// RegisterReports registers report generation. Workers build pending reports
+// in the background; expired reports and their files are purged hourly.
+func (r *ServiceRegistry) RegisterReports() error {
+	if r.container.Database == nil {
+		return errors.New("database dependency not available")
+	}
+	if r.container.Storage == nil {
+		return errors.New("storage dependency not available")
+	}
+
+	reportRepo := report.NewReportRepository(r.container.Database.GetDB())
+	reportUsecase := report.NewReportUsecase(reportRepo, r.container.Storage)
+	reportHandler := report.NewReportHandler(reportUsecase)
+
+	r.container.Lifecycle.Background("report workers", reportUsecase.Run)
+	r.container.Lifecycle.Background("report purge", func(ctx context.Context) {
+		ticker := time.NewTicker(report.PurgeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			purged, err := reportUsecase.Purge(ctx)
+			if err != nil {
+				logger.Warn("Failed to purge expired reports", zap.Error(err))
+			} else if purged > 0 {
+				logger.Info("Expired reports purged", zap.Int("count", purged))
+			}
+		}
+	})
+
+	// Register in container
+	r.container.ReportRepo = reportRepo
+	r.container.ReportUsecase = reportUsecase
+	r.container.ReportHandler = reportHandler
+
+	logger.Info("Report services registered successfully")
+	return nil
+}
+
+// RegisterExport registers data exports and imports. Without a queue, jobs
+// run on in-process workers; job status is kept in the cache, or in memory
+// when Redis is not configured.
+func (r *ServiceRegistry) RegisterExport() error {
+	if r.container.Database == nil {
+		return errors.New("database dependency not available")
+	}
+	if r.container.Storage == nil {
+		return errors.New("storage dependency not available")
+	}
+
+	cfg := r.container.Config.Export
+	manager := export.NewManager(r.container.Database.GetDB(), r.container.Cache, r.container.Storage, export.Config{
+		ChunkSize:     cfg.ChunkSize,
+		MaxImportSize: cfg.MaxImportSize,
+		JobTTL:        cfg.JobTTL,
+		URLTTL:        cfg.URLTTL,
+		Workers:       cfg.Workers,
+	})
+	r.container.Lifecycle.Background("export workers", manager.Run)
+
+	// Register in container
+	r.container.Export = manager
+	r.container.ExportHandler = export.NewHandler(manager, r.container.Permissions)
+
+	logger.Info("Export services registered successfully")
+	return nil
+}
+
+// RegisterMessageTemplate registers email/webhook template management services
+func (r *ServiceRegistry) RegisterMessageTemplate() error {
+	if r.container.Database == nil {
+		return errors.New("database dependency not available")
+	}
+
+	templateRepo := message_template.NewMessageTemplateRepository(r.container.Database.GetDB())
+	templateUsecase, err := message_template.NewMessageTemplateUsecase(templateRepo, r.container.Mail, r.container.Config.AppName)
+	if err != nil {
+		return err
+	}
+	templateHandler := message_template.NewMessageTemplateHandler(templateUsecase)
+
+	// Register in container
+	r.container.MessageTemplateRepo = templateRepo
+	r.container.MessageTemplateUsecase = templateUsecase
+	r.container.MessageTemplateHandler = templateHandler
+
+	logger.Info("Message template services registered successfully")
+	return nil
+}
+
+// RegisterExperiment registers A/B experiment services
+func (r *ServiceRegistry) RegisterExperiment() error {
+	if r.container.Database == nil {
+		return errors.New("database dependency not available")
+	}
+
+	var experiments []*experiment.Experiment
+	if file := r.container.Config.Feature.ExperimentsFile; file != "" {
+		loaded, err := experiment.LoadFile(file)
+		if err != nil {
+			return err
+		}
+		experiments = loaded
+	}
+
+	store := experiment.NewGormStore(r.container.Database.GetDB())
+	manager, err := experiment.NewManager(store, r.container.Feature, r.container.Events, experiments...)
+	if err != nil {
+		return err
+	}
+
+	// Register in container
+	r.container.Experiment = manager
+	r.container.ExperimentHandler = experiment.NewHandler(manager)
+
+	logger.Info("Experiment services registered successfully")
+	return nil
+}
+
+// RegisterImaging registers the image variant processor used by image processing jobs
+func (r *ServiceRegistry) RegisterImaging() error {
+	if r.container.Database == nil {
+		return errors.New("database dependency not available")
+	}
+	if r.container.Storage == nil {
+		return errors.New("storage dependency not available")
+	}
+
+	cfg := r.container.Config.Imaging
+	variants, err := imaging.ParseVariants(cfg.Variants)
+	if err != nil {
+		return err
+	}
+
+	store := imaging.NewGormStore(r.container.Database.GetDB())
+	processor, err := imaging.NewProcessor(r.container.Storage, store, imaging.Config{
+		Variants:  variants,
+		Quality:   cfg.Quality,
+		MaxPixels: cfg.MaxPixels,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Register in container
+	r.container.ImageProcessor = processor
+
+	logger.Info("Image processing services registered successfully")
+	return nil
+}
+
+// RegisterWebhooks registers outgoing webhook endpoints and delivery
+func (r *ServiceRegistry) RegisterWebhooks() error {
+	if r.container.Database == nil {
+		return errors.New("database dependency not available")
+	}
+
+	cfg := r.container.Config.Webhook
+	store := webhook.NewGormStore(r.container.Database.GetDB())
+	manager := webhook.NewManager(store, webhook.Config{
+		MaxAttempts: cfg.MaxAttempts,
+		RetryBase:   cfg.RetryBase,
+		RetryMax:    cfg.RetryMax,
+		Timeout:     cfg.Timeout,
+		UserAgent:   r.container.Config.AppName + "-webhooks",
+	})
+
+	// Register in container
+	r.container.Webhooks = manager
+	r.container.WebhookHandler = webhook.NewHandler(manager)
+
+	logger.Info("Webhook services registered successfully")
+	return nil
+}
+
+// RegisterCounters registers the Redis-backed counter service and flushes it
+// to the database every COUNTER_FLUSH_INTERVAL. Counters need Redis; without
+// it Container.Counters stays nil.
+func (r *ServiceRegistry) RegisterCounters() error {
+	if r.container.Database == nil {
+		return errors.New("database dependency not available")
+	}
+	redisCache, ok := r.container.Cache.(cache.ClientProvider)
+	if !ok {
+		logger.Warn("Redis not available, counters disabled")
+		return nil
+	}
+
+	cfg := r.container.Config.Counter
+	config := counter.DefaultConfig()
+	config.KeyTTL = cfg.KeyTTL
+	service := counter.NewService(redisCache.Client(), counter.NewGormStore(r.container.Database.GetDB()), config)
+	// Flushes once more when stopped, before Redis and the database close
+	r.container.Lifecycle.Background("counter flush", func(ctx context.Context) {
+		service.Run(ctx, cfg.FlushInterval)
+	})
+
+	// Register in container
+	r.container.Counters = service
+
+	logger.Info("Counter services registered successfully",
+		zap.Duration("flush_interval", cfg.FlushInterval))
+	return nil
+}
+
+// RegisterRateLimitPolicies registers the rate limit policies from
+// RATELIMIT_POLICIES_FILE and tb_rate_limit_policy, reloading the table every
+// RATELIMIT_POLICY_REFRESH. A missing or invalid file leaves only the stored
+// policies rather than failing startup.
+func (r *ServiceRegistry) RegisterRateLimitPolicies() error {
+	if r.container.Database == nil {
+		return errors.New("database dependency not available")
+	}
+
+	cfg := r.container.Config.Ratelimit
+	var file []rate_limit.Policy
+	if cfg.PoliciesFile != "" {
+		loaded, err := rate_limit.LoadPolicyFile(cfg.PoliciesFile)
+		if err != nil {
+			logger.Warn("Failed to load rate limit policies",
+				zap.String("file", cfg.PoliciesFile),
+				zap.Error(err))
+		} else {
+			file = loaded
+		}
+	}
+
+	policies, err := rate_limit.NewPolicies(r.container.Cache, file,
+		rate_limit.NewGormPolicyStore(r.container.Database.GetDB()),
+		&rate_limit.PolicyConfig{
+			Skip: func(c *gin.Context) bool {
+				return r.container.Config.Env == "development"
+			},
+			Quotas: r.container.Quotas,
+		})
+	if err != nil {
+		return fmt.Errorf("failed to create rate limit policies: %w", err)
+	}
+	if err := policies.Reload(context.Background()); err != nil {
+		logger.Warn("Failed to load stored rate limit policies", zap.Error(err))
+	}
+	r.container.Lifecycle.Background("rate limit policy refresh", func(ctx context.Context) {
+		policies.Start(ctx, cfg.PolicyRefresh)
+	})
+
+	// Register in container
+	r.container.RateLimitPolicies = policies
+
+	logger.Info("Rate limit policies registered successfully",
+		zap.Int("file_policies", len(file)),
+		zap.Duration("refresh", cfg.PolicyRefresh))
+	return nil
+}
+
+// RegisterHealth registers the health checks behind /readyz and /healthz: the
+// database is critical, the cache only degrades the service since every
+// cache user falls back without it. The disk check is critical but degrades
+// the service on its own below the critical threshold.
+func (r *ServiceRegistry) RegisterHealth() error {
+	if r.container.Database == nil {
+		return errors.New("database dependency not available")
+	}
+
+	cfg := r.container.Config.Health
+	health := metrics.NewHealth(cfg.CheckTimeout)
+	health.Register(metrics.Check{
+		Checker:  metrics.DatabaseHealthChecker{DB: r.container.Database.GetDB()},
+		Critical: true,
+	})
+	if r.container.Cache != nil {
+		health.Register(metrics.Check{Checker: metrics.CacheHealthChecker{Cache: r.container.Cache}})
+	}
+	health.Register(metrics.Check{
+		Checker: metrics.DiskSpaceHealthChecker{
+			Path:            cfg.DiskPath,
+			WarningPercent:  float64(cfg.DiskWarningPercent),
+			CriticalPercent: float64(cfg.DiskCriticalPercent),
+		},
+		Critical: true,
+	})
+
+	system := metrics.NewSystemCollector(cfg.DiskPath)
+	system.Publish()
+	r.container.Lifecycle.Background("system metrics", func(ctx context.Context) {
+		system.Run(ctx, cfg.SystemInterval)
+	})
+
+	// Register in container
+	r.container.Health = health
+	r.container.System = system
+	r.container.Probes = metrics.NewProbes(health, metrics.ProbeConfig{
+		DegradedCode:  cfg.DegradedCode,
+		UnhealthyCode: cfg.UnhealthyCode,
+	})
+
+	logger.Info("Health checks registered successfully",
+		zap.Strings("checks", health.Names()))
+	return nil
+}
+
+// RegisterAudit creates the audit recorder and makes it the default for
+// audit.Record. Entries are written synchronously; a worker process can
+// switch to the queue with WithQueue and audit.JobHandler.
+func (r *ServiceRegistry) RegisterAudit() error {
+	if !r.container.Config.Audit.Enabled {
+		logger.Info("Audit log disabled")
+		return nil
+	}
+	if r.container.Database == nil {
+		return errors.New("database dependency not available")
+	}
+
+	recorder := audit.NewRecorder(audit.NewGormStore(r.container.Database.GetDB()))
+	audit.SetDefault(recorder)
+
+	// Register in container
+	r.container.Audit = recorder
+
+	logger.Info("Audit log registered successfully",
+		zap.Strings("methods", r.container.Config.Audit.Methods))
+	return nil
+}
+
+// RegisterSearch creates the search engine and makes it the default for
+// generated list endpoints. Modules register their entities in Boot. Indexes
+// are updated in the writing request; a worker process can switch to the
+// queue with WithQueue and search.JobHandler.
+func (r *ServiceRegistry) RegisterSearch() error {
+	cfg := r.container.Config.Search
+	if cfg.Driver == "" {
+		logger.Info("Search disabled")
+		return nil
+	}
+	if r.container.Database == nil {
+		return errors.New("database dependency not available")
+	}
+
+	var driver search.Driver
+	switch cfg.Driver {
+	case search.DriverMeilisearch:
+		driver = search.NewMeilisearch(cfg.URL, cfg.APIKey, cfg.Timeout)
+	case search.DriverElasticsearch:
+		driver = search.NewElasticsearch(cfg.URL, cfg.APIKey, cfg.Timeout)
+	default:
+		return fmt.Errorf("unknown search driver %q", cfg.Driver)
+	}
+
+	engine := search.NewEngine(driver, r.container.Database.GetDB(), search.Config{
+		IndexPrefix: cfg.IndexPrefix,
+		BatchSize:   cfg.BatchSize,
+		QueueDelay:  cfg.QueueDelay,
+	})
+	search.SetDefault(engine)
+
+	// Register in container
+	r.container.Search = engine
+
+	logger.Info("Search registered successfully",
+		zap.String("driver", cfg.Driver),
+		zap.String("index_prefix", cfg.IndexPrefix))
+	return nil
+}
+
 // RegisterAll registers all available services
 func (r *ServiceRegistry) RegisterAll() error {
 	services := []func() error{
+		r.RegisterRBAC,
+		r.RegisterPolicies,
 		r.RegisterUserAuth,
+		r.RegisterAPIKey,
+		r.RegisterDrafts,
+		r.RegisterReports,
+		r.RegisterExport,
+		r.RegisterMessageTemplate,
+		r.RegisterExperiment,
+		r.RegisterImaging,
+		r.RegisterWebhooks,
+		r.RegisterCounters,
+		r.RegisterRateLimitPolicies,
+		r.RegisterHealth,
+		r.RegisterAudit,
+		r.RegisterSearch,
 	}
 
 	for _, registerService := range services {
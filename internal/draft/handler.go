@@ -0,0 +1,110 @@
+package draft
+
+import (
+	"net/http"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/request"
+	"flex-service/pkg/requestctx"
+	"flex-service/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DraftHandler struct {
+	usecase DraftUsecase
+}
+
+func NewDraftHandler(usecase DraftUsecase) *DraftHandler {
+	return &DraftHandler{
+		usecase: usecase,
+	}
+}
+
+func (h *DraftHandler) List(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	drafts, err := h.usecase.List(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Drafts retrieved successfully", drafts)
+}
+
+// Resume returns the draft of a form, e.g. to restore a multi-step form at its
+// last step. ?reference= selects the draft of an existing entity.
+func (h *DraftHandler) Resume(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	draft, err := h.usecase.Resume(c.Request.Context(), userID, c.Param("form"), c.Query("reference"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Draft retrieved successfully", draft)
+}
+
+func (h *DraftHandler) Save(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	req, err := request.BindJSON[entity.SaveDraftRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	draft, err := h.usecase.Save(c.Request.Context(), userID, c.Param("form"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Draft saved successfully", draft)
+}
+
+func (h *DraftHandler) Discard(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	if err := h.usecase.Discard(c.Request.Context(), userID, c.Param("form"), c.Query("reference")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Draft discarded successfully", nil)
+}
+
+// Finalize validates the complete draft and creates the real entity from it
+func (h *DraftHandler) Finalize(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	result, err := h.usecase.Finalize(c.Request.Context(), userID, c.Param("form"), c.Query("reference"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Draft finalized successfully", result)
+}
@@ -0,0 +1,86 @@
+package draft
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"flex-service/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+// DefaultTTL is how long a draft is kept after its last save when its Form
+// sets no TTL
+const DefaultTTL = 7 * 24 * time.Hour
+
+// PurgeInterval is how often expired drafts are deleted
+const PurgeInterval = time.Hour
+
+// Form is a multi-step form whose partial payloads can be saved as drafts.
+// Register forms on the DraftUsecase; a draft for an unknown form is rejected.
+type Form struct {
+	Name string
+	// TTL is how long a draft is kept after its last save
+	TTL time.Duration
+	// decode turns a payload into the form's request struct
+	decode func(payload []byte) (interface{}, error)
+	// finalize creates the real entity from the complete request inside tx
+	finalize func(ctx context.Context, tx *gorm.DB, userID int, reference string, req interface{}) (interface{}, error)
+}
+
+// NewForm creates a Form whose payload is validated as T. Saving a step only
+// checks the fields sent so far; finalize receives T once every rule passes and
+// runs in the transaction that deletes the draft, so a failed finalize keeps it.
+//
+//	drafts.Register(draft.NewForm("order", 24*time.Hour,
+//		func(ctx context.Context, tx *gorm.DB, userID int, reference string, req *entity.CreateOrderRequest) (interface{}, error) {
+//			order := &entity.Order{UserID: userID, Address: req.Address}
+//			return order, tx.Create(order).Error
+//		}))
+func NewForm[T any](name string, ttl time.Duration, finalize func(ctx context.Context, tx *gorm.DB, userID int, reference string, req *T) (interface{}, error)) *Form {
+	return &Form{
+		Name: name,
+		TTL:  ttl,
+		decode: func(payload []byte) (interface{}, error) {
+			req := new(T)
+			if err := json.Unmarshal(payload, req); err != nil {
+				return nil, err
+			}
+			return req, nil
+		},
+		finalize: func(ctx context.Context, tx *gorm.DB, userID int, reference string, req interface{}) (interface{}, error) {
+			return finalize(ctx, tx, userID, reference, req.(*T))
+		},
+	}
+}
+
+// DraftUsecase defines the business logic interface for drafts
+type DraftUsecase interface {
+	// Register adds a form; registering a name again replaces it
+	Register(form *Form)
+
+	List(ctx context.Context, userID int) ([]entity.Draft, error)
+	// Resume returns the user's unexpired draft of a form
+	Resume(ctx context.Context, userID int, form, reference string) (*entity.Draft, error)
+	// Save merges a step into the draft, validating the fields saved so far,
+	// and extends its expiry
+	Save(ctx context.Context, userID int, form string, req *entity.SaveDraftRequest) (*entity.Draft, error)
+	Discard(ctx context.Context, userID int, form, reference string) error
+	// Finalize validates the complete draft and promotes it with the form's
+	// finalize function, returning the created entity
+	Finalize(ctx context.Context, userID int, form, reference string) (interface{}, error)
+	// Purge deletes expired drafts
+	Purge(ctx context.Context) (int64, error)
+}
+
+// DraftRepository defines the data access interface for drafts
+type DraftRepository interface {
+	List(ctx context.Context, userID int, now time.Time) ([]entity.Draft, error)
+	Get(ctx context.Context, userID int, form, reference string) (*entity.Draft, error)
+	Save(ctx context.Context, draft *entity.Draft) error
+	Delete(ctx context.Context, draft *entity.Draft) error
+	// Finalize runs fn and deletes the draft in one transaction
+	Finalize(ctx context.Context, draft *entity.Draft, fn func(tx *gorm.DB) error) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
@@ -0,0 +1,85 @@
+package draft
+
+import (
+	"context"
+	"time"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/database"
+	"flex-service/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+type draftRepository struct {
+	db *gorm.DB
+}
+
+func NewDraftRepository(db *gorm.DB) DraftRepository {
+	return &draftRepository{
+		db: db,
+	}
+}
+
+func (r *draftRepository) List(ctx context.Context, userID int, now time.Time) ([]entity.Draft, error) {
+	var drafts []entity.Draft
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND expires_at > ?", userID, now).
+		Order("updated_at DESC").
+		Find(&drafts).Error
+	if err != nil {
+		return nil, errors.WrapDatabase(err, "failed to list drafts")
+	}
+	return drafts, nil
+}
+
+func (r *draftRepository) Get(ctx context.Context, userID int, form, reference string) (*entity.Draft, error) {
+	var draft entity.Draft
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND form = ? AND reference = ?", userID, form, reference).
+		First(&draft).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NotFound("Draft not found")
+		}
+		return nil, errors.WrapDatabase(err, "failed to get draft")
+	}
+	return &draft, nil
+}
+
+func (r *draftRepository) Save(ctx context.Context, draft *entity.Draft) error {
+	if err := r.db.WithContext(ctx).Omit("User").Save(draft).Error; err != nil {
+		return database.WrapError(err, "failed to save draft")
+	}
+	return nil
+}
+
+func (r *draftRepository) Delete(ctx context.Context, draft *entity.Draft) error {
+	if err := r.db.WithContext(ctx).Delete(draft).Error; err != nil {
+		return errors.WrapDatabase(err, "failed to delete draft")
+	}
+	return nil
+}
+
+func (r *draftRepository) Finalize(ctx context.Context, draft *entity.Draft, fn func(tx *gorm.DB) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Deleting first locks the row, so a concurrent finalize of the same
+		// draft finds nothing to delete and stops
+		result := tx.Where("id = ?", draft.ID).Delete(&entity.Draft{})
+		if result.Error != nil {
+			return errors.WrapDatabase(result.Error, "failed to delete draft")
+		}
+		if result.RowsAffected == 0 {
+			return errors.Conflict("Draft was already finalized")
+		}
+		return fn(tx)
+	})
+}
+
+func (r *draftRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&entity.Draft{})
+	if result.Error != nil {
+		return 0, errors.WrapDatabase(result.Error, "failed to delete expired drafts")
+	}
+	return result.RowsAffected, nil
+}
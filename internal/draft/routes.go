@@ -0,0 +1,22 @@
+package draft
+
+import (
+	"net/http"
+
+	"flex-service/pkg/routes"
+)
+
+// Manifest declares the draft routes. Drafts belong to the authenticated user;
+// ?reference= selects the draft of an existing entity.
+var Manifest = routes.Manifest{
+	Module:     "draft",
+	Prefix:     "/drafts",
+	Middleware: []string{"auth"},
+	Routes: []routes.Route{
+		{Method: http.MethodGet, Path: "", Handler: "List", Summary: "List the user's drafts"},
+		{Method: http.MethodGet, Path: "/:form", Handler: "Resume", Summary: "Resume the draft of a form"},
+		{Method: http.MethodPut, Path: "/:form", Handler: "Save", Summary: "Save a step of a form"},
+		{Method: http.MethodDelete, Path: "/:form", Handler: "Discard", Summary: "Discard the draft of a form"},
+		{Method: http.MethodPost, Path: "/:form/finalize", Handler: "Finalize", Summary: "Create the entity from the draft"},
+	},
+}
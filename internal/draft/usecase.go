@@ -0,0 +1,181 @@
+package draft
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/errors"
+	"flex-service/pkg/i18n"
+	"flex-service/pkg/validator"
+
+	"gorm.io/gorm"
+)
+
+type draftUsecase struct {
+	repo DraftRepository
+
+	mu    sync.RWMutex
+	forms map[string]*Form
+}
+
+func NewDraftUsecase(repo DraftRepository) DraftUsecase {
+	return &draftUsecase{
+		repo:  repo,
+		forms: make(map[string]*Form),
+	}
+}
+
+func (u *draftUsecase) Register(form *Form) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.forms[form.Name] = form
+}
+
+func (u *draftUsecase) List(ctx context.Context, userID int) ([]entity.Draft, error) {
+	return u.repo.List(ctx, userID, time.Now())
+}
+
+func (u *draftUsecase) Resume(ctx context.Context, userID int, form, reference string) (*entity.Draft, error) {
+	if _, err := u.form(form); err != nil {
+		return nil, err
+	}
+	return u.current(ctx, userID, form, reference)
+}
+
+func (u *draftUsecase) Save(ctx context.Context, userID int, name string, req *entity.SaveDraftRequest) (*entity.Draft, error) {
+	form, err := u.form(name)
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := u.repo.Get(ctx, userID, name, req.Reference)
+	if err != nil {
+		if !errors.HasCode(err, errors.ErrNotFound) {
+			return nil, err
+		}
+		draft = &entity.Draft{UserID: userID, Form: name, Reference: req.Reference}
+	}
+	// An expired draft not purged yet starts over
+	if draft.Data == nil || (!draft.ExpiresAt.IsZero() && !draft.ExpiresAt.After(time.Now())) {
+		draft.Data = map[string]interface{}{}
+	}
+
+	for field, value := range req.Data {
+		if value == nil {
+			delete(draft.Data, field)
+			continue
+		}
+		draft.Data[field] = value
+	}
+	draft.Step = req.Step
+
+	if _, err := u.validate(ctx, form, draft, true); err != nil {
+		return nil, err
+	}
+
+	ttl := form.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	draft.ExpiresAt = time.Now().Add(ttl)
+
+	if err := u.repo.Save(ctx, draft); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+func (u *draftUsecase) Discard(ctx context.Context, userID int, form, reference string) error {
+	draft, err := u.repo.Get(ctx, userID, form, reference)
+	if err != nil {
+		return err
+	}
+	return u.repo.Delete(ctx, draft)
+}
+
+func (u *draftUsecase) Finalize(ctx context.Context, userID int, name, reference string) (interface{}, error) {
+	form, err := u.form(name)
+	if err != nil {
+		return nil, err
+	}
+	draft, err := u.current(ctx, userID, name, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := u.validate(ctx, form, draft, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var result interface{}
+	err = u.repo.Finalize(ctx, draft, func(tx *gorm.DB) error {
+		var err error
+		result, err = form.finalize(ctx, tx, userID, reference, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (u *draftUsecase) Purge(ctx context.Context) (int64, error) {
+	return u.repo.DeleteExpired(ctx, time.Now())
+}
+
+func (u *draftUsecase) form(name string) (*Form, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	form, ok := u.forms[name]
+	if !ok {
+		return nil, errors.NotFound("Form not found")
+	}
+	return form, nil
+}
+
+// current returns the draft unless it has expired
+func (u *draftUsecase) current(ctx context.Context, userID int, form, reference string) (*entity.Draft, error) {
+	draft, err := u.repo.Get(ctx, userID, form, reference)
+	if err != nil {
+		return nil, err
+	}
+	if !draft.ExpiresAt.After(time.Now()) {
+		return nil, errors.NotFound("Draft not found")
+	}
+	return draft, nil
+}
+
+// validate decodes the draft into the form's request and validates it. A
+// partial check only reports failures of fields the draft already has, so
+// required fields of later steps don't fail earlier ones.
+func (u *draftUsecase) validate(ctx context.Context, form *Form, draft *entity.Draft, partial bool) (interface{}, error) {
+	payload, err := json.Marshal(draft.Data)
+	if err != nil {
+		return nil, errors.WrapBadRequest(err, "Invalid draft data")
+	}
+	req, err := form.decode(payload)
+	if err != nil {
+		return nil, errors.WrapBadRequest(err, "Invalid draft data")
+	}
+
+	errs := validator.Validate(req, i18n.FromContext(ctx))
+	if partial {
+		saved := errs[:0]
+		for _, fe := range errs {
+			if _, ok := draft.Data[fe.Field]; ok {
+				saved = append(saved, fe)
+			}
+		}
+		errs = saved
+	}
+	if len(errs) > 0 {
+		return nil, errors.Wrap(errs, errors.ErrValidation, "Validation failed", http.StatusBadRequest)
+	}
+	return req, nil
+}
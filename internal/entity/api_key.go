@@ -0,0 +1,58 @@
+package entity
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APIKey is a long-lived credential that acts as its owner, limited to its
+// scopes. Only the SHA-256 hash of the key is stored; Prefix identifies the key
+// for lookups and in listings. Plan is the subscription plan that tiered rate
+// limit policies read.
+type APIKey struct {
+	ID         int        `json:"-" gorm:"primaryKey"`
+	UUID       uuid.UUID  `json:"id" gorm:"type:varchar(36);unique;not null;index"`
+	UserID     int        `json:"-" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"type:varchar(100);not null"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(32);not null;uniqueIndex"`
+	KeyHash    string     `json:"-" gorm:"type:varchar(64);not null"`
+	Scopes     string     `json:"scopes" gorm:"type:varchar(1000);not null"` // Space-separated permissions, e.g. "orders:read orders:create"
+	Plan       string     `json:"plan" gorm:"type:varchar(50);not null;default:''"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" gorm:"index"`
+	User       User       `json:"-" gorm:"foreignKey:UserID;references:ID"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for GORM
+func (APIKey) TableName() string {
+	return "tb_api_key"
+}
+
+// BeforeCreate is a hook that runs before creating an APIKey
+func (e *APIKey) BeforeCreate(tx *gorm.DB) (err error) {
+	e.UUID = uuid.New()
+	return
+}
+
+// ScopeList returns the key's scopes
+func (e *APIKey) ScopeList() []string {
+	return strings.Fields(e.Scopes)
+}
+
+// IsUsable reports whether the key is neither revoked nor expired
+func (e *APIKey) IsUsable(now time.Time) bool {
+	return e.RevokedAt == nil && (e.ExpiresAt == nil || now.Before(*e.ExpiresAt))
+}
+
+// CreateAPIKeyRequest represents a request to issue an API key
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,required,max=100"`
+	ExpiresAt *time.Time `json:"expires_at"` // Omit for a key that never expires
+}
@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Draft is the partially filled payload of a multi-step form. A user has at
+// most one draft per form and reference; Reference names the entity being
+// edited and is empty for a new one.
+type Draft struct {
+	ID        int                    `json:"-" gorm:"primaryKey"`
+	UserID    int                    `json:"-" gorm:"not null;uniqueIndex:idx_draft_owner"`
+	Form      string                 `json:"form" gorm:"type:varchar(100);not null;uniqueIndex:idx_draft_owner"`
+	Reference string                 `json:"reference" gorm:"type:varchar(100);not null;default:'';uniqueIndex:idx_draft_owner"`
+	Step      int                    `json:"step" gorm:"not null;default:0"`
+	Payload   string                 `json:"-" gorm:"type:text;not null"`
+	Data      map[string]interface{} `json:"data" gorm:"-"`
+	ExpiresAt time.Time              `json:"expires_at" gorm:"not null;index"`
+	User      User                   `json:"-" gorm:"foreignKey:UserID;references:ID"`
+	CreatedAt time.Time              `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time              `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for GORM
+func (Draft) TableName() string {
+	return "tb_draft"
+}
+
+// BeforeSave is a hook that stores Data as the JSON Payload
+func (e *Draft) BeforeSave(tx *gorm.DB) (err error) {
+	if e.Data == nil {
+		e.Data = map[string]interface{}{}
+	}
+	payload, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+	e.Payload = string(payload)
+	return
+}
+
+// AfterFind is a hook that decodes Payload into Data
+func (e *Draft) AfterFind(tx *gorm.DB) (err error) {
+	e.Data = map[string]interface{}{}
+	if e.Payload == "" {
+		return
+	}
+	return json.Unmarshal([]byte(e.Payload), &e.Data)
+}
+
+// SaveDraftRequest saves one step of a form. Data is merged into the fields
+// already saved; a null value removes a field.
+type SaveDraftRequest struct {
+	Reference string                 `json:"reference" validate:"omitempty,max=100"`
+	Step      int                    `json:"step" validate:"omitempty,min=0"`
+	Data      map[string]interface{} `json:"data" validate:"required"`
+}
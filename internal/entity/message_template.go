@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type MessageTemplateChannel string
+
+const (
+	MessageTemplateEmail   MessageTemplateChannel = "email"
+	MessageTemplateWebhook MessageTemplateChannel = "webhook"
+)
+
+// MessageTemplate represents an editable email or webhook template.
+// Rows override the embedded defaults shipped with the binary.
+type MessageTemplate struct {
+	ID        int                    `json:"-" gorm:"primaryKey"`
+	UUID      uuid.UUID              `json:"uuid" gorm:"type:varchar(36);unique;not null;index"`
+	Channel   MessageTemplateChannel `json:"channel" gorm:"type:varchar(20);not null;uniqueIndex:idx_message_template_channel_key"`
+	Key       string                 `json:"key" gorm:"column:template_key;type:varchar(100);not null;uniqueIndex:idx_message_template_channel_key"`
+	Subject   string                 `json:"subject" gorm:"type:varchar(255)"`
+	Body      string                 `json:"body" gorm:"type:text;not null"`
+	Version   int                    `json:"version" gorm:"not null;default:1"`
+	UpdatedBy *int                   `json:"updated_by" gorm:"index"`
+	CreatedAt time.Time              `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time              `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt         `json:"-" gorm:"index"`
+}
+
+// TableName returns the table name for GORM
+func (MessageTemplate) TableName() string {
+	return "tb_message_template"
+}
+
+// BeforeCreate is a hook that runs before creating a MessageTemplate
+func (e *MessageTemplate) BeforeCreate(tx *gorm.DB) (err error) {
+	e.UUID = uuid.New()
+	return
+}
+
+// MessageTemplateVersion is an immutable snapshot taken on every template edit
+type MessageTemplateVersion struct {
+	ID         int       `json:"-" gorm:"primaryKey"`
+	TemplateID int       `json:"-" gorm:"not null;uniqueIndex:idx_message_template_version"`
+	Version    int       `json:"version" gorm:"not null;uniqueIndex:idx_message_template_version"`
+	Subject    string    `json:"subject" gorm:"type:varchar(255)"`
+	Body       string    `json:"body" gorm:"type:text;not null"`
+	CreatedBy  *int      `json:"created_by" gorm:"index"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for GORM
+func (MessageTemplateVersion) TableName() string {
+	return "tb_message_template_version"
+}
+
+// UpdateMessageTemplateRequest represents a request to edit a template
+type UpdateMessageTemplateRequest struct {
+	Subject string `json:"subject" validate:"omitempty,max=255"`
+	Body    string `json:"body" validate:"required"`
+}
+
+// PreviewMessageTemplateRequest renders a template with sample data.
+// Subject and Body are optional drafts; when empty the current version is used.
+type PreviewMessageTemplateRequest struct {
+	Subject *string                `json:"subject,omitempty" validate:"omitempty,max=255"`
+	Body    *string                `json:"body,omitempty"`
+	Data    map[string]interface{} `json:"data"`
+}
+
+// TestSendMessageTemplateRequest sends a rendered template to a test recipient
+type TestSendMessageTemplateRequest struct {
+	To   string                 `json:"to" validate:"omitempty,email"`
+	URL  string                 `json:"url" validate:"omitempty,url"`
+	Data map[string]interface{} `json:"data"`
+}
@@ -0,0 +1,22 @@
+package entity
+
+import "flex-service/pkg/migration"
+
+// The models migrate:verify compares with the live schema. make:migration
+// -create and db:introspect add the entities they generate.
+func init() {
+	migration.RegisterModels(
+		&APIKey{},
+		&Draft{},
+		&MessageTemplate{},
+		&MessageTemplateVersion{},
+		&Permission{},
+		&Report{},
+		&Role{},
+		&RoleUser{},
+		&SocialAccount{},
+		&TwoFactorSecret{},
+		&User{},
+		&UserToken{},
+	)
+}
@@ -0,0 +1,59 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Report statuses
+const (
+	ReportPending   = "pending"
+	ReportRunning   = "running"
+	ReportCompleted = "completed"
+	ReportFailed    = "failed"
+)
+
+// Report is a generated file, built in the background from a report type and
+// its parameters. Fingerprint identifies identical requests, so a recent
+// report can be handed out again instead of being built twice.
+type Report struct {
+	ID          int        `json:"-" gorm:"primaryKey"`
+	UUID        uuid.UUID  `json:"id" gorm:"type:varchar(36);not null;uniqueIndex"`
+	UserID      int        `json:"-" gorm:"not null;index:idx_report_reuse"`
+	Type        string     `json:"type" gorm:"type:varchar(100);not null;index:idx_report_reuse"`
+	Fingerprint string     `json:"-" gorm:"type:varchar(64);not null;index:idx_report_reuse"`
+	Params      string     `json:"params" gorm:"type:text;not null"`
+	Status      string     `json:"status" gorm:"type:varchar(20);not null;default:pending;index"`
+	Progress    int        `json:"progress" gorm:"not null;default:0"` // percent
+	Message     string     `json:"message,omitempty" gorm:"type:varchar(255)"`
+	Error       string     `json:"error,omitempty" gorm:"type:text"`
+	Path        string     `json:"-" gorm:"type:varchar(255)"`
+	FileName    string     `json:"file_name,omitempty" gorm:"type:varchar(255)"`
+	ContentType string     `json:"content_type,omitempty" gorm:"type:varchar(100)"`
+	Size        int64      `json:"size,omitempty" gorm:"not null;default:0"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null;index"`
+	User        User       `json:"-" gorm:"foreignKey:UserID;references:ID"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for GORM
+func (Report) TableName() string {
+	return "tb_report"
+}
+
+// Done reports whether the report has finished, successfully or not
+func (e *Report) Done() bool {
+	return e.Status == ReportCompleted || e.Status == ReportFailed
+}
+
+// RequestReportRequest asks for a report of a registered type
+type RequestReportRequest struct {
+	Type   string                 `json:"type" validate:"required,max=100"`
+	Params map[string]interface{} `json:"params"`
+	// Fresh builds a new report even if an identical recent one exists
+	Fresh bool `json:"fresh"`
+}
@@ -0,0 +1,82 @@
+package entity
+
+import "time"
+
+// Role is a named set of permissions assigned to users. Roles stored here are
+// loaded into auth.PermissionChecker on top of the roles file.
+type Role struct {
+	ID          int          `json:"-" gorm:"primaryKey"`
+	Name        string       `json:"name" gorm:"type:varchar(100);not null;uniqueIndex"`
+	Description string       `json:"description" gorm:"type:varchar(255)"`
+	Permissions []Permission `json:"permissions" gorm:"many2many:tb_permission_role;joinForeignKey:RoleID;joinReferences:PermissionID"`
+	CreatedAt   time.Time    `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time    `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for GORM
+func (Role) TableName() string {
+	return "tb_role"
+}
+
+// PermissionNames returns the names of the role's permissions
+func (r *Role) PermissionNames() []string {
+	names := make([]string, 0, len(r.Permissions))
+	for _, permission := range r.Permissions {
+		names = append(names, permission.Name)
+	}
+	return names
+}
+
+// Permission is a grant such as "orders.create", "orders.*" or "post:update:own"
+type Permission struct {
+	ID          int       `json:"-" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"type:varchar(100);not null;uniqueIndex"`
+	Description string    `json:"description" gorm:"type:varchar(255)"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for GORM
+func (Permission) TableName() string {
+	return "tb_permission"
+}
+
+// RoleUser assigns a role to a user
+type RoleUser struct {
+	RoleID    int       `json:"-" gorm:"primaryKey"`
+	UserID    int       `json:"-" gorm:"primaryKey;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for GORM
+func (RoleUser) TableName() string {
+	return "tb_role_user"
+}
+
+// CreateRoleRequest represents a request to create a Role
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,min=1,max=100"`
+	Description string   `json:"description" validate:"omitempty,max=255"`
+	Permissions []string `json:"permissions" validate:"omitempty,dive,required,max=100"`
+}
+
+// UpdateRoleRequest represents a request to update a Role
+type UpdateRoleRequest struct {
+	Description *string `json:"description,omitempty" validate:"omitempty,max=255"`
+}
+
+// SetRolePermissionsRequest replaces the permissions of a Role
+type SetRolePermissionsRequest struct {
+	Permissions []string `json:"permissions" validate:"dive,required,max=100"`
+}
+
+// CreatePermissionRequest represents a request to create a Permission
+type CreatePermissionRequest struct {
+	Name        string `json:"name" validate:"required,min=1,max=100"`
+	Description string `json:"description" validate:"omitempty,max=255"`
+}
+
+// AssignRoleRequest assigns a Role to a user
+type AssignRoleRequest struct {
+	Role string `json:"role" validate:"required,max=100"`
+}
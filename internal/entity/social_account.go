@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"time"
 
+	"flex-service/pkg/pagination"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -54,6 +56,5 @@ type SocialAccountFilter struct {
 	Provider   string `form:"provider"`
 	ProviderID string `form:"provider_id"`
 	Search     string `form:"search"`
-	Page       int    `form:"page" validate:"min=1"`
-	Limit      int    `form:"limit" validate:"min=1,max=100"`
+	pagination.Params
 }
@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"time"
+)
+
+// TwoFactorSecret holds a user's TOTP secret, encrypted with pkg/secure, and the
+// SHA-256 hashes of the unused recovery codes. The row exists from setup on;
+// 2FA is only enforced once EnabledAt is set.
+type TwoFactorSecret struct {
+	ID            int        `json:"-" gorm:"primaryKey;autoIncrement"`
+	UserID        int        `json:"user_id" gorm:"not null;uniqueIndex"`
+	Secret        string     `json:"-" gorm:"type:varchar(255);not null"`
+	RecoveryCodes string     `json:"-" gorm:"type:text"` // JSON array of hashes
+	LastUsedStep  int64      `json:"-" gorm:"not null;default:0"`
+	EnabledAt     *time.Time `json:"enabled_at" gorm:"index"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime;not null"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"autoUpdateTime;not null"`
+}
+
+// TableName returns the table name for GORM
+func (TwoFactorSecret) TableName() string {
+	return "tb_two_factor_secret"
+}
+
+// IsEnabled reports whether setup was confirmed with a valid code
+func (t *TwoFactorSecret) IsEnabled() bool {
+	return t != nil && t.EnabledAt != nil
+}
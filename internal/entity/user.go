@@ -3,6 +3,8 @@ package entity
 import (
 	"time"
 
+	"flex-service/pkg/pagination"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -23,24 +25,25 @@ const (
 
 // User represents a User entity
 type User struct {
-	ID             int             `json:"-" gorm:"primaryKey"`
-	UUID           uuid.UUID       `json:"uuid" gorm:"type:varchar(36);unique;not null;index"`
-	MemberNo       string          `json:"member_no" gorm:"type:varchar(100);unique;not null;index"`
-	Username       string          `json:"username" gorm:"type:varchar(100);unique;index"`
-	Password       *string         `json:"-" gorm:"type:varchar(100);"`
-	Title          *string         `json:"title" gorm:"type:varchar(100);index"`
-	FirstName      string          `json:"first_name" gorm:"type:varchar(100);not null;index:idx_full_name"`
-	LastName       string          `json:"last_name" gorm:"type:varchar(100);not null;index:idx_full_name"`
-	Gender         UserGender      `json:"gender" gorm:"type:enum('male', 'female');not null;default:male;index"`
-	BirthDate      *time.Time      `json:"birth_date" gorm:"type:date;index"`
-	ProfilePicture *string         `json:"profile_picture" gorm:"type:varchar(255)"`
-	Phone          *string         `json:"phone" gorm:"type:varchar(100);index"`
-	Email          *string         `json:"email" gorm:"type:varchar(100);unique;index"`
-	Active         UserStatus      `json:"active" gorm:"type:enum('active', 'inactive');not null;default:inactive;index"`
-	CreatedAt      time.Time       `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt      time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
-	DeletedAt      gorm.DeletedAt  `json:"-" gorm:"index"`
-	SocialAccounts []SocialAccount `json:"-" gorm:"foreignKey:UserID;references:ID"`
+	ID                    int             `json:"-" gorm:"primaryKey"`
+	UUID                  uuid.UUID       `json:"uuid" gorm:"type:varchar(36);unique;not null;index"`
+	MemberNo              string          `json:"member_no" gorm:"type:varchar(100);unique;not null;index"`
+	Username              string          `json:"username" gorm:"type:varchar(100);unique;index"`
+	Password              *string         `json:"-" gorm:"type:varchar(100);"`
+	Title                 *string         `json:"title" gorm:"type:varchar(100);index"`
+	FirstName             string          `json:"first_name" gorm:"type:varchar(100);not null;index:idx_full_name"`
+	LastName              string          `json:"last_name" gorm:"type:varchar(100);not null;index:idx_full_name"`
+	Gender                UserGender      `json:"gender" gorm:"type:enum('male', 'female');not null;default:male;index"`
+	BirthDate             *time.Time      `json:"birth_date" gorm:"type:date;index"`
+	ProfilePicture        *string         `json:"profile_picture" gorm:"type:varchar(255)"`
+	Phone                 *string         `json:"phone" gorm:"type:varchar(100);index"`
+	Email                 *string         `json:"email" gorm:"type:varchar(100);unique;index"`
+	Active                UserStatus      `json:"active" gorm:"type:enum('active', 'inactive');not null;default:inactive;index"`
+	PasswordResetRequired bool            `json:"password_reset_required" gorm:"not null;default:false"` // password login is refused until reset
+	CreatedAt             time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt             time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt             gorm.DeletedAt  `json:"-" gorm:"index"`
+	SocialAccounts        []SocialAccount `json:"-" gorm:"foreignKey:UserID;references:ID"`
 }
 
 // TableName returns the table name for GORM
@@ -95,8 +98,7 @@ type UserFilter struct {
 	Phone          string     `form:"phone"`
 	Active         UserStatus `form:"active"`
 	Search         string     `form:"search"`
-	Page           int        `form:"page" validate:"min=1"`
-	Limit          int        `form:"limit" validate:"min=1,max=1000"`
+	pagination.Params
 }
 
 func (u *User) IsActive() bool {
@@ -3,6 +3,8 @@ package entity
 import (
 	"time"
 
+	"flex-service/pkg/pagination"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -24,6 +26,9 @@ type UserToken struct {
 	AccessJti   string          `json:"access_jti" gorm:"type:varchar(36);not null;index"`
 	RefreshJti  string          `json:"refresh_jti" gorm:"type:varchar(36);not null;index"`
 	RevokedAt   *time.Time      `json:"revoked_at" gorm:"type:datetime;index"`
+	IPAddress   string          `json:"ip_address" gorm:"type:varchar(45)"`  // client IP at sign-in or the last refresh
+	UserAgent   string          `json:"user_agent" gorm:"type:varchar(255)"` // client user agent at sign-in or the last refresh
+	LastUsedAt  *time.Time      `json:"last_used_at"`                        // updated at most every few minutes
 	CreatedAt   time.Time       `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt   time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
 	DeletedAt   gorm.DeletedAt  `json:"-" gorm:"index"`
@@ -53,6 +58,5 @@ type UpdateUserTokenRequest struct {
 // UserTokenFilter represents filters for UserToken queries
 type UserTokenFilter struct {
 	Search string `form:"search"`
-	Page   int    `form:"page" validate:"min=1"`
-	Limit  int    `form:"limit" validate:"min=1,max=100"`
+	pagination.Params
 }
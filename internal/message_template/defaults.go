@@ -0,0 +1,66 @@
+package message_template
+
+import (
+	"embed"
+	"path"
+	"strings"
+
+	"flex-service/internal/entity"
+)
+
+// Embedded default templates, used when no database row overrides them.
+// Layout: defaults/<channel>/<key>.tmpl. Email files start with a "Subject: ..." line
+// followed by a blank line and the HTML body.
+//
+//go:embed defaults
+var defaultsFS embed.FS
+
+const subjectPrefix = "Subject: "
+
+// loadDefaults reads all embedded templates
+func loadDefaults() (map[string]*Template, error) {
+	defaults := make(map[string]*Template)
+
+	for _, channel := range []entity.MessageTemplateChannel{entity.MessageTemplateEmail, entity.MessageTemplateWebhook} {
+		dir := path.Join("defaults", string(channel))
+		files, err := defaultsFS.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			if file.IsDir() || !strings.HasSuffix(file.Name(), ".tmpl") {
+				continue
+			}
+
+			content, err := defaultsFS.ReadFile(path.Join(dir, file.Name()))
+			if err != nil {
+				return nil, err
+			}
+
+			tmpl := &Template{
+				Channel: channel,
+				Key:     strings.TrimSuffix(file.Name(), ".tmpl"),
+				Source:  SourceDefault,
+			}
+			tmpl.Subject, tmpl.Body = splitSubject(string(content))
+			defaults[templateID(channel, tmpl.Key)] = tmpl
+		}
+	}
+
+	return defaults, nil
+}
+
+// splitSubject separates the "Subject: ..." header line from the body
+func splitSubject(content string) (string, string) {
+	if !strings.HasPrefix(content, subjectPrefix) {
+		return "", content
+	}
+
+	header, body, _ := strings.Cut(content, "\n")
+	return strings.TrimSpace(strings.TrimPrefix(header, subjectPrefix)), strings.TrimLeft(body, "\r\n")
+}
+
+func templateID(channel entity.MessageTemplateChannel, key string) string {
+	return string(channel) + "/" + key
+}
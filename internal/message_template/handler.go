@@ -0,0 +1,123 @@
+package message_template
+
+import (
+	"net/http"
+	"strconv"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/request"
+	"flex-service/pkg/requestctx"
+	"flex-service/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MessageTemplateHandler struct {
+	usecase MessageTemplateUsecase
+}
+
+func NewMessageTemplateHandler(usecase MessageTemplateUsecase) *MessageTemplateHandler {
+	return &MessageTemplateHandler{
+		usecase: usecase,
+	}
+}
+
+func (h *MessageTemplateHandler) List(c *gin.Context) {
+	templates, err := h.usecase.List(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Message templates retrieved successfully", templates)
+}
+
+func (h *MessageTemplateHandler) Get(c *gin.Context) {
+	tmpl, err := h.usecase.Get(c.Request.Context(), channelParam(c), c.Param("key"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Message template retrieved successfully", tmpl)
+}
+
+func (h *MessageTemplateHandler) Update(c *gin.Context) {
+	req, err := request.Bind[entity.UpdateMessageTemplateRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	userID, _ := requestctx.CurrentUserID(c)
+	tmpl, err := h.usecase.Update(c.Request.Context(), channelParam(c), c.Param("key"), req, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Message template updated successfully", tmpl)
+}
+
+func (h *MessageTemplateHandler) Versions(c *gin.Context) {
+	versions, err := h.usecase.Versions(c.Request.Context(), channelParam(c), c.Param("key"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Message template versions retrieved successfully", versions)
+}
+
+func (h *MessageTemplateHandler) Restore(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid version", nil)
+		return
+	}
+
+	userID, _ := requestctx.CurrentUserID(c)
+	tmpl, err := h.usecase.Restore(c.Request.Context(), channelParam(c), c.Param("key"), version, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Message template restored successfully", tmpl)
+}
+
+func (h *MessageTemplateHandler) Preview(c *gin.Context) {
+	req, err := request.Bind[entity.PreviewMessageTemplateRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	rendered, err := h.usecase.Preview(c.Request.Context(), channelParam(c), c.Param("key"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Message template rendered successfully", rendered)
+}
+
+func (h *MessageTemplateHandler) TestSend(c *gin.Context) {
+	req, err := request.Bind[entity.TestSendMessageTemplateRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	rendered, err := h.usecase.TestSend(c.Request.Context(), channelParam(c), c.Param("key"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Test message sent successfully", rendered)
+}
+
+func channelParam(c *gin.Context) entity.MessageTemplateChannel {
+	return entity.MessageTemplateChannel(c.Param("channel"))
+}
@@ -0,0 +1,52 @@
+package message_template
+
+import (
+	"context"
+	"time"
+
+	"flex-service/internal/entity"
+)
+
+// Template sources
+const (
+	SourceDefault  = "default"
+	SourceDatabase = "database"
+)
+
+// Template is the effective template: a database override or the embedded default
+type Template struct {
+	Channel   entity.MessageTemplateChannel `json:"channel"`
+	Key       string                        `json:"key"`
+	Subject   string                        `json:"subject,omitempty"`
+	Body      string                        `json:"body"`
+	Version   int                           `json:"version"`
+	Source    string                        `json:"source"`
+	UpdatedAt *time.Time                    `json:"updated_at,omitempty"`
+}
+
+// Rendered is the output of rendering a template with data
+type Rendered struct {
+	Subject string `json:"subject,omitempty"`
+	Body    string `json:"body"`
+}
+
+// MessageTemplateUsecase defines the business logic interface for message templates
+type MessageTemplateUsecase interface {
+	List(ctx context.Context) ([]*Template, error)
+	Get(ctx context.Context, channel entity.MessageTemplateChannel, key string) (*Template, error)
+	Update(ctx context.Context, channel entity.MessageTemplateChannel, key string, req *entity.UpdateMessageTemplateRequest, userID int) (*Template, error)
+	Versions(ctx context.Context, channel entity.MessageTemplateChannel, key string) ([]entity.MessageTemplateVersion, error)
+	Restore(ctx context.Context, channel entity.MessageTemplateChannel, key string, version int, userID int) (*Template, error)
+	Preview(ctx context.Context, channel entity.MessageTemplateChannel, key string, req *entity.PreviewMessageTemplateRequest) (*Rendered, error)
+	TestSend(ctx context.Context, channel entity.MessageTemplateChannel, key string, req *entity.TestSendMessageTemplateRequest) (*Rendered, error)
+	Render(ctx context.Context, channel entity.MessageTemplateChannel, key string, data interface{}) (*Rendered, error)
+}
+
+// MessageTemplateRepository defines the data access interface for message templates
+type MessageTemplateRepository interface {
+	List(ctx context.Context) ([]entity.MessageTemplate, error)
+	Get(ctx context.Context, channel entity.MessageTemplateChannel, key string) (*entity.MessageTemplate, error)
+	Save(ctx context.Context, tmpl *entity.MessageTemplate, userID int) error
+	Versions(ctx context.Context, templateID int) ([]entity.MessageTemplateVersion, error)
+	GetVersion(ctx context.Context, templateID int, version int) (*entity.MessageTemplateVersion, error)
+}
@@ -0,0 +1,92 @@
+package message_template
+
+import (
+	"context"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/database"
+	"flex-service/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+type messageTemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewMessageTemplateRepository(db *gorm.DB) MessageTemplateRepository {
+	return &messageTemplateRepository{
+		db: db,
+	}
+}
+
+func (r *messageTemplateRepository) List(ctx context.Context) ([]entity.MessageTemplate, error) {
+	var templates []entity.MessageTemplate
+	if err := r.db.WithContext(ctx).Order("channel ASC, template_key ASC").Find(&templates).Error; err != nil {
+		return nil, errors.WrapDatabase(err, "failed to list message templates")
+	}
+	return templates, nil
+}
+
+func (r *messageTemplateRepository) Get(ctx context.Context, channel entity.MessageTemplateChannel, key string) (*entity.MessageTemplate, error) {
+	var tmpl entity.MessageTemplate
+	err := r.db.WithContext(ctx).Where("channel = ? AND template_key = ?", channel, key).First(&tmpl).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NotFound("Message template not found")
+		}
+		return nil, errors.WrapDatabase(err, "failed to get message template")
+	}
+	return &tmpl, nil
+}
+
+// Save creates or updates the template, bumps its version and records a version snapshot
+func (r *messageTemplateRepository) Save(ctx context.Context, tmpl *entity.MessageTemplate, userID int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		updatedBy := &userID
+		if userID == 0 {
+			updatedBy = nil
+		}
+
+		tmpl.Version++
+		tmpl.UpdatedBy = updatedBy
+
+		if err := tx.Save(tmpl).Error; err != nil {
+			return database.WrapError(err, "failed to save message template")
+		}
+
+		version := &entity.MessageTemplateVersion{
+			TemplateID: tmpl.ID,
+			Version:    tmpl.Version,
+			Subject:    tmpl.Subject,
+			Body:       tmpl.Body,
+			CreatedBy:  updatedBy,
+		}
+		if err := tx.Create(version).Error; err != nil {
+			return errors.WrapDatabase(err, "failed to save message template version")
+		}
+
+		return nil
+	})
+}
+
+func (r *messageTemplateRepository) Versions(ctx context.Context, templateID int) ([]entity.MessageTemplateVersion, error) {
+	var versions []entity.MessageTemplateVersion
+	err := r.db.WithContext(ctx).Where("template_id = ?", templateID).Order("version DESC").Find(&versions).Error
+	if err != nil {
+		return nil, errors.WrapDatabase(err, "failed to list message template versions")
+	}
+	return versions, nil
+}
+
+func (r *messageTemplateRepository) GetVersion(ctx context.Context, templateID int, version int) (*entity.MessageTemplateVersion, error) {
+	var v entity.MessageTemplateVersion
+	err := r.db.WithContext(ctx).Where("template_id = ? AND version = ?", templateID, version).First(&v).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NotFound("Message template version not found")
+		}
+		return nil, errors.WrapDatabase(err, "failed to get message template version")
+	}
+	return &v, nil
+}
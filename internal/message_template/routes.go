@@ -0,0 +1,23 @@
+package message_template
+
+import (
+	"net/http"
+
+	"flex-service/pkg/routes"
+)
+
+// Manifest declares the email/webhook template management routes
+var Manifest = routes.Manifest{
+	Module:     "message_template",
+	Prefix:     "/templates",
+	Middleware: []string{"auth"},
+	Routes: []routes.Route{
+		{Method: http.MethodGet, Path: "", Handler: "List", Summary: "List templates"},
+		{Method: http.MethodGet, Path: "/:channel/:key", Handler: "Get", Summary: "Get a template"},
+		{Method: http.MethodPut, Path: "/:channel/:key", Handler: "Update", Summary: "Update a template, keeping the old version"},
+		{Method: http.MethodGet, Path: "/:channel/:key/versions", Handler: "Versions", Summary: "List template versions"},
+		{Method: http.MethodPost, Path: "/:channel/:key/versions/:version/restore", Handler: "Restore", Summary: "Restore a template version"},
+		{Method: http.MethodPost, Path: "/:channel/:key/preview", Handler: "Preview", Summary: "Render a template with sample data"},
+		{Method: http.MethodPost, Path: "/:channel/:key/test", Handler: "TestSend", Middleware: []string{"rate.user:5,1m"}, Summary: "Send a test message"},
+	},
+}
@@ -0,0 +1,325 @@
+package message_template
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	htmlTemplate "html/template"
+	"net/http"
+	"sort"
+	textTemplate "text/template"
+	"time"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/errors"
+	"flex-service/pkg/httpclient"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/mail"
+
+	"go.uber.org/zap"
+)
+
+type messageTemplateUsecase struct {
+	repo       MessageTemplateRepository
+	mailer     *mail.Mailer
+	httpClient *http.Client
+	defaults   map[string]*Template
+	appName    string
+}
+
+func NewMessageTemplateUsecase(repo MessageTemplateRepository, mailer *mail.Mailer, appName string) (MessageTemplateUsecase, error) {
+	defaults, err := loadDefaults()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load default message templates: %w", err)
+	}
+
+	return &messageTemplateUsecase{
+		repo:       repo,
+		mailer:     mailer,
+		httpClient: httpclient.New(httpclient.Config{Timeout: 10 * time.Second, Name: "message_template"}),
+		defaults:   defaults,
+		appName:    appName,
+	}, nil
+}
+
+func (u *messageTemplateUsecase) List(ctx context.Context) ([]*Template, error) {
+	rows, err := u.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]*Template, len(u.defaults)+len(rows))
+	for id, tmpl := range u.defaults {
+		merged[id] = tmpl
+	}
+	for i := range rows {
+		merged[templateID(rows[i].Channel, rows[i].Key)] = fromEntity(&rows[i])
+	}
+
+	templates := make([]*Template, 0, len(merged))
+	for _, tmpl := range merged {
+		templates = append(templates, tmpl)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Channel != templates[j].Channel {
+			return templates[i].Channel < templates[j].Channel
+		}
+		return templates[i].Key < templates[j].Key
+	})
+
+	return templates, nil
+}
+
+func (u *messageTemplateUsecase) Get(ctx context.Context, channel entity.MessageTemplateChannel, key string) (*Template, error) {
+	row, err := u.repo.Get(ctx, channel, key)
+	if err == nil {
+		return fromEntity(row), nil
+	}
+	if !isNotFound(err) {
+		return nil, err
+	}
+
+	if tmpl, ok := u.defaults[templateID(channel, key)]; ok {
+		return tmpl, nil
+	}
+	return nil, errors.NotFound("Message template not found")
+}
+
+func (u *messageTemplateUsecase) Update(ctx context.Context, channel entity.MessageTemplateChannel, key string, req *entity.UpdateMessageTemplateRequest, userID int) (*Template, error) {
+	if err := validateChannel(channel); err != nil {
+		return nil, err
+	}
+	if _, err := parse(channel, req.Subject, req.Body); err != nil {
+		return nil, errors.BadRequest(err.Error())
+	}
+
+	row, err := u.repo.Get(ctx, channel, key)
+	if err != nil {
+		if !isNotFound(err) {
+			return nil, err
+		}
+		row = &entity.MessageTemplate{Channel: channel, Key: key}
+	}
+
+	row.Subject = req.Subject
+	row.Body = req.Body
+	if err := u.repo.Save(ctx, row, userID); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Message template updated",
+		zap.String("channel", string(channel)),
+		zap.String("key", key),
+		zap.Int("version", row.Version),
+		zap.Int("user_id", userID))
+
+	return fromEntity(row), nil
+}
+
+func (u *messageTemplateUsecase) Versions(ctx context.Context, channel entity.MessageTemplateChannel, key string) ([]entity.MessageTemplateVersion, error) {
+	row, err := u.repo.Get(ctx, channel, key)
+	if err != nil {
+		if isNotFound(err) {
+			// Templates still on their embedded default have no history
+			return []entity.MessageTemplateVersion{}, nil
+		}
+		return nil, err
+	}
+	return u.repo.Versions(ctx, row.ID)
+}
+
+// Restore copies an old version into a new version, keeping history append-only
+func (u *messageTemplateUsecase) Restore(ctx context.Context, channel entity.MessageTemplateChannel, key string, version int, userID int) (*Template, error) {
+	row, err := u.repo.Get(ctx, channel, key)
+	if err != nil {
+		return nil, err
+	}
+
+	old, err := u.repo.GetVersion(ctx, row.ID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.Update(ctx, channel, key, &entity.UpdateMessageTemplateRequest{
+		Subject: old.Subject,
+		Body:    old.Body,
+	}, userID)
+}
+
+func (u *messageTemplateUsecase) Preview(ctx context.Context, channel entity.MessageTemplateChannel, key string, req *entity.PreviewMessageTemplateRequest) (*Rendered, error) {
+	tmpl, err := u.Get(ctx, channel, key)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, body := tmpl.Subject, tmpl.Body
+	if req.Subject != nil {
+		subject = *req.Subject
+	}
+	if req.Body != nil {
+		body = *req.Body
+	}
+
+	rendered, err := u.render(channel, subject, body, req.Data)
+	if err != nil {
+		return nil, errors.BadRequest(err.Error())
+	}
+	return rendered, nil
+}
+
+func (u *messageTemplateUsecase) TestSend(ctx context.Context, channel entity.MessageTemplateChannel, key string, req *entity.TestSendMessageTemplateRequest) (*Rendered, error) {
+	rendered, err := u.Preview(ctx, channel, key, &entity.PreviewMessageTemplateRequest{Data: req.Data})
+	if err != nil {
+		return nil, err
+	}
+
+	switch channel {
+	case entity.MessageTemplateEmail:
+		if req.To == "" {
+			return nil, errors.BadRequest("to is required for email templates")
+		}
+		if err := u.mailer.SendHTMLEmail([]string{req.To}, "[TEST] "+rendered.Subject, rendered.Body, nil); err != nil {
+			return nil, errors.WrapInternal(err, "failed to send test email")
+		}
+	case entity.MessageTemplateWebhook:
+		if req.URL == "" {
+			return nil, errors.BadRequest("url is required for webhook templates")
+		}
+		if err := u.postWebhook(ctx, req.URL, rendered.Body); err != nil {
+			return nil, errors.WrapInternal(err, "failed to send test webhook")
+		}
+	}
+
+	logger.Info("Message template test sent",
+		zap.String("channel", string(channel)),
+		zap.String("key", key))
+
+	return rendered, nil
+}
+
+func (u *messageTemplateUsecase) Render(ctx context.Context, channel entity.MessageTemplateChannel, key string, data interface{}) (*Rendered, error) {
+	tmpl, err := u.Get(ctx, channel, key)
+	if err != nil {
+		return nil, err
+	}
+	return u.render(channel, tmpl.Subject, tmpl.Body, data)
+}
+
+// render executes subject and body; map data gets AppName filled in when missing
+func (u *messageTemplateUsecase) render(channel entity.MessageTemplateChannel, subject, body string, data interface{}) (*Rendered, error) {
+	if m, ok := data.(map[string]interface{}); ok || data == nil {
+		if m == nil {
+			m = make(map[string]interface{})
+		}
+		if _, exists := m["AppName"]; !exists {
+			m["AppName"] = u.appName
+		}
+		data = m
+	}
+
+	parsed, err := parse(channel, subject, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var subjectBuf, bodyBuf bytes.Buffer
+	if err := parsed.subject.Execute(&subjectBuf, data); err != nil {
+		return nil, fmt.Errorf("failed to render subject: %w", err)
+	}
+	if err := parsed.body(&bodyBuf, data); err != nil {
+		return nil, fmt.Errorf("failed to render body: %w", err)
+	}
+
+	if channel == entity.MessageTemplateWebhook && !json.Valid(bodyBuf.Bytes()) {
+		return nil, fmt.Errorf("rendered webhook body is not valid JSON")
+	}
+
+	return &Rendered{Subject: subjectBuf.String(), Body: bodyBuf.String()}, nil
+}
+
+func (u *messageTemplateUsecase) postWebhook(ctx context.Context, url, body string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBufferString(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", u.appName)
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// templateFuncs are available in every template; use {{json .Field}} in webhook bodies
+// so values are quoted and escaped
+var templateFuncs = map[string]interface{}{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+type parsedTemplate struct {
+	subject *textTemplate.Template
+	body    func(buf *bytes.Buffer, data interface{}) error
+}
+
+// parse compiles a template; email bodies use html/template so data is escaped
+func parse(channel entity.MessageTemplateChannel, subject, body string) (*parsedTemplate, error) {
+	subjectTmpl, err := textTemplate.New("subject").Funcs(templateFuncs).Parse(subject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subject template: %w", err)
+	}
+
+	parsed := &parsedTemplate{subject: subjectTmpl}
+
+	if channel == entity.MessageTemplateEmail {
+		bodyTmpl, err := htmlTemplate.New("body").Funcs(templateFuncs).Parse(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body template: %w", err)
+		}
+		parsed.body = func(buf *bytes.Buffer, data interface{}) error { return bodyTmpl.Execute(buf, data) }
+	} else {
+		bodyTmpl, err := textTemplate.New("body").Funcs(templateFuncs).Parse(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body template: %w", err)
+		}
+		parsed.body = func(buf *bytes.Buffer, data interface{}) error { return bodyTmpl.Execute(buf, data) }
+	}
+
+	return parsed, nil
+}
+
+func validateChannel(channel entity.MessageTemplateChannel) error {
+	switch channel {
+	case entity.MessageTemplateEmail, entity.MessageTemplateWebhook:
+		return nil
+	default:
+		return errors.BadRequest("channel must be email or webhook")
+	}
+}
+
+func isNotFound(err error) bool {
+	return errors.Is(err, errors.ErrNotFoundError)
+}
+
+func fromEntity(row *entity.MessageTemplate) *Template {
+	updatedAt := row.UpdatedAt
+	return &Template{
+		Channel:   row.Channel,
+		Key:       row.Key,
+		Subject:   row.Subject,
+		Body:      row.Body,
+		Version:   row.Version,
+		Source:    SourceDatabase,
+		UpdatedAt: &updatedAt,
+	}
+}
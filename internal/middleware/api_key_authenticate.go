@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+
+	"flex-service/internal/api_key"
+	"flex-service/pkg/requestctx"
+	"flex-service/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyAuthenticate authenticates the X-API-Key header. The principal is the
+// key's owner limited to the key's scopes, so auth.PermissionChecker grants only
+// what both the owner and the key allow. The request must also carry every scope
+// listed here.
+func APIKeyAuthenticate(apiKeyUsecase api_key.APIKeyUsecase, scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(api_key.Header)
+		if key == "" {
+			response.Error(c, http.StatusUnauthorized, "INVALID_API_KEY", "Missing "+api_key.Header+" header", nil)
+			c.Abort()
+			return
+		}
+
+		principal, err := apiKeyUsecase.Authenticate(c.Request.Context(), key)
+		if err != nil {
+			response.Error(c, http.StatusUnauthorized, "INVALID_API_KEY", err.Error(), nil)
+			c.Abort()
+			return
+		}
+
+		for _, scope := range scopes {
+			if !principal.HasScope(scope) {
+				response.Error(c, http.StatusForbidden, "INSUFFICIENT_SCOPE", "API key is missing scope "+scope, nil)
+				c.Abort()
+				return
+			}
+		}
+
+		requestctx.SetPrincipal(c, principal)
+		c.Next()
+	}
+}
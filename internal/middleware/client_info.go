@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"flex-service/pkg/requestctx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientInfo stores the client IP and user agent in requestctx, so usecases
+// can record where a request came from, such as the device of a new session.
+// The IP honours the proxies trusted with TrustProxies.
+func ClientInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestctx.SetClient(c, requestctx.Client{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		})
+		c.Next()
+	}
+}
@@ -1,51 +1,111 @@
 package middleware
 
 import (
+	"expvar"
+	"fmt"
 	"net/http"
+	"runtime/debug"
+	"strconv"
 
 	"flex-service/pkg/errors"
+	"flex-service/pkg/i18n"
 	"flex-service/pkg/logger"
 	"flex-service/pkg/response"
+	"flex-service/pkg/validator"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-// ErrorHandler middleware handles errors and returns standardized responses
+// errorMetrics counts error responses by "<status>:<code>", exposed at /debug/vars when expvar is mounted
+var errorMetrics = expvar.NewMap("http_errors_total")
+
+// ErrorHandler middleware recovers panics and turns errors added with c.Error into standardized responses.
+// Handlers can simply do:
+//
+//	if err != nil {
+//		c.Error(err)
+//		return
+//	}
 func ErrorHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				logger.WithContext(c.Request.Context()).Error("Panic recovered",
+					zap.Any("error", recovered),
+					zap.String("path", c.Request.URL.Path),
+					zap.String("method", c.Request.Method),
+					zap.String("stack", string(debug.Stack())),
+				)
+
+				c.Error(fmt.Errorf("panic: %v", recovered))
+				writeError(c, errors.Internal("Internal server error"))
+			}
+		}()
+
 		c.Next()
 
 		// Check if there are any errors
-		if len(c.Errors) > 0 {
-			err := c.Errors.Last()
-
-			switch e := err.Err.(type) {
-			case *errors.AppError:
-				// Handle application errors
-				logger.Error("Application error",
-					zap.String("code", e.Code),
-					zap.String("message", e.Message),
-					zap.Int("status", e.StatusCode),
-					zap.String("path", c.Request.URL.Path),
-					zap.Error(e.Cause),
-				)
+		if len(c.Errors) == 0 {
+			return
+		}
 
-				response.Error(c, e.StatusCode, e.Code, e.Message, e.Details)
-			default:
-				// Handle unknown errors
-				logger.Error("Unknown error",
-					zap.String("path", c.Request.URL.Path),
-					zap.Error(err.Err),
-				)
+		err := c.Errors.Last().Err
 
-				response.Error(c, http.StatusInternalServerError,
-					errors.ErrInternal, "Internal server error", nil)
-			}
+		appErr, ok := errors.AsAppError(err)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			// A body read past BodyLimit, however the handler wrapped it
+			appErr = errors.RequestTooLarge(fmt.Sprintf("Request body exceeds %d bytes", maxBytesErr.Limit))
+		} else if !ok {
+			// Handle unknown errors
+			logger.WithContext(c.Request.Context()).Error("Unknown error",
+				zap.String("path", c.Request.URL.Path),
+				zap.Error(err),
+			)
+			appErr = errors.Internal("Internal server error")
+		} else if appErr.StatusCode >= http.StatusInternalServerError {
+			logger.WithContext(c.Request.Context()).Error("Application error",
+				zap.String("code", appErr.Code),
+				zap.String("message", appErr.Message),
+				zap.Int("status", appErr.StatusCode),
+				zap.String("path", c.Request.URL.Path),
+				zap.Error(appErr.Cause),
+				zap.Strings("stack", appErr.StackTrace()),
+			)
+		} else {
+			logger.WithContext(c.Request.Context()).Warn("Application error",
+				zap.String("code", appErr.Code),
+				zap.String("message", appErr.Message),
+				zap.Int("status", appErr.StatusCode),
+				zap.String("path", c.Request.URL.Path),
+			)
+		}
+
+		writeError(c, appErr)
+	}
+}
+
+// writeError sends the error response unless the handler already wrote one
+func writeError(c *gin.Context, appErr *errors.AppError) {
+	statusCode := appErr.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusInternalServerError
+	}
+
+	errorMetrics.Add(strconv.Itoa(statusCode)+":"+appErr.Code, 1)
+
+	if !c.Writer.Written() {
+		message := appErr.LocalizedMessage(i18n.Locale(c))
 
-			c.Abort()
+		var violations validator.ValidationErrors
+		if errors.As(appErr, &violations) {
+			response.ValidationErrors(c, message, violations)
+		} else {
+			response.Error(c, statusCode, appErr.Code, message, appErr.Details)
 		}
 	}
+	c.Abort()
 }
 
 // HandleError is a helper function to add errors to context
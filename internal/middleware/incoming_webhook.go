@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"flex-service/pkg/response"
+	"flex-service/pkg/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IncomingWebhook rejects provider webhooks (Stripe, GitHub, LINE, ...) that fail
+// signature, timestamp or replay checks before the handler runs. The signed raw
+// body is available from webhook.RawBody. If the handler answers with a 5xx the
+// webhook is released so the provider's retry gets through.
+func IncomingWebhook(receiver *webhook.Receiver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, signature, err := receiver.Verify(c.Request)
+		if err != nil {
+			switch {
+			case errors.Is(err, webhook.ErrReplayed):
+				// Providers treat 2xx as delivered; 409 still tells them it was a duplicate
+				response.Error(c, http.StatusConflict, "WEBHOOK_REPLAYED", err.Error(), nil)
+			case errors.Is(err, webhook.ErrBodyTooLarge):
+				response.Error(c, http.StatusRequestEntityTooLarge, "WEBHOOK_TOO_LARGE", err.Error(), nil)
+			default:
+				response.Error(c, http.StatusUnauthorized, "INVALID_WEBHOOK_SIGNATURE", err.Error(), nil)
+			}
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(webhook.WithRawBody(c.Request.Context(), body))
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			receiver.Release(c.Request.Context(), signature)
+		}
+	}
+}
@@ -65,7 +65,7 @@ func Logging() gin.HandlerFunc {
 			logFields = append(logFields, zap.String("response_body", responseBody))
 		}
 
-		logger.Info("HTTP Request", logFields...)
+		logger.WithContext(c.Request.Context()).Info("HTTP Request", logFields...)
 	}
 }
 
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+
+	"flex-service/pkg/requestctx"
+	"flex-service/pkg/response"
+	"flex-service/pkg/signing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestSignature rejects requests without a valid service signature (see pkg/signing)
+// and stores the calling service's key ID (requestctx.ServiceKeyID). The service becomes
+// the request principal unless a user was already authenticated.
+func RequestSignature(verifier *signing.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, err := verifier.Verify(c.Request)
+		if err != nil {
+			response.Error(c, http.StatusUnauthorized, "INVALID_SIGNATURE", err.Error(), nil)
+			c.Abort()
+			return
+		}
+
+		requestctx.SetServiceKeyID(c, key.ID)
+		if _, exists := requestctx.CurrentPrincipal(c); !exists {
+			requestctx.SetPrincipal(c, requestctx.NewServicePrincipal(key.ID))
+		}
+		c.Next()
+	}
+}
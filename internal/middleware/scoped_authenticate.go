@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+
+	"flex-service/internal/user_auth"
+	"flex-service/pkg/requestctx"
+	"flex-service/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScopedAuthenticate accepts session access tokens and scoped tokens carrying
+// every required scope. Scoped tokens are rejected by UserAuthenticate, so only
+// routes using this middleware can be reached with them. The token may also be
+// passed as ?token= for links opened directly by a browser, such as downloads.
+func ScopedAuthenticate(userAuthUsecase user_auth.UserAuthUsecase, scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Query("token")
+		if header := c.GetHeader("Authorization"); header != "" || token == "" {
+			var err error
+			if token, err = user_auth.ExtractTokenFromHeader(c); err != nil {
+				response.Error(c, http.StatusUnauthorized, "INVALID_TOKEN", err.Error(), nil)
+				c.Abort()
+				return
+			}
+		}
+
+		data, err := userAuthUsecase.ValidateScopedToken(c.Request.Context(), token)
+		if err != nil {
+			response.Error(c, http.StatusUnauthorized, "INVALID_TOKEN", err.Error(), nil)
+			c.Abort()
+			return
+		}
+
+		principal := data.Principal()
+		for _, scope := range scopes {
+			if !principal.HasScope(scope) {
+				response.Error(c, http.StatusForbidden, "INSUFFICIENT_SCOPE", "Token is missing scope "+scope, nil)
+				c.Abort()
+				return
+			}
+		}
+
+		requestctx.SetPrincipal(c, principal)
+		c.Next()
+	}
+}
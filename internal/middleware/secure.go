@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"flex-service/config"
+	"flex-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/unrolled/secure"
+)
+
+// Secure returns the security middleware every request passes through: the
+// security response headers, then the request body size limit. Trusted
+// proxies are an engine setting, see TrustProxies.
+func Secure(cfg config.SecurityConfig) []gin.HandlerFunc {
+	return []gin.HandlerFunc{
+		SecurityHeaders(cfg),
+		BodyLimit(cfg.MaxBodySize, cfg.MaxMultipartSize),
+	}
+}
+
+// SecurityHeaders sets HSTS (over HTTPS only, including TLS terminated by a
+// proxy that sends X-Forwarded-Proto), X-Content-Type-Options, X-Frame-Options,
+// the Content-Security-Policy and the referrer and permissions policies
+func SecurityHeaders(cfg config.SecurityConfig) gin.HandlerFunc {
+	options := secure.Options{
+		ContentTypeNosniff:      true,
+		BrowserXssFilter:        true,
+		CustomFrameOptionsValue: strings.ToUpper(cfg.FrameOptions),
+		ReferrerPolicy:          cfg.ReferrerPolicy,
+		PermissionsPolicy:       cfg.PermissionsPolicy,
+
+		STSSeconds:           int64(cfg.HSTSMaxAge.Seconds()),
+		STSIncludeSubdomains: cfg.HSTSIncludeSubdomains,
+		STSPreload:           cfg.HSTSPreload,
+		SSLProxyHeaders:      map[string]string{"X-Forwarded-Proto": "https"},
+	}
+	if cfg.CSPReportOnly {
+		options.ContentSecurityPolicyReportOnly = cfg.ContentSecurityPolicy
+	} else {
+		options.ContentSecurityPolicy = cfg.ContentSecurityPolicy
+	}
+	secureMiddleware := secure.New(options)
+
+	return func(c *gin.Context) {
+		err := secureMiddleware.Process(c.Writer, c.Request)
+		if err != nil {
+			c.AbortWithStatus(500)
+			return
+		}
+		c.Next()
+	}
+}
+
+// BodyLimit rejects request bodies over maxSize bytes, or maxMultipart for
+// multipart/form-data uploads, with 413. A declared Content-Length over the
+// limit is refused before the handler runs; other bodies fail to read past the
+// limit, which ErrorHandler reports as 413 too. A limit of 0 disables it.
+func BodyLimit(maxSize, maxMultipart int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := maxSize
+		if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+			limit = maxMultipart
+		}
+		if limit <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > limit {
+			writeError(c, errors.RequestTooLarge(fmt.Sprintf("Request body exceeds %d bytes", limit)))
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// TrustProxies sets which proxies' forwarding headers c.ClientIP believes.
+// Without trusted proxies it returns the connection's address, so clients
+// cannot spoof their IP with X-Forwarded-For.
+func TrustProxies(engine *gin.Engine, cfg config.SecurityConfig) error {
+	if err := engine.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	if len(cfg.RemoteIPHeaders) > 0 {
+		engine.RemoteIPHeaders = cfg.RemoteIPHeaders
+	}
+	engine.TrustedPlatform = cfg.TrustedPlatform
+	return nil
+}
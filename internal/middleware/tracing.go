@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"flex-service/pkg/logger"
+	"flex-service/pkg/requestctx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tracing joins the caller's W3C trace (traceparent header) or starts a new one,
+// giving the request its own span ID. The IDs are stored in requestctx, so every
+// logger.WithContext entry carries trace_id and span_id, and the response
+// traceparent lets clients find the request's logs.
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		traceID, _, sampled, ok := logger.ParseTraceparent(c.GetHeader(logger.TraceparentHeader))
+		if !ok {
+			traceID = logger.NewTraceID()
+		}
+		spanID := logger.NewSpanID()
+
+		requestctx.SetTrace(c, traceID, spanID)
+		c.Header(logger.TraceparentHeader, logger.FormatTraceparent(traceID, spanID, sampled))
+		c.Next()
+	}
+}
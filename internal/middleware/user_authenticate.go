@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"flex-service/internal/user_auth"
+	"flex-service/pkg/requestctx"
 	"flex-service/pkg/response"
 	"net/http"
 
@@ -24,9 +25,7 @@ func UserAuthenticate(userAuthUsecase user_auth.UserAuthUsecase) gin.HandlerFunc
 			return
 		}
 
-		c.Set("user_id", data.User.ID)
-		c.Set("email", data.User.Email)
-		c.Set("type", data.UserClaims.Type)
+		requestctx.SetPrincipal(c, data.Principal())
 		c.Next()
 	}
 }
@@ -0,0 +1,72 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MessageTemplate entity struct for migration
+type MessageTemplate struct {
+	ID        int            `gorm:"primaryKey"`
+	UUID      uuid.UUID      `gorm:"type:varchar(36);unique;not null;index"`
+	Channel   string         `gorm:"type:varchar(20);not null;uniqueIndex:idx_message_template_channel_key"`
+	Key       string         `gorm:"column:template_key;type:varchar(100);not null;uniqueIndex:idx_message_template_channel_key"`
+	Subject   string         `gorm:"type:varchar(255)"`
+	Body      string         `gorm:"type:text;not null"`
+	Version   int            `gorm:"not null;default:1"`
+	UpdatedBy *int           `gorm:"index"`
+	CreatedAt time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// TableName returns the table name for GORM
+func (MessageTemplate) TableName() string {
+	return "tb_message_template"
+}
+
+// MessageTemplateVersion entity struct for migration
+type MessageTemplateVersion struct {
+	ID         int       `gorm:"primaryKey"`
+	TemplateID int       `gorm:"not null;uniqueIndex:idx_message_template_version"`
+	Version    int       `gorm:"not null;uniqueIndex:idx_message_template_version"`
+	Subject    string    `gorm:"type:varchar(255)"`
+	Body       string    `gorm:"type:text;not null"`
+	CreatedBy  *int      `gorm:"index"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for GORM
+func (MessageTemplateVersion) TableName() string {
+	return "tb_message_template_version"
+}
+
+// CreateMessageTemplateTable migration - Create tb_message_template and tb_message_template_version tables
+type CreateMessageTemplateTable struct{}
+
+// Up creates the template tables
+func (m *CreateMessageTemplateTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&MessageTemplate{}, &MessageTemplateVersion{})
+}
+
+// Down drops the template tables
+func (m *CreateMessageTemplateTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&MessageTemplateVersion{}, &MessageTemplate{})
+}
+
+// Description returns migration description
+func (m *CreateMessageTemplateTable) Description() string {
+	return "Create tb_message_template and tb_message_template_version tables"
+}
+
+// Version returns migration version
+func (m *CreateMessageTemplateTable) Version() string {
+	return "2026_10_15_090000_create_message_template_table"
+}
+
+// Auto-register migration
+func init() {
+	Register(&CreateMessageTemplateTable{})
+}
@@ -0,0 +1,66 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExperimentAssignment entity struct for migration
+type ExperimentAssignment struct {
+	ID            int       `gorm:"primaryKey"`
+	ExperimentKey string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_experiment_assignment"`
+	UserID        string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_experiment_assignment"`
+	Variant       string    `gorm:"type:varchar(100);not null"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for GORM
+func (ExperimentAssignment) TableName() string {
+	return "tb_experiment_assignment"
+}
+
+// ExperimentEvent entity struct for migration
+type ExperimentEvent struct {
+	ID            int       `gorm:"primaryKey"`
+	ExperimentKey string    `gorm:"type:varchar(100);not null;index:idx_experiment_event"`
+	Variant       string    `gorm:"type:varchar(100);not null;index:idx_experiment_event"`
+	UserID        string    `gorm:"type:varchar(64);not null;index"`
+	Type          string    `gorm:"type:varchar(20);not null;index:idx_experiment_event"`
+	Goal          string    `gorm:"type:varchar(100);index:idx_experiment_event"`
+	Value         float64   `gorm:"not null;default:0"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for GORM
+func (ExperimentEvent) TableName() string {
+	return "tb_experiment_event"
+}
+
+// CreateExperimentTables migration - Create tb_experiment_assignment and tb_experiment_event tables
+type CreateExperimentTables struct{}
+
+// Up creates the experiment tables
+func (m *CreateExperimentTables) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&ExperimentAssignment{}, &ExperimentEvent{})
+}
+
+// Down drops the experiment tables
+func (m *CreateExperimentTables) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&ExperimentEvent{}, &ExperimentAssignment{})
+}
+
+// Description returns migration description
+func (m *CreateExperimentTables) Description() string {
+	return "Create tb_experiment_assignment and tb_experiment_event tables"
+}
+
+// Version returns migration version
+func (m *CreateExperimentTables) Version() string {
+	return "2026_10_15_100000_create_experiment_tables"
+}
+
+// Auto-register migration
+func init() {
+	Register(&CreateExperimentTables{})
+}
@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ImageVariant entity struct for migration
+type ImageVariant struct {
+	ID          int       `gorm:"primaryKey"`
+	SourcePath  string    `gorm:"type:varchar(500);not null;uniqueIndex:idx_image_variant"`
+	Name        string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_image_variant"`
+	Path        string    `gorm:"type:varchar(500);not null"`
+	Width       int       `gorm:"not null"`
+	Height      int       `gorm:"not null"`
+	Size        int64     `gorm:"not null"`
+	ContentType string    `gorm:"type:varchar(100);not null"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for GORM
+func (ImageVariant) TableName() string {
+	return "tb_image_variant"
+}
+
+// CreateImageVariantTable migration - Create tb_image_variant table
+type CreateImageVariantTable struct{}
+
+// Up creates the image variant table
+func (m *CreateImageVariantTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&ImageVariant{})
+}
+
+// Down drops the image variant table
+func (m *CreateImageVariantTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&ImageVariant{})
+}
+
+// Description returns migration description
+func (m *CreateImageVariantTable) Description() string {
+	return "Create tb_image_variant table"
+}
+
+// Version returns migration version
+func (m *CreateImageVariantTable) Version() string {
+	return "2026_10_16_090000_create_image_variant_table"
+}
+
+// Auto-register migration
+func init() {
+	Register(&CreateImageVariantTable{})
+}
@@ -0,0 +1,77 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookEndpoint entity struct for migration
+type WebhookEndpoint struct {
+	ID          int       `gorm:"primaryKey"`
+	OwnerType   string    `gorm:"type:varchar(20);not null;index:idx_webhook_endpoint_owner"`
+	OwnerID     string    `gorm:"type:varchar(64);not null;index:idx_webhook_endpoint_owner"`
+	URL         string    `gorm:"type:varchar(500);not null"`
+	Secret      string    `gorm:"type:varchar(100);not null"`
+	Events      string    `gorm:"type:varchar(1000);not null"`
+	Description string    `gorm:"type:varchar(255)"`
+	Active      bool      `gorm:"not null;default:true"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for GORM
+func (WebhookEndpoint) TableName() string {
+	return "tb_webhook_endpoint"
+}
+
+// WebhookDelivery entity struct for migration
+type WebhookDelivery struct {
+	ID             int    `gorm:"primaryKey"`
+	EndpointID     int    `gorm:"not null;index"`
+	Event          string `gorm:"type:varchar(100);not null;index"`
+	Payload        string `gorm:"type:text;not null"`
+	Status         string `gorm:"type:varchar(20);not null;index"`
+	Attempts       int    `gorm:"not null;default:0"`
+	ResponseStatus int
+	ResponseBody   string `gorm:"type:text"`
+	Error          string `gorm:"type:text"`
+	DurationMs     int64
+	NextRetryAt    *time.Time
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for GORM
+func (WebhookDelivery) TableName() string {
+	return "tb_webhook_delivery"
+}
+
+// CreateWebhookTables migration - Create tb_webhook_endpoint and tb_webhook_delivery tables
+type CreateWebhookTables struct{}
+
+// Up creates the webhook tables
+func (m *CreateWebhookTables) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&WebhookEndpoint{}, &WebhookDelivery{})
+}
+
+// Down drops the webhook tables
+func (m *CreateWebhookTables) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&WebhookDelivery{}, &WebhookEndpoint{})
+}
+
+// Description returns migration description
+func (m *CreateWebhookTables) Description() string {
+	return "Create tb_webhook_endpoint and tb_webhook_delivery tables"
+}
+
+// Version returns migration version
+func (m *CreateWebhookTables) Version() string {
+	return "2026_10_16_100000_create_webhook_tables"
+}
+
+// Auto-register migration
+func init() {
+	Register(&CreateWebhookTables{})
+}
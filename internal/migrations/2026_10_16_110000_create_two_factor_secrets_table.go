@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TwoFactorSecret entity struct for migration
+type TwoFactorSecret struct {
+	ID            int        `gorm:"primaryKey"`
+	UserID        int        `gorm:"not null;uniqueIndex"`
+	Secret        string     `gorm:"type:varchar(255);not null"`
+	RecoveryCodes string     `gorm:"type:text"`
+	LastUsedStep  int64      `gorm:"not null;default:0"`
+	EnabledAt     *time.Time `gorm:"index"`
+	User          User       `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for GORM
+func (TwoFactorSecret) TableName() string {
+	return "tb_two_factor_secret"
+}
+
+// CreateTwoFactorSecretsTable migration - Create tb_two_factor_secret table
+type CreateTwoFactorSecretsTable struct{}
+
+// Up creates the tb_two_factor_secret table using the TwoFactorSecret struct
+func (m *CreateTwoFactorSecretsTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&TwoFactorSecret{})
+}
+
+// Down drops the tb_two_factor_secret table
+func (m *CreateTwoFactorSecretsTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&TwoFactorSecret{})
+}
+
+// Description returns migration description
+func (m *CreateTwoFactorSecretsTable) Description() string {
+	return "Create tb_two_factor_secret table"
+}
+
+// Version returns migration version
+func (m *CreateTwoFactorSecretsTable) Version() string {
+	return "2026_10_16_110000_create_two_factor_secrets_table"
+}
+
+// Auto-register migration
+func init() {
+	Register(&CreateTwoFactorSecretsTable{})
+}
@@ -0,0 +1,57 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey entity struct for migration
+type APIKey struct {
+	ID         int    `gorm:"primaryKey"`
+	UUID       string `gorm:"type:varchar(36);unique;not null;index"`
+	UserID     int    `gorm:"not null;index"`
+	Name       string `gorm:"type:varchar(100);not null"`
+	Prefix     string `gorm:"type:varchar(32);not null;uniqueIndex"`
+	KeyHash    string `gorm:"type:varchar(64);not null"`
+	Scopes     string `gorm:"type:varchar(1000);not null"`
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time `gorm:"index"`
+	User       User       `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for GORM
+func (APIKey) TableName() string {
+	return "tb_api_key"
+}
+
+// CreateAPIKeyTable migration - Create tb_api_key table
+type CreateAPIKeyTable struct{}
+
+// Up creates the API key table
+func (m *CreateAPIKeyTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&APIKey{})
+}
+
+// Down drops the API key table
+func (m *CreateAPIKeyTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&APIKey{})
+}
+
+// Description returns migration description
+func (m *CreateAPIKeyTable) Description() string {
+	return "Create tb_api_key table"
+}
+
+// Version returns migration version
+func (m *CreateAPIKeyTable) Version() string {
+	return "2026_10_16_120000_create_api_key_table"
+}
+
+// Auto-register migration
+func init() {
+	Register(&CreateAPIKeyTable{})
+}
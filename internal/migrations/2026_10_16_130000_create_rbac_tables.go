@@ -0,0 +1,90 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Role entity struct for migration
+type Role struct {
+	ID          int       `gorm:"primaryKey"`
+	Name        string    `gorm:"type:varchar(100);not null;uniqueIndex"`
+	Description string    `gorm:"type:varchar(255)"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for GORM
+func (Role) TableName() string {
+	return "tb_role"
+}
+
+// Permission entity struct for migration
+type Permission struct {
+	ID          int       `gorm:"primaryKey"`
+	Name        string    `gorm:"type:varchar(100);not null;uniqueIndex"`
+	Description string    `gorm:"type:varchar(255)"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for GORM
+func (Permission) TableName() string {
+	return "tb_permission"
+}
+
+// PermissionRole entity struct for migration
+type PermissionRole struct {
+	PermissionID int        `gorm:"primaryKey"`
+	RoleID       int        `gorm:"primaryKey;index"`
+	Permission   Permission `gorm:"foreignKey:PermissionID;references:ID;constraint:OnDelete:CASCADE"`
+	Role         Role       `gorm:"foreignKey:RoleID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName returns the table name for GORM
+func (PermissionRole) TableName() string {
+	return "tb_permission_role"
+}
+
+// RoleUser entity struct for migration
+type RoleUser struct {
+	RoleID    int       `gorm:"primaryKey"`
+	UserID    int       `gorm:"primaryKey;index"`
+	Role      Role      `gorm:"foreignKey:RoleID;references:ID;constraint:OnDelete:CASCADE"`
+	User      User      `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for GORM
+func (RoleUser) TableName() string {
+	return "tb_role_user"
+}
+
+// CreateRBACTables migration - Create tb_role, tb_permission, tb_permission_role and tb_role_user tables
+type CreateRBACTables struct{}
+
+// Up creates the RBAC tables
+func (m *CreateRBACTables) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Role{}, &Permission{}, &PermissionRole{}, &RoleUser{})
+}
+
+// Down drops the RBAC tables
+func (m *CreateRBACTables) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&RoleUser{}, &PermissionRole{}, &Permission{}, &Role{})
+}
+
+// Description returns migration description
+func (m *CreateRBACTables) Description() string {
+	return "Create tb_role, tb_permission, tb_permission_role and tb_role_user tables"
+}
+
+// Version returns migration version
+func (m *CreateRBACTables) Version() string {
+	return "2026_10_16_130000_create_rbac_tables"
+}
+
+// Auto-register migration
+func init() {
+	Register(&CreateRBACTables{})
+}
@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Draft entity struct for migration
+type Draft struct {
+	ID        int       `gorm:"primaryKey"`
+	UserID    int       `gorm:"not null;uniqueIndex:idx_draft_owner"`
+	Form      string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_draft_owner"`
+	Reference string    `gorm:"type:varchar(100);not null;default:'';uniqueIndex:idx_draft_owner"`
+	Step      int       `gorm:"not null;default:0"`
+	Payload   string    `gorm:"type:text;not null"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	User      User      `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for GORM
+func (Draft) TableName() string {
+	return "tb_draft"
+}
+
+// CreateDraftTable migration - Create tb_draft table
+type CreateDraftTable struct{}
+
+// Up creates the draft table
+func (m *CreateDraftTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Draft{})
+}
+
+// Down drops the draft table
+func (m *CreateDraftTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Draft{})
+}
+
+// Description returns migration description
+func (m *CreateDraftTable) Description() string {
+	return "Create tb_draft table"
+}
+
+// Version returns migration version
+func (m *CreateDraftTable) Version() string {
+	return "2026_10_16_140000_create_draft_table"
+}
+
+// Auto-register migration
+func init() {
+	Register(&CreateDraftTable{})
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session entity struct for migration
+type Session struct {
+	ID        string    `gorm:"type:varchar(64);primaryKey"`
+	Data      string    `gorm:"type:text;not null"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for GORM
+func (Session) TableName() string {
+	return "tb_session"
+}
+
+// CreateSessionTable migration - Create tb_session table for SESSION_DRIVER=database
+type CreateSessionTable struct{}
+
+// Up creates the session table
+func (m *CreateSessionTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Session{})
+}
+
+// Down drops the session table
+func (m *CreateSessionTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Session{})
+}
+
+// Description returns migration description
+func (m *CreateSessionTable) Description() string {
+	return "Create tb_session table"
+}
+
+// Version returns migration version
+func (m *CreateSessionTable) Version() string {
+	return "2026_10_16_150000_create_session_table"
+}
+
+// Auto-register migration
+func init() {
+	Register(&CreateSessionTable{})
+}
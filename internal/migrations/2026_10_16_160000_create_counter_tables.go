@@ -0,0 +1,63 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Counter entity struct for migration
+type Counter struct {
+	ID        int    `gorm:"primaryKey"`
+	Name      string `gorm:"type:varchar(100);not null;uniqueIndex:idx_counter_subject;index:idx_counter_value,priority:1"`
+	Subject   string `gorm:"type:varchar(191);not null;uniqueIndex:idx_counter_subject"`
+	Value     int64  `gorm:"not null;default:0;index:idx_counter_value,priority:2"`
+	UpdatedAt time.Time
+}
+
+// TableName returns the table name for GORM
+func (Counter) TableName() string {
+	return "tb_counter"
+}
+
+// CounterSketch entity struct for migration
+type CounterSketch struct {
+	ID        int    `gorm:"primaryKey"`
+	Name      string `gorm:"type:varchar(100);not null;uniqueIndex:idx_counter_sketch_subject"`
+	Subject   string `gorm:"type:varchar(191);not null;uniqueIndex:idx_counter_sketch_subject"`
+	Data      []byte `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+// TableName returns the table name for GORM
+func (CounterSketch) TableName() string {
+	return "tb_counter_sketch"
+}
+
+// CreateCounterTables migration - Create tb_counter and tb_counter_sketch tables
+type CreateCounterTables struct{}
+
+// Up creates the counter tables
+func (m *CreateCounterTables) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Counter{}, &CounterSketch{})
+}
+
+// Down drops the counter tables
+func (m *CreateCounterTables) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&CounterSketch{}, &Counter{})
+}
+
+// Description returns migration description
+func (m *CreateCounterTables) Description() string {
+	return "Create tb_counter and tb_counter_sketch tables"
+}
+
+// Version returns migration version
+func (m *CreateCounterTables) Version() string {
+	return "2026_10_16_160000_create_counter_tables"
+}
+
+// Auto-register migration
+func init() {
+	Register(&CreateCounterTables{})
+}
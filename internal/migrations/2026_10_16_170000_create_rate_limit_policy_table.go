@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RateLimitPolicy entity struct for migration
+type RateLimitPolicy struct {
+	ID        int    `gorm:"primaryKey"`
+	Name      string `gorm:"type:varchar(100);not null;uniqueIndex"`
+	Route     string `gorm:"type:varchar(255);not null"`
+	Key       string `gorm:"type:varchar(20);not null"`
+	Limit     int    `gorm:"not null"`
+	Window    string `gorm:"type:varchar(20);not null"`
+	Algorithm string `gorm:"type:varchar(20);not null;default:fixed_window"`
+	Plans     string `gorm:"type:text"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName returns the table name for GORM
+func (RateLimitPolicy) TableName() string {
+	return "tb_rate_limit_policy"
+}
+
+// CreateRateLimitPolicyTable migration - Create tb_rate_limit_policy table
+type CreateRateLimitPolicyTable struct{}
+
+// Up creates the rate limit policy table
+func (m *CreateRateLimitPolicyTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&RateLimitPolicy{})
+}
+
+// Down drops the rate limit policy table
+func (m *CreateRateLimitPolicyTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&RateLimitPolicy{})
+}
+
+// Description returns migration description
+func (m *CreateRateLimitPolicyTable) Description() string {
+	return "Create tb_rate_limit_policy table"
+}
+
+// Version returns migration version
+func (m *CreateRateLimitPolicyTable) Version() string {
+	return "2026_10_16_170000_create_rate_limit_policy_table"
+}
+
+// Auto-register migration
+func init() {
+	Register(&CreateRateLimitPolicyTable{})
+}
@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// apiKeyPlan adds the plan column to tb_api_key
+type apiKeyPlan struct {
+	Plan string `gorm:"type:varchar(50);not null;default:''"`
+}
+
+// TableName returns the table name for GORM
+func (apiKeyPlan) TableName() string {
+	return "tb_api_key"
+}
+
+// AddPlanToAPIKeyTable migration - Add the plan column to tb_api_key
+type AddPlanToAPIKeyTable struct{}
+
+// Up adds the plan column
+func (m *AddPlanToAPIKeyTable) Up(db *gorm.DB) error {
+	if db.Migrator().HasColumn(&apiKeyPlan{}, "Plan") {
+		return nil
+	}
+	return db.Migrator().AddColumn(&apiKeyPlan{}, "Plan")
+}
+
+// Down drops the plan column
+func (m *AddPlanToAPIKeyTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropColumn(&apiKeyPlan{}, "Plan")
+}
+
+// Description returns migration description
+func (m *AddPlanToAPIKeyTable) Description() string {
+	return "Add plan column to tb_api_key"
+}
+
+// Version returns migration version
+func (m *AddPlanToAPIKeyTable) Version() string {
+	return "2026_10_16_171000_add_plan_to_api_key_table"
+}
+
+// Auto-register migration
+func init() {
+	Register(&AddPlanToAPIKeyTable{})
+}
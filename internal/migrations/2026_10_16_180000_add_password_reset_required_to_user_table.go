@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// userPasswordResetRequired adds the password_reset_required column to tb_user
+type userPasswordResetRequired struct {
+	PasswordResetRequired bool `gorm:"not null;default:false"`
+}
+
+// TableName returns the table name for GORM
+func (userPasswordResetRequired) TableName() string {
+	return "tb_user"
+}
+
+// AddPasswordResetRequiredToUserTable migration - Add the password_reset_required column to tb_user
+type AddPasswordResetRequiredToUserTable struct{}
+
+// Up adds the password_reset_required column
+func (m *AddPasswordResetRequiredToUserTable) Up(db *gorm.DB) error {
+	if db.Migrator().HasColumn(&userPasswordResetRequired{}, "PasswordResetRequired") {
+		return nil
+	}
+	return db.Migrator().AddColumn(&userPasswordResetRequired{}, "PasswordResetRequired")
+}
+
+// Down drops the password_reset_required column
+func (m *AddPasswordResetRequiredToUserTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropColumn(&userPasswordResetRequired{}, "PasswordResetRequired")
+}
+
+// Description returns migration description
+func (m *AddPasswordResetRequiredToUserTable) Description() string {
+	return "Add password_reset_required column to tb_user"
+}
+
+// Version returns migration version
+func (m *AddPasswordResetRequiredToUserTable) Version() string {
+	return "2026_10_16_180000_add_password_reset_required_to_user_table"
+}
+
+// Auto-register migration
+func init() {
+	Register(&AddPasswordResetRequiredToUserTable{})
+}
@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditLog entity struct for migration
+type AuditLog struct {
+	ID           int64     `gorm:"primaryKey"`
+	ActorType    string    `gorm:"type:varchar(20);not null;index:idx_audit_log_actor"`
+	ActorID      string    `gorm:"type:varchar(64);not null;index:idx_audit_log_actor"`
+	ActorEmail   string    `gorm:"type:varchar(255)"`
+	Action       string    `gorm:"type:varchar(255);not null;index"`
+	ResourceType string    `gorm:"type:varchar(100);index:idx_audit_log_resource"`
+	ResourceID   string    `gorm:"type:varchar(64);index:idx_audit_log_resource"`
+	Before       string    `gorm:"type:text"`
+	After        string    `gorm:"type:text"`
+	Changes      string    `gorm:"type:text"`
+	Metadata     string    `gorm:"type:text"`
+	IP           string    `gorm:"type:varchar(45)"`
+	UserAgent    string    `gorm:"type:varchar(255)"`
+	RequestID    string    `gorm:"type:varchar(64);index"`
+	CreatedAt    time.Time `gorm:"not null;precision:6;index"`
+	PrevHash     string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Hash         string    `gorm:"type:varchar(64);not null"`
+}
+
+// TableName returns the table name for GORM
+func (AuditLog) TableName() string {
+	return "tb_audit_log"
+}
+
+// CreateAuditLogTable migration - Create tb_audit_log table
+type CreateAuditLogTable struct{}
+
+// Up creates the audit log table
+func (m *CreateAuditLogTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&AuditLog{})
+}
+
+// Down drops the audit log table
+func (m *CreateAuditLogTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&AuditLog{})
+}
+
+// Description returns migration description
+func (m *CreateAuditLogTable) Description() string {
+	return "Create tb_audit_log table"
+}
+
+// Version returns migration version
+func (m *CreateAuditLogTable) Version() string {
+	return "2026_10_16_181000_create_audit_log_table"
+}
+
+// Auto-register migration
+func init() {
+	Register(&CreateAuditLogTable{})
+}
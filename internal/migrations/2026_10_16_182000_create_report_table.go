@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Report entity struct for migration
+type Report struct {
+	ID          int    `gorm:"primaryKey"`
+	UUID        string `gorm:"type:varchar(36);not null;uniqueIndex"`
+	UserID      int    `gorm:"not null;index:idx_report_reuse"`
+	Type        string `gorm:"type:varchar(100);not null;index:idx_report_reuse"`
+	Fingerprint string `gorm:"type:varchar(64);not null;index:idx_report_reuse"`
+	Params      string `gorm:"type:text;not null"`
+	Status      string `gorm:"type:varchar(20);not null;default:pending;index"`
+	Progress    int    `gorm:"not null;default:0"`
+	Message     string `gorm:"type:varchar(255)"`
+	Error       string `gorm:"type:text"`
+	Path        string `gorm:"type:varchar(255)"`
+	FileName    string `gorm:"type:varchar(255)"`
+	ContentType string `gorm:"type:varchar(100)"`
+	Size        int64  `gorm:"not null;default:0"`
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+	ExpiresAt   time.Time `gorm:"not null;index"`
+	User        User      `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for GORM
+func (Report) TableName() string {
+	return "tb_report"
+}
+
+// CreateReportTable migration - Create tb_report table
+type CreateReportTable struct{}
+
+// Up creates the report table
+func (m *CreateReportTable) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&Report{})
+}
+
+// Down drops the report table
+func (m *CreateReportTable) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&Report{})
+}
+
+// Description returns migration description
+func (m *CreateReportTable) Description() string {
+	return "Create tb_report table"
+}
+
+// Version returns migration version
+func (m *CreateReportTable) Version() string {
+	return "2026_10_16_182000_create_report_table"
+}
+
+// Auto-register migration
+func init() {
+	Register(&CreateReportTable{})
+}
@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// userTokenDevice adds the columns describing where a session is used to
+// tb_user_token
+type userTokenDevice struct {
+	IPAddress  string `gorm:"type:varchar(45)"`
+	UserAgent  string `gorm:"type:varchar(255)"`
+	LastUsedAt *time.Time
+}
+
+// TableName returns the table name for GORM
+func (userTokenDevice) TableName() string {
+	return "tb_user_token"
+}
+
+// userTokenDeviceColumns are added in this order and dropped in reverse
+var userTokenDeviceColumns = []string{"IPAddress", "UserAgent", "LastUsedAt"}
+
+// AddDeviceToUserTokenTable migration - Add the ip_address, user_agent and last_used_at columns to tb_user_token
+type AddDeviceToUserTokenTable struct{}
+
+// Up adds the device columns
+func (m *AddDeviceToUserTokenTable) Up(db *gorm.DB) error {
+	for _, column := range userTokenDeviceColumns {
+		if db.Migrator().HasColumn(&userTokenDevice{}, column) {
+			continue
+		}
+		if err := db.Migrator().AddColumn(&userTokenDevice{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down drops the device columns
+func (m *AddDeviceToUserTokenTable) Down(db *gorm.DB) error {
+	for i := len(userTokenDeviceColumns) - 1; i >= 0; i-- {
+		if err := db.Migrator().DropColumn(&userTokenDevice{}, userTokenDeviceColumns[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Description returns migration description
+func (m *AddDeviceToUserTokenTable) Description() string {
+	return "Add ip_address, user_agent and last_used_at columns to tb_user_token"
+}
+
+// Version returns migration version
+func (m *AddDeviceToUserTokenTable) Version() string {
+	return "2026_10_16_183000_add_device_to_user_token_table"
+}
+
+// Auto-register migration
+func init() {
+	Register(&AddDeviceToUserTokenTable{})
+}
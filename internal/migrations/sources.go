@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"embed"
+
+	"flex-service/pkg/migration"
+)
+
+// sources are the migration files, compiled in so the checksums recorded
+// when a migration is applied can be verified by any build
+//
+//go:embed *.go
+var sources embed.FS
+
+func init() {
+	migration.UseSources(sources)
+}
@@ -0,0 +1,8 @@
+// Package modules compiles in the modules that register themselves with
+// container.Register. make:package adds a blank import for each package it
+// generates; delete the import to unplug a module.
+package modules
+
+import (
+// make:package adds module imports here
+)
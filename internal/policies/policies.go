@@ -0,0 +1,13 @@
+// Package policies holds the authorization policies of the entities, checked
+// with authorize.Can. make:policy generates a policy and adds it to Register.
+package policies
+
+import (
+	"flex-service/pkg/auth"
+)
+
+// Register sets the entity policies on p; the container calls it at startup.
+// permissions lets policies honour role grants such as "post:update".
+func Register(p *auth.Policies, permissions *auth.PermissionChecker) {
+	// make:policy adds policies here
+}
@@ -0,0 +1,190 @@
+package rbac
+
+import (
+	"net/http"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/errors"
+	"flex-service/pkg/request"
+	"flex-service/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RBACHandler struct {
+	usecase RBACUsecase
+}
+
+func NewRBACHandler(usecase RBACUsecase) *RBACHandler {
+	return &RBACHandler{
+		usecase: usecase,
+	}
+}
+
+func (h *RBACHandler) ListRoles(c *gin.Context) {
+	roles, err := h.usecase.ListRoles(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Roles retrieved successfully", roles)
+}
+
+func (h *RBACHandler) GetRole(c *gin.Context) {
+	role, err := h.usecase.GetRole(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Role retrieved successfully", role)
+}
+
+func (h *RBACHandler) CreateRole(c *gin.Context) {
+	req, err := request.Bind[entity.CreateRoleRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	role, err := h.usecase.CreateRole(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Role created successfully", role)
+}
+
+func (h *RBACHandler) UpdateRole(c *gin.Context) {
+	req, err := request.BindJSON[entity.UpdateRoleRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	role, err := h.usecase.UpdateRole(c.Request.Context(), c.Param("name"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Role updated successfully", role)
+}
+
+func (h *RBACHandler) DeleteRole(c *gin.Context) {
+	if err := h.usecase.DeleteRole(c.Request.Context(), c.Param("name")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Role deleted successfully", nil)
+}
+
+// SetRolePermissions replaces the permissions of a role
+func (h *RBACHandler) SetRolePermissions(c *gin.Context) {
+	req, err := request.BindJSON[entity.SetRolePermissionsRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	role, err := h.usecase.SetRolePermissions(c.Request.Context(), c.Param("name"), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Role permissions updated successfully", role)
+}
+
+func (h *RBACHandler) ListPermissions(c *gin.Context) {
+	permissions, err := h.usecase.ListPermissions(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Permissions retrieved successfully", permissions)
+}
+
+func (h *RBACHandler) CreatePermission(c *gin.Context) {
+	req, err := request.Bind[entity.CreatePermissionRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	permission, err := h.usecase.CreatePermission(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Permission created successfully", permission)
+}
+
+func (h *RBACHandler) DeletePermission(c *gin.Context) {
+	if err := h.usecase.DeletePermission(c.Request.Context(), c.Param("name")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Permission deleted successfully", nil)
+}
+
+func (h *RBACHandler) ListUserRoles(c *gin.Context) {
+	userUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(errors.BadRequest("Invalid user ID"))
+		return
+	}
+
+	roles, err := h.usecase.ListUserRoles(c.Request.Context(), userUUID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "User roles retrieved successfully", gin.H{"roles": roles})
+}
+
+func (h *RBACHandler) AssignRole(c *gin.Context) {
+	userUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(errors.BadRequest("Invalid user ID"))
+		return
+	}
+
+	req, err := request.BindJSON[entity.AssignRoleRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	roles, err := h.usecase.AssignRole(c.Request.Context(), userUUID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Role assigned successfully", gin.H{"roles": roles})
+}
+
+func (h *RBACHandler) RevokeRole(c *gin.Context) {
+	userUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(errors.BadRequest("Invalid user ID"))
+		return
+	}
+
+	roles, err := h.usecase.RevokeRole(c.Request.Context(), userUUID, c.Param("role"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Role revoked successfully", gin.H{"roles": roles})
+}
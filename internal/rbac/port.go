@@ -0,0 +1,65 @@
+package rbac
+
+import (
+	"context"
+	"time"
+
+	"flex-service/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+// ManagePermission is required for every RBAC management route
+const ManagePermission = "rbac.manage"
+
+// SyncInterval is how often an instance checks whether another instance changed
+// roles, so changes made elsewhere apply within this delay
+const SyncInterval = 10 * time.Second
+
+// UserRolesTTL is how long the role names of a user are cached
+const UserRolesTTL = 5 * time.Minute
+
+// RBACUsecase defines the business logic interface for roles and permissions.
+// It also implements auth.RoleResolver.
+type RBACUsecase interface {
+	ListRoles(ctx context.Context) ([]entity.Role, error)
+	GetRole(ctx context.Context, name string) (*entity.Role, error)
+	CreateRole(ctx context.Context, req *entity.CreateRoleRequest) (*entity.Role, error)
+	UpdateRole(ctx context.Context, name string, req *entity.UpdateRoleRequest) (*entity.Role, error)
+	DeleteRole(ctx context.Context, name string) error
+	SetRolePermissions(ctx context.Context, name string, req *entity.SetRolePermissionsRequest) (*entity.Role, error)
+
+	ListPermissions(ctx context.Context) ([]entity.Permission, error)
+	CreatePermission(ctx context.Context, req *entity.CreatePermissionRequest) (*entity.Permission, error)
+	DeletePermission(ctx context.Context, name string) error
+
+	ListUserRoles(ctx context.Context, userUUID uuid.UUID) ([]string, error)
+	AssignRole(ctx context.Context, userUUID uuid.UUID, req *entity.AssignRoleRequest) ([]string, error)
+	RevokeRole(ctx context.Context, userUUID uuid.UUID, role string) ([]string, error)
+
+	// UserRoles returns the role names of a user, cached, and brings the
+	// permission checker up to date first
+	UserRoles(ctx context.Context, userID int) ([]string, error)
+	// Load replaces the database roles in the permission checker
+	Load(ctx context.Context) error
+}
+
+// RBACRepository defines the data access interface for roles and permissions
+type RBACRepository interface {
+	ListRoles(ctx context.Context) ([]entity.Role, error)
+	GetRole(ctx context.Context, name string) (*entity.Role, error)
+	CreateRole(ctx context.Context, role *entity.Role, permissions []string) error
+	SaveRole(ctx context.Context, role *entity.Role) error
+	DeleteRole(ctx context.Context, role *entity.Role) error
+	// SetRolePermissions replaces the role's permissions, creating missing ones
+	SetRolePermissions(ctx context.Context, role *entity.Role, permissions []string) error
+
+	ListPermissions(ctx context.Context) ([]entity.Permission, error)
+	CreatePermission(ctx context.Context, permission *entity.Permission) error
+	DeletePermission(ctx context.Context, name string) error
+
+	GetUserID(ctx context.Context, userUUID uuid.UUID) (int, error)
+	UserRoles(ctx context.Context, userID int) ([]string, error)
+	AssignRole(ctx context.Context, userID, roleID int) error
+	RevokeRole(ctx context.Context, userID, roleID int) error
+}
@@ -0,0 +1,184 @@
+package rbac
+
+import (
+	"context"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/database"
+	"flex-service/pkg/errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type rbacRepository struct {
+	db *gorm.DB
+}
+
+func NewRBACRepository(db *gorm.DB) RBACRepository {
+	return &rbacRepository{
+		db: db,
+	}
+}
+
+func (r *rbacRepository) ListRoles(ctx context.Context) ([]entity.Role, error) {
+	var roles []entity.Role
+	if err := r.db.WithContext(ctx).Preload("Permissions").Order("name ASC").Find(&roles).Error; err != nil {
+		return nil, errors.WrapDatabase(err, "failed to list roles")
+	}
+	return roles, nil
+}
+
+func (r *rbacRepository) GetRole(ctx context.Context, name string) (*entity.Role, error) {
+	var role entity.Role
+	err := r.db.WithContext(ctx).Preload("Permissions").Where("name = ?", name).First(&role).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NotFound("Role not found")
+		}
+		return nil, errors.WrapDatabase(err, "failed to get role")
+	}
+	return &role, nil
+}
+
+func (r *rbacRepository) CreateRole(ctx context.Context, role *entity.Role, permissions []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Permissions").Create(role).Error; err != nil {
+			return database.WrapError(err, "failed to create role")
+		}
+		return setPermissions(tx, role, permissions)
+	})
+}
+
+func (r *rbacRepository) SaveRole(ctx context.Context, role *entity.Role) error {
+	if err := r.db.WithContext(ctx).Omit("Permissions").Save(role).Error; err != nil {
+		return database.WrapError(err, "failed to save role")
+	}
+	return nil
+}
+
+func (r *rbacRepository) DeleteRole(ctx context.Context, role *entity.Role) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", role.ID).Delete(&entity.RoleUser{}).Error; err != nil {
+			return errors.WrapDatabase(err, "failed to delete role assignments")
+		}
+		if err := tx.Table("tb_permission_role").Where("role_id = ?", role.ID).Delete(nil).Error; err != nil {
+			return errors.WrapDatabase(err, "failed to delete role permissions")
+		}
+		if err := tx.Delete(&entity.Role{}, role.ID).Error; err != nil {
+			return errors.WrapDatabase(err, "failed to delete role")
+		}
+		return nil
+	})
+}
+
+func (r *rbacRepository) SetRolePermissions(ctx context.Context, role *entity.Role, permissions []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return setPermissions(tx, role, permissions)
+	})
+}
+
+// setPermissions replaces the permissions of role with the named ones, creating
+// permissions that don't exist yet
+func setPermissions(tx *gorm.DB, role *entity.Role, names []string) error {
+	permissions := make([]entity.Permission, 0, len(names))
+	if len(names) > 0 {
+		rows := make([]entity.Permission, 0, len(names))
+		for _, name := range names {
+			rows = append(rows, entity.Permission{Name: name})
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+			return errors.WrapDatabase(err, "failed to create permissions")
+		}
+		if err := tx.Where("name IN ?", names).Order("name ASC").Find(&permissions).Error; err != nil {
+			return errors.WrapDatabase(err, "failed to load permissions")
+		}
+	}
+
+	if err := tx.Model(role).Association("Permissions").Replace(permissions); err != nil {
+		return errors.WrapDatabase(err, "failed to set role permissions")
+	}
+	role.Permissions = permissions
+	return nil
+}
+
+func (r *rbacRepository) ListPermissions(ctx context.Context) ([]entity.Permission, error) {
+	var permissions []entity.Permission
+	if err := r.db.WithContext(ctx).Order("name ASC").Find(&permissions).Error; err != nil {
+		return nil, errors.WrapDatabase(err, "failed to list permissions")
+	}
+	return permissions, nil
+}
+
+func (r *rbacRepository) CreatePermission(ctx context.Context, permission *entity.Permission) error {
+	if err := r.db.WithContext(ctx).Create(permission).Error; err != nil {
+		return database.WrapError(err, "failed to create permission")
+	}
+	return nil
+}
+
+func (r *rbacRepository) DeletePermission(ctx context.Context, name string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var permission entity.Permission
+		if err := tx.Where("name = ?", name).First(&permission).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.NotFound("Permission not found")
+			}
+			return errors.WrapDatabase(err, "failed to get permission")
+		}
+		if err := tx.Table("tb_permission_role").Where("permission_id = ?", permission.ID).Delete(nil).Error; err != nil {
+			return errors.WrapDatabase(err, "failed to delete permission grants")
+		}
+		if err := tx.Delete(&permission).Error; err != nil {
+			return errors.WrapDatabase(err, "failed to delete permission")
+		}
+		return nil
+	})
+}
+
+func (r *rbacRepository) GetUserID(ctx context.Context, userUUID uuid.UUID) (int, error) {
+	var user entity.User
+	err := r.db.WithContext(ctx).Select("id").Where("uuid = ?", userUUID).First(&user).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return 0, errors.NotFound("User not found")
+		}
+		return 0, errors.WrapDatabase(err, "failed to get user")
+	}
+	return user.ID, nil
+}
+
+func (r *rbacRepository) UserRoles(ctx context.Context, userID int) ([]string, error) {
+	var names []string
+	err := r.db.WithContext(ctx).
+		Table("tb_role_user").
+		Joins("JOIN tb_role ON tb_role.id = tb_role_user.role_id").
+		Where("tb_role_user.user_id = ?", userID).
+		Order("tb_role.name ASC").
+		Pluck("tb_role.name", &names).Error
+	if err != nil {
+		return nil, errors.WrapDatabase(err, "failed to get user roles")
+	}
+	return names, nil
+}
+
+func (r *rbacRepository) AssignRole(ctx context.Context, userID, roleID int) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&entity.RoleUser{RoleID: roleID, UserID: userID}).Error
+	if err != nil {
+		return errors.WrapDatabase(err, "failed to assign role")
+	}
+	return nil
+}
+
+func (r *rbacRepository) RevokeRole(ctx context.Context, userID, roleID int) error {
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND role_id = ?", userID, roleID).
+		Delete(&entity.RoleUser{}).Error
+	if err != nil {
+		return errors.WrapDatabase(err, "failed to revoke role")
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package rbac
+
+import (
+	"net/http"
+
+	"flex-service/pkg/routes"
+)
+
+// Manifest declares the role and permission management routes, mounted with
+// the admin route profile. Every route requires ManagePermission, which the
+// seeded "admin" role grants through "*".
+var Manifest = routes.Manifest{
+	Module:     "rbac",
+	Prefix:     "/rbac",
+	Middleware: []string{"auth"},
+	Profile:    routes.ProfileAdmin,
+	Routes: []routes.Route{
+		{Method: http.MethodGet, Path: "/roles", Handler: "ListRoles", Permission: ManagePermission, Summary: "List roles with their permissions"},
+		{Method: http.MethodPost, Path: "/roles", Handler: "CreateRole", Permission: ManagePermission, Summary: "Create a role"},
+		{Method: http.MethodGet, Path: "/roles/:name", Handler: "GetRole", Permission: ManagePermission, Summary: "Get a role"},
+		{Method: http.MethodPut, Path: "/roles/:name", Handler: "UpdateRole", Permission: ManagePermission, Summary: "Update a role"},
+		{Method: http.MethodDelete, Path: "/roles/:name", Handler: "DeleteRole", Permission: ManagePermission, Summary: "Delete a role and its assignments"},
+		{Method: http.MethodPut, Path: "/roles/:name/permissions", Handler: "SetRolePermissions", Permission: ManagePermission, Summary: "Replace the permissions of a role"},
+		{Method: http.MethodGet, Path: "/permissions", Handler: "ListPermissions", Permission: ManagePermission, Summary: "List permissions"},
+		{Method: http.MethodPost, Path: "/permissions", Handler: "CreatePermission", Permission: ManagePermission, Summary: "Create a permission"},
+		{Method: http.MethodDelete, Path: "/permissions/:name", Handler: "DeletePermission", Permission: ManagePermission, Summary: "Delete a permission and its grants"},
+		{Method: http.MethodGet, Path: "/users/:id/roles", Handler: "ListUserRoles", Permission: ManagePermission, Summary: "List the roles of a user"},
+		{Method: http.MethodPost, Path: "/users/:id/roles", Handler: "AssignRole", Permission: ManagePermission, Summary: "Assign a role to a user"},
+		{Method: http.MethodDelete, Path: "/users/:id/roles/:role", Handler: "RevokeRole", Permission: ManagePermission, Summary: "Revoke a role from a user"},
+	},
+}
@@ -0,0 +1,290 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/auth"
+	"flex-service/pkg/cache"
+	"flex-service/pkg/errors"
+	"flex-service/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// versionKey is bumped on every change so all instances reload their checker
+// and stop using cached user roles
+const versionKey = "rbac:version"
+
+type rbacUsecase struct {
+	repo    RBACRepository
+	cache   cache.Cache
+	checker *auth.PermissionChecker
+
+	mu        sync.Mutex
+	loaded    map[string]bool // Roles defined in the checker by Load
+	version   int64
+	checkedAt time.Time
+}
+
+func NewRBACUsecase(repo RBACRepository, cache cache.Cache, checker *auth.PermissionChecker) RBACUsecase {
+	return &rbacUsecase{
+		repo:    repo,
+		cache:   cache,
+		checker: checker,
+		loaded:  make(map[string]bool),
+	}
+}
+
+func (u *rbacUsecase) ListRoles(ctx context.Context) ([]entity.Role, error) {
+	return u.repo.ListRoles(ctx)
+}
+
+func (u *rbacUsecase) GetRole(ctx context.Context, name string) (*entity.Role, error) {
+	return u.repo.GetRole(ctx, name)
+}
+
+func (u *rbacUsecase) CreateRole(ctx context.Context, req *entity.CreateRoleRequest) (*entity.Role, error) {
+	if _, err := u.repo.GetRole(ctx, req.Name); err == nil {
+		return nil, errors.Conflict("Role already exists")
+	} else if !errors.HasCode(err, errors.ErrNotFound) {
+		return nil, err
+	}
+
+	role := &entity.Role{Name: req.Name, Description: req.Description}
+	if err := u.repo.CreateRole(ctx, role, req.Permissions); err != nil {
+		return nil, err
+	}
+
+	u.changed(ctx)
+	logger.Info("Role created", zap.String("role", role.Name))
+	return role, nil
+}
+
+func (u *rbacUsecase) UpdateRole(ctx context.Context, name string, req *entity.UpdateRoleRequest) (*entity.Role, error) {
+	role, err := u.repo.GetRole(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Description != nil {
+		role.Description = *req.Description
+	}
+	if err := u.repo.SaveRole(ctx, role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+func (u *rbacUsecase) DeleteRole(ctx context.Context, name string) error {
+	role, err := u.repo.GetRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := u.repo.DeleteRole(ctx, role); err != nil {
+		return err
+	}
+
+	u.changed(ctx)
+	logger.Info("Role deleted", zap.String("role", role.Name))
+	return nil
+}
+
+func (u *rbacUsecase) SetRolePermissions(ctx context.Context, name string, req *entity.SetRolePermissionsRequest) (*entity.Role, error) {
+	role, err := u.repo.GetRole(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.repo.SetRolePermissions(ctx, role, req.Permissions); err != nil {
+		return nil, err
+	}
+
+	u.changed(ctx)
+	logger.Info("Role permissions updated", zap.String("role", role.Name), zap.Strings("permissions", role.PermissionNames()))
+	return role, nil
+}
+
+func (u *rbacUsecase) ListPermissions(ctx context.Context) ([]entity.Permission, error) {
+	return u.repo.ListPermissions(ctx)
+}
+
+func (u *rbacUsecase) CreatePermission(ctx context.Context, req *entity.CreatePermissionRequest) (*entity.Permission, error) {
+	permissions, err := u.repo.ListPermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, permission := range permissions {
+		if permission.Name == req.Name {
+			return nil, errors.Conflict("Permission already exists")
+		}
+	}
+
+	permission := &entity.Permission{Name: req.Name, Description: req.Description}
+	if err := u.repo.CreatePermission(ctx, permission); err != nil {
+		return nil, err
+	}
+	return permission, nil
+}
+
+func (u *rbacUsecase) DeletePermission(ctx context.Context, name string) error {
+	if err := u.repo.DeletePermission(ctx, name); err != nil {
+		return err
+	}
+
+	u.changed(ctx)
+	logger.Info("Permission deleted", zap.String("permission", name))
+	return nil
+}
+
+func (u *rbacUsecase) ListUserRoles(ctx context.Context, userUUID uuid.UUID) ([]string, error) {
+	userID, err := u.repo.GetUserID(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	return u.repo.UserRoles(ctx, userID)
+}
+
+func (u *rbacUsecase) AssignRole(ctx context.Context, userUUID uuid.UUID, req *entity.AssignRoleRequest) ([]string, error) {
+	userID, role, err := u.userAndRole(ctx, userUUID, req.Role)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.repo.AssignRole(ctx, userID, role.ID); err != nil {
+		return nil, err
+	}
+
+	u.changed(ctx)
+	logger.Info("Role assigned", zap.Int("user_id", userID), zap.String("role", role.Name))
+	return u.repo.UserRoles(ctx, userID)
+}
+
+func (u *rbacUsecase) RevokeRole(ctx context.Context, userUUID uuid.UUID, name string) ([]string, error) {
+	userID, role, err := u.userAndRole(ctx, userUUID, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.repo.RevokeRole(ctx, userID, role.ID); err != nil {
+		return nil, err
+	}
+
+	u.changed(ctx)
+	logger.Info("Role revoked", zap.Int("user_id", userID), zap.String("role", role.Name))
+	return u.repo.UserRoles(ctx, userID)
+}
+
+func (u *rbacUsecase) userAndRole(ctx context.Context, userUUID uuid.UUID, name string) (int, *entity.Role, error) {
+	userID, err := u.repo.GetUserID(ctx, userUUID)
+	if err != nil {
+		return 0, nil, err
+	}
+	role, err := u.repo.GetRole(ctx, name)
+	if err != nil {
+		return 0, nil, err
+	}
+	return userID, role, nil
+}
+
+func (u *rbacUsecase) UserRoles(ctx context.Context, userID int) ([]string, error) {
+	version := u.refresh(ctx)
+
+	if u.cache == nil {
+		return u.repo.UserRoles(ctx, userID)
+	}
+
+	key := fmt.Sprintf("rbac:user:%d:roles:%d", userID, version)
+	return cache.Remember(ctx, u.cache, key, UserRolesTTL, func() ([]string, error) {
+		return u.repo.UserRoles(ctx, userID)
+	})
+}
+
+// Load defines every database role in the checker and removes roles that were
+// loaded before but have since been deleted. A database role replaces a role of
+// the same name from the roles file.
+func (u *rbacUsecase) Load(ctx context.Context) error {
+	roles, err := u.repo.ListRoles(ctx)
+	if err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	current := make(map[string]bool, len(roles))
+	for i := range roles {
+		role := &auth.Role{Name: roles[i].Name, Permissions: roles[i].PermissionNames()}
+		if err := u.checker.DefineRole(role); err != nil {
+			logger.Warn("Failed to load role", zap.String("role", role.Name), zap.Error(err))
+			continue
+		}
+		current[role.Name] = true
+	}
+	for name := range u.loaded {
+		if !current[name] {
+			u.checker.RemoveRole(name)
+		}
+	}
+	u.loaded = current
+
+	logger.Debug("Roles loaded into permission checker", zap.Int("roles", len(current)))
+	return nil
+}
+
+// changed reloads the checker and bumps the shared version after a change
+func (u *rbacUsecase) changed(ctx context.Context) {
+	if err := u.Load(ctx); err != nil {
+		logger.Warn("Failed to reload roles", zap.Error(err))
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.cache == nil {
+		u.version++
+		return
+	}
+	version, err := u.cache.Incr(ctx, versionKey)
+	if err != nil {
+		logger.Warn("Failed to bump RBAC version", zap.Error(err))
+		u.version++
+		return
+	}
+	u.version = version
+	u.checkedAt = time.Now()
+}
+
+// refresh reloads the checker when another instance changed roles, checking the
+// shared version at most once per SyncInterval. It returns the current version.
+func (u *rbacUsecase) refresh(ctx context.Context) int64 {
+	u.mu.Lock()
+	if u.cache == nil || time.Since(u.checkedAt) < SyncInterval {
+		version := u.version
+		u.mu.Unlock()
+		return version
+	}
+	u.checkedAt = time.Now()
+	local := u.version
+	u.mu.Unlock()
+
+	var shared int64
+	if raw, err := u.cache.Get(ctx, versionKey); err == nil {
+		shared, _ = strconv.ParseInt(raw, 10, 64)
+	}
+	if shared == local {
+		return local
+	}
+
+	if err := u.Load(ctx); err != nil {
+		logger.Warn("Failed to reload roles", zap.Error(err))
+		return local
+	}
+
+	u.mu.Lock()
+	u.version = shared
+	u.mu.Unlock()
+	return shared
+}
@@ -0,0 +1,136 @@
+package report
+
+import (
+	"net/http"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/errors"
+	"flex-service/pkg/request"
+	"flex-service/pkg/requestctx"
+	"flex-service/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ReportHandler struct {
+	usecase ReportUsecase
+}
+
+func NewReportHandler(usecase ReportUsecase) *ReportHandler {
+	return &ReportHandler{
+		usecase: usecase,
+	}
+}
+
+func (h *ReportHandler) List(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	reports, err := h.usecase.List(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Reports retrieved successfully", reports)
+}
+
+// Request queues a report and answers 202 with it; poll Show for progress.
+// An identical report that is still running or recent answers 200 instead.
+func (h *ReportHandler) Request(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	req, err := request.BindJSON[entity.RequestReportRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	report, reused, err := h.usecase.Request(c.Request.Context(), userID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if reused {
+		response.Success(c, http.StatusOK, "Existing report reused", report)
+		return
+	}
+	c.Header("Location", c.FullPath()+"/"+report.UUID.String())
+	response.Success(c, http.StatusAccepted, "Report requested successfully", report)
+}
+
+// Show returns a report with its status and progress
+func (h *ReportHandler) Show(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	reportUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(errors.BadRequest("Invalid report ID"))
+		return
+	}
+
+	report, err := h.usecase.Get(c.Request.Context(), userID, reportUUID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Report retrieved successfully", report)
+}
+
+// Download returns a temporary URL of a completed report's file
+func (h *ReportHandler) Download(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	reportUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(errors.BadRequest("Invalid report ID"))
+		return
+	}
+
+	download, err := h.usecase.Download(c.Request.Context(), userID, reportUUID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Report download URL created", download)
+}
+
+func (h *ReportHandler) Delete(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	reportUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(errors.BadRequest("Invalid report ID"))
+		return
+	}
+
+	if err := h.usecase.Delete(c.Request.Context(), userID, reportUUID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Report deleted successfully", nil)
+}
@@ -0,0 +1,131 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"flex-service/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTTL is how long a finished report is kept, and handed out again for
+// identical requests, when its Type sets no TTL
+const DefaultTTL = 24 * time.Hour
+
+// DownloadURLTTL is how long a download URL stays valid
+const DownloadURLTTL = 15 * time.Minute
+
+// Workers is how many reports one instance builds at a time
+const Workers = 2
+
+// PollInterval is how often idle workers look for pending reports; a report
+// requested on the same instance starts at once
+const PollInterval = 5 * time.Second
+
+// StaleAfter is how long a report may run without reporting progress before
+// it is considered abandoned, e.g. by an instance that crashed, and failed
+const StaleAfter = 30 * time.Minute
+
+// PurgeInterval is how often expired reports and their files are deleted
+const PurgeInterval = time.Hour
+
+// Progress reports how far a report is, in percent, with an optional message
+// such as "Reading orders". Calls that don't change the percentage are cheap.
+type Progress func(percent int, message string)
+
+// File is what a report type writes
+type File struct {
+	Name        string // download file name, e.g. "sales-2026-10.csv"
+	ContentType string
+}
+
+// Type is a kind of report. Register types on the ReportUsecase; requests for
+// an unknown type are rejected.
+type Type struct {
+	Name string
+	// TTL is how long a finished report is kept and reused
+	TTL time.Duration
+	// decode turns the request params into the type's params struct
+	decode func(params []byte) (interface{}, error)
+	// generate writes the report for the decoded params to w
+	generate func(ctx context.Context, params interface{}, progress Progress, w io.Writer) (*File, error)
+}
+
+// NewType creates a Type whose params are validated as T. generate writes the
+// report to w and returns its file name and content type; it should stop when
+// ctx is done.
+//
+//	reports.Register(report.NewType("sales", time.Hour,
+//		func(ctx context.Context, params *SalesParams, progress report.Progress, w io.Writer) (*report.File, error) {
+//			progress(0, "Reading orders")
+//			...
+//			return &report.File{Name: "sales.csv", ContentType: "text/csv"}, nil
+//		}))
+func NewType[T any](name string, ttl time.Duration, generate func(ctx context.Context, params *T, progress Progress, w io.Writer) (*File, error)) *Type {
+	return &Type{
+		Name: name,
+		TTL:  ttl,
+		decode: func(params []byte) (interface{}, error) {
+			req := new(T)
+			if err := json.Unmarshal(params, req); err != nil {
+				return nil, err
+			}
+			return req, nil
+		},
+		generate: func(ctx context.Context, params interface{}, progress Progress, w io.Writer) (*File, error) {
+			return generate(ctx, params.(*T), progress, w)
+		},
+	}
+}
+
+// Download is a temporary link to a finished report
+type Download struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+	FileName  string    `json:"file_name"`
+}
+
+// ReportUsecase defines the business logic interface for reports
+type ReportUsecase interface {
+	// Register adds a report type; registering a name again replaces it
+	Register(reportType *Type)
+
+	List(ctx context.Context, userID int) ([]entity.Report, error)
+	// Request queues a report, or returns the user's identical report that is
+	// still running or finished within the type's TTL. reused tells which.
+	Request(ctx context.Context, userID int, req *entity.RequestReportRequest) (report *entity.Report, reused bool, err error)
+	// Get returns a report with its progress
+	Get(ctx context.Context, userID int, reportUUID uuid.UUID) (*entity.Report, error)
+	// Download returns a temporary URL of a completed report's file
+	Download(ctx context.Context, userID int, reportUUID uuid.UUID) (*Download, error)
+	Delete(ctx context.Context, userID int, reportUUID uuid.UUID) error
+
+	// Run builds pending reports until ctx is done
+	Run(ctx context.Context)
+	// Purge deletes expired reports and their files
+	Purge(ctx context.Context) (int, error)
+}
+
+// ReportRepository defines the data access interface for reports
+type ReportRepository interface {
+	List(ctx context.Context, userID int) ([]entity.Report, error)
+	Get(ctx context.Context, userID int, reportUUID uuid.UUID) (*entity.Report, error)
+	// FindReusable returns the newest report with the fingerprint that is
+	// pending, running, or completed and not expired, or nil
+	FindReusable(ctx context.Context, userID int, reportType, fingerprint string, now time.Time) (*entity.Report, error)
+	Create(ctx context.Context, report *entity.Report) error
+	// Claim marks the oldest pending report running and returns it, or nil.
+	// Only one instance can claim a report.
+	Claim(ctx context.Context, now time.Time) (*entity.Report, error)
+	// UpdateProgress records the progress of a running report
+	UpdateProgress(ctx context.Context, report *entity.Report) error
+	// Finish saves a completed or failed report
+	Finish(ctx context.Context, report *entity.Report) error
+	// FailStale fails running reports without progress since before
+	FailStale(ctx context.Context, before time.Time) (int64, error)
+	Delete(ctx context.Context, report *entity.Report) error
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]entity.Report, error)
+}
@@ -0,0 +1,153 @@
+package report
+
+import (
+	"context"
+	"time"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/database"
+	"flex-service/pkg/errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type reportRepository struct {
+	db *gorm.DB
+}
+
+func NewReportRepository(db *gorm.DB) ReportRepository {
+	return &reportRepository{
+		db: db,
+	}
+}
+
+func (r *reportRepository) List(ctx context.Context, userID int) ([]entity.Report, error) {
+	var reports []entity.Report
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&reports).Error
+	if err != nil {
+		return nil, errors.WrapDatabase(err, "failed to list reports")
+	}
+	return reports, nil
+}
+
+func (r *reportRepository) Get(ctx context.Context, userID int, reportUUID uuid.UUID) (*entity.Report, error) {
+	var report entity.Report
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND uuid = ?", userID, reportUUID).
+		First(&report).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NotFound("Report not found")
+		}
+		return nil, errors.WrapDatabase(err, "failed to get report")
+	}
+	return &report, nil
+}
+
+func (r *reportRepository) FindReusable(ctx context.Context, userID int, reportType, fingerprint string, now time.Time) (*entity.Report, error) {
+	var reports []entity.Report
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND type = ? AND fingerprint = ?", userID, reportType, fingerprint).
+		Where("status IN ? OR (status = ? AND expires_at > ?)",
+			[]string{entity.ReportPending, entity.ReportRunning}, entity.ReportCompleted, now).
+		Order("created_at DESC").
+		Limit(1).
+		Find(&reports).Error
+	if err != nil {
+		return nil, errors.WrapDatabase(err, "failed to find report")
+	}
+	if len(reports) == 0 {
+		return nil, nil
+	}
+	return &reports[0], nil
+}
+
+func (r *reportRepository) Create(ctx context.Context, report *entity.Report) error {
+	if err := r.db.WithContext(ctx).Omit("User").Create(report).Error; err != nil {
+		return database.WrapError(err, "failed to create report")
+	}
+	return nil
+}
+
+func (r *reportRepository) Claim(ctx context.Context, now time.Time) (*entity.Report, error) {
+	for {
+		// Find rather than First: an empty queue is the normal case
+		var reports []entity.Report
+		err := r.db.WithContext(ctx).
+			Where("status = ?", entity.ReportPending).
+			Order("id ASC").
+			Limit(1).
+			Find(&reports).Error
+		if err != nil {
+			return nil, errors.WrapDatabase(err, "failed to find pending report")
+		}
+		if len(reports) == 0 {
+			return nil, nil
+		}
+		report := reports[0]
+
+		// The status condition makes the update a compare-and-swap: another
+		// instance that claimed the report first leaves nothing to update
+		result := r.db.WithContext(ctx).Model(&entity.Report{}).
+			Where("id = ? AND status = ?", report.ID, entity.ReportPending).
+			Updates(map[string]interface{}{"status": entity.ReportRunning, "started_at": now, "updated_at": now})
+		if result.Error != nil {
+			return nil, errors.WrapDatabase(result.Error, "failed to claim report")
+		}
+		if result.RowsAffected == 1 {
+			report.Status = entity.ReportRunning
+			report.StartedAt = &now
+			return &report, nil
+		}
+	}
+}
+
+func (r *reportRepository) UpdateProgress(ctx context.Context, report *entity.Report) error {
+	err := r.db.WithContext(ctx).Model(report).
+		Updates(map[string]interface{}{"progress": report.Progress, "message": report.Message}).Error
+	if err != nil {
+		return errors.WrapDatabase(err, "failed to update report progress")
+	}
+	return nil
+}
+
+func (r *reportRepository) Finish(ctx context.Context, report *entity.Report) error {
+	if err := r.db.WithContext(ctx).Omit("User").Save(report).Error; err != nil {
+		return errors.WrapDatabase(err, "failed to save report")
+	}
+	return nil
+}
+
+func (r *reportRepository) FailStale(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&entity.Report{}).
+		Where("status = ? AND updated_at < ?", entity.ReportRunning, before).
+		Updates(map[string]interface{}{"status": entity.ReportFailed, "error": "Report generation was interrupted"})
+	if result.Error != nil {
+		return 0, errors.WrapDatabase(result.Error, "failed to fail stale reports")
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *reportRepository) Delete(ctx context.Context, report *entity.Report) error {
+	if err := r.db.WithContext(ctx).Delete(report).Error; err != nil {
+		return errors.WrapDatabase(err, "failed to delete report")
+	}
+	return nil
+}
+
+func (r *reportRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]entity.Report, error) {
+	var reports []entity.Report
+	err := r.db.WithContext(ctx).
+		Where("expires_at <= ? AND status IN ?", now, []string{entity.ReportCompleted, entity.ReportFailed}).
+		Order("id ASC").
+		Limit(limit).
+		Find(&reports).Error
+	if err != nil {
+		return nil, errors.WrapDatabase(err, "failed to list expired reports")
+	}
+	return reports, nil
+}
@@ -0,0 +1,22 @@
+package report
+
+import (
+	"net/http"
+
+	"flex-service/pkg/routes"
+)
+
+// Manifest declares the report routes. Reports belong to the authenticated
+// user; requesting one is rate limited since each may take minutes to build.
+var Manifest = routes.Manifest{
+	Module:     "report",
+	Prefix:     "/reports",
+	Middleware: []string{"auth"},
+	Routes: []routes.Route{
+		{Method: http.MethodGet, Path: "", Handler: "List", Summary: "List the user's reports"},
+		{Method: http.MethodPost, Path: "", Handler: "Request", Middleware: []string{"rate.user:10,1m"}, Summary: "Request a report"},
+		{Method: http.MethodGet, Path: "/:id", Handler: "Show", Summary: "Get a report and its progress"},
+		{Method: http.MethodGet, Path: "/:id/download", Handler: "Download", Summary: "Get a temporary download URL of a report"},
+		{Method: http.MethodDelete, Path: "/:id", Handler: "Delete", Summary: "Delete a finished report"},
+	},
+}
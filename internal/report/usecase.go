@@ -0,0 +1,388 @@
+package report
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/errors"
+	"flex-service/pkg/i18n"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/storage"
+	"flex-service/pkg/validator"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// purgeBatchSize bounds how many expired reports one query returns
+const purgeBatchSize = 100
+
+type reportUsecase struct {
+	repo  ReportRepository
+	files storage.Filesystem
+
+	mu    sync.RWMutex
+	types map[string]*Type
+
+	// wake starts an idle worker when a report is requested on this instance
+	wake chan struct{}
+}
+
+// NewReportUsecase creates the report usecase. Reports are built by Run and
+// stored in files under reports/<id>/.
+func NewReportUsecase(repo ReportRepository, files storage.Filesystem) ReportUsecase {
+	return &reportUsecase{
+		repo:  repo,
+		files: files,
+		types: make(map[string]*Type),
+		wake:  make(chan struct{}, Workers),
+	}
+}
+
+func (u *reportUsecase) Register(reportType *Type) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.types[reportType.Name] = reportType
+}
+
+func (u *reportUsecase) List(ctx context.Context, userID int) ([]entity.Report, error) {
+	return u.repo.List(ctx, userID)
+}
+
+func (u *reportUsecase) Request(ctx context.Context, userID int, req *entity.RequestReportRequest) (*entity.Report, bool, error) {
+	reportType, err := u.reportType(req.Type)
+	if err != nil {
+		return nil, false, err
+	}
+
+	params, err := u.validate(ctx, reportType, req.Params)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// The decoded struct marshals its fields in a fixed order, so requests
+	// that differ only in key order or unknown keys share a fingerprint
+	canonical, err := json.Marshal(params)
+	if err != nil {
+		return nil, false, errors.WrapInternal(err, "Failed to encode report params")
+	}
+	fingerprint := fingerprint(reportType.Name, canonical)
+
+	if !req.Fresh {
+		existing, err := u.repo.FindReusable(ctx, userID, reportType.Name, fingerprint, time.Now())
+		if err != nil {
+			return nil, false, err
+		}
+		if existing != nil {
+			return existing, true, nil
+		}
+	}
+
+	report := &entity.Report{
+		UUID:        uuid.New(),
+		UserID:      userID,
+		Type:        reportType.Name,
+		Fingerprint: fingerprint,
+		Params:      string(canonical),
+		Status:      entity.ReportPending,
+		ExpiresAt:   time.Now().Add(u.ttl(reportType)),
+	}
+	if err := u.repo.Create(ctx, report); err != nil {
+		return nil, false, err
+	}
+
+	select {
+	case u.wake <- struct{}{}:
+	default:
+	}
+	return report, false, nil
+}
+
+func (u *reportUsecase) Get(ctx context.Context, userID int, reportUUID uuid.UUID) (*entity.Report, error) {
+	return u.repo.Get(ctx, userID, reportUUID)
+}
+
+func (u *reportUsecase) Download(ctx context.Context, userID int, reportUUID uuid.UUID) (*Download, error) {
+	report, err := u.repo.Get(ctx, userID, reportUUID)
+	if err != nil {
+		return nil, err
+	}
+	if report.Status != entity.ReportCompleted {
+		return nil, errors.Conflict("Report is not ready")
+	}
+
+	url, err := u.files.TemporaryURL(ctx, report.Path, DownloadURLTTL)
+	if err != nil {
+		return nil, errors.WrapInternal(err, "Failed to create report download URL")
+	}
+	return &Download{
+		URL:       url,
+		ExpiresAt: time.Now().Add(DownloadURLTTL),
+		FileName:  report.FileName,
+	}, nil
+}
+
+func (u *reportUsecase) Delete(ctx context.Context, userID int, reportUUID uuid.UUID) error {
+	report, err := u.repo.Get(ctx, userID, reportUUID)
+	if err != nil {
+		return err
+	}
+	if !report.Done() {
+		return errors.Conflict("Report is still being generated")
+	}
+	return u.remove(ctx, report)
+}
+
+// Run starts Workers workers that build pending reports, claimed from the
+// database so several instances can share the work
+func (u *reportUsecase) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (u *reportUsecase) work(ctx context.Context) {
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Build reports until none are pending, then wait
+		for ctx.Err() == nil {
+			report, err := u.repo.Claim(ctx, time.Now())
+			if err != nil {
+				logger.Warn("Failed to claim report", zap.Error(err))
+				break
+			}
+			if report == nil {
+				break
+			}
+			u.build(ctx, report)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-u.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// build generates a claimed report into a temporary file, stores the file and
+// records the outcome
+func (u *reportUsecase) build(ctx context.Context, report *entity.Report) {
+	started := time.Now()
+	file, err := u.generate(ctx, report)
+	if err != nil {
+		report.Status = entity.ReportFailed
+		report.Error = failureMessage(err)
+		logger.Error("Report generation failed",
+			zap.String("report", report.UUID.String()),
+			zap.String("type", report.Type),
+			zap.Error(err))
+	} else {
+		report.Status = entity.ReportCompleted
+		report.Progress = 100
+		report.Message = ""
+		report.FileName = file.Name
+		report.ContentType = file.ContentType
+		logger.Info("Report generated",
+			zap.String("report", report.UUID.String()),
+			zap.String("type", report.Type),
+			zap.Int64("size", report.Size),
+			zap.Duration("duration", time.Since(started)))
+	}
+
+	now := time.Now()
+	report.CompletedAt = &now
+	report.ExpiresAt = now.Add(u.ttlOf(report.Type))
+	// Record the outcome even when shutdown interrupted the report
+	if err := u.repo.Finish(context.WithoutCancel(ctx), report); err != nil {
+		logger.Error("Failed to save report", zap.String("report", report.UUID.String()), zap.Error(err))
+	}
+}
+
+func (u *reportUsecase) generate(ctx context.Context, report *entity.Report) (file *File, err error) {
+	reportType, err := u.reportType(report.Type)
+	if err != nil {
+		return nil, err
+	}
+	params, err := reportType.decode([]byte(report.Params))
+	if err != nil {
+		return nil, fmt.Errorf("invalid report params: %w", err)
+	}
+
+	tmp, err := os.CreateTemp("", "report-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("report panicked: %v", recovered)
+		}
+	}()
+
+	file, err = reportType.generate(ctx, params, u.progress(ctx, report), tmp)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil || file.Name == "" {
+		return nil, fmt.Errorf("report type %s returned no file name", report.Type)
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report size: %w", err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind report: %w", err)
+	}
+
+	report.Path = path.Join("reports", report.UUID.String(), path.Base(file.Name))
+	if err := u.files.Put(ctx, report.Path, tmp, &storage.PutOptions{ContentType: file.ContentType}); err != nil {
+		return nil, fmt.Errorf("failed to store report: %w", err)
+	}
+	report.Size = size
+	return file, nil
+}
+
+// progress returns the Progress of a running report. Percentages are kept
+// below 100 until the file is stored; a message alone is saved at most once
+// a second.
+func (u *reportUsecase) progress(ctx context.Context, report *entity.Report) Progress {
+	var mu sync.Mutex
+	var saved time.Time
+
+	return func(percent int, message string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		percent = min(max(percent, 0), 99)
+		if percent == report.Progress && (message == report.Message || time.Since(saved) < time.Second) {
+			return
+		}
+		report.Progress = percent
+		report.Message = message
+		saved = time.Now()
+		if err := u.repo.UpdateProgress(ctx, report); err != nil {
+			logger.Warn("Failed to save report progress", zap.String("report", report.UUID.String()), zap.Error(err))
+		}
+	}
+}
+
+func (u *reportUsecase) Purge(ctx context.Context) (int, error) {
+	if stale, err := u.repo.FailStale(ctx, time.Now().Add(-StaleAfter)); err != nil {
+		logger.Warn("Failed to fail stale reports", zap.Error(err))
+	} else if stale > 0 {
+		logger.Warn("Stale reports failed", zap.Int64("count", stale))
+	}
+
+	purged := 0
+	for {
+		reports, err := u.repo.ListExpired(ctx, time.Now(), purgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+		for i := range reports {
+			if err := u.remove(ctx, &reports[i]); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+		if len(reports) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// remove deletes a report's file, then the report
+func (u *reportUsecase) remove(ctx context.Context, report *entity.Report) error {
+	if report.Path != "" {
+		if err := u.files.Delete(ctx, report.Path); err != nil {
+			return errors.WrapInternal(err, "Failed to delete report file")
+		}
+	}
+	return u.repo.Delete(ctx, report)
+}
+
+func (u *reportUsecase) reportType(name string) (*Type, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	reportType, ok := u.types[name]
+	if !ok {
+		return nil, errors.NotFound("Report type not found")
+	}
+	return reportType, nil
+}
+
+func (u *reportUsecase) ttl(reportType *Type) time.Duration {
+	if reportType.TTL > 0 {
+		return reportType.TTL
+	}
+	return DefaultTTL
+}
+
+// ttlOf returns the TTL of a type by name, DefaultTTL if it is gone
+func (u *reportUsecase) ttlOf(name string) time.Duration {
+	reportType, err := u.reportType(name)
+	if err != nil {
+		return DefaultTTL
+	}
+	return u.ttl(reportType)
+}
+
+// validate decodes the params into the type's struct and validates it
+func (u *reportUsecase) validate(ctx context.Context, reportType *Type, params map[string]interface{}) (interface{}, error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return nil, errors.WrapBadRequest(err, "Invalid report params")
+	}
+	decoded, err := reportType.decode(payload)
+	if err != nil {
+		return nil, errors.WrapBadRequest(err, "Invalid report params")
+	}
+
+	if errs := validator.Validate(decoded, i18n.FromContext(ctx)); len(errs) > 0 {
+		return nil, errors.Wrap(errs, errors.ErrValidation, "Validation failed", http.StatusBadRequest)
+	}
+	return decoded, nil
+}
+
+// fingerprint identifies a report type and params
+func fingerprint(reportType string, params []byte) string {
+	sum := sha256.Sum256(append([]byte(reportType+"\n"), params...))
+	return hex.EncodeToString(sum[:])
+}
+
+// failureMessage is what the user sees of a failed report: the message of an
+// AppError, which generators return for expected failures, and a generic
+// message otherwise, which may hold internal details
+func failureMessage(err error) string {
+	if appErr, ok := errors.AsAppError(err); ok {
+		return appErr.Message
+	}
+	return "Report generation failed"
+}
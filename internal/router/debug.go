@@ -0,0 +1,65 @@
+package router
+
+import (
+	"expvar"
+	"net/http/pprof"
+	"strconv"
+
+	"flex-service/pkg/cache"
+	"flex-service/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// mountDebug serves expvar at /debug/vars, pprof at /debug/pprof and cache
+// statistics at /debug/cache. None is authenticated, so they are only mounted
+// with the debug route profile.
+func mountDebug(router *gin.Engine, store cache.Cache) {
+	debug := router.Group("/debug")
+	debug.GET("/vars", gin.WrapH(expvar.Handler()))
+	debug.GET("/cache", cacheStats(store))
+	debug.GET("/pprof/*name", func(c *gin.Context) {
+		switch c.Param("name") {
+		case "/cmdline":
+			pprof.Cmdline(c.Writer, c.Request)
+		case "/profile":
+			pprof.Profile(c.Writer, c.Request)
+		case "/symbol":
+			pprof.Symbol(c.Writer, c.Request)
+		case "/trace":
+			pprof.Trace(c.Writer, c.Request)
+		default:
+			// The index, and named profiles such as /heap and /goroutine
+			pprof.Index(c.Writer, c.Request)
+		}
+	})
+	debug.POST("/pprof/symbol", gin.WrapF(pprof.Symbol))
+}
+
+// cacheStats reports hit ratios per namespace and the hottest keys
+// (CACHE_HOT_KEYS). ?keys=1 also counts the keys stored in Redis per
+// namespace, scanning at most ?scan_limit keys.
+func cacheStats(store cache.Cache) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+		hot, tracking := cache.HotKeys(limit)
+		data := gin.H{
+			"hit_ratio":         cache.HitRatios(),
+			"hot_keys":          hot,
+			"hot_keys_tracking": tracking,
+		}
+
+		if ctx.Query("keys") == "1" && store != nil {
+			scanLimit, _ := strconv.Atoi(ctx.DefaultQuery("scan_limit", "100000"))
+			counts, truncated, err := cache.CountKeys(ctx.Request.Context(), store, scanLimit)
+			if err != nil {
+				response.Error(ctx, 500, "CACHE_ERROR", "Failed to count cache keys", gin.H{"error": err.Error()})
+				return
+			}
+			data["key_counts"] = counts
+			data["key_counts_truncated"] = truncated
+		}
+
+		response.Success(ctx, 200, "Cache statistics", data)
+	}
+}
@@ -0,0 +1,195 @@
+package router
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+
+	"flex-service/internal/container"
+	"flex-service/pkg/routes"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteInfo describes a mounted route for artisan route:list
+type RouteInfo struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Handler string `json:"handler"`
+	Module  string `json:"module,omitempty"` // manifest module, empty for hand-wired routes
+	// GlobalMiddleware is how many of the global middleware run first; routes
+	// mounted before the last router.Use (the probes) skip the later ones
+	GlobalMiddleware int `json:"global_middleware"`
+	// Middleware runs after the global middleware: manifest refs for manifest
+	// routes, function names otherwise
+	Middleware []string `json:"middleware"`
+	// Auth lists the authentication the route requires, "public" for none
+	Auth       []string `json:"auth"`
+	Permission string   `json:"permission,omitempty"`
+	Summary    string   `json:"summary,omitempty"`
+}
+
+// RouteTable is the result of Inspect
+type RouteTable struct {
+	Global []string    `json:"global_middleware"`
+	Routes []RouteInfo `json:"routes"`
+}
+
+// authenticators maps middleware function names to the authentication they enforce
+var authenticators = []struct {
+	function string
+	auth     string
+}{
+	{"middleware.UserAuthenticate", "user"},
+	{"middleware.ScopedAuthenticate", "scoped_token"},
+	{"middleware.APIKeyAuthenticate", "api_key"},
+	{"middleware.RequestSignature", "signature"},
+	{"middleware.IncomingWebhook", "webhook_signature"},
+	{"session.(*Manager).Middleware", "session"},
+	{"mtls.Require", "client_cert"},
+}
+
+// closureSuffix matches the .func1, .func1.2 and -fm suffixes of closures and
+// method values
+var closureSuffix = regexp.MustCompile(`(\.func\d+(\.\d+)*|-fm)+$`)
+
+type chainKey struct{}
+
+// Inspect builds the router without serving it and describes every route.
+// The handler chain of each route is read by dispatching a request to it
+// that a probe in front of the global middleware records and aborts, so no
+// middleware or handler runs.
+func Inspect(container *container.Container) RouteTable {
+	writer := gin.DefaultWriter
+	gin.DefaultWriter = io.Discard // no [GIN-debug] route lines
+	defer func() { gin.DefaultWriter = writer }()
+
+	probe := func(c *gin.Context) {
+		if chain, ok := c.Request.Context().Value(chainKey{}).(*[]string); ok {
+			*chain = c.HandlerNames()[1:]
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+	engine, definitions := setupRouter(container, probe)
+
+	byRoute := make(map[string]routes.Definition, len(definitions))
+	for _, definition := range definitions {
+		byRoute[definition.Method+" "+definition.Path] = definition
+	}
+
+	// Unmatched requests run the global middleware and the NoRoute handler
+	global := dispatch(engine, http.MethodGet, "/__route_list__/not-found")
+	if len(global) > 0 {
+		global = global[:len(global)-1]
+	}
+
+	table := RouteTable{Global: shortNames(global)}
+	for _, route := range engine.Routes() {
+		chain := dispatch(engine, route.Method, samplePath(route.Path))
+		shared := 0
+		for shared < len(global) && shared < len(chain) && chain[shared] == global[shared] {
+			shared++
+		}
+		var own []string
+		if len(chain) > shared {
+			own = chain[shared : len(chain)-1]
+		}
+
+		info := RouteInfo{
+			Method:           route.Method,
+			Path:             route.Path,
+			Handler:          shortName(route.Handler),
+			GlobalMiddleware: shared,
+			Middleware:       shortNames(own),
+			Auth:             authOf(chain),
+		}
+		if definition, ok := byRoute[route.Method+" "+route.Path]; ok {
+			info.Module = definition.Module
+			info.Handler = definition.Module + "." + definition.Handler
+			info.Middleware = definition.Middleware
+			info.Permission = definition.Permission
+			info.Summary = definition.Summary
+		} else {
+			info.Permission = permissionOf(own)
+		}
+		table.Routes = append(table.Routes, info)
+	}
+
+	sort.SliceStable(table.Routes, func(i, j int) bool {
+		if table.Routes[i].Path != table.Routes[j].Path {
+			return table.Routes[i].Path < table.Routes[j].Path
+		}
+		return table.Routes[i].Method < table.Routes[j].Method
+	})
+	return table
+}
+
+// dispatch returns the names of the handlers gin runs for a request
+func dispatch(engine *gin.Engine, method, path string) []string {
+	var chain []string
+	ctx := context.WithValue(context.Background(), chainKey{}, &chain)
+	request := httptest.NewRequest(method, path, nil).WithContext(ctx)
+	engine.ServeHTTP(httptest.NewRecorder(), request)
+	return chain
+}
+
+// samplePath fills the :param and *wildcard segments of a route path
+func samplePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "__route_list__"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func authOf(chain []string) []string {
+	var auth []string
+	for _, name := range chain {
+		for _, a := range authenticators {
+			if strings.Contains(name, a.function) {
+				auth = append(auth, a.auth)
+			}
+		}
+	}
+	if len(auth) == 0 {
+		return []string{"public"}
+	}
+	return auth
+}
+
+// permissionOf reports permission checks of hand-wired routes, whose
+// arguments the chain does not show
+func permissionOf(chain []string) string {
+	for _, name := range chain {
+		switch {
+		case strings.Contains(name, "(*PermissionChecker).RequireRole"):
+			return "(role)"
+		case strings.Contains(name, "(*PermissionChecker).Require"):
+			return "(permission)"
+		}
+	}
+	return ""
+}
+
+// shortName trims the module path and closure suffixes of a function name:
+// flex-service/internal/middleware.Tracing.func1 becomes middleware.Tracing
+func shortName(name string) string {
+	if slash := strings.LastIndex(name, "/"); slash >= 0 {
+		name = name[slash+1:]
+	}
+	return closureSuffix.ReplaceAllString(name, "")
+}
+
+func shortNames(names []string) []string {
+	short := make([]string, 0, len(names))
+	for _, name := range names {
+		short = append(short, shortName(name))
+	}
+	return short
+}
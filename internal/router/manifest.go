@@ -0,0 +1,191 @@
+package router
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"flex-service/internal/api_key"
+	"flex-service/internal/container"
+	"flex-service/internal/draft"
+	"flex-service/internal/message_template"
+	"flex-service/internal/middleware"
+	"flex-service/internal/rbac"
+	"flex-service/internal/report"
+	"flex-service/internal/user_auth"
+	"flex-service/pkg/auth"
+	"flex-service/pkg/export"
+	"flex-service/pkg/httpcache"
+	"flex-service/pkg/idempotency"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/routes"
+	"flex-service/pkg/session"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Manifests returns the route manifests of the built-in modules mounted under
+// /api/v1. Modules registered with container.Register add their own.
+func Manifests() []routes.Manifest {
+	return []routes.Manifest{
+		api_key.Manifest,
+		draft.Manifest,
+		export.Manifest,
+		message_template.Manifest,
+		rbac.Manifest,
+		report.Manifest,
+		user_auth.AdminManifest,
+	}
+}
+
+// NewRouteRegistry resolves the handler and middleware refs used in manifests:
+//
+//	auth                      bearer token (middleware.UserAuthenticate)
+//	api_key[:scope,...]       X-API-Key header (middleware.APIKeyAuthenticate)
+//	rate.ip:<limit>,<window>  and rate.user, rate.api_key, rate.endpoint
+//	session                   cookie session (session.Manager.Middleware)
+//	csrf                      CSRF token check, after session (session.CSRF)
+//	cache:<ttl>[,tag,...]     GET response caching with ETags, after the permission check
+//	cache.invalidate:<tags>   invalidates the tags after a successful mutation
+//	idempotent[:<ttl>][,required]  replays responses for retries with the same
+//	                          Idempotency-Key, after the permission check
+//
+// Every route also runs the rate limit policies matching its path
+// (rate.policy), after authentication so user, api_key and plan limits apply.
+func NewRouteRegistry(container *container.Container) *routes.Registry {
+	registry := routes.NewRegistry(container.Permissions.Require)
+
+	registry.Handler("api_key", container.APIKeyHandler)
+	registry.Handler("draft", container.DraftHandler)
+	registry.Handler("export", container.ExportHandler)
+	registry.Handler("message_template", container.MessageTemplateHandler)
+	registry.Handler("rbac", container.RBACHandler)
+	registry.Handler("report", container.ReportHandler)
+	registry.Handler("user_auth_admin", container.SessionAdminHandler)
+	for module, handler := range container.ModuleHandlers() {
+		registry.Handler(module, handler)
+	}
+
+	registry.Authenticator("auth", func(args ...string) (gin.HandlerFunc, error) {
+		if len(args) > 0 {
+			return nil, fmt.Errorf("takes no arguments")
+		}
+		return middleware.UserAuthenticate(container.UserAuthUsecase), nil
+	})
+	registry.Authenticator("api_key", func(scopes ...string) (gin.HandlerFunc, error) {
+		return middleware.APIKeyAuthenticate(container.APIKeyUsecase, scopes...), nil
+	})
+
+	registry.Middleware("session", func(args ...string) (gin.HandlerFunc, error) {
+		return container.Sessions.Middleware(), nil
+	})
+	registry.Middleware("csrf", func(args ...string) (gin.HandlerFunc, error) {
+		return session.CSRF(), nil
+	})
+
+	registry.Late("cache", func(args ...string) (gin.HandlerFunc, error) {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("expected <ttl>[,tag,...]")
+		}
+		ttl, err := time.ParseDuration(args[0])
+		if err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("invalid ttl %q", args[0])
+		}
+		return container.ResponseCache.Middleware(httpcache.Config{TTL: ttl, Tags: args[1:]}), nil
+	})
+	registry.Middleware("cache.invalidate", func(tags ...string) (gin.HandlerFunc, error) {
+		if len(tags) == 0 {
+			return nil, fmt.Errorf("expected at least one tag")
+		}
+		return container.ResponseCache.InvalidateOnSuccess(tags...), nil
+	})
+
+	registry.Late("idempotent", func(args ...string) (gin.HandlerFunc, error) {
+		config := idempotency.Config{}
+		for _, arg := range args {
+			if arg == "required" {
+				config.Required = true
+				continue
+			}
+			ttl, err := time.ParseDuration(arg)
+			if err != nil || ttl <= 0 {
+				return nil, fmt.Errorf("expected [<ttl>][,required], got %q", arg)
+			}
+			config.TTL = ttl
+		}
+		return container.Idempotency.Middleware(config), nil
+	})
+
+	registry.Attach("rate.policy", container.RateLimitPolicies.Middleware())
+
+	rateLimits := map[string]func(limit int, window time.Duration) gin.HandlerFunc{
+		"rate.ip": func(limit int, window time.Duration) gin.HandlerFunc {
+			return container.RateLimit.IPRateLimit(container.Cache, limit, window)
+		},
+		"rate.user": func(limit int, window time.Duration) gin.HandlerFunc {
+			return container.RateLimit.UserRateLimit(container.Cache, limit, window)
+		},
+		"rate.api_key": func(limit int, window time.Duration) gin.HandlerFunc {
+			return container.RateLimit.APIKeyRateLimit(container.Cache, limit, window)
+		},
+		"rate.endpoint": func(limit int, window time.Duration) gin.HandlerFunc {
+			return container.RateLimit.EndpointRateLimit(container.Cache, limit, window)
+		},
+	}
+	for name, build := range rateLimits {
+		build := build
+		registry.Middleware(name, func(args ...string) (gin.HandlerFunc, error) {
+			limit, window, err := parseRateLimit(args)
+			if err != nil {
+				return nil, err
+			}
+			return build(limit, window), nil
+		})
+	}
+
+	return registry
+}
+
+// parseRateLimit reads the "<limit>,<window>" arguments of a rate.* ref
+func parseRateLimit(args []string) (int, time.Duration, error) {
+	if len(args) != 2 {
+		return 0, 0, fmt.Errorf("expected <limit>,<window>")
+	}
+	limit, err := strconv.Atoi(args[0])
+	if err != nil || limit < 1 {
+		return 0, 0, fmt.Errorf("invalid limit %q", args[0])
+	}
+	window, err := time.ParseDuration(args[1])
+	if err != nil || window <= 0 {
+		return 0, 0, fmt.Errorf("invalid window %q", args[1])
+	}
+	return limit, window, nil
+}
+
+// auditPermissions warns about routes requiring a permission that no role
+// grants, since nobody without a direct grant can call them
+func auditPermissions(checker *auth.PermissionChecker, definitions []routes.Definition) {
+	for _, definition := range definitions {
+		if definition.Permission == "" || granted(checker, definition.Permission) {
+			continue
+		}
+		logger.Warn("Route requires a permission no role grants",
+			zap.String("method", definition.Method),
+			zap.String("path", definition.Path),
+			zap.String("permission", definition.Permission))
+	}
+}
+
+func granted(checker *auth.PermissionChecker, permission string) bool {
+	for _, role := range checker.Roles() {
+		for _, grant := range checker.RolePermissions(role.Name) {
+			base, _ := strings.CutSuffix(grant, auth.OwnSuffix)
+			if auth.Match(base, permission) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -5,12 +5,27 @@ import (
 
 	"flex-service/internal/container"
 	"flex-service/internal/middleware"
+	"flex-service/pkg/cors"
+	"flex-service/pkg/i18n"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/mtls"
 	"flex-service/pkg/response"
+	"flex-service/pkg/routes"
+	"flex-service/pkg/session"
+	"flex-service/pkg/storage"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 func SetupRouter(container *container.Container) *gin.Engine {
+	router, _ := setupRouter(container, nil)
+	return router
+}
+
+// setupRouter builds the router and returns the definitions of the manifest
+// routes. probe, when set, runs before every other middleware (see Inspect).
+func setupRouter(container *container.Container, probe gin.HandlerFunc) (*gin.Engine, []routes.Definition) {
 	// Set Gin mode based on environment
 	if container.Config.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -19,15 +34,46 @@ func SetupRouter(container *container.Container) *gin.Engine {
 	}
 
 	router := gin.New()
+	if err := middleware.TrustProxies(router, container.Config.Security); err != nil {
+		logger.Fatal("Failed to configure trusted proxies", zap.Error(err))
+	}
+	if probe != nil {
+		router.Use(probe)
+	}
+
+	// Route profiles decide which optional route groups are mounted
+	profileNames := container.Config.Server.RouteProfiles
+	if len(profileNames) == 0 {
+		profileNames = routes.DefaultProfiles(container.Config.Env)
+	}
+	profiles := routes.NewProfiles(profileNames...)
 
 	// Global middleware
-	router.Use(middleware.CORS())
+	router.Use(cors.Middleware(container.Config.CORS))
 	router.Use(middleware.Recovery())
+
+	// Kubernetes probes (/livez, /readyz, /healthz), mounted before the
+	// logging and rate limiting middleware so frequent probes skip both
+	container.Probes.Register(router)
+	router.Use(middleware.Tracing())
+	router.Use(middleware.ClientInfo())
 	router.Use(middleware.Logging())
-	router.Use(middleware.Helmet())
+	// 503 while artisan down is in effect; the probes above still answer
+	router.Use(container.Maintenance.Middleware())
+	// Audit log of successful mutations; also gives audit.Record the client IP
+	// and request ID
+	if container.Audit != nil {
+		router.Use(container.Audit.Middleware(container.Config.Audit.Methods...))
+	}
+	router.Use(middleware.Secure(container.Config.Security)...)
+	router.Use(i18n.Middleware())
+	router.Use(mtls.Middleware())
 
 	// Rate limiting middleware (only if Redis cache is available)
 	router.Use(container.RateLimit.IPRateLimit(container.Cache, 100, time.Minute))
+	// Declared policies (RATELIMIT_POLICIES_FILE, tb_rate_limit_policy); user,
+	// api_key and plan limits run again after each route's authentication
+	router.Use(container.RateLimitPolicies.Middleware())
 	router.Use(middleware.ErrorHandler())
 
 	// Health check endpoint
@@ -57,6 +103,25 @@ func SetupRouter(container *container.Container) *gin.Engine {
 		})
 	})
 
+	// expvar, pprof and cache statistics
+	if profiles.Enabled(routes.ProfileDebug) {
+		mountDebug(router, container.Cache)
+	}
+
+	// Public keys for verifying access tokens (RS256/EdDSA only)
+	if container.JWTKeys != nil {
+		router.GET("/.well-known/jwks.json", container.JWTKeys.JWKSHandler())
+	}
+
+	// Cookie sessions for browser clients; unsafe methods need the CSRF token
+	web := router.Group("/web", container.Sessions.Middleware(), session.CSRF())
+	web.GET("/csrf-token", session.CSRFTokenHandler)
+
+	// Files on the local storage disk (signed temporary URLs unless STORAGE_LOCAL_PUBLIC)
+	if local, ok := container.Storage.(*storage.Local); ok {
+		router.GET("/storage/*path", storage.ServeLocal(local))
+	}
+
 	// 404 handler
 	router.NoRoute(func(c *gin.Context) {
 		response.Error(c, 404, "NOT_FOUND", "Route not found", gin.H{
@@ -66,6 +131,7 @@ func SetupRouter(container *container.Container) *gin.Engine {
 	})
 
 	// API v1 routes
+	var registry *routes.Registry
 	v1 := router.Group("/api/v1")
 	{
 		userAuthRoutes := v1.Group("/user-auth")
@@ -76,16 +142,85 @@ func SetupRouter(container *container.Container) *gin.Engine {
 			userAuthRoutes.POST("/register", container.RateLimit.RegisterRateLimit(container.Cache, 15, 1*time.Hour), container.UserAuthHandler.Register)
 			userAuthRoutes.POST("/register-social", container.RateLimit.RegisterRateLimit(container.Cache, 15, 1*time.Hour), container.UserAuthHandler.RegisterWithSocialAccount)
 			userAuthRoutes.POST("/refresh", container.RateLimit.IPRateLimit(container.Cache, 10, 1*time.Minute), container.UserAuthHandler.RefreshToken)
+			userAuthRoutes.POST("/password/reset", container.RateLimit.IPRateLimit(container.Cache, 5, 1*time.Minute), container.UserAuthHandler.ResetPassword)
+			userAuthRoutes.POST("/unlock", container.RateLimit.IPRateLimit(container.Cache, 5, 1*time.Minute), container.UserAuthHandler.UnlockAccount)
+			userAuthRoutes.POST("/token/exchange", container.RateLimit.IPRateLimit(container.Cache, 30, 1*time.Minute), container.UserAuthHandler.ExchangeToken)
+
+			// Server-side OAuth login (google, facebook, apple, line)
+			userAuthRoutes.GET("/oauth/:provider", container.RateLimit.IPRateLimit(container.Cache, 20, 1*time.Minute), container.UserAuthHandler.OAuthRedirect)
+			userAuthRoutes.GET("/oauth/:provider/callback", container.RateLimit.IPRateLimit(container.Cache, 10, 1*time.Minute), container.UserAuthHandler.OAuthCallback)
+			userAuthRoutes.POST("/oauth/:provider/callback", container.RateLimit.IPRateLimit(container.Cache, 10, 1*time.Minute), container.UserAuthHandler.OAuthCallback)
+
+			// SAML 2.0 single sign-on (enabled with SAML_ENABLED)
+			userAuthRoutes.GET("/saml/metadata", container.UserAuthHandler.SAMLMetadata)
+			userAuthRoutes.GET("/saml/login", container.RateLimit.IPRateLimit(container.Cache, 20, 1*time.Minute), container.UserAuthHandler.SAMLLogin)
+			userAuthRoutes.POST("/saml/acs", container.RateLimit.IPRateLimit(container.Cache, 10, 1*time.Minute), container.UserAuthHandler.SAMLCallback)
+
+			// Second step of a password login when 2FA is enabled
+			userAuthRoutes.POST("/2fa/verify", container.RateLimit.IPRateLimit(container.Cache, 10, 1*time.Minute), container.UserAuthHandler.VerifyTwoFactor)
 
 			// Protected routes with user-based rate limiting
 			userAuthProtected := userAuthRoutes.Group("/")
-			userAuthProtected.Use(middleware.UserAuthenticate(container.UserAuthUsecase))
+			userAuthProtected.Use(middleware.UserAuthenticate(container.UserAuthUsecase), container.RateLimitPolicies.Middleware())
 			{
 				userAuthProtected.POST("/logout", container.RateLimit.UserRateLimit(container.Cache, 10, 1*time.Minute), container.UserAuthHandler.Logout)
 				userAuthProtected.GET("/me", container.RateLimit.UserRateLimit(container.Cache, 30, 1*time.Minute), container.UserAuthHandler.Me)
+
+				// Signed-in devices of the current user
+				userAuthProtected.GET("/sessions", container.RateLimit.UserRateLimit(container.Cache, 30, 1*time.Minute), container.UserAuthHandler.ListSessions)
+				userAuthProtected.DELETE("/sessions", container.RateLimit.UserRateLimit(container.Cache, 10, 1*time.Minute), container.UserAuthHandler.RevokeOtherSessions)
+				userAuthProtected.DELETE("/sessions/:id", container.RateLimit.UserRateLimit(container.Cache, 10, 1*time.Minute), container.UserAuthHandler.RevokeSession)
+
+				// Two-factor authentication (TOTP)
+				userAuthProtected.POST("/2fa/setup", container.RateLimit.UserRateLimit(container.Cache, 5, 1*time.Minute), container.UserAuthHandler.SetupTwoFactor)
+				userAuthProtected.POST("/2fa/enable", container.RateLimit.UserRateLimit(container.Cache, 5, 1*time.Minute), container.UserAuthHandler.EnableTwoFactor)
+				userAuthProtected.POST("/2fa/disable", container.RateLimit.UserRateLimit(container.Cache, 5, 1*time.Minute), container.UserAuthHandler.DisableTwoFactor)
+				userAuthProtected.POST("/2fa/recovery-codes", container.RateLimit.UserRateLimit(container.Cache, 5, 1*time.Minute), container.UserAuthHandler.RegenerateRecoveryCodes)
+			}
+		}
+
+		// Module routes declared in manifests (see Manifests), then those of
+		// the self-registering modules in internal/modules
+		registry = NewRouteRegistry(container).Profiles(profiles)
+		if err := registry.Load(v1, append(Manifests(), container.ModuleManifests()...)...); err != nil {
+			logger.Fatal("Failed to load route manifests", zap.Error(err))
+		}
+		auditPermissions(container.Permissions, registry.Definitions())
+
+		// A/B experiments
+		if profiles.Enabled(routes.ProfileExperimental) {
+			experimentRoutes := v1.Group("/experiments")
+			experimentRoutes.Use(middleware.UserAuthenticate(container.UserAuthUsecase), container.RateLimitPolicies.Middleware())
+			{
+				experimentRoutes.GET("/:key/variant", container.ExperimentHandler.Assign)
+				experimentRoutes.POST("/:key/convert", container.ExperimentHandler.Convert)
+				experimentRoutes.GET("/:key/results", container.ExperimentHandler.Results)
 			}
 		}
+
+		// Outgoing webhook endpoints and delivery logs
+		webhookRoutes := v1.Group("/webhooks")
+		webhookRoutes.Use(middleware.UserAuthenticate(container.UserAuthUsecase), container.RateLimitPolicies.Middleware())
+		{
+			webhookRoutes.GET("/endpoints", container.WebhookHandler.ListEndpoints)
+			webhookRoutes.POST("/endpoints", container.WebhookHandler.CreateEndpoint)
+			webhookRoutes.GET("/endpoints/:id", container.WebhookHandler.GetEndpoint)
+			webhookRoutes.PUT("/endpoints/:id", container.WebhookHandler.UpdateEndpoint)
+			webhookRoutes.DELETE("/endpoints/:id", container.WebhookHandler.DeleteEndpoint)
+			webhookRoutes.POST("/endpoints/:id/rotate-secret", container.WebhookHandler.RotateSecret)
+			webhookRoutes.GET("/endpoints/:id/deliveries", container.WebhookHandler.ListDeliveries)
+			webhookRoutes.GET("/deliveries/:id", container.WebhookHandler.GetDelivery)
+			webhookRoutes.POST("/deliveries/:id/redeliver", container.RateLimit.UserRateLimit(container.Cache, 10, 1*time.Minute), container.WebhookHandler.Redeliver)
+		}
+
+		logger.Info("Route profiles",
+			zap.Strings("active", profiles.Active()),
+			zap.Strings("inactive", profiles.Inactive()),
+			zap.Strings("skipped_modules", registry.Skipped()))
+		if container.Config.Env == "production" && profiles.Enabled(routes.ProfileDebug) {
+			logger.Warn("Debug routes are mounted in production; /debug/vars, /debug/pprof and /debug/cache are unauthenticated")
+		}
 	}
 
-	return router
+	return router, registry.Definitions()
 }
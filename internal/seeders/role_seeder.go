@@ -0,0 +1,79 @@
+package seeders
+
+import (
+	"flex-service/internal/entity"
+	"flex-service/pkg/logger"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RoleSeeder seeds the admin role and gives it to the test user
+type RoleSeeder struct{}
+
+// Run executes the seeder
+func (s *RoleSeeder) Run(db *gorm.DB) error {
+	logger.Info("Running RoleSeeder...")
+
+	var count int64
+	if err := db.Raw("SELECT COUNT(*) FROM tb_role").Scan(&count).Error; err != nil {
+		return err
+	}
+
+	if count > 0 {
+		logger.Info("tb_role already exist, skipping RoleSeeder")
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		permissions := []entity.Permission{
+			{Name: "*", Description: "Every permission"},
+			{Name: "rbac.manage", Description: "Manage roles, permissions and role assignments"},
+			{Name: "user_auth.sessions.manage", Description: "Inspect and revoke user sessions, force password resets"},
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&permissions).Error; err != nil {
+			return err
+		}
+
+		var all entity.Permission
+		if err := tx.Where("name = ?", "*").First(&all).Error; err != nil {
+			return err
+		}
+
+		admin := entity.Role{Name: "admin", Description: "Full access", Permissions: []entity.Permission{all}}
+		if err := tx.Create(&admin).Error; err != nil {
+			return err
+		}
+
+		var user entity.User
+		err := tx.Where("username = ?", "test_user").First(&user).Error
+		if err == gorm.ErrRecordNotFound {
+			logger.Info("RoleSeeder completed successfully")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&entity.RoleUser{RoleID: admin.ID, UserID: user.ID}).Error; err != nil {
+			return err
+		}
+
+		logger.Info("RoleSeeder completed successfully")
+		return nil
+	})
+}
+
+// Name returns seeder name
+func (s *RoleSeeder) Name() string {
+	return "RoleSeeder"
+}
+
+// Dependencies returns list of seeders that must run before this seeder
+func (s *RoleSeeder) Dependencies() []string {
+	return []string{"UserSeeder"}
+}
+
+// Auto-register seeder
+func init() {
+	Register(&RoleSeeder{})
+}
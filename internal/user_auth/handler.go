@@ -3,13 +3,16 @@ package user_auth
 import (
 	"flex-service/internal/entity"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"flex-service/pkg/errors"
+	"flex-service/pkg/request"
+	"flex-service/pkg/requestctx"
 	"flex-service/pkg/response"
-	"flex-service/pkg/validator"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -24,128 +27,237 @@ func NewUserAuthHandler(usecase UserAuthUsecase) *UserAuthHandler {
 }
 
 func (h *UserAuthHandler) Register(c *gin.Context) {
-	var req entity.CreateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request format", err)
+	req, err := request.Bind[entity.CreateUserRequest](c)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	if errors := validator.ValidateStruct(&req); errors != nil {
-		response.ValidationError(c, "Validation failed", errors)
+	result, err := h.usecase.Register(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	result, err := h.usecase.Register(c.Request.Context(), &req)
+	response.Success(c, http.StatusCreated, "User registered successfully", result)
+}
+
+func (h *UserAuthHandler) RegisterWithSocialAccount(c *gin.Context) {
+	req, err := request.Bind[RegisterWithSocialAccountRequest](c)
 	if err != nil {
-		if appErr, ok := err.(*errors.AppError); ok {
-			response.Error(c, appErr.StatusCode, appErr.Code, appErr.Message, appErr.Details)
-		} else {
-			response.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error", nil)
-		}
+		c.Error(err)
 		return
 	}
 
-	response.Success(c, http.StatusCreated, "User registered successfully", result)
+	result, err := h.usecase.RegisterWithSocialAccount(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Register with social account successful", result)
 }
 
-func (h *UserAuthHandler) RegisterWithSocialAccount(c *gin.Context) {
-	var req RegisterWithSocialAccountRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request format", err)
+func (h *UserAuthHandler) Login(c *gin.Context) {
+	req, err := request.Bind[LoginRequest](c)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	if errors := validator.ValidateStruct(&req); errors != nil {
-		response.ValidationError(c, "Validation failed", errors)
+	result, err := h.usecase.Login(c.Request.Context(), req)
+	if err != nil {
+		setRetryAfter(c, err)
+		c.Error(err)
 		return
 	}
 
-	result, err := h.usecase.RegisterWithSocialAccount(c.Request.Context(), &req)
+	if result.TwoFactorRequired {
+		response.Success(c, http.StatusOK, "Two-factor authentication required", result)
+		return
+	}
+	response.Success(c, http.StatusOK, "Login successful", result)
+}
+
+func (h *UserAuthHandler) LoginWithSocialAccount(c *gin.Context) {
+	req, err := request.Bind[LoginWithSocialAccountRequest](c)
 	if err != nil {
-		if appErr, ok := err.(*errors.AppError); ok {
-			response.Error(c, appErr.StatusCode, appErr.Code, appErr.Message, appErr.Details)
-		} else {
-			response.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error", nil)
-		}
+		c.Error(err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, "Register with social account successful", result)
+	result, err := h.usecase.LoginWithSocialAccount(c.Request.Context(), req)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Login successful", result)
 }
 
-func (h *UserAuthHandler) Login(c *gin.Context) {
-	var req LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request format", err)
+// OAuthRedirect sends the browser to the provider's login page. With
+// ?redirect=false it returns the URL as JSON instead, for SPAs and mobile apps.
+func (h *UserAuthHandler) OAuthRedirect(c *gin.Context) {
+	result, err := h.usecase.OAuthRedirect(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	if errors := validator.ValidateStruct(&req); errors != nil {
-		response.ValidationError(c, "Validation failed", errors)
+	if c.Query("redirect") == "false" {
+		response.Success(c, http.StatusOK, "OAuth redirect created", result)
 		return
 	}
+	c.Redirect(http.StatusFound, result.URL)
+}
 
-	result, err := h.usecase.Login(c.Request.Context(), &req)
+// OAuthCallback receives the provider redirect: a GET with a query string, or a
+// form POST from Apple. SPAs may also POST the code and state as JSON.
+func (h *UserAuthHandler) OAuthCallback(c *gin.Context) {
+	req := &OAuthCallbackRequest{}
+	if err := c.ShouldBindUri(req); err != nil {
+		c.Error(errors.WrapBadRequest(err, "Invalid request"))
+		return
+	}
+	if err := c.ShouldBind(req); err != nil {
+		c.Error(errors.WrapBadRequest(err, "Invalid request"))
+		return
+	}
+	if err := request.Validate(c, req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	result, err := h.usecase.OAuthCallback(c.Request.Context(), req)
 	if err != nil {
-		if appErr, ok := err.(*errors.AppError); ok {
-			response.Error(c, appErr.StatusCode, appErr.Code, appErr.Message, appErr.Details)
-		} else {
-			response.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error", nil)
-		}
+		c.Error(err)
 		return
 	}
 
 	response.Success(c, http.StatusOK, "Login successful", result)
 }
 
-func (h *UserAuthHandler) LoginWithSocialAccount(c *gin.Context) {
-	var req LoginWithSocialAccountRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request format", err)
+// SAMLMetadata serves the SP metadata XML to register with the IdP
+func (h *UserAuthHandler) SAMLMetadata(c *gin.Context) {
+	metadata, err := h.usecase.SAMLMetadata(c.Request.Context())
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	if errors := validator.ValidateStruct(&req); errors != nil {
-		response.ValidationError(c, "Validation failed", errors)
+	c.Data(http.StatusOK, "application/samlmetadata+xml", metadata)
+}
+
+// SAMLLogin sends the browser to the IdP (SP-initiated login). With
+// ?redirect=false it returns the URL as JSON instead.
+func (h *UserAuthHandler) SAMLLogin(c *gin.Context) {
+	result, err := h.usecase.SAMLLoginURL(c.Request.Context())
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	result, err := h.usecase.LoginWithSocialAccount(c.Request.Context(), &req)
-	if err != nil && err != gorm.ErrRecordNotFound {
-		response.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error", nil)
+	if c.Query("redirect") == "false" {
+		response.Success(c, http.StatusOK, "SAML redirect created", result)
+		return
+	}
+	c.Redirect(http.StatusFound, result.URL)
+}
+
+// SAMLCallback is the Assertion Consumer Service: the IdP posts the SAMLResponse
+// form here for both SP- and IdP-initiated logins
+func (h *UserAuthHandler) SAMLCallback(c *gin.Context) {
+	req := &SAMLCallbackRequest{}
+	if err := c.ShouldBind(req); err != nil {
+		c.Error(errors.WrapBadRequest(err, "Invalid request"))
+		return
+	}
+	if err := request.Validate(c, req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	result, err := h.usecase.SAMLCallback(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
 	response.Success(c, http.StatusOK, "Login successful", result)
 }
 
-func (h *UserAuthHandler) RefreshToken(c *gin.Context) {
-	var req RefreshTokenRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request format", err)
+// ExchangeToken issues a scoped token (RFC 8693 token exchange). The body may be
+// form-encoded as in the RFC, or JSON.
+func (h *UserAuthHandler) ExchangeToken(c *gin.Context) {
+	req := &TokenExchangeRequest{}
+	if err := c.ShouldBind(req); err != nil {
+		c.Error(errors.WrapBadRequest(err, "Invalid request"))
+		return
+	}
+	if err := request.Validate(c, req); err != nil {
+		c.Error(err)
 		return
 	}
 
-	if errors := validator.ValidateStruct(&req); errors != nil {
-		response.ValidationError(c, "Validation failed", errors)
+	result, err := h.usecase.ExchangeToken(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	result, err := h.usecase.RefreshToken(c.Request.Context(), &req)
+	response.Success(c, http.StatusOK, "Token issued successfully", result)
+}
+
+func (h *UserAuthHandler) RefreshToken(c *gin.Context) {
+	req, err := request.Bind[RefreshTokenRequest](c)
 	if err != nil {
-		if appErr, ok := err.(*errors.AppError); ok {
-			response.Error(c, appErr.StatusCode, appErr.Code, appErr.Message, appErr.Details)
-		} else {
-			response.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error", nil)
-		}
+		c.Error(err)
+		return
+	}
+
+	result, err := h.usecase.RefreshToken(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
 	response.Success(c, http.StatusOK, "Token refreshed successfully", result)
 }
 
+// ResetPassword sets a new password with a reset token
+func (h *UserAuthHandler) ResetPassword(c *gin.Context) {
+	req, err := request.Bind[ResetPasswordRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.usecase.ResetPassword(c.Request.Context(), req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Password reset successfully", nil)
+}
+
+// UnlockAccount lifts a lockout with the token from the lockout email
+func (h *UserAuthHandler) UnlockAccount(c *gin.Context) {
+	req, err := request.Bind[UnlockAccountRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.usecase.UnlockAccount(c.Request.Context(), req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Account unlocked", nil)
+}
+
 func (h *UserAuthHandler) Logout(c *gin.Context) {
-	userID, exists := c.Get("user_id")
+	userID, exists := requestctx.CurrentUserID(c)
 	if !exists {
 		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
 		return
@@ -157,13 +269,9 @@ func (h *UserAuthHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	err = h.usecase.Logout(c.Request.Context(), token, userID.(int))
+	err = h.usecase.Logout(c.Request.Context(), token, userID)
 	if err != nil {
-		if appErr, ok := err.(*errors.AppError); ok {
-			response.Error(c, appErr.StatusCode, appErr.Code, appErr.Message, appErr.Details)
-		} else {
-			response.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error", nil)
-		}
+		c.Error(err)
 		return
 	}
 
@@ -171,22 +279,191 @@ func (h *UserAuthHandler) Logout(c *gin.Context) {
 }
 
 func (h *UserAuthHandler) Me(c *gin.Context) {
-	userID, exists := c.Get("user_id")
+	userID, exists := requestctx.CurrentUserID(c)
 	if !exists {
 		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
 		return
 	}
 
-	user, err := h.usecase.GetUserProfile(c.Request.Context(), userID.(int))
-
+	user, err := h.usecase.GetUserProfile(c.Request.Context(), userID)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error", nil)
+		c.Error(err)
 		return
 	}
 
 	response.Success(c, http.StatusOK, "User information retrieved successfully", user)
 }
 
+// ListSessions lists the signed-in devices of the current user
+func (h *UserAuthHandler) ListSessions(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	token, err := ExtractTokenFromHeader(c)
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "INVALID_TOKEN", err.Error(), nil)
+		return
+	}
+
+	sessions, err := h.usecase.ListSessions(c.Request.Context(), userID, token)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Sessions retrieved successfully", gin.H{"sessions": sessions})
+}
+
+// RevokeSession signs one device of the current user out
+func (h *UserAuthHandler) RevokeSession(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	sessionUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(errors.BadRequest("Invalid session ID"))
+		return
+	}
+
+	if err := h.usecase.RevokeSession(c.Request.Context(), userID, sessionUUID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Session revoked successfully", nil)
+}
+
+// RevokeOtherSessions signs the current user out of every other device
+func (h *UserAuthHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	token, err := ExtractTokenFromHeader(c)
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "INVALID_TOKEN", err.Error(), nil)
+		return
+	}
+
+	result, err := h.usecase.RevokeOtherSessions(c.Request.Context(), userID, token)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Other sessions revoked successfully", result)
+}
+
+// SetupTwoFactor creates a TOTP secret and returns it with the QR provisioning URI
+func (h *UserAuthHandler) SetupTwoFactor(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	result, err := h.usecase.SetupTwoFactor(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Scan the QR code and confirm with a code to enable two-factor authentication", result)
+}
+
+// EnableTwoFactor confirms setup with a TOTP code and returns the recovery codes
+func (h *UserAuthHandler) EnableTwoFactor(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	req, err := request.Bind[TwoFactorCodeRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	result, err := h.usecase.EnableTwoFactor(c.Request.Context(), userID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Two-factor authentication enabled", result)
+}
+
+// DisableTwoFactor turns 2FA off with a TOTP or recovery code
+func (h *UserAuthHandler) DisableTwoFactor(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	req, err := request.Bind[TwoFactorCodeRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.usecase.DisableTwoFactor(c.Request.Context(), userID, req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Two-factor authentication disabled", nil)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes with new ones
+func (h *UserAuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	req, err := request.Bind[TwoFactorCodeRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	result, err := h.usecase.RegenerateRecoveryCodes(c.Request.Context(), userID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Recovery codes regenerated", result)
+}
+
+// VerifyTwoFactor completes a login that returned two_factor_required
+func (h *UserAuthHandler) VerifyTwoFactor(c *gin.Context) {
+	req, err := request.Bind[TwoFactorVerifyRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	result, err := h.usecase.VerifyTwoFactor(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Login successful", result)
+}
+
 func ExtractTokenFromHeader(c *gin.Context) (string, error) {
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
@@ -200,3 +477,98 @@ func ExtractTokenFromHeader(c *gin.Context) (string, error) {
 
 	return parts[1], nil
 }
+
+// SessionAdminHandler serves the admin routes in AdminManifest
+type SessionAdminHandler struct {
+	usecase SessionAdminUsecase
+}
+
+func NewSessionAdminHandler(usecase SessionAdminUsecase) *SessionAdminHandler {
+	return &SessionAdminHandler{
+		usecase: usecase,
+	}
+}
+
+// ListSessions lists the user's active sessions, or every issued token with ?status=all
+func (h *SessionAdminHandler) ListSessions(c *gin.Context) {
+	userUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(errors.BadRequest("Invalid user ID"))
+		return
+	}
+
+	sessions, err := h.usecase.ListSessions(c.Request.Context(), userUUID, c.Query("status") == "all")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Sessions retrieved successfully", gin.H{"sessions": sessions})
+}
+
+func (h *SessionAdminHandler) RevokeSessions(c *gin.Context) {
+	userUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(errors.BadRequest("Invalid user ID"))
+		return
+	}
+
+	req, err := request.BindJSON[RevokeSessionsRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	result, err := h.usecase.RevokeSessions(c.Request.Context(), userUUID, req.Sessions)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Sessions revoked successfully", result)
+}
+
+func (h *SessionAdminHandler) RevokeAllSessions(c *gin.Context) {
+	userUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(errors.BadRequest("Invalid user ID"))
+		return
+	}
+
+	result, err := h.usecase.RevokeAllSessions(c.Request.Context(), userUUID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Sessions revoked successfully", result)
+}
+
+func (h *SessionAdminHandler) ForcePasswordReset(c *gin.Context) {
+	userUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(errors.BadRequest("Invalid user ID"))
+		return
+	}
+
+	result, err := h.usecase.ForcePasswordReset(c.Request.Context(), userUUID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Password reset required", result)
+}
+
+// setRetryAfter sends Retry-After with the seconds left of a lockout
+func setRetryAfter(c *gin.Context, err error) {
+	appErr, ok := errors.AsAppError(err)
+	if !ok || appErr.Code != errors.ErrAccountLocked {
+		return
+	}
+	if details, ok := appErr.Details.(map[string]interface{}); ok {
+		if seconds, ok := details["retry_after"].(int); ok {
+			c.Header("Retry-After", strconv.Itoa(seconds))
+		}
+	}
+}
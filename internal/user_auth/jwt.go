@@ -1,42 +1,169 @@
 package user_auth
 
 import (
+	"flex-service/pkg/auth"
 	"flex-service/pkg/utils"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type UserJWT struct {
-	secret          []byte
-	accessTokenTTL  time.Duration
-	refreshTokenTTL time.Duration
-	issuer          string
+	secret            []byte
+	keys              *auth.KeySet
+	accessTokenTTL    time.Duration
+	refreshTokenTTL   time.Duration
+	scopedTokenTTL    time.Duration
+	scopedTokenMaxTTL time.Duration
+	issuer            string
 }
 
+// Scoped token lifetimes used unless WithScopedTokenTTL overrides them
+const (
+	DefaultScopedTokenTTL    = 5 * time.Minute
+	DefaultScopedTokenMaxTTL = time.Hour
+)
+
 type TokenType string
 
 const (
 	TokenTypeAccess  TokenType = "access"
 	TokenTypeRefresh TokenType = "refresh"
+	// TokenTypeScoped is a short-lived token derived from a session that only
+	// carries the permissions listed in its scope
+	TokenTypeScoped TokenType = "scoped"
+	// TokenTypeTwoFactor is the step-up token returned by a password login that
+	// still needs a TOTP or recovery code
+	TokenTypeTwoFactor TokenType = "two_factor"
 )
 
+// TwoFactorTokenTTL is how long a user has to enter the 2FA code after the password
+const TwoFactorTokenTTL = 5 * time.Minute
+
 type UserClaims struct {
 	UUID      string    `json:"uuid"`
 	Email     string    `json:"email"`
 	Type      string    `json:"type"`
 	TokenType TokenType `json:"token_type"`
+	Scope     string    `json:"scope,omitempty"` // Space-separated permissions of a scoped token
+	SessionID string    `json:"sid,omitempty"`   // Access JTI of the session a scoped token came from
 	jwt.RegisteredClaims
 }
 
+// Scopes returns the permissions of a scoped token
+func (c *UserClaims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
 func NewUserJWT(secret string, accessTTL, refreshTTL time.Duration, issuer string) *UserJWT {
 	return &UserJWT{
-		secret:          []byte(secret),
-		accessTokenTTL:  accessTTL,
-		refreshTokenTTL: refreshTTL,
-		issuer:          issuer,
+		secret:            []byte(secret),
+		accessTokenTTL:    accessTTL,
+		refreshTokenTTL:   refreshTTL,
+		scopedTokenTTL:    DefaultScopedTokenTTL,
+		scopedTokenMaxTTL: DefaultScopedTokenMaxTTL,
+		issuer:            issuer,
+	}
+}
+
+// WithScopedTokenTTL sets the default and maximum lifetime of scoped tokens
+func (j *UserJWT) WithScopedTokenTTL(ttl, maxTTL time.Duration) *UserJWT {
+	if ttl > 0 {
+		j.scopedTokenTTL = ttl
+	}
+	if maxTTL > 0 {
+		j.scopedTokenMaxTTL = maxTTL
+	}
+	return j
+}
+
+// WithKeySet signs tokens with the active key of keys (RS256 or EdDSA, kid
+// header) instead of the shared HS256 secret. Only tokens from the key set are
+// accepted afterwards, so existing HS256 sessions end.
+func (j *UserJWT) WithKeySet(keys *auth.KeySet) *UserJWT {
+	j.keys = keys
+	return j
+}
+
+// sign signs claims with the key set when configured, or else the HS256 secret
+func (j *UserJWT) sign(claims jwt.Claims) (string, error) {
+	if j.keys != nil {
+		return j.keys.Sign(claims)
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secret)
+}
+
+// keyfunc returns the verification key for a token signed by sign
+func (j *UserJWT) keyfunc(token *jwt.Token) (interface{}, error) {
+	if j.keys != nil {
+		return j.keys.Keyfunc(token)
+	}
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return j.secret, nil
+}
+
+// GenerateScopedToken creates a scoped token for the session sessionID. ttl is
+// capped at the maximum scoped token lifetime; 0 uses the default.
+func (j *UserJWT) GenerateScopedToken(userUUID, email, sessionID string, scopes []string, audience string, ttl time.Duration) (string, time.Duration, error) {
+	if ttl <= 0 {
+		ttl = j.scopedTokenTTL
+	}
+	if ttl > j.scopedTokenMaxTTL {
+		ttl = j.scopedTokenMaxTTL
+	}
+
+	now := time.Now()
+	claims := UserClaims{
+		UUID:      userUUID,
+		Email:     email,
+		Type:      "user",
+		TokenType: TokenTypeScoped,
+		Scope:     strings.Join(scopes, " "),
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        utils.GenerateUUID().String(),
+			Subject:   userUUID,
+			Issuer:    j.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	if audience != "" {
+		claims.Audience = jwt.ClaimStrings{audience}
+	}
+
+	tokenString, err := j.sign(claims)
+	if err != nil {
+		return "", 0, err
 	}
+	return tokenString, ttl, nil
+}
+
+// GenerateTwoFactorToken creates the step-up token that VerifyTwoFactor trades
+// for a session
+func (j *UserJWT) GenerateTwoFactorToken(userUUID, email string) (string, error) {
+	now := time.Now()
+	claims := UserClaims{
+		UUID:      userUUID,
+		Email:     email,
+		Type:      "user",
+		TokenType: TokenTypeTwoFactor,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        utils.GenerateUUID().String(),
+			Subject:   userUUID,
+			Issuer:    j.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(TwoFactorTokenTTL)),
+		},
+	}
+
+	return j.sign(claims)
 }
 
 func (j *UserJWT) GenerateUserToken(userUUID, email string, tokenType TokenType, jti string) (string, string, error) {
@@ -65,9 +192,7 @@ func (j *UserJWT) GenerateUserToken(userUUID, email string, tokenType TokenType,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	tokenString, err := token.SignedString(j.secret)
+	tokenString, err := j.sign(claims)
 	if err != nil {
 		return "", "", err
 	}
@@ -76,12 +201,7 @@ func (j *UserJWT) GenerateUserToken(userUUID, email string, tokenType TokenType,
 }
 
 func (j *UserJWT) ValidateUserToken(tokenString string) (*UserClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return j.secret, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, j.keyfunc)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -0,0 +1,369 @@
+package user_auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"expvar"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/email"
+	"flex-service/pkg/errors"
+	"flex-service/pkg/event"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/requestctx"
+
+	"go.uber.org/zap"
+)
+
+// Security events published on the event bus with a SecurityEvent payload
+const (
+	EventLoginFailed     = "auth.login.failed"
+	EventAccountLocked   = "auth.account.locked"
+	EventAccountUnlocked = "auth.account.unlocked"
+	EventSuspiciousLogin = "auth.login.suspicious"
+)
+
+// Reasons of a suspicious login
+const (
+	SuspiciousAfterFailures = "after_failures" // the password was wrong from the same IP before
+	SuspiciousNewIP         = "new_ip"         // no earlier session came from the IP
+)
+
+// UnlockTokenTTL is how long the link in a lockout email is valid
+const UnlockTokenTTL = 24 * time.Hour
+
+// lockoutStreakTTL is how long after a lockout ends the next one still doubles
+const lockoutStreakTTL = 24 * time.Hour
+
+// securityMetrics counts the security events by name, exposed at /debug/vars
+// as auth_security_events_total
+var securityMetrics = expvar.NewMap("auth_security_events_total")
+
+// LockoutPolicy sets when failed password logins lock a user out. Failures are
+// counted per user and IP, so an attacker can't lock the owner out everywhere.
+type LockoutPolicy struct {
+	MaxAttempts int           // Failed logins within Window before a lockout
+	Window      time.Duration // How long a failed login counts
+	Duration    time.Duration // First lockout; each further one doubles it
+	MaxDuration time.Duration // Longest lockout
+	UnlockURL   string        // Page the unlock email links to, with ?token= appended
+}
+
+// duration is the length of the lockouts-th lockout in a row
+func (p LockoutPolicy) duration(lockouts int64) time.Duration {
+	d := p.Duration
+	for i := int64(1); i < lockouts && d < p.MaxDuration; i++ {
+		d *= 2
+	}
+	if p.MaxDuration > 0 && d > p.MaxDuration {
+		d = p.MaxDuration
+	}
+	return d
+}
+
+// SecurityEvent describes a login event of a user
+type SecurityEvent struct {
+	UserID      int        `json:"user_id"`
+	UserUUID    string     `json:"user_uuid"`
+	IP          string     `json:"ip"`
+	UserAgent   string     `json:"user_agent,omitempty"`
+	Attempts    int64      `json:"attempts,omitempty"`     // failed logins in the window
+	LockedUntil *time.Time `json:"locked_until,omitempty"` // auth.account.locked only
+	Reason      string     `json:"reason,omitempty"`       // auth.login.suspicious only
+}
+
+// lockout tracks failed password logins in the usecase's cache
+type lockout struct {
+	policy LockoutPolicy
+	events event.Bus     // nil publishes nothing
+	mailer *email.Mailer // nil sends no unlock emails
+}
+
+// WithLockout enables failed-login tracking and temporary lockouts, which
+// need the usecase's cache. Security events are published on events and
+// locked out users are emailed an unlock link through mailer; either may be nil.
+func WithLockout(policy LockoutPolicy, events event.Bus, mailer *email.Mailer) UsecaseOption {
+	return func(u *userAuthUsecase) {
+		u.lockout = &lockout{policy: policy, events: events, mailer: mailer}
+	}
+}
+
+// unlockTarget is what an unlock token lifts
+type unlockTarget struct {
+	UserID int    `json:"user_id"`
+	IP     string `json:"ip"`
+}
+
+// checkLockout returns AccountLocked while the user is locked out from the
+// request's IP. It runs before the password is checked, so a locked account
+// doesn't tell whether a guess was right.
+func (u *userAuthUsecase) checkLockout(ctx context.Context, user *entity.User) error {
+	if u.lockout == nil || u.cache == nil {
+		return nil
+	}
+	ttl, err := u.cache.TTL(ctx, lockedKey(user.ID, loginClient(ctx).IP))
+	if err != nil || ttl <= 0 {
+		return nil
+	}
+	return errors.AccountLocked(ttl)
+}
+
+// loginFailed counts a wrong password and locks the user out from the
+// request's IP once the policy's attempts are used up. It returns the error
+// for the response.
+func (u *userAuthUsecase) loginFailed(ctx context.Context, user *entity.User) error {
+	if u.lockout == nil || u.cache == nil {
+		return errors.InvalidCredentials()
+	}
+
+	client := loginClient(ctx)
+	key := failedLoginsKey(user.ID, client.IP)
+	attempts, err := u.cache.Incr(ctx, key)
+	if err != nil {
+		logger.Warn("Failed to count failed login", zap.Int("user_id", user.ID), zap.Error(err))
+		return errors.InvalidCredentials()
+	}
+	if attempts == 1 {
+		_ = u.cache.Expire(ctx, key, u.lockout.policy.Window)
+	}
+
+	u.publishSecurityEvent(ctx, EventLoginFailed, securityEvent(user, client, attempts))
+	if attempts < int64(u.lockout.policy.MaxAttempts) {
+		return errors.InvalidCredentials()
+	}
+	return u.lock(ctx, user, client, attempts)
+}
+
+// lock locks the user out from the client's IP, doubling the lockout for
+// each one in a row
+func (u *userAuthUsecase) lock(ctx context.Context, user *entity.User, client requestctx.Client, attempts int64) error {
+	streakKey := lockoutsKey(user.ID, client.IP)
+	lockouts, err := u.cache.Incr(ctx, streakKey)
+	if err != nil {
+		lockouts = 1
+	}
+	duration := u.lockout.policy.duration(lockouts)
+	_ = u.cache.Expire(ctx, streakKey, duration+lockoutStreakTTL)
+
+	if err := u.cache.Set(ctx, lockedKey(user.ID, client.IP), "locked", duration); err != nil {
+		logger.Warn("Failed to lock account", zap.Int("user_id", user.ID), zap.Error(err))
+		return errors.InvalidCredentials()
+	}
+	_ = u.cache.Del(ctx, failedLoginsKey(user.ID, client.IP))
+
+	until := time.Now().Add(duration)
+	u.sendUnlockEmail(ctx, user, client, attempts, until)
+
+	payload := securityEvent(user, client, attempts)
+	payload.LockedUntil = &until
+	u.publishSecurityEvent(ctx, EventAccountLocked, payload)
+	recordAudit(ctx, "user.locked", user, map[string]interface{}{
+		"ip":       client.IP,
+		"attempts": attempts,
+		"duration": duration.String(),
+	})
+	logger.Warn("Account locked after failed logins",
+		zap.String("user_id", user.UUID.String()),
+		zap.String("ip", client.IP),
+		zap.Int64("lockouts", lockouts),
+		zap.Duration("duration", duration))
+
+	return errors.AccountLocked(duration)
+}
+
+// loginSucceeded clears the failed logins of the request's IP once the
+// password was right, and reports the login as suspicious when it follows
+// failures or comes from an IP no earlier session came from
+func (u *userAuthUsecase) loginSucceeded(ctx context.Context, user *entity.User) {
+	if u.lockout == nil || u.cache == nil {
+		return
+	}
+
+	client := loginClient(ctx)
+	var failures int64
+	if value, err := u.cache.Get(ctx, failedLoginsKey(user.ID, client.IP)); err == nil {
+		failures, _ = strconv.ParseInt(value, 10, 64)
+	}
+	_ = u.cache.Del(ctx, failedLoginsKey(user.ID, client.IP), lockoutsKey(user.ID, client.IP))
+
+	var reason string
+	switch {
+	case failures > 0:
+		reason = SuspiciousAfterFailures
+	case client.IP != "":
+		history, err := u.repo.GetLoginHistory(ctx, user.ID, client.IP)
+		if err != nil {
+			logger.Warn("Failed to get login history", zap.Int("user_id", user.ID), zap.Error(err))
+		} else if history.Sessions > 0 && history.FromIP == 0 {
+			reason = SuspiciousNewIP
+		}
+	}
+	if reason == "" {
+		return
+	}
+
+	payload := securityEvent(user, client, failures)
+	payload.Reason = reason
+	u.publishSecurityEvent(ctx, EventSuspiciousLogin, payload)
+	logger.Info("Suspicious login",
+		zap.String("user_id", user.UUID.String()),
+		zap.String("ip", client.IP),
+		zap.String("reason", reason))
+}
+
+// UnlockAccount lifts the lockout an unlock email was sent for. The token is
+// single-use.
+func (u *userAuthUsecase) UnlockAccount(ctx context.Context, req *UnlockAccountRequest) error {
+	if u.lockout == nil || u.cache == nil {
+		return errors.NotFound("Account lockout is not enabled")
+	}
+
+	key := unlockTokenKey(req.Token)
+	var target unlockTarget
+	if err := u.cache.GetJSON(ctx, key, &target); err != nil {
+		return errors.BadRequest("Invalid or expired unlock token")
+	}
+	_ = u.cache.Del(ctx, key)
+
+	user, err := u.repo.GetUserByID(ctx, target.UserID)
+	if err != nil {
+		return err
+	}
+	if err := u.cache.Del(ctx,
+		lockedKey(user.ID, target.IP),
+		failedLoginsKey(user.ID, target.IP),
+		lockoutsKey(user.ID, target.IP),
+	); err != nil {
+		return errors.WrapInternal(err, "failed to unlock account")
+	}
+
+	u.publishSecurityEvent(ctx, EventAccountUnlocked, securityEvent(user, requestctx.Client{IP: target.IP}, 0))
+	recordAudit(ctx, "user.unlocked", user, map[string]interface{}{"ip": target.IP})
+	logger.Info("Account unlocked", zap.String("user_id", user.UUID.String()), zap.String("ip", target.IP))
+	return nil
+}
+
+// sendUnlockEmail emails the user a link that lifts the lockout. The email is
+// sent in the background so the login response doesn't wait for SMTP.
+func (u *userAuthUsecase) sendUnlockEmail(ctx context.Context, user *entity.User, client requestctx.Client, attempts int64, until time.Time) {
+	if u.lockout.mailer == nil || user.Email == nil || *user.Email == "" {
+		return
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		logger.Error("Failed to generate unlock token", zap.Error(err))
+		return
+	}
+	target := unlockTarget{UserID: user.ID, IP: client.IP}
+	if err := u.cache.SetJSON(ctx, unlockTokenKey(token), target, UnlockTokenTTL); err != nil {
+		logger.Error("Failed to store unlock token", zap.Error(err))
+		return
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hi %s,\n\n", user.FirstName)
+	fmt.Fprintf(&body, "After %d failed login attempts from %s, signing in to your account from there is locked until %s.\n\n",
+		attempts, displayIP(client.IP), until.UTC().Format("2006-01-02 15:04 MST"))
+	if link := unlockLink(u.lockout.policy.UnlockURL, token); link != "" {
+		fmt.Fprintf(&body, "If it was you, unlock your account now:\n%s\n\n", link)
+	} else {
+		fmt.Fprintf(&body, "If it was you, unlock your account now with this code:\n%s\n\n", token)
+	}
+	body.WriteString("If it wasn't, someone may be guessing your password. Consider changing it.\n")
+
+	msg := email.NewMessage(*user.Email).
+		SetSubject("Your account was locked after failed login attempts").
+		SetText(body.String())
+	go func() {
+		if err := u.lockout.mailer.Send(context.WithoutCancel(ctx), email.Raw(msg)); err != nil {
+			logger.Error("Failed to send unlock email", zap.String("user_id", user.UUID.String()), zap.Error(err))
+		}
+	}()
+}
+
+// publishSecurityEvent counts the event and publishes it in the background
+func (u *userAuthUsecase) publishSecurityEvent(ctx context.Context, name string, payload SecurityEvent) {
+	securityMetrics.Add(name, 1)
+	if u.lockout.events != nil {
+		u.lockout.events.PublishAsync(ctx, name, payload)
+	}
+}
+
+func securityEvent(user *entity.User, client requestctx.Client, attempts int64) SecurityEvent {
+	return SecurityEvent{
+		UserID:    user.ID,
+		UserUUID:  user.UUID.String(),
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Attempts:  attempts,
+	}
+}
+
+// SecurityStats returns the security event counts since start
+func SecurityStats() map[string]int64 {
+	stats := map[string]int64{
+		EventLoginFailed:     0,
+		EventAccountLocked:   0,
+		EventAccountUnlocked: 0,
+		EventSuspiciousLogin: 0,
+	}
+	securityMetrics.Do(func(kv expvar.KeyValue) {
+		if v, ok := kv.Value.(*expvar.Int); ok {
+			stats[kv.Key] = v.Value()
+		}
+	})
+	return stats
+}
+
+// loginClient returns the client of the request, set by middleware.ClientInfo
+func loginClient(ctx context.Context) requestctx.Client {
+	client, _ := requestctx.ClientFrom(ctx)
+	return client
+}
+
+// unlockLink appends the token to the unlock page, or returns "" without one
+func unlockLink(page, token string) string {
+	if page == "" {
+		return ""
+	}
+	link, err := url.Parse(page)
+	if err != nil {
+		return ""
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+func displayIP(ip string) string {
+	if ip == "" {
+		return "an unknown address"
+	}
+	return ip
+}
+
+func failedLoginsKey(userID int, ip string) string {
+	return fmt.Sprintf("login:failures:%d:%s", userID, ip)
+}
+
+func lockoutsKey(userID int, ip string) string {
+	return fmt.Sprintf("login:lockouts:%d:%s", userID, ip)
+}
+
+func lockedKey(userID int, ip string) string {
+	return fmt.Sprintf("login:locked:%d:%s", userID, ip)
+}
+
+// unlockTokenKey stores only a hash of the token, like password reset tokens
+func unlockTokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return fmt.Sprintf("login:unlock:%s", hex.EncodeToString(sum[:]))
+}
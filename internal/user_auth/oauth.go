@@ -0,0 +1,155 @@
+package user_auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OAuthStateTTL is how long a user has to finish the provider's login page
+const OAuthStateTTL = 10 * time.Minute
+
+// OAuthIdentity is the verified account returned by a provider
+type OAuthIdentity struct {
+	Provider   string
+	ProviderID string
+	Email      string
+	FirstName  string
+	LastName   string
+	Roles      []string        // Roles mapped from the IdP (SAML)
+	Raw        json.RawMessage // Provider profile, stored as SocialAccount.ProviderData
+}
+
+// OAuthProvider runs the server side of an authorization code flow
+type OAuthProvider interface {
+	// Name is the provider key used in routes and social accounts, e.g. "google"
+	Name() string
+	// AuthCodeURL returns the provider login URL. codeChallenge is the S256 PKCE
+	// challenge; providers without PKCE support ignore it.
+	AuthCodeURL(state, codeChallenge string) string
+	// Exchange trades the code for tokens and returns the verified identity
+	Exchange(ctx context.Context, code, codeVerifier string) (*OAuthIdentity, error)
+}
+
+// OAuthRedirect is returned when starting a flow
+type OAuthRedirect struct {
+	URL   string `json:"url"`
+	State string `json:"state"`
+}
+
+// OAuthCallbackRequest is what the provider sends back to the redirect URL.
+// Apple posts it as a form; other providers use the query string.
+type OAuthCallbackRequest struct {
+	Provider         string `uri:"provider" json:"-" form:"-" validate:"required"`
+	Code             string `json:"code" form:"code"`
+	State            string `json:"state" form:"state" validate:"required"`
+	Error            string `json:"error" form:"error"`
+	ErrorDescription string `json:"error_description" form:"error_description"`
+	User             string `json:"user" form:"user"` // Apple sends the name once, on first login
+}
+
+// oauthState is kept in the cache between the redirect and the callback
+type oauthState struct {
+	Provider     string `json:"provider"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+func oauthStateKey(state string) string {
+	return "oauth:state:" + state
+}
+
+// newPKCE returns a code verifier and its S256 challenge (RFC 7636)
+func newPKCE() (verifier, challenge string, err error) {
+	verifier, err = randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// oauthToken is the token endpoint response shared by every provider
+type oauthToken struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchangeCode posts an authorization code to a token endpoint
+func exchangeCode(ctx context.Context, client *http.Client, tokenURL string, form url.Values) (*oauthToken, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token oauthToken
+	if err := doJSON(client, req, &token); err != nil {
+		if token.Error != "" {
+			return nil, fmt.Errorf("token exchange failed: %s %s", token.Error, token.ErrorDescription)
+		}
+		return nil, err
+	}
+	if token.AccessToken == "" && token.IDToken == "" {
+		return nil, fmt.Errorf("token exchange failed: empty token response")
+	}
+	return &token, nil
+}
+
+// doJSON sends req and decodes the JSON response into out. out is decoded even
+// for error statuses so callers can read provider error fields.
+func doJSON(client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	_ = json.Unmarshal(body, out)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s responded %d", req.URL.Host, resp.StatusCode)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// getJSON fetches a profile endpoint with a bearer token
+func getJSON(ctx context.Context, client *http.Client, endpoint, accessToken string, out interface{}) (json.RawMessage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	var raw json.RawMessage
+	if err := doJSON(client, req, &raw); err != nil {
+		return nil, err
+	}
+	return raw, json.Unmarshal(raw, out)
+}
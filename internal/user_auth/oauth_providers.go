@@ -0,0 +1,386 @@
+package user_auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"flex-service/pkg/httpclient"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OAuthClientConfig is the app registered with a provider
+type OAuthClientConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string // Provider defaults when empty
+}
+
+// oauthClient holds what every provider needs
+type oauthClient struct {
+	cfg      OAuthClientConfig
+	authURL  string
+	tokenURL string
+	http     *http.Client
+}
+
+func newOAuthClient(cfg OAuthClientConfig, authURL, tokenURL string, defaultScopes ...string) oauthClient {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = defaultScopes
+	}
+	httpConfig := httpclient.DefaultConfig()
+	httpConfig.Name = "oauth"
+	return oauthClient{
+		cfg:      cfg,
+		authURL:  authURL,
+		tokenURL: tokenURL,
+		http:     httpclient.New(httpConfig),
+	}
+}
+
+// authCodeURL builds the login URL; extra overrides or adds query parameters
+func (o *oauthClient) authCodeURL(state string, extra url.Values) string {
+	q := url.Values{
+		"response_type": {"code"},
+		"client_id":     {o.cfg.ClientID},
+		"redirect_uri":  {o.cfg.RedirectURL},
+		"scope":         {strings.Join(o.cfg.Scopes, " ")},
+		"state":         {state},
+	}
+	for k, v := range extra {
+		q[k] = v
+	}
+	return o.authURL + "?" + q.Encode()
+}
+
+func (o *oauthClient) exchange(ctx context.Context, code, codeVerifier, clientSecret string) (*oauthToken, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {o.cfg.RedirectURL},
+		"client_id":     {o.cfg.ClientID},
+		"client_secret": {clientSecret},
+	}
+	if codeVerifier != "" {
+		form.Set("code_verifier", codeVerifier)
+	}
+	return exchangeCode(ctx, o.http, o.tokenURL, form)
+}
+
+func pkceParams(codeChallenge string) url.Values {
+	if codeChallenge == "" {
+		return nil
+	}
+	return url.Values{"code_challenge": {codeChallenge}, "code_challenge_method": {"S256"}}
+}
+
+// GoogleProvider signs in with Google (OpenID Connect)
+type GoogleProvider struct {
+	oauthClient
+	userInfoURL string
+}
+
+// NewGoogleProvider creates the Google provider
+func NewGoogleProvider(cfg OAuthClientConfig) *GoogleProvider {
+	return &GoogleProvider{
+		oauthClient: newOAuthClient(cfg,
+			"https://accounts.google.com/o/oauth2/v2/auth",
+			"https://oauth2.googleapis.com/token",
+			"openid", "email", "profile"),
+		userInfoURL: "https://openidconnect.googleapis.com/v1/userinfo",
+	}
+}
+
+// Name implements OAuthProvider
+func (p *GoogleProvider) Name() string { return "google" }
+
+// AuthCodeURL implements OAuthProvider
+func (p *GoogleProvider) AuthCodeURL(state, codeChallenge string) string {
+	return p.authCodeURL(state, pkceParams(codeChallenge))
+}
+
+// Exchange implements OAuthProvider. The profile comes from the userinfo
+// endpoint, which only answers for tokens Google issued to this client.
+func (p *GoogleProvider) Exchange(ctx context.Context, code, codeVerifier string) (*OAuthIdentity, error) {
+	token, err := p.exchange(ctx, code, codeVerifier, p.cfg.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	var profile struct {
+		Sub           string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		GivenName     string `json:"given_name"`
+		FamilyName    string `json:"family_name"`
+	}
+	raw, err := getJSON(ctx, p.http, p.userInfoURL, token.AccessToken, &profile)
+	if err != nil {
+		return nil, err
+	}
+	if profile.Sub == "" {
+		return nil, fmt.Errorf("google userinfo has no subject")
+	}
+	if !profile.EmailVerified {
+		profile.Email = ""
+	}
+
+	return &OAuthIdentity{
+		Provider:   p.Name(),
+		ProviderID: profile.Sub,
+		Email:      profile.Email,
+		FirstName:  profile.GivenName,
+		LastName:   profile.FamilyName,
+		Raw:        raw,
+	}, nil
+}
+
+// FacebookProvider signs in with Facebook Login
+type FacebookProvider struct {
+	oauthClient
+	profileURL string
+}
+
+// NewFacebookProvider creates the Facebook provider
+func NewFacebookProvider(cfg OAuthClientConfig) *FacebookProvider {
+	return &FacebookProvider{
+		oauthClient: newOAuthClient(cfg,
+			"https://www.facebook.com/v19.0/dialog/oauth",
+			"https://graph.facebook.com/v19.0/oauth/access_token",
+			"email", "public_profile"),
+		profileURL: "https://graph.facebook.com/v19.0/me",
+	}
+}
+
+// Name implements OAuthProvider
+func (p *FacebookProvider) Name() string { return "facebook" }
+
+// AuthCodeURL implements OAuthProvider
+func (p *FacebookProvider) AuthCodeURL(state, codeChallenge string) string {
+	return p.authCodeURL(state, pkceParams(codeChallenge))
+}
+
+// Exchange implements OAuthProvider. Graph API calls carry an appsecret_proof so
+// a token leaked from another app can't be used here.
+func (p *FacebookProvider) Exchange(ctx context.Context, code, codeVerifier string) (*OAuthIdentity, error) {
+	token, err := p.exchange(ctx, code, codeVerifier, p.cfg.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, []byte(p.cfg.ClientSecret))
+	mac.Write([]byte(token.AccessToken))
+	q := url.Values{
+		"fields":          {"id,email,first_name,last_name"},
+		"appsecret_proof": {hex.EncodeToString(mac.Sum(nil))},
+	}
+
+	var profile struct {
+		ID        string `json:"id"`
+		Email     string `json:"email"`
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+	}
+	raw, err := getJSON(ctx, p.http, p.profileURL+"?"+q.Encode(), token.AccessToken, &profile)
+	if err != nil {
+		return nil, err
+	}
+	if profile.ID == "" {
+		return nil, fmt.Errorf("facebook profile has no id")
+	}
+
+	return &OAuthIdentity{
+		Provider:   p.Name(),
+		ProviderID: profile.ID,
+		Email:      profile.Email,
+		FirstName:  profile.FirstName,
+		LastName:   profile.LastName,
+		Raw:        raw,
+	}, nil
+}
+
+// LINEProvider signs in with LINE Login (OpenID Connect)
+type LINEProvider struct {
+	oauthClient
+	verifyURL string
+}
+
+// NewLINEProvider creates the LINE provider. ClientID is the LINE Login channel ID.
+func NewLINEProvider(cfg OAuthClientConfig) *LINEProvider {
+	return &LINEProvider{
+		oauthClient: newOAuthClient(cfg,
+			"https://access.line.me/oauth2/v2.1/authorize",
+			"https://api.line.me/oauth2/v2.1/token",
+			"openid", "profile", "email"),
+		verifyURL: "https://api.line.me/oauth2/v2.1/verify",
+	}
+}
+
+// Name implements OAuthProvider
+func (p *LINEProvider) Name() string { return "line" }
+
+// AuthCodeURL implements OAuthProvider
+func (p *LINEProvider) AuthCodeURL(state, codeChallenge string) string {
+	return p.authCodeURL(state, pkceParams(codeChallenge))
+}
+
+// Exchange implements OAuthProvider. The ID token is verified by LINE's verify
+// endpoint, which checks the signature, audience and expiry.
+func (p *LINEProvider) Exchange(ctx context.Context, code, codeVerifier string) (*OAuthIdentity, error) {
+	token, err := p.exchange(ctx, code, codeVerifier, p.cfg.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("line token response has no id_token; is the openid scope enabled?")
+	}
+
+	form := url.Values{"id_token": {token.IDToken}, "client_id": {p.cfg.ClientID}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var raw json.RawMessage
+	if err := doJSON(p.http, req, &raw); err != nil {
+		return nil, fmt.Errorf("line id_token verification failed: %w", err)
+	}
+	var claims struct {
+		Sub   string `json:"sub"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(raw, &claims); err != nil || claims.Sub == "" {
+		return nil, fmt.Errorf("line id_token has no subject")
+	}
+
+	// LINE only has a display name
+	return &OAuthIdentity{
+		Provider:   p.Name(),
+		ProviderID: claims.Sub,
+		Email:      claims.Email,
+		FirstName:  claims.Name,
+		Raw:        raw,
+	}, nil
+}
+
+// AppleProvider signs in with Apple. The client secret is a short-lived ES256
+// JWT signed with the key downloaded from the Apple developer portal.
+type AppleProvider struct {
+	oauthClient
+	teamID string
+	keyID  string
+	key    interface{}
+
+	mu        sync.Mutex
+	secret    string
+	secretExp time.Time
+}
+
+// AppleIssuer is the iss and aud of Apple's tokens
+const AppleIssuer = "https://appleid.apple.com"
+
+// NewAppleProvider creates the Apple provider. ClientID is the Services ID;
+// privateKeyPEM is the contents of the .p8 key file.
+func NewAppleProvider(cfg OAuthClientConfig, teamID, keyID string, privateKeyPEM []byte) (*AppleProvider, error) {
+	key, err := jwt.ParseECPrivateKeyFromPEM(privateKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse apple private key: %w", err)
+	}
+
+	return &AppleProvider{
+		oauthClient: newOAuthClient(cfg,
+			AppleIssuer+"/auth/authorize",
+			AppleIssuer+"/auth/token",
+			"name", "email"),
+		teamID: teamID,
+		keyID:  keyID,
+		key:    key,
+	}, nil
+}
+
+// Name implements OAuthProvider
+func (p *AppleProvider) Name() string { return "apple" }
+
+// AuthCodeURL implements OAuthProvider. Apple requires form_post when asking for
+// name or email, so the callback arrives as a POST.
+func (p *AppleProvider) AuthCodeURL(state, codeChallenge string) string {
+	return p.authCodeURL(state, url.Values{"response_mode": {"form_post"}})
+}
+
+// Exchange implements OAuthProvider. The ID token comes straight from Apple's
+// token endpoint over TLS, so its issuer, audience and expiry are checked
+// without fetching Apple's signing keys (OpenID Connect Core 3.1.3.7).
+func (p *AppleProvider) Exchange(ctx context.Context, code, codeVerifier string) (*OAuthIdentity, error) {
+	secret, err := p.clientSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.exchange(ctx, code, "", secret)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token.IDToken, claims); err != nil {
+		return nil, fmt.Errorf("invalid apple id_token: %w", err)
+	}
+	validator := jwt.NewValidator(jwt.WithIssuer(AppleIssuer), jwt.WithAudience(p.cfg.ClientID), jwt.WithExpirationRequired())
+	if err := validator.Validate(claims); err != nil {
+		return nil, fmt.Errorf("invalid apple id_token: %w", err)
+	}
+
+	sub, _ := claims.GetSubject()
+	if sub == "" {
+		return nil, fmt.Errorf("apple id_token has no subject")
+	}
+	email, _ := claims["email"].(string)
+	raw, _ := json.Marshal(claims)
+
+	return &OAuthIdentity{
+		Provider:   p.Name(),
+		ProviderID: sub,
+		Email:      email,
+		Raw:        raw,
+	}, nil
+}
+
+// clientSecret returns a cached client secret JWT, refreshed a day before it expires
+func (p *AppleProvider) clientSecret() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if p.secret != "" && now.Before(p.secretExp.Add(-24*time.Hour)) {
+		return p.secret, nil
+	}
+
+	exp := now.Add(30 * 24 * time.Hour)
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.RegisteredClaims{
+		Issuer:    p.teamID,
+		Subject:   p.cfg.ClientID,
+		Audience:  jwt.ClaimStrings{AppleIssuer},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(exp),
+	})
+	token.Header["kid"] = p.keyID
+
+	secret, err := token.SignedString(p.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign apple client secret: %w", err)
+	}
+	p.secret, p.secretExp = secret, exp
+	return secret, nil
+}
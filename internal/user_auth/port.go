@@ -3,6 +3,8 @@ package user_auth
 import (
 	"context"
 	"flex-service/internal/entity"
+	"flex-service/pkg/requestctx"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -26,12 +28,21 @@ type ResetPasswordRequest struct {
 	Password string `json:"password" validate:"required,min=6"`
 }
 
+// UnlockAccountRequest carries the token from the email sent on a lockout
+type UnlockAccountRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
 // AuthResponse structures
 type AuthResponse struct {
 	User         *entity.User `json:"user"`
 	AccessToken  string       `json:"access_token"`
 	RefreshToken string       `json:"refresh_token"`
 	ExpiresIn    int64        `json:"expires_in"`
+	// Set instead of the tokens when the password was right but 2FA is enabled:
+	// send TwoFactorToken and a code to POST /user-auth/2fa/verify
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	TwoFactorToken    string `json:"two_factor_token,omitempty"`
 }
 
 type GenerateTokensResponse struct {
@@ -52,6 +63,62 @@ type UpdateUserTokenRequest struct {
 type ValidateTokenResponse struct {
 	User       *entity.User
 	UserClaims *UserClaims
+	Roles      []string
+}
+
+// Principal converts a validated token into the request principal. Principals
+// from scoped tokens are limited to the token's scopes.
+func (r *ValidateTokenResponse) Principal() requestctx.Principal {
+	principal := requestctx.NewUserPrincipal(r.User.ID, r.User.UUID.String(), r.UserClaims.Email)
+	principal.Roles = r.Roles
+	if r.UserClaims.TokenType == TokenTypeScoped {
+		principal.Scopes = r.UserClaims.Scopes()
+	}
+	return principal
+}
+
+// Token exchange (RFC 8693) identifiers
+const (
+	GrantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	TokenTypeURIAccess     = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeURIJWT        = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// TokenExchangeRequest trades a session or scoped token for a narrower scoped token
+type TokenExchangeRequest struct {
+	GrantType          string `json:"grant_type" form:"grant_type" validate:"required"`
+	SubjectToken       string `json:"subject_token" form:"subject_token" validate:"required"`
+	SubjectTokenType   string `json:"subject_token_type" form:"subject_token_type" validate:"required"`
+	RequestedTokenType string `json:"requested_token_type" form:"requested_token_type"`
+	Scope              string `json:"scope" form:"scope" validate:"required"` // Space-separated permissions
+	Audience           string `json:"audience" form:"audience"`
+	ExpiresIn          int    `json:"expires_in" form:"expires_in" validate:"omitempty,min=1"` // Seconds; capped by JWT_SCOPED_TOKEN_MAX_TTL
+}
+
+// TokenExchangeResponse follows RFC 8693 section 2.2.1
+type TokenExchangeResponse struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int64  `json:"expires_in"`
+	Scope           string `json:"scope"`
+}
+
+// Session is a signed-in device of the current user, from the user tokens
+type Session struct {
+	UUID       uuid.UUID  `json:"uuid"`
+	Device     string     `json:"device"` // e.g. "Chrome on macOS"
+	IPAddress  string     `json:"ip_address"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	Current    bool       `json:"current"` // the session of the request's token
+}
+
+// LoginHistory counts the sessions of a user, to spot logins from new IPs
+type LoginHistory struct {
+	Sessions int64 `gorm:"column:sessions"`
+	FromIP   int64 `gorm:"column:from_ip"`
 }
 
 type LoginWithSocialAccountRequest struct {
@@ -60,7 +127,7 @@ type LoginWithSocialAccountRequest struct {
 }
 
 type RegisterWithSocialAccountRequest struct {
-	Provider     string `json:"provider" validate:"required,oneof=google facebook apple"`
+	Provider     string `json:"provider" validate:"required,oneof=google facebook apple line"`
 	ProviderID   string `json:"provider_id" validate:"required"`
 	ProviderData string `json:"provider_data" validate:"omitempty,json"`
 	FirstName    string `json:"first_name" validate:"required,min=3"`
@@ -81,16 +148,46 @@ type UserAuthUsecase interface {
 	GetUserByID(ctx context.Context, userID int) (*entity.User, error)
 	GetUserByUUID(ctx context.Context, userUUID uuid.UUID) (*entity.User, error)
 	ValidateToken(ctx context.Context, token string) (*ValidateTokenResponse, error)
+	ValidateScopedToken(ctx context.Context, token string) (*ValidateTokenResponse, error)
+	IssueScopedToken(ctx context.Context, subjectToken string, scopes []string, audience string, ttl time.Duration) (*TokenExchangeResponse, error)
+	ExchangeToken(ctx context.Context, req *TokenExchangeRequest) (*TokenExchangeResponse, error)
 	GetUserProfile(ctx context.Context, userID int) (*entity.User, error)
-	// TODO: Add password reset methods
+	InvalidateUserCache(ctx context.Context, userID int) error
+	FlushUserCache(ctx context.Context) error
+	OAuthRedirect(ctx context.Context, provider string) (*OAuthRedirect, error)
+	OAuthCallback(ctx context.Context, req *OAuthCallbackRequest) (*AuthResponse, error)
+	SAMLMetadata(ctx context.Context) ([]byte, error)
+	SAMLLoginURL(ctx context.Context) (*OAuthRedirect, error)
+	SAMLCallback(ctx context.Context, req *SAMLCallbackRequest) (*AuthResponse, error)
+	SetupTwoFactor(ctx context.Context, userID int) (*TwoFactorSetupResponse, error)
+	EnableTwoFactor(ctx context.Context, userID int, req *TwoFactorCodeRequest) (*TwoFactorRecoveryCodesResponse, error)
+	DisableTwoFactor(ctx context.Context, userID int, req *TwoFactorCodeRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, userID int, req *TwoFactorCodeRequest) (*TwoFactorRecoveryCodesResponse, error)
+	VerifyTwoFactor(ctx context.Context, req *TwoFactorVerifyRequest) (*AuthResponse, error)
+	ResetPassword(ctx context.Context, req *ResetPasswordRequest) error
+	// Sessions of the signed-in user; accessToken marks the current one
+	ListSessions(ctx context.Context, userID int, accessToken string) ([]Session, error)
+	RevokeSession(ctx context.Context, userID int, sessionUUID uuid.UUID) error
+	RevokeOtherSessions(ctx context.Context, userID int, accessToken string) (*RevokeSessionsResponse, error)
+	UnlockAccount(ctx context.Context, req *UnlockAccountRequest) error
+	// TODO: Add forgot password
 	// ForgotPassword(ctx context.Context, req *ForgotPasswordRequest) error
-	// ResetPassword(ctx context.Context, req *ResetPasswordRequest) error
+}
+
+// SessionAdminUsecase lets admins inspect and revoke the sessions (user tokens)
+// of any user
+type SessionAdminUsecase interface {
+	ListSessions(ctx context.Context, userUUID uuid.UUID, includeRevoked bool) ([]entity.UserToken, error)
+	RevokeSessions(ctx context.Context, userUUID uuid.UUID, sessionUUIDs []uuid.UUID) (*RevokeSessionsResponse, error)
+	RevokeAllSessions(ctx context.Context, userUUID uuid.UUID) (*RevokeSessionsResponse, error)
+	ForcePasswordReset(ctx context.Context, userUUID uuid.UUID) (*ForcePasswordResetResponse, error)
 }
 
 // AuthRepository defines the data access interface for auth
 type UserAuthRepository interface {
 	CreateUser(ctx context.Context, user *entity.User) error
 	GetUserByUsername(ctx context.Context, username string) (*entity.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*entity.User, error)
 	GetUserByID(ctx context.Context, id int) (*entity.User, error)
 	UpdateUser(ctx context.Context, user *entity.User) error
 	CreateUserToken(ctx context.Context, userID int, accessJti string, refreshJti string) error
@@ -100,4 +197,13 @@ type UserAuthRepository interface {
 	GetUserTokenByAccessJti(ctx context.Context, accessJti string) (*entity.UserToken, error)
 	GetUserBySocialAccount(ctx context.Context, provider, providerID string) (*entity.User, error)
 	CreateSocialAccount(ctx context.Context, req *RegisterWithSocialAccountRequest) (*entity.User, error)
+	UpdateSocialAccountData(ctx context.Context, provider, providerID, data string) error
+	GetTwoFactorSecret(ctx context.Context, userID int) (*entity.TwoFactorSecret, error)
+	SaveTwoFactorSecret(ctx context.Context, secret *entity.TwoFactorSecret) error
+	DeleteTwoFactorSecret(ctx context.Context, userID int) error
+	UseTwoFactorStep(ctx context.Context, id int, step int64) (bool, error)
+	TouchUserToken(ctx context.Context, id int, usedAt time.Time) error
+	ListUserTokens(ctx context.Context, userID int, includeRevoked bool) ([]entity.UserToken, error)
+	RevokeUserTokens(ctx context.Context, userID int, tokenUUIDs []uuid.UUID) ([]entity.UserToken, error)
+	GetLoginHistory(ctx context.Context, userID int, ip string) (*LoginHistory, error)
 }
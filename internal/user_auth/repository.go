@@ -4,7 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"flex-service/internal/entity"
+	"flex-service/pkg/database"
 	"flex-service/pkg/errors"
+	"flex-service/pkg/requestctx"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -22,7 +25,7 @@ func NewUserAuthRepository(db *gorm.DB) UserAuthRepository {
 
 func (r *userAuthRepository) CreateUser(ctx context.Context, user *entity.User) error {
 	if err := r.db.WithContext(ctx).Create(user).Error; err != nil {
-		return errors.WrapDatabase(err, "failed to create user")
+		return database.WrapError(err, "failed to create user")
 	}
 	return nil
 }
@@ -43,21 +46,26 @@ func (r *userAuthRepository) CreateSocialAccount(ctx context.Context, req *Regis
 
 	user := &entity.User{
 		UUID:      uuid.New(),
-		Email:     &req.Email,
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
 		MemberNo:  memberNo,
+		// The provider has already verified the account
+		Active: entity.UserActive,
 	}
 
+	// Email is unique, so accounts without one (e.g. LINE) store NULL rather than ""
 	if req.Email != "" {
+		user.Email = &req.Email
 		user.Username = req.Email
 	} else if req.Phone != "" {
 		user.Username = req.Phone
+	} else {
+		user.Username = req.Provider + ":" + req.ProviderID
 	}
 
 	if err := tx.Create(user).Error; err != nil {
 		tx.Rollback()
-		return nil, errors.WrapDatabase(err, "failed to create user")
+		return nil, database.WrapError(err, "failed to create user")
 	}
 
 	socialAccount := &entity.SocialAccount{
@@ -72,7 +80,7 @@ func (r *userAuthRepository) CreateSocialAccount(ctx context.Context, req *Regis
 
 	if err := tx.Create(socialAccount).Error; err != nil {
 		tx.Rollback()
-		return nil, errors.WrapDatabase(err, "failed to create social account")
+		return nil, database.WrapError(err, "failed to create social account")
 	}
 
 	if err := tx.Commit().Error; err != nil {
@@ -132,9 +140,52 @@ func (r *userAuthRepository) GetUserBySocialAccount(ctx context.Context, provide
 	return &socialAccount.User, nil
 }
 
+func (r *userAuthRepository) UpdateSocialAccountData(ctx context.Context, provider, providerID, data string) error {
+	if err := r.db.WithContext(ctx).Model(&entity.SocialAccount{}).
+		Where("provider = ? AND provider_id = ?", provider, providerID).
+		Update("provider_data", json.RawMessage(data)).Error; err != nil {
+		return errors.WrapDatabase(err, "failed to update social account")
+	}
+	return nil
+}
+
+func (r *userAuthRepository) GetTwoFactorSecret(ctx context.Context, userID int) (*entity.TwoFactorSecret, error) {
+	var secret entity.TwoFactorSecret
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&secret).Error; err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+func (r *userAuthRepository) SaveTwoFactorSecret(ctx context.Context, secret *entity.TwoFactorSecret) error {
+	if err := r.db.WithContext(ctx).Save(secret).Error; err != nil {
+		return errors.WrapDatabase(err, "failed to save two-factor secret")
+	}
+	return nil
+}
+
+func (r *userAuthRepository) DeleteTwoFactorSecret(ctx context.Context, userID int) error {
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entity.TwoFactorSecret{}).Error; err != nil {
+		return errors.WrapDatabase(err, "failed to delete two-factor secret")
+	}
+	return nil
+}
+
+// UseTwoFactorStep records a used TOTP step. It returns false when the step (or
+// a later one) was already used, so concurrent logins can't share a code.
+func (r *userAuthRepository) UseTwoFactorStep(ctx context.Context, id int, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.TwoFactorSecret{}).
+		Where("id = ? AND last_used_step < ?", id, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return false, errors.WrapDatabase(result.Error, "failed to update two-factor secret")
+	}
+	return result.RowsAffected == 1, nil
+}
+
 func (r *userAuthRepository) UpdateUser(ctx context.Context, user *entity.User) error {
 	if err := r.db.WithContext(ctx).Save(user).Error; err != nil {
-		return errors.WrapDatabase(err, "failed to update user")
+		return database.WrapError(err, "failed to update user")
 	}
 	return nil
 }
@@ -148,11 +199,18 @@ func (r *userAuthRepository) CreateUserToken(ctx context.Context, userID int, ac
 		}
 	}()
 
+	now := time.Now()
 	userToken := &entity.UserToken{
 		UserID:      userID,
 		AccessJti:   accessJti,
 		RefreshJti:  refreshJti,
 		TokenStatus: entity.UserTokenActive,
+		LastUsedAt:  &now,
+	}
+	// The device is the client of the sign-in request
+	if client, ok := requestctx.ClientFrom(ctx); ok {
+		userToken.IPAddress = truncate(client.IP, 45)
+		userToken.UserAgent = truncate(client.UserAgent, 255)
 	}
 
 	if err := tx.Create(userToken).Error; err != nil {
@@ -175,8 +233,14 @@ func (r *userAuthRepository) UpdateUserToken(ctx context.Context, req *UpdateUse
 		return errors.WrapDatabase(err, "failed to update user token")
 	}
 
+	now := time.Now()
 	userToken.AccessJti = req.AccessJti
 	userToken.RefreshJti = req.RefreshJti
+	userToken.LastUsedAt = &now
+	if client, ok := requestctx.ClientFrom(ctx); ok {
+		userToken.IPAddress = truncate(client.IP, 45)
+		userToken.UserAgent = truncate(client.UserAgent, 255)
+	}
 
 	if err := r.db.WithContext(ctx).Save(&userToken).Error; err != nil {
 		return errors.WrapDatabase(err, "failed to update user token")
@@ -203,3 +267,67 @@ func (r *userAuthRepository) RevokeAccessTokenByJTI(ctx context.Context, jti str
 	}
 	return nil
 }
+
+// TouchUserToken sets when a token was last used, without changing updated_at
+func (r *userAuthRepository) TouchUserToken(ctx context.Context, id int, usedAt time.Time) error {
+	if err := r.db.WithContext(ctx).Model(&entity.UserToken{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", usedAt).Error; err != nil {
+		return errors.WrapDatabase(err, "failed to update user token")
+	}
+	return nil
+}
+
+func (r *userAuthRepository) ListUserTokens(ctx context.Context, userID int, includeRevoked bool) ([]entity.UserToken, error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if !includeRevoked {
+		query = query.Where("token_status = ? AND revoked_at IS NULL", entity.UserTokenActive)
+	}
+
+	var tokens []entity.UserToken
+	if err := query.Order("created_at DESC").Find(&tokens).Error; err != nil {
+		return nil, errors.WrapDatabase(err, "failed to list user tokens")
+	}
+	return tokens, nil
+}
+
+// RevokeUserTokens revokes the user's active tokens with the given UUIDs, or
+// all of them when tokenUUIDs is nil, and returns the tokens it revoked
+func (r *userAuthRepository) RevokeUserTokens(ctx context.Context, userID int, tokenUUIDs []uuid.UUID) ([]entity.UserToken, error) {
+	var tokens []entity.UserToken
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("user_id = ? AND token_status = ? AND revoked_at IS NULL", userID, entity.UserTokenActive)
+		if tokenUUIDs != nil {
+			query = query.Where("uuid IN ?", tokenUUIDs)
+		}
+		if err := query.Find(&tokens).Error; err != nil || len(tokens) == 0 {
+			return err
+		}
+
+		ids := make([]int, len(tokens))
+		for i, token := range tokens {
+			ids[i] = token.ID
+		}
+		return tx.Model(&entity.UserToken{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"token_status": entity.UserTokenInactive,
+			"revoked_at":   time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, errors.WrapDatabase(err, "failed to revoke user tokens")
+	}
+	return tokens, nil
+}
+
+// GetLoginHistory counts the user's sessions, revoked ones included, in total
+// and from ip
+func (r *userAuthRepository) GetLoginHistory(ctx context.Context, userID int, ip string) (*LoginHistory, error) {
+	var history LoginHistory
+	if err := r.db.WithContext(ctx).Model(&entity.UserToken{}).
+		Select("COUNT(*) AS sessions, COALESCE(SUM(CASE WHEN ip_address = ? THEN 1 ELSE 0 END), 0) AS from_ip", ip).
+		Where("user_id = ?", userID).
+		Scan(&history).Error; err != nil {
+		return nil, errors.WrapDatabase(err, "failed to get login history")
+	}
+	return &history, nil
+}
@@ -0,0 +1,25 @@
+package user_auth
+
+import (
+	"net/http"
+
+	"flex-service/pkg/routes"
+)
+
+// AdminManifest declares the routes admins use to inspect and revoke the
+// sessions of any user, mounted with the admin route profile. Every route
+// requires SessionManagePermission; the user auth routes themselves are
+// mounted in the router.
+var AdminManifest = routes.Manifest{
+	Module:     "user_auth_admin",
+	Prefix:     "/admin/users",
+	Middleware: []string{"auth"},
+	Permission: SessionManagePermission,
+	Profile:    routes.ProfileAdmin,
+	Routes: []routes.Route{
+		{Method: http.MethodGet, Path: "/:id/sessions", Handler: "ListSessions", Summary: "List the active sessions of a user (?status=all includes revoked)"},
+		{Method: http.MethodPost, Path: "/:id/sessions/revoke", Handler: "RevokeSessions", Summary: "Revoke selected sessions of a user"},
+		{Method: http.MethodDelete, Path: "/:id/sessions", Handler: "RevokeAllSessions", Summary: "Revoke every session of a user"},
+		{Method: http.MethodPost, Path: "/:id/force-password-reset", Handler: "ForcePasswordReset", Summary: "Require a password reset and revoke every session"},
+	},
+}
@@ -0,0 +1,171 @@
+package user_auth
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"flex-service/pkg/errors"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/saml"
+
+	"go.uber.org/zap"
+)
+
+// SAMLProvider is the social account provider name of SAML identities
+const SAMLProvider = "saml"
+
+// SAMLLogin configures SAML single sign-on: the service provider and how
+// assertion attributes map to the user and roles
+type SAMLLogin struct {
+	SP                 *saml.ServiceProvider
+	EmailAttribute     string // Falls back to the NameID when its format is an email address
+	FirstNameAttribute string
+	LastNameAttribute  string
+	RolesAttribute     string
+	RoleMap            map[string]string // IdP group -> app role; when set, unmapped groups are dropped
+}
+
+// SAMLCallbackRequest is the HTTP-POST binding form the IdP posts to the ACS URL
+type SAMLCallbackRequest struct {
+	SAMLResponse string `form:"SAMLResponse" json:"saml_response" validate:"required"`
+	RelayState   string `form:"RelayState" json:"relay_state"`
+}
+
+// samlProviderData is stored as the social account's provider data
+type samlProviderData struct {
+	Issuer     string              `json:"issuer"`
+	NameID     string              `json:"name_id"`
+	Attributes map[string][]string `json:"attributes"`
+	Roles      []string            `json:"roles"`
+}
+
+func samlRequestKey(id string) string {
+	return "saml:request:" + id
+}
+
+func samlAssertionKey(id string) string {
+	return "saml:assertion:" + id
+}
+
+// identity maps a verified assertion to the user identity
+func (s *SAMLLogin) identity(assertion *saml.Assertion) (*OAuthIdentity, error) {
+	identity := &OAuthIdentity{
+		Provider:   SAMLProvider,
+		ProviderID: assertion.NameID,
+		Email:      assertion.Attribute(s.EmailAttribute),
+		FirstName:  assertion.Attribute(s.FirstNameAttribute),
+		LastName:   assertion.Attribute(s.LastNameAttribute),
+	}
+	if identity.Email == "" && assertion.NameIDFormat == saml.NameIDFormatEmail {
+		identity.Email = assertion.NameID
+	}
+
+	if s.RolesAttribute != "" {
+		seen := make(map[string]bool)
+		for _, group := range assertion.Attributes[s.RolesAttribute] {
+			role := group
+			if len(s.RoleMap) > 0 {
+				role = s.RoleMap[group]
+			}
+			if role != "" && !seen[role] {
+				seen[role] = true
+				identity.Roles = append(identity.Roles, role)
+			}
+		}
+	}
+
+	raw, err := json.Marshal(samlProviderData{
+		Issuer:     assertion.Issuer,
+		NameID:     assertion.NameID,
+		Attributes: assertion.Attributes,
+		Roles:      identity.Roles,
+	})
+	if err != nil {
+		return nil, err
+	}
+	identity.Raw = raw
+	return identity, nil
+}
+
+// SAMLMetadata returns the SP metadata to register with the IdP
+func (u *userAuthUsecase) SAMLMetadata(ctx context.Context) ([]byte, error) {
+	if u.saml == nil {
+		return nil, errors.NotFound("SAML login is not enabled")
+	}
+
+	metadata, err := u.saml.SP.Metadata()
+	if err != nil {
+		return nil, errors.WrapInternal(err, "failed to create SAML metadata")
+	}
+	return metadata, nil
+}
+
+// SAMLLoginURL starts an SP-initiated login. The request ID is kept in the cache
+// until the IdP posts the response back to SAMLCallback.
+func (u *userAuthUsecase) SAMLLoginURL(ctx context.Context) (*OAuthRedirect, error) {
+	if u.saml == nil {
+		return nil, errors.NotFound("SAML login is not enabled")
+	}
+	if u.cache == nil {
+		return nil, errors.Internal("SAML login requires a cache")
+	}
+
+	redirectURL, requestID, err := u.saml.SP.AuthnRequestURL("")
+	if err != nil {
+		return nil, errors.WrapInternal(err, "failed to create SAML request")
+	}
+	if err := u.cache.Set(ctx, samlRequestKey(requestID), "1", OAuthStateTTL); err != nil {
+		return nil, errors.WrapInternal(err, "failed to store SAML request")
+	}
+
+	return &OAuthRedirect{URL: redirectURL, State: requestID}, nil
+}
+
+// SAMLCallback verifies the posted response, then logs the user in, registering
+// the account on first login. SP-initiated responses must answer a pending
+// request; IdP-initiated ones are accepted only when enabled. Each assertion can
+// be used once.
+func (u *userAuthUsecase) SAMLCallback(ctx context.Context, req *SAMLCallbackRequest) (*AuthResponse, error) {
+	if u.saml == nil {
+		return nil, errors.NotFound("SAML login is not enabled")
+	}
+	if u.cache == nil {
+		return nil, errors.Internal("SAML login requires a cache")
+	}
+
+	assertion, err := u.saml.SP.ParseResponse(req.SAMLResponse)
+	if err != nil {
+		logger.Warn("SAML response rejected", zap.Error(err))
+		return nil, errors.WrapUnauthorized(err, "SAML login failed")
+	}
+
+	// The request ID is single-use
+	if assertion.InResponseTo != "" {
+		found, err := u.cache.Exists(ctx, samlRequestKey(assertion.InResponseTo))
+		if err != nil || found == 0 {
+			return nil, errors.Unauthorized("Unknown or expired SAML request")
+		}
+		_ = u.cache.Del(ctx, samlRequestKey(assertion.InResponseTo))
+	}
+
+	// Reject replays until the assertion expires
+	count, err := u.cache.Incr(ctx, samlAssertionKey(assertion.ID))
+	if err != nil {
+		return nil, errors.WrapInternal(err, "failed to record SAML assertion")
+	}
+	if count == 1 {
+		_ = u.cache.Expire(ctx, samlAssertionKey(assertion.ID), time.Until(assertion.NotOnOrAfter)+saml.DefaultClockSkew)
+	}
+	if count > 1 || assertion.ID == "" {
+		return nil, errors.Unauthorized("SAML assertion has already been used")
+	}
+
+	identity, err := u.saml.identity(assertion)
+	if err != nil {
+		return nil, errors.WrapInternal(err, "failed to map SAML attributes")
+	}
+
+	logger.Info("SAML callback", zap.String("issuer", assertion.Issuer), zap.String("name_id", assertion.NameID), zap.Strings("roles", identity.Roles))
+	return u.loginWithIdentity(ctx, identity)
+}
@@ -0,0 +1,423 @@
+package user_auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/audit"
+	"flex-service/pkg/cache"
+	"flex-service/pkg/errors"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"go.uber.org/zap"
+)
+
+// SessionManagePermission is required by every session admin route
+const SessionManagePermission = "user_auth.sessions.manage"
+
+// PasswordResetTokenTTL is how long a reset token from ForcePasswordReset is valid
+const PasswordResetTokenTTL = 24 * time.Hour
+
+// SessionTouchInterval is how often a session's last_used_at is updated while
+// its access token is used, so a busy client doesn't write on every request
+const SessionTouchInterval = 5 * time.Minute
+
+// RevokeSessionsRequest selects the sessions to revoke by UUID
+type RevokeSessionsRequest struct {
+	Sessions []uuid.UUID `json:"sessions" validate:"required,min=1"`
+}
+
+// RevokeSessionsResponse counts the sessions that were still active
+type RevokeSessionsResponse struct {
+	Revoked int `json:"revoked"`
+}
+
+// ForcePasswordResetResponse carries the one-time reset token to deliver to
+// the user, who sets a new password with POST /user-auth/password/reset
+type ForcePasswordResetResponse struct {
+	ResetToken string `json:"reset_token"`
+	ExpiresIn  int64  `json:"expires_in"`
+	Revoked    int    `json:"revoked"`
+}
+
+type sessionAdminUsecase struct {
+	repo  UserAuthRepository
+	jwt   *UserJWT
+	cache cache.Cache
+}
+
+// NewSessionAdminUsecase creates the session admin usecase. Revoked sessions
+// are marked in the database and their access tokens blacklisted in cache, so
+// they stop working at once even where validation hits the cache first.
+func NewSessionAdminUsecase(repo UserAuthRepository, jwt *UserJWT, cache cache.Cache) SessionAdminUsecase {
+	return &sessionAdminUsecase{
+		repo:  repo,
+		jwt:   jwt,
+		cache: cache,
+	}
+}
+
+func (u *sessionAdminUsecase) ListSessions(ctx context.Context, userUUID uuid.UUID, includeRevoked bool) ([]entity.UserToken, error) {
+	user, err := u.user(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	return u.repo.ListUserTokens(ctx, user.ID, includeRevoked)
+}
+
+func (u *sessionAdminUsecase) RevokeSessions(ctx context.Context, userUUID uuid.UUID, sessionUUIDs []uuid.UUID) (*RevokeSessionsResponse, error) {
+	if len(sessionUUIDs) == 0 {
+		return nil, errors.BadRequest("At least one session is required")
+	}
+
+	user, err := u.user(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := revokeSessions(ctx, u.repo, u.cache, u.jwt, user.ID, sessionUUIDs)
+	if err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, "user.sessions.revoked", user, map[string]interface{}{
+		"sessions": sessionUUIDs,
+		"revoked":  revoked,
+	})
+	return &RevokeSessionsResponse{Revoked: revoked}, nil
+}
+
+func (u *sessionAdminUsecase) RevokeAllSessions(ctx context.Context, userUUID uuid.UUID) (*RevokeSessionsResponse, error) {
+	user, err := u.user(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := revokeSessions(ctx, u.repo, u.cache, u.jwt, user.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, "user.sessions.revoked_all", user, map[string]interface{}{"revoked": revoked})
+	return &RevokeSessionsResponse{Revoked: revoked}, nil
+}
+
+// ForcePasswordReset blocks password login until the user sets a new password
+// with the returned token, and signs the user out everywhere
+func (u *sessionAdminUsecase) ForcePasswordReset(ctx context.Context, userUUID uuid.UUID) (*ForcePasswordResetResponse, error) {
+	if u.cache == nil {
+		return nil, errors.Internal("Password reset requires a cache")
+	}
+
+	user, err := u.user(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, errors.WrapInternal(err, "failed to generate password reset token")
+	}
+	if err := u.cache.Set(ctx, passwordResetKey(token), strconv.Itoa(user.ID), PasswordResetTokenTTL); err != nil {
+		return nil, errors.WrapInternal(err, "failed to store password reset token")
+	}
+
+	user.PasswordResetRequired = true
+	if err := u.repo.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	revoked, err := revokeSessions(ctx, u.repo, u.cache, u.jwt, user.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, "user.password.reset_forced", user, map[string]interface{}{"revoked": revoked})
+	logger.Info("Password reset forced",
+		zap.String("user_id", user.UUID.String()),
+		zap.Int("revoked_sessions", revoked))
+
+	return &ForcePasswordResetResponse{
+		ResetToken: token,
+		ExpiresIn:  int64(PasswordResetTokenTTL.Seconds()),
+		Revoked:    revoked,
+	}, nil
+}
+
+func (u *sessionAdminUsecase) user(ctx context.Context, userUUID uuid.UUID) (*entity.User, error) {
+	user, err := u.repo.GetUserByUUID(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.UserNotFound()
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// ListSessions returns the user's active sessions, newest first, marking the
+// one of accessToken as current
+func (u *userAuthUsecase) ListSessions(ctx context.Context, userID int, accessToken string) ([]Session, error) {
+	tokens, err := u.repo.ListUserTokens(ctx, userID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	currentJti := u.accessJti(accessToken)
+	sessions := make([]Session, len(tokens))
+	for i, token := range tokens {
+		sessions[i] = Session{
+			UUID:       token.UUID,
+			Device:     describeDevice(token.UserAgent),
+			IPAddress:  token.IPAddress,
+			UserAgent:  token.UserAgent,
+			CreatedAt:  token.CreatedAt,
+			LastUsedAt: token.LastUsedAt,
+			Current:    currentJti != "" && token.AccessJti == currentJti,
+		}
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs one of the user's sessions out. Revoking the current
+// session is the same as logging out.
+func (u *userAuthUsecase) RevokeSession(ctx context.Context, userID int, sessionUUID uuid.UUID) error {
+	user, err := u.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	revoked, err := revokeSessions(ctx, u.repo, u.cache, u.jwt, user.ID, []uuid.UUID{sessionUUID})
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return errors.NotFound("Session not found")
+	}
+
+	recordAudit(ctx, "user.sessions.revoked", user, map[string]interface{}{
+		"sessions": []uuid.UUID{sessionUUID},
+		"revoked":  revoked,
+	})
+	return nil
+}
+
+// RevokeOtherSessions signs the user out everywhere except the session of
+// accessToken
+func (u *userAuthUsecase) RevokeOtherSessions(ctx context.Context, userID int, accessToken string) (*RevokeSessionsResponse, error) {
+	currentJti := u.accessJti(accessToken)
+	if currentJti == "" {
+		return nil, errors.TokenInvalid()
+	}
+
+	user, err := u.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := u.repo.ListUserTokens(ctx, user.ID, false)
+	if err != nil {
+		return nil, err
+	}
+	var others []uuid.UUID
+	for _, token := range tokens {
+		if token.AccessJti != currentJti {
+			others = append(others, token.UUID)
+		}
+	}
+	if len(others) == 0 {
+		return &RevokeSessionsResponse{}, nil
+	}
+
+	revoked, err := revokeSessions(ctx, u.repo, u.cache, u.jwt, user.ID, others)
+	if err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, "user.sessions.revoked_others", user, map[string]interface{}{"revoked": revoked})
+	return &RevokeSessionsResponse{Revoked: revoked}, nil
+}
+
+// accessJti returns the session ID of an access token, or "" if it isn't one
+func (u *userAuthUsecase) accessJti(accessToken string) string {
+	claims, err := u.jwt.ValidateUserToken(accessToken)
+	if err != nil || claims.TokenType != TokenTypeAccess {
+		return ""
+	}
+	return claims.ID
+}
+
+// touchSession records that a session was used, at most once per
+// SessionTouchInterval. A failure only leaves last_used_at behind, so it is
+// logged rather than returned.
+func (u *userAuthUsecase) touchSession(ctx context.Context, token *entity.UserToken) {
+	now := time.Now()
+	if token.LastUsedAt != nil && now.Sub(*token.LastUsedAt) < SessionTouchInterval {
+		return
+	}
+	if err := u.repo.TouchUserToken(ctx, token.ID, now); err != nil {
+		logger.Warn("Failed to update session last use",
+			zap.String("session", token.UUID.String()),
+			zap.Error(err))
+	}
+}
+
+// describeDevice names the browser and operating system of a user agent for
+// the session list, e.g. "Chrome on macOS"
+func describeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	browser := "Unknown browser"
+	for _, b := range []struct{ token, name string }{
+		// Order matters: Edge and Opera also send "Chrome", Chrome also sends "Safari"
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"CriOS/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"okhttp", "Android app"},
+		{"CFNetwork", "iOS app"},
+		{"curl/", "curl"},
+	} {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	for _, system := range []struct{ token, name string }{
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Mac OS X", "macOS"},
+		{"Macintosh", "macOS"},
+		{"Windows", "Windows"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, system.token) {
+			return browser + " on " + system.name
+		}
+	}
+	return browser
+}
+
+// truncate cuts s to at most n bytes for a column of that size
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// ResetPassword sets a new password with a token from ForcePasswordReset. The
+// token is single-use, the password must satisfy the password policy, and
+// every session of the user is revoked.
+func (u *userAuthUsecase) ResetPassword(ctx context.Context, req *ResetPasswordRequest) error {
+	if u.cache == nil {
+		return errors.Internal("Password reset requires a cache")
+	}
+
+	key := passwordResetKey(req.Token)
+	value, err := u.cache.Get(ctx, key)
+	if err != nil {
+		return errors.BadRequest("Invalid or expired password reset token")
+	}
+
+	userID, err := strconv.Atoi(value)
+	if err != nil {
+		return errors.BadRequest("Invalid or expired password reset token")
+	}
+	user, err := u.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// A rejected password keeps the token, so the user can try another
+	if err := u.checkPassword(ctx, req.Password, user.Username, stringValue(user.Email)); err != nil {
+		return err
+	}
+	_ = u.cache.Del(ctx, key)
+
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return errors.WrapInternal(err, "failed to hash password")
+	}
+	user.Password = &hashedPassword
+	user.PasswordResetRequired = false
+	if err := u.repo.UpdateUser(ctx, user); err != nil {
+		return err
+	}
+
+	if _, err := revokeSessions(ctx, u.repo, u.cache, u.jwt, user.ID, nil); err != nil {
+		return err
+	}
+	_ = u.InvalidateUserCache(ctx, user.ID)
+
+	recordAudit(ctx, "user.password.reset", user, nil)
+	logger.Info("Password reset", zap.String("user_id", user.UUID.String()))
+	return nil
+}
+
+// revokeSessions revokes the user's sessions in the database, then blacklists
+// their access tokens until they would have expired anyway. Refresh tokens
+// are rejected by the database alone.
+func revokeSessions(ctx context.Context, repo UserAuthRepository, c cache.Cache, jwt *UserJWT, userID int, sessionUUIDs []uuid.UUID) (int, error) {
+	tokens, err := repo.RevokeUserTokens(ctx, userID, sessionUUIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	if c != nil {
+		for _, token := range tokens {
+			if err := c.Set(ctx, revokedSessionKey(token.AccessJti), "revoked", jwt.accessTokenTTL); err != nil {
+				logger.Warn("Failed to blacklist revoked session",
+					zap.String("session", token.UUID.String()),
+					zap.Error(err))
+			}
+		}
+	}
+
+	logger.Info("User sessions revoked", zap.Int("user_id", userID), zap.Int("revoked", len(tokens)))
+	return len(tokens), nil
+}
+
+// recordAudit records an action on user. The action has already happened, so
+// a failure to record it is logged rather than returned.
+func recordAudit(ctx context.Context, action string, user *entity.User, metadata map[string]interface{}) {
+	err := audit.Record(ctx, audit.Event{
+		Action:       action,
+		ResourceType: "user",
+		ResourceID:   user.UUID.String(),
+		Metadata:     metadata,
+	})
+	if err != nil {
+		logger.Error("Failed to record audit entry", zap.String("action", action), zap.Error(err))
+	}
+}
+
+// sessionRevoked reports whether the session with access token jti was
+// blacklisted by revokeSessions
+func (u *userAuthUsecase) sessionRevoked(ctx context.Context, jti string) bool {
+	if u.cache == nil || jti == "" {
+		return false
+	}
+	exists, _ := u.cache.Exists(ctx, revokedSessionKey(jti))
+	return exists > 0
+}
+
+func revokedSessionKey(jti string) string {
+	return fmt.Sprintf("token:blacklist:jti:%s", jti)
+}
+
+// passwordResetKey stores only a hash of the token, like API keys
+func passwordResetKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return fmt.Sprintf("password:reset:%s", hex.EncodeToString(sum[:]))
+}
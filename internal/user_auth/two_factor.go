@@ -0,0 +1,410 @@
+package user_auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"flex-service/internal/entity"
+	"flex-service/pkg/errors"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/secure"
+	"flex-service/pkg/totp"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"go.uber.org/zap"
+)
+
+// Two-factor limits
+const (
+	RecoveryCodeCount     = 10
+	MaxTwoFactorAttempts  = 5 // Wrong codes allowed per step-up token
+	twoFactorAttemptsTTL  = TwoFactorTokenTTL
+	recoveryCodeByteCount = 5 // 8 base32 characters
+)
+
+// twoFactor encrypts TOTP secrets at rest and names the account in authenticator apps
+type twoFactor struct {
+	secure *secure.Secure
+	issuer string
+}
+
+// WithTwoFactor enables TOTP two-factor authentication. Secrets are encrypted
+// with s; issuer is the name authenticator apps show.
+func WithTwoFactor(s *secure.Secure, issuer string) UsecaseOption {
+	return func(u *userAuthUsecase) {
+		u.twoFactor = &twoFactor{secure: s, issuer: issuer}
+	}
+}
+
+// TwoFactorSetupResponse is shown once while setting up an authenticator app
+type TwoFactorSetupResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"` // otpauth:// provisioning URI to render as a QR code
+}
+
+// TwoFactorCodeRequest confirms a 2FA change with a TOTP code, or a recovery
+// code where noted
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// TwoFactorRecoveryCodesResponse returns new recovery codes; they are only
+// shown once and stored hashed
+type TwoFactorRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorVerifyRequest completes a login that returned two_factor_required
+type TwoFactorVerifyRequest struct {
+	TwoFactorToken string `json:"two_factor_token" validate:"required"`
+	Code           string `json:"code" validate:"required"` // TOTP or recovery code
+}
+
+// SetupTwoFactor creates a new TOTP secret for the user. 2FA is enforced only
+// after EnableTwoFactor confirms a code from the authenticator app; calling
+// setup again before that replaces the secret.
+func (u *userAuthUsecase) SetupTwoFactor(ctx context.Context, userID int) (*TwoFactorSetupResponse, error) {
+	if u.twoFactor == nil {
+		return nil, errors.NotFound("Two-factor authentication is not enabled")
+	}
+
+	user, err := u.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := u.getTwoFactorSecret(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if secret.IsEnabled() {
+		return nil, errors.Conflict("Two-factor authentication is already enabled")
+	}
+	if secret == nil {
+		secret = &entity.TwoFactorSecret{UserID: userID}
+	}
+
+	plain, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, errors.WrapInternal(err, "failed to generate two-factor secret")
+	}
+	if secret.Secret, err = u.twoFactor.secure.Encrypt(plain); err != nil {
+		return nil, errors.WrapInternal(err, "failed to encrypt two-factor secret")
+	}
+	secret.LastUsedStep = 0
+	secret.RecoveryCodes = ""
+
+	if err := u.repo.SaveTwoFactorSecret(ctx, secret); err != nil {
+		return nil, err
+	}
+
+	account := user.Username
+	if user.Email != nil && *user.Email != "" {
+		account = *user.Email
+	}
+
+	return &TwoFactorSetupResponse{
+		Secret: plain,
+		URI:    totp.ProvisioningURI(u.twoFactor.issuer, account, plain),
+	}, nil
+}
+
+// EnableTwoFactor confirms setup with a TOTP code and returns the recovery codes
+func (u *userAuthUsecase) EnableTwoFactor(ctx context.Context, userID int, req *TwoFactorCodeRequest) (*TwoFactorRecoveryCodesResponse, error) {
+	if u.twoFactor == nil {
+		return nil, errors.NotFound("Two-factor authentication is not enabled")
+	}
+
+	secret, err := u.getTwoFactorSecret(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, errors.BadRequest("Two-factor setup has not been started")
+	}
+	if secret.IsEnabled() {
+		return nil, errors.Conflict("Two-factor authentication is already enabled")
+	}
+
+	if err := u.verifyTOTP(ctx, secret, req.Code); err != nil {
+		return nil, err
+	}
+
+	codes, err := setRecoveryCodes(secret)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	secret.EnabledAt = &now
+
+	if err := u.repo.SaveTwoFactorSecret(ctx, secret); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Two-factor authentication enabled", zap.Int("user_id", userID))
+	return &TwoFactorRecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTwoFactor turns 2FA off after checking a TOTP or recovery code
+func (u *userAuthUsecase) DisableTwoFactor(ctx context.Context, userID int, req *TwoFactorCodeRequest) error {
+	secret, err := u.enabledTwoFactorSecret(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := u.verifyTwoFactorCode(ctx, secret, req.Code); err != nil {
+		return err
+	}
+
+	if err := u.repo.DeleteTwoFactorSecret(ctx, userID); err != nil {
+		return err
+	}
+
+	logger.Info("Two-factor authentication disabled", zap.Int("user_id", userID))
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes after checking a TOTP code
+func (u *userAuthUsecase) RegenerateRecoveryCodes(ctx context.Context, userID int, req *TwoFactorCodeRequest) (*TwoFactorRecoveryCodesResponse, error) {
+	secret, err := u.enabledTwoFactorSecret(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.verifyTOTP(ctx, secret, req.Code); err != nil {
+		return nil, err
+	}
+
+	codes, err := setRecoveryCodes(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.repo.SaveTwoFactorSecret(ctx, secret); err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorRecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// VerifyTwoFactor trades the step-up token from Login and a TOTP or recovery
+// code for a session. Each token allows MaxTwoFactorAttempts wrong codes.
+func (u *userAuthUsecase) VerifyTwoFactor(ctx context.Context, req *TwoFactorVerifyRequest) (*AuthResponse, error) {
+	if u.twoFactor == nil {
+		return nil, errors.NotFound("Two-factor authentication is not enabled")
+	}
+
+	claims, err := u.jwt.ValidateUserToken(req.TwoFactorToken)
+	if err != nil || claims.TokenType != TokenTypeTwoFactor {
+		return nil, errors.TokenInvalid()
+	}
+
+	if u.cache != nil {
+		attemptsKey := fmt.Sprintf("2fa:attempts:%s", claims.ID)
+		attempts, err := u.cache.Incr(ctx, attemptsKey)
+		if err == nil {
+			if attempts == 1 {
+				_ = u.cache.Expire(ctx, attemptsKey, twoFactorAttemptsTTL)
+			}
+			if attempts > MaxTwoFactorAttempts {
+				return nil, errors.TooManyRequests("Too many two-factor attempts, please log in again")
+			}
+		}
+	}
+
+	userUUID, err := uuid.Parse(claims.UUID)
+	if err != nil {
+		return nil, errors.TokenInvalid()
+	}
+	user, err := u.repo.GetUserByUUID(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive() {
+		return nil, errors.AccountDisabled()
+	}
+
+	secret, err := u.enabledTwoFactorSecret(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.verifyTwoFactorCode(ctx, secret, req.Code); err != nil {
+		logger.Warn("Two-factor verification failed", zap.String("user_id", user.UUID.String()))
+		return nil, err
+	}
+
+	token, err := u.generateTokens(ctx, user)
+	if err != nil {
+		return nil, errors.WrapTokenError(err, "failed to generate tokens")
+	}
+
+	if err := u.repo.CreateUserToken(ctx, user.ID, token.AccessJti, token.RefreshJti); err != nil {
+		return nil, err
+	}
+
+	logger.Info("User logged in successfully", zap.String("user_id", user.UUID.String()), zap.Bool("two_factor", true))
+
+	return &AuthResponse{
+		User:         user,
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		ExpiresIn:    token.ExpiresIn,
+	}, nil
+}
+
+// twoFactorChallenge returns the step-up response of a password login when the
+// user has 2FA enabled, or nil when no second factor is needed
+func (u *userAuthUsecase) twoFactorChallenge(ctx context.Context, user *entity.User) (*AuthResponse, error) {
+	if u.twoFactor == nil {
+		return nil, nil
+	}
+
+	secret, err := u.getTwoFactorSecret(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !secret.IsEnabled() {
+		return nil, nil
+	}
+
+	email := ""
+	if user.Email != nil {
+		email = *user.Email
+	}
+	token, err := u.jwt.GenerateTwoFactorToken(user.UUID.String(), email)
+	if err != nil {
+		return nil, errors.WrapTokenError(err, "failed to generate two-factor token")
+	}
+
+	return &AuthResponse{TwoFactorRequired: true, TwoFactorToken: token}, nil
+}
+
+// getTwoFactorSecret returns the user's secret, or nil when 2FA was never set up
+func (u *userAuthUsecase) getTwoFactorSecret(ctx context.Context, userID int) (*entity.TwoFactorSecret, error) {
+	secret, err := u.repo.GetTwoFactorSecret(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WrapDatabase(err, "failed to get two-factor secret")
+	}
+	return secret, nil
+}
+
+func (u *userAuthUsecase) enabledTwoFactorSecret(ctx context.Context, userID int) (*entity.TwoFactorSecret, error) {
+	if u.twoFactor == nil {
+		return nil, errors.NotFound("Two-factor authentication is not enabled")
+	}
+
+	secret, err := u.getTwoFactorSecret(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !secret.IsEnabled() {
+		return nil, errors.BadRequest("Two-factor authentication is not enabled for this account")
+	}
+	return secret, nil
+}
+
+// verifyTwoFactorCode accepts a TOTP code or an unused recovery code, which is
+// then removed
+func (u *userAuthUsecase) verifyTwoFactorCode(ctx context.Context, secret *entity.TwoFactorSecret, code string) error {
+	if len(strings.ReplaceAll(code, " ", "")) == totp.Digits {
+		return u.verifyTOTP(ctx, secret, code)
+	}
+
+	if !useRecoveryCode(secret, code) {
+		return errors.Unauthorized("Invalid two-factor code")
+	}
+	if err := u.repo.SaveTwoFactorSecret(ctx, secret); err != nil {
+		return err
+	}
+
+	logger.Info("Recovery code used", zap.Int("user_id", secret.UserID), zap.Int("remaining", len(recoveryCodeHashes(secret))))
+	return nil
+}
+
+// verifyTOTP checks a TOTP code and records its step so it can't be used again
+func (u *userAuthUsecase) verifyTOTP(ctx context.Context, secret *entity.TwoFactorSecret, code string) error {
+	plain, err := u.twoFactor.secure.Decrypt(secret.Secret)
+	if err != nil {
+		return errors.WrapInternal(err, "failed to decrypt two-factor secret")
+	}
+
+	step, err := totp.Validate(plain, code, time.Now(), secret.LastUsedStep)
+	if err != nil {
+		return errors.WrapUnauthorized(err, "Invalid two-factor code")
+	}
+
+	used, err := u.repo.UseTwoFactorStep(ctx, secret.ID, step)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return errors.WrapUnauthorized(totp.ErrCodeReused, "Invalid two-factor code")
+	}
+	secret.LastUsedStep = step
+	return nil
+}
+
+// setRecoveryCodes generates new recovery codes and stores their hashes on secret
+func setRecoveryCodes(secret *entity.TwoFactorSecret) ([]string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, recoveryCodeByteCount)
+		if _, err := rand.Read(b); err != nil {
+			return nil, errors.WrapInternal(err, "failed to generate recovery codes")
+		}
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+		codes[i] = code[:4] + "-" + code[4:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	data, err := json.Marshal(hashes)
+	if err != nil {
+		return nil, errors.WrapInternal(err, "failed to store recovery codes")
+	}
+	secret.RecoveryCodes = string(data)
+	return codes, nil
+}
+
+// useRecoveryCode removes code from secret's recovery codes if present
+func useRecoveryCode(secret *entity.TwoFactorSecret, code string) bool {
+	hash := hashRecoveryCode(code)
+	hashes := recoveryCodeHashes(secret)
+	for i, stored := range hashes {
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(hash)) == 1 {
+			hashes = append(hashes[:i], hashes[i+1:]...)
+			data, _ := json.Marshal(hashes)
+			secret.RecoveryCodes = string(data)
+			return true
+		}
+	}
+	return false
+}
+
+func recoveryCodeHashes(secret *entity.TwoFactorSecret) []string {
+	var hashes []string
+	if secret.RecoveryCodes != "" {
+		_ = json.Unmarshal([]byte(secret.RecoveryCodes), &hashes)
+	}
+	return hashes
+}
+
+// hashRecoveryCode hashes a normalized recovery code. Codes are random, so a
+// fast hash is enough.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
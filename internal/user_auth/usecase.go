@@ -2,34 +2,86 @@ package user_auth
 
 import (
 	"context"
+	"encoding/json"
 	"flex-service/internal/entity"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
+	"flex-service/pkg/auth"
 	"flex-service/pkg/cache"
 	"flex-service/pkg/errors"
 	"flex-service/pkg/logger"
+	"flex-service/pkg/password"
 	"flex-service/pkg/utils"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
 	"go.uber.org/zap"
 )
 
 type userAuthUsecase struct {
-	repo  UserAuthRepository
-	jwt   *UserJWT
-	cache cache.Cache
+	repo      UserAuthRepository
+	jwt       *UserJWT
+	cache     cache.Cache
+	providers map[string]OAuthProvider
+	saml      *SAMLLogin
+	twoFactor *twoFactor
+	roles     auth.RoleResolver
+	lockout   *lockout
+	passwords *password.Policy
 }
 
-func NewUserAuthUsecase(repo UserAuthRepository, jwt *UserJWT, cache cache.Cache) UserAuthUsecase {
-	return &userAuthUsecase{
-		repo:  repo,
-		jwt:   jwt,
-		cache: cache,
+// UsecaseOption configures optional login methods of the auth usecase
+type UsecaseOption func(*userAuthUsecase)
+
+// WithOAuthProviders enables server-side OAuth login. Social login from
+// client-side tokens works without it.
+func WithOAuthProviders(providers ...OAuthProvider) UsecaseOption {
+	return func(u *userAuthUsecase) {
+		for _, provider := range providers {
+			u.providers[provider.Name()] = provider
+		}
+	}
+}
+
+// WithSAML enables SAML 2.0 single sign-on
+func WithSAML(saml *SAMLLogin) UsecaseOption {
+	return func(u *userAuthUsecase) {
+		u.saml = saml
+	}
+}
+
+// WithRoles fills the roles of authenticated principals, e.g. from rbac
+func WithRoles(roles auth.RoleResolver) UsecaseOption {
+	return func(u *userAuthUsecase) {
+		u.roles = roles
+	}
+}
+
+// WithPasswordPolicy checks new passwords at registration and reset
+func WithPasswordPolicy(policy *password.Policy) UsecaseOption {
+	return func(u *userAuthUsecase) {
+		u.passwords = policy
 	}
 }
 
+// NewUserAuthUsecase creates the auth usecase
+func NewUserAuthUsecase(repo UserAuthRepository, jwt *UserJWT, cache cache.Cache, opts ...UsecaseOption) UserAuthUsecase {
+	u := &userAuthUsecase{
+		repo:      repo,
+		jwt:       jwt,
+		cache:     cache,
+		providers: make(map[string]OAuthProvider),
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
+}
+
 func (u *userAuthUsecase) Register(ctx context.Context, req *entity.CreateUserRequest) (*AuthResponse, error) {
 	logger.Info("Register attempt", zap.String("email", req.Email), zap.String("username", req.Username))
 
@@ -37,7 +89,10 @@ func (u *userAuthUsecase) Register(ctx context.Context, req *entity.CreateUserRe
 		return nil, errors.UserExists("Username")
 	}
 
-	fmt.Println("req.Password", req.Password)
+	if err := u.checkPassword(ctx, req.Password, req.Username, req.Email); err != nil {
+		return nil, err
+	}
+
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
 		return nil, errors.WrapInternal(err, "failed to hash password")
@@ -96,8 +151,8 @@ func (u *userAuthUsecase) RegisterWithSocialAccount(ctx context.Context, req *Re
 	logger.Info("Register with social account attempt", zap.String("provider", req.Provider), zap.String("provider_id", req.ProviderID))
 
 	user, err := u.repo.GetUserBySocialAccount(ctx, req.Provider, req.ProviderID)
-	if err != nil {
-		return nil, err
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.WrapDatabase(err, "failed to get user by social account")
 	}
 
 	fmt.Println("user", user)
@@ -131,35 +186,35 @@ func (u *userAuthUsecase) RegisterWithSocialAccount(ctx context.Context, req *Re
 	}, nil
 }
 
-func (u *userAuthUsecase) GetUserProfile(ctx context.Context, userID int) (*entity.User, error) {
-	cacheKey := fmt.Sprintf("user:profile:%d", userID)
+// UserCacheTag groups every cached user projection, so FlushUserCache can drop
+// them all at once
+const UserCacheTag = "users"
 
-	if u.cache != nil {
-		// u.cache.Del(ctx, cacheKey)
-		var user entity.User
-		if err := u.cache.GetJSON(ctx, cacheKey, &user); err == nil {
-			return &user, nil
-		}
+func (u *userAuthUsecase) GetUserProfile(ctx context.Context, userID int) (*entity.User, error) {
+	if u.cache == nil {
+		return u.repo.GetUserByID(ctx, userID)
 	}
 
-	user, err := u.repo.GetUserByID(ctx, userID)
-	if err != nil {
-		return nil, err
-	}
+	cacheKey := fmt.Sprintf("user:profile:%d", userID)
+	return cache.Remember(ctx, u.cache.Tags(UserCacheTag), cacheKey, 30*time.Minute, func() (*entity.User, error) {
+		return u.repo.GetUserByID(ctx, userID)
+	})
+}
 
-	if u.cache != nil {
-		u.cache.SetJSON(ctx, cacheKey, user, 30*time.Minute)
+func (u *userAuthUsecase) InvalidateUserCache(ctx context.Context, userID int) error {
+	if u.cache == nil {
+		return nil
 	}
 
-	return user, nil
+	return u.cache.Del(ctx, fmt.Sprintf("user:profile:%d", userID))
 }
 
-func (u *userAuthUsecase) InvalidateUserCache(ctx context.Context, userID int) error {
+func (u *userAuthUsecase) FlushUserCache(ctx context.Context) error {
 	if u.cache == nil {
 		return nil
 	}
 
-	return u.cache.Del(ctx, fmt.Sprintf("user:profile:%d", userID))
+	return u.cache.Tags(UserCacheTag).Flush(ctx)
 }
 
 func (u *userAuthUsecase) Login(ctx context.Context, req *LoginRequest) (*AuthResponse, error) {
@@ -172,12 +227,31 @@ func (u *userAuthUsecase) Login(ctx context.Context, req *LoginRequest) (*AuthRe
 		return nil, errors.InvalidCredentials()
 	}
 
+	if err := u.checkLockout(ctx, user); err != nil {
+		return nil, err
+	}
+
 	if !user.IsActive() {
 		return nil, errors.AccountDisabled()
 	}
 
 	if !utils.VerifyPassword(req.Password, *user.Password) {
-		return nil, errors.InvalidCredentials()
+		return nil, u.loginFailed(ctx, user)
+	}
+	u.loginSucceeded(ctx, user)
+
+	if user.PasswordResetRequired {
+		return nil, errors.PasswordResetRequired()
+	}
+
+	// With 2FA enabled the password only earns a step-up token
+	challenge, err := u.twoFactorChallenge(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		logger.Info("Two-factor authentication required", zap.String("user_id", user.UUID.String()))
+		return challenge, nil
 	}
 
 	token, err := u.generateTokens(ctx, user)
@@ -304,6 +378,25 @@ func (u *userAuthUsecase) Logout(ctx context.Context, token string, userID int)
 	return nil
 }
 
+// checkPassword returns a VALIDATION_ERROR listing every password policy rule
+// the new password breaks; personal are the user's details it must not contain
+func (u *userAuthUsecase) checkPassword(ctx context.Context, newPassword string, personal ...string) error {
+	if u.passwords == nil {
+		return nil
+	}
+	if errs := u.passwords.Validate(ctx, newPassword, personal...); errs != nil {
+		return errors.Wrap(errs, errors.ErrValidation, "Validation failed", http.StatusBadRequest)
+	}
+	return nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
 func (u *userAuthUsecase) GetUserByID(ctx context.Context, userID int) (*entity.User, error) {
 	return u.repo.GetUserByID(ctx, userID)
 }
@@ -317,12 +410,17 @@ func (u *userAuthUsecase) generateTokens(ctx context.Context, user *entity.User)
 	accessJti := utils.GenerateUUID().String()
 	refreshJti := utils.GenerateUUID().String()
 
-	accessToken, accessJti, err := u.jwt.GenerateUserToken(user.UUID.String(), *user.Email, TokenTypeAccess, accessJti)
+	email := ""
+	if user.Email != nil {
+		email = *user.Email
+	}
+
+	accessToken, accessJti, err := u.jwt.GenerateUserToken(user.UUID.String(), email, TokenTypeAccess, accessJti)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, refreshJti, err := u.jwt.GenerateUserToken(user.UUID.String(), *user.Email, TokenTypeRefresh, refreshJti)
+	refreshToken, refreshJti, err := u.jwt.GenerateUserToken(user.UUID.String(), email, TokenTypeRefresh, refreshJti)
 	if err != nil {
 		return nil, err
 	}
@@ -349,22 +447,292 @@ func (u *userAuthUsecase) ValidateToken(ctx context.Context, token string) (*Val
 		return nil, errors.TokenInvalid()
 	}
 
+	// Scoped tokens are only accepted through ValidateScopedToken
+	if claims.TokenType != TokenTypeAccess {
+		return nil, errors.TokenInvalid()
+	}
+
 	accessJti := claims.ID
 	if err != nil {
 		return nil, errors.TokenInvalid()
 	}
 
+	// Sessions revoked by an admin or a password reset
+	if u.sessionRevoked(ctx, accessJti) {
+		return nil, errors.TokenInvalid()
+	}
+
 	userToken, err := u.repo.GetUserTokenByAccessJti(ctx, accessJti)
 	if err != nil {
 		return nil, errors.TokenInvalid()
 	}
+	u.touchSession(ctx, userToken)
+
+	return u.validated(ctx, &userToken.User, claims)
+}
+
+// ValidateScopedToken accepts session access tokens and scoped tokens. A scoped
+// token is only valid while the session it came from is, so logging out revokes it.
+func (u *userAuthUsecase) ValidateScopedToken(ctx context.Context, token string) (*ValidateTokenResponse, error) {
+	claims, err := u.jwt.ValidateUserToken(token)
+	if err != nil {
+		return nil, errors.TokenInvalid()
+	}
 
-	return &ValidateTokenResponse{
-		User:       &userToken.User,
+	switch claims.TokenType {
+	case TokenTypeAccess:
+		return u.ValidateToken(ctx, token)
+	case TokenTypeScoped:
+	default:
+		return nil, errors.TokenInvalid()
+	}
+
+	if claims.SessionID == "" || len(claims.Scopes()) == 0 || u.sessionRevoked(ctx, claims.SessionID) {
+		return nil, errors.TokenInvalid()
+	}
+
+	session, err := u.repo.GetUserTokenByAccessJti(ctx, claims.SessionID)
+	if err != nil {
+		return nil, errors.TokenInvalid()
+	}
+	if session.User.UUID.String() != claims.UUID {
+		return nil, errors.TokenInvalid()
+	}
+
+	return u.validated(ctx, &session.User, claims)
+}
+
+// validated builds the validation result, resolving the user's roles when a
+// role resolver is configured
+func (u *userAuthUsecase) validated(ctx context.Context, user *entity.User, claims *UserClaims) (*ValidateTokenResponse, error) {
+	result := &ValidateTokenResponse{
+		User:       user,
 		UserClaims: claims,
+	}
+	if u.roles != nil {
+		roles, err := u.roles.UserRoles(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		result.Roles = roles
+	}
+	return result, nil
+}
+
+// IssueScopedToken derives a short-lived token carrying only scopes from
+// subjectToken. A scoped subject can only be narrowed further, never widened,
+// and the new token never outlives it.
+func (u *userAuthUsecase) IssueScopedToken(ctx context.Context, subjectToken string, scopes []string, audience string, ttl time.Duration) (*TokenExchangeResponse, error) {
+	scopes = uniqueScopes(scopes)
+	if len(scopes) == 0 {
+		return nil, errors.BadRequest("At least one scope is required")
+	}
+
+	subject, err := u.ValidateScopedToken(ctx, subjectToken)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID := subject.UserClaims.ID
+	if subject.UserClaims.TokenType == TokenTypeScoped {
+		sessionID = subject.UserClaims.SessionID
+
+		granted := subject.UserClaims.Scopes()
+		for _, scope := range scopes {
+			if !scopeCovered(granted, scope) {
+				return nil, errors.Forbidden(fmt.Sprintf("Scope %q exceeds the subject token", scope))
+			}
+		}
+	}
+
+	// Never outlive the subject token
+	if ttl <= 0 {
+		ttl = u.jwt.scopedTokenTTL
+	}
+	if exp := subject.UserClaims.ExpiresAt; exp != nil {
+		if remaining := time.Until(exp.Time); ttl > remaining {
+			ttl = remaining
+		}
+	}
+
+	token, ttl, err := u.jwt.GenerateScopedToken(subject.User.UUID.String(), subject.UserClaims.Email, sessionID, scopes, audience, ttl)
+	if err != nil {
+		return nil, errors.WrapTokenError(err, "failed to generate scoped token")
+	}
+
+	logger.Info("Scoped token issued",
+		zap.Int("user_id", subject.User.ID),
+		zap.Strings("scopes", scopes),
+		zap.Duration("ttl", ttl))
+
+	return &TokenExchangeResponse{
+		AccessToken:     token,
+		IssuedTokenType: TokenTypeURIAccess,
+		TokenType:       "Bearer",
+		ExpiresIn:       int64(ttl.Seconds()),
+		Scope:           strings.Join(scopes, " "),
+	}, nil
+}
+
+// ExchangeToken implements the token exchange grant (RFC 8693) for scoped tokens
+func (u *userAuthUsecase) ExchangeToken(ctx context.Context, req *TokenExchangeRequest) (*TokenExchangeResponse, error) {
+	if req.GrantType != GrantTypeTokenExchange {
+		return nil, errors.BadRequest("Unsupported grant_type")
+	}
+	if req.SubjectTokenType != TokenTypeURIAccess && req.SubjectTokenType != TokenTypeURIJWT {
+		return nil, errors.BadRequest("Unsupported subject_token_type")
+	}
+	if req.RequestedTokenType != "" && req.RequestedTokenType != TokenTypeURIAccess {
+		return nil, errors.BadRequest("Unsupported requested_token_type")
+	}
+
+	return u.IssueScopedToken(ctx, req.SubjectToken, strings.Fields(req.Scope), req.Audience, time.Duration(req.ExpiresIn)*time.Second)
+}
+
+// scopeCovered reports whether a granted scope covers scope, including wildcards
+func scopeCovered(granted []string, scope string) bool {
+	for _, g := range granted {
+		if auth.Match(g, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueScopes(scopes []string) []string {
+	seen := make(map[string]bool, len(scopes))
+	unique := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if scope != "" && !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+	return unique
+}
+
+// OAuthRedirect starts a server-side OAuth login. The state and PKCE verifier are
+// kept in the cache until the provider redirects back to OAuthCallback.
+func (u *userAuthUsecase) OAuthRedirect(ctx context.Context, providerName string) (*OAuthRedirect, error) {
+	provider, ok := u.providers[providerName]
+	if !ok {
+		return nil, errors.NotFound("OAuth provider not supported")
+	}
+	if u.cache == nil {
+		return nil, errors.Internal("OAuth login requires a cache")
+	}
+
+	state, err := randomToken(32)
+	if err != nil {
+		return nil, errors.WrapInternal(err, "failed to generate OAuth state")
+	}
+	verifier, challenge, err := newPKCE()
+	if err != nil {
+		return nil, errors.WrapInternal(err, "failed to generate PKCE verifier")
+	}
+
+	if err := u.cache.SetJSON(ctx, oauthStateKey(state), oauthState{Provider: providerName, CodeVerifier: verifier}, OAuthStateTTL); err != nil {
+		return nil, errors.WrapInternal(err, "failed to store OAuth state")
+	}
+
+	return &OAuthRedirect{
+		URL:   provider.AuthCodeURL(state, challenge),
+		State: state,
 	}, nil
 }
 
+// OAuthCallback finishes an OAuth login: it checks the state, exchanges the code,
+// then logs the user in, registering the social account on first login
+func (u *userAuthUsecase) OAuthCallback(ctx context.Context, req *OAuthCallbackRequest) (*AuthResponse, error) {
+	logger.Info("OAuth callback", zap.String("provider", req.Provider))
+
+	provider, ok := u.providers[req.Provider]
+	if !ok {
+		return nil, errors.NotFound("OAuth provider not supported")
+	}
+	if req.Error != "" {
+		return nil, errors.Unauthorized(fmt.Sprintf("OAuth login failed: %s", req.Error))
+	}
+	if u.cache == nil {
+		return nil, errors.Internal("OAuth login requires a cache")
+	}
+
+	// The state is single-use
+	var state oauthState
+	if err := u.cache.GetJSON(ctx, oauthStateKey(req.State), &state); err != nil {
+		return nil, errors.BadRequest("Invalid or expired OAuth state")
+	}
+	_ = u.cache.Del(ctx, oauthStateKey(req.State))
+	if state.Provider != req.Provider {
+		return nil, errors.BadRequest("Invalid or expired OAuth state")
+	}
+	if req.Code == "" {
+		return nil, errors.BadRequest("Missing authorization code")
+	}
+
+	identity, err := provider.Exchange(ctx, req.Code, state.CodeVerifier)
+	if err != nil {
+		logger.Warn("OAuth code exchange failed", zap.String("provider", req.Provider), zap.Error(err))
+		return nil, errors.WrapUnauthorized(err, "OAuth login failed")
+	}
+	if identity.FirstName == "" && req.User != "" {
+		applyAppleUser(identity, req.User)
+	}
+
+	return u.loginWithIdentity(ctx, identity)
+}
+
+// loginWithIdentity logs in the user linked to an external identity, registering
+// the social account on first login. The stored provider data is refreshed on
+// every login so mapped attributes such as SAML roles stay current.
+func (u *userAuthUsecase) loginWithIdentity(ctx context.Context, identity *OAuthIdentity) (*AuthResponse, error) {
+	_, err := u.repo.GetUserBySocialAccount(ctx, identity.Provider, identity.ProviderID)
+	switch {
+	case err == nil:
+		if len(identity.Raw) > 0 {
+			if err := u.repo.UpdateSocialAccountData(ctx, identity.Provider, identity.ProviderID, string(identity.Raw)); err != nil {
+				return nil, err
+			}
+		}
+		return u.LoginWithSocialAccount(ctx, &LoginWithSocialAccountRequest{
+			Provider:   identity.Provider,
+			ProviderID: identity.ProviderID,
+		})
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, errors.WrapDatabase(err, "failed to get user by social account")
+	}
+
+	// Accounts are not linked by email: the existing owner must link them while signed in
+	if identity.Email != "" {
+		if _, err := u.repo.GetUserByEmail(ctx, identity.Email); err == nil {
+			return nil, errors.UserExists("Email")
+		}
+	}
+
+	return u.RegisterWithSocialAccount(ctx, &RegisterWithSocialAccountRequest{
+		Provider:     identity.Provider,
+		ProviderID:   identity.ProviderID,
+		ProviderData: string(identity.Raw),
+		FirstName:    identity.FirstName,
+		LastName:     identity.LastName,
+		Email:        identity.Email,
+	})
+}
+
+// applyAppleUser reads the name Apple posts with the first callback
+func applyAppleUser(identity *OAuthIdentity, user string) {
+	var payload struct {
+		Name struct {
+			FirstName string `json:"firstName"`
+			LastName  string `json:"lastName"`
+		} `json:"name"`
+	}
+	if json.Unmarshal([]byte(user), &payload) == nil {
+		identity.FirstName = payload.Name.FirstName
+		identity.LastName = payload.Name.LastName
+	}
+}
+
 func GenerateMemberNo() (string, error) {
 	randomString, err := utils.GenerateRandomString(12)
 	if err != nil {
@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Redacted replaces the value of sensitive fields in before/after snapshots
+const Redacted = "[REDACTED]"
+
+// sensitiveFields are matched case-insensitively as substrings of a key
+var sensitiveFields = []string{"password", "secret", "token"}
+
+// Change is the old and new value of one field
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Diff returns the top-level fields that differ between the JSON forms of
+// before and after. Either side may be nil, for creates and deletes.
+func Diff(before, after interface{}) (map[string]Change, error) {
+	from, err := toMap(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := toMap(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Change)
+	for key, value := range from {
+		if other, ok := to[key]; !ok || !reflect.DeepEqual(value, other) {
+			changes[key] = Change{From: value, To: to[key]}
+		}
+	}
+	for key, value := range to {
+		if _, ok := from[key]; !ok {
+			changes[key] = Change{To: value}
+		}
+	}
+	return changes, nil
+}
+
+// toMap returns the redacted JSON object form of v; nil and non-object
+// values give an empty map
+func toMap(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return map[string]interface{}{}, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, err
+	}
+	object, ok := redact(decoded).(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{}, nil
+	}
+	return object, nil
+}
+
+// redact replaces sensitive fields at any depth
+func redact(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, inner := range value {
+			if sensitive(key) {
+				value[key] = Redacted
+			} else {
+				value[key] = redact(inner)
+			}
+		}
+	case []interface{}:
+		for i, inner := range value {
+			value[i] = redact(inner)
+		}
+	}
+	return v
+}
+
+func sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, field := range sensitiveFields {
+		if strings.Contains(key, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshot returns the redacted JSON of v, or "" for nil
+func snapshot(v interface{}) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return "", err
+	}
+	raw, err = json.Marshal(redact(decoded))
+	return string(raw), err
+}
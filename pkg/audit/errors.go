@@ -0,0 +1,9 @@
+package audit
+
+import "errors"
+
+// Audit errors
+var (
+	ErrMissingAction = errors.New("audit action is required")
+	ErrChainBroken   = errors.New("audit log hash chain is broken")
+)
@@ -0,0 +1,90 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Actor types; user and service come from the request principal
+const (
+	ActorUser    = "user"
+	ActorService = "service"
+	ActorSystem  = "system" // no principal: jobs, commands, unauthenticated requests
+)
+
+// Event is what callers record
+type Event struct {
+	Action       string // e.g. "user.sessions.revoked"
+	ResourceType string
+	ResourceID   string
+	// Before and After are marshalled to JSON; their top-level differences are
+	// stored as the entry's changes
+	Before   interface{}
+	After    interface{}
+	Metadata map[string]interface{}
+}
+
+// Entry is one row of the audit log. Entries form a hash chain: Hash covers
+// the entry and PrevHash, the hash of the entry before it, so editing or
+// deleting a row breaks the chain from there on (see Verify).
+type Entry struct {
+	ID           int64     `json:"id" gorm:"primaryKey"`
+	ActorType    string    `json:"actor_type" gorm:"type:varchar(20);not null;index:idx_audit_log_actor"`
+	ActorID      string    `json:"actor_id" gorm:"type:varchar(64);not null;index:idx_audit_log_actor"`
+	ActorEmail   string    `json:"actor_email,omitempty" gorm:"type:varchar(255)"`
+	Action       string    `json:"action" gorm:"type:varchar(255);not null;index"`
+	ResourceType string    `json:"resource_type,omitempty" gorm:"type:varchar(100);index:idx_audit_log_resource"`
+	ResourceID   string    `json:"resource_id,omitempty" gorm:"type:varchar(64);index:idx_audit_log_resource"`
+	Before       string    `json:"before,omitempty" gorm:"type:text"`   // JSON
+	After        string    `json:"after,omitempty" gorm:"type:text"`    // JSON
+	Changes      string    `json:"changes,omitempty" gorm:"type:text"`  // JSON: {"field": {"from": ..., "to": ...}}
+	Metadata     string    `json:"metadata,omitempty" gorm:"type:text"` // JSON
+	IP           string    `json:"ip,omitempty" gorm:"type:varchar(45)"`
+	UserAgent    string    `json:"user_agent,omitempty" gorm:"type:varchar(255)"`
+	RequestID    string    `json:"request_id,omitempty" gorm:"type:varchar(64);index"`
+	CreatedAt    time.Time `json:"created_at" gorm:"not null;precision:6;index"`
+	PrevHash     string    `json:"prev_hash" gorm:"type:varchar(64);not null;uniqueIndex"`
+	Hash         string    `json:"hash" gorm:"type:varchar(64);not null"`
+}
+
+// TableName returns the table name for GORM
+func (Entry) TableName() string {
+	return "tb_audit_log"
+}
+
+// ComputeHash returns the hash of the entry's content and PrevHash
+func (e *Entry) ComputeHash() string {
+	// A struct keeps the field order, and so the hash, stable
+	content, _ := json.Marshal(struct {
+		PrevHash     string `json:"prev_hash"`
+		ActorType    string `json:"actor_type"`
+		ActorID      string `json:"actor_id"`
+		ActorEmail   string `json:"actor_email"`
+		Action       string `json:"action"`
+		ResourceType string `json:"resource_type"`
+		ResourceID   string `json:"resource_id"`
+		Before       string `json:"before"`
+		After        string `json:"after"`
+		Changes      string `json:"changes"`
+		Metadata     string `json:"metadata"`
+		IP           string `json:"ip"`
+		UserAgent    string `json:"user_agent"`
+		RequestID    string `json:"request_id"`
+		CreatedAt    int64  `json:"created_at"`
+	}{
+		e.PrevHash, e.ActorType, e.ActorID, e.ActorEmail, e.Action, e.ResourceType, e.ResourceID,
+		e.Before, e.After, e.Changes, e.Metadata, e.IP, e.UserAgent, e.RequestID,
+		e.CreatedAt.UnixMicro(),
+	})
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Store appends entries to the chain
+type Store interface {
+	// Append sets the entry's PrevHash and Hash and stores it as the newest entry
+	Append(ctx context.Context, entry *Entry) error
+}
@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"flex-service/pkg/logger"
+	"flex-service/pkg/queue"
+
+	"go.uber.org/zap"
+)
+
+// entryPayload stores an entry under "entry" as plain JSON values
+func entryPayload(entry *Entry) (map[string]interface{}, error) {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode queued audit entry: %w", err)
+	}
+
+	var encoded map[string]interface{}
+	if err := json.Unmarshal(raw, &encoded); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"entry": encoded}, nil
+}
+
+// entryFromPayload decodes the entry of a queued audit job
+func entryFromPayload(payload map[string]interface{}) (*Entry, error) {
+	value, ok := payload["entry"]
+	if !ok {
+		return nil, fmt.Errorf("audit job has no entry")
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, fmt.Errorf("failed to decode queued audit entry: %w", err)
+	}
+	return &entry, nil
+}
+
+// JobHandler appends entries queued by a recorder with WithQueue:
+//
+//	worker.RegisterHandler(queue.JobTypeAudit, audit.JobHandler(container.Audit))
+//
+// Entries are chained in the order workers append them, which may differ
+// slightly from their CreatedAt order.
+func JobHandler(r *Recorder) queue.Handler {
+	return queue.HandlerFunc(func(ctx context.Context, job *queue.Job) *queue.JobResult {
+		entry, err := entryFromPayload(job.Payload)
+		if err != nil {
+			return &queue.JobResult{Success: false, Error: err.Error()}
+		}
+
+		if err := r.store.Append(ctx, entry); err != nil {
+			logger.Error("Queued audit entry failed",
+				zap.String("job_id", job.ID),
+				zap.String("action", entry.Action),
+				zap.Error(err))
+			return &queue.JobResult{Success: false, Error: err.Error()}
+		}
+
+		return &queue.JobResult{
+			Success: true,
+			Data: map[string]interface{}{
+				"id":     entry.ID,
+				"action": entry.Action,
+			},
+		}
+	})
+}
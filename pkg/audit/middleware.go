@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"flex-service/pkg/logger"
+	"flex-service/pkg/requestctx"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RequestIDHeader carries the request ID from clients and proxies
+const RequestIDHeader = "X-Request-ID"
+
+// DefaultMethods are the methods Middleware records by default
+var DefaultMethods = []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+type requestInfoKey struct{}
+
+type requestInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// WithRequestInfo stores the client IP and user agent for entries recorded
+// with ctx
+func WithRequestInfo(ctx context.Context, ip, userAgent string) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, requestInfo{IP: ip, UserAgent: userAgent})
+}
+
+func requestInfoFrom(ctx context.Context) (requestInfo, bool) {
+	info, ok := ctx.Value(requestInfoKey{}).(requestInfo)
+	return info, ok
+}
+
+// Middleware makes the client IP, user agent and X-Request-ID available to
+// Record for the rest of the request, and records every successful request
+// with one of methods (DefaultMethods if none) as "<METHOD> <route>". Mount
+// it before authentication; the principal is read once the handler is done.
+func (r *Recorder) Middleware(methods ...string) gin.HandlerFunc {
+	if len(methods) == 0 {
+		methods = DefaultMethods
+	}
+	recorded := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		recorded[strings.ToUpper(method)] = true
+	}
+
+	return func(c *gin.Context) {
+		ctx := WithRequestInfo(c.Request.Context(), c.ClientIP(), c.Request.UserAgent())
+		if requestctx.RequestID(ctx) == "" {
+			if requestID := c.GetHeader(RequestIDHeader); requestID != "" {
+				ctx = requestctx.WithRequestID(ctx, truncate(requestID, 64))
+			}
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		route := c.FullPath()
+		status := c.Writer.Status()
+		if !recorded[c.Request.Method] || route == "" || status >= http.StatusBadRequest {
+			return
+		}
+
+		event := Event{
+			Action:       c.Request.Method + " " + route,
+			ResourceType: route,
+			ResourceID:   c.Param("id"),
+			Metadata: map[string]interface{}{
+				"path":   c.Request.URL.Path,
+				"status": status,
+			},
+		}
+		if err := r.Record(c.Request.Context(), event); err != nil {
+			logger.Error("Failed to record audit entry",
+				zap.String("action", event.Action),
+				zap.Error(err))
+		}
+	}
+}
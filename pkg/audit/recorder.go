@@ -0,0 +1,153 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"flex-service/pkg/logger"
+	"flex-service/pkg/queue"
+	"flex-service/pkg/requestctx"
+
+	"go.uber.org/zap"
+)
+
+// Recorder turns events into entries, filling in who did it and from where
+// from the request context, and appends them directly or through the queue
+type Recorder struct {
+	store      Store
+	dispatcher *queue.JobDispatcher
+}
+
+// NewRecorder creates a recorder that appends to store
+func NewRecorder(store Store) *Recorder {
+	return &Recorder{store: store}
+}
+
+// WithQueue appends entries from a queue worker instead of the request.
+// Register JobHandler for queue.JobTypeAudit on the worker.
+func (r *Recorder) WithQueue(dispatcher *queue.JobDispatcher) *Recorder {
+	r.dispatcher = dispatcher
+	return r
+}
+
+// Record appends an entry for event. With a queue, a failed dispatch falls
+// back to appending directly, so entries aren't lost while Redis is down.
+func (r *Recorder) Record(ctx context.Context, event Event) error {
+	entry, err := r.entry(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	if r.dispatcher != nil {
+		payload, err := entryPayload(entry)
+		if err == nil {
+			if err = r.dispatcher.Dispatch(queue.JobTypeAudit, payload); err == nil {
+				return nil
+			}
+		}
+		logger.Warn("Failed to queue audit entry, writing it directly",
+			zap.String("action", entry.Action),
+			zap.Error(err))
+	}
+
+	return r.store.Append(ctx, entry)
+}
+
+// entry builds the entry for event without PrevHash and Hash, which the
+// store sets when appending
+func (r *Recorder) entry(ctx context.Context, event Event) (*Entry, error) {
+	if event.Action == "" {
+		return nil, ErrMissingAction
+	}
+
+	entry := &Entry{
+		ActorType:    ActorSystem,
+		ActorID:      ActorSystem,
+		Action:       event.Action,
+		ResourceType: event.ResourceType,
+		ResourceID:   event.ResourceID,
+		RequestID:    requestctx.RequestID(ctx),
+		// The hash covers microseconds, which every supported database keeps
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if entry.RequestID == "" {
+		entry.RequestID = requestctx.TraceID(ctx)
+	}
+
+	if principal, ok := requestctx.PrincipalFrom(ctx); ok {
+		entry.ActorType = principal.Type
+		entry.ActorID = principal.Subject
+		if entry.ActorID == "" {
+			entry.ActorID = strconv.Itoa(principal.ID)
+		}
+		entry.ActorEmail = principal.Email
+	}
+
+	if info, ok := requestInfoFrom(ctx); ok {
+		entry.IP = info.IP
+		entry.UserAgent = truncate(info.UserAgent, 255)
+	}
+
+	var err error
+	if entry.Before, err = snapshot(event.Before); err != nil {
+		return nil, fmt.Errorf("failed to encode audit before: %w", err)
+	}
+	if entry.After, err = snapshot(event.After); err != nil {
+		return nil, fmt.Errorf("failed to encode audit after: %w", err)
+	}
+	if event.Before != nil || event.After != nil {
+		changes, err := Diff(event.Before, event.After)
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff audit snapshots: %w", err)
+		}
+		if len(changes) > 0 {
+			raw, _ := json.Marshal(changes)
+			entry.Changes = string(raw)
+		}
+	}
+	if len(event.Metadata) > 0 {
+		if entry.Metadata, err = snapshot(event.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to encode audit metadata: %w", err)
+		}
+	}
+
+	return entry, nil
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
+
+// defaultRecorder is used by the package-level Record
+var defaultRecorder atomic.Pointer[Recorder]
+
+// SetDefault sets the recorder used by Record
+func SetDefault(r *Recorder) {
+	defaultRecorder.Store(r)
+}
+
+// Default returns the recorder used by Record, or nil
+func Default() *Recorder {
+	return defaultRecorder.Load()
+}
+
+// Record appends an entry with the default recorder, so usecases can audit
+// without a recorder dependency:
+//
+//	audit.Record(ctx, audit.Event{Action: "user.password.reset", ResourceType: "user", ResourceID: id})
+//
+// It does nothing when no default is set, e.g. with auditing disabled.
+func Record(ctx context.Context, event Event) error {
+	r := Default()
+	if r == nil {
+		return nil
+	}
+	return r.Record(ctx, event)
+}
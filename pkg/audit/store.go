@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// maxAppendAttempts bounds retries when other instances append concurrently
+const maxAppendAttempts = 5
+
+// GormStore keeps the audit log in tb_audit_log. The unique index on
+// prev_hash keeps the chain linear when several instances append at once:
+// only one entry can follow a given hash, and the loser retries.
+type GormStore struct {
+	db *gorm.DB
+	mu sync.Mutex // serializes appends within this instance
+}
+
+// NewGormStore creates a store on db
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+// Append links the entry to the newest one and stores it
+func (s *GormStore) Append(ctx context.Context, entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	db := s.db.WithContext(ctx)
+	var err error
+	for attempt := 0; attempt < maxAppendAttempts; attempt++ {
+		prev, lastErr := s.lastHash(db)
+		if lastErr != nil {
+			return lastErr
+		}
+
+		entry.ID = 0
+		entry.PrevHash = prev
+		entry.Hash = entry.ComputeHash()
+		if err = db.Create(entry).Error; err == nil {
+			return nil
+		}
+
+		// Retry only if another instance appended in the meantime
+		current, lastErr := s.lastHash(db)
+		if lastErr != nil || current == prev {
+			break
+		}
+	}
+	return fmt.Errorf("failed to append audit entry: %w", err)
+}
+
+func (s *GormStore) lastHash(db *gorm.DB) (string, error) {
+	var hashes []string
+	if err := db.Model(&Entry{}).Order("id DESC").Limit(1).Pluck("hash", &hashes).Error; err != nil {
+		return "", fmt.Errorf("failed to read the last audit entry: %w", err)
+	}
+	if len(hashes) == 0 {
+		return "", nil
+	}
+	return hashes[0], nil
+}
+
+// VerifyResult is the outcome of Verify
+type VerifyResult struct {
+	Checked  int64  `json:"checked"`
+	LastHash string `json:"last_hash"`           // keep it elsewhere to detect truncation later
+	BrokenAt int64  `json:"broken_at,omitempty"` // ID of the first entry that doesn't match
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Verify walks the chain in ID order and recomputes every hash. It returns
+// ErrChainBroken with the first entry that was edited, or whose predecessor
+// was deleted. Removing the newest entries can only be caught by comparing
+// LastHash with a copy kept outside the database.
+func Verify(ctx context.Context, db *gorm.DB) (*VerifyResult, error) {
+	const batchSize = 500
+
+	result := &VerifyResult{}
+	var lastID int64
+	for {
+		var entries []Entry
+		if err := db.WithContext(ctx).Where("id > ?", lastID).Order("id ASC").Limit(batchSize).Find(&entries).Error; err != nil {
+			return result, fmt.Errorf("failed to read audit entries: %w", err)
+		}
+
+		for i := range entries {
+			entry := &entries[i]
+			switch {
+			case entry.PrevHash != result.LastHash:
+				result.BrokenAt, result.Reason = entry.ID, "previous entry is missing or was changed"
+			case entry.ComputeHash() != entry.Hash:
+				result.BrokenAt, result.Reason = entry.ID, "entry was changed"
+			}
+			if result.BrokenAt != 0 {
+				return result, fmt.Errorf("%w at entry %d: %s", ErrChainBroken, result.BrokenAt, result.Reason)
+			}
+			result.Checked++
+			result.LastHash = entry.Hash
+			lastID = entry.ID
+		}
+
+		if len(entries) < batchSize {
+			return result, nil
+		}
+	}
+}
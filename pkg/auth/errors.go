@@ -0,0 +1,14 @@
+package auth
+
+import "errors"
+
+// Auth errors
+var (
+	ErrInvalidRole   = errors.New("invalid role")
+	ErrRoleCycle     = errors.New("role inheritance cycle")
+	ErrUnknownParent = errors.New("role inherits an unknown role")
+
+	ErrNoSigningKey         = errors.New("no signing key")
+	ErrUnknownKey           = errors.New("unknown signing key")
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+)
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"net/http"
+
+	"flex-service/pkg/requestctx"
+	"flex-service/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Require returns middleware that responds 403 unless the principal holds every
+// permission. Ownership grants don't apply here; check those in the handler with Can.
+func (pc *PermissionChecker) Require(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, exists := requestctx.CurrentPrincipal(c)
+		if !exists {
+			response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "Not authenticated", nil)
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !pc.Can(principal, permission, nil) {
+				response.Error(c, http.StatusForbidden, "FORBIDDEN", "Not allowed to perform this action", nil)
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequireRole returns middleware that responds 403 unless the principal has the
+// role, directly or through inheritance
+func (pc *PermissionChecker) RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, exists := requestctx.CurrentPrincipal(c)
+		if !exists {
+			response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "Not authenticated", nil)
+			c.Abort()
+			return
+		}
+
+		if !pc.HasRole(principal, role) {
+			response.Error(c, http.StatusForbidden, "FORBIDDEN", "Not allowed to perform this action", nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// JWKSHandler serves the public keys of the set as a JSON Web Key Set, for
+// /.well-known/jwks.json. Verifiers may cache it for five minutes, so new keys
+// should exist that long before they sign.
+func (ks *KeySet) JWKSHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, ks.JWKS())
+	}
+}
@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Asymmetric JWT algorithms supported by KeySet
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// SigningKey is a private key used to sign JWTs. Its ID is sent as the kid
+// header so verifiers pick the matching public key from the JWKS.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	Key       crypto.Signer
+	CreatedAt time.Time
+}
+
+// GenerateSigningKey creates a new RS256 (RSA 2048) or EdDSA (Ed25519) key
+func GenerateSigningKey(algorithm string) (*SigningKey, error) {
+	var (
+		key crypto.Signer
+		err error
+	)
+	switch algorithm {
+	case AlgorithmRS256:
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgorithmEdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %s key: %w", algorithm, err)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	return &SigningKey{
+		ID:        now.Format("20060102T150405") + "-" + hex.EncodeToString(suffix),
+		Algorithm: algorithm,
+		Key:       key,
+		CreatedAt: now,
+	}, nil
+}
+
+// algorithmOf returns the JWT algorithm for a private key
+func algorithmOf(key crypto.Signer) (string, error) {
+	switch key.(type) {
+	case *rsa.PrivateKey:
+		return AlgorithmRS256, nil
+	case ed25519.PrivateKey:
+		return AlgorithmEdDSA, nil
+	}
+	return "", fmt.Errorf("%w: %T", ErrUnsupportedAlgorithm, key)
+}
+
+func (k *SigningKey) method() jwt.SigningMethod {
+	switch k.Algorithm {
+	case AlgorithmRS256:
+		return jwt.SigningMethodRS256
+	case AlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA
+	}
+	return nil
+}
+
+// KeySet holds the keys tokens are signed and verified with. The newest key
+// signs; older keys only verify, so tokens issued before a rotation stay valid
+// until their keys are removed.
+type KeySet struct {
+	mu   sync.RWMutex
+	keys map[string]*SigningKey
+}
+
+// NewKeySet creates a key set from keys
+func NewKeySet(keys ...*SigningKey) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*SigningKey)}
+	if err := ks.Replace(keys); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Replace swaps every key in the set, e.g. after reloading a KeyStore
+func (ks *KeySet) Replace(keys []*SigningKey) error {
+	loaded := make(map[string]*SigningKey, len(keys))
+	for _, key := range keys {
+		if key == nil || key.ID == "" || key.Key == nil {
+			return fmt.Errorf("%w: key ID and private key are required", ErrUnknownKey)
+		}
+		if key.method() == nil {
+			return fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, key.Algorithm)
+		}
+		if alg, err := algorithmOf(key.Key); err != nil || alg != key.Algorithm {
+			return fmt.Errorf("%w: key %s is not a %s key", ErrUnsupportedAlgorithm, key.ID, key.Algorithm)
+		}
+		loaded[key.ID] = key
+	}
+
+	ks.mu.Lock()
+	ks.keys = loaded
+	ks.mu.Unlock()
+	return nil
+}
+
+// Keys returns every key, newest first
+func (ks *KeySet) Keys() []*SigningKey {
+	ks.mu.RLock()
+	keys := make([]*SigningKey, 0, len(ks.keys))
+	for _, key := range ks.keys {
+		keys = append(keys, key)
+	}
+	ks.mu.RUnlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.After(keys[j].CreatedAt)
+		}
+		return keys[i].ID > keys[j].ID
+	})
+	return keys
+}
+
+// Active returns the key new tokens are signed with: the newest one
+func (ks *KeySet) Active() (*SigningKey, error) {
+	keys := ks.Keys()
+	if len(keys) == 0 {
+		return nil, ErrNoSigningKey
+	}
+	return keys[0], nil
+}
+
+// Sign signs claims with the active key and sets the kid header
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	key, err := ks.Active()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Key)
+}
+
+// Keyfunc is a jwt.Keyfunc that returns the public key named by the token's
+// kid header, rejecting tokens whose alg doesn't match the key
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	ks.mu.RLock()
+	key, ok := ks.keys[kid]
+	ks.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.Key.Public(), nil
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set, newest first
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range ks.Keys() {
+		jwk := JWK{Use: "sig", Alg: key.Algorithm, Kid: key.ID}
+		switch public := key.Key.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
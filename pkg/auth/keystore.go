@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"flex-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// KeyStore persists signing keys so every instance signs and verifies with the same set
+type KeyStore interface {
+	Load(ctx context.Context) ([]*SigningKey, error)
+	Save(ctx context.Context, key *SigningKey) error
+	Delete(ctx context.Context, id string) error
+}
+
+// DirKeyStore keeps each key as <kid>.pem (PKCS#8) in a directory. Keys created
+// with openssl work too; their file modification time is used as CreatedAt.
+type DirKeyStore struct {
+	Dir string
+}
+
+// NewDirKeyStore creates a store in dir, creating the directory if needed
+func NewDirKeyStore(dir string) (*DirKeyStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+	return &DirKeyStore{Dir: dir}, nil
+}
+
+const createdAtHeader = "Created-At"
+
+// Load reads every .pem file in the directory
+func (s *DirKeyStore) Load(ctx context.Context) ([]*SigningKey, error) {
+	paths, err := filepath.Glob(filepath.Join(s.Dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*SigningKey, 0, len(paths))
+	for _, path := range paths {
+		key, err := readKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Save writes the key as <kid>.pem, readable only by the owner
+func (s *DirKeyStore) Save(ctx context.Context, key *SigningKey) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key.Key)
+	if err != nil {
+		return fmt.Errorf("failed to encode key %s: %w", key.ID, err)
+	}
+	block := &pem.Block{
+		Type:    "PRIVATE KEY",
+		Headers: map[string]string{createdAtHeader: key.CreatedAt.UTC().Format(time.RFC3339)},
+		Bytes:   der,
+	}
+
+	path := filepath.Join(s.Dir, key.ID+".pem")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, pem.EncodeToMemory(block), 0600); err != nil {
+		return fmt.Errorf("failed to write key %s: %w", key.ID, err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Delete removes the key file
+func (s *DirKeyStore) Delete(ctx context.Context, id string) error {
+	err := os.Remove(filepath.Join(s.Dir, id+".pem"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func readKeyFile(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block", path)
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", path, ErrUnsupportedAlgorithm)
+	}
+	algorithm, err := algorithmOf(signer)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, block.Headers[createdAtHeader])
+	if err != nil {
+		info, statErr := os.Stat(path)
+		if statErr != nil {
+			return nil, statErr
+		}
+		createdAt = info.ModTime()
+	}
+
+	return &SigningKey{
+		ID:        strings.TrimSuffix(filepath.Base(path), ".pem"),
+		Algorithm: algorithm,
+		Key:       signer,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// KeyRotator keeps a KeySet in sync with a KeyStore and rotates its keys
+type KeyRotator struct {
+	Store     KeyStore
+	Set       *KeySet
+	Algorithm string
+	// Period is how long a key signs before a new one replaces it; 0 never rotates
+	Period time.Duration
+	// Retain is how long a replaced key still verifies. Set it to at least the
+	// longest token lifetime, or tokens signed with it stop working early.
+	Retain time.Duration
+}
+
+// Rotate loads the store, creates a key when there is none or the active one is
+// older than Period, removes keys retired longer than Retain ago and swaps the
+// result into the set
+func (r *KeyRotator) Rotate(ctx context.Context) error {
+	keys, err := r.Store.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	set, err := NewKeySet(keys...)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if active, err := set.Active(); err != nil || (r.Period > 0 && now.Sub(active.CreatedAt) >= r.Period) {
+		key, err := GenerateSigningKey(r.Algorithm)
+		if err != nil {
+			return err
+		}
+		if err := r.Store.Save(ctx, key); err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		logger.Info("JWT signing key created", zap.String("kid", key.ID), zap.String("alg", key.Algorithm))
+	}
+
+	if err := set.Replace(keys); err != nil {
+		return err
+	}
+	sorted := set.Keys()
+	kept := make([]*SigningKey, 0, len(sorted))
+	for i, key := range sorted {
+		// A key was retired when the next newer key was created
+		if i > 0 && r.Period > 0 && now.Sub(sorted[i-1].CreatedAt) > r.Retain {
+			if err := r.Store.Delete(ctx, key.ID); err != nil {
+				logger.Warn("Failed to delete retired JWT signing key", zap.String("kid", key.ID), zap.Error(err))
+			} else {
+				logger.Info("JWT signing key removed", zap.String("kid", key.ID))
+			}
+			continue
+		}
+		kept = append(kept, key)
+	}
+
+	return r.Set.Replace(kept)
+}
+
+// Start calls Rotate every interval until ctx is done, so keys created or
+// rotated by another instance are picked up
+func (r *KeyRotator) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.Rotate(ctx); err != nil {
+					logger.Warn("Failed to rotate JWT signing keys", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,280 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"flex-service/pkg/requestctx"
+)
+
+// OwnSuffix marks a permission that only applies to resources the principal owns,
+// e.g. "post:update:own" grants "post:update" on the principal's own posts
+const OwnSuffix = ":own"
+
+// Role is a named set of permissions. A role has every permission of the roles it
+// inherits, so "admin" can inherit "moderator" instead of repeating its list.
+type Role struct {
+	Name        string   `json:"name"`
+	Inherits    []string `json:"inherits,omitempty"`
+	Permissions []string `json:"permissions"`
+}
+
+// Owned is implemented by resources that belong to a principal
+type Owned interface {
+	// OwnerSubject returns the Principal.Subject of the owner, e.g. the user ID as a string
+	OwnerSubject() string
+}
+
+// OwnedBy wraps an owner subject for resources that don't implement Owned
+type OwnedBy string
+
+// OwnerSubject implements Owned
+func (o OwnedBy) OwnerSubject() string {
+	return string(o)
+}
+
+// RoleResolver returns the roles assigned to a user, e.g. from the database.
+// Authentication uses it to fill Principal.Roles.
+type RoleResolver interface {
+	UserRoles(ctx context.Context, userID int) ([]string, error)
+}
+
+// PermissionChecker resolves roles to permissions and answers permission checks.
+// Effective permissions per role are cached and recomputed after roles change.
+type PermissionChecker struct {
+	mu        sync.RWMutex
+	roles     map[string]*Role
+	effective map[string][]string
+}
+
+// NewPermissionChecker creates a checker with the given roles
+func NewPermissionChecker(roles ...*Role) (*PermissionChecker, error) {
+	pc := &PermissionChecker{
+		roles:     make(map[string]*Role, len(roles)),
+		effective: make(map[string][]string),
+	}
+	for _, role := range roles {
+		if role == nil || role.Name == "" {
+			return nil, fmt.Errorf("%w: name is required", ErrInvalidRole)
+		}
+		pc.roles[role.Name] = role
+	}
+	if err := pc.validate(); err != nil {
+		return nil, err
+	}
+	return pc, nil
+}
+
+// LoadRolesFile creates a checker from a JSON file containing an array of roles
+func LoadRolesFile(path string) (*PermissionChecker, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read roles file: %w", err)
+	}
+
+	var roles []*Role
+	if err := json.Unmarshal(data, &roles); err != nil {
+		return nil, fmt.Errorf("failed to parse roles file: %w", err)
+	}
+
+	return NewPermissionChecker(roles...)
+}
+
+// DefineRole adds or replaces a role. Inherited roles must already be defined.
+func (pc *PermissionChecker) DefineRole(role *Role) error {
+	if role == nil || role.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRole)
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	previous, existed := pc.roles[role.Name]
+	pc.roles[role.Name] = role
+	if err := pc.validate(); err != nil {
+		if existed {
+			pc.roles[role.Name] = previous
+		} else {
+			delete(pc.roles, role.Name)
+		}
+		return err
+	}
+
+	pc.effective = make(map[string][]string)
+	return nil
+}
+
+// RemoveRole deletes a role. Roles inheriting it lose its permissions.
+func (pc *PermissionChecker) RemoveRole(name string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	delete(pc.roles, name)
+	pc.effective = make(map[string][]string)
+}
+
+// Roles returns the defined roles
+func (pc *PermissionChecker) Roles() []*Role {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+
+	roles := make([]*Role, 0, len(pc.roles))
+	for _, role := range pc.roles {
+		roles = append(roles, role)
+	}
+	return roles
+}
+
+// RolePermissions returns the permissions of a role including inherited ones
+func (pc *PermissionChecker) RolePermissions(name string) []string {
+	pc.mu.RLock()
+	permissions, cached := pc.effective[name]
+	pc.mu.RUnlock()
+	if cached {
+		return permissions
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if permissions, cached := pc.effective[name]; cached {
+		return permissions
+	}
+
+	seen := make(map[string]bool)
+	set := make(map[string]bool)
+	pc.collect(name, seen, set)
+
+	permissions = make([]string, 0, len(set))
+	for permission := range set {
+		permissions = append(permissions, permission)
+	}
+	pc.effective[name] = permissions
+	return permissions
+}
+
+// HasRole reports whether the principal has the role directly or through inheritance
+func (pc *PermissionChecker) HasRole(principal requestctx.Principal, role string) bool {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+
+	for _, name := range principal.Roles {
+		if pc.inherits(name, role, make(map[string]bool)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions returns every permission the principal holds: its own list plus
+// the effective permissions of its roles
+func (pc *PermissionChecker) Permissions(principal requestctx.Principal) []string {
+	permissions := append([]string(nil), principal.Permissions...)
+	for _, role := range principal.Roles {
+		permissions = append(permissions, pc.RolePermissions(role)...)
+	}
+	return permissions
+}
+
+// Can reports whether the principal may perform permission, optionally on a
+// resource. Grants are evaluated in this order, the first match allowing:
+//
+//  1. "*" allows everything
+//  2. an exact grant ("post:update")
+//  3. a wildcard grant ("post:*", or "orders.*" in the dotted style)
+//  4. an ownership grant ("post:update:own", "post:*:own") when the resource is
+//     owned by the principal
+//
+// Anything else is denied. Ownership grants never match without a resource.
+// A principal from a scoped token is also denied anything outside its scopes.
+func (pc *PermissionChecker) Can(principal requestctx.Principal, permission string, resource Owned) bool {
+	if !principal.HasScope(permission) {
+		return false
+	}
+
+	owner := resource != nil && principal.Subject != "" && resource.OwnerSubject() == principal.Subject
+
+	for _, granted := range pc.Permissions(principal) {
+		if base, ok := strings.CutSuffix(granted, OwnSuffix); ok {
+			if owner && Match(base, permission) {
+				return true
+			}
+			continue
+		}
+		if Match(granted, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether a granted permission covers permission. "*" matches
+// everything and a grant ending in ":*" or ".*" matches anything under its prefix.
+func Match(granted, permission string) bool {
+	if granted == "*" || granted == permission {
+		return true
+	}
+	if strings.HasSuffix(granted, ":*") || strings.HasSuffix(granted, ".*") {
+		return strings.HasPrefix(permission, strings.TrimSuffix(granted, "*"))
+	}
+	return false
+}
+
+// collect adds the permissions of name and its ancestors to set
+func (pc *PermissionChecker) collect(name string, seen, set map[string]bool) {
+	if seen[name] {
+		return
+	}
+	seen[name] = true
+
+	role, ok := pc.roles[name]
+	if !ok {
+		return
+	}
+	for _, permission := range role.Permissions {
+		set[permission] = true
+	}
+	for _, parent := range role.Inherits {
+		pc.collect(parent, seen, set)
+	}
+}
+
+// inherits reports whether name is target or inherits it
+func (pc *PermissionChecker) inherits(name, target string, seen map[string]bool) bool {
+	if name == target {
+		return true
+	}
+	if seen[name] {
+		return false
+	}
+	seen[name] = true
+
+	role, ok := pc.roles[name]
+	if !ok {
+		return false
+	}
+	for _, parent := range role.Inherits {
+		if pc.inherits(parent, target, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks that inherited roles exist and inheritance has no cycles
+func (pc *PermissionChecker) validate() error {
+	for name, role := range pc.roles {
+		for _, parent := range role.Inherits {
+			if _, ok := pc.roles[parent]; !ok {
+				return fmt.Errorf("%w: %s inherits %s", ErrUnknownParent, name, parent)
+			}
+			if pc.inherits(parent, name, make(map[string]bool)) {
+				return fmt.Errorf("%w: %s", ErrRoleCycle, name)
+			}
+		}
+	}
+	return nil
+}
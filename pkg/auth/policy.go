@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"flex-service/pkg/requestctx"
+)
+
+// Policy decides which actions a principal may perform on entities of type T.
+// Unlike permissions, it sees the resource, so it can express rules such as
+// "users may edit their own posts". resource is nil for actions on no
+// particular entity, such as "create" or "viewAny".
+type Policy[T any] interface {
+	Can(ctx context.Context, principal requestctx.Principal, action string, resource *T) bool
+}
+
+// policyFunc is a registered policy with its resource type erased
+type policyFunc func(ctx context.Context, principal requestctx.Principal, action string, resource interface{}) bool
+
+// Policies holds one policy per entity type and answers Can for any resource
+type Policies struct {
+	mu       sync.RWMutex
+	policies map[reflect.Type]policyFunc
+}
+
+// NewPolicies creates an empty policy registry
+func NewPolicies() *Policies {
+	return &Policies{policies: make(map[reflect.Type]policyFunc)}
+}
+
+// RegisterPolicy sets the policy of entity type T, replacing any previous one:
+//
+//	auth.RegisterPolicy[entity.Post](policies, &PostPolicy{})
+//
+// T must be a struct type.
+func RegisterPolicy[T any](p *Policies, policy Policy[T]) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("auth: policy type %s is not a struct", t))
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.policies[t] = func(ctx context.Context, principal requestctx.Principal, action string, resource interface{}) bool {
+		switch r := resource.(type) {
+		case *T:
+			return policy.Can(ctx, principal, action, r)
+		case T:
+			return policy.Can(ctx, principal, action, &r)
+		}
+		return false
+	}
+}
+
+// Has reports whether a policy is registered for the type of resource
+func (p *Policies) Has(resource interface{}) bool {
+	_, ok := p.lookup(resource)
+	return ok
+}
+
+// Can reports whether the principal may perform action on resource, a *T or T
+// whose policy is registered. Pass a nil *T for actions on no particular
+// entity:
+//
+//	policies.Can(ctx, principal, "create", (*entity.Post)(nil))
+//
+// Resources without a policy are denied.
+func (p *Policies) Can(ctx context.Context, principal requestctx.Principal, action string, resource interface{}) bool {
+	policy, ok := p.lookup(resource)
+	if !ok {
+		return false
+	}
+	return policy(ctx, principal, action, resource)
+}
+
+// lookup returns the policy of the resource's type
+func (p *Policies) lookup(resource interface{}) (policyFunc, bool) {
+	t := reflect.TypeOf(resource)
+	if t == nil {
+		return nil, false
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	policy, ok := p.policies[t]
+	return policy, ok
+}
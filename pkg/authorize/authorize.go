@@ -0,0 +1,63 @@
+// Package authorize checks entity policies for the principal of a context, so
+// handlers and usecases can authorize without a policy dependency:
+//
+//	if err := authorize.Check(ctx, "update", post); err != nil {
+//		return err
+//	}
+//
+// Handlers pass c.Request.Context(), which carries the principal set by the
+// auth middleware.
+package authorize
+
+import (
+	"context"
+	"sync/atomic"
+
+	"flex-service/pkg/auth"
+	"flex-service/pkg/errors"
+	"flex-service/pkg/requestctx"
+)
+
+// defaultPolicies is used by the package-level checks
+var defaultPolicies atomic.Pointer[auth.Policies]
+
+func init() {
+	defaultPolicies.Store(auth.NewPolicies())
+}
+
+// SetDefault sets the policies used by Can and Check
+func SetDefault(p *auth.Policies) {
+	defaultPolicies.Store(p)
+}
+
+// Default returns the policies used by Can and Check
+func Default() *auth.Policies {
+	return defaultPolicies.Load()
+}
+
+// Can reports whether the principal of ctx may perform action on resource.
+// Pass a nil pointer of the entity type for actions on no particular entity:
+//
+//	authorize.Can(ctx, "create", (*entity.Post)(nil))
+//
+// It is false without a principal or a policy for the resource's type.
+func Can(ctx context.Context, action string, resource interface{}) bool {
+	principal, ok := requestctx.PrincipalFrom(ctx)
+	if !ok {
+		return false
+	}
+	return Default().Can(ctx, principal, action, resource)
+}
+
+// Check is Can returning an error for the response: 401 without a principal,
+// 403 when the policy denies the action
+func Check(ctx context.Context, action string, resource interface{}) error {
+	principal, ok := requestctx.PrincipalFrom(ctx)
+	if !ok {
+		return errors.Unauthorized("Not authenticated")
+	}
+	if !Default().Can(ctx, principal, action, resource) {
+		return errors.Forbidden("Not allowed to perform this action")
+	}
+	return nil
+}
@@ -0,0 +1,510 @@
+package bulk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"flex-service/pkg/errors"
+	"flex-service/pkg/i18n"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/queue"
+	"flex-service/pkg/validator"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Actions
+const (
+	Create = "create"
+	Update = "update"
+	Delete = "delete"
+)
+
+// Modes
+const (
+	// Transactional applies every item in one transaction: one invalid or
+	// failing item and nothing is written
+	Transactional = "transactional"
+	// BestEffort applies each valid item in its own transaction and reports
+	// the others
+	BestEffort = "best_effort"
+)
+
+// Item statuses
+const (
+	StatusOK         = "ok"
+	StatusInvalid    = "invalid"     // failed validation, not applied
+	StatusFailed     = "failed"      // applying it returned an error
+	StatusRolledBack = "rolled_back" // applied, then undone because another item failed
+	StatusSkipped    = "skipped"     // not applied because another item failed
+)
+
+// Defaults
+const (
+	DefaultMaxItems  = 100
+	DefaultBatchSize = 100
+)
+
+// batchSavepoint is the savepoint a transactional batch rolls back to
+const batchSavepoint = "bulk_batch"
+
+// Request is the body of POST /<resource>/bulk. Update and delete also take
+// IDs instead of Items: update applies Data to every listed record.
+type Request struct {
+	Action string            `json:"action" validate:"required,oneof=create update delete"`
+	Mode   string            `json:"mode" validate:"omitempty,oneof=transactional best_effort"`
+	Items  []json.RawMessage `json:"items,omitempty" validate:"required_without=IDs"`
+	IDs    []json.RawMessage `json:"ids,omitempty"`
+	Data   json.RawMessage   `json:"data,omitempty"`
+}
+
+// Item is one decoded item handed to an apply function
+type Item[T any] struct {
+	Index int
+	// ID is the item's "id" field; required for update and delete
+	ID   interface{}
+	Data *T
+}
+
+// ItemResult reports what happened to one item
+type ItemResult struct {
+	Index  int                        `json:"index"`
+	ID     interface{}                `json:"id,omitempty"` // the item's id, for update and delete
+	Status string                     `json:"status"`
+	Data   interface{}                `json:"data,omitempty"`
+	Code   string                     `json:"code,omitempty"`
+	Error  string                     `json:"error,omitempty"`
+	Errors validator.ValidationErrors `json:"errors,omitempty"`
+}
+
+// Result is the outcome of a bulk request. JobID is set instead of Items when
+// the request was handed to the queue.
+type Result struct {
+	Action    string       `json:"action"`
+	Mode      string       `json:"mode"`
+	Total     int          `json:"total"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	JobID     string       `json:"job_id,omitempty"`
+	Items     []ItemResult `json:"items,omitempty"`
+}
+
+// StatusCode is the HTTP status for the result: 202 when queued, 200 when
+// every item succeeded, 422 when none did and 207 otherwise
+func (r *Result) StatusCode() int {
+	switch {
+	case r.JobID != "":
+		return http.StatusAccepted
+	case r.Failed == 0:
+		return http.StatusOK
+	case r.Succeeded == 0:
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusMultiStatus
+}
+
+// Config limits bulk requests
+type Config struct {
+	// MaxItems is the largest accepted request
+	MaxItems int
+	// BatchSize is how many rows functions registered with HandleBatch write
+	// per statement, e.g. as gorm.Session's CreateBatchSize
+	BatchSize int
+	// Queue, when set, receives requests with more than QueueThreshold items
+	Queue          queue.Queue
+	QueueThreshold int
+}
+
+// DefaultConfig returns default bulk configuration (no queue offloading)
+func DefaultConfig() *Config {
+	return &Config{MaxItems: DefaultMaxItems, BatchSize: DefaultBatchSize}
+}
+
+// action decodes, validates and applies the items of one action
+type action struct {
+	prepare func(ctx context.Context, index int, raw json.RawMessage, needsID bool) (interface{}, validator.ValidationErrors)
+	apply   func(ctx context.Context, tx *gorm.DB, item interface{}) (interface{}, error)
+}
+
+// batch applies every valid item of an action at once
+type batch func(ctx context.Context, tx *gorm.DB, items []interface{}) ([]interface{}, error)
+
+// Processor runs bulk requests for one resource
+type Processor struct {
+	resource string
+	db       *gorm.DB
+	config   *Config
+	actions  map[string]action
+	batches  map[string]batch
+}
+
+// NewProcessor creates a processor for resource; register actions with Handle
+func NewProcessor(resource string, db *gorm.DB, config *Config) *Processor {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &Processor{
+		resource: resource,
+		db:       db,
+		config:   config,
+		actions:  make(map[string]action),
+		batches:  make(map[string]batch),
+	}
+}
+
+// Handle registers the function applying one item of an action. Items are
+// decoded into T and validated before any of them is applied; apply runs
+// inside the transaction tx.
+func Handle[T any](p *Processor, name string, apply func(ctx context.Context, tx *gorm.DB, item *Item[T]) (interface{}, error)) {
+	p.actions[name] = action{
+		prepare: func(ctx context.Context, index int, raw json.RawMessage, needsID bool) (interface{}, validator.ValidationErrors) {
+			item := &Item[T]{Index: index, Data: new(T)}
+			if err := json.Unmarshal(raw, item.Data); err != nil {
+				return nil, validator.ValidationErrors{{Field: "item", Rule: "json", Message: err.Error()}}
+			}
+
+			id, err := itemID(raw)
+			if err != nil {
+				return nil, validator.ValidationErrors{{Field: "id", Rule: "json", Message: err.Error()}}
+			}
+			item.ID = id
+
+			violations := validator.Validate(item.Data, i18n.FromContext(ctx))
+			if needsID && item.ID == nil {
+				violations = append(violations, validator.FieldError{
+					Field:   "id",
+					Rule:    "required",
+					Message: i18n.T(i18n.FromContext(ctx), "validation.required", i18n.Args{"field": "id"}),
+				})
+			}
+			if len(violations) > 0 {
+				return nil, violations
+			}
+			return item, nil
+		},
+		apply: func(ctx context.Context, tx *gorm.DB, item interface{}) (interface{}, error) {
+			return apply(ctx, tx, item.(*Item[T]))
+		},
+	}
+}
+
+// HandleBatch registers a function applying all valid items of an action at
+// once, such as a multi-row insert, and returning each item's data in order.
+// The action still needs Handle: when the batch fails, it is rolled back and
+// the items are applied one by one, so the failing ones are reported.
+func HandleBatch[T any](p *Processor, name string, apply func(ctx context.Context, tx *gorm.DB, items []*Item[T]) ([]interface{}, error)) {
+	p.batches[name] = func(ctx context.Context, tx *gorm.DB, items []interface{}) ([]interface{}, error) {
+		typed := make([]*Item[T], len(items))
+		for i, item := range items {
+			typed[i] = item.(*Item[T])
+		}
+		return apply(ctx, tx, typed)
+	}
+}
+
+// Config returns the processor's limits
+func (p *Processor) Config() *Config {
+	return p.config
+}
+
+// BatchSize returns Config.BatchSize, or DefaultBatchSize when unset
+func (p *Processor) BatchSize() int {
+	if p.config.BatchSize <= 0 {
+		return DefaultBatchSize
+	}
+	return p.config.BatchSize
+}
+
+// Run checks the request against the limits and either processes it or,
+// above the queue threshold, hands it to the queue
+func (p *Processor) Run(ctx context.Context, req *Request) (*Result, error) {
+	if _, ok := p.actions[req.Action]; !ok {
+		return nil, errors.BadRequest(fmt.Sprintf("Bulk %s is not supported for %s", req.Action, p.resource))
+	}
+	req, err := expandIDs(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Items) > p.config.MaxItems {
+		return nil, errors.BadRequest(fmt.Sprintf("A bulk request takes at most %d items", p.config.MaxItems)).
+			WithDetails(map[string]interface{}{"max_items": p.config.MaxItems, "items": len(req.Items)})
+	}
+
+	if p.config.Queue != nil && len(req.Items) > p.config.QueueThreshold {
+		return p.enqueue(ctx, req)
+	}
+	return p.Process(ctx, req)
+}
+
+// Process validates and applies every item now
+func (p *Processor) Process(ctx context.Context, req *Request) (*Result, error) {
+	act, ok := p.actions[req.Action]
+	if !ok {
+		return nil, errors.BadRequest(fmt.Sprintf("Bulk %s is not supported for %s", req.Action, p.resource))
+	}
+	req, err := expandIDs(req)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = Transactional
+	}
+	result := &Result{
+		Action: req.Action,
+		Mode:   mode,
+		Total:  len(req.Items),
+		Items:  make([]ItemResult, len(req.Items)),
+	}
+
+	// Validate everything first so a transactional request fails before writing
+	prepared := make([]interface{}, len(req.Items))
+	invalid := false
+	for i, raw := range req.Items {
+		result.Items[i] = ItemResult{Index: i}
+		if req.Action != Create {
+			result.Items[i].ID, _ = itemID(raw)
+		}
+		item, violations := act.prepare(ctx, i, raw, req.Action != Create)
+		if violations != nil {
+			result.Items[i].Status = StatusInvalid
+			result.Items[i].Code = errors.ErrValidation
+			result.Items[i].Errors = violations
+			invalid = true
+			continue
+		}
+		prepared[i] = item
+	}
+
+	if mode == BestEffort {
+		p.bestEffort(ctx, req.Action, act, prepared, result)
+	} else if invalid {
+		markRemaining(result, StatusSkipped)
+	} else {
+		p.transactional(ctx, req.Action, act, prepared, result)
+	}
+
+	for _, item := range result.Items {
+		if item.Status == StatusOK {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+	}
+	return result, nil
+}
+
+// transactional applies every item in one transaction and rolls all of them
+// back when one fails. A failed batch is rolled back to a savepoint first, so
+// the items applied one by one can tell which failed.
+func (p *Processor) transactional(ctx context.Context, name string, act action, prepared []interface{}, result *Result) {
+	failed := -1
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if apply, ok := p.batches[name]; ok && len(prepared) > 1 {
+			if err := tx.SavePoint(batchSavepoint).Error; err != nil {
+				return err
+			}
+			data, err := apply(ctx, tx, prepared)
+			if err == nil {
+				markApplied(result, prepared, data)
+				return nil
+			}
+			p.batchFailed(err)
+			if err := tx.RollbackTo(batchSavepoint).Error; err != nil {
+				return err
+			}
+		}
+
+		for i, item := range prepared {
+			data, err := act.apply(ctx, tx, item)
+			if err != nil {
+				failed = i
+				return err
+			}
+			result.Items[i].Status = StatusOK
+			result.Items[i].Data = data
+		}
+		return nil
+	})
+	if err == nil {
+		return
+	}
+
+	if failed < 0 {
+		// The commit itself failed
+		for i := range result.Items {
+			p.fail(&result.Items[i], err)
+		}
+		return
+	}
+	for i := range result.Items {
+		switch {
+		case i < failed:
+			result.Items[i].Status = StatusRolledBack
+			result.Items[i].Data = nil
+		case i == failed:
+			p.fail(&result.Items[i], err)
+		default:
+			result.Items[i].Status = StatusSkipped
+		}
+	}
+}
+
+// bestEffort applies each valid item in its own transaction, or all of them
+// in one when the action has a batch and it succeeds
+func (p *Processor) bestEffort(ctx context.Context, name string, act action, prepared []interface{}, result *Result) {
+	if apply, ok := p.batches[name]; ok {
+		if valid := validItems(prepared); len(valid) > 1 {
+			var data []interface{}
+			err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				var err error
+				data, err = apply(ctx, tx, valid)
+				return err
+			})
+			if err == nil {
+				markApplied(result, prepared, data)
+				return
+			}
+			p.batchFailed(err)
+		}
+	}
+
+	for i, item := range prepared {
+		if item == nil {
+			continue
+		}
+
+		var data interface{}
+		err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var err error
+			data, err = act.apply(ctx, tx, item)
+			return err
+		})
+		if err != nil {
+			p.fail(&result.Items[i], err)
+			continue
+		}
+		result.Items[i].Status = StatusOK
+		result.Items[i].Data = data
+	}
+}
+
+// fail records err on an item, hiding messages of unexpected errors
+func (p *Processor) fail(item *ItemResult, err error) {
+	item.Status = StatusFailed
+	item.Data = nil
+	if appErr, ok := errors.AsAppError(err); ok {
+		item.Code = appErr.Code
+		item.Error = appErr.Message
+		return
+	}
+
+	logger.Error("Bulk item failed",
+		zap.String("resource", p.resource),
+		zap.Int("index", item.Index),
+		zap.Error(err))
+	item.Code = errors.ErrInternal
+	item.Error = "Failed to apply item"
+}
+
+// batchFailed logs a batch that is retried one item at a time
+func (p *Processor) batchFailed(err error) {
+	logger.Warn("Bulk batch failed, applying items one by one",
+		zap.String("resource", p.resource),
+		zap.Error(err))
+}
+
+// validItems returns the prepared items that passed validation
+func validItems(prepared []interface{}) []interface{} {
+	valid := make([]interface{}, 0, len(prepared))
+	for _, item := range prepared {
+		if item != nil {
+			valid = append(valid, item)
+		}
+	}
+	return valid
+}
+
+// markApplied marks the valid items applied by a batch with its data, which
+// is in the order of the items
+func markApplied(result *Result, prepared []interface{}, data []interface{}) {
+	n := 0
+	for i, item := range prepared {
+		if item == nil {
+			continue
+		}
+		result.Items[i].Status = StatusOK
+		if n < len(data) {
+			result.Items[i].Data = data[n]
+		}
+		n++
+	}
+}
+
+// markRemaining sets status on every item that has none yet
+func markRemaining(result *Result, status string) {
+	for i := range result.Items {
+		if result.Items[i].Status == "" {
+			result.Items[i].Status = status
+		}
+	}
+}
+
+// expandIDs turns an update or delete by IDs into one item per ID: Data with
+// the ID set, or just the ID
+func expandIDs(req *Request) (*Request, error) {
+	if len(req.IDs) == 0 {
+		return req, nil
+	}
+	switch {
+	case req.Action == Create:
+		return nil, errors.BadRequest("Bulk create takes items, not ids")
+	case len(req.Items) > 0:
+		return nil, errors.BadRequest("Send either items or ids, not both")
+	case req.Action == Update && len(req.Data) == 0:
+		return nil, errors.BadRequest("Bulk update by ids needs data")
+	}
+
+	fields := map[string]json.RawMessage{}
+	if req.Action == Update {
+		if err := json.Unmarshal(req.Data, &fields); err != nil {
+			return nil, errors.BadRequest("data must be an object")
+		}
+	}
+
+	expanded := &Request{Action: req.Action, Mode: req.Mode, Items: make([]json.RawMessage, len(req.IDs))}
+	for i, id := range req.IDs {
+		fields["id"] = id
+		item, err := json.Marshal(fields)
+		if err != nil {
+			return nil, errors.BadRequest(fmt.Sprintf("ids[%d] is not a valid id", i))
+		}
+		expanded.Items[i] = item
+	}
+	return expanded, nil
+}
+
+// itemID reads the optional "id" field of an item, keeping integers as int64
+func itemID(raw json.RawMessage) (interface{}, error) {
+	var key struct {
+		ID interface{} `json:"id"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&key); err != nil {
+		return nil, err
+	}
+
+	if number, ok := key.ID.(json.Number); ok {
+		if id, err := number.Int64(); err == nil {
+			return id, nil
+		}
+		return nil, fmt.Errorf("id must be an integer or a string")
+	}
+	return key.ID, nil
+}
@@ -0,0 +1,65 @@
+package bulk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"flex-service/pkg/errors"
+	"flex-service/pkg/i18n"
+	"flex-service/pkg/queue"
+)
+
+// jobPayload is a queued bulk request
+type jobPayload struct {
+	Request
+	Locale string `json:"locale"`
+}
+
+// JobType is the queue job type of the processor's offloaded requests, e.g.
+// "bulk:products"
+func (p *Processor) JobType() string {
+	return "bulk:" + p.resource
+}
+
+// JobHandler processes queued requests; register it on a worker under
+// JobType. Item failures are reported in the job result rather than failing
+// the job, since retrying would apply the successful items again.
+func (p *Processor) JobHandler() queue.Handler {
+	return queue.HandlerFunc(func(ctx context.Context, job *queue.Job) *queue.JobResult {
+		var payload jobPayload
+		data, _ := json.Marshal(job.Payload)
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return &queue.JobResult{Success: false, Error: fmt.Sprintf("invalid bulk payload: %v", err)}
+		}
+
+		result, err := p.Process(i18n.WithLocale(ctx, payload.Locale), &payload.Request)
+		if err != nil {
+			return &queue.JobResult{Success: false, Error: err.Error()}
+		}
+		return &queue.JobResult{Success: true, Data: result}
+	})
+}
+
+// enqueue pushes the request to the queue and returns a result carrying the job ID
+func (p *Processor) enqueue(ctx context.Context, req *Request) (*Result, error) {
+	data, err := json.Marshal(jobPayload{Request: *req, Locale: i18n.FromContext(ctx)})
+	if err != nil {
+		return nil, errors.WrapInternal(err, "Failed to queue bulk request")
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, errors.WrapInternal(err, "Failed to queue bulk request")
+	}
+
+	job := &queue.Job{Type: p.JobType(), Payload: payload, MaxAttempts: 1}
+	if err := p.config.Queue.Push(job); err != nil {
+		return nil, errors.WrapInternal(err, "Failed to queue bulk request")
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = Transactional
+	}
+	return &Result{Action: req.Action, Mode: mode, Total: len(req.Items), JobID: job.ID}, nil
+}
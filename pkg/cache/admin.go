@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// deleteBatch is how many keys Clear and Forget unlink per round trip
+const deleteBatch = 500
+
+// Clear deletes every key under the cache prefix and returns how many were
+// deleted. Unlike FlushAll, keys of other users of the Redis database, such
+// as sessions and locks, are kept. Tiered caches drop their local copies.
+func Clear(ctx context.Context, client *redis.Client, config *CacheConfig) (int64, error) {
+	deleted, err := deleteMatching(ctx, client, config.KeyPrefix+"*")
+	if err != nil {
+		return deleted, fmt.Errorf("failed to clear cache: %w", err)
+	}
+	return deleted, purgeLocal(ctx, client, config)
+}
+
+// Forget deletes keys, given as the application uses them without the cache
+// prefix. Keys with *, ? or [ are glob patterns, e.g. "product:*"; versioned
+// copies of each key are deleted too. Tiered caches drop their local copies.
+func Forget(ctx context.Context, client *redis.Client, config *CacheConfig, keys ...string) (int64, error) {
+	var deleted int64
+	for _, key := range keys {
+		patterns := []string{config.KeyPrefix + key, config.KeyPrefix + "@*:" + key}
+		if !strings.ContainsAny(key, "*?[") {
+			n, err := client.Unlink(ctx, patterns[0]).Result()
+			if err != nil {
+				return deleted, fmt.Errorf("failed to forget %s: %w", key, err)
+			}
+			deleted += n
+			patterns = patterns[1:]
+		}
+		for _, pattern := range patterns {
+			n, err := deleteMatching(ctx, client, pattern)
+			deleted += n
+			if err != nil {
+				return deleted, fmt.Errorf("failed to forget %s: %w", key, err)
+			}
+		}
+	}
+	return deleted, purgeLocal(ctx, client, config)
+}
+
+// deleteMatching scans for keys matching pattern and unlinks them in batches
+func deleteMatching(ctx context.Context, client *redis.Client, pattern string) (int64, error) {
+	var deleted int64
+	batch := make([]string, 0, deleteBatch)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		n, err := client.Unlink(ctx, batch...).Result()
+		deleted += n
+		batch = batch[:0]
+		return err
+	}
+
+	iter := client.Scan(ctx, 0, pattern, 1000).Iterator()
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == deleteBatch {
+			if err := flush(); err != nil {
+				return deleted, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return deleted, err
+	}
+	return deleted, flush()
+}
+
+// purgeLocal tells tiered caches to drop every local copy
+func purgeLocal(ctx context.Context, client *redis.Client, config *CacheConfig) error {
+	message, _ := json.Marshal(invalidation{Origin: "cli"})
+	if err := client.Publish(ctx, config.KeyPrefix+"cache:invalidate", message).Err(); err != nil {
+		return fmt.Errorf("keys deleted but tiered caches were not notified: %w", err)
+	}
+	return nil
+}
+
+// Stats summarizes the Redis server behind the cache
+type Stats struct {
+	Version          string
+	UptimeSeconds    int64
+	Hits             int64
+	Misses           int64
+	EvictedKeys      int64
+	ExpiredKeys      int64
+	UsedMemory       string
+	MaxMemory        string
+	EvictionPolicy   string
+	ConnectedClients int64
+	// Keys and Expires are the key counts of the client's database
+	Keys    int64
+	Expires int64
+}
+
+// HitRatio is Hits over all lookups, 0 before the first lookup
+func (s *Stats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// ReadStats reads the default sections of Redis INFO. Hits and misses are
+// server-wide, not limited to the cache prefix.
+func ReadStats(ctx context.Context, client *redis.Client) (*Stats, error) {
+	raw, err := client.Info(ctx).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Redis INFO: %w", err)
+	}
+	info := parseInfo(raw)
+	number := func(field string) int64 {
+		n, _ := strconv.ParseInt(info[field], 10, 64)
+		return n
+	}
+
+	stats := &Stats{
+		Version:          info["redis_version"],
+		UptimeSeconds:    number("uptime_in_seconds"),
+		Hits:             number("keyspace_hits"),
+		Misses:           number("keyspace_misses"),
+		EvictedKeys:      number("evicted_keys"),
+		ExpiredKeys:      number("expired_keys"),
+		UsedMemory:       info["used_memory_human"],
+		MaxMemory:        info["maxmemory_human"],
+		EvictionPolicy:   info["maxmemory_policy"],
+		ConnectedClients: number("connected_clients"),
+	}
+
+	// db0:keys=12,expires=3,avg_ttl=0
+	for _, field := range strings.Split(info["db"+strconv.Itoa(client.Options().DB)], ",") {
+		name, value, _ := strings.Cut(field, "=")
+		n, _ := strconv.ParseInt(value, 10, 64)
+		switch name {
+		case "keys":
+			stats.Keys = n
+		case "expires":
+			stats.Expires = n
+		}
+	}
+	return stats, nil
+}
+
+// parseInfo reads the "field:value" lines of Redis INFO
+func parseInfo(raw string) map[string]string {
+	info := make(map[string]string)
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if field, value, ok := strings.Cut(line, ":"); ok {
+			info[field] = value
+		}
+	}
+	return info
+}
@@ -44,6 +44,15 @@ func NewRedisClient(cfg *config.RedisConfig) (*redis.Client, error) {
 	return client, nil
 }
 
+// NewCacheConfig returns the default cache configuration with the key
+// versioning settings of the application configuration
+func NewCacheConfig(cfg *config.CacheConfig) *CacheConfig {
+	cacheConfig := DefaultCacheConfig()
+	cacheConfig.Version = cfg.Version
+	cacheConfig.Versioned = cfg.Versioned
+	return cacheConfig
+}
+
 // NewCache creates a new cache instance with Redis client
 func NewCache(cfg *config.RedisConfig) (Cache, error) {
 	client, err := NewRedisClient(cfg)
@@ -54,3 +63,13 @@ func NewCache(cfg *config.RedisConfig) (Cache, error) {
 	cacheConfig := DefaultCacheConfig()
 	return NewRedisCache(client, cacheConfig), nil
 }
+
+// NewTiered creates a tiered cache (in-process LRU in front of Redis) from configuration
+func NewTiered(cfg *config.RedisConfig, tiered *TieredConfig) (Cache, error) {
+	client, err := NewRedisClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTieredCache(client, DefaultCacheConfig(), tiered), nil
+}
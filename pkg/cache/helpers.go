@@ -91,7 +91,7 @@ func (h *CacheHelper) Forever(ctx context.Context, key string, value interface{}
 	return h.cache.SetJSON(ctx, key, value, 0) // 0 means no expiration
 }
 
-// Tags provides a way to group cache keys for easier invalidation
+// CacheTag groups Remember results under a tag for easier invalidation
 type CacheTag struct {
 	helper *CacheHelper
 	tag    string
@@ -107,90 +107,30 @@ func (h *CacheHelper) Tag(tag string) *CacheTag {
 
 // Remember caches with tag
 func (t *CacheTag) Remember(ctx context.Context, key string, ttl time.Duration, fn func() (interface{}, error)) (interface{}, error) {
-	taggedKey := t.buildTaggedKey(key)
-	result, err := t.helper.Remember(ctx, taggedKey, ttl, fn)
-	if err != nil {
-		return nil, err
-	}
-
-	// Add key to tag set
-	t.addToTagSet(ctx, key)
-
-	return result, nil
-}
+	tagged := t.helper.cache.Tags(t.tag)
 
-// Flush removes all cache entries with this tag
-func (t *CacheTag) Flush(ctx context.Context) error {
-	// Get all keys for this tag
-	keys, err := t.getTagKeys(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get tag keys: %w", err)
-	}
-
-	if len(keys) == 0 {
-		return nil
-	}
-
-	// Add tagged keys to deletion list
-	taggedKeys := make([]string, len(keys))
-	for i, key := range keys {
-		taggedKeys[i] = t.buildTaggedKey(key)
+	var result interface{}
+	err := tagged.GetJSON(ctx, key, &result)
+	if err == nil {
+		return result, nil
 	}
-
-	// Delete all tagged keys and the tag set itself
-	allKeys := append(taggedKeys, t.getTagSetKey())
-	return t.helper.cache.Del(ctx, allKeys...)
-}
-
-// buildTaggedKey creates a tagged cache key
-func (t *CacheTag) buildTaggedKey(key string) string {
-	return fmt.Sprintf("tag:%s:%s", t.tag, key)
-}
-
-// getTagSetKey returns the key for storing tag members
-func (t *CacheTag) getTagSetKey() string {
-	return fmt.Sprintf("tagset:%s", t.tag)
-}
-
-// addToTagSet adds a key to the tag set
-func (t *CacheTag) addToTagSet(ctx context.Context, key string) error {
-	// For simplicity, we'll store tag members as a JSON array
-	// In a more sophisticated implementation, you might use Redis sets
-	tagSetKey := t.getTagSetKey()
-
-	// Get existing keys
-	var existingKeys []string
-	err := t.helper.cache.GetJSON(ctx, tagSetKey, &existingKeys)
-	if err != nil && err != ErrCacheMiss {
-		return err
+	if err != ErrCacheMiss {
+		return nil, fmt.Errorf("cache error for key %s: %w", key, err)
 	}
 
-	// Add new key if not already present
-	found := false
-	for _, existingKey := range existingKeys {
-		if existingKey == key {
-			found = true
-			break
-		}
+	data, err := fn()
+	if err != nil {
+		return nil, fmt.Errorf("function execution failed for key %s: %w", key, err)
 	}
 
-	if !found {
-		existingKeys = append(existingKeys, key)
-		return t.helper.cache.SetJSON(ctx, tagSetKey, existingKeys, 24*time.Hour)
+	if cacheErr := tagged.SetJSON(ctx, key, data, ttl); cacheErr != nil {
+		fmt.Printf("Warning: failed to cache result for key %s: %v\n", key, cacheErr)
 	}
 
-	return nil
+	return data, nil
 }
 
-// getTagKeys retrieves all keys for this tag
-func (t *CacheTag) getTagKeys(ctx context.Context) ([]string, error) {
-	var keys []string
-	err := t.helper.cache.GetJSON(ctx, t.getTagSetKey(), &keys)
-	if err != nil {
-		if err == ErrCacheMiss {
-			return []string{}, nil
-		}
-		return nil, err
-	}
-	return keys, nil
+// Flush removes all cache entries with this tag
+func (t *CacheTag) Flush(ctx context.Context) error {
+	return t.helper.cache.Tags(t.tag).Flush(ctx)
 }
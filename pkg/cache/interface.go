@@ -45,12 +45,21 @@ type Cache interface {
 
 	// FlushAll clears all cache data (use with caution)
 	FlushAll(ctx context.Context) error
+
+	// Tags groups writes under tags so they can be flushed together
+	Tags(tags ...string) TaggedCache
 }
 
 // CacheConfig holds cache configuration
 type CacheConfig struct {
 	DefaultTTL time.Duration
 	KeyPrefix  string
+	// Version is the deploy version embedded in keys of the Versioned
+	// namespaces, e.g. the git SHA; a new deploy starts with fresh entries
+	Version string
+	// Versioned lists key prefixes, such as "user:", whose keys carry the
+	// version
+	Versioned []string
 }
 
 // DefaultCacheConfig returns default cache configuration
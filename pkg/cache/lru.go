@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lru is a size-bounded in-process cache of string values with per-entry
+// expiry, evicting the least recently used entry when full
+type lru struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List // front is most recently used
+
+	// generation changes on every invalidation, so a value read from Redis
+	// before an invalidation is not stored after it
+	generation uint64
+}
+
+type lruEntry struct {
+	key       string
+	value     string
+	expiresAt time.Time
+}
+
+func newLRU(size int) *lru {
+	return &lru{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get returns the value of an unexpired entry and marks it recently used
+func (l *lru) get(key string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	element, ok := l.entries[key]
+	if !ok {
+		return "", false
+	}
+	entry := element.Value.(*lruEntry)
+	if time.Now().After(entry.expiresAt) {
+		l.removeElement(element)
+		return "", false
+	}
+	l.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// snapshot returns the current generation, to be passed to set
+func (l *lru) snapshot() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.generation
+}
+
+// set stores value unless an invalidation happened since generation was taken
+func (l *lru) set(key, value string, ttl time.Duration, generation uint64) {
+	if ttl <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if generation != l.generation {
+		return
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	if element, ok := l.entries[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		l.order.MoveToFront(element)
+		return
+	}
+
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	localKeys.Add(1)
+	for l.order.Len() > l.size {
+		l.removeElement(l.order.Back())
+	}
+}
+
+// remove drops keys from the cache
+func (l *lru) remove(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.generation++
+	for _, key := range keys {
+		if element, ok := l.entries[key]; ok {
+			l.removeElement(element)
+		}
+	}
+}
+
+// purge drops every entry
+func (l *lru) purge() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.generation++
+	localKeys.Add(-int64(len(l.entries)))
+	l.entries = make(map[string]*list.Element)
+	l.order.Init()
+}
+
+func (l *lru) removeElement(element *list.Element) {
+	l.order.Remove(element)
+	delete(l.entries, element.Value.(*lruEntry).key)
+	localKeys.Add(-1)
+}
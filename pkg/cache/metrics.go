@@ -0,0 +1,231 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Operation results counted by the cache implementations
+const (
+	resultHit      = "hit"
+	resultLocalHit = "local_hit" // served from the process memory of TieredCache
+	resultMiss     = "miss"
+	resultError    = "error"
+	resultSet      = "set"
+	resultDel      = "del"
+	resultIncr     = "incr"
+)
+
+// defaultNamespace labels keys without a "<namespace>:" prefix
+const defaultNamespace = "default"
+
+var (
+	// operationMetrics counts cache operations by "<namespace>:<result>",
+	// exposed at /debug/vars when expvar is mounted
+	operationMetrics = expvar.NewMap("cache_operations_total")
+	// latencyMetrics sums operation seconds by namespace; divide by the
+	// namespace's operation count for the mean latency
+	latencyMetrics = expvar.NewMap("cache_operation_seconds_total")
+	// localKeys counts the keys held in process by tiered caches
+	localKeys atomic.Int64
+
+	// hotKeys tracks the most read keys once TrackHotKeys enabled it
+	hotKeys atomic.Pointer[hotKeyTracker]
+)
+
+func init() {
+	expvar.Publish("cache_hit_ratio", expvar.Func(func() interface{} {
+		return HitRatios()
+	}))
+	expvar.Publish("cache_local_keys", expvar.Func(func() interface{} {
+		return localKeys.Load()
+	}))
+}
+
+// Namespace returns the namespace of a key: its first ":" segment, e.g. "rbac"
+// for "rbac:user:1:roles:3"
+func Namespace(key string) string {
+	namespace, _, found := strings.Cut(key, ":")
+	if !found || namespace == "" {
+		return defaultNamespace
+	}
+	return namespace
+}
+
+// observe records an operation on key that took since start
+func observe(key, result string, start time.Time) {
+	namespace := Namespace(key)
+	operationMetrics.Add(namespace+":"+result, 1)
+	latencyMetrics.AddFloat(namespace, time.Since(start).Seconds())
+}
+
+// observeRead records a read, telling misses from errors, and counts the key
+// as read by the hot key tracker
+func observeRead(key string, err error, start time.Time) {
+	switch {
+	case err == nil:
+		observe(key, resultHit, start)
+	case errors.Is(err, ErrCacheMiss):
+		observe(key, resultMiss, start)
+	default:
+		observe(key, resultError, start)
+	}
+	if tracker := hotKeys.Load(); tracker != nil {
+		tracker.record(key)
+	}
+}
+
+// observeWrite records a write, or its error
+func observeWrite(key, result string, err error, start time.Time) {
+	if err != nil {
+		result = resultError
+	}
+	observe(key, result, start)
+}
+
+// HitRatios returns the share of reads served from the cache per namespace,
+// local hits included
+func HitRatios() map[string]float64 {
+	reads := make(map[string][2]int64) // hits, lookups
+	operationMetrics.Do(func(kv expvar.KeyValue) {
+		namespace, result, _ := strings.Cut(kv.Key, ":")
+		counter, ok := kv.Value.(*expvar.Int)
+		if !ok {
+			return
+		}
+		r := reads[namespace]
+		switch result {
+		case resultHit, resultLocalHit:
+			r[0] += counter.Value()
+			r[1] += counter.Value()
+		case resultMiss:
+			r[1] += counter.Value()
+		default:
+			return
+		}
+		reads[namespace] = r
+	})
+
+	ratios := make(map[string]float64, len(reads))
+	for namespace, r := range reads {
+		if r[1] > 0 {
+			ratios[namespace] = math.Round(float64(r[0])/float64(r[1])*1000) / 1000
+		}
+	}
+	return ratios
+}
+
+// HotKey is a key with the number of times it was read
+type HotKey struct {
+	Key       string `json:"key"`
+	Namespace string `json:"namespace"`
+	Reads     uint64 `json:"reads"`
+}
+
+// TrackHotKeys starts counting reads per key, keeping the size most read
+// keys. Tracking takes a lock on every read, so it is meant for development;
+// size 0 stops it.
+func TrackHotKeys(size int) {
+	if size <= 0 {
+		hotKeys.Store(nil)
+		return
+	}
+	hotKeys.Store(&hotKeyTracker{size: size, counts: make(map[string]uint64, size)})
+}
+
+// HotKeys returns the n most read keys, most read first, and false when
+// tracking is off. Counts are upper bounds once more keys than the tracked
+// size were read.
+func HotKeys(n int) ([]HotKey, bool) {
+	tracker := hotKeys.Load()
+	if tracker == nil {
+		return nil, false
+	}
+	return tracker.top(n), true
+}
+
+// hotKeyTracker approximates the most read keys with the Space-Saving
+// algorithm: an untracked key replaces the least read one and inherits its
+// count, so memory stays bounded and frequent keys are never lost
+type hotKeyTracker struct {
+	mu     sync.Mutex
+	size   int
+	counts map[string]uint64
+}
+
+func (t *hotKeyTracker) record(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, tracked := t.counts[key]; tracked || len(t.counts) < t.size {
+		t.counts[key]++
+		return
+	}
+	least, count := "", uint64(math.MaxUint64)
+	for k, c := range t.counts {
+		if c < count {
+			least, count = k, c
+		}
+	}
+	delete(t.counts, least)
+	t.counts[key] = count + 1
+}
+
+func (t *hotKeyTracker) top(n int) []HotKey {
+	t.mu.Lock()
+	keys := make([]HotKey, 0, len(t.counts))
+	for key, reads := range t.counts {
+		keys = append(keys, HotKey{Key: key, Namespace: Namespace(key), Reads: reads})
+	}
+	t.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Reads != keys[j].Reads {
+			return keys[i].Reads > keys[j].Reads
+		}
+		return keys[i].Key < keys[j].Key
+	})
+	if n > 0 && len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys
+}
+
+// CountKeys counts the keys stored in Redis per namespace by scanning every
+// key under the cache prefix, stopping after limit keys. Scanning is slow on
+// large databases, so it is meant for development.
+func CountKeys(ctx context.Context, c Cache, limit int) (map[string]int, bool, error) {
+	var r *RedisCache
+	switch v := c.(type) {
+	case *RedisCache:
+		r = v
+	case *TieredCache:
+		r = v.redis
+	default:
+		return nil, false, errors.New("key counts need a Redis cache")
+	}
+
+	counts := make(map[string]int)
+	scanned := 0
+	iter := r.client.Scan(ctx, 0, r.config.KeyPrefix+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		if limit > 0 && scanned >= limit {
+			return counts, true, nil
+		}
+		scanned++
+		key := strings.TrimPrefix(iter.Val(), r.config.KeyPrefix)
+		if strings.HasPrefix(key, "@") {
+			// Versioned keys carry "@<version>:" before their namespace
+			_, key, _ = strings.Cut(key, ":")
+		}
+		counts[Namespace(key)]++
+	}
+	return counts, false, iter.Err()
+}
@@ -11,8 +11,9 @@ import (
 
 // RedisCache implements Cache interface using Redis
 type RedisCache struct {
-	client *redis.Client
-	config *CacheConfig
+	client   *redis.Client
+	config   *CacheConfig
+	versions *versioning
 }
 
 // NewRedisCache creates a new Redis cache instance
@@ -21,15 +22,24 @@ func NewRedisCache(client *redis.Client, config *CacheConfig) Cache {
 		config = DefaultCacheConfig()
 	}
 	return &RedisCache{
-		client: client,
-		config: config,
+		client:   client,
+		config:   config,
+		versions: &versioning{},
 	}
 }
 
+// Client returns the underlying Redis client, for packages that need Redis
+// commands the Cache interface does not cover
+func (r *RedisCache) Client() *redis.Client {
+	return r.client
+}
+
 // Get retrieves a value from Redis cache
-func (r *RedisCache) Get(ctx context.Context, key string) (string, error) {
+func (r *RedisCache) Get(ctx context.Context, key string) (result string, err error) {
+	defer func(start time.Time) { observeRead(key, err, start) }(time.Now())
+
 	fullKey := r.buildKey(key)
-	result, err := r.client.Get(ctx, fullKey).Result()
+	result, err = r.client.Get(ctx, fullKey).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return "", ErrCacheMiss
@@ -40,13 +50,15 @@ func (r *RedisCache) Get(ctx context.Context, key string) (string, error) {
 }
 
 // Set stores a value in Redis cache with TTL
-func (r *RedisCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+func (r *RedisCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) (err error) {
+	defer func(start time.Time) { observeWrite(key, resultSet, err, start) }(time.Now())
+
 	fullKey := r.buildKey(key)
 	if ttl == 0 {
 		ttl = r.config.DefaultTTL
 	}
 
-	err := r.client.Set(ctx, fullKey, value, ttl).Err()
+	err = r.client.Set(ctx, fullKey, value, ttl).Err()
 	if err != nil {
 		return fmt.Errorf("failed to set key %s: %w", fullKey, err)
 	}
@@ -59,12 +71,16 @@ func (r *RedisCache) Del(ctx context.Context, keys ...string) error {
 		return nil
 	}
 
+	start := time.Now()
 	fullKeys := make([]string, len(keys))
 	for i, key := range keys {
 		fullKeys[i] = r.buildKey(key)
 	}
 
 	err := r.client.Del(ctx, fullKeys...).Err()
+	for _, key := range keys {
+		observeWrite(key, resultDel, err, start)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to delete keys: %w", err)
 	}
@@ -110,9 +126,11 @@ func (r *RedisCache) TTL(ctx context.Context, key string) (time.Duration, error)
 }
 
 // Incr increments a counter in Redis cache
-func (r *RedisCache) Incr(ctx context.Context, key string) (int64, error) {
+func (r *RedisCache) Incr(ctx context.Context, key string) (result int64, err error) {
+	defer func(start time.Time) { observeWrite(key, resultIncr, err, start) }(time.Now())
+
 	fullKey := r.buildKey(key)
-	result, err := r.client.Incr(ctx, fullKey).Result()
+	result, err = r.client.Incr(ctx, fullKey).Result()
 	if err != nil {
 		return 0, fmt.Errorf("failed to increment key %s: %w", fullKey, err)
 	}
@@ -120,9 +138,11 @@ func (r *RedisCache) Incr(ctx context.Context, key string) (int64, error) {
 }
 
 // IncrBy increments a counter by value in Redis cache
-func (r *RedisCache) IncrBy(ctx context.Context, key string, value int64) (int64, error) {
+func (r *RedisCache) IncrBy(ctx context.Context, key string, value int64) (result int64, err error) {
+	defer func(start time.Time) { observeWrite(key, resultIncr, err, start) }(time.Now())
+
 	fullKey := r.buildKey(key)
-	result, err := r.client.IncrBy(ctx, fullKey, value).Result()
+	result, err = r.client.IncrBy(ctx, fullKey, value).Result()
 	if err != nil {
 		return 0, fmt.Errorf("failed to increment key %s by %d: %w", fullKey, value, err)
 	}
@@ -176,10 +196,11 @@ func (r *RedisCache) FlushAll(ctx context.Context) error {
 	return nil
 }
 
-// buildKey creates a full key with prefix
+// buildKey creates a full key with prefix. Keys in versioned namespaces also
+// carry the cache version, e.g. "flex-service:@a1b2c3d-0:user:profile:1".
 func (r *RedisCache) buildKey(key string) string {
-	if r.config.KeyPrefix == "" {
-		return key
+	if r.versioned(key) {
+		key = "@" + r.versionLabel() + ":" + key
 	}
 	return r.config.KeyPrefix + key
 }
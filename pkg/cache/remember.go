@@ -0,0 +1,233 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"reflect"
+	"time"
+
+	"flex-service/pkg/logger"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// Store is the part of Cache and TaggedCache that Remember needs
+type Store interface {
+	GetJSON(ctx context.Context, key string, dest interface{}) error
+	SetJSON(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+}
+
+// RememberConfig configures stampede protection for Remember
+type RememberConfig struct {
+	// Stale is how long an expired value is kept to be served while another
+	// caller recomputes it
+	Stale time.Duration
+	// LockTTL bounds how long one caller may hold the recompute lock
+	LockTTL time.Duration
+	// Wait is how long callers without a stale value wait for the lock
+	// holder's result before computing it themselves
+	Wait time.Duration
+	// Jitter shortens each TTL by a random fraction up to this value, so keys
+	// written together do not expire together
+	Jitter float64
+}
+
+// DefaultRememberConfig returns default stampede protection configuration
+func DefaultRememberConfig() *RememberConfig {
+	return &RememberConfig{
+		Stale:   time.Minute,
+		LockTTL: 10 * time.Second,
+		Wait:    5 * time.Second,
+		Jitter:  0.1,
+	}
+}
+
+// rememberPoll is how often waiting callers look for the lock holder's result
+const rememberPoll = 50 * time.Millisecond
+
+// flights collapses concurrent Remember calls for a key within this process
+var flights singleflight.Group
+
+// remembered is the stored form of a Remember value; the key outlives
+// FreshUntil by RememberConfig.Stale
+type remembered[T any] struct {
+	Value      T         `json:"value"`
+	FreshUntil time.Time `json:"fresh_until"`
+}
+
+// Remember returns the cached value of key or stores the result of fn for ttl.
+// Concurrent callers in this process share one call of fn, and a short Redis
+// lock keeps other instances from recomputing the same key: they serve the
+// expired value meanwhile, or wait for the new one when there is none.
+// Errors from fn are returned and not cached. Callers sharing a call share the
+// returned value, so pointers and slices in T must not be modified.
+func Remember[T any](ctx context.Context, store Store, key string, ttl time.Duration, fn func() (T, error)) (T, error) {
+	return RememberWith(ctx, store, key, ttl, DefaultRememberConfig(), fn)
+}
+
+// RememberWith is Remember with explicit stampede protection configuration
+func RememberWith[T any](ctx context.Context, store Store, key string, ttl time.Duration, config *RememberConfig, fn func() (T, error)) (T, error) {
+	// The type is part of the flight key so callers asking for the same key
+	// as different types never receive each other's values
+	flight := key + "\x00" + reflect.TypeOf((*T)(nil)).Elem().String()
+
+	// The shared call must not fail for everyone when its first caller goes away
+	shared := context.WithoutCancel(ctx)
+	value, err, _ := flights.Do(flight, func() (interface{}, error) {
+		return remember(shared, store, key, ttl, config, fn)
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return value.(T), nil
+}
+
+func remember[T any](ctx context.Context, store Store, key string, ttl time.Duration, config *RememberConfig, fn func() (T, error)) (T, error) {
+	entry, found := lookup[T](ctx, store, key)
+	if found && time.Now().Before(entry.FreshUntil) {
+		return entry.Value, nil
+	}
+
+	l, _ := store.(locker)
+	if l != nil {
+		token, err := l.lock(ctx, key, config.LockTTL)
+		switch {
+		case err != nil:
+			// Without Redis there is no one to coordinate with
+			logger.Warn("Failed to take cache recompute lock", zap.String("key", key), zap.Error(err))
+		case token == "" && found:
+			return entry.Value, nil
+		case token == "":
+			if entry, ok := wait[T](ctx, store, key, config.Wait); ok {
+				return entry.Value, nil
+			}
+		default:
+			defer l.unlock(ctx, key, token)
+		}
+	}
+
+	value, err := fn()
+	if err != nil {
+		return value, err
+	}
+
+	ttl = jitter(ttl, config.Jitter)
+	stored := remembered[T]{Value: value, FreshUntil: time.Now().Add(ttl)}
+	if err := store.SetJSON(ctx, key, stored, ttl+config.Stale); err != nil {
+		logger.Warn("Failed to cache value", zap.String("key", key), zap.Error(err))
+	}
+	return value, nil
+}
+
+// lookup reads a stored value, treating unreadable entries as missing
+func lookup[T any](ctx context.Context, store Store, key string) (remembered[T], bool) {
+	var entry remembered[T]
+	err := store.GetJSON(ctx, key, &entry)
+	if err != nil && !errors.Is(err, ErrCacheMiss) {
+		logger.Warn("Failed to read cached value", zap.String("key", key), zap.Error(err))
+	}
+	// Entries written by Set or SetJSON have no freshness and are recomputed
+	return entry, err == nil && !entry.FreshUntil.IsZero()
+}
+
+// wait polls for a value written by the lock holder of another instance
+func wait[T any](ctx context.Context, store Store, key string, timeout time.Duration) (remembered[T], bool) {
+	ticker := time.NewTicker(rememberPoll)
+	defer ticker.Stop()
+	deadline := time.After(timeout)
+
+	for {
+		select {
+		case <-ticker.C:
+			if entry, ok := lookup[T](ctx, store, key); ok {
+				return entry, true
+			}
+		case <-deadline:
+			return remembered[T]{}, false
+		}
+	}
+}
+
+// jitter shortens ttl by a random fraction of up to factor
+func jitter(ttl time.Duration, factor float64) time.Duration {
+	spread := int64(float64(ttl) * factor)
+	if spread <= 0 {
+		return ttl
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(spread))
+	if err != nil {
+		return ttl
+	}
+	return ttl - time.Duration(n.Int64())
+}
+
+// locker is implemented by Redis-backed stores to coordinate recomputation
+// across instances
+type locker interface {
+	// lock returns a token when the lock was taken and "" when it is held
+	lock(ctx context.Context, key string, ttl time.Duration) (string, error)
+	unlock(ctx context.Context, key, token string)
+}
+
+// unlockScript deletes the lock only if it still holds our token, so a lock
+// that expired and was taken by another caller is left alone
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+func (r *RedisCache) lock(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(random)
+
+	ok, err := r.client.SetNX(ctx, r.lockKey(key), token, ttl).Result()
+	if err != nil || !ok {
+		return "", err
+	}
+	return token, nil
+}
+
+func (r *RedisCache) unlock(ctx context.Context, key, token string) {
+	if err := unlockScript.Run(ctx, r.client, []string{r.lockKey(key)}, token).Err(); err != nil {
+		// The lock expires on its own after LockTTL
+		logger.Warn("Failed to release cache recompute lock", zap.String("key", key), zap.Error(err))
+	}
+}
+
+func (r *RedisCache) lockKey(key string) string {
+	return r.buildKey("lock:remember:" + key)
+}
+
+func (t *TieredCache) lock(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	return t.redis.lock(ctx, key, ttl)
+}
+
+func (t *TieredCache) unlock(ctx context.Context, key, token string) {
+	t.redis.unlock(ctx, key, token)
+}
+
+func (t *redisTaggedCache) lock(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	return t.cache.lock(ctx, key, ttl)
+}
+
+func (t *redisTaggedCache) unlock(ctx context.Context, key, token string) {
+	t.cache.unlock(ctx, key, token)
+}
+
+func (t *tieredTaggedCache) lock(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	return t.cache.lock(ctx, key, ttl)
+}
+
+func (t *tieredTaggedCache) unlock(ctx context.Context, key, token string) {
+	t.cache.unlock(ctx, key, token)
+}
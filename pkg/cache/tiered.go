@@ -0,0 +1,294 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"flex-service/pkg/logger"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// ClientProvider is implemented by caches backed by a Redis client
+type ClientProvider interface {
+	Client() *redis.Client
+}
+
+// TieredConfig configures the in-process tier of TieredCache
+type TieredConfig struct {
+	// Size is the maximum number of keys kept in process
+	Size int
+	// TTL caps how long a value is served from process memory; it bounds
+	// staleness if an invalidation message is missed
+	TTL time.Duration
+}
+
+// DefaultTieredConfig returns default in-process tier configuration
+func DefaultTieredConfig() *TieredConfig {
+	return &TieredConfig{
+		Size: 10000,
+		TTL:  time.Minute,
+	}
+}
+
+// TieredCache serves reads from an in-process LRU in front of Redis. Writes
+// go to Redis and are announced on the <prefix>cache:invalidate channel so
+// every instance drops its local copy.
+type TieredCache struct {
+	redis  *RedisCache
+	local  *lru
+	ttl    time.Duration
+	origin string
+	pubsub *redis.PubSub
+}
+
+// invalidation is published after a write; Keys nil means every key
+type invalidation struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys,omitempty"`
+}
+
+// NewTieredCache creates a tiered cache and subscribes to invalidations
+func NewTieredCache(client *redis.Client, config *CacheConfig, tiered *TieredConfig) Cache {
+	if tiered == nil {
+		tiered = DefaultTieredConfig()
+	}
+
+	origin := make([]byte, 8)
+	_, _ = rand.Read(origin)
+
+	t := &TieredCache{
+		redis:  NewRedisCache(client, config).(*RedisCache),
+		local:  newLRU(tiered.Size),
+		ttl:    tiered.TTL,
+		origin: hex.EncodeToString(origin),
+	}
+	t.pubsub = client.Subscribe(context.Background(), t.channel())
+	go t.listen()
+	return t
+}
+
+// Client returns the underlying Redis client
+func (t *TieredCache) Client() *redis.Client {
+	return t.redis.Client()
+}
+
+// Get retrieves a value from process memory, falling back to Redis
+func (t *TieredCache) Get(ctx context.Context, key string) (string, error) {
+	start := time.Now()
+	if value, ok := t.local.get(key); ok {
+		observe(key, resultLocalHit, start)
+		if tracker := hotKeys.Load(); tracker != nil {
+			tracker.record(key)
+		}
+		return value, nil
+	}
+
+	generation := t.local.snapshot()
+	value, err := t.redis.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	t.local.set(key, value, t.ttl, generation)
+	return value, nil
+}
+
+// Set stores a value in Redis and invalidates it everywhere
+func (t *TieredCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if err := t.redis.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	t.invalidate(ctx, key)
+	return nil
+}
+
+// Del deletes keys from Redis and every instance's memory
+func (t *TieredCache) Del(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := t.redis.Del(ctx, keys...); err != nil {
+		return err
+	}
+	t.invalidate(ctx, keys...)
+	return nil
+}
+
+// Exists checks if keys exist in Redis
+func (t *TieredCache) Exists(ctx context.Context, keys ...string) (int64, error) {
+	return t.redis.Exists(ctx, keys...)
+}
+
+// Expire sets TTL for a key in Redis and drops local copies, which may
+// outlive a shortened TTL
+func (t *TieredCache) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	if err := t.redis.Expire(ctx, key, ttl); err != nil {
+		return err
+	}
+	t.invalidate(ctx, key)
+	return nil
+}
+
+// TTL returns the remaining TTL of a key in Redis
+func (t *TieredCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return t.redis.TTL(ctx, key)
+}
+
+// Incr increments a counter in Redis
+func (t *TieredCache) Incr(ctx context.Context, key string) (int64, error) {
+	return t.IncrBy(ctx, key, 1)
+}
+
+// IncrBy increments a counter by value in Redis. Counters sit on hot paths
+// such as rate limiting, so the increment and its invalidation share one
+// round trip.
+func (t *TieredCache) IncrBy(ctx context.Context, key string, value int64) (result int64, err error) {
+	defer func(start time.Time) { observeWrite(key, resultIncr, err, start) }(time.Now())
+
+	fullKey := t.redis.buildKey(key)
+	message, _ := json.Marshal(invalidation{Origin: t.origin, Keys: []string{key}})
+
+	pipe := t.redis.client.TxPipeline()
+	incr := pipe.IncrBy(ctx, fullKey, value)
+	pipe.Publish(ctx, t.channel(), message)
+	if _, err = pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to increment key %s by %d: %w", fullKey, value, err)
+	}
+	t.local.remove(key)
+	return incr.Val(), nil
+}
+
+// GetJSON retrieves and unmarshals JSON data
+func (t *TieredCache) GetJSON(ctx context.Context, key string, dest interface{}) error {
+	data, err := t.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal([]byte(data), dest); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON for key %s: %w", key, err)
+	}
+	return nil
+}
+
+// SetJSON marshals and stores JSON data
+func (t *TieredCache) SetJSON(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON for key %s: %w", key, err)
+	}
+
+	return t.Set(ctx, key, string(data), ttl)
+}
+
+// Close stops listening for invalidations and closes the Redis connection
+func (t *TieredCache) Close() error {
+	_ = t.pubsub.Close()
+	return t.redis.Close()
+}
+
+// Ping checks if Redis is available
+func (t *TieredCache) Ping(ctx context.Context) error {
+	return t.redis.Ping(ctx)
+}
+
+// FlushAll clears Redis and every instance's memory (use with caution)
+func (t *TieredCache) FlushAll(ctx context.Context) error {
+	if err := t.redis.FlushAll(ctx); err != nil {
+		return err
+	}
+	t.invalidate(ctx)
+	return nil
+}
+
+// Tags returns a cache whose writes are grouped under the given tags
+func (t *TieredCache) Tags(tags ...string) TaggedCache {
+	return &tieredTaggedCache{cache: t, tagged: t.redis.Tags(tags...)}
+}
+
+// tieredTaggedCache reads through the local tier and invalidates it on writes
+type tieredTaggedCache struct {
+	cache  *TieredCache
+	tagged TaggedCache
+}
+
+func (t *tieredTaggedCache) Get(ctx context.Context, key string) (string, error) {
+	return t.cache.Get(ctx, key)
+}
+
+func (t *tieredTaggedCache) GetJSON(ctx context.Context, key string, dest interface{}) error {
+	return t.cache.GetJSON(ctx, key, dest)
+}
+
+func (t *tieredTaggedCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if err := t.tagged.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	t.cache.invalidate(ctx, key)
+	return nil
+}
+
+func (t *tieredTaggedCache) SetJSON(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON for key %s: %w", key, err)
+	}
+
+	return t.Set(ctx, key, string(data), ttl)
+}
+
+// Flush deletes the tagged keys from Redis. Tag members are not tracked in
+// process, so every instance drops its whole local tier.
+func (t *tieredTaggedCache) Flush(ctx context.Context) error {
+	if err := t.tagged.Flush(ctx); err != nil {
+		return err
+	}
+	t.cache.invalidate(ctx)
+	return nil
+}
+
+// invalidate drops keys (all keys when none are given) locally and tells the
+// other instances to do the same
+func (t *TieredCache) invalidate(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		t.local.purge()
+	} else {
+		t.local.remove(keys...)
+	}
+
+	message, _ := json.Marshal(invalidation{Origin: t.origin, Keys: keys})
+	if err := t.redis.client.Publish(ctx, t.channel(), message).Err(); err != nil {
+		// Other instances serve their copy until the local TTL runs out
+		logger.Warn("Failed to publish cache invalidation", zap.Error(err), zap.Strings("keys", keys))
+	}
+}
+
+// listen applies invalidations published by other instances
+func (t *TieredCache) listen() {
+	for message := range t.pubsub.Channel() {
+		var inv invalidation
+		if err := json.Unmarshal([]byte(message.Payload), &inv); err != nil {
+			logger.Warn("Invalid cache invalidation message", zap.Error(err))
+			continue
+		}
+		if inv.Origin == t.origin {
+			continue
+		}
+
+		if len(inv.Keys) == 0 {
+			t.local.purge()
+		} else {
+			t.local.remove(inv.Keys...)
+		}
+	}
+}
+
+// channel is never versioned, so instances of every deploy hear each other
+func (t *TieredCache) channel() string {
+	return t.redis.config.KeyPrefix + "cache:invalidate"
+}
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// VersionSyncInterval is how often an instance checks the shared cache
+// generation, so a bump reaches every instance within this interval
+const VersionSyncInterval = 10 * time.Second
+
+// generationKey holds the generation bumped by `artisan -action=cache:version`
+const generationKey = "cache:generation"
+
+// versioning tracks the shared generation embedded in versioned keys
+type versioning struct {
+	mu         sync.Mutex
+	generation int64
+	checkedAt  time.Time
+}
+
+// versioned reports whether key is in one of the configured namespaces
+func (r *RedisCache) versioned(key string) bool {
+	for _, namespace := range r.config.Versioned {
+		if strings.HasPrefix(key, namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// versionLabel is the deploy version and shared generation embedded in
+// versioned keys, e.g. "a1b2c3d-4"
+func (r *RedisCache) versionLabel() string {
+	v := r.versions
+	v.mu.Lock()
+	if time.Since(v.checkedAt) >= VersionSyncInterval {
+		v.checkedAt = time.Now()
+		v.mu.Unlock()
+
+		// Keep the last known generation when Redis cannot be read
+		if generation, err := readGeneration(context.Background(), r.client, r.config); err == nil {
+			v.mu.Lock()
+			v.generation = generation
+			v.mu.Unlock()
+		}
+		v.mu.Lock()
+	}
+	generation := v.generation
+	v.mu.Unlock()
+
+	return label(r.config.Version, generation)
+}
+
+// CurrentVersion returns the label embedded in versioned keys right now
+func CurrentVersion(ctx context.Context, client *redis.Client, config *CacheConfig) (string, error) {
+	generation, err := readGeneration(ctx, client, config)
+	if err != nil {
+		return "", err
+	}
+	return label(config.Version, generation), nil
+}
+
+// BumpVersion increments the shared generation, so every versioned key
+// written so far is abandoned and left to expire. Tiered caches are told to
+// drop their local copies at once.
+func BumpVersion(ctx context.Context, client *redis.Client, config *CacheConfig) (int64, error) {
+	generation, err := client.Incr(ctx, config.KeyPrefix+generationKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to bump cache version: %w", err)
+	}
+
+	message, _ := json.Marshal(invalidation{Origin: "version"})
+	if err := client.Publish(ctx, config.KeyPrefix+"cache:invalidate", message).Err(); err != nil {
+		return generation, fmt.Errorf("cache version bumped but tiered caches were not notified: %w", err)
+	}
+	return generation, nil
+}
+
+func readGeneration(ctx context.Context, client *redis.Client, config *CacheConfig) (int64, error) {
+	raw, err := client.Get(ctx, config.KeyPrefix+generationKey).Result()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache version: %w", err)
+	}
+	return strconv.ParseInt(raw, 10, 64)
+}
+
+func label(version string, generation int64) string {
+	if version == "" {
+		version = "dev"
+	}
+	return version + "-" + strconv.FormatInt(generation, 10)
+}
@@ -0,0 +1,216 @@
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Config configures cross-origin requests. Origins are matched exactly, with
+// a wildcard subdomain (https://*.example.com), or "*" for any origin; an
+// origin prefixed with "!" is refused even when another entry matches, e.g.
+// "*,!https://untrusted.example.com".
+type Config struct {
+	AllowedOrigins []string
+	AllowedMethods []string
+	// AllowedHeaders may be "*" to allow whatever headers a preflight asks for
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight; 0 sends no header
+	MaxAge time.Duration
+	// Routes override the allowed origins under path prefixes; the longest
+	// matching prefix wins
+	Routes []Route
+}
+
+// Route overrides the allowed origins of the paths under Prefix
+type Route struct {
+	Prefix  string
+	Origins []string
+}
+
+// Default request settings, used when the config leaves them empty
+var (
+	DefaultMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions}
+	DefaultHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "X-CSRF-Token", "X-API-Key", "Idempotency-Key"}
+)
+
+// ParseRoutes parses "<prefix>=<origin>,<origin>;<prefix>=..." route
+// overrides, skipping malformed entries
+func ParseRoutes(value string) []Route {
+	var routes []Route
+	for _, entry := range strings.Split(value, ";") {
+		prefix, origins, ok := strings.Cut(entry, "=")
+		prefix = strings.TrimSpace(prefix)
+		if !ok || !strings.HasPrefix(prefix, "/") {
+			continue
+		}
+		route := Route{Prefix: prefix}
+		for _, origin := range strings.Split(origins, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				route.Origins = append(route.Origins, origin)
+			}
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// AllowsAnyOrigin reports whether origins contains "*"
+func AllowsAnyOrigin(origins []string) bool {
+	return containsWildcard(origins)
+}
+
+func containsWildcard(values []string) bool {
+	for _, value := range values {
+		if strings.TrimSpace(value) == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// matcher decides which origins are allowed
+type matcher struct {
+	any      bool
+	exact    map[string]bool
+	schemes  []string // scheme and domain suffix of each wildcard subdomain
+	suffixes []string
+	excluded map[string]bool
+}
+
+func newMatcher(origins []string) *matcher {
+	m := &matcher{exact: make(map[string]bool), excluded: make(map[string]bool)}
+	for _, origin := range origins {
+		origin = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+		switch {
+		case origin == "":
+			continue
+		case origin == "*":
+			m.any = true
+		case strings.HasPrefix(origin, "!"):
+			m.excluded[strings.TrimPrefix(origin, "!")] = true
+		case strings.Contains(origin, "://*."):
+			scheme, domain, _ := strings.Cut(origin, "://*")
+			m.schemes = append(m.schemes, scheme+"://")
+			m.suffixes = append(m.suffixes, domain)
+		default:
+			m.exact[origin] = true
+		}
+	}
+	return m
+}
+
+func (m *matcher) allows(origin string) bool {
+	origin = strings.ToLower(origin)
+	if m.excluded[origin] {
+		return false
+	}
+	if m.any || m.exact[origin] {
+		return true
+	}
+	for i, suffix := range m.suffixes {
+		if strings.HasPrefix(origin, m.schemes[i]) && strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+type routeMatcher struct {
+	prefix  string
+	matcher *matcher
+}
+
+// Middleware answers preflight requests and adds the CORS headers to
+// requests from allowed origins. Requests from other origins get no CORS
+// headers, so browsers block them; non-browser clients are unaffected.
+// Allowed origins are echoed rather than sent as "*", so "*" works with
+// credentials.
+func Middleware(cfg Config) gin.HandlerFunc {
+	methods := cfg.AllowedMethods
+	if len(methods) == 0 {
+		methods = DefaultMethods
+	}
+	headers := cfg.AllowedHeaders
+	if len(headers) == 0 {
+		headers = DefaultHeaders
+	}
+	anyHeader := containsWildcard(headers)
+
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(headers, ", ")
+	exposeHeaders := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := ""
+	if cfg.MaxAge > 0 {
+		maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+
+	global := newMatcher(cfg.AllowedOrigins)
+	routes := make([]routeMatcher, 0, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		routes = append(routes, routeMatcher{prefix: route.Prefix, matcher: newMatcher(route.Origins)})
+	}
+	matcherFor := func(path string) *matcher {
+		best, length := global, -1
+		for _, route := range routes {
+			if strings.HasPrefix(path, route.prefix) && len(route.prefix) > length {
+				best, length = route.matcher, len(route.prefix)
+			}
+		}
+		return best
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if origin != "" {
+			// Shared caches must not reuse a response across origins
+			c.Writer.Header().Add("Vary", "Origin")
+		}
+
+		if origin == "" || !matcherFor(c.Request.URL.Path).allows(origin) {
+			if c.Request.Method == http.MethodOptions {
+				c.AbortWithStatus(http.StatusNoContent)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Set("Access-Control-Allow-Origin", origin)
+		if cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", allowMethods)
+			if anyHeader {
+				h.Set("Access-Control-Allow-Headers", c.GetHeader("Access-Control-Request-Headers"))
+			} else {
+				h.Set("Access-Control-Allow-Headers", allowHeaders)
+			}
+			if maxAge != "" {
+				h.Set("Access-Control-Max-Age", maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		if exposeHeaders != "" {
+			h.Set("Access-Control-Expose-Headers", exposeHeaders)
+		}
+		if c.Request.Method == http.MethodOptions {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,442 @@
+package counter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"flex-service/pkg/logger"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// Dirty set member kinds
+const (
+	kindValue  = "v"
+	kindSketch = "u"
+)
+
+// incrScript increments a counter that is loaded in Redis (or may be created,
+// when ARGV[4] is "1") and marks it for the next flush. It returns nil when
+// the counter has to be loaded from the database first.
+var incrScript = redis.NewScript(`
+if ARGV[4] ~= "1" and redis.call("EXISTS", KEYS[1]) == 0 then
+	return false
+end
+local value = redis.call("INCRBY", KEYS[1], ARGV[1])
+redis.call("EXPIRE", KEYS[1], ARGV[2])
+redis.call("SADD", KEYS[2], ARGV[3])
+return value`)
+
+// pfaddScript is incrScript for HyperLogLogs; only changed sketches are
+// marked for the next flush
+var pfaddScript = redis.NewScript(`
+if ARGV[3] ~= "1" and redis.call("EXISTS", KEYS[1]) == 0 then
+	return false
+end
+local changed = redis.call("PFADD", KEYS[1], unpack(ARGV, 4))
+redis.call("EXPIRE", KEYS[1], ARGV[1])
+if changed == 1 then
+	redis.call("SADD", KEYS[2], ARGV[2])
+end
+return changed`)
+
+// Service keeps high-frequency counters in Redis and persists them to the
+// database in batches. Counters are loaded from the database when they are
+// not in Redis, so Redis only holds recently used ones.
+type Service struct {
+	client *redis.Client
+	store  Store
+	config *Config
+}
+
+// NewService creates a counter service
+func NewService(client *redis.Client, store Store, config *Config) *Service {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &Service{
+		client: client,
+		store:  store,
+		config: config,
+	}
+}
+
+// Incr increments a counter by one and returns the new value
+func (s *Service) Incr(ctx context.Context, name, subject string) (int64, error) {
+	return s.IncrBy(ctx, name, subject, 1)
+}
+
+// IncrBy adds delta (which may be negative) to a counter and returns the new
+// value
+func (s *Service) IncrBy(ctx context.Context, name, subject string, delta int64) (int64, error) {
+	if name == "" || subject == "" {
+		return 0, ErrInvalidCounter
+	}
+
+	key := s.valueKey(name, subject)
+	run := func() (int64, error) {
+		return incrScript.Run(ctx, s.client, []string{key, s.dirtyKey()},
+			delta, s.ttlSeconds(), member(kindValue, name, subject), "0").Int64()
+	}
+
+	value, err := run()
+	if err != redis.Nil {
+		return value, wrap(err, "increment", name, subject)
+	}
+
+	if err := s.loadValues(ctx, name, []string{subject}); err != nil {
+		return 0, err
+	}
+	value, err = run()
+	if err == redis.Nil {
+		return 0, ErrUnavailable
+	}
+	return value, wrap(err, "increment", name, subject)
+}
+
+// Get returns the current value of a counter
+func (s *Service) Get(ctx context.Context, name, subject string) (int64, error) {
+	values, err := s.GetMany(ctx, name, subject)
+	if err != nil {
+		return 0, err
+	}
+	return values[subject], nil
+}
+
+// GetMany returns the current values of several subjects of one counter, e.g.
+// the view counts of a page of products
+func (s *Service) GetMany(ctx context.Context, name string, subjects ...string) (map[string]int64, error) {
+	values := make(map[string]int64, len(subjects))
+	if len(subjects) == 0 {
+		return values, nil
+	}
+	if name == "" {
+		return nil, ErrInvalidCounter
+	}
+
+	missing, err := s.read(ctx, name, subjects, values)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	if err := s.loadValues(ctx, name, missing); err != nil {
+		return nil, err
+	}
+	if _, err := s.read(ctx, name, missing, values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// AddUnique records members (e.g. user or visitor IDs) in a HyperLogLog and
+// reports whether the estimated count changed
+func (s *Service) AddUnique(ctx context.Context, name, subject string, members ...string) (bool, error) {
+	if name == "" || subject == "" {
+		return false, ErrInvalidCounter
+	}
+	if len(members) == 0 {
+		return false, nil
+	}
+
+	key := s.sketchKey(name, subject)
+	run := func(create bool) (bool, error) {
+		args := []interface{}{s.ttlSeconds(), member(kindSketch, name, subject), "0"}
+		if create {
+			args[2] = "1"
+		}
+		for _, m := range members {
+			args = append(args, m)
+		}
+		changed, err := pfaddScript.Run(ctx, s.client, []string{key, s.dirtyKey()}, args...).Int64()
+		return changed == 1, err
+	}
+
+	changed, err := run(false)
+	if err != redis.Nil {
+		return changed, wrap(err, "add unique members to", name, subject)
+	}
+
+	// A subject without a persisted sketch is new and may be created
+	found, err := s.loadSketch(ctx, name, subject)
+	if err != nil {
+		return false, err
+	}
+	changed, err = run(!found)
+	if err == redis.Nil {
+		return false, ErrUnavailable
+	}
+	return changed, wrap(err, "add unique members to", name, subject)
+}
+
+// CountUnique returns the approximate number of distinct members recorded
+// for subjects, counting members of several subjects once (standard error
+// 0.81%)
+func (s *Service) CountUnique(ctx context.Context, name string, subjects ...string) (int64, error) {
+	if name == "" || len(subjects) == 0 {
+		return 0, ErrInvalidCounter
+	}
+
+	keys := make([]string, len(subjects))
+	exists := make([]*redis.IntCmd, len(subjects))
+	pipe := s.client.Pipeline()
+	for i, subject := range subjects {
+		keys[i] = s.sketchKey(name, subject)
+		exists[i] = pipe.Exists(ctx, keys[i])
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to read unique counter %s: %w", name, err)
+	}
+	for i, subject := range subjects {
+		if exists[i].Val() == 0 {
+			if _, err := s.loadSketch(ctx, name, subject); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	count, err := s.client.PFCount(ctx, keys...).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unique counter %s: %w", name, err)
+	}
+	return count, nil
+}
+
+// Top returns the highest counters of name. The ranking comes from the
+// database, so it trails Redis by up to one flush; values are current.
+func (s *Service) Top(ctx context.Context, name string, limit int) ([]Counter, error) {
+	counters, err := s.store.Top(ctx, name, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load top counters %s: %w", name, err)
+	}
+
+	subjects := make([]string, len(counters))
+	for i, counter := range counters {
+		subjects[i] = counter.Subject
+	}
+	values, err := s.GetMany(ctx, name, subjects...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range counters {
+		counters[i].Value = values[counters[i].Subject]
+	}
+	return counters, nil
+}
+
+// Flush persists every counter and sketch changed since the last flush and
+// returns how many were written. Failed batches are kept for the next flush.
+func (s *Service) Flush(ctx context.Context) (int, error) {
+	flushed := 0
+	for {
+		members, err := s.client.SPopN(ctx, s.dirtyKey(), int64(s.config.BatchSize)).Result()
+		if err != nil {
+			return flushed, fmt.Errorf("failed to read changed counters: %w", err)
+		}
+		if len(members) == 0 {
+			return flushed, nil
+		}
+
+		if err := s.persist(ctx, members); err != nil {
+			pending := make([]interface{}, len(members))
+			for i, m := range members {
+				pending[i] = m
+			}
+			if restoreErr := s.client.SAdd(ctx, s.dirtyKey(), pending...).Err(); restoreErr != nil {
+				logger.Error("Failed to requeue unflushed counters", zap.Int("count", len(members)), zap.Error(restoreErr))
+			}
+			return flushed, err
+		}
+
+		flushed += len(members)
+		if len(members) < s.config.BatchSize {
+			return flushed, nil
+		}
+	}
+}
+
+// Start runs Run in a goroutine
+func (s *Service) Start(ctx context.Context, interval time.Duration) {
+	go s.Run(ctx, interval)
+}
+
+// Run calls Flush every interval until ctx is done, then flushes once more
+// before returning
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if _, err := s.Flush(context.Background()); err != nil {
+				logger.Warn("Failed to flush counters", zap.Error(err))
+			}
+			return
+		case <-ticker.C:
+			if _, err := s.Flush(ctx); err != nil {
+				logger.Warn("Failed to flush counters", zap.Error(err))
+			}
+		}
+	}
+}
+
+// persist writes the current Redis state of the given dirty members. Values
+// are absolute, so writing a member twice is harmless.
+func (s *Service) persist(ctx context.Context, members []string) error {
+	type pending struct {
+		kind, name, subject string
+		cmd                 *redis.StringCmd
+	}
+
+	items := make([]pending, 0, len(members))
+	pipe := s.client.Pipeline()
+	for _, m := range members {
+		kind, name, subject, ok := parseMember(m)
+		if !ok {
+			continue
+		}
+		key := s.valueKey(name, subject)
+		if kind == kindSketch {
+			key = s.sketchKey(name, subject)
+		}
+		items = append(items, pending{kind: kind, name: name, subject: subject, cmd: pipe.Get(ctx, key)})
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to read changed counters: %w", err)
+	}
+
+	var counters []Counter
+	var sketches []Sketch
+	for _, item := range items {
+		data, err := item.cmd.Bytes()
+		if err != nil {
+			// Expired before it was flushed; KeyTTL is too short for the interval
+			continue
+		}
+		if item.kind == kindSketch {
+			sketches = append(sketches, Sketch{Name: item.name, Subject: item.subject, Data: data})
+			continue
+		}
+		value, err := strconv.ParseInt(string(data), 10, 64)
+		if err != nil {
+			continue
+		}
+		counters = append(counters, Counter{Name: item.name, Subject: item.subject, Value: value})
+	}
+
+	if len(counters) > 0 {
+		if err := s.store.SaveValues(ctx, counters); err != nil {
+			return fmt.Errorf("failed to persist counters: %w", err)
+		}
+	}
+	if len(sketches) > 0 {
+		if err := s.store.SaveSketches(ctx, sketches); err != nil {
+			return fmt.Errorf("failed to persist unique counters: %w", err)
+		}
+	}
+	return nil
+}
+
+// read fills values with subjects found in Redis and returns the others
+func (s *Service) read(ctx context.Context, name string, subjects []string, values map[string]int64) ([]string, error) {
+	keys := make([]string, len(subjects))
+	for i, subject := range subjects {
+		keys[i] = s.valueKey(name, subject)
+	}
+	results, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read counter %s: %w", name, err)
+	}
+
+	var missing []string
+	for i, result := range results {
+		text, ok := result.(string)
+		if !ok {
+			missing = append(missing, subjects[i])
+			continue
+		}
+		value, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for counter %s of %s: %w", name, subjects[i], err)
+		}
+		values[subjects[i]] = value
+	}
+	return missing, nil
+}
+
+// loadValues copies persisted values into Redis unless another caller
+// already did
+func (s *Service) loadValues(ctx context.Context, name string, subjects []string) error {
+	persisted, err := s.store.Values(ctx, name, subjects)
+	if err != nil {
+		return fmt.Errorf("failed to load counter %s: %w", name, err)
+	}
+
+	pipe := s.client.Pipeline()
+	for _, subject := range subjects {
+		pipe.SetNX(ctx, s.valueKey(name, subject), persisted[subject], s.config.KeyTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to load counter %s: %w", name, err)
+	}
+	return nil
+}
+
+// loadSketch copies a persisted HyperLogLog into Redis unless another caller
+// already did, reporting whether one was persisted
+func (s *Service) loadSketch(ctx context.Context, name, subject string) (bool, error) {
+	data, err := s.store.Sketch(ctx, name, subject)
+	if err != nil {
+		return false, fmt.Errorf("failed to load unique counter %s of %s: %w", name, subject, err)
+	}
+	if data == nil {
+		return false, nil
+	}
+	if err := s.client.SetNX(ctx, s.sketchKey(name, subject), data, s.config.KeyTTL).Err(); err != nil {
+		return false, fmt.Errorf("failed to load unique counter %s of %s: %w", name, subject, err)
+	}
+	return true, nil
+}
+
+func (s *Service) valueKey(name, subject string) string {
+	return s.config.Prefix + "v:" + name + ":" + subject
+}
+
+func (s *Service) sketchKey(name, subject string) string {
+	return s.config.Prefix + "u:" + name + ":" + subject
+}
+
+func (s *Service) dirtyKey() string {
+	return s.config.Prefix + "dirty"
+}
+
+func (s *Service) ttlSeconds() int64 {
+	return int64(s.config.KeyTTL / time.Second)
+}
+
+// member encodes a counter in the dirty set; names and subjects may contain
+// colons, so NUL separates the parts
+func member(kind, name, subject string) string {
+	return kind + "\x00" + name + "\x00" + subject
+}
+
+func parseMember(m string) (kind, name, subject string, ok bool) {
+	parts := strings.SplitN(m, "\x00", 3)
+	if len(parts) != 3 {
+		return "", "", "", false
+	}
+	return parts[0], parts[1], parts[2], true
+}
+
+func wrap(err error, action, name, subject string) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("failed to %s counter %s of %s: %w", action, name, subject, err)
+}
@@ -0,0 +1,9 @@
+package counter
+
+import "errors"
+
+// Counter errors
+var (
+	ErrInvalidCounter = errors.New("counter name and subject are required")
+	ErrUnavailable    = errors.New("counter could not be loaded into Redis")
+)
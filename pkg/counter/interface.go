@@ -0,0 +1,69 @@
+package counter
+
+import (
+	"context"
+	"time"
+)
+
+// Counter is the persisted value of one counter
+type Counter struct {
+	ID        int       `json:"-" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"type:varchar(100);not null;uniqueIndex:idx_counter_subject;index:idx_counter_value,priority:1"`
+	Subject   string    `json:"subject" gorm:"type:varchar(191);not null;uniqueIndex:idx_counter_subject"`
+	Value     int64     `json:"value" gorm:"not null;default:0;index:idx_counter_value,priority:2"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for GORM
+func (Counter) TableName() string {
+	return "tb_counter"
+}
+
+// Sketch is the persisted HyperLogLog of one unique counter
+type Sketch struct {
+	ID        int    `gorm:"primaryKey"`
+	Name      string `gorm:"type:varchar(100);not null;uniqueIndex:idx_counter_sketch_subject"`
+	Subject   string `gorm:"type:varchar(191);not null;uniqueIndex:idx_counter_sketch_subject"`
+	Data      []byte `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+// TableName returns the table name for GORM
+func (Sketch) TableName() string {
+	return "tb_counter_sketch"
+}
+
+// Store persists flushed counters and sketches
+type Store interface {
+	// Values returns the persisted values of subjects; missing ones are absent
+	Values(ctx context.Context, name string, subjects []string) (map[string]int64, error)
+	// SaveValues stores absolute values, replacing the persisted ones
+	SaveValues(ctx context.Context, counters []Counter) error
+	// Sketch returns the persisted HyperLogLog or nil when there is none
+	Sketch(ctx context.Context, name, subject string) ([]byte, error)
+	// SaveSketches stores sketches, replacing the persisted ones
+	SaveSketches(ctx context.Context, sketches []Sketch) error
+	// Top returns the highest persisted counters of name
+	Top(ctx context.Context, name string, limit int) ([]Counter, error)
+}
+
+// Config configures the counter service
+type Config struct {
+	// Prefix is prepended to every Redis key
+	Prefix string
+	// KeyTTL is how long an idle counter stays in Redis; it is loaded from
+	// the database again afterwards, so it must be well above the flush
+	// interval
+	KeyTTL time.Duration
+	// BatchSize is the number of changed counters written per flush query
+	BatchSize int
+}
+
+// DefaultConfig returns default counter configuration
+func DefaultConfig() *Config {
+	return &Config{
+		Prefix:    "counter:",
+		KeyTTL:    24 * time.Hour,
+		BatchSize: 500,
+	}
+}
@@ -0,0 +1,86 @@
+package counter
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormStore persists counters with GORM
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore creates a GORM-backed store
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+// Values returns the persisted values of subjects
+func (s *GormStore) Values(ctx context.Context, name string, subjects []string) (map[string]int64, error) {
+	var counters []Counter
+	err := s.db.WithContext(ctx).
+		Where("name = ? AND subject IN ?", name, subjects).
+		Find(&counters).Error
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]int64, len(counters))
+	for _, counter := range counters {
+		values[counter.Subject] = counter.Value
+	}
+	return values, nil
+}
+
+// SaveValues upserts absolute counter values
+func (s *GormStore) SaveValues(ctx context.Context, counters []Counter) error {
+	now := time.Now()
+	for i := range counters {
+		counters[i].UpdatedAt = now
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}, {Name: "subject"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(&counters).Error
+}
+
+// Sketch returns the persisted HyperLogLog or nil when there is none
+func (s *GormStore) Sketch(ctx context.Context, name, subject string) ([]byte, error) {
+	var sketch Sketch
+	err := s.db.WithContext(ctx).
+		Where("name = ? AND subject = ?", name, subject).
+		First(&sketch).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sketch.Data, nil
+}
+
+// SaveSketches upserts HyperLogLogs
+func (s *GormStore) SaveSketches(ctx context.Context, sketches []Sketch) error {
+	now := time.Now()
+	for i := range sketches {
+		sketches[i].UpdatedAt = now
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}, {Name: "subject"}},
+		DoUpdates: clause.AssignmentColumns([]string{"data", "updated_at"}),
+	}).Create(&sketches).Error
+}
+
+// Top returns the highest persisted counters of name
+func (s *GormStore) Top(ctx context.Context, name string, limit int) ([]Counter, error) {
+	var counters []Counter
+	err := s.db.WithContext(ctx).
+		Where("name = ?", name).
+		Order("value DESC").
+		Limit(limit).
+		Find(&counters).Error
+	return counters, err
+}
@@ -0,0 +1,296 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Overview describes the connected database
+type Overview struct {
+	Driver   string
+	Version  string
+	Database string
+	// Size is the on-disk size in bytes, 0 when the driver cannot tell
+	Size   int64
+	Tables []TableSummary
+}
+
+// TableSummary is a table with its row count and size. MySQL and PostgreSQL
+// report estimated rows from their statistics; SQLite counts them but has no
+// per-table size.
+type TableSummary struct {
+	Name string
+	Rows int64 `gorm:"column:row_count"`
+	Size int64
+}
+
+// TableDescription lists the columns, indexes and foreign keys of a table
+type TableDescription struct {
+	Name        string
+	Columns     []Column
+	Indexes     []Index
+	ForeignKeys []ForeignKey
+}
+
+// Column is a table column as reported by the GORM migrator
+type Column struct {
+	Name          string
+	Type          string
+	Nullable      bool
+	Default       string
+	HasDefault    bool
+	PrimaryKey    bool
+	AutoIncrement bool
+}
+
+// Index is a table index, including the primary key
+type Index struct {
+	Name       string
+	Columns    []string
+	Unique     bool
+	PrimaryKey bool
+}
+
+// ForeignKey is a constraint from Columns to RefColumns of RefTable
+type ForeignKey struct {
+	Name       string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	OnUpdate   string
+	OnDelete   string
+}
+
+// Tables returns the base tables of the connected database, without views
+// and SQLite's internal tables
+func Tables(db *gorm.DB) ([]string, error) {
+	var tables []string
+	var err error
+	switch db.Dialector.Name() {
+	case "mysql":
+		err = db.Raw("SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'").
+			Scan(&tables).Error
+	case "sqlite":
+		err = db.Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite\\_%' ESCAPE '\\'").
+			Scan(&tables).Error
+	default:
+		tables, err = db.Migrator().GetTables()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	sort.Strings(tables)
+	return tables, nil
+}
+
+// Inspect returns the version, size and tables of the connected database
+func Inspect(db *gorm.DB) (*Overview, error) {
+	overview := &Overview{
+		Driver:   db.Dialector.Name(),
+		Database: db.Migrator().CurrentDatabase(),
+	}
+
+	var err error
+	switch overview.Driver {
+	case "mysql":
+		err = db.Raw("SELECT VERSION()").Scan(&overview.Version).Error
+		if err == nil {
+			err = db.Raw(`SELECT table_name AS name, COALESCE(table_rows, 0) AS row_count,
+				COALESCE(data_length + index_length, 0) AS size
+				FROM information_schema.tables
+				WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'
+				ORDER BY table_name`).Scan(&overview.Tables).Error
+		}
+	case "postgres":
+		err = db.Raw("SHOW server_version").Scan(&overview.Version).Error
+		if err == nil {
+			err = db.Raw("SELECT pg_database_size(current_database())").Scan(&overview.Size).Error
+		}
+		if err == nil {
+			err = db.Raw(`SELECT c.relname AS name, GREATEST(c.reltuples, 0)::bigint AS row_count,
+				pg_total_relation_size(c.oid) AS size
+				FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+				WHERE n.nspname = current_schema() AND c.relkind IN ('r', 'p') AND NOT c.relispartition
+				ORDER BY c.relname`).Scan(&overview.Tables).Error
+		}
+	case "sqlite":
+		err = db.Raw("SELECT sqlite_version()").Scan(&overview.Version).Error
+		if err == nil {
+			err = db.Raw("SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()").Scan(&overview.Size).Error
+		}
+		if err == nil {
+			err = inspectSQLiteTables(db, overview)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDatabaseType, overview.Driver)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect database: %w", err)
+	}
+
+	if overview.Driver == "mysql" {
+		for _, table := range overview.Tables {
+			overview.Size += table.Size
+		}
+	}
+	return overview, nil
+}
+
+// inspectSQLiteTables counts the rows of each table
+func inspectSQLiteTables(db *gorm.DB, overview *Overview) error {
+	tables, err := Tables(db)
+	if err != nil {
+		return err
+	}
+	for _, name := range tables {
+		table := TableSummary{Name: name}
+		if err := db.Table(name).Count(&table.Rows).Error; err != nil {
+			return err
+		}
+		overview.Tables = append(overview.Tables, table)
+	}
+	return nil
+}
+
+// DescribeTable returns the columns, indexes and foreign keys of table
+func DescribeTable(db *gorm.DB, table string) (*TableDescription, error) {
+	migrator := db.Migrator()
+	if !migrator.HasTable(table) {
+		return nil, fmt.Errorf("table %s does not exist", table)
+	}
+	description := &TableDescription{Name: table}
+
+	columnTypes, err := migrator.ColumnTypes(table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	for _, columnType := range columnTypes {
+		column := Column{Name: columnType.Name(), Type: columnType.DatabaseTypeName()}
+		if fullType, ok := columnType.ColumnType(); ok && fullType != "" {
+			column.Type = fullType
+		}
+		column.Nullable, _ = columnType.Nullable()
+		column.Default, column.HasDefault = columnType.DefaultValue()
+		column.PrimaryKey, _ = columnType.PrimaryKey()
+		column.AutoIncrement, _ = columnType.AutoIncrement()
+		// SQLite reports INTEGER PRIMARY KEY as nullable, though it never is
+		column.Nullable = column.Nullable && !column.PrimaryKey
+		description.Columns = append(description.Columns, column)
+	}
+
+	indexes, err := migrator.GetIndexes(table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read indexes of %s: %w", table, err)
+	}
+	for _, index := range indexes {
+		unique, _ := index.Unique()
+		primaryKey, _ := index.PrimaryKey()
+		description.Indexes = append(description.Indexes, Index{
+			Name:       index.Name(),
+			Columns:    index.Columns(),
+			Unique:     unique || primaryKey,
+			PrimaryKey: primaryKey,
+		})
+	}
+	sort.Slice(description.Indexes, func(i, j int) bool {
+		a, b := description.Indexes[i], description.Indexes[j]
+		if a.PrimaryKey != b.PrimaryKey {
+			return a.PrimaryKey
+		}
+		return a.Name < b.Name
+	})
+
+	description.ForeignKeys, err = foreignKeys(db, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read foreign keys of %s: %w", table, err)
+	}
+	return description, nil
+}
+
+// foreignKeyColumn is one column pair of a foreign key; composite keys have
+// one row per column, in key order
+type foreignKeyColumn struct {
+	Name      string
+	Column    string
+	RefTable  string
+	RefColumn string
+	OnUpdate  string
+	OnDelete  string
+}
+
+func foreignKeys(db *gorm.DB, table string) ([]ForeignKey, error) {
+	var rows []foreignKeyColumn
+	var err error
+	switch db.Dialector.Name() {
+	case "mysql":
+		err = db.Raw(`SELECT k.constraint_name AS name, k.column_name AS `+"`column`"+`,
+			k.referenced_table_name AS ref_table, k.referenced_column_name AS ref_column,
+			r.update_rule AS on_update, r.delete_rule AS on_delete
+			FROM information_schema.key_column_usage k
+			JOIN information_schema.referential_constraints r
+				ON r.constraint_schema = k.constraint_schema AND r.constraint_name = k.constraint_name
+			WHERE k.table_schema = DATABASE() AND k.table_name = ? AND k.referenced_table_name IS NOT NULL
+			ORDER BY k.constraint_name, k.ordinal_position`, table).Scan(&rows).Error
+	case "postgres":
+		err = db.Raw(`SELECT k.constraint_name AS name, k.column_name AS "column",
+			ref.table_name AS ref_table, ref.column_name AS ref_column,
+			r.update_rule AS on_update, r.delete_rule AS on_delete
+			FROM information_schema.key_column_usage k
+			JOIN information_schema.referential_constraints r
+				ON r.constraint_schema = k.constraint_schema AND r.constraint_name = k.constraint_name
+			JOIN information_schema.key_column_usage ref
+				ON ref.constraint_schema = r.unique_constraint_schema AND ref.constraint_name = r.unique_constraint_name
+				AND ref.ordinal_position = k.position_in_unique_constraint
+			WHERE k.table_schema = current_schema() AND k.table_name = ?
+			ORDER BY k.constraint_name, k.ordinal_position`, table).Scan(&rows).Error
+	case "sqlite":
+		// SQLite foreign keys are unnamed; id numbers them within the table
+		err = db.Raw(`SELECT 'fk_' || id AS name, "from" AS "column", "table" AS ref_table,
+			"to" AS ref_column, on_update, on_delete
+			FROM pragma_foreign_key_list(?) ORDER BY id, seq`, table).Scan(&rows).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []ForeignKey
+	for _, row := range rows {
+		if len(keys) == 0 || keys[len(keys)-1].Name != row.Name {
+			keys = append(keys, ForeignKey{
+				Name:     row.Name,
+				RefTable: row.RefTable,
+				OnUpdate: strings.ToUpper(row.OnUpdate),
+				OnDelete: strings.ToUpper(row.OnDelete),
+			})
+		}
+		key := &keys[len(keys)-1]
+		key.Columns = append(key.Columns, row.Column)
+		key.RefColumns = append(key.RefColumns, row.RefColumn)
+	}
+	return keys, nil
+}
+
+// DropAllTables drops every table, including the migrations table, and
+// returns their names. Foreign key checks are suspended while dropping, so
+// the order does not matter.
+func DropAllTables(db *gorm.DB) ([]string, error) {
+	tables, err := Tables(db)
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, nil
+	}
+
+	values := make([]interface{}, len(tables))
+	for i, table := range tables {
+		values[i] = table
+	}
+	if err := db.Migrator().DropTable(values...); err != nil {
+		return nil, fmt.Errorf("failed to drop tables: %w", err)
+	}
+	return tables, nil
+}
@@ -52,13 +52,15 @@ type ConnectionPoolConfig struct {
 
 // Common database configuration fields
 type BaseConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	Name     string
-	LogLevel string
-	Pool     ConnectionPoolConfig
+	Host      string
+	Port      int
+	User      string
+	Password  string
+	Name      string
+	LogLevel  string
+	Pool      ConnectionPoolConfig
+	Naming    NamingConfig
+	SlowQuery SlowQueryConfig
 }
 
 func (c *BaseConfig) Validate() error {
@@ -53,9 +53,11 @@ func NewMySQL(config *MySQLConfig) (*MySQL, error) {
 		logLevel = gormLogger.Error
 	}
 
-	// Configure GORM
+	// Configure GORM; slow queries also go to the slow query log
+	slowQueries := NewSlowQueryLogger(gormLogger.Default.LogMode(logLevel), config.SlowQuery)
 	gormConfig := &gorm.Config{
-		Logger: gormLogger.Default.LogMode(logLevel),
+		Logger:         slowQueries,
+		NamingStrategy: config.Naming.Strategy(),
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
 		},
@@ -69,6 +71,7 @@ func NewMySQL(config *MySQLConfig) (*MySQL, error) {
 		logger.Error("Failed to connect to MySQL database", zap.Error(err))
 		return nil, fmt.Errorf("%w: %v", ErrConnectionFailed, err)
 	}
+	slowQueries.explainOn(db)
 
 	// Get underlying sql.DB
 	sqlDB, err := db.DB()
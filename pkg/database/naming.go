@@ -0,0 +1,51 @@
+package database
+
+import (
+	"sort"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+// NamingConfig configures how GORM derives table and column names for models
+// without an explicit TableName or column tag
+type NamingConfig struct {
+	TablePrefix    string // e.g. "tb_"
+	SingularTables bool   // "tb_user" instead of "tb_users"
+	// Replacements are applied to struct and field names before they are
+	// snake-cased, e.g. {"CID": "Cid"} maps field CID to column "cid"
+	// instead of "c_id"
+	Replacements map[string]string
+}
+
+// Strategy returns the GORM naming strategy for the configuration
+func (n NamingConfig) Strategy() schema.NamingStrategy {
+	strategy := schema.NamingStrategy{
+		TablePrefix:   n.TablePrefix,
+		SingularTable: n.SingularTables,
+	}
+	if len(n.Replacements) > 0 {
+		// Longest names first so "UUID" wins over "ID" regardless of map order
+		names := make([]string, 0, len(n.Replacements))
+		for from := range n.Replacements {
+			names = append(names, from)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if len(names[i]) != len(names[j]) {
+				return len(names[i]) > len(names[j])
+			}
+			return names[i] < names[j]
+		})
+		pairs := make([]string, 0, len(names)*2)
+		for _, from := range names {
+			pairs = append(pairs, from, n.Replacements[from])
+		}
+		strategy.NameReplacer = strings.NewReplacer(pairs...)
+	}
+	return strategy
+}
+
+// TableName returns the table GORM uses for a struct named entity
+func (n NamingConfig) TableName(entity string) string {
+	return n.Strategy().TableName(entity)
+}
@@ -0,0 +1,189 @@
+package database
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"expvar"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"flex-service/pkg/logger"
+	"flex-service/pkg/requestctx"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// slowQueryMetrics counts slow queries by fingerprint, exposed at /debug/vars when expvar is mounted
+var slowQueryMetrics = expvar.NewMap("db_slow_queries_total")
+
+// SlowQueryConfig configures the slow query log
+type SlowQueryConfig struct {
+	Threshold time.Duration // queries taking at least this long are logged; 0 disables
+	// Explain logs the plan of slow SELECTs by running EXPLAIN on them
+	// afterwards. It runs the query planner again, so keep it to development.
+	Explain bool
+	LogFile string // JSON log file for slow queries; empty logs with the application logger
+}
+
+// SlowQueryLogger is a GORM logger that reports queries above the threshold
+// to a dedicated sink and counts them by fingerprint, delegating everything
+// else to the wrapped logger
+type SlowQueryLogger struct {
+	gormLogger.Interface
+	config SlowQueryConfig
+	sink   *zap.Logger
+	db     *gorm.DB // for EXPLAIN; set by explainOn
+}
+
+// NewSlowQueryLogger wraps base. A log file that can't be opened falls back
+// to the application logger.
+func NewSlowQueryLogger(base gormLogger.Interface, config SlowQueryConfig) *SlowQueryLogger {
+	l := &SlowQueryLogger{Interface: base, config: config, sink: logger.Logger}
+	if config.Threshold > 0 && config.LogFile != "" {
+		zapConfig := zap.NewProductionConfig()
+		zapConfig.OutputPaths = []string{config.LogFile}
+		zapConfig.DisableStacktrace = true
+		zapConfig.DisableCaller = true
+		sink, err := zapConfig.Build()
+		if err != nil {
+			logger.Warn("Failed to open slow query log, using the application log",
+				zap.String("file", config.LogFile),
+				zap.Error(err))
+		} else {
+			l.sink = sink
+		}
+	}
+	return l
+}
+
+// explainOn lets the logger run EXPLAIN on db once it is open
+func (l *SlowQueryLogger) explainOn(db *gorm.DB) {
+	if l.config.Explain {
+		l.db = db
+	}
+}
+
+// LogMode returns a copy whose wrapped logger has the level
+func (l *SlowQueryLogger) LogMode(level gormLogger.LogLevel) gormLogger.Interface {
+	copied := *l
+	copied.Interface = l.Interface.LogMode(level)
+	return &copied
+}
+
+// Trace passes the query to the wrapped logger and reports it when slow
+func (l *SlowQueryLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	l.Interface.Trace(ctx, begin, fc, err)
+
+	elapsed := time.Since(begin)
+	if l.config.Threshold <= 0 || elapsed < l.config.Threshold {
+		return
+	}
+
+	sql, rows := fc()
+	fingerprint := Fingerprint(sql)
+	id := fingerprintID(fingerprint)
+	slowQueryMetrics.Add(id, 1)
+
+	fields := []zap.Field{
+		zap.String("fingerprint", id),
+		zap.String("sql", sql),
+		zap.Duration(logger.FieldDuration, elapsed),
+		zap.Int64("rows", rows),
+		zap.String("source", utils.FileWithLineNum()), // the application code that ran it
+	}
+	if requestID := requestctx.RequestID(ctx); requestID != "" {
+		fields = append(fields, zap.String(logger.FieldRequestID, requestID))
+	}
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+	l.sink.Warn("Slow query", fields...)
+
+	if l.db != nil && err == nil && isSelect(sql) {
+		// After the query's own connection is released; a transaction may
+		// still hold the only connection of a SQLite pool
+		go l.explain(id, sql)
+	}
+}
+
+// explain logs the plan of a slow query
+func (l *SlowQueryLogger) explain(id, sql string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	prefix := "EXPLAIN "
+	if l.db.Dialector.Name() == "sqlite" {
+		prefix = "EXPLAIN QUERY PLAN "
+	}
+
+	// Silent, so the EXPLAIN itself is neither logged nor reported as slow
+	rows, err := l.db.Session(&gorm.Session{Logger: gormLogger.Discard}).
+		WithContext(ctx).Raw(prefix + sql).Rows()
+	if err != nil {
+		l.sink.Warn("Failed to explain slow query", zap.String("fingerprint", id), zap.Error(err))
+		return
+	}
+	defer rows.Close()
+
+	columns, _ := rows.Columns()
+	var plan []string
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			break
+		}
+		cells := make([]string, len(values))
+		for i, value := range values {
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+			cells[i] = fmt.Sprintf("%s=%v", columns[i], value)
+		}
+		plan = append(plan, strings.Join(cells, " "))
+	}
+
+	l.sink.Info("Slow query plan",
+		zap.String("fingerprint", id),
+		zap.Strings("plan", plan))
+}
+
+var (
+	stringLiteral  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	numberLiteral  = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	placeholders   = regexp.MustCompile(`\$\d+`)
+	placeholderSet = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	whitespace     = regexp.MustCompile(`\s+`)
+)
+
+// Fingerprint normalizes a query so executions differing only in their values
+// share it: literals become ?, IN lists collapse to (?+) and case and
+// whitespace are normalized
+func Fingerprint(sql string) string {
+	sql = stringLiteral.ReplaceAllString(sql, "?")
+	sql = placeholders.ReplaceAllString(sql, "?")
+	sql = numberLiteral.ReplaceAllString(sql, "?")
+	sql = placeholderSet.ReplaceAllString(sql, "(?+)")
+	sql = whitespace.ReplaceAllString(sql, " ")
+	return strings.ToLower(strings.TrimSpace(sql))
+}
+
+// fingerprintID is the short label slow queries are counted under
+func fingerprintID(fingerprint string) string {
+	sum := sha1.Sum([]byte(fingerprint))
+	return hex.EncodeToString(sum[:6])
+}
+
+func isSelect(sql string) bool {
+	fields := strings.Fields(sql)
+	return len(fields) > 0 && strings.EqualFold(fields[0], "SELECT")
+}
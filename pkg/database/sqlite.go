@@ -38,9 +38,11 @@ func NewSQLite(config *SQLiteConfig) (*SQLite, error) {
 		logLevel = gormLogger.Error
 	}
 
-	// Configure GORM
+	// Configure GORM; slow queries also go to the slow query log
+	slowQueries := NewSlowQueryLogger(gormLogger.Default.LogMode(logLevel), config.SlowQuery)
 	gormConfig := &gorm.Config{
-		Logger: gormLogger.Default.LogMode(logLevel),
+		Logger:         slowQueries,
+		NamingStrategy: config.Naming.Strategy(),
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
 		},
@@ -55,6 +57,7 @@ func NewSQLite(config *SQLiteConfig) (*SQLite, error) {
 		logger.Error("Failed to connect to SQLite database", zap.Error(err))
 		return nil, fmt.Errorf("%w: %v", ErrConnectionFailed, err)
 	}
+	slowQueries.explainOn(db)
 
 	// Get underlying sql.DB
 	sqlDB, err := db.DB()
@@ -13,6 +13,8 @@ type SQLiteConfig struct {
 	Synchronous string // OFF, NORMAL, FULL, EXTRA
 	CacheSize   int    // in KB
 	TempStore   string // DEFAULT, FILE, MEMORY
+	Naming      NamingConfig
+	SlowQuery   SlowQueryConfig
 }
 
 // GetDatabaseType returns the database type
@@ -0,0 +1,186 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"flex-service/pkg/errors"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+)
+
+// Driver codes of unique violations
+const (
+	mysqlDuplicateEntry     = 1062    // ER_DUP_ENTRY
+	postgresUniqueViolation = "23505" // unique_violation
+)
+
+var (
+	// Duplicate entry 'a@b.c' for key 'tb_user.uidx_tb_user_email'
+	mysqlKeyPattern = regexp.MustCompile(`for key '([^']+)'`)
+	// Key (email)=(a@b.c) already exists.
+	postgresKeyPattern = regexp.MustCompile(`^Key \((.+?)\)=`)
+)
+
+// UniqueViolation is a write that broke a unique index or primary key.
+// Constraint and Columns are filled in as far as the driver reports them:
+// PostgreSQL gives both, SQLite the columns, MySQL the index name, from which
+// the column is derived for GORM and make:migration index names.
+type UniqueViolation struct {
+	Constraint string
+	Columns    []string
+	Err        error
+}
+
+func (e *UniqueViolation) Error() string {
+	switch {
+	case len(e.Columns) > 0:
+		return fmt.Sprintf("unique violation on %s: %v", strings.Join(e.Columns, ", "), e.Err)
+	case e.Constraint != "":
+		return fmt.Sprintf("unique violation on %s: %v", e.Constraint, e.Err)
+	}
+	return fmt.Sprintf("unique violation: %v", e.Err)
+}
+
+func (e *UniqueViolation) Unwrap() error {
+	return e.Err
+}
+
+// AsUniqueViolation reports whether err, from any supported driver, is a
+// unique violation, and describes it
+func AsUniqueViolation(err error) (*UniqueViolation, bool) {
+	if err == nil {
+		return nil, false
+	}
+
+	var violation *UniqueViolation
+	if errors.As(err, &violation) {
+		return violation, true
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		violation = &UniqueViolation{Err: err}
+		if match := mysqlKeyPattern.FindStringSubmatch(mysqlErr.Message); match != nil {
+			violation.Constraint, violation.Columns = mysqlKey(match[1])
+		}
+		return violation, true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == postgresUniqueViolation {
+		violation = &UniqueViolation{Constraint: pgErr.ConstraintName, Err: err}
+		if match := postgresKeyPattern.FindStringSubmatch(pgErr.Detail); match != nil {
+			violation.Columns = splitColumns(match[1], "")
+		} else {
+			violation.Columns = indexColumns(pgErr.TableName, pgErr.ConstraintName)
+		}
+		return violation, true
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) &&
+		(sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey) {
+		violation = &UniqueViolation{Err: err}
+		// UNIQUE constraint failed: tb_user.email, tb_user.name
+		if _, columns, ok := strings.Cut(sqliteErr.Error(), "constraint failed: "); ok {
+			if index, ok := strings.CutPrefix(columns, "index "); ok {
+				violation.Constraint = strings.Trim(index, "'")
+			} else {
+				violation.Columns = splitColumns(columns, ".")
+			}
+		}
+		return violation, true
+	}
+
+	// GORM's own translation (gorm.Config.TranslateError) keeps no details
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return &UniqueViolation{Err: err}, true
+	}
+
+	return nil, false
+}
+
+// mysqlKey returns the index name of a MySQL key, "tb_user.uidx_tb_user_email"
+// since 8.0 and "uidx_tb_user_email" before, and its column if known
+func mysqlKey(key string) (string, []string) {
+	table, name, ok := strings.Cut(key, ".")
+	if !ok {
+		return key, nil
+	}
+	return name, indexColumns(table, name)
+}
+
+// indexColumns returns the column of an index named the way GORM
+// (idx_<table>_<column>, uni_<table>_<column>) or make:migration
+// (uidx_<table>_<column>) name them, or nil
+func indexColumns(table, name string) []string {
+	if table == "" {
+		return nil
+	}
+	for _, prefix := range []string{"uidx_", "idx_", "uni_"} {
+		if column, ok := strings.CutPrefix(name, prefix+table+"_"); ok && column != "" {
+			return []string{column}
+		}
+	}
+	return nil
+}
+
+// splitColumns splits a comma-separated column list, dropping everything up
+// to the last qualifier, e.g. the table of "tb_user.email"
+func splitColumns(list, qualifier string) []string {
+	var columns []string
+	for _, column := range strings.Split(list, ",") {
+		column = strings.Trim(strings.TrimSpace(column), `"`)
+		if qualifier != "" {
+			if i := strings.LastIndex(column, qualifier); i >= 0 {
+				column = column[i+len(qualifier):]
+			}
+		}
+		if column != "" {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// TranslateError turns a unique violation into a 409 CONFLICT error naming the
+// offending columns, with the same details as integrity.CheckUnique, and
+// returns any other error unchanged
+func TranslateError(err error) error {
+	violation, ok := AsUniqueViolation(err)
+	if !ok {
+		return err
+	}
+
+	message := "Resource already exists"
+	details := map[string]interface{}{}
+	if len(violation.Columns) > 0 {
+		message = fmt.Sprintf("%s already exists", strings.Join(violation.Columns, ", "))
+		details["fields"] = violation.Columns
+	}
+	if violation.Constraint != "" {
+		details["constraint"] = violation.Constraint
+	}
+	if len(details) == 0 {
+		return errors.Conflict(message)
+	}
+	return errors.Conflict(message).WithDetails(details)
+}
+
+// WrapError is errors.WrapDatabase for writes: unique violations become 409
+// CONFLICT errors (see TranslateError), anything else a database error
+//
+//	if err := r.db.WithContext(ctx).Create(user).Error; err != nil {
+//		return database.WrapError(err, "failed to create user")
+//	}
+func WrapError(err error, message string) error {
+	if _, ok := AsUniqueViolation(err); ok {
+		return TranslateError(err)
+	}
+	return errors.WrapDatabase(err, message)
+}
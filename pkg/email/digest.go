@@ -0,0 +1,166 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"flex-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// DigestFunc merges the messages collected under one digest key, oldest first,
+// into the message that is sent
+type DigestFunc func(key string, messages []*Message) *Message
+
+// Digest collects messages sent with the same key to the same recipients
+// within window and sends them as one, e.g. a burst of comment notifications.
+// The first message starts the window; a single collected message is sent
+// unchanged. Digests are held in process and sent on Flush at shutdown.
+func Digest(key string, window time.Duration) Option {
+	return func(o *sendOptions) {
+		o.digestKey = key
+		o.digestWindow = window
+	}
+}
+
+// digester holds the open digests of a mailer
+type digester struct {
+	mu      sync.Mutex
+	pending map[string]*pendingDigest
+}
+
+type pendingDigest struct {
+	key      string
+	messages []*Message
+	options  *sendOptions
+	timer    *time.Timer
+}
+
+// add collects msg under id; the first message of a digest starts its window,
+// after which flush is called once
+func (d *digester) add(id string, msg *Message, options *sendOptions, flush func(*pendingDigest)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.pending == nil {
+		d.pending = make(map[string]*pendingDigest)
+	}
+	if p, ok := d.pending[id]; ok {
+		p.messages = append(p.messages, msg)
+		count(statDigested)
+		return
+	}
+
+	p := &pendingDigest{key: options.digestKey, messages: []*Message{msg}, options: options}
+	p.timer = time.AfterFunc(options.digestWindow, func() {
+		if d.take(id, p) {
+			flush(p)
+		}
+	})
+	d.pending[id] = p
+}
+
+// take removes p if it is still pending, so it is flushed only once
+func (d *digester) take(id string, p *pendingDigest) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.pending[id] != p {
+		return false
+	}
+	delete(d.pending, id)
+	return true
+}
+
+// drain removes and returns every pending digest
+func (d *digester) drain() []*pendingDigest {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	digests := make([]*pendingDigest, 0, len(d.pending))
+	for id, p := range d.pending {
+		p.timer.Stop()
+		digests = append(digests, p)
+		delete(d.pending, id)
+	}
+	return digests
+}
+
+// digestID groups messages by key and recipients
+func digestID(key string, msg *Message) string {
+	recipients := make([]string, 0, len(msg.To)+len(msg.Cc)+len(msg.Bcc))
+	for _, list := range [][]string{msg.To, msg.Cc, msg.Bcc} {
+		for _, address := range list {
+			recipients = append(recipients, strings.ToLower(address))
+		}
+	}
+	sort.Strings(recipients)
+	return key + "\x00" + strings.Join(recipients, ",")
+}
+
+// collect adds msg to its digest; the digest is delivered when its window ends
+func (m *Mailer) collect(msg *Message, options *sendOptions) {
+	m.digests.add(digestID(options.digestKey, msg), msg, options, func(p *pendingDigest) {
+		// The window outlives the request that opened it
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		m.deliverDigest(ctx, p)
+	})
+}
+
+func (m *Mailer) deliverDigest(ctx context.Context, p *pendingDigest) {
+	msg := p.messages[0]
+	if len(p.messages) > 1 {
+		msg = m.digestFunc(p.key, p.messages)
+	}
+
+	var err error
+	if p.options.queued {
+		err = m.enqueue(msg, p.options.job)
+	} else {
+		err = m.SendMessage(ctx, msg)
+	}
+	if err != nil {
+		logger.Error("Failed to send email digest",
+			zap.String("digest", p.key),
+			zap.Int("messages", len(p.messages)),
+			zap.Strings("to", msg.To),
+			zap.Error(err))
+	}
+}
+
+// Flush sends every open digest now; call it on shutdown
+func (m *Mailer) Flush(ctx context.Context) error {
+	for _, p := range m.digests.drain() {
+		m.deliverDigest(ctx, p)
+	}
+	return ctx.Err()
+}
+
+// DefaultDigest keeps the first message's headers and recipients, counts the
+// messages in the subject and joins the bodies and attachments
+func DefaultDigest(key string, messages []*Message) *Message {
+	first := *messages[0]
+	digest := &first
+	digest.Subject = fmt.Sprintf("%s (%d updates)", first.Subject, len(messages))
+	digest.Attachments = nil
+
+	var html, text []string
+	for _, msg := range messages {
+		if msg.HTML != "" {
+			html = append(html, msg.HTML)
+		}
+		if msg.Text != "" {
+			text = append(text, msg.Text)
+		}
+		digest.Attachments = append(digest.Attachments, msg.Attachments...)
+	}
+	digest.HTML = strings.Join(html, "\n<hr>\n")
+	digest.Text = strings.Join(text, "\n\n---\n\n")
+	return digest
+}
@@ -0,0 +1,99 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"flex-service/pkg/logger"
+	"flex-service/pkg/queue"
+
+	"go.uber.org/zap"
+)
+
+// messagePayload stores a message under "message" as plain JSON values, so the
+// job looks the same before and after a round trip through Redis
+func messagePayload(msg *Message) (map[string]interface{}, error) {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode queued email: %w", err)
+	}
+
+	var encoded map[string]interface{}
+	if err := json.Unmarshal(raw, &encoded); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"message": encoded}, nil
+}
+
+// messageFromPayload decodes the message of a queued email job
+func messageFromPayload(payload map[string]interface{}) (*Message, error) {
+	value, ok := payload["message"]
+	if !ok {
+		return nil, fmt.Errorf("email job has no message")
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg Message
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return nil, fmt.Errorf("failed to decode queued email: %w", err)
+	}
+	return &msg, nil
+}
+
+// JobHandler delivers emails queued with the Queued option:
+//
+//	worker.RegisterHandler(queue.JobTypeEmail, email.JobHandler(container.Email))
+//
+// Each job makes one delivery attempt; retries come from the job's MaxAttempts.
+// A job over the send rate is dispatched again with a delay instead, without
+// using up an attempt.
+func JobHandler(m *Mailer) queue.Handler {
+	return queue.HandlerFunc(func(ctx context.Context, job *queue.Job) *queue.JobResult {
+		msg, err := messageFromPayload(job.Payload)
+		if err != nil {
+			return &queue.JobResult{Success: false, Error: err.Error()}
+		}
+
+		if err := msg.Validate(); err != nil {
+			return &queue.JobResult{Success: false, Error: err.Error()}
+		}
+
+		deferred, err := m.wait(ctx, msg)
+		if err != nil {
+			return &queue.JobResult{Success: false, Error: err.Error()}
+		}
+		if deferred {
+			return &queue.JobResult{Success: true, Data: map[string]interface{}{"deferred": true}}
+		}
+
+		if err := m.sender.Send(ctx, msg); err != nil {
+			count(statFailed)
+			logger.Error("Queued email delivery failed",
+				zap.String("job_id", job.ID),
+				zap.Strings("to", msg.To),
+				zap.Error(err))
+			return &queue.JobResult{Success: false, Error: err.Error()}
+		}
+
+		count(statSent)
+		logger.Info("Queued email sent",
+			zap.String("job_id", job.ID),
+			zap.Strings("to", msg.To),
+			zap.String("subject", msg.Subject))
+
+		return &queue.JobResult{
+			Success: true,
+			Data: map[string]interface{}{
+				"to":      msg.To,
+				"subject": msg.Subject,
+				"sent_at": time.Now(),
+			},
+		}
+	})
+}
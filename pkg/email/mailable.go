@@ -0,0 +1,31 @@
+package email
+
+// Mailable is an email defined as a type, so the recipients, subject and template
+// of e.g. a welcome email live in one place:
+//
+//	type WelcomeEmail struct{ User *User }
+//
+//	func (e WelcomeEmail) Build(m *email.Message) error {
+//		m.To = []string{e.User.Email}
+//		m.SetSubject("Welcome!").Template("welcome", e)
+//		return nil
+//	}
+type Mailable interface {
+	Build(m *Message) error
+}
+
+// MailableFunc adapts a function to Mailable
+type MailableFunc func(m *Message) error
+
+// Build implements Mailable
+func (fn MailableFunc) Build(m *Message) error {
+	return fn(m)
+}
+
+// Raw wraps an already built message as a Mailable
+func Raw(msg *Message) Mailable {
+	return MailableFunc(func(m *Message) error {
+		*m = *msg
+		return nil
+	})
+}
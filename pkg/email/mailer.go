@@ -0,0 +1,339 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"time"
+
+	"flex-service/config"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/queue"
+
+	"go.uber.org/zap"
+	"gopkg.in/gomail.v2"
+)
+
+// Sender delivers a rendered message. The SMTP sender is used by default; tests
+// and local development can swap in another one with WithSender.
+type Sender interface {
+	Send(ctx context.Context, m *Message) error
+}
+
+// SenderFunc adapts a function to Sender
+type SenderFunc func(ctx context.Context, m *Message) error
+
+// Send implements Sender
+func (fn SenderFunc) Send(ctx context.Context, m *Message) error {
+	return fn(ctx, m)
+}
+
+// Mailer builds, renders and delivers emails, directly or through the queue
+type Mailer struct {
+	config     *config.EmailConfig
+	sender     Sender
+	renderer   *Renderer
+	dispatcher *queue.JobDispatcher
+	throttle   *Throttle
+	digests    digester
+	digestFunc DigestFunc
+}
+
+// New creates a mailer that sends over SMTP with templates from cfg.TemplateDir
+func New(cfg *config.EmailConfig) (*Mailer, error) {
+	if cfg.From == "" {
+		return nil, fmt.Errorf("email from address is required")
+	}
+
+	return &Mailer{
+		config:   cfg,
+		sender:   NewSMTPSender(cfg),
+		renderer: NewRenderer(cfg.TemplateDir, true),
+		throttle: NewThrottle(ThrottleConfig{
+			Rate:        cfg.RateLimit,
+			DomainRate:  cfg.DomainRateLimit,
+			DomainRates: cfg.DomainRateLimits,
+			Window:      cfg.RateWindow,
+			MaxWait:     cfg.ThrottleMaxWait,
+		}),
+		digestFunc: DefaultDigest,
+	}, nil
+}
+
+// WithQueue enables the Queued option by dispatching through pkg/queue
+func (m *Mailer) WithQueue(dispatcher *queue.JobDispatcher) *Mailer {
+	m.dispatcher = dispatcher
+	return m
+}
+
+// WithSender replaces the delivery backend
+func (m *Mailer) WithSender(sender Sender) *Mailer {
+	m.sender = sender
+	return m
+}
+
+// WithThrottle replaces the send rate limits; nil removes them
+func (m *Mailer) WithThrottle(throttle *Throttle) *Mailer {
+	m.throttle = throttle
+	return m
+}
+
+// WithDigestFunc replaces how digests are merged (DefaultDigest)
+func (m *Mailer) WithDigestFunc(fn DigestFunc) *Mailer {
+	m.digestFunc = fn
+	return m
+}
+
+// WithRenderer replaces the template renderer (e.g. one without caching in development)
+func (m *Mailer) WithRenderer(renderer *Renderer) *Mailer {
+	m.renderer = renderer
+	return m
+}
+
+// sendOptions collects per-send options
+type sendOptions struct {
+	queued bool
+	job    *queue.JobOptions
+
+	digestKey    string
+	digestWindow time.Duration
+}
+
+// Option changes how Send delivers a mailable
+type Option func(*sendOptions)
+
+// Queued renders the email now and delivers it from a queue worker.
+// opts can set a delay, priority or attempt count.
+func Queued(opts ...*queue.JobOptions) Option {
+	return func(o *sendOptions) {
+		o.queued = true
+		if len(opts) > 0 {
+			o.job = opts[0]
+		}
+	}
+}
+
+// Send builds and renders the mailable, then delivers it with retries
+// (or hands it to the queue with the Queued option)
+func (m *Mailer) Send(ctx context.Context, mailable Mailable, opts ...Option) error {
+	options := &sendOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	msg, err := m.Build(mailable)
+	if err != nil {
+		return err
+	}
+
+	if options.digestKey != "" && options.digestWindow > 0 {
+		m.collect(msg, options)
+		return nil
+	}
+	if options.queued {
+		return m.enqueue(msg, options.job)
+	}
+	return m.SendMessage(ctx, msg)
+}
+
+// Build runs the mailable, renders its template and fills in the default sender
+func (m *Mailer) Build(mailable Mailable) (*Message, error) {
+	msg := &Message{}
+	if err := mailable.Build(msg); err != nil {
+		return nil, err
+	}
+
+	if err := m.renderer.Render(msg); err != nil {
+		return nil, err
+	}
+
+	if msg.From == "" {
+		msg.From = m.config.From
+		if msg.FromName == "" {
+			msg.FromName = m.config.FromName
+		}
+	}
+
+	if err := msg.Validate(); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// SendMessage delivers a built message, retrying up to MaxRetries times with
+// RetryDelay (doubling after each attempt) between tries. It first waits for
+// a send slot; see wait for messages over the rate.
+func (m *Mailer) SendMessage(ctx context.Context, msg *Message) error {
+	if err := msg.Validate(); err != nil {
+		return err
+	}
+
+	if deferred, err := m.wait(ctx, msg); err != nil || deferred {
+		return err
+	}
+
+	delay := m.config.RetryDelay
+	var err error
+	for attempt := 0; attempt <= m.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			logger.Warn("Retrying email delivery",
+				zap.Int("attempt", attempt),
+				zap.Strings("to", msg.To),
+				zap.Error(err))
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+
+		if err = m.sender.Send(ctx, msg); err == nil {
+			count(statSent)
+			logger.Info("Email sent",
+				zap.Strings("to", msg.To),
+				zap.String("subject", msg.Subject))
+			return nil
+		}
+	}
+
+	count(statFailed)
+	return fmt.Errorf("failed to send email after %d attempts: %w", m.config.MaxRetries+1, err)
+}
+
+// wait takes a send slot from the throttle, sleeping until it is free. When
+// the slot is further away than MaxWait the message is deferred to the queue
+// with that delay, or ErrThrottled is returned without a queue.
+func (m *Mailer) wait(ctx context.Context, msg *Message) (deferred bool, err error) {
+	if m.throttle == nil {
+		return false, nil
+	}
+
+	delay, ok := m.throttle.Reserve(msg)
+	if !ok {
+		if m.dispatcher == nil {
+			return false, ErrThrottled
+		}
+		return true, m.deferMessage(msg, delay)
+	}
+	if delay > 0 {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	return false, nil
+}
+
+// deferMessage queues msg to be sent after delay
+func (m *Mailer) deferMessage(msg *Message, delay time.Duration) error {
+	payload, err := messagePayload(msg)
+	if err != nil {
+		return err
+	}
+	if err := m.dispatcher.DispatchDelayed(queue.JobTypeEmail, payload, delay); err != nil {
+		return err
+	}
+
+	count(statDeferred)
+	logger.Info("Email deferred by send rate limit",
+		zap.Strings("to", msg.To),
+		zap.Duration("delay", delay))
+	return nil
+}
+
+// enqueue dispatches a rendered message as a queue.JobTypeEmail job
+func (m *Mailer) enqueue(msg *Message, opts *queue.JobOptions) error {
+	if m.dispatcher == nil {
+		return ErrQueueNotConfigured
+	}
+
+	payload, err := messagePayload(msg)
+	if err != nil {
+		return err
+	}
+	if err := m.dispatcher.Dispatch(queue.JobTypeEmail, payload, opts); err != nil {
+		return err
+	}
+	count(statQueued)
+	return nil
+}
+
+// SMTPSender delivers messages with gomail
+type SMTPSender struct {
+	dialer *gomail.Dialer
+}
+
+// NewSMTPSender creates an SMTP sender from the email config
+func NewSMTPSender(cfg *config.EmailConfig) *SMTPSender {
+	d := gomail.NewDialer(cfg.Host, cfg.Port, cfg.Username, cfg.Password)
+	d.TLSConfig = &tls.Config{
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		ServerName:         cfg.Host,
+	}
+	return &SMTPSender{dialer: d}
+}
+
+// Send implements Sender
+func (s *SMTPSender) Send(ctx context.Context, m *Message) error {
+	msg := gomail.NewMessage()
+
+	if m.FromName != "" {
+		msg.SetAddressHeader("From", m.From, m.FromName)
+	} else {
+		msg.SetHeader("From", m.From)
+	}
+	if len(m.To) > 0 {
+		msg.SetHeader("To", m.To...)
+	}
+	if len(m.Cc) > 0 {
+		msg.SetHeader("Cc", m.Cc...)
+	}
+	if len(m.Bcc) > 0 {
+		msg.SetHeader("Bcc", m.Bcc...)
+	}
+	if m.ReplyTo != "" {
+		msg.SetHeader("Reply-To", m.ReplyTo)
+	}
+	msg.SetHeader("Subject", m.Subject)
+	for name, value := range m.Headers {
+		msg.SetHeader(name, value)
+	}
+
+	// Text first so clients that can't show HTML fall back to it
+	switch {
+	case m.Text != "" && m.HTML != "":
+		msg.SetBody("text/plain", m.Text)
+		msg.AddAlternative("text/html", m.HTML)
+	case m.HTML != "":
+		msg.SetBody("text/html", m.HTML)
+	default:
+		msg.SetBody("text/plain", m.Text)
+	}
+
+	for _, a := range m.Attachments {
+		content := a.Content
+		settings := []gomail.FileSetting{gomail.SetCopyFunc(func(w io.Writer) error {
+			_, err := io.Copy(w, bytes.NewReader(content))
+			return err
+		})}
+		if a.ContentType != "" {
+			settings = append(settings, gomail.SetHeader(map[string][]string{"Content-Type": {a.ContentType}}))
+		}
+
+		if a.Inline {
+			msg.Embed(a.Filename, settings...)
+		} else {
+			msg.Attach(a.Filename, settings...)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.dialer.DialAndSend(msg)
+}
@@ -0,0 +1,142 @@
+package email
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// Email errors
+var (
+	ErrNoRecipients       = errors.New("email has no recipients")
+	ErrNoContent          = errors.New("email has no HTML or text body")
+	ErrTemplateNotFound   = errors.New("email template not found")
+	ErrQueueNotConfigured = errors.New("email queue is not configured")
+)
+
+// Attachment is a file attached to a message. Content is kept in memory so
+// queued messages carry their attachments with them.
+type Attachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type,omitempty"`
+	Content     []byte `json:"content"`
+	Inline      bool   `json:"inline,omitempty"` // Embed for <img src="cid:filename">
+}
+
+// Message is a rendered email ready to be delivered
+type Message struct {
+	From        string            `json:"from,omitempty"`
+	FromName    string            `json:"from_name,omitempty"`
+	To          []string          `json:"to"`
+	Cc          []string          `json:"cc,omitempty"`
+	Bcc         []string          `json:"bcc,omitempty"`
+	ReplyTo     string            `json:"reply_to,omitempty"`
+	Subject     string            `json:"subject"`
+	HTML        string            `json:"html,omitempty"`
+	Text        string            `json:"text,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Attachments []Attachment      `json:"attachments,omitempty"`
+
+	template     string
+	templateData interface{}
+}
+
+// NewMessage creates a message to the given recipients
+func NewMessage(to ...string) *Message {
+	return &Message{To: to}
+}
+
+// SetSubject sets the subject
+func (m *Message) SetSubject(subject string) *Message {
+	m.Subject = subject
+	return m
+}
+
+// AddCc adds carbon-copy recipients
+func (m *Message) AddCc(addresses ...string) *Message {
+	m.Cc = append(m.Cc, addresses...)
+	return m
+}
+
+// AddBcc adds blind carbon-copy recipients
+func (m *Message) AddBcc(addresses ...string) *Message {
+	m.Bcc = append(m.Bcc, addresses...)
+	return m
+}
+
+// SetReplyTo sets the Reply-To address
+func (m *Message) SetReplyTo(address string) *Message {
+	m.ReplyTo = address
+	return m
+}
+
+// SetHeader sets an extra header, e.g. List-Unsubscribe
+func (m *Message) SetHeader(name, value string) *Message {
+	if m.Headers == nil {
+		m.Headers = make(map[string]string)
+	}
+	m.Headers[name] = value
+	return m
+}
+
+// SetHTML sets the HTML body
+func (m *Message) SetHTML(html string) *Message {
+	m.HTML = html
+	return m
+}
+
+// SetText sets the plain text body
+func (m *Message) SetText(text string) *Message {
+	m.Text = text
+	return m
+}
+
+// Template renders <name>.html and/or <name>.txt from the template directory when the message is sent.
+// A {{define "subject"}} block in either file sets the subject if it is empty.
+func (m *Message) Template(name string, data interface{}) *Message {
+	m.template = name
+	m.templateData = data
+	return m
+}
+
+// Attach attaches in-memory content
+func (m *Message) Attach(filename string, content []byte, contentType string) *Message {
+	m.Attachments = append(m.Attachments, Attachment{
+		Filename:    filename,
+		ContentType: contentType,
+		Content:     content,
+	})
+	return m
+}
+
+// Embed attaches an inline image referenced from HTML as cid:filename
+func (m *Message) Embed(filename string, content []byte, contentType string) *Message {
+	m.Attachments = append(m.Attachments, Attachment{
+		Filename:    filename,
+		ContentType: contentType,
+		Content:     content,
+		Inline:      true,
+	})
+	return m
+}
+
+// AttachFile reads a file from disk and attaches it
+func (m *Message) AttachFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	m.Attach(filepath.Base(path), content, "")
+	return nil
+}
+
+// Validate checks the message can be delivered
+func (m *Message) Validate() error {
+	if len(m.To)+len(m.Cc)+len(m.Bcc) == 0 {
+		return ErrNoRecipients
+	}
+	if m.HTML == "" && m.Text == "" {
+		return ErrNoContent
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package email
+
+import "expvar"
+
+// Message counts exposed at /debug/vars as email_messages_total
+const (
+	statQueued   = "queued"   // handed to the queue
+	statSent     = "sent"     // accepted by the sender
+	statDeferred = "deferred" // re-queued with a delay by the throttle
+	statFailed   = "failed"   // gave up after the last attempt
+	statDigested = "digested" // folded into a digest instead of sent alone
+)
+
+var messageMetrics = expvar.NewMap("email_messages_total")
+
+func count(stat string) {
+	messageMetrics.Add(stat, 1)
+}
+
+// Stats returns the message counts since start: queued, sent, deferred,
+// failed and digested
+func Stats() map[string]int64 {
+	stats := map[string]int64{
+		statQueued:   0,
+		statSent:     0,
+		statDeferred: 0,
+		statFailed:   0,
+		statDigested: 0,
+	}
+	messageMetrics.Do(func(kv expvar.KeyValue) {
+		if v, ok := kv.Value.(*expvar.Int); ok {
+			stats[kv.Key] = v.Value()
+		}
+	})
+	return stats
+}
@@ -0,0 +1,198 @@
+package email
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+)
+
+// subjectBlock is the template name that, when defined, renders the subject line
+const subjectBlock = "subject"
+
+// Renderer renders <name>.html and <name>.txt templates from a directory. If a
+// layout.html / layout.txt exists, the named template becomes its {{template "content" .}}.
+type Renderer struct {
+	dir   string
+	cache bool
+
+	mu   sync.RWMutex
+	html map[string]*htmltemplate.Template
+	text map[string]*texttemplate.Template
+}
+
+// NewRenderer creates a renderer for dir. With cache off, templates are re-read on
+// every render so edits show up without a restart (useful in development).
+func NewRenderer(dir string, cache bool) *Renderer {
+	return &Renderer{
+		dir:   dir,
+		cache: cache,
+		html:  make(map[string]*htmltemplate.Template),
+		text:  make(map[string]*texttemplate.Template),
+	}
+}
+
+// Render fills the message's HTML, Text and (if empty) Subject from its template.
+// A subject block in the .txt template wins over one in the .html template.
+func (r *Renderer) Render(m *Message) error {
+	if m.template == "" {
+		return nil
+	}
+
+	found := false
+
+	textTmpl, err := r.textTemplate(m.template)
+	if err != nil && !errors.Is(err, ErrTemplateNotFound) {
+		return err
+	}
+	if textTmpl != nil {
+		found = true
+		var buf bytes.Buffer
+		if err := textTmpl.Execute(&buf, m.templateData); err != nil {
+			return fmt.Errorf("failed to render %s.txt: %w", m.template, err)
+		}
+		m.Text = buf.String()
+
+		if m.Subject == "" && textTmpl.Lookup(subjectBlock) != nil {
+			buf.Reset()
+			if err := textTmpl.ExecuteTemplate(&buf, subjectBlock, m.templateData); err != nil {
+				return fmt.Errorf("failed to render %s.txt subject: %w", m.template, err)
+			}
+			m.Subject = strings.TrimSpace(buf.String())
+		}
+	}
+
+	htmlTmpl, err := r.htmlTemplate(m.template)
+	if err != nil && !errors.Is(err, ErrTemplateNotFound) {
+		return err
+	}
+	if htmlTmpl != nil {
+		found = true
+		var buf bytes.Buffer
+		if err := htmlTmpl.Execute(&buf, m.templateData); err != nil {
+			return fmt.Errorf("failed to render %s.html: %w", m.template, err)
+		}
+		m.HTML = buf.String()
+
+		if m.Subject == "" && htmlTmpl.Lookup(subjectBlock) != nil {
+			buf.Reset()
+			if err := htmlTmpl.ExecuteTemplate(&buf, subjectBlock, m.templateData); err != nil {
+				return fmt.Errorf("failed to render %s.html subject: %w", m.template, err)
+			}
+			m.Subject = strings.TrimSpace(html.UnescapeString(buf.String())) // Subjects are plain text
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("%w: %s (.html or .txt in %s)", ErrTemplateNotFound, m.template, r.dir)
+	}
+
+	m.template = ""
+	m.templateData = nil
+	return nil
+}
+
+func (r *Renderer) htmlTemplate(name string) (*htmltemplate.Template, error) {
+	if r.cache {
+		r.mu.RLock()
+		tmpl, ok := r.html[name]
+		r.mu.RUnlock()
+		if ok {
+			return tmpl, nil
+		}
+	}
+
+	layout, content, err := r.read(name, ".html")
+	if err != nil {
+		return nil, err
+	}
+	tmpl := htmltemplate.New(name)
+	if layout != "" {
+		tmpl, err = tmpl.Parse(layout)
+		if err == nil {
+			_, err = tmpl.New("content").Parse(content)
+		}
+	} else {
+		tmpl, err = tmpl.Parse(content)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s.html: %w", name, err)
+	}
+
+	if r.cache {
+		r.mu.Lock()
+		r.html[name] = tmpl
+		r.mu.Unlock()
+	}
+	return tmpl, nil
+}
+
+func (r *Renderer) textTemplate(name string) (*texttemplate.Template, error) {
+	if r.cache {
+		r.mu.RLock()
+		tmpl, ok := r.text[name]
+		r.mu.RUnlock()
+		if ok {
+			return tmpl, nil
+		}
+	}
+
+	layout, content, err := r.read(name, ".txt")
+	if err != nil {
+		return nil, err
+	}
+	tmpl := texttemplate.New(name)
+	if layout != "" {
+		tmpl, err = tmpl.Parse(layout)
+		if err == nil {
+			_, err = tmpl.New("content").Parse(content)
+		}
+	} else {
+		tmpl, err = tmpl.Parse(content)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s.txt: %w", name, err)
+	}
+
+	if r.cache {
+		r.mu.Lock()
+		r.text[name] = tmpl
+		r.mu.Unlock()
+	}
+	return tmpl, nil
+}
+
+// read returns the layout source (empty when there is none) and the named template source
+func (r *Renderer) read(name, ext string) (string, string, error) {
+	if strings.Contains(name, "..") {
+		return "", "", fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+
+	content, err := os.ReadFile(filepath.Join(r.dir, name+ext))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", "", fmt.Errorf("%w: %s%s", ErrTemplateNotFound, name, ext)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	if name == "layout" {
+		return "", string(content), nil
+	}
+
+	layout, err := os.ReadFile(filepath.Join(r.dir, "layout"+ext))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", string(content), nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return string(layout), string(content), nil
+}
@@ -0,0 +1,164 @@
+package email
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrThrottled is returned when a message would wait longer than
+// ThrottleConfig.MaxWait for a send slot and no queue is configured to defer it
+var ErrThrottled = errors.New("email send rate exceeded")
+
+// ThrottleConfig limits how fast messages are handed to the sender, to stay
+// within the SMTP provider's limits
+type ThrottleConfig struct {
+	// Rate is the number of messages per Window across all domains; 0 means
+	// no global limit
+	Rate int
+	// DomainRate is the number of messages per Window to one recipient
+	// domain; 0 means no limit
+	DomainRate int
+	// DomainRates overrides DomainRate for specific domains, e.g. gmail.com
+	DomainRates map[string]int
+	// Window is the period the rates are counted over
+	Window time.Duration
+	// MaxWait is the longest a send waits for a slot; longer waits are
+	// deferred to the queue
+	MaxWait time.Duration
+}
+
+// Throttle is a set of token buckets, one global and one per recipient
+// domain. Limits are kept in process, so each instance gets the full rate.
+type Throttle struct {
+	config  ThrottleConfig
+	mu      sync.Mutex
+	global  *bucket
+	domains map[string]*bucket
+}
+
+// NewThrottle creates a throttle; nil means cfg has no limits
+func NewThrottle(cfg ThrottleConfig) *Throttle {
+	if cfg.Rate <= 0 && cfg.DomainRate <= 0 && len(cfg.DomainRates) == 0 {
+		return nil
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = time.Minute
+	}
+
+	t := &Throttle{config: cfg, domains: make(map[string]*bucket)}
+	if cfg.Rate > 0 {
+		t.global = newBucket(cfg.Rate, cfg.Window)
+	}
+	return t
+}
+
+// Reserve takes a slot for every recipient domain of msg and returns how long
+// to wait before sending. When the wait exceeds MaxWait nothing is taken and
+// ok is false.
+func (t *Throttle) Reserve(msg *Message) (wait time.Duration, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	buckets := t.buckets(msg)
+	for _, b := range buckets {
+		if d := b.delay(now); d > wait {
+			wait = d
+		}
+	}
+	if wait > t.config.MaxWait {
+		return wait, false
+	}
+	for _, b := range buckets {
+		b.take(now)
+	}
+	return wait, true
+}
+
+// buckets returns the global bucket and one per recipient domain
+func (t *Throttle) buckets(msg *Message) []*bucket {
+	var buckets []*bucket
+	if t.global != nil {
+		buckets = append(buckets, t.global)
+	}
+
+	for _, domain := range recipientDomains(msg) {
+		rate, ok := t.config.DomainRates[domain]
+		if !ok {
+			rate = t.config.DomainRate
+		}
+		if rate <= 0 {
+			continue
+		}
+
+		b, ok := t.domains[domain]
+		if !ok {
+			b = newBucket(rate, t.config.Window)
+			t.domains[domain] = b
+		}
+		buckets = append(buckets, b)
+	}
+	return buckets
+}
+
+// recipientDomains returns the lower-cased domains of every recipient, once each
+func recipientDomains(msg *Message) []string {
+	seen := make(map[string]bool)
+	var domains []string
+	for _, list := range [][]string{msg.To, msg.Cc, msg.Bcc} {
+		for _, address := range list {
+			at := strings.LastIndex(address, "@")
+			if at < 0 {
+				continue
+			}
+			domain := strings.ToLower(strings.TrimRight(address[at+1:], "> "))
+			if !seen[domain] {
+				seen[domain] = true
+				domains = append(domains, domain)
+			}
+		}
+	}
+	return domains
+}
+
+// bucket refills capacity tokens evenly over window. Taking a token may leave
+// it below zero, which later reservations wait out.
+type bucket struct {
+	capacity float64
+	perToken time.Duration
+	tokens   float64
+	last     time.Time
+}
+
+func newBucket(rate int, window time.Duration) *bucket {
+	return &bucket{
+		capacity: float64(rate),
+		perToken: window / time.Duration(rate),
+		tokens:   float64(rate),
+		last:     time.Now(),
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	b.tokens += float64(now.Sub(b.last)) / float64(b.perToken)
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+}
+
+// delay returns how long until a token is available
+func (b *bucket) delay(now time.Time) time.Duration {
+	b.refill(now)
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) * float64(b.perToken))
+}
+
+func (b *bucket) take(now time.Time) {
+	b.refill(now)
+	b.tokens--
+}
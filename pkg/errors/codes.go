@@ -0,0 +1,151 @@
+package errors
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// GRPCCode mirrors google.golang.org/grpc/codes so error codes can be mapped
+// without pulling gRPC into every service
+type GRPCCode uint32
+
+const (
+	GRPCOK                 GRPCCode = 0
+	GRPCCanceled           GRPCCode = 1
+	GRPCUnknown            GRPCCode = 2
+	GRPCInvalidArgument    GRPCCode = 3
+	GRPCDeadlineExceeded   GRPCCode = 4
+	GRPCNotFound           GRPCCode = 5
+	GRPCAlreadyExists      GRPCCode = 6
+	GRPCPermissionDenied   GRPCCode = 7
+	GRPCResourceExhausted  GRPCCode = 8
+	GRPCFailedPrecondition GRPCCode = 9
+	GRPCAborted            GRPCCode = 10
+	GRPCOutOfRange         GRPCCode = 11
+	GRPCUnimplemented      GRPCCode = 12
+	GRPCInternal           GRPCCode = 13
+	GRPCUnavailable        GRPCCode = 14
+	GRPCDataLoss           GRPCCode = 15
+	GRPCUnauthenticated    GRPCCode = 16
+)
+
+// CodeInfo describes a machine-readable error code
+type CodeInfo struct {
+	Code        string   `json:"code"`
+	HTTPStatus  int      `json:"http_status"`
+	GRPCCode    GRPCCode `json:"grpc_code"`
+	Description string   `json:"description"`
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]CodeInfo)
+)
+
+func init() {
+	for _, info := range []CodeInfo{
+		{ErrInternal, http.StatusInternalServerError, GRPCInternal, "Unexpected server error"},
+		{ErrNotFound, http.StatusNotFound, GRPCNotFound, "Resource not found"},
+		{ErrBadRequest, http.StatusBadRequest, GRPCInvalidArgument, "Malformed request"},
+		{"INVALID_REQUEST", http.StatusBadRequest, GRPCInvalidArgument, "Request body or parameters could not be decoded"},
+		{ErrUnauthorized, http.StatusUnauthorized, GRPCUnauthenticated, "Authentication required"},
+		{ErrForbidden, http.StatusForbidden, GRPCPermissionDenied, "Not allowed to perform this action"},
+		{ErrConflict, http.StatusConflict, GRPCAlreadyExists, "Resource conflict"},
+		{ErrValidation, http.StatusBadRequest, GRPCInvalidArgument, "Validation failed"},
+		{ErrTooManyRequests, http.StatusTooManyRequests, GRPCResourceExhausted, "Rate limit exceeded"},
+		{ErrDeleteRestricted, http.StatusConflict, GRPCFailedPrecondition, "Other records still reference the resource"},
+		{ErrRequestTooLarge, http.StatusRequestEntityTooLarge, GRPCResourceExhausted, "Request body exceeds the size limit"},
+		{ErrInvalidCredentials, http.StatusUnauthorized, GRPCUnauthenticated, "Invalid username or password"},
+		{ErrTokenExpired, http.StatusUnauthorized, GRPCUnauthenticated, "Token has expired"},
+		{ErrTokenInvalid, http.StatusUnauthorized, GRPCUnauthenticated, "Token is invalid"},
+		{ErrUserExists, http.StatusConflict, GRPCAlreadyExists, "User already exists"},
+		{ErrUserNotFound, http.StatusNotFound, GRPCNotFound, "User not found"},
+		{ErrPasswordResetRequired, http.StatusForbidden, GRPCFailedPrecondition, "Password must be reset before signing in"},
+		{ErrAccountLocked, http.StatusTooManyRequests, GRPCResourceExhausted, "Account is temporarily locked after too many failed login attempts"},
+		{"DATABASE_ERROR", http.StatusInternalServerError, GRPCInternal, "Database operation failed"},
+		{"TOKEN_ERROR", http.StatusInternalServerError, GRPCInternal, "Token operation failed"},
+	} {
+		RegisterCode(info)
+	}
+}
+
+// RegisterCode adds or replaces a code in the registry. Packages usually call it from init().
+func RegisterCode(info CodeInfo) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[info.Code] = info
+}
+
+// LookupCode returns the registry entry for a code
+func LookupCode(code string) (CodeInfo, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	info, ok := registry[code]
+	return info, ok
+}
+
+// Codes returns all registered codes sorted by code, e.g. for API documentation
+func Codes() []CodeInfo {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	codes := make([]CodeInfo, 0, len(registry))
+	for _, info := range registry {
+		codes = append(codes, info)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i].Code < codes[j].Code })
+	return codes
+}
+
+// FromCode creates an AppError with the HTTP status from the registry (500 for unknown codes)
+func FromCode(code, message string) *AppError {
+	status := http.StatusInternalServerError
+	if info, ok := LookupCode(code); ok {
+		status = info.HTTPStatus
+		if message == "" {
+			message = info.Description
+		}
+	}
+
+	return New(code, message, status)
+}
+
+// GRPCCode returns the gRPC code for the error: the registry mapping when the code
+// is registered, otherwise one derived from the HTTP status
+func (e *AppError) GRPCCode() GRPCCode {
+	if info, ok := LookupCode(e.Code); ok {
+		return info.GRPCCode
+	}
+	return grpcCodeFromHTTP(e.StatusCode)
+}
+
+func grpcCodeFromHTTP(status int) GRPCCode {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return GRPCInvalidArgument
+	case http.StatusUnauthorized:
+		return GRPCUnauthenticated
+	case http.StatusForbidden:
+		return GRPCPermissionDenied
+	case http.StatusNotFound:
+		return GRPCNotFound
+	case http.StatusConflict:
+		return GRPCAlreadyExists
+	case http.StatusPreconditionFailed:
+		return GRPCFailedPrecondition
+	case http.StatusTooManyRequests:
+		return GRPCResourceExhausted
+	case http.StatusNotImplemented:
+		return GRPCUnimplemented
+	case http.StatusServiceUnavailable:
+		return GRPCUnavailable
+	case http.StatusGatewayTimeout:
+		return GRPCDeadlineExceeded
+	default:
+		if status >= 500 {
+			return GRPCInternal
+		}
+		return GRPCUnknown
+	}
+}
@@ -2,7 +2,11 @@ package errors
 
 import (
 	"fmt"
+	"math"
 	"net/http"
+	"time"
+
+	"flex-service/pkg/i18n"
 )
 
 // AppError represents application-specific errors
@@ -12,6 +16,12 @@ type AppError struct {
 	StatusCode int         `json:"-"`
 	Details    interface{} `json:"details,omitempty"`
 	Cause      error       `json:"-"`
+
+	// MessageKey and MessageArgs translate Message through pkg/i18n
+	MessageKey  string                 `json:"-"`
+	MessageArgs map[string]interface{} `json:"-"`
+
+	stack []uintptr
 }
 
 func (e *AppError) Error() string {
@@ -21,17 +31,31 @@ func (e *AppError) Error() string {
 	return e.Message
 }
 
+// Unwrap returns the wrapped cause so errors.Is/As see through AppError
+func (e *AppError) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is an AppError with the same code,
+// so errors.Is(err, errors.ErrNotFoundError) matches any NOT_FOUND error
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Code == e.Code
+}
+
 // Error codes
 const (
 	// General errors
-	ErrInternal        = "INTERNAL_ERROR"
-	ErrNotFound        = "NOT_FOUND"
-	ErrBadRequest      = "BAD_REQUEST"
-	ErrUnauthorized    = "UNAUTHORIZED"
-	ErrForbidden       = "FORBIDDEN"
-	ErrConflict        = "CONFLICT"
-	ErrValidation      = "VALIDATION_ERROR"
-	ErrTooManyRequests = "TOO_MANY_REQUESTS"
+	ErrInternal         = "INTERNAL_ERROR"
+	ErrNotFound         = "NOT_FOUND"
+	ErrBadRequest       = "BAD_REQUEST"
+	ErrUnauthorized     = "UNAUTHORIZED"
+	ErrForbidden        = "FORBIDDEN"
+	ErrConflict         = "CONFLICT"
+	ErrValidation       = "VALIDATION_ERROR"
+	ErrTooManyRequests  = "TOO_MANY_REQUESTS"
+	ErrDeleteRestricted = "DELETE_RESTRICTED"
+	ErrRequestTooLarge  = "REQUEST_TOO_LARGE"
 
 	// Auth errors
 	ErrInvalidCredentials = "INVALID_CREDENTIALS"
@@ -39,6 +63,9 @@ const (
 	ErrTokenInvalid       = "TOKEN_INVALID"
 	ErrUserExists         = "USER_EXISTS"
 	ErrUserNotFound       = "USER_NOT_FOUND"
+
+	ErrPasswordResetRequired = "PASSWORD_RESET_REQUIRED"
+	ErrAccountLocked         = "ACCOUNT_LOCKED"
 )
 
 // New creates a new AppError
@@ -47,6 +74,7 @@ func New(code, message string, statusCode int) *AppError {
 		Code:       code,
 		Message:    message,
 		StatusCode: statusCode,
+		stack:      callers(),
 	}
 }
 
@@ -57,6 +85,7 @@ func Wrap(err error, code, message string, statusCode int) *AppError {
 		Message:    message,
 		StatusCode: statusCode,
 		Cause:      err,
+		stack:      callers(),
 	}
 }
 
@@ -66,6 +95,32 @@ func (e *AppError) WithDetails(details interface{}) *AppError {
 	return e
 }
 
+// WithMessageKey sets the i18n key used by LocalizedMessage
+func (e *AppError) WithMessageKey(key string, args map[string]interface{}) *AppError {
+	e.MessageKey = key
+	e.MessageArgs = args
+	return e
+}
+
+// LocalizedMessage translates the message into locale. An explicit MessageKey wins;
+// otherwise a message equal to the default text for its code (e.g. "Resource not found"
+// for NOT_FOUND) is translated via errors.<CODE>. Custom messages are returned as is.
+func (e *AppError) LocalizedMessage(locale string) string {
+	key := e.MessageKey
+	if key == "" {
+		defaultKey := "errors." + e.Code
+		if text, ok := i18n.Lookup(i18n.Default().DefaultLocale(), defaultKey); !ok || text != e.Message {
+			return e.Message
+		}
+		key = defaultKey
+	}
+
+	if message, ok := i18n.Lookup(locale, key); ok {
+		return i18n.Format(message, e.MessageArgs)
+	}
+	return e.Message
+}
+
 // Predefined errors
 var (
 	ErrInternalServer    = New(ErrInternal, "Internal server error", http.StatusInternalServerError)
@@ -156,6 +211,14 @@ func WrapTooManyRequests(err error, message string) *AppError {
 	return Wrap(err, ErrTooManyRequests, message, http.StatusTooManyRequests)
 }
 
+// RequestTooLarge creates an error for a request body over the size limit
+func RequestTooLarge(message string) *AppError {
+	if message == "" {
+		message = "Request body too large"
+	}
+	return New(ErrRequestTooLarge, message, http.StatusRequestEntityTooLarge)
+}
+
 // =============================================================================
 // Wrapping Helper Functions
 // =============================================================================
@@ -233,11 +296,11 @@ func TokenInvalid() *AppError {
 
 // UserExists creates user already exists error
 func UserExists(field string) *AppError {
-	message := "User already exists"
 	if field != "" {
-		message = fmt.Sprintf("%s already exists", field)
+		return New(ErrUserExists, fmt.Sprintf("%s already exists", field), http.StatusConflict).
+			WithMessageKey("errors.FIELD_EXISTS", map[string]interface{}{"field": field})
 	}
-	return New(ErrUserExists, message, http.StatusConflict)
+	return New(ErrUserExists, "User already exists", http.StatusConflict)
 }
 
 // UserNotFound creates user not found error
@@ -261,7 +324,31 @@ func WrapTokenError(err error, message string) *AppError {
 	return Wrap(err, "TOKEN_ERROR", message, http.StatusInternalServerError)
 }
 
+// DeleteRestricted creates the error for deleting a record that other records
+// still reference; details list the blocking references
+func DeleteRestricted(details interface{}) *AppError {
+	return New(ErrDeleteRestricted, "Cannot delete: other records still reference it", http.StatusConflict).
+		WithDetails(details)
+}
+
 // AccountDisabled creates account disabled error
 func AccountDisabled() *AppError {
-	return New(ErrUnauthorized, "Account is disabled", http.StatusUnauthorized)
+	return New(ErrUnauthorized, "Account is disabled", http.StatusUnauthorized).
+		WithMessageKey("errors.ACCOUNT_DISABLED", nil)
+}
+
+// PasswordResetRequired creates the error for a password login to an account
+// whose password must be reset first
+func PasswordResetRequired() *AppError {
+	return New(ErrPasswordResetRequired, "Password must be reset before signing in", http.StatusForbidden).
+		WithMessageKey("errors.PASSWORD_RESET_REQUIRED", nil)
+}
+
+// AccountLocked creates the error for a login while the account is locked
+// after too many failed attempts; details carry the seconds until it unlocks
+func AccountLocked(retryAfter time.Duration) *AppError {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	return New(ErrAccountLocked, "Account is temporarily locked after too many failed login attempts", http.StatusTooManyRequests).
+		WithMessageKey("errors.ACCOUNT_LOCKED", nil).
+		WithDetails(map[string]interface{}{"retry_after": seconds})
 }
@@ -0,0 +1,48 @@
+package errors
+
+import (
+	stderrors "errors"
+)
+
+// Is reports whether any error in err's chain matches target (see errors.Is)
+func Is(err, target error) bool {
+	return stderrors.Is(err, target)
+}
+
+// As finds the first error in err's chain that matches target (see errors.As)
+func As(err error, target interface{}) bool {
+	return stderrors.As(err, target)
+}
+
+// Unwrap returns the result of calling Unwrap on err (see errors.Unwrap)
+func Unwrap(err error) error {
+	return stderrors.Unwrap(err)
+}
+
+// AsAppError returns the first AppError in err's chain
+func AsAppError(err error) (*AppError, bool) {
+	var appErr *AppError
+	if stderrors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
+
+// HasCode reports whether err's chain contains an AppError with the given code
+func HasCode(err error, code string) bool {
+	for err != nil {
+		if appErr, ok := err.(*AppError); ok && appErr.Code == code {
+			return true
+		}
+		err = stderrors.Unwrap(err)
+	}
+	return false
+}
+
+// CodeOf returns the code of the first AppError in err's chain, or INTERNAL_ERROR
+func CodeOf(err error) string {
+	if appErr, ok := AsAppError(err); ok {
+		return appErr.Code
+	}
+	return ErrInternal
+}
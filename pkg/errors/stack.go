@@ -0,0 +1,57 @@
+package errors
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+const maxStackDepth = 32
+
+var captureStack atomic.Bool
+
+// EnableStackTraces turns stack capture on or off for new AppErrors.
+// Capturing costs a runtime.Callers call per error, so enable it in development only.
+func EnableStackTraces(enabled bool) {
+	captureStack.Store(enabled)
+}
+
+// StackTracesEnabled reports whether stack capture is on
+func StackTracesEnabled() bool {
+	return captureStack.Load()
+}
+
+// callers records the stack of the function that created the error
+func callers() []uintptr {
+	if !captureStack.Load() {
+		return nil
+	}
+
+	pcs := make([]uintptr, maxStackDepth)
+	// Skip runtime.Callers, callers and New/Wrap
+	n := runtime.Callers(3, pcs)
+	return pcs[:n]
+}
+
+// StackTrace returns the captured stack as "function file:line" entries,
+// or nil when stack traces were disabled at creation time
+func (e *AppError) StackTrace() []string {
+	if len(e.stack) == 0 {
+		return nil
+	}
+
+	var trace []string
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		frame, more := frames.Next()
+		// Skip helper constructors in this package (NotFound, WrapDatabase, ...)
+		if !strings.HasPrefix(frame.Function, "flex-service/pkg/errors.") {
+			trace = append(trace, fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return trace
+}
@@ -0,0 +1,77 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"flex-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// Wildcard subscribes a handler to every event
+const Wildcard = "*"
+
+// Event is a named message published on the bus
+type Event struct {
+	Name       string      `json:"name"`
+	Payload    interface{} `json:"payload"`
+	OccurredAt time.Time   `json:"occurred_at"`
+}
+
+// Handler processes an event
+type Handler func(ctx context.Context, e Event) error
+
+// Bus is an in-process publish/subscribe event bus
+type Bus interface {
+	Subscribe(name string, handler Handler)
+	Publish(ctx context.Context, name string, payload interface{}) error
+	PublishAsync(ctx context.Context, name string, payload interface{})
+}
+
+type bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// NewBus creates an in-memory event bus
+func NewBus() Bus {
+	return &bus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe registers a handler for an event name, or Wildcard for all events
+func (b *bus) Subscribe(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], handler)
+}
+
+// Publish runs every matching handler synchronously and returns their joined errors.
+// A failing handler does not stop the others.
+func (b *bus) Publish(ctx context.Context, name string, payload interface{}) error {
+	e := Event{Name: name, Payload: payload, OccurredAt: time.Now().UTC()}
+
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.handlers[name])+len(b.handlers[Wildcard]))
+	handlers = append(handlers, b.handlers[name]...)
+	handlers = append(handlers, b.handlers[Wildcard]...)
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, e); err != nil {
+			logger.Error("Event handler failed", zap.String("event", name), zap.Error(err))
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PublishAsync publishes in a goroutine, detached from the request context's cancellation
+func (b *bus) PublishAsync(ctx context.Context, name string, payload interface{}) {
+	go func() {
+		_ = b.Publish(context.WithoutCancel(ctx), name, payload)
+	}()
+}
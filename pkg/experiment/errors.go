@@ -0,0 +1,11 @@
+package experiment
+
+import "errors"
+
+// Experiment errors
+var (
+	ErrExperimentNotFound = errors.New("experiment not found")
+	ErrInvalidExperiment  = errors.New("invalid experiment")
+	ErrNotAssigned        = errors.New("user is not assigned to experiment")
+	ErrMissingUser        = errors.New("experiment subject requires a user id")
+)
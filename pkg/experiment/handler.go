@@ -0,0 +1,79 @@
+package experiment
+
+import (
+	"net/http"
+
+	"flex-service/pkg/errors"
+	"flex-service/pkg/feature"
+	"flex-service/pkg/request"
+	"flex-service/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConvertRequest records a conversion for the current user
+type ConvertRequest struct {
+	Goal  string  `json:"goal" validate:"required,max=100"`
+	Value float64 `json:"value"`
+}
+
+// Handler exposes experiments over HTTP
+type Handler struct {
+	manager *Manager
+}
+
+// NewHandler creates an experiment HTTP handler
+func NewHandler(manager *Manager) *Handler {
+	return &Handler{manager: manager}
+}
+
+// Assign returns the current user's variant: GET /experiments/:key/variant
+func (h *Handler) Assign(c *gin.Context) {
+	result, err := h.manager.Assign(c.Request.Context(), c.Param("key"), feature.SubjectFromContext(c))
+	if err != nil {
+		c.Error(toAppError(err))
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Experiment variant assigned", result)
+}
+
+// Convert records a conversion: POST /experiments/:key/convert
+func (h *Handler) Convert(c *gin.Context) {
+	req, err := request.Bind[ConvertRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.manager.Convert(c.Request.Context(), c.Param("key"), feature.SubjectFromContext(c), req.Goal, req.Value); err != nil {
+		c.Error(toAppError(err))
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Conversion recorded", nil)
+}
+
+// Results returns per-variant statistics: GET /experiments/:key/results?goal=checkout
+func (h *Handler) Results(c *gin.Context) {
+	results, err := h.manager.Results(c.Request.Context(), c.Param("key"), c.Query("goal"))
+	if err != nil {
+		c.Error(toAppError(err))
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Experiment results retrieved", results)
+}
+
+func toAppError(err error) error {
+	switch {
+	case errors.Is(err, ErrExperimentNotFound):
+		return errors.NotFound("Experiment not found")
+	case errors.Is(err, ErrNotAssigned):
+		return errors.New("EXPERIMENT_NOT_ASSIGNED", "User is not assigned to this experiment", http.StatusConflict)
+	case errors.Is(err, ErrMissingUser):
+		return errors.Unauthorized("User not authenticated")
+	default:
+		return errors.WrapInternal(err, "Experiment operation failed")
+	}
+}
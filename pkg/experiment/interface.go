@@ -0,0 +1,82 @@
+package experiment
+
+import (
+	"context"
+	"time"
+)
+
+// Event names published on the event bus
+const (
+	EventExposure   = "experiment.exposure"
+	EventConversion = "experiment.conversion"
+)
+
+// Variant is one arm of an experiment
+type Variant struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"` // Relative traffic share
+}
+
+// Experiment defines an A/B test
+type Experiment struct {
+	Key         string    `json:"key"`
+	Description string    `json:"description,omitempty"`
+	FlagKey     string    `json:"flag_key,omitempty"` // Feature flag gating enrollment; empty = everyone
+	Control     string    `json:"control"`            // Variant used for non-enrolled subjects and as the results baseline
+	Variants    []Variant `json:"variants"`
+}
+
+// Assignment records which variant a user got
+type Assignment struct {
+	ID            int       `json:"-" gorm:"primaryKey"`
+	ExperimentKey string    `json:"experiment_key" gorm:"type:varchar(100);not null;uniqueIndex:idx_experiment_assignment"`
+	UserID        string    `json:"user_id" gorm:"type:varchar(64);not null;uniqueIndex:idx_experiment_assignment"`
+	Variant       string    `json:"variant" gorm:"type:varchar(100);not null"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for GORM
+func (Assignment) TableName() string {
+	return "tb_experiment_assignment"
+}
+
+// EventType is exposure or conversion
+type EventType string
+
+const (
+	EventTypeExposure   EventType = "exposure"
+	EventTypeConversion EventType = "conversion"
+)
+
+// Record is a persisted exposure or conversion
+type Record struct {
+	ID            int       `json:"-" gorm:"primaryKey"`
+	ExperimentKey string    `json:"experiment_key" gorm:"type:varchar(100);not null;index:idx_experiment_event"`
+	Variant       string    `json:"variant" gorm:"type:varchar(100);not null;index:idx_experiment_event"`
+	UserID        string    `json:"user_id" gorm:"type:varchar(64);not null;index"`
+	Type          EventType `json:"type" gorm:"type:varchar(20);not null;index:idx_experiment_event"`
+	Goal          string    `json:"goal,omitempty" gorm:"type:varchar(100);index:idx_experiment_event"`
+	Value         float64   `json:"value"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for GORM
+func (Record) TableName() string {
+	return "tb_experiment_event"
+}
+
+// VariantCounts are raw per-variant numbers used for results
+type VariantCounts struct {
+	Variant    string
+	Exposed    int64   // Distinct exposed users
+	Converted  int64   // Distinct converted users
+	TotalValue float64 // Sum of conversion values
+}
+
+// Store persists assignments and events
+type Store interface {
+	GetAssignment(ctx context.Context, experimentKey, userID string) (*Assignment, error)
+	SaveAssignment(ctx context.Context, assignment *Assignment) error
+	SaveRecord(ctx context.Context, record *Record) error
+	Counts(ctx context.Context, experimentKey, goal string) ([]VariantCounts, error)
+}
@@ -0,0 +1,256 @@
+package experiment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+
+	"flex-service/pkg/event"
+	"flex-service/pkg/feature"
+	"flex-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// Result is the outcome of assigning a subject to an experiment
+type Result struct {
+	Experiment string `json:"experiment"`
+	Variant    string `json:"variant"`
+	Enrolled   bool   `json:"enrolled"` // false when the gating flag is off; Variant is then the control
+}
+
+// Exposure is the payload of EventExposure
+type Exposure struct {
+	Experiment string `json:"experiment"`
+	Variant    string `json:"variant"`
+	UserID     string `json:"user_id"`
+}
+
+// Conversion is the payload of EventConversion
+type Conversion struct {
+	Experiment string  `json:"experiment"`
+	Variant    string  `json:"variant"`
+	UserID     string  `json:"user_id"`
+	Goal       string  `json:"goal"`
+	Value      float64 `json:"value"`
+}
+
+// Manager assigns variants and records exposures and conversions
+type Manager struct {
+	store       Store
+	flags       *feature.Manager
+	bus         event.Bus
+	experiments map[string]*Experiment
+}
+
+// NewManager creates an experiment manager and subscribes the store to experiment events.
+// flags may be nil when no experiment uses FlagKey.
+func NewManager(store Store, flags *feature.Manager, bus event.Bus, experiments ...*Experiment) (*Manager, error) {
+	m := &Manager{
+		store:       store,
+		flags:       flags,
+		bus:         bus,
+		experiments: make(map[string]*Experiment, len(experiments)),
+	}
+
+	for _, exp := range experiments {
+		if err := Validate(exp); err != nil {
+			return nil, err
+		}
+		m.experiments[exp.Key] = exp
+	}
+
+	bus.Subscribe(EventExposure, m.recordExposure)
+	bus.Subscribe(EventConversion, m.recordConversion)
+
+	return m, nil
+}
+
+// LoadFile reads experiment definitions from a JSON array
+func LoadFile(path string) ([]*Experiment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read experiments file: %w", err)
+	}
+
+	var experiments []*Experiment
+	if err := json.Unmarshal(data, &experiments); err != nil {
+		return nil, fmt.Errorf("failed to parse experiments file: %w", err)
+	}
+	return experiments, nil
+}
+
+// Validate checks an experiment definition
+func Validate(exp *Experiment) error {
+	if exp == nil || exp.Key == "" {
+		return fmt.Errorf("%w: key is required", ErrInvalidExperiment)
+	}
+	if len(exp.Variants) < 2 {
+		return fmt.Errorf("%w: %s needs at least two variants", ErrInvalidExperiment, exp.Key)
+	}
+
+	hasControl := false
+	for _, v := range exp.Variants {
+		if v.Weight < 0 {
+			return fmt.Errorf("%w: %s variant %s has a negative weight", ErrInvalidExperiment, exp.Key, v.Name)
+		}
+		if v.Name == exp.Control {
+			hasControl = true
+		}
+	}
+	if !hasControl {
+		return fmt.Errorf("%w: %s control %q is not a variant", ErrInvalidExperiment, exp.Key, exp.Control)
+	}
+	if totalWeight(exp) == 0 {
+		return fmt.Errorf("%w: %s variant weights sum to zero", ErrInvalidExperiment, exp.Key)
+	}
+	return nil
+}
+
+// Get returns an experiment definition
+func (m *Manager) Get(key string) (*Experiment, error) {
+	exp, ok := m.experiments[key]
+	if !ok {
+		return nil, ErrExperimentNotFound
+	}
+	return exp, nil
+}
+
+// Assign returns the subject's variant, creating a sticky assignment on first call,
+// and publishes an exposure event
+func (m *Manager) Assign(ctx context.Context, key string, subject feature.Subject) (*Result, error) {
+	exp, err := m.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if subject.UserID == "" {
+		return nil, ErrMissingUser
+	}
+
+	if exp.FlagKey != "" && (m.flags == nil || !m.flags.IsEnabled(ctx, exp.FlagKey, subject)) {
+		return &Result{Experiment: key, Variant: exp.Control}, nil
+	}
+
+	variant, err := m.assignment(ctx, exp, subject.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.bus.PublishAsync(ctx, EventExposure, &Exposure{Experiment: key, Variant: variant, UserID: subject.UserID})
+
+	return &Result{Experiment: key, Variant: variant, Enrolled: true}, nil
+}
+
+// Convert records a conversion for an assigned subject
+func (m *Manager) Convert(ctx context.Context, key string, subject feature.Subject, goal string, value float64) error {
+	if _, err := m.Get(key); err != nil {
+		return err
+	}
+	if subject.UserID == "" {
+		return ErrMissingUser
+	}
+
+	assignment, err := m.store.GetAssignment(ctx, key, subject.UserID)
+	if err != nil {
+		return err
+	}
+	if assignment == nil {
+		return ErrNotAssigned
+	}
+
+	return m.bus.Publish(ctx, EventConversion, &Conversion{
+		Experiment: key,
+		Variant:    assignment.Variant,
+		UserID:     subject.UserID,
+		Goal:       goal,
+		Value:      value,
+	})
+}
+
+// assignment returns the stored variant or buckets the user and stores the result.
+// Stored assignments win over the hash so changing weights never moves existing users.
+func (m *Manager) assignment(ctx context.Context, exp *Experiment, userID string) (string, error) {
+	existing, err := m.store.GetAssignment(ctx, exp.Key, userID)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil && hasVariant(exp, existing.Variant) {
+		return existing.Variant, nil
+	}
+
+	variant := pick(exp, userID)
+	if err := m.store.SaveAssignment(ctx, &Assignment{ExperimentKey: exp.Key, UserID: userID, Variant: variant}); err != nil {
+		return "", err
+	}
+	return variant, nil
+}
+
+func (m *Manager) recordExposure(ctx context.Context, e event.Event) error {
+	exposure, ok := e.Payload.(*Exposure)
+	if !ok {
+		return nil
+	}
+	return m.store.SaveRecord(ctx, &Record{
+		ExperimentKey: exposure.Experiment,
+		Variant:       exposure.Variant,
+		UserID:        exposure.UserID,
+		Type:          EventTypeExposure,
+	})
+}
+
+func (m *Manager) recordConversion(ctx context.Context, e event.Event) error {
+	conversion, ok := e.Payload.(*Conversion)
+	if !ok {
+		return nil
+	}
+
+	logger.Debug("Experiment conversion",
+		zap.String("experiment", conversion.Experiment),
+		zap.String("variant", conversion.Variant),
+		zap.String("goal", conversion.Goal))
+
+	return m.store.SaveRecord(ctx, &Record{
+		ExperimentKey: conversion.Experiment,
+		Variant:       conversion.Variant,
+		UserID:        conversion.UserID,
+		Type:          EventTypeConversion,
+		Goal:          conversion.Goal,
+		Value:         conversion.Value,
+	})
+}
+
+// pick maps a stable hash of experiment and user onto the weighted variants
+func pick(exp *Experiment, userID string) string {
+	h := fnv.New32a()
+	h.Write([]byte(exp.Key))
+	h.Write([]byte{':'})
+	h.Write([]byte(userID))
+
+	point := int(h.Sum32() % uint32(totalWeight(exp)))
+	for _, v := range exp.Variants {
+		if point < v.Weight {
+			return v.Name
+		}
+		point -= v.Weight
+	}
+	return exp.Control
+}
+
+func totalWeight(exp *Experiment) int {
+	total := 0
+	for _, v := range exp.Variants {
+		total += v.Weight
+	}
+	return total
+}
+
+func hasVariant(exp *Experiment, name string) bool {
+	for _, v := range exp.Variants {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package experiment
+
+import (
+	"context"
+	"math"
+)
+
+// Significance threshold for the two-proportion z-test
+const significanceLevel = 0.05
+
+// VariantResult holds per-variant statistics
+type VariantResult struct {
+	Variant        string  `json:"variant"`
+	Control        bool    `json:"control"`
+	Exposed        int64   `json:"exposed"`
+	Converted      int64   `json:"converted"`
+	ConversionRate float64 `json:"conversion_rate"`
+	CILow          float64 `json:"ci_low"` // 95% confidence interval of the rate
+	CIHigh         float64 `json:"ci_high"`
+	TotalValue     float64 `json:"total_value"`
+	Uplift         float64 `json:"uplift"`  // Relative to control, e.g. 0.12 = +12%
+	PValue         float64 `json:"p_value"` // Two-sided two-proportion z-test against control
+	Significant    bool    `json:"significant"`
+}
+
+// Results summarizes an experiment for one goal
+type Results struct {
+	Experiment string          `json:"experiment"`
+	Goal       string          `json:"goal,omitempty"`
+	Variants   []VariantResult `json:"variants"`
+}
+
+// Results computes conversion statistics per variant. An empty goal counts any conversion.
+func (m *Manager) Results(ctx context.Context, key, goal string) (*Results, error) {
+	exp, err := m.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := m.store.Counts(ctx, key, goal)
+	if err != nil {
+		return nil, err
+	}
+
+	byVariant := make(map[string]VariantCounts, len(counts))
+	for _, c := range counts {
+		byVariant[c.Variant] = c
+	}
+	control := byVariant[exp.Control]
+
+	results := &Results{Experiment: key, Goal: goal}
+	for _, v := range exp.Variants {
+		c := byVariant[v.Name]
+		r := VariantResult{
+			Variant:    v.Name,
+			Control:    v.Name == exp.Control,
+			Exposed:    c.Exposed,
+			Converted:  c.Converted,
+			TotalValue: c.TotalValue,
+			PValue:     1,
+		}
+		r.ConversionRate = rate(c.Converted, c.Exposed)
+		r.CILow, r.CIHigh = confidenceInterval(c.Converted, c.Exposed)
+
+		if !r.Control {
+			controlRate := rate(control.Converted, control.Exposed)
+			if controlRate > 0 {
+				r.Uplift = (r.ConversionRate - controlRate) / controlRate
+			}
+			r.PValue = zTest(control.Converted, control.Exposed, c.Converted, c.Exposed)
+			r.Significant = r.PValue < significanceLevel
+		}
+
+		results.Variants = append(results.Variants, r)
+	}
+
+	return results, nil
+}
+
+func rate(converted, exposed int64) float64 {
+	if exposed == 0 {
+		return 0
+	}
+	return float64(converted) / float64(exposed)
+}
+
+// confidenceInterval returns the 95% normal-approximation interval, clamped to [0, 1]
+func confidenceInterval(converted, exposed int64) (float64, float64) {
+	if exposed == 0 {
+		return 0, 0
+	}
+	p := rate(converted, exposed)
+	margin := 1.96 * math.Sqrt(p*(1-p)/float64(exposed))
+	return math.Max(0, p-margin), math.Min(1, p+margin)
+}
+
+// zTest returns the two-sided p-value of a pooled two-proportion z-test
+func zTest(c1, n1, c2, n2 int64) float64 {
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	p1, p2 := rate(c1, n1), rate(c2, n2)
+	pooled := float64(c1+c2) / float64(n1+n2)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(n1) + 1/float64(n2)))
+	if se == 0 {
+		return 1
+	}
+
+	z := (p2 - p1) / se
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
@@ -0,0 +1,63 @@
+package experiment
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormStore persists assignments and events with GORM
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore creates a GORM-backed store
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+// GetAssignment returns the user's assignment or nil when there is none
+func (s *GormStore) GetAssignment(ctx context.Context, experimentKey, userID string) (*Assignment, error) {
+	var assignment Assignment
+	err := s.db.WithContext(ctx).
+		Where("experiment_key = ? AND user_id = ?", experimentKey, userID).
+		First(&assignment).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// SaveAssignment stores or replaces the user's assignment
+func (s *GormStore) SaveAssignment(ctx context.Context, assignment *Assignment) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "experiment_key"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"variant"}),
+	}).Create(assignment).Error
+}
+
+// SaveRecord stores an exposure or conversion
+func (s *GormStore) SaveRecord(ctx context.Context, record *Record) error {
+	return s.db.WithContext(ctx).Create(record).Error
+}
+
+// Counts returns distinct exposed and converted users per variant. An empty goal counts any conversion.
+func (s *GormStore) Counts(ctx context.Context, experimentKey, goal string) ([]VariantCounts, error) {
+	var counts []VariantCounts
+	err := s.db.WithContext(ctx).Model(&Record{}).
+		Select(`variant,
+			COUNT(DISTINCT CASE WHEN type = ? THEN user_id END) AS exposed,
+			COUNT(DISTINCT CASE WHEN type = ? AND (? = '' OR goal = ?) THEN user_id END) AS converted,
+			COALESCE(SUM(CASE WHEN type = ? AND (? = '' OR goal = ?) THEN value ELSE 0 END), 0) AS total_value`,
+			EventTypeExposure,
+			EventTypeConversion, goal, goal,
+			EventTypeConversion, goal, goal).
+		Where("experiment_key = ?", experimentKey).
+		Group("variant").
+		Scan(&counts).Error
+	return counts, err
+}
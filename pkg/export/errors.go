@@ -0,0 +1,14 @@
+package export
+
+import "errors"
+
+// Export errors
+var (
+	ErrUnsupportedFormat = errors.New("unsupported format")
+	ErrUnknownDataset    = errors.New("unknown dataset")
+	ErrJobNotFound       = errors.New("export job not found")
+	ErrNotReady          = errors.New("export is not ready")
+	ErrBusy              = errors.New("too many exports and imports in progress")
+	ErrMissingColumn     = errors.New("query result has no column")
+	ErrTooManyRows       = errors.New("too many rows for an XLSX sheet")
+)
@@ -0,0 +1,49 @@
+package export
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Format is a file format of exports and imports
+type Format string
+
+// Formats
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+	JSON Format = "json"
+)
+
+// ParseFormat returns the format named s, e.g. "csv"
+func ParseFormat(s string) (Format, error) {
+	switch format := Format(strings.ToLower(strings.TrimSpace(s))); format {
+	case CSV, XLSX, JSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnsupportedFormat, s)
+}
+
+// FormatOf returns the format of a file name by its extension
+func FormatOf(name string) (Format, error) {
+	return ParseFormat(strings.TrimPrefix(filepath.Ext(name), "."))
+}
+
+// ContentType is the MIME type of files in the format
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case JSON:
+		return "application/json"
+	}
+	return "application/octet-stream"
+}
+
+// Extension is the file extension of the format, with the dot
+func (f Format) Extension() string {
+	return "." + string(f)
+}
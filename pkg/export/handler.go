@@ -0,0 +1,216 @@
+package export
+
+import (
+	"context"
+	"net/http"
+
+	"flex-service/pkg/auth"
+	"flex-service/pkg/errors"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/request"
+	"flex-service/pkg/requestctx"
+	"flex-service/pkg/response"
+	"flex-service/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// importTypes are the sniffed types of CSV and JSON (text) and XLSX (zip) files
+var importTypes = []string{"text/*", "application/zip"}
+
+// ExportRequest requests an export
+type ExportRequest struct {
+	Dataset string            `json:"dataset" validate:"required,max=100"`
+	Format  string            `json:"format" validate:"required,oneof=csv xlsx json"`
+	Params  map[string]string `json:"params" validate:"max=20"`
+}
+
+// Handler exposes exports and imports of the authenticated user over HTTP
+type Handler struct {
+	manager     *Manager
+	permissions *auth.PermissionChecker
+}
+
+// NewHandler creates an export HTTP handler. Datasets with a Permission are
+// checked against permissions; a nil checker denies them.
+func NewHandler(manager *Manager, permissions *auth.PermissionChecker) *Handler {
+	return &Handler{manager: manager, permissions: permissions}
+}
+
+// RequestExport queues an export and answers 202 with the job; poll
+// ShowExport for progress: POST /data/exports
+func (h *Handler) RequestExport(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	req, err := request.BindJSON[ExportRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !h.allowed(c, req.Dataset) {
+		c.Error(errors.Forbidden("Not allowed to export this dataset"))
+		return
+	}
+
+	job, err := h.manager.RequestExport(c.Request.Context(), userID, req.Dataset, Format(req.Format), req.Params)
+	if err != nil {
+		c.Error(toAppError(err))
+		return
+	}
+
+	c.Header("Location", c.FullPath()+"/"+job.ID)
+	response.Success(c, http.StatusAccepted, "Export requested successfully", job)
+}
+
+// ShowExport returns an export with its status and progress: GET /data/exports/:id
+func (h *Handler) ShowExport(c *gin.Context) {
+	h.show(c, KindExport, "Export retrieved successfully")
+}
+
+// DownloadExport returns a temporary URL of a completed export's file:
+// GET /data/exports/:id/download
+func (h *Handler) DownloadExport(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	download, err := h.manager.Download(c.Request.Context(), userID, id)
+	if err != nil {
+		c.Error(toAppError(err))
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Export download URL created", download)
+}
+
+// RequestImport stores the uploaded "file" and queues its import into the
+// "dataset" form field. The format is taken from the file extension:
+// POST /data/imports
+func (h *Handler) RequestImport(c *gin.Context) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+
+	uploaded, err := storage.Store(c, h.manager.files, storage.UploadOptions{
+		Directory:    "imports/{yyyy}/{mm}/{dd}",
+		MaxSize:      h.manager.config.MaxImportSize,
+		AllowedTypes: importTypes,
+	})
+	if err != nil {
+		c.Error(storage.UploadError(err))
+		return
+	}
+
+	job, err := h.requestImport(c, userID, uploaded)
+	if err != nil {
+		if deleteErr := h.manager.files.Delete(context.WithoutCancel(c.Request.Context()), uploaded.Path); deleteErr != nil {
+			logger.Warn("Failed to delete import file", zap.String("path", uploaded.Path), zap.Error(deleteErr))
+		}
+		c.Error(err)
+		return
+	}
+
+	c.Header("Location", c.FullPath()+"/"+job.ID)
+	response.Success(c, http.StatusAccepted, "Import requested successfully", job)
+}
+
+func (h *Handler) requestImport(c *gin.Context, userID int, uploaded *storage.UploadedFile) (*Job, error) {
+	dataset := c.PostForm("dataset")
+	if dataset == "" {
+		return nil, errors.BadRequest("dataset is required")
+	}
+	format, err := FormatOf(uploaded.OriginalName)
+	if err != nil {
+		return nil, errors.BadRequest("Import files must be .csv, .xlsx or .json")
+	}
+	if !h.allowed(c, dataset) {
+		return nil, errors.Forbidden("Not allowed to import this dataset")
+	}
+
+	job, err := h.manager.RequestImport(c.Request.Context(), userID, dataset, format, uploaded.Path, uploaded.OriginalName)
+	if err != nil {
+		return nil, toAppError(err)
+	}
+	return job, nil
+}
+
+// ShowImport returns an import with its progress and, once done, its report
+// of failed rows: GET /data/imports/:id
+func (h *Handler) ShowImport(c *gin.Context) {
+	h.show(c, KindImport, "Import retrieved successfully")
+}
+
+func (h *Handler) show(c *gin.Context, kind JobKind, message string) {
+	userID, exists := requestctx.CurrentUserID(c)
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated", nil)
+		return
+	}
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.manager.Get(c.Request.Context(), userID, kind, id)
+	if err != nil {
+		c.Error(toAppError(err))
+		return
+	}
+
+	response.Success(c, http.StatusOK, message, job)
+}
+
+// allowed checks the dataset's permission. Unknown datasets pass, so the
+// manager can report them as not found.
+func (h *Handler) allowed(c *gin.Context, name string) bool {
+	dataset, err := h.manager.Dataset(name)
+	if err != nil || dataset.Permission == "" {
+		return true
+	}
+
+	principal, exists := requestctx.CurrentPrincipal(c)
+	return exists && h.permissions != nil && h.permissions.Can(principal, dataset.Permission, nil)
+}
+
+func idParam(c *gin.Context) (string, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(errors.BadRequest("Invalid job ID"))
+		return "", false
+	}
+	return id.String(), true
+}
+
+func toAppError(err error) error {
+	if _, ok := errors.AsAppError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, ErrUnknownDataset):
+		return errors.NotFound("Dataset not found")
+	case errors.Is(err, ErrUnsupportedFormat):
+		return errors.WrapBadRequest(err, "Unsupported format")
+	case errors.Is(err, ErrJobNotFound):
+		return errors.NotFound("Job not found")
+	case errors.Is(err, ErrNotReady):
+		return errors.Conflict("Export is not ready")
+	case errors.Is(err, ErrBusy):
+		return errors.Wrap(err, "SERVICE_BUSY", "Too many exports and imports in progress, try again later", http.StatusServiceUnavailable)
+	default:
+		return errors.WrapInternal(err, "Export operation failed")
+	}
+}
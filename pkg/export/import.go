@@ -0,0 +1,321 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"flex-service/pkg/errors"
+	"flex-service/pkg/validator"
+)
+
+// Import defaults
+const (
+	// DefaultChunkSize is how many valid rows are applied at a time
+	DefaultChunkSize = 500
+	// MaxReportedErrors bounds the row errors kept in an import report
+	MaxReportedErrors = 1000
+)
+
+// RowError reports why a row was not imported
+type RowError struct {
+	Row    int                        `json:"row"`
+	Error  string                     `json:"error,omitempty"`
+	Errors validator.ValidationErrors `json:"errors,omitempty"`
+}
+
+// ImportReport is the outcome of an import. Rows are counted as they are read;
+// Imported and Failed add up to Total once the import is done.
+type ImportReport struct {
+	Total    int        `json:"total"`
+	Imported int        `json:"imported"`
+	Failed   int        `json:"failed"`
+	Errors   []RowError `json:"errors,omitempty"`
+	// Truncated is set when more than MaxReportedErrors rows failed
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+func (r *ImportReport) fail(rowError RowError) {
+	r.Failed++
+	if len(r.Errors) < MaxReportedErrors {
+		r.Errors = append(r.Errors, rowError)
+	} else {
+		r.Truncated = true
+	}
+}
+
+// Importer decodes, validates and applies the records of an import file.
+// Create it with NewImporter.
+type Importer struct {
+	decode func(rec Record) (interface{}, validator.ValidationErrors)
+	apply  func(ctx context.Context, userID int, rows []interface{}) error
+}
+
+// NewImporter creates an importer of rows of type T. Records are decoded into
+// T by json tag, with CSV and XLSX text converted to the field's type, then
+// validated with its validate tags. Valid rows are passed to apply in
+// chunks; a chunk that fails is reported row by row and the import goes on,
+// so apply should write a chunk in one transaction.
+//
+//	export.NewImporter(func(ctx context.Context, userID int, rows []*ProductRow) error {
+//		return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+//			return tx.CreateInBatches(toProducts(rows), len(rows)).Error
+//		})
+//	})
+func NewImporter[T any](apply func(ctx context.Context, userID int, rows []*T) error) *Importer {
+	kinds := fieldKinds(reflect.TypeOf((*T)(nil)).Elem())
+	return &Importer{
+		decode: func(rec Record) (interface{}, validator.ValidationErrors) {
+			row := new(T)
+			if errs := decodeRecord(rec, kinds, row); len(errs) > 0 {
+				return nil, errs
+			}
+			return row, nil
+		},
+		apply: func(ctx context.Context, userID int, rows []interface{}) error {
+			typed := make([]*T, len(rows))
+			for i, row := range rows {
+				typed[i] = row.(*T)
+			}
+			return apply(ctx, userID, typed)
+		},
+	}
+}
+
+// ImportOptions configure one run of an importer
+type ImportOptions struct {
+	UserID    int
+	ChunkSize int
+	// Columns map file headers to record keys: a column titled with its
+	// Header, in any case, is read as its Name, so exported files import back
+	Columns []Column
+	// Locale of the validation messages
+	Locale string
+	// Progress, if not nil, is called after every chunk
+	Progress func(report *ImportReport)
+}
+
+// Run imports every record of r. Invalid rows and failed chunks are listed in
+// the report; only a file that can't be read, or ctx ending, stops the import
+// with an error, leaving the chunks applied so far in place.
+func (i *Importer) Run(ctx context.Context, r Reader, opts ImportOptions) (*ImportReport, error) {
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultChunkSize
+	}
+
+	headers := make(map[string]string, len(opts.Columns))
+	for _, column := range opts.Columns {
+		headers[strings.ToLower(column.title())] = column.Name
+	}
+
+	report := &ImportReport{}
+	chunk := make([]interface{}, 0, opts.ChunkSize)
+	chunkRows := make([]int, 0, opts.ChunkSize)
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := i.apply(ctx, opts.UserID, chunk); err != nil {
+			message := "Failed to import row"
+			if appErr, ok := errors.AsAppError(err); ok {
+				message = appErr.Message
+			}
+			for _, row := range chunkRows {
+				report.fail(RowError{Row: row, Error: message})
+			}
+		} else {
+			report.Imported += len(chunk)
+		}
+		chunk, chunkRows = chunk[:0], chunkRows[:0]
+		if opts.Progress != nil {
+			opts.Progress(report)
+		}
+		return nil
+	}
+
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return report, err
+		}
+		report.Total++
+
+		row, errs := i.decode(renameColumns(rec, headers))
+		if len(errs) == 0 {
+			errs = validator.Validate(row, opts.Locale)
+		}
+		if len(errs) > 0 {
+			report.fail(RowError{Row: r.Row(), Errors: errs})
+			continue
+		}
+
+		chunk = append(chunk, row)
+		chunkRows = append(chunkRows, r.Row())
+		if len(chunk) == opts.ChunkSize {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return report, err
+	}
+	sort.SliceStable(report.Errors, func(a, b int) bool { return report.Errors[a].Row < report.Errors[b].Row })
+	return report, nil
+}
+
+// renameColumns keys the values of rec by column name instead of header
+func renameColumns(rec Record, headers map[string]string) Record {
+	if len(headers) == 0 {
+		return rec
+	}
+	renamed := make(Record, len(rec))
+	for key, value := range rec {
+		if name, ok := headers[strings.ToLower(key)]; ok {
+			key = name
+		}
+		renamed[key] = value
+	}
+	return renamed
+}
+
+// fieldKind is how a text value is converted for a field
+type fieldKind int
+
+const (
+	kindText fieldKind = iota
+	kindInt
+	kindUint
+	kindFloat
+	kindBool
+	kindTime
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// fieldKinds maps the lower-cased json names of a struct's fields to their
+// kind. Names match in any case, as they do for encoding/json.
+func fieldKinds(t reflect.Type) map[string]fieldKind {
+	kinds := make(map[string]fieldKind)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		name = strings.ToLower(name)
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		switch {
+		case fieldType == timeType:
+			kinds[name] = kindTime
+		case fieldType.Kind() >= reflect.Int && fieldType.Kind() <= reflect.Int64:
+			kinds[name] = kindInt
+		case fieldType.Kind() >= reflect.Uint && fieldType.Kind() <= reflect.Uint64:
+			kinds[name] = kindUint
+		case fieldType.Kind() == reflect.Float32 || fieldType.Kind() == reflect.Float64:
+			kinds[name] = kindFloat
+		case fieldType.Kind() == reflect.Bool:
+			kinds[name] = kindBool
+		case fieldType.Kind() == reflect.String:
+			kinds[name] = kindText
+		}
+	}
+	return kinds
+}
+
+// importTimeLayouts are the accepted time formats besides RFC 3339
+var importTimeLayouts = []string{"2006-01-02 15:04:05", "2006-01-02"}
+
+// decodeRecord converts the text values of rec to the kinds of the target's
+// fields and decodes it into target. Empty text leaves a field zero.
+func decodeRecord(rec Record, kinds map[string]fieldKind, target interface{}) validator.ValidationErrors {
+	var errs validator.ValidationErrors
+	values := make(map[string]interface{}, len(rec))
+	for name, value := range rec {
+		kind, known := kinds[strings.ToLower(name)]
+		if !known {
+			continue
+		}
+		// JSON numbers are parsed like text, so 1 and 0 are booleans too
+		if number, ok := value.(json.Number); ok {
+			value = number.String()
+		}
+		text, isText := value.(string)
+		if !isText {
+			values[name] = value
+			continue
+		}
+
+		text = strings.TrimSpace(text)
+		if text == "" && kind != kindText {
+			continue
+		}
+		var err error
+		switch kind {
+		case kindText:
+			values[name] = text
+		case kindInt:
+			values[name], err = strconv.ParseInt(text, 10, 64)
+		case kindUint:
+			values[name], err = strconv.ParseUint(text, 10, 64)
+		case kindFloat:
+			values[name], err = strconv.ParseFloat(text, 64)
+		case kindBool:
+			values[name], err = strconv.ParseBool(text)
+		case kindTime:
+			values[name], err = parseTime(text)
+		}
+		if err != nil {
+			errs = append(errs, validator.FieldError{Field: name, Rule: "type", Message: name + " has an invalid value"})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	data, err := json.Marshal(values)
+	if err == nil {
+		err = json.Unmarshal(data, target)
+	}
+	if err != nil {
+		field := ""
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			field = typeErr.Field
+		}
+		return validator.ValidationErrors{{Field: field, Rule: "type", Message: "Row has an invalid value: " + err.Error()}}
+	}
+	return nil
+}
+
+func parseTime(text string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, text); err == nil {
+		return t, nil
+	}
+	var err error
+	for _, layout := range importTimeLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, text); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
@@ -0,0 +1,33 @@
+package export
+
+import (
+	"context"
+
+	"flex-service/pkg/queue"
+)
+
+// jobPayload references a job record; the record itself stays in the cache
+func jobPayload(id string) map[string]interface{} {
+	return map[string]interface{}{"job_id": id}
+}
+
+// JobHandler runs exports and imports queued by a manager with WithQueue:
+//
+//	worker.RegisterHandler(queue.JobTypeDataExport, export.JobHandler(container.Export))
+//	worker.RegisterHandler(queue.JobTypeDataImport, export.JobHandler(container.Export))
+//
+// A failed export or import is recorded on its job, so the queue job only
+// fails when the job record can't be read or written.
+func JobHandler(m *Manager) queue.Handler {
+	return queue.HandlerFunc(func(ctx context.Context, job *queue.Job) *queue.JobResult {
+		id, _ := job.Payload["job_id"].(string)
+		if id == "" {
+			return &queue.JobResult{Success: false, Error: ErrJobNotFound.Error()}
+		}
+
+		if err := m.Process(ctx, id); err != nil {
+			return &queue.JobResult{Success: false, Error: err.Error()}
+		}
+		return &queue.JobResult{Success: true, Data: map[string]interface{}{"job_id": id}}
+	})
+}
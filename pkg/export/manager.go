@@ -0,0 +1,581 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"flex-service/pkg/cache"
+	appErrors "flex-service/pkg/errors"
+	"flex-service/pkg/i18n"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/queue"
+	"flex-service/pkg/storage"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// jobKeyPrefix prefixes the cache keys of job records
+const jobKeyPrefix = "export:job:"
+
+// pendingSize bounds the jobs waiting for an in-process worker
+const pendingSize = 100
+
+// Config configures exports and imports
+type Config struct {
+	ChunkSize     int           // Rows applied per import chunk (default 500)
+	MaxImportSize int64         // Largest accepted import file in bytes (default 20MB)
+	JobTTL        time.Duration // How long job status is kept after the last update (default 24h)
+	URLTTL        time.Duration // How long a download URL stays valid (default 15m)
+	Workers       int           // In-process workers when there is no queue (default 2)
+}
+
+// Dataset is something users can export or import, e.g. "products". Register
+// datasets on the Manager; requests for an unknown dataset are rejected.
+type Dataset struct {
+	Name string
+	// Permission, if set, is required to export or import the dataset
+	Permission string
+	// Columns are the exported columns, in file order
+	Columns []Column
+	// Query returns the rows the user exports, filtered by the request params.
+	// Nil disables exports.
+	Query func(ctx context.Context, db *gorm.DB, userID int, params map[string]string) *gorm.DB
+	// Import applies imported rows. Nil disables imports.
+	Import *Importer
+}
+
+// JobKind tells exports and imports apart
+type JobKind string
+
+// Job kinds
+const (
+	KindExport JobKind = "export"
+	KindImport JobKind = "import"
+)
+
+// JobStatus is the state of a job
+type JobStatus string
+
+// Job statuses
+const (
+	StatusPending   JobStatus = "pending"
+	StatusRunning   JobStatus = "running"
+	StatusCompleted JobStatus = "completed"
+	StatusFailed    JobStatus = "failed"
+)
+
+// Job is an export or import and its progress
+type Job struct {
+	ID      string    `json:"id"`
+	Kind    JobKind   `json:"kind"`
+	Dataset string    `json:"dataset"`
+	Format  Format    `json:"format"`
+	Status  JobStatus `json:"status"`
+	// Processed is how many rows were written or read so far
+	Processed int64  `json:"processed"`
+	FileName  string `json:"file_name,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// Report lists the imported and failed rows of an import
+	Report      *ImportReport `json:"report,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	CompletedAt *time.Time    `json:"completed_at,omitempty"`
+}
+
+// Done reports whether the job completed or failed
+func (j *Job) Done() bool {
+	return j.Status == StatusCompleted || j.Status == StatusFailed
+}
+
+// Download is a temporary link to a finished export
+type Download struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+	FileName  string    `json:"file_name"`
+}
+
+// jobRecord is what is kept of a job: the job and what is needed to run it
+type jobRecord struct {
+	Job
+	UserID int               `json:"user_id"`
+	Params map[string]string `json:"params,omitempty"`
+	Locale string            `json:"locale,omitempty"`
+	// Path is the stored export file, or the uploaded import file
+	Path string `json:"path,omitempty"`
+
+	expires time.Time // of records kept in memory
+}
+
+// Manager runs exports and imports of registered datasets. Job status is
+// kept in the cache, so any instance can answer for it; files go to storage.
+type Manager struct {
+	db         *gorm.DB
+	cache      cache.Cache
+	files      storage.Filesystem
+	dispatcher *queue.JobDispatcher
+	config     Config
+
+	mu       sync.RWMutex
+	datasets map[string]*Dataset
+	// jobs holds job records when there is no cache
+	jobs map[string]*jobRecord
+
+	// pending feeds Run when there is no queue
+	pending chan string
+}
+
+// NewManager creates a manager. Without WithQueue, jobs run in the workers
+// started by Run. Without a cache, job status is kept in memory and only this
+// instance can report it.
+func NewManager(db *gorm.DB, c cache.Cache, files storage.Filesystem, cfg Config) *Manager {
+	if cfg.ChunkSize <= 0 {
+		cfg.ChunkSize = DefaultChunkSize
+	}
+	if cfg.MaxImportSize <= 0 {
+		cfg.MaxImportSize = 20 << 20
+	}
+	if cfg.JobTTL <= 0 {
+		cfg.JobTTL = 24 * time.Hour
+	}
+	if cfg.URLTTL <= 0 {
+		cfg.URLTTL = 15 * time.Minute
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 2
+	}
+
+	return &Manager{
+		db:       db,
+		cache:    c,
+		files:    files,
+		config:   cfg,
+		datasets: make(map[string]*Dataset),
+		jobs:     make(map[string]*jobRecord),
+		pending:  make(chan string, pendingSize),
+	}
+}
+
+// WithQueue runs jobs on queue workers, registered with JobHandler. Workers
+// in another process need the same cache to see the jobs.
+func (m *Manager) WithQueue(dispatcher *queue.JobDispatcher) *Manager {
+	m.dispatcher = dispatcher
+	return m
+}
+
+// Config returns the manager's configuration, with defaults applied
+func (m *Manager) Config() Config {
+	return m.config
+}
+
+// Register adds a dataset; registering a name again replaces it
+func (m *Manager) Register(dataset *Dataset) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.datasets[dataset.Name] = dataset
+}
+
+// Dataset returns the registered dataset named name
+func (m *Manager) Dataset(name string) (*Dataset, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	dataset, ok := m.datasets[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownDataset, name)
+	}
+	return dataset, nil
+}
+
+// RequestExport queues an export of dataset for the user. params are passed
+// to the dataset's Query.
+func (m *Manager) RequestExport(ctx context.Context, userID int, datasetName string, format Format, params map[string]string) (*Job, error) {
+	dataset, err := m.Dataset(datasetName)
+	if err != nil {
+		return nil, err
+	}
+	if dataset.Query == nil {
+		return nil, fmt.Errorf("%w: %q can't be exported", ErrUnknownDataset, datasetName)
+	}
+	if _, err := ParseFormat(string(format)); err != nil {
+		return nil, err
+	}
+
+	rec := m.newRecord(KindExport, userID, dataset.Name, format)
+	rec.Params = params
+	if err := m.enqueue(ctx, rec, nil); err != nil {
+		return nil, err
+	}
+	return &rec.Job, nil
+}
+
+// RequestImport queues an import of the file at uploadPath into dataset for
+// the user. The file is deleted from storage once the import is done.
+// Validation messages use the locale of ctx.
+func (m *Manager) RequestImport(ctx context.Context, userID int, datasetName string, format Format, uploadPath, fileName string) (*Job, error) {
+	dataset, err := m.Dataset(datasetName)
+	if err != nil {
+		return nil, err
+	}
+	if dataset.Import == nil {
+		return nil, fmt.Errorf("%w: %q can't be imported", ErrUnknownDataset, datasetName)
+	}
+	if _, err := ParseFormat(string(format)); err != nil {
+		return nil, err
+	}
+
+	rec := m.newRecord(KindImport, userID, dataset.Name, format)
+	rec.Path = uploadPath
+	rec.FileName = fileName
+	rec.Locale = i18n.FromContext(ctx)
+	// A retried import would apply its chunks again
+	if err := m.enqueue(ctx, rec, &queue.JobOptions{MaxAttempts: 1}); err != nil {
+		return nil, err
+	}
+	return &rec.Job, nil
+}
+
+// Get returns a job of the user
+func (m *Manager) Get(ctx context.Context, userID int, kind JobKind, id string) (*Job, error) {
+	rec, err := m.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rec.UserID != userID || rec.Kind != kind {
+		return nil, ErrJobNotFound
+	}
+	return &rec.Job, nil
+}
+
+// Download returns a temporary URL of a completed export of the user
+func (m *Manager) Download(ctx context.Context, userID int, id string) (*Download, error) {
+	rec, err := m.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rec.UserID != userID || rec.Kind != KindExport {
+		return nil, ErrJobNotFound
+	}
+	if rec.Status != StatusCompleted {
+		return nil, ErrNotReady
+	}
+
+	url, err := m.files.TemporaryURL(ctx, rec.Path, m.config.URLTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create download URL: %w", err)
+	}
+	return &Download{
+		URL:       url,
+		ExpiresAt: time.Now().Add(m.config.URLTTL),
+		FileName:  rec.FileName,
+	}, nil
+}
+
+// Run starts Config.Workers workers that run jobs requested on this instance
+// when there is no queue, until ctx is done
+func (m *Manager) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < m.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-m.pending:
+					if err := m.Process(ctx, id); err != nil {
+						logger.Warn("Failed to process export job", zap.String("job_id", id), zap.Error(err))
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Process runs a pending job. Jobs that are already done are skipped. The
+// outcome is recorded on the job; the error is only for failures to read or
+// save it.
+func (m *Manager) Process(ctx context.Context, id string) error {
+	rec, err := m.load(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rec.Done() {
+		return nil
+	}
+
+	rec.Status = StatusRunning
+	if err := m.save(ctx, rec); err != nil {
+		return err
+	}
+
+	started := time.Now()
+	if rec.Kind == KindImport {
+		err = m.runImport(ctx, rec)
+	} else {
+		err = m.runExport(ctx, rec)
+	}
+
+	now := time.Now()
+	rec.CompletedAt = &now
+	if err != nil {
+		rec.Status = StatusFailed
+		rec.Error = failureMessage(rec.Kind, err)
+		logger.Error("Export job failed",
+			zap.String("job_id", rec.ID),
+			zap.String("kind", string(rec.Kind)),
+			zap.String("dataset", rec.Dataset),
+			zap.Error(err))
+	} else {
+		rec.Status = StatusCompleted
+		logger.Info("Export job completed",
+			zap.String("job_id", rec.ID),
+			zap.String("kind", string(rec.Kind)),
+			zap.String("dataset", rec.Dataset),
+			zap.Int64("rows", rec.Processed),
+			zap.Duration("duration", time.Since(started)))
+	}
+	// Record the outcome even when shutdown interrupted the job
+	return m.save(context.WithoutCancel(ctx), rec)
+}
+
+// runExport streams the dataset into a temporary file, then stores it under
+// exports/<id>/
+func (m *Manager) runExport(ctx context.Context, rec *jobRecord) error {
+	dataset, err := m.Dataset(rec.Dataset)
+	if err != nil {
+		return err
+	}
+	if dataset.Query == nil {
+		return fmt.Errorf("%w: %q can't be exported", ErrUnknownDataset, rec.Dataset)
+	}
+
+	tmp, err := os.CreateTemp("", "export-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	w, err := NewWriter(rec.Format, tmp, dataset.Columns)
+	if err != nil {
+		return err
+	}
+	save := m.progress(ctx, rec)
+	written, err := Stream(ctx, dataset.Query(ctx, m.db.WithContext(ctx), rec.UserID, rec.Params), dataset.Columns, w, func(rows int64) {
+		rec.Processed = rows
+		save()
+	})
+	if closeErr := w.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write file: %w", closeErr)
+	}
+	if err != nil {
+		return err
+	}
+	rec.Processed = written
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind export: %w", err)
+	}
+	rec.Path = path.Join("exports", rec.ID, dataset.Name+rec.Format.Extension())
+	if err := m.files.Put(ctx, rec.Path, tmp, &storage.PutOptions{ContentType: rec.Format.ContentType()}); err != nil {
+		return fmt.Errorf("failed to store export: %w", err)
+	}
+	rec.FileName = dataset.Name + "-" + rec.CreatedAt.Format("20060102-150405") + rec.Format.Extension()
+	return nil
+}
+
+// runImport reads the uploaded file into the dataset, then deletes the file
+func (m *Manager) runImport(ctx context.Context, rec *jobRecord) error {
+	defer func() {
+		if err := m.files.Delete(context.WithoutCancel(ctx), rec.Path); err != nil {
+			logger.Warn("Failed to delete import file", zap.String("path", rec.Path), zap.Error(err))
+		}
+	}()
+
+	dataset, err := m.Dataset(rec.Dataset)
+	if err != nil {
+		return err
+	}
+	if dataset.Import == nil {
+		return fmt.Errorf("%w: %q can't be imported", ErrUnknownDataset, rec.Dataset)
+	}
+
+	file, err := m.files.Get(ctx, rec.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer file.Close()
+
+	reader, err := NewReader(rec.Format, file)
+	if err != nil {
+		return invalidFile(err)
+	}
+	defer reader.Close()
+
+	save := m.progress(ctx, rec)
+	report, err := dataset.Import.Run(ctx, reader, ImportOptions{
+		UserID:    rec.UserID,
+		ChunkSize: m.config.ChunkSize,
+		Columns:   dataset.Columns,
+		Locale:    rec.Locale,
+		Progress: func(report *ImportReport) {
+			rec.Report = report
+			rec.Processed = int64(report.Total)
+			save()
+		},
+	})
+	rec.Report = report
+	rec.Processed = int64(report.Total)
+	if err != nil && ctx.Err() == nil {
+		return invalidFile(err)
+	}
+	return err
+}
+
+// progress returns a function that saves a running job at most once a second
+func (m *Manager) progress(ctx context.Context, rec *jobRecord) func() {
+	var saved time.Time
+	return func() {
+		if time.Since(saved) < time.Second {
+			return
+		}
+		saved = time.Now()
+		if err := m.save(ctx, rec); err != nil {
+			logger.Warn("Failed to save export job progress", zap.String("job_id", rec.ID), zap.Error(err))
+		}
+	}
+}
+
+func (m *Manager) newRecord(kind JobKind, userID int, dataset string, format Format) *jobRecord {
+	return &jobRecord{
+		Job: Job{
+			ID:        uuid.NewString(),
+			Kind:      kind,
+			Dataset:   dataset,
+			Format:    format,
+			Status:    StatusPending,
+			CreatedAt: time.Now(),
+		},
+		UserID: userID,
+	}
+}
+
+// enqueue saves a new job and hands it to the queue or the local workers
+func (m *Manager) enqueue(ctx context.Context, rec *jobRecord, options *queue.JobOptions) error {
+	if err := m.save(ctx, rec); err != nil {
+		return err
+	}
+
+	if m.dispatcher != nil {
+		jobType := queue.JobTypeDataExport
+		if rec.Kind == KindImport {
+			jobType = queue.JobTypeDataImport
+		}
+		var opts []*queue.JobOptions
+		if options != nil {
+			opts = append(opts, options)
+		}
+		if err := m.dispatcher.Dispatch(jobType, jobPayload(rec.ID), opts...); err != nil {
+			m.discard(ctx, rec)
+			return fmt.Errorf("failed to queue %s: %w", rec.Kind, err)
+		}
+		return nil
+	}
+
+	select {
+	case m.pending <- rec.ID:
+		return nil
+	default:
+		m.discard(ctx, rec)
+		return ErrBusy
+	}
+}
+
+// discard deletes a job that couldn't be queued, with its uploaded file
+func (m *Manager) discard(ctx context.Context, rec *jobRecord) {
+	if rec.Kind == KindImport && rec.Path != "" {
+		if err := m.files.Delete(ctx, rec.Path); err != nil {
+			logger.Warn("Failed to delete import file", zap.String("path", rec.Path), zap.Error(err))
+		}
+	}
+	if m.cache != nil {
+		if err := m.cache.Del(ctx, jobKeyPrefix+rec.ID); err != nil {
+			logger.Warn("Failed to delete export job", zap.String("job_id", rec.ID), zap.Error(err))
+		}
+		return
+	}
+	m.mu.Lock()
+	delete(m.jobs, rec.ID)
+	m.mu.Unlock()
+}
+
+func (m *Manager) save(ctx context.Context, rec *jobRecord) error {
+	if m.cache != nil {
+		if err := m.cache.SetJSON(ctx, jobKeyPrefix+rec.ID, rec, m.config.JobTTL); err != nil {
+			return fmt.Errorf("failed to save job: %w", err)
+		}
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for id, kept := range m.jobs {
+		if now.After(kept.expires) {
+			delete(m.jobs, id)
+		}
+	}
+	saved := *rec
+	saved.expires = now.Add(m.config.JobTTL)
+	m.jobs[rec.ID] = &saved
+	return nil
+}
+
+func (m *Manager) load(ctx context.Context, id string) (*jobRecord, error) {
+	if m.cache != nil {
+		var rec jobRecord
+		if err := m.cache.GetJSON(ctx, jobKeyPrefix+id, &rec); err != nil {
+			if errors.Is(err, cache.ErrCacheMiss) {
+				return nil, ErrJobNotFound
+			}
+			return nil, fmt.Errorf("failed to load job: %w", err)
+		}
+		return &rec, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	kept, ok := m.jobs[id]
+	if !ok || time.Now().After(kept.expires) {
+		return nil, ErrJobNotFound
+	}
+	rec := *kept
+	return &rec, nil
+}
+
+// invalidFile reports an import file that can't be read as the user's mistake
+func invalidFile(err error) error {
+	return appErrors.WrapBadRequest(err, "Invalid import file: "+err.Error())
+}
+
+// failureMessage is what the user sees of a failed job: the message of an
+// AppError, which datasets return for expected failures, and a generic
+// message otherwise, which may hold internal details
+func failureMessage(kind JobKind, err error) string {
+	if appErr, ok := appErrors.AsAppError(err); ok {
+		return appErr.Message
+	}
+	if kind == KindImport {
+		return "Import failed"
+	}
+	return "Export failed"
+}
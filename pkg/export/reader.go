@@ -0,0 +1,216 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// maxXLSXUnzipSize bounds the uncompressed size of an imported workbook, so
+// a small zip bomb can't exhaust memory or disk
+const maxXLSXUnzipSize = 512 << 20
+
+// Record is an imported row keyed by column header. CSV and XLSX values are
+// strings; JSON values keep their JSON type.
+type Record map[string]interface{}
+
+// Reader reads the records of an import file
+type Reader interface {
+	// Read returns the next record, or io.EOF after the last. Blank rows are
+	// skipped.
+	Read() (Record, error)
+	// Row is the position of the last record in the file: the spreadsheet row
+	// for CSV and XLSX, where the header is row 1, and the 1-based array
+	// index for JSON
+	Row() int
+	Close() error
+}
+
+// NewReader returns a reader of format from r. CSV and XLSX files need a
+// header row naming the columns; XLSX files are read from their first sheet.
+// JSON files are an array of objects.
+func NewReader(format Format, r io.Reader) (Reader, error) {
+	switch format {
+	case CSV:
+		return newCSVReader(r)
+	case XLSX:
+		return newXLSXReader(r)
+	case JSON:
+		return newJSONReader(r)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+}
+
+// headerNames trims the header cells, dropping a UTF-8 byte order mark
+func headerNames(cells []string) []string {
+	names := make([]string, len(cells))
+	for i, cell := range cells {
+		if i == 0 {
+			cell = strings.TrimPrefix(cell, "\ufeff")
+		}
+		names[i] = strings.TrimSpace(cell)
+	}
+	return names
+}
+
+// record keys cells by header, reporting false for a blank row
+func record(header, cells []string) (Record, bool) {
+	rec := make(Record, len(header))
+	blank := true
+	for i, name := range header {
+		if name == "" {
+			continue
+		}
+		value := ""
+		if i < len(cells) {
+			value = cells[i]
+		}
+		if strings.TrimSpace(value) != "" {
+			blank = false
+		}
+		rec[name] = value
+	}
+	return rec, !blank
+}
+
+type csvReader struct {
+	r      *csv.Reader
+	header []string
+	row    int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("file has no header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	return &csvReader{r: reader, header: headerNames(header), row: 1}, nil
+}
+
+func (c *csvReader) Read() (Record, error) {
+	for {
+		cells, err := c.r.Read()
+		if err != nil {
+			return nil, err
+		}
+		c.row, _ = c.r.FieldPos(0)
+		for i, cell := range cells {
+			cells[i] = unescapeFormula(cell)
+		}
+		if rec, ok := record(c.header, cells); ok {
+			return rec, nil
+		}
+	}
+}
+
+// unescapeFormula drops the quote escapeFormula adds, so exported CSV files
+// import back unchanged
+func unescapeFormula(s string) string {
+	if len(s) > 1 && s[0] == '\'' && escapeFormula(s[1:]) == s {
+		return s[1:]
+	}
+	return s
+}
+
+func (c *csvReader) Row() int     { return c.row }
+func (c *csvReader) Close() error { return nil }
+
+type xlsxReader struct {
+	file   *excelize.File
+	rows   *excelize.Rows
+	header []string
+	row    int
+}
+
+func newXLSXReader(r io.Reader) (*xlsxReader, error) {
+	file, err := excelize.OpenReader(r, excelize.Options{UnzipSizeLimit: maxXLSXUnzipSize})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open workbook: %w", err)
+	}
+	rows, err := file.Rows(file.GetSheetName(0))
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read sheet: %w", err)
+	}
+
+	reader := &xlsxReader{file: file, rows: rows}
+	if !rows.Next() {
+		reader.Close()
+		return nil, fmt.Errorf("file has no header row")
+	}
+	header, err := rows.Columns()
+	if err != nil {
+		reader.Close()
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	reader.header = headerNames(header)
+	reader.row = 1
+	return reader, nil
+}
+
+func (x *xlsxReader) Read() (Record, error) {
+	for x.rows.Next() {
+		x.row++
+		cells, err := x.rows.Columns()
+		if err != nil {
+			return nil, err
+		}
+		if rec, ok := record(x.header, cells); ok {
+			return rec, nil
+		}
+	}
+	if err := x.rows.Error(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (x *xlsxReader) Row() int { return x.row }
+
+func (x *xlsxReader) Close() error {
+	x.rows.Close()
+	return x.file.Close()
+}
+
+type jsonReader struct {
+	decoder *json.Decoder
+	row     int
+}
+
+func newJSONReader(r io.Reader) (*jsonReader, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("JSON import must be an array of objects")
+	}
+	return &jsonReader{decoder: decoder}, nil
+}
+
+func (j *jsonReader) Read() (Record, error) {
+	if !j.decoder.More() {
+		return nil, io.EOF
+	}
+	j.row++
+	var rec Record
+	if err := j.decoder.Decode(&rec); err != nil {
+		return nil, fmt.Errorf("item %d is not an object: %w", j.row, err)
+	}
+	return rec, nil
+}
+
+func (j *jsonReader) Row() int     { return j.row }
+func (j *jsonReader) Close() error { return nil }
@@ -0,0 +1,23 @@
+package export
+
+import (
+	"net/http"
+
+	"flex-service/pkg/routes"
+)
+
+// Manifest declares the export and import routes. Jobs belong to the
+// authenticated user; requesting one is rate limited since each may read or
+// write a whole table.
+var Manifest = routes.Manifest{
+	Module:     "export",
+	Prefix:     "/data",
+	Middleware: []string{"auth"},
+	Routes: []routes.Route{
+		{Method: http.MethodPost, Path: "/exports", Handler: "RequestExport", Middleware: []string{"rate.user:10,1m"}, Summary: "Request an export of a dataset"},
+		{Method: http.MethodGet, Path: "/exports/:id", Handler: "ShowExport", Summary: "Get an export and its progress"},
+		{Method: http.MethodGet, Path: "/exports/:id/download", Handler: "DownloadExport", Summary: "Get a temporary download URL of an export"},
+		{Method: http.MethodPost, Path: "/imports", Handler: "RequestImport", Middleware: []string{"rate.user:10,1m"}, Summary: "Upload a file to import into a dataset"},
+		{Method: http.MethodGet, Path: "/imports/:id", Handler: "ShowImport", Summary: "Get an import, its progress and failed rows"},
+	},
+}
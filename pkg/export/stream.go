@@ -0,0 +1,69 @@
+package export
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// progressEvery is how many rows Stream writes between progress calls
+const progressEvery = 1000
+
+// Stream writes the rows of query to w and returns how many it wrote. Rows
+// are read one at a time from the database cursor, so exports of any size
+// use constant memory. Columns are looked up by name in the query result,
+// which may have others. progress, if not nil, is called with the rows
+// written so far every 1000 rows.
+func Stream(ctx context.Context, query *gorm.DB, columns []Column, w Writer, progress func(rows int64)) (int64, error) {
+	rows, err := query.WithContext(ctx).Rows()
+	if err != nil {
+		return 0, fmt.Errorf("failed to query rows: %w", err)
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		index[name] = i
+	}
+	positions := make([]int, len(columns))
+	for i, column := range columns {
+		position, ok := index[column.Name]
+		if !ok {
+			return 0, fmt.Errorf("%w %s", ErrMissingColumn, column.Name)
+		}
+		positions[i] = position
+	}
+
+	scanned := make([]interface{}, len(names))
+	targets := make([]interface{}, len(names))
+	for i := range scanned {
+		targets[i] = &scanned[i]
+	}
+	values := make([]interface{}, len(columns))
+
+	var written int64
+	for rows.Next() {
+		if err := rows.Scan(targets...); err != nil {
+			return written, fmt.Errorf("failed to read row: %w", err)
+		}
+		for i, position := range positions {
+			values[i] = scanned[position]
+		}
+		if err := w.WriteRow(values); err != nil {
+			return written, fmt.Errorf("failed to write row: %w", err)
+		}
+		written++
+		if progress != nil && written%progressEvery == 0 {
+			progress(written)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return written, fmt.Errorf("failed to read rows: %w", err)
+	}
+	return written, nil
+}
@@ -0,0 +1,239 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Column is an exported column: Name is the query result column (or record
+// key) and Header the column title in the file, Name when empty
+type Column struct {
+	Name   string `json:"name"`
+	Header string `json:"header,omitempty"`
+}
+
+func (c Column) title() string {
+	if c.Header != "" {
+		return c.Header
+	}
+	return c.Name
+}
+
+// Writer writes rows to a file. Each row has one value per column, in column
+// order. Close writes what is buffered; the file is incomplete without it.
+type Writer interface {
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// NewWriter returns a streaming writer of format to w. CSV and XLSX files
+// start with a header row of the column titles; JSON files are an array of
+// objects keyed by column name.
+func NewWriter(format Format, w io.Writer, columns []Column) (Writer, error) {
+	switch format {
+	case CSV:
+		return newCSVWriter(w, columns)
+	case XLSX:
+		return newXLSXWriter(w, columns)
+	case JSON:
+		return newJSONWriter(w, columns), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+}
+
+// csvWriter writes RFC 4180 CSV with a header row
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer, columns []Column) (*csvWriter, error) {
+	writer := &csvWriter{w: csv.NewWriter(w), record: make([]string, len(columns))}
+	for i, column := range columns {
+		writer.record[i] = column.title()
+	}
+	return writer, writer.w.Write(writer.record)
+}
+
+func (c *csvWriter) WriteRow(values []interface{}) error {
+	for i, value := range values {
+		c.record[i] = escapeFormula(formatValue(value))
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// xlsxDateFormat is the number format of time cells
+var xlsxDateFormat = "yyyy-mm-dd hh:mm:ss"
+
+// xlsxWriter streams rows into the first sheet of a workbook. The workbook
+// is only written to w on Close, since XLSX is a zip archive.
+type xlsxWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+	values []interface{}
+	// dateStyle shows times as text that imports read back
+	dateStyle int
+}
+
+func newXLSXWriter(w io.Writer, columns []Column) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter("Sheet1")
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	bold, err := file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	dateStyle, err := file.NewStyle(&excelize.Style{CustomNumFmt: &xlsxDateFormat})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = column.title()
+	}
+	if err := stream.SetRow("A1", header, excelize.RowOpts{StyleID: bold}); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &xlsxWriter{w: w, file: file, stream: stream, row: 1, values: make([]interface{}, len(columns)), dateStyle: dateStyle}, nil
+}
+
+func (x *xlsxWriter) WriteRow(values []interface{}) error {
+	if x.row >= excelize.TotalRows {
+		return ErrTooManyRows
+	}
+	x.row++
+	for i, value := range values {
+		// Strings are stored as text, never as formulas
+		switch v := value.(type) {
+		case []byte:
+			x.values[i] = string(v)
+		case time.Time:
+			x.values[i] = excelize.Cell{StyleID: x.dateStyle, Value: v.UTC()}
+		default:
+			x.values[i] = v
+		}
+	}
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.stream.SetRow(cell, x.values)
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	return x.file.Write(x.w)
+}
+
+// jsonWriter writes an array of objects with keys in column order
+type jsonWriter struct {
+	w    *bufio.Writer
+	keys [][]byte
+	rows int
+}
+
+func newJSONWriter(w io.Writer, columns []Column) *jsonWriter {
+	writer := &jsonWriter{w: bufio.NewWriter(w), keys: make([][]byte, len(columns))}
+	for i, column := range columns {
+		writer.keys[i], _ = json.Marshal(column.Name)
+	}
+	return writer
+}
+
+func (j *jsonWriter) WriteRow(values []interface{}) error {
+	separator := byte(',')
+	if j.rows == 0 {
+		separator = '['
+	}
+	j.rows++
+	j.w.WriteByte(separator)
+	j.w.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			j.w.WriteByte(',')
+		}
+		j.w.Write(j.keys[i])
+		j.w.WriteByte(':')
+		if b, ok := value.([]byte); ok {
+			value = string(b)
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", j.keys[i], err)
+		}
+		j.w.Write(encoded)
+	}
+	_, err := j.w.WriteString("}\n")
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	if j.rows == 0 {
+		j.w.WriteByte('[')
+	}
+	j.w.WriteString("]\n")
+	return j.w.Flush()
+}
+
+// formatValue formats a query result value as text
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// escapeFormula prefixes text that spreadsheets would run as a formula with
+// a quote, so an exported value like =HYPERLINK(...) stays text. Numbers,
+// including negative ones, are kept.
+func escapeFormula(s string) string {
+	if s == "" {
+		return s
+	}
+	switch s[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return s
+		}
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,9 @@
+package feature
+
+import "errors"
+
+// Feature flag errors
+var (
+	ErrFlagNotFound = errors.New("feature flag not found")
+	ErrInvalidFlag  = errors.New("invalid feature flag")
+)
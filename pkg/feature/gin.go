@@ -0,0 +1,36 @@
+package feature
+
+import (
+	"net/http"
+
+	"flex-service/pkg/requestctx"
+	"flex-service/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SubjectFromContext builds a subject from values set by the authentication middleware
+func SubjectFromContext(c *gin.Context) Subject {
+	ctx := c.Request.Context()
+
+	subject := Subject{}
+	if user, exists := requestctx.UserFrom(ctx); exists {
+		subject.UserID = user.Subject
+	}
+	if tenantID, exists := requestctx.TenantID(ctx); exists {
+		subject.TenantID = tenantID
+	}
+	return subject
+}
+
+// Require returns middleware that responds 404 when the flag is off for the current subject
+func (m *Manager) Require(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.IsEnabled(c.Request.Context(), key, SubjectFromContext(c)) {
+			response.Error(c, http.StatusNotFound, "NOT_FOUND", "Route not found", nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,68 @@
+package feature
+
+import (
+	"context"
+	"time"
+)
+
+// HashBy selects which subject attribute percentage rollouts hash on
+type HashBy string
+
+const (
+	HashByUser   HashBy = "user"
+	HashByTenant HashBy = "tenant"
+)
+
+// Flag defines a feature flag and its rollout rules
+type Flag struct {
+	Key         string     `json:"key"`
+	Description string     `json:"description,omitempty"`
+	Enabled     bool       `json:"enabled"`             // Master switch; false disables the flag for everyone
+	Percentage  int        `json:"percentage"`          // 0-100 share of subjects that get the flag
+	HashBy      HashBy     `json:"hash_by,omitempty"`   // user (default) or tenant
+	Allow       []string   `json:"allow,omitempty"`     // User/tenant IDs that always get the flag
+	Deny        []string   `json:"deny,omitempty"`      // User/tenant IDs that never get the flag (wins over allow)
+	StartsAt    *time.Time `json:"starts_at,omitempty"` // Activation window start (inclusive)
+	EndsAt      *time.Time `json:"ends_at,omitempty"`   // Activation window end (exclusive)
+}
+
+// Subject is who a flag is evaluated for
+type Subject struct {
+	UserID   string
+	TenantID string
+}
+
+// Reason explains an evaluation result
+type Reason string
+
+const (
+	ReasonNotFound   Reason = "not_found"
+	ReasonDisabled   Reason = "disabled"
+	ReasonNotStarted Reason = "not_started"
+	ReasonEnded      Reason = "ended"
+	ReasonDenied     Reason = "denied"
+	ReasonAllowed    Reason = "allowed"
+	ReasonRollout    Reason = "rollout"
+	ReasonExcluded   Reason = "rollout_excluded"
+)
+
+// Evaluation is the result of evaluating a flag for a subject
+type Evaluation struct {
+	Key     string  `json:"key"`
+	Enabled bool    `json:"enabled"`
+	Reason  Reason  `json:"reason"`
+	Bucket  int     `json:"bucket"` // 0-99 rollout bucket, -1 when not hashed
+	Subject Subject `json:"-"`
+}
+
+// Store provides flag definitions
+type Store interface {
+	Get(ctx context.Context, key string) (*Flag, error)
+	All(ctx context.Context) ([]*Flag, error)
+}
+
+// ExposureRecorder receives an event every time a flag is evaluated,
+// so rollouts can be measured
+type ExposureRecorder interface {
+	RecordExposure(ctx context.Context, evaluation *Evaluation)
+}
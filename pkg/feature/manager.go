@@ -0,0 +1,135 @@
+package feature
+
+import (
+	"context"
+	"hash/fnv"
+	"time"
+
+	"flex-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// Manager evaluates flags against subjects
+type Manager struct {
+	store    Store
+	recorder ExposureRecorder
+	now      func() time.Time
+}
+
+// NewManager creates a flag manager. recorder may be nil to disable exposure events.
+func NewManager(store Store, recorder ExposureRecorder) *Manager {
+	return &Manager{
+		store:    store,
+		recorder: recorder,
+		now:      time.Now,
+	}
+}
+
+// IsEnabled reports whether the flag is on for the subject
+func (m *Manager) IsEnabled(ctx context.Context, key string, subject Subject) bool {
+	return m.Evaluate(ctx, key, subject).Enabled
+}
+
+// Evaluate evaluates the flag for the subject and records an exposure event.
+// Rules are applied in order: master switch, activation window, deny list, allow list, percentage.
+func (m *Manager) Evaluate(ctx context.Context, key string, subject Subject) *Evaluation {
+	evaluation := &Evaluation{Key: key, Bucket: -1, Subject: subject}
+
+	flag, err := m.store.Get(ctx, key)
+	if err != nil {
+		if err != ErrFlagNotFound {
+			logger.Warn("Failed to load feature flag", zap.String("flag", key), zap.Error(err))
+		}
+		evaluation.Reason = ReasonNotFound
+		m.record(ctx, evaluation)
+		return evaluation
+	}
+
+	evaluation.Enabled, evaluation.Reason, evaluation.Bucket = evaluate(flag, subject, m.now())
+	m.record(ctx, evaluation)
+	return evaluation
+}
+
+// EvaluateAll evaluates every flag for the subject, e.g. to send to a frontend
+func (m *Manager) EvaluateAll(ctx context.Context, subject Subject) (map[string]bool, error) {
+	flags, err := m.store.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	result := make(map[string]bool, len(flags))
+	for _, flag := range flags {
+		evaluation := &Evaluation{Key: flag.Key, Subject: subject}
+		evaluation.Enabled, evaluation.Reason, evaluation.Bucket = evaluate(flag, subject, now)
+		m.record(ctx, evaluation)
+		result[flag.Key] = evaluation.Enabled
+	}
+	return result, nil
+}
+
+func (m *Manager) record(ctx context.Context, evaluation *Evaluation) {
+	if m.recorder != nil {
+		m.recorder.RecordExposure(ctx, evaluation)
+	}
+}
+
+func evaluate(flag *Flag, subject Subject, now time.Time) (bool, Reason, int) {
+	if !flag.Enabled {
+		return false, ReasonDisabled, -1
+	}
+	if flag.StartsAt != nil && now.Before(*flag.StartsAt) {
+		return false, ReasonNotStarted, -1
+	}
+	if flag.EndsAt != nil && !now.Before(*flag.EndsAt) {
+		return false, ReasonEnded, -1
+	}
+
+	id := subject.UserID
+	if flag.HashBy == HashByTenant {
+		id = subject.TenantID
+	}
+
+	if contains(flag.Deny, subject.UserID) || contains(flag.Deny, subject.TenantID) {
+		return false, ReasonDenied, -1
+	}
+	if contains(flag.Allow, subject.UserID) || contains(flag.Allow, subject.TenantID) {
+		return true, ReasonAllowed, -1
+	}
+
+	if flag.Percentage >= 100 {
+		return true, ReasonRollout, -1
+	}
+	if flag.Percentage <= 0 || id == "" {
+		return false, ReasonExcluded, -1
+	}
+
+	bucket := Bucket(flag.Key, id)
+	if bucket < flag.Percentage {
+		return true, ReasonRollout, bucket
+	}
+	return false, ReasonExcluded, bucket
+}
+
+// Bucket returns a stable 0-99 bucket for the id. The flag key is mixed in
+// so the same users are not always first in every rollout.
+func Bucket(flagKey, id string) int {
+	h := fnv.New32a()
+	h.Write([]byte(flagKey))
+	h.Write([]byte{':'})
+	h.Write([]byte(id))
+	return int(h.Sum32() % 100)
+}
+
+func contains(list []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
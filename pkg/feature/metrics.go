@@ -0,0 +1,41 @@
+package feature
+
+import (
+	"context"
+	"expvar"
+	"strconv"
+)
+
+// exposureMetrics counts evaluations by "<flag>:<enabled>:<reason>", exposed at /debug/vars when expvar is mounted
+var exposureMetrics = expvar.NewMap("feature_exposures_total")
+
+// ExpvarRecorder records exposure counts in expvar
+type ExpvarRecorder struct{}
+
+// NewExpvarRecorder creates an expvar exposure recorder
+func NewExpvarRecorder() *ExpvarRecorder {
+	return &ExpvarRecorder{}
+}
+
+// RecordExposure increments the exposure counter for the evaluation
+func (r *ExpvarRecorder) RecordExposure(ctx context.Context, evaluation *Evaluation) {
+	exposureMetrics.Add(evaluation.Key+":"+strconv.FormatBool(evaluation.Enabled)+":"+string(evaluation.Reason), 1)
+}
+
+// RecorderFunc adapts a function to ExposureRecorder
+type RecorderFunc func(ctx context.Context, evaluation *Evaluation)
+
+// RecordExposure calls f
+func (f RecorderFunc) RecordExposure(ctx context.Context, evaluation *Evaluation) {
+	f(ctx, evaluation)
+}
+
+// MultiRecorder fans exposure events out to several recorders
+type MultiRecorder []ExposureRecorder
+
+// RecordExposure calls every recorder
+func (m MultiRecorder) RecordExposure(ctx context.Context, evaluation *Evaluation) {
+	for _, recorder := range m {
+		recorder.RecordExposure(ctx, evaluation)
+	}
+}
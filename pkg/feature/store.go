@@ -0,0 +1,96 @@
+package feature
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// MemoryStore keeps flags in memory
+type MemoryStore struct {
+	mu    sync.RWMutex
+	flags map[string]*Flag
+}
+
+// NewMemoryStore creates a store with the given flags
+func NewMemoryStore(flags ...*Flag) (*MemoryStore, error) {
+	s := &MemoryStore{flags: make(map[string]*Flag)}
+	for _, flag := range flags {
+		if err := s.Set(flag); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// LoadFile creates a store from a JSON file containing an array of flags
+func LoadFile(path string) (*MemoryStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feature flags file: %w", err)
+	}
+
+	var flags []*Flag
+	if err := json.Unmarshal(data, &flags); err != nil {
+		return nil, fmt.Errorf("failed to parse feature flags file: %w", err)
+	}
+
+	return NewMemoryStore(flags...)
+}
+
+// Set adds or replaces a flag
+func (s *MemoryStore) Set(flag *Flag) error {
+	if err := Validate(flag); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flags[flag.Key] = flag
+	return nil
+}
+
+// Get returns a flag by key
+func (s *MemoryStore) Get(ctx context.Context, key string) (*Flag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	flag, ok := s.flags[key]
+	if !ok {
+		return nil, ErrFlagNotFound
+	}
+	return flag, nil
+}
+
+// All returns all flags sorted by key
+func (s *MemoryStore) All(ctx context.Context) ([]*Flag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	flags := make([]*Flag, 0, len(s.flags))
+	for _, flag := range s.flags {
+		flags = append(flags, flag)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Key < flags[j].Key })
+	return flags, nil
+}
+
+// Validate checks a flag definition
+func Validate(flag *Flag) error {
+	if flag == nil || flag.Key == "" {
+		return fmt.Errorf("%w: key is required", ErrInvalidFlag)
+	}
+	if flag.Percentage < 0 || flag.Percentage > 100 {
+		return fmt.Errorf("%w: %s percentage must be between 0 and 100", ErrInvalidFlag, flag.Key)
+	}
+	if flag.HashBy != "" && flag.HashBy != HashByUser && flag.HashBy != HashByTenant {
+		return fmt.Errorf("%w: %s hash_by must be user or tenant", ErrInvalidFlag, flag.Key)
+	}
+	if flag.StartsAt != nil && flag.EndsAt != nil && !flag.EndsAt.After(*flag.StartsAt) {
+		return fmt.Errorf("%w: %s ends_at must be after starts_at", ErrInvalidFlag, flag.Key)
+	}
+	return nil
+}
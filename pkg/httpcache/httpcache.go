@@ -0,0 +1,245 @@
+package httpcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"flex-service/pkg/cache"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/requestctx"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// DefaultVary lists the request headers that get separate entries when a
+// route does not set Config.Vary
+var DefaultVary = []string{"Accept", "Accept-Language"}
+
+// MaxBodySize is the largest response body that is cached
+const MaxBodySize = 1 << 20
+
+// Config configures caching of one route
+type Config struct {
+	TTL time.Duration
+	// Tags group entries for Invalidate; "{id}" is replaced with the route's
+	// :id parameter, e.g. "product:{id}"
+	Tags []string
+	// Vary lists the request headers whose values get separate entries
+	Vary []string
+}
+
+// ResponseCache caches GET responses and answers conditional requests
+type ResponseCache struct {
+	cache  cache.Cache
+	prefix string
+}
+
+// entry is a stored response
+type entry struct {
+	Status       int                 `json:"status"`
+	Header       map[string][]string `json:"header"`
+	Body         []byte              `json:"body"`
+	ETag         string              `json:"etag"`
+	LastModified time.Time           `json:"last_modified"`
+}
+
+// New creates a response cache. With a nil cache the middleware only passes
+// requests through.
+func New(c cache.Cache) *ResponseCache {
+	return &ResponseCache{cache: c, prefix: "httpcache:"}
+}
+
+// Middleware caches successful GET responses of the route for config.TTL.
+// Entries are kept per path, query, Vary header values and principal, so
+// authenticated responses are never shared between users. Every response
+// carries an ETag and Last-Modified; matching If-None-Match or
+// If-Modified-Since requests get 304 Not Modified. Clients are told to
+// revalidate each time, since entries may be invalidated before they expire.
+func (r *ResponseCache) Middleware(config Config) gin.HandlerFunc {
+	if config.Vary == nil {
+		config.Vary = DefaultVary
+	}
+
+	return func(c *gin.Context) {
+		if r.cache == nil || c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		key := r.key(c, config.Vary)
+		if !strings.Contains(c.GetHeader("Cache-Control"), "no-cache") {
+			var cached entry
+			if err := r.cache.GetJSON(ctx, key, &cached); err == nil {
+				r.serve(c, &cached, config, "HIT")
+				c.Abort()
+				return
+			}
+		}
+
+		// Headers set before the handler, such as the request ID, belong to
+		// this request and are not replayed
+		before := c.Writer.Header().Clone()
+		writer := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if !writer.cacheable() {
+			writer.release()
+			return
+		}
+
+		sum := sha256.Sum256(writer.body.Bytes())
+		stored := &entry{
+			Status:       writer.Status(),
+			Header:       handlerHeader(before, writer.Header()),
+			Body:         writer.body.Bytes(),
+			ETag:         `"` + hex.EncodeToString(sum[:16]) + `"`,
+			LastModified: time.Now().UTC().Truncate(time.Second),
+		}
+		if err := r.store(c, key, stored, config); err != nil {
+			logger.Warn("Failed to cache response", zap.String("path", c.Request.URL.Path), zap.Error(err))
+		}
+		r.serve(c, stored, config, "MISS")
+	}
+}
+
+// Invalidate drops every entry stored under any of the tags
+func (r *ResponseCache) Invalidate(ctx context.Context, tags ...string) error {
+	if r.cache == nil || len(tags) == 0 {
+		return nil
+	}
+	return r.cache.Tags(r.tagKeys(tags)...).Flush(ctx)
+}
+
+// InvalidateOnSuccess returns middleware for mutations that invalidates the
+// tags after the handler succeeds; "{id}" is replaced as in Config.Tags
+func (r *ResponseCache) InvalidateOnSuccess(tags ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+		resolved := resolveTags(c, tags)
+		if err := r.Invalidate(c.Request.Context(), resolved...); err != nil {
+			logger.Warn("Failed to invalidate cached responses", zap.Strings("tags", resolved), zap.Error(err))
+		}
+	}
+}
+
+func (r *ResponseCache) store(c *gin.Context, key string, stored *entry, config Config) error {
+	ctx := c.Request.Context()
+	if len(config.Tags) == 0 {
+		return r.cache.SetJSON(ctx, key, stored, config.TTL)
+	}
+	return r.cache.Tags(r.tagKeys(resolveTags(c, config.Tags))...).SetJSON(ctx, key, stored, config.TTL)
+}
+
+// serve writes a stored response, or 304 when the client's copy is current
+func (r *ResponseCache) serve(c *gin.Context, stored *entry, config Config, status string) {
+	header := c.Writer.Header()
+	for name, values := range stored.Header {
+		header[name] = values
+	}
+	header.Set("ETag", stored.ETag)
+	header.Set("Last-Modified", stored.LastModified.Format(http.TimeFormat))
+	header.Set("X-Cache", status)
+	if len(config.Vary) > 0 {
+		header.Set("Vary", strings.Join(config.Vary, ", "))
+	}
+	if _, ok := requestctx.CurrentPrincipal(c); ok {
+		header.Set("Cache-Control", "private, no-cache")
+	} else {
+		header.Set("Cache-Control", "no-cache")
+	}
+
+	if notModified(c.Request, stored) {
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+		c.Writer.WriteHeader(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	c.Writer.WriteHeader(stored.Status)
+	_, _ = c.Writer.Write(stored.Body)
+}
+
+// key identifies the response by path, query, Vary header values, tenant and
+// principal
+func (r *ResponseCache) key(c *gin.Context, vary []string) string {
+	parts := []string{c.Request.URL.Path, c.Request.URL.Query().Encode()}
+	for _, name := range vary {
+		parts = append(parts, name+"="+c.GetHeader(name))
+	}
+	if principal, ok := requestctx.CurrentPrincipal(c); ok {
+		parts = append(parts, "principal="+principal.Type+":"+principal.Subject)
+	}
+	if tenantID, ok := requestctx.TenantID(c.Request.Context()); ok {
+		parts = append(parts, "tenant="+tenantID)
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return r.prefix + hex.EncodeToString(sum[:])
+}
+
+func (r *ResponseCache) tagKeys(tags []string) []string {
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = r.prefix + tag
+	}
+	return keys
+}
+
+// resolveTags replaces "{param}" in tags with the route's parameters
+func resolveTags(c *gin.Context, tags []string) []string {
+	resolved := make([]string, len(tags))
+	for i, tag := range tags {
+		for _, param := range c.Params {
+			tag = strings.ReplaceAll(tag, "{"+param.Key+"}", param.Value)
+		}
+		resolved[i] = tag
+	}
+	return resolved
+}
+
+// notModified reports whether the request's validators match the entry.
+// If-None-Match takes precedence over If-Modified-Since.
+func notModified(req *http.Request, stored *entry) bool {
+	if match := req.Header.Get("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == stored.ETag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if since := req.Header.Get("If-Modified-Since"); since != "" {
+		t, err := http.ParseTime(since)
+		return err == nil && !stored.LastModified.After(t)
+	}
+	return false
+}
+
+// handlerHeader returns the headers the handler added or changed
+func handlerHeader(before, after http.Header) map[string][]string {
+	stored := make(map[string][]string)
+	for name, values := range after {
+		if name == "Content-Length" || name == "Date" {
+			continue
+		}
+		if previous, ok := before[name]; ok && strings.Join(previous, "\n") == strings.Join(values, "\n") {
+			continue
+		}
+		stored[name] = append([]string(nil), values...)
+	}
+	return stored
+}
@@ -0,0 +1,87 @@
+package httpcache
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bufferedWriter holds the handler's response so it can be stored and
+// answered with validators. Streamed responses are passed through and not
+// cached.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body        bytes.Buffer
+	passthrough bool
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.WriteString(s)
+	}
+	return w.body.WriteString(s)
+}
+
+// WriteHeaderNow is deferred until the response is released or served
+func (w *bufferedWriter) WriteHeaderNow() {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+// Written reports whether the handler wrote a response, as gin expects
+func (w *bufferedWriter) Written() bool {
+	return w.passthrough || w.body.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// Size is the number of body bytes written so far
+func (w *bufferedWriter) Size() int {
+	if w.passthrough {
+		return w.ResponseWriter.Size()
+	}
+	return w.body.Len()
+}
+
+// Flush switches to streaming: the buffer is written out and the response is
+// not cached
+func (w *bufferedWriter) Flush() {
+	w.release()
+	w.ResponseWriter.Flush()
+}
+
+// release writes the buffered response unchanged and passes further writes
+// through
+func (w *bufferedWriter) release() {
+	if w.passthrough {
+		return
+	}
+	w.passthrough = true
+	w.ResponseWriter.WriteHeaderNow()
+	if w.body.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.body.Bytes())
+		w.body.Reset()
+	}
+}
+
+// cacheable reports whether the response may be stored: a complete 200 that
+// sets no cookie and does not opt out with Cache-Control
+func (w *bufferedWriter) cacheable() bool {
+	if w.passthrough || w.Status() != http.StatusOK || w.body.Len() > MaxBodySize {
+		return false
+	}
+	header := w.Header()
+	if header.Get("Set-Cookie") != "" {
+		return false
+	}
+	control := header.Get("Cache-Control")
+	return !strings.Contains(control, "no-store") && !strings.Contains(control, "private")
+}
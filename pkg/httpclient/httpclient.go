@@ -0,0 +1,64 @@
+package httpclient
+
+import (
+	"net/http"
+	"time"
+
+	"flex-service/pkg/signing"
+)
+
+// Config configures outbound HTTP clients
+type Config struct {
+	Timeout time.Duration // of the whole call, retries included
+	// Name labels the client's log entries, e.g. "slack"
+	Name string
+	// Signer signs every request for service-to-service calls (optional)
+	Signer *signing.Signer
+	// Retry repeats failed idempotent requests; the zero value never retries
+	Retry RetryPolicy
+	// Breaker stops calls to failing hosts (optional)
+	Breaker Breaker
+	// Transport overrides http.DefaultTransport (optional)
+	Transport http.RoundTripper
+}
+
+// DefaultConfig returns the default client configuration
+func DefaultConfig() Config {
+	return Config{
+		Timeout: 10 * time.Second,
+		Retry:   DefaultRetryPolicy(),
+	}
+}
+
+// New creates an *http.Client from the config. Every request carries the
+// caller's trace, and every attempt is logged with credentials redacted and
+// counted by host.
+func New(cfg Config) *http.Client {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultConfig().Timeout
+	}
+
+	transport := cfg.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	// Signing is innermost so each attempt gets a fresh timestamp and nonce
+	if cfg.Signer != nil {
+		transport = &signing.Transport{Base: transport, Signer: cfg.Signer}
+	}
+	transport = &instrumentTransport{base: transport, breaker: cfg.Breaker, name: cfg.Name}
+	transport = &retryTransport{base: transport, policy: cfg.Retry}
+	transport = &traceTransport{base: transport}
+
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: transport,
+	}
+}
+
+// NewSigned creates a client that signs every request with signer
+func NewSigned(signer *signing.Signer) *http.Client {
+	cfg := DefaultConfig()
+	cfg.Signer = signer
+	return New(cfg)
+}
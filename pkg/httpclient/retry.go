@@ -0,0 +1,147 @@
+package httpclient
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures retries of failed requests. Only requests that are
+// safe to repeat are retried: idempotent methods, and others carrying an
+// Idempotency-Key header. Their body must be replayable (http.NewRequest sets
+// GetBody for bytes, strings and bytes.Buffer readers).
+type RetryPolicy struct {
+	MaxAttempts int           // including the first; 0 or 1 disables retries
+	BaseDelay   time.Duration // before the first retry, doubled for each one after
+	MaxDelay    time.Duration // cap of the backoff and of Retry-After
+	// RetryOn reports whether a response status is worth retrying (default:
+	// 429, 502, 503 and 504)
+	RetryOn func(status int) bool
+}
+
+// DefaultRetryPolicy returns 3 attempts backing off from 200ms up to 5s
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+	}
+}
+
+// IdempotencyKeyHeader marks a non-idempotent request as safe to retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryTransport repeats requests that failed with a network error or a
+// retryable status, backing off exponentially with full jitter
+type retryTransport struct {
+	base   http.RoundTripper
+	policy RetryPolicy
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.policy.MaxAttempts <= 1 || !replayable(req) {
+		return t.base.RoundTrip(req)
+	}
+	retryOn := t.policy.RetryOn
+	if retryOn == nil {
+		retryOn = retryableStatus
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 {
+			var err error
+			if attemptReq, err = rewind(req); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		if attempt >= t.policy.MaxAttempts || req.Context().Err() != nil {
+			return resp, err
+		}
+		if err == nil && !retryOn(resp.StatusCode) {
+			return resp, nil
+		}
+
+		delay := t.backoff(attempt)
+		if err == nil {
+			if after, ok := retryAfter(resp); ok {
+				delay = min(after, t.policy.MaxDelay)
+			}
+			// Drain so the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns a random delay up to BaseDelay*2^(attempt-1), capped at MaxDelay
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	ceiling := t.policy.BaseDelay << (attempt - 1)
+	if ceiling <= 0 || ceiling > t.policy.MaxDelay {
+		ceiling = t.policy.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// replayable reports whether the request may be sent again
+func replayable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(IdempotencyKeyHeader) != ""
+}
+
+// rewind returns a copy of req with a fresh body
+func rewind(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
+
+// retryAfter reads a Retry-After header in seconds or as an HTTP date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
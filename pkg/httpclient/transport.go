@@ -0,0 +1,165 @@
+package httpclient
+
+import (
+	"errors"
+	"expvar"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"flex-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// requestMetrics counts outbound requests by "<host>:<status>" ("<host>:error"
+	// for network errors), exposed at /debug/vars when expvar is mounted
+	requestMetrics = expvar.NewMap("httpclient_requests_total")
+	// latencyMetrics sums request seconds by host; divide by the host's
+	// request count for the mean latency
+	latencyMetrics = expvar.NewMap("httpclient_request_seconds_total")
+)
+
+// ErrCircuitOpen is returned by breakers that reject a request
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Breaker is a circuit breaker hook. Allow rejects requests to a host whose
+// circuit is open, and Record reports the outcome of every attempt. A 5xx
+// response counts as a failure.
+type Breaker interface {
+	Allow(host string) error
+	Record(host string, success bool)
+}
+
+// redactedHeaders are logged as [REDACTED]
+var redactedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+	"X-Signature":         true,
+}
+
+// redactedParams are query parameters replaced in logged URLs
+var redactedParams = []string{"token", "access_token", "api_key", "key", "secret", "signature", "password", "code"}
+
+// instrumentTransport applies the breaker and logs and counts every attempt
+type instrumentTransport struct {
+	base    http.RoundTripper
+	breaker Breaker
+	name    string
+}
+
+func (t *instrumentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	if t.breaker != nil {
+		if err := t.breaker.Allow(host); err != nil {
+			requestMetrics.Add(host+":open", 1)
+			return nil, err
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	requestMetrics.Add(host+":"+status, 1)
+	latencyMetrics.AddFloat(host, elapsed.Seconds())
+	if t.breaker != nil {
+		t.breaker.Record(host, err == nil && resp.StatusCode < http.StatusInternalServerError)
+	}
+
+	fields := []zap.Field{
+		zap.String("client", t.name),
+		zap.String(logger.FieldMethod, req.Method),
+		zap.String("url", RedactURL(req.URL)),
+		zap.Duration(logger.FieldDuration, elapsed),
+	}
+	if span := spanOf(req); span != "" {
+		fields = append(fields, zap.String("outbound_span_id", span))
+	}
+	log := logger.WithContext(req.Context())
+	switch {
+	case err != nil:
+		log.Warn("Outbound request failed", append(fields, zap.Error(err))...)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		log.Warn("Outbound request returned an error", append(fields, zap.Int(logger.FieldStatusCode, resp.StatusCode))...)
+	default:
+		log.Debug("Outbound request",
+			append(fields,
+				zap.Int(logger.FieldStatusCode, resp.StatusCode),
+				zap.Any("request_headers", RedactHeaders(req.Header)),
+				zap.Any("response_headers", RedactHeaders(resp.Header)))...)
+	}
+	return resp, err
+}
+
+// RedactHeaders returns a copy of h with credentials replaced
+func RedactHeaders(h http.Header) http.Header {
+	redacted := make(http.Header, len(h))
+	for name, values := range h {
+		if redactedHeaders[http.CanonicalHeaderKey(name)] {
+			redacted[name] = []string{"[REDACTED]"}
+			continue
+		}
+		redacted[name] = values
+	}
+	return redacted
+}
+
+// RedactURL returns u as a string without user info and with secret-looking
+// query parameters replaced
+func RedactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	query := redacted.Query()
+	changed := false
+	for name := range query {
+		for _, secret := range redactedParams {
+			if strings.EqualFold(name, secret) {
+				query.Set(name, "REDACTED")
+				changed = true
+			}
+		}
+	}
+	if changed {
+		redacted.RawQuery = query.Encode()
+	}
+	return redacted.String()
+}
+
+// traceTransport propagates the caller's trace with a new span per request
+type traceTransport struct {
+	base http.RoundTripper
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get(logger.TraceparentHeader) != "" {
+		return t.base.RoundTrip(req)
+	}
+	traceID, _ := logger.Trace(req.Context())
+	if traceID == "" {
+		return t.base.RoundTrip(req)
+	}
+
+	clone := req.Clone(req.Context())
+	clone.Header.Set(logger.TraceparentHeader, logger.FormatTraceparent(traceID, logger.NewSpanID(), true))
+	return t.base.RoundTrip(clone)
+}
+
+// spanOf returns the span ID of the request's traceparent
+func spanOf(req *http.Request) string {
+	_, span, _, ok := logger.ParseTraceparent(req.Header.Get(logger.TraceparentHeader))
+	if !ok {
+		return ""
+	}
+	return span
+}
@@ -0,0 +1,201 @@
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Args are named placeholder values, e.g. Args{"field": "email"} for "{field} is required"
+type Args map[string]interface{}
+
+// Bundle holds translated messages per locale
+type Bundle struct {
+	mu            sync.RWMutex
+	defaultLocale string
+	messages      map[string]map[string]string
+}
+
+// NewBundle creates an empty bundle
+func NewBundle(defaultLocale string) *Bundle {
+	return &Bundle{
+		defaultLocale: normalize(defaultLocale),
+		messages:      make(map[string]map[string]string),
+	}
+}
+
+// DefaultLocale returns the fallback locale
+func (b *Bundle) DefaultLocale() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.defaultLocale
+}
+
+// SetDefaultLocale changes the fallback locale
+func (b *Bundle) SetDefaultLocale(locale string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.defaultLocale = normalize(locale)
+}
+
+// AddMessages merges messages into a locale, overriding existing keys
+func (b *Bundle) AddMessages(locale string, messages map[string]string) {
+	locale = normalize(locale)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.messages[locale] == nil {
+		b.messages[locale] = make(map[string]string, len(messages))
+	}
+	for key, message := range messages {
+		b.messages[locale][key] = message
+	}
+}
+
+// LoadDir loads <locale>.json and <locale>.toml files from a directory
+func (b *Bundle) LoadDir(dir string) error {
+	return b.LoadFS(os.DirFS(dir), ".")
+}
+
+// LoadFS loads <locale>.json and <locale>.toml files from a filesystem directory.
+// Nested objects are flattened with dots: {"errors": {"NOT_FOUND": "..."}} -> errors.NOT_FOUND
+func (b *Bundle) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("failed to read locale directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		ext := path.Ext(entry.Name())
+		if ext != ".json" && ext != ".toml" {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read locale file %s: %w", entry.Name(), err)
+		}
+
+		var raw map[string]interface{}
+		if ext == ".json" {
+			err = json.Unmarshal(data, &raw)
+		} else {
+			err = toml.Unmarshal(data, &raw)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse locale file %s: %w", entry.Name(), err)
+		}
+
+		messages := make(map[string]string)
+		flatten("", raw, messages)
+		b.AddMessages(strings.TrimSuffix(entry.Name(), ext), messages)
+	}
+
+	return nil
+}
+
+// Locales returns the loaded locales, sorted
+func (b *Bundle) Locales() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	locales := make([]string, 0, len(b.messages))
+	for locale := range b.messages {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Lookup finds the raw message for a key, falling back from "th-TH" to "th"
+// and then to the default locale
+func (b *Bundle) Lookup(locale, key string) (string, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, candidate := range b.fallbacks(normalize(locale)) {
+		if message, ok := b.messages[candidate][key]; ok {
+			return message, true
+		}
+	}
+	return "", false
+}
+
+// T translates a key and fills {placeholders}. Missing keys return the key itself.
+func (b *Bundle) T(locale, key string, args Args) string {
+	message, ok := b.Lookup(locale, key)
+	if !ok {
+		return key
+	}
+	return Format(message, args)
+}
+
+// Has reports whether the locale (or its base language) has messages
+func (b *Bundle) Has(locale string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	_, ok := b.messages[normalize(locale)]
+	return ok
+}
+
+func (b *Bundle) fallbacks(locale string) []string {
+	candidates := []string{locale}
+	if base, _, found := strings.Cut(locale, "-"); found {
+		candidates = append(candidates, base)
+	}
+	if locale != b.defaultLocale {
+		candidates = append(candidates, b.defaultLocale)
+	}
+	return candidates
+}
+
+// Format replaces {name} placeholders with args
+func Format(message string, args Args) string {
+	if len(args) == 0 {
+		return message
+	}
+
+	pairs := make([]string, 0, len(args)*2)
+	for name, value := range args {
+		pairs = append(pairs, "{"+name+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(pairs...).Replace(message)
+}
+
+func flatten(prefix string, raw map[string]interface{}, out map[string]string) {
+	for key, value := range raw {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flatten(key, v, out)
+		case string:
+			out[key] = v
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+// normalize turns "th_TH" / "TH-th" into "th-TH"
+func normalize(locale string) string {
+	locale = strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	base, region, found := strings.Cut(locale, "-")
+	if !found {
+		return strings.ToLower(base)
+	}
+	return strings.ToLower(base) + "-" + strings.ToUpper(region)
+}
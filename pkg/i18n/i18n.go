@@ -0,0 +1,140 @@
+package i18n
+
+import (
+	"context"
+	"embed"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed locales
+var builtinLocales embed.FS
+
+// DefaultLocale is the fallback locale before configuration is applied
+const DefaultLocale = "en"
+
+type contextKey struct{}
+
+var defaultBundle = newBuiltinBundle()
+
+func newBuiltinBundle() *Bundle {
+	b := NewBundle(DefaultLocale)
+	if err := b.LoadFS(builtinLocales, "locales"); err != nil {
+		panic("i18n: failed to load built-in locales: " + err.Error())
+	}
+	return b
+}
+
+// Default returns the global bundle, preloaded with the built-in messages
+func Default() *Bundle {
+	return defaultBundle
+}
+
+// T translates with the global bundle
+func T(locale, key string, args Args) string {
+	return defaultBundle.T(locale, key, args)
+}
+
+// Lookup finds a raw message in the global bundle
+func Lookup(locale, key string) (string, bool) {
+	return defaultBundle.Lookup(locale, key)
+}
+
+// WithLocale stores the locale in a context
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, contextKey{}, locale)
+}
+
+// FromContext returns the locale stored in the context, or the default locale
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(contextKey{}).(string); ok && locale != "" {
+		return locale
+	}
+	return defaultBundle.DefaultLocale()
+}
+
+// Locale returns the negotiated locale for a gin request
+func Locale(c *gin.Context) string {
+	if locale := c.GetString("locale"); locale != "" {
+		return locale
+	}
+	return FromContext(c.Request.Context())
+}
+
+// Middleware negotiates the locale from ?lang= or Accept-Language against the loaded locales,
+// stores it on the gin and request contexts and sets Content-Language
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := Negotiate(defaultBundle, c.Query("lang"), c.GetHeader("Accept-Language"))
+
+		c.Set("locale", locale)
+		c.Request = c.Request.WithContext(WithLocale(c.Request.Context(), locale))
+		c.Header("Content-Language", locale)
+		c.Next()
+	}
+}
+
+// Negotiate picks the best supported locale from an explicit override and an Accept-Language header
+func Negotiate(b *Bundle, override, acceptLanguage string) string {
+	if override != "" {
+		if locale := supported(b, override); locale != "" {
+			return locale
+		}
+	}
+
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if locale := supported(b, tag); locale != "" {
+			return locale
+		}
+	}
+	return b.DefaultLocale()
+}
+
+// supported returns the exact locale or its base language if loaded
+func supported(b *Bundle, tag string) string {
+	tag = normalize(tag)
+	if b.Has(tag) {
+		return tag
+	}
+	if base, _, found := strings.Cut(tag, "-"); found && b.Has(base) {
+		return base
+	}
+	return ""
+}
+
+// parseAcceptLanguage returns language tags ordered by q-value
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag, q})
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	result := make([]string, len(tags))
+	for i, t := range tags {
+		result[i] = t.tag
+	}
+	return result
+}
@@ -0,0 +1,260 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"flex-service/pkg/cache"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/requestctx"
+	"flex-service/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Header is the request header carrying the client's key
+const Header = "Idempotency-Key"
+
+// ReplayedHeader is set to "true" on responses replayed from the store
+const ReplayedHeader = "Idempotent-Replayed"
+
+// Defaults used when Config leaves them zero
+const (
+	DefaultTTL         = 24 * time.Hour
+	DefaultLockTimeout = time.Minute
+	// MaxKeyLength is the longest key accepted
+	MaxKeyLength = 255
+	// MaxBodySize is the largest response body stored for replay; larger
+	// responses are replayed with their status and headers only
+	MaxBodySize = 1 << 20
+)
+
+// Config configures idempotency of one route
+type Config struct {
+	// TTL is how long a response is replayed for retries with the same key
+	TTL time.Duration
+	// LockTimeout bounds how long a request holds its key; it should exceed
+	// the route's longest response time
+	LockTimeout time.Duration
+	// Required rejects requests without an Idempotency-Key with 400
+	Required bool
+}
+
+// Store records the responses of requests sent with an Idempotency-Key
+type Store struct {
+	cache  cache.Cache
+	prefix string
+}
+
+// entry is a recorded response
+type entry struct {
+	Fingerprint string              `json:"fingerprint"` // hash of method, path and request body
+	Status      int                 `json:"status"`
+	Header      map[string][]string `json:"header"`
+	Body        []byte              `json:"body,omitempty"`
+	BodyHash    string              `json:"body_hash"`
+	Truncated   bool                `json:"truncated,omitempty"` // body over MaxBodySize, not stored
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+// New creates an idempotency store. With a nil cache the middleware only
+// passes requests through.
+func New(c cache.Cache) *Store {
+	return &Store{cache: c, prefix: "idempotency:"}
+}
+
+// Middleware makes POST, PUT, PATCH and DELETE requests with an
+// Idempotency-Key safe to retry. The first request runs and its response is
+// stored; a retry with the same key and body gets the stored response with
+// Idempotent-Replayed: true instead of running again. A retry while the
+// first request is still running gets 409, and the same key with a different
+// method, path or body gets 422. Keys are scoped to the principal and tenant.
+// Server errors (5xx) are not stored, so the request can be retried.
+func (s *Store) Middleware(config Config) gin.HandlerFunc {
+	if config.TTL <= 0 {
+		config.TTL = DefaultTTL
+	}
+	if config.LockTimeout <= 0 {
+		config.LockTimeout = DefaultLockTimeout
+	}
+
+	return func(c *gin.Context) {
+		if !unsafeMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		key := strings.TrimSpace(c.GetHeader(Header))
+		switch {
+		case key == "" && config.Required:
+			response.Error(c, http.StatusBadRequest, "IDEMPOTENCY_KEY_REQUIRED", Header+" header is required", nil)
+			c.Abort()
+			return
+		case len(key) > MaxKeyLength:
+			response.Error(c, http.StatusBadRequest, "INVALID_IDEMPOTENCY_KEY", Header+" must be at most 255 characters", nil)
+			c.Abort()
+			return
+		case key == "" || s.cache == nil:
+			c.Next()
+			return
+		}
+
+		fingerprint, err := s.fingerprint(c)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "INVALID_REQUEST", "Failed to read request body", nil)
+			c.Abort()
+			return
+		}
+
+		// The key is released and the response stored even if the client
+		// disconnects, so its retry is answered
+		ctx := context.WithoutCancel(c.Request.Context())
+		storeKey := s.key(c, key)
+		lockKey := storeKey + ":lock"
+		if s.replay(c, storeKey, fingerprint) {
+			return
+		}
+
+		// Incr is atomic: only the first request sees 1
+		holders, err := s.cache.Incr(ctx, lockKey)
+		if err != nil {
+			logger.Warn("Idempotency store unavailable, running request without it",
+				zap.String("path", c.Request.URL.Path), zap.Error(err))
+			c.Next()
+			return
+		}
+		if holders > 1 {
+			c.Header("Retry-After", "1")
+			response.Error(c, http.StatusConflict, "IDEMPOTENCY_KEY_IN_USE", "A request with this "+Header+" is still being processed", nil)
+			c.Abort()
+			return
+		}
+		defer func() {
+			if err := s.cache.Del(ctx, lockKey); err != nil {
+				logger.Warn("Failed to release idempotency key", zap.String("path", c.Request.URL.Path), zap.Error(err))
+			}
+		}()
+		if err := s.cache.Expire(ctx, lockKey, config.LockTimeout); err != nil {
+			logger.Warn("Failed to set idempotency lock timeout", zap.Error(err))
+		}
+
+		// The previous holder may have finished between the lookup and the lock
+		if s.replay(c, storeKey, fingerprint) {
+			return
+		}
+
+		before := c.Writer.Header().Clone()
+		writer := &recordingWriter{ResponseWriter: c.Writer, hash: sha256.New()}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		if writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+		stored := &entry{
+			Fingerprint: fingerprint,
+			Status:      writer.Status(),
+			Header:      handlerHeader(before, writer.Header()),
+			BodyHash:    hex.EncodeToString(writer.hash.Sum(nil)),
+			Truncated:   writer.truncated,
+			CreatedAt:   time.Now().UTC(),
+		}
+		if !writer.truncated {
+			stored.Body = writer.body.Bytes()
+		}
+		if err := s.cache.SetJSON(ctx, storeKey, stored, config.TTL); err != nil {
+			logger.Warn("Failed to store idempotent response", zap.String("path", c.Request.URL.Path), zap.Error(err))
+		}
+	}
+}
+
+// replay answers the request from a stored response, or with 422 when the key
+// was used for a different request. It reports whether it responded.
+func (s *Store) replay(c *gin.Context, storeKey, fingerprint string) bool {
+	var stored entry
+	if err := s.cache.GetJSON(c.Request.Context(), storeKey, &stored); err != nil {
+		return false
+	}
+
+	if stored.Fingerprint != fingerprint {
+		response.Error(c, http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_MISMATCH", Header+" was already used for a different request", nil)
+		c.Abort()
+		return true
+	}
+
+	header := c.Writer.Header()
+	for name, values := range stored.Header {
+		header[name] = values
+	}
+	header.Set(ReplayedHeader, "true")
+	c.Writer.WriteHeader(stored.Status)
+	if len(stored.Body) > 0 {
+		_, _ = c.Writer.Write(stored.Body)
+	} else {
+		c.Writer.WriteHeaderNow()
+	}
+	c.Abort()
+	return true
+}
+
+// fingerprint hashes the method, path and body of the request, restoring the
+// body for the handler
+func (s *Store) fingerprint(c *gin.Context) (string, error) {
+	hash := sha256.New()
+	io.WriteString(hash, c.Request.Method+" "+c.Request.URL.Path+"\n")
+	if c.Request.Body != nil && c.Request.Body != http.NoBody {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return "", err
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		hash.Write(body)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// key scopes the client's key to the principal and tenant, so clients cannot
+// replay each other's responses
+func (s *Store) key(c *gin.Context, key string) string {
+	parts := []string{key}
+	if principal, ok := requestctx.CurrentPrincipal(c); ok {
+		parts = append(parts, "principal="+principal.Type+":"+principal.Subject)
+	}
+	if tenantID, ok := requestctx.TenantID(c.Request.Context()); ok {
+		parts = append(parts, "tenant="+tenantID)
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return s.prefix + hex.EncodeToString(sum[:])
+}
+
+func unsafeMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// handlerHeader returns the headers the handler added or changed
+func handlerHeader(before, after http.Header) map[string][]string {
+	stored := make(map[string][]string)
+	for name, values := range after {
+		if name == "Content-Length" || name == "Date" || name == "Set-Cookie" {
+			continue
+		}
+		if previous, ok := before[name]; ok && strings.Join(previous, "\n") == strings.Join(values, "\n") {
+			continue
+		}
+		stored[name] = append([]string(nil), values...)
+	}
+	return stored
+}
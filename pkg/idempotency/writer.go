@@ -0,0 +1,40 @@
+package idempotency
+
+import (
+	"bytes"
+	"hash"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordingWriter passes the response through to the client and keeps a copy
+// of the body, up to MaxBodySize, and its hash for the store
+type recordingWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	hash      hash.Hash
+	truncated bool
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.record(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *recordingWriter) record(data []byte) {
+	w.hash.Write(data)
+	if w.truncated {
+		return
+	}
+	if w.body.Len()+len(data) > MaxBodySize {
+		w.truncated = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(data)
+}
@@ -0,0 +1,85 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	// Register the GIF decoder for image.Decode
+	_ "image/gif"
+)
+
+// DefaultQuality is the JPEG quality used when a variant doesn't set one
+const DefaultQuality = 85
+
+// headerSize is how much is buffered to read image dimensions before decoding
+const headerSize = 64 * 1024
+
+// Decode reads an image, rejecting images with more than maxPixels pixels
+// (0 = unlimited) before the pixel data is allocated
+func Decode(r io.Reader, maxPixels int) (image.Image, string, error) {
+	br := bufio.NewReaderSize(r, headerSize)
+
+	if maxPixels > 0 {
+		// Dimensions sit near the start of every registered format
+		header, _ := br.Peek(headerSize)
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(header))
+		if err == nil && cfg.Width*cfg.Height > maxPixels {
+			return nil, "", fmt.Errorf("%w: %dx%d", ErrImageTooLarge, cfg.Width, cfg.Height)
+		}
+	}
+
+	img, format, err := image.Decode(br)
+	if err == image.ErrFormat {
+		return nil, "", ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return img, format, nil
+}
+
+// Encode writes img as JPEG or PNG
+func Encode(w io.Writer, img image.Image, format string, quality int) error {
+	switch format {
+	case FormatJPEG, "jpg":
+		if quality <= 0 {
+			quality = DefaultQuality
+		}
+		return jpeg.Encode(w, flatten(img), &jpeg.Options{Quality: quality})
+	case FormatPNG:
+		return png.Encode(w, img)
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
+
+// ContentType returns the MIME type of an output format
+func ContentType(format string) string {
+	if format == FormatPNG {
+		return "image/png"
+	}
+	return "image/jpeg"
+}
+
+// Extension returns the file extension of an output format
+func Extension(format string) string {
+	if format == FormatPNG {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+// flatten draws transparent pixels onto white, since JPEG has no alpha channel
+func flatten(img image.Image) image.Image {
+	b := img.Bounds()
+	dst := image.NewRGBA(b)
+	draw.Draw(dst, b, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, b, img, b.Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,12 @@
+package imaging
+
+import "errors"
+
+// Imaging errors
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrImageTooLarge     = errors.New("image exceeds the maximum pixel count")
+	ErrInvalidVariant    = errors.New("invalid image variant")
+	ErrUnknownVariant    = errors.New("unknown image variant")
+	ErrMissingPath       = errors.New("image path is required")
+)
@@ -0,0 +1,60 @@
+package imaging
+
+import (
+	"context"
+	"time"
+)
+
+// Resize modes
+const (
+	ModeFit  = "fit"  // Scale down to fit inside the box, keeping aspect ratio
+	ModeFill = "fill" // Scale to cover the box, then center-crop to exactly Width x Height
+)
+
+// Output formats
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+)
+
+// Variant describes one derived image, e.g. a thumbnail
+type Variant struct {
+	Name    string `json:"name"`
+	Width   int    `json:"width"`  // 0 = derive from Height
+	Height  int    `json:"height"` // 0 = derive from Width
+	Mode    string `json:"mode"`
+	Format  string `json:"format,omitempty"`  // Empty keeps the source format (gif becomes png)
+	Quality int    `json:"quality,omitempty"` // JPEG quality; 0 uses the processor default
+}
+
+// ImageVariant records a stored variant of a source image
+type ImageVariant struct {
+	ID          int       `json:"-" gorm:"primaryKey"`
+	SourcePath  string    `json:"source_path" gorm:"type:varchar(500);not null;uniqueIndex:idx_image_variant"`
+	Name        string    `json:"name" gorm:"type:varchar(100);not null;uniqueIndex:idx_image_variant"`
+	Path        string    `json:"path" gorm:"type:varchar(500);not null"`
+	URL         string    `json:"url" gorm:"-"`
+	Width       int       `json:"width" gorm:"not null"`
+	Height      int       `json:"height" gorm:"not null"`
+	Size        int64     `json:"size" gorm:"not null"`
+	ContentType string    `json:"content_type" gorm:"type:varchar(100);not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for GORM
+func (ImageVariant) TableName() string {
+	return "tb_image_variant"
+}
+
+// Store persists variant metadata
+type Store interface {
+	// SaveVariants creates or replaces variants by source path and name
+	SaveVariants(ctx context.Context, variants []ImageVariant) error
+
+	// ListVariants returns the stored variants of a source image
+	ListVariants(ctx context.Context, sourcePath string) ([]ImageVariant, error)
+
+	// DeleteVariants removes the metadata of a source image's variants
+	DeleteVariants(ctx context.Context, sourcePath string) error
+}
@@ -0,0 +1,77 @@
+package imaging
+
+import (
+	"context"
+
+	"flex-service/pkg/logger"
+	"flex-service/pkg/queue"
+
+	"go.uber.org/zap"
+)
+
+// JobPayload builds the payload of a queue.JobTypeImageProcessing job.
+// With no variant names every configured variant is generated.
+func JobPayload(path string, variants ...string) map[string]interface{} {
+	payload := map[string]interface{}{"path": path}
+	if len(variants) > 0 {
+		payload["variants"] = variants
+	}
+	return payload
+}
+
+// Dispatch queues variant generation for the image at path
+func Dispatch(dispatcher *queue.JobDispatcher, path string, variants ...string) error {
+	return dispatcher.Dispatch(queue.JobTypeImageProcessing, JobPayload(path, variants...))
+}
+
+// JobHandler processes queue.JobTypeImageProcessing jobs:
+//
+//	worker.RegisterHandler(queue.JobTypeImageProcessing, imaging.JobHandler(processor))
+func JobHandler(p *Processor) queue.Handler {
+	return queue.HandlerFunc(func(ctx context.Context, job *queue.Job) *queue.JobResult {
+		path, _ := job.Payload["path"].(string)
+		if path == "" {
+			return &queue.JobResult{Success: false, Error: ErrMissingPath.Error()}
+		}
+
+		variants, err := p.Process(ctx, path, payloadStrings(job.Payload["variants"])...)
+		if err != nil {
+			logger.Error("Image processing job failed",
+				zap.String("job_id", job.ID),
+				zap.String("path", path),
+				zap.Error(err))
+			return &queue.JobResult{Success: false, Error: err.Error()}
+		}
+
+		logger.Info("Image processing job completed",
+			zap.String("job_id", job.ID),
+			zap.String("path", path),
+			zap.Int("variants", len(variants)))
+
+		return &queue.JobResult{
+			Success: true,
+			Data: map[string]interface{}{
+				"path":     path,
+				"variants": variants,
+			},
+		}
+	})
+}
+
+// payloadStrings reads a string list from a payload value, which is []interface{}
+// after a JSON round trip through the queue
+func payloadStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				items = append(items, s)
+			}
+		}
+		return items
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+
+	"flex-service/pkg/storage"
+)
+
+// Config configures a Processor
+type Config struct {
+	Variants  []Variant
+	Quality   int // Default JPEG quality
+	MaxPixels int // Reject larger source images (0 = unlimited)
+}
+
+// Processor derives configured variants from images in storage
+type Processor struct {
+	fs       storage.Filesystem
+	store    Store
+	variants map[string]Variant
+	order    []string
+	config   Config
+}
+
+// NewProcessor creates a processor. store may be nil to skip recording metadata.
+func NewProcessor(fs storage.Filesystem, store Store, cfg Config) (*Processor, error) {
+	if cfg.Quality <= 0 {
+		cfg.Quality = DefaultQuality
+	}
+
+	p := &Processor{
+		fs:       fs,
+		store:    store,
+		variants: make(map[string]Variant),
+		config:   cfg,
+	}
+	for _, v := range cfg.Variants {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+		if _, exists := p.variants[v.Name]; exists {
+			return nil, fmt.Errorf("%w: duplicate name %q", ErrInvalidVariant, v.Name)
+		}
+		p.variants[v.Name] = v
+		p.order = append(p.order, v.Name)
+	}
+	return p, nil
+}
+
+// Variants returns the configured variants in definition order
+func (p *Processor) Variants() []Variant {
+	variants := make([]Variant, 0, len(p.order))
+	for _, name := range p.order {
+		variants = append(variants, p.variants[name])
+	}
+	return variants
+}
+
+// Process derives the named variants (all when none are given) from the image at source,
+// stores them next to it and records their metadata
+func (p *Processor) Process(ctx context.Context, source string, names ...string) ([]ImageVariant, error) {
+	if source == "" {
+		return nil, ErrMissingPath
+	}
+	if len(names) == 0 {
+		names = p.order
+	}
+
+	selected := make([]Variant, 0, len(names))
+	for _, name := range names {
+		v, ok := p.variants[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownVariant, name)
+		}
+		selected = append(selected, v)
+	}
+
+	rc, err := p.fs.Get(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	img, sourceFormat, err := Decode(rc, p.config.MaxPixels)
+	rc.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", source, err)
+	}
+
+	results := make([]ImageVariant, 0, len(selected))
+	for _, v := range selected {
+		result, err := p.derive(ctx, source, sourceFormat, img, v)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *result)
+	}
+
+	if p.store != nil {
+		if err := p.store.SaveVariants(ctx, results); err != nil {
+			return nil, fmt.Errorf("failed to record variants of %s: %w", source, err)
+		}
+	}
+
+	return results, nil
+}
+
+// Stored returns the recorded variants of source with their URLs
+func (p *Processor) Stored(ctx context.Context, source string) ([]ImageVariant, error) {
+	if p.store == nil {
+		return nil, nil
+	}
+	variants, err := p.store.ListVariants(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	for i := range variants {
+		variants[i].URL = p.fs.URL(variants[i].Path)
+	}
+	return variants, nil
+}
+
+// Delete removes the variant files and metadata of source. Without a store,
+// every configured variant is removed in both output formats.
+func (p *Processor) Delete(ctx context.Context, source string) error {
+	var paths []string
+	if p.store != nil {
+		recorded, err := p.store.ListVariants(ctx, source)
+		if err != nil {
+			return err
+		}
+		for _, v := range recorded {
+			paths = append(paths, v.Path)
+		}
+	} else {
+		for _, name := range p.order {
+			paths = append(paths,
+				VariantPath(source, name, FormatJPEG),
+				VariantPath(source, name, FormatPNG))
+		}
+	}
+
+	for _, target := range paths {
+		if err := p.fs.Delete(ctx, target); err != nil {
+			return err
+		}
+	}
+
+	if p.store != nil {
+		return p.store.DeleteVariants(ctx, source)
+	}
+	return nil
+}
+
+// derive resizes img for one variant and stores the encoded result
+func (p *Processor) derive(ctx context.Context, source, sourceFormat string, img image.Image, v Variant) (*ImageVariant, error) {
+	resized := v.Apply(img)
+	format := v.OutputFormat(sourceFormat)
+
+	quality := v.Quality
+	if quality == 0 {
+		quality = p.config.Quality
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, resized, format, quality); err != nil {
+		return nil, fmt.Errorf("failed to encode %s variant of %s: %w", v.Name, source, err)
+	}
+
+	target := VariantPath(source, v.Name, format)
+	size := int64(buf.Len())
+	if err := p.fs.Put(ctx, target, &buf, &storage.PutOptions{ContentType: ContentType(format)}); err != nil {
+		return nil, fmt.Errorf("failed to store %s: %w", target, err)
+	}
+
+	bounds := resized.Bounds()
+	return &ImageVariant{
+		SourcePath:  source,
+		Name:        v.Name,
+		Path:        target,
+		URL:         p.fs.URL(target),
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+		Size:        size,
+		ContentType: ContentType(format),
+	}, nil
+}
@@ -0,0 +1,193 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// Resize scales img to exactly width x height using a linear (tent) filter.
+// When shrinking, the filter widens with the scale so every source pixel contributes.
+func Resize(img image.Image, width, height int) *image.RGBA {
+	src := toRGBA(img)
+	if width <= 0 || height <= 0 {
+		return image.NewRGBA(image.Rect(0, 0, 0, 0))
+	}
+
+	b := src.Bounds()
+	if b.Dx() == width && b.Dy() == height {
+		return src
+	}
+
+	return resampleVertical(resampleHorizontal(src, width), height)
+}
+
+// Fit scales img down so it fits inside width x height, keeping its aspect ratio.
+// A zero dimension is unbounded. Images that already fit are returned unscaled.
+func Fit(img image.Image, width, height int) *image.RGBA {
+	b := img.Bounds()
+	w, h := fitSize(b.Dx(), b.Dy(), width, height)
+	return Resize(img, w, h)
+}
+
+// Fill scales img to cover width x height and center-crops the overflow.
+// A zero dimension takes the other dimension's scale.
+func Fill(img image.Image, width, height int) *image.RGBA {
+	b := img.Bounds()
+	if width <= 0 || height <= 0 {
+		w, h := scaleSize(b.Dx(), b.Dy(), width, height)
+		return Resize(img, w, h)
+	}
+
+	scale := math.Max(float64(width)/float64(b.Dx()), float64(height)/float64(b.Dy()))
+	w := max(width, int(math.Round(float64(b.Dx())*scale)))
+	h := max(height, int(math.Round(float64(b.Dy())*scale)))
+
+	scaled := Resize(img, w, h)
+	x := (w - width) / 2
+	y := (h - height) / 2
+	return Crop(scaled, image.Rect(x, y, x+width, y+height))
+}
+
+// Crop returns the part of img inside rect (relative to img's top-left corner)
+func Crop(img image.Image, rect image.Rectangle) *image.RGBA {
+	b := img.Bounds()
+	rect = rect.Add(b.Min).Intersect(b)
+
+	dst := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, rect.Min, draw.Src)
+	return dst
+}
+
+// fitSize returns the largest size inside the box that keeps the aspect ratio, never upscaling
+func fitSize(srcW, srcH, width, height int) (int, int) {
+	if width <= 0 && height <= 0 {
+		return srcW, srcH
+	}
+
+	scale := 1.0
+	if width > 0 {
+		scale = math.Min(scale, float64(width)/float64(srcW))
+	}
+	if height > 0 {
+		scale = math.Min(scale, float64(height)/float64(srcH))
+	}
+
+	return max(1, int(math.Round(float64(srcW)*scale))), max(1, int(math.Round(float64(srcH)*scale)))
+}
+
+// scaleSize fills in a zero dimension from the other one
+func scaleSize(srcW, srcH, width, height int) (int, int) {
+	switch {
+	case width <= 0 && height <= 0:
+		return srcW, srcH
+	case width <= 0:
+		return max(1, int(math.Round(float64(srcW)*float64(height)/float64(srcH)))), height
+	case height <= 0:
+		return width, max(1, int(math.Round(float64(srcH)*float64(width)/float64(srcW))))
+	}
+	return width, height
+}
+
+// toRGBA converts img to a zero-based premultiplied RGBA image
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+// contribution is one source pixel's weight for a destination pixel
+type contribution struct {
+	index  int
+	weight float64
+}
+
+// weights precomputes the tent filter contributions for each destination index
+func weights(srcSize, dstSize int) [][]contribution {
+	scale := float64(srcSize) / float64(dstSize)
+	support := math.Max(scale, 1)
+
+	result := make([][]contribution, dstSize)
+	for i := range result {
+		center := (float64(i)+0.5)*scale - 0.5
+		start := int(math.Floor(center - support))
+		end := int(math.Ceil(center + support))
+
+		var total float64
+		var list []contribution
+		for j := start; j <= end; j++ {
+			w := 1 - math.Abs(float64(j)-center)/support
+			if w <= 0 {
+				continue
+			}
+			idx := min(max(j, 0), srcSize-1)
+			list = append(list, contribution{index: idx, weight: w})
+			total += w
+		}
+		for k := range list {
+			list[k].weight /= total
+		}
+		result[i] = list
+	}
+	return result
+}
+
+func resampleHorizontal(src *image.RGBA, width int) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, b.Dy()))
+	table := weights(b.Dx(), width)
+
+	for y := 0; y < b.Dy(); y++ {
+		row := src.Pix[y*src.Stride:]
+		out := dst.Pix[y*dst.Stride:]
+		for x, list := range table {
+			var r, g, bl, a float64
+			for _, c := range list {
+				p := row[c.index*4:]
+				r += float64(p[0]) * c.weight
+				g += float64(p[1]) * c.weight
+				bl += float64(p[2]) * c.weight
+				a += float64(p[3]) * c.weight
+			}
+			writePixel(out[x*4:], r, g, bl, a)
+		}
+	}
+	return dst
+}
+
+func resampleVertical(src *image.RGBA, height int) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), height))
+	table := weights(b.Dy(), height)
+
+	for y, list := range table {
+		out := dst.Pix[y*dst.Stride:]
+		for x := 0; x < b.Dx(); x++ {
+			var r, g, bl, a float64
+			for _, c := range list {
+				p := src.Pix[c.index*src.Stride+x*4:]
+				r += float64(p[0]) * c.weight
+				g += float64(p[1]) * c.weight
+				bl += float64(p[2]) * c.weight
+				a += float64(p[3]) * c.weight
+			}
+			writePixel(out[x*4:], r, g, bl, a)
+		}
+	}
+	return dst
+}
+
+func writePixel(p []uint8, r, g, b, a float64) {
+	p[0] = clampUint8(r)
+	p[1] = clampUint8(g)
+	p[2] = clampUint8(b)
+	p[3] = clampUint8(a)
+}
+
+func clampUint8(v float64) uint8 {
+	return uint8(min(max(math.Round(v), 0), 255))
+}
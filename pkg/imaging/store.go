@@ -0,0 +1,44 @@
+package imaging
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormStore persists variant metadata with GORM
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore creates a GORM-backed store
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+// SaveVariants creates or replaces variants by source path and name
+func (s *GormStore) SaveVariants(ctx context.Context, variants []ImageVariant) error {
+	if len(variants) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "source_path"}, {Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"path", "width", "height", "size", "content_type", "updated_at"}),
+	}).Create(&variants).Error
+}
+
+// ListVariants returns the stored variants of a source image
+func (s *GormStore) ListVariants(ctx context.Context, sourcePath string) ([]ImageVariant, error) {
+	var variants []ImageVariant
+	err := s.db.WithContext(ctx).
+		Where("source_path = ?", sourcePath).
+		Order("name").
+		Find(&variants).Error
+	return variants, err
+}
+
+// DeleteVariants removes the metadata of a source image's variants
+func (s *GormStore) DeleteVariants(ctx context.Context, sourcePath string) error {
+	return s.db.WithContext(ctx).Where("source_path = ?", sourcePath).Delete(&ImageVariant{}).Error
+}
@@ -0,0 +1,101 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ParseVariants parses "name:WxH[:mode[:format[:quality]]]" definitions separated by commas,
+// e.g. "thumb:150x150:fill,medium:800x0:fit:jpeg:80"
+func ParseVariants(spec string) ([]Variant, error) {
+	var variants []Variant
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.Split(item, ":")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidVariant, item)
+		}
+
+		size := strings.SplitN(strings.ToLower(parts[1]), "x", 2)
+		if len(size) != 2 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidVariant, item)
+		}
+		width, errW := strconv.Atoi(size[0])
+		height, errH := strconv.Atoi(size[1])
+		if errW != nil || errH != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidVariant, item)
+		}
+
+		variant := Variant{Name: parts[0], Width: width, Height: height, Mode: ModeFit}
+		if len(parts) > 2 && parts[2] != "" {
+			variant.Mode = parts[2]
+		}
+		if len(parts) > 3 {
+			variant.Format = parts[3]
+		}
+		if len(parts) > 4 {
+			quality, err := strconv.Atoi(parts[4])
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidVariant, item)
+			}
+			variant.Quality = quality
+		}
+
+		if err := variant.Validate(); err != nil {
+			return nil, err
+		}
+		variants = append(variants, variant)
+	}
+	return variants, nil
+}
+
+// Validate checks the variant definition
+func (v Variant) Validate() error {
+	switch {
+	case v.Name == "" || strings.ContainsAny(v.Name, "/\\."):
+		return fmt.Errorf("%w: invalid name %q", ErrInvalidVariant, v.Name)
+	case v.Width < 0 || v.Height < 0 || (v.Width == 0 && v.Height == 0):
+		return fmt.Errorf("%w: %s needs a width or height", ErrInvalidVariant, v.Name)
+	case v.Mode != ModeFit && v.Mode != ModeFill:
+		return fmt.Errorf("%w: %s has unknown mode %q", ErrInvalidVariant, v.Name, v.Mode)
+	case v.Format != "" && v.Format != FormatJPEG && v.Format != FormatPNG:
+		return fmt.Errorf("%w: %s has unsupported format %q", ErrInvalidVariant, v.Name, v.Format)
+	case v.Quality < 0 || v.Quality > 100:
+		return fmt.Errorf("%w: %s quality must be 1-100", ErrInvalidVariant, v.Name)
+	}
+	return nil
+}
+
+// Apply resizes img according to the variant's mode
+func (v Variant) Apply(img image.Image) *image.RGBA {
+	if v.Mode == ModeFill {
+		return Fill(img, v.Width, v.Height)
+	}
+	return Fit(img, v.Width, v.Height)
+}
+
+// OutputFormat returns the variant's format, falling back to the decoded source format
+func (v Variant) OutputFormat(sourceFormat string) string {
+	if v.Format != "" {
+		return v.Format
+	}
+	if sourceFormat == FormatJPEG {
+		return FormatJPEG
+	}
+	return FormatPNG
+}
+
+// VariantPath returns where a variant of source is stored:
+// "avatars/abc.png" + "thumb" -> "avatars/abc_thumb.jpg"
+func VariantPath(source, name, format string) string {
+	dir, file := path.Split(source)
+	stem := strings.TrimSuffix(file, path.Ext(file))
+	return dir + stem + "_" + name + Extension(format)
+}
@@ -0,0 +1,133 @@
+package integrity
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"flex-service/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+// Dependent is a relation whose rows reference a parent entity and so block
+// deleting it
+type Dependent struct {
+	// Relation is the name reported to clients, e.g. "orders"
+	Relation string
+	// Model is the dependent entity, e.g. &entity.Order{}
+	Model interface{}
+	// ForeignKey is the column holding the parent's ID, e.g. "user_id"
+	ForeignKey string
+	// Scope adds conditions, e.g. the type column of a polymorphic relation
+	Scope func(db *gorm.DB) *gorm.DB
+}
+
+// Reference is a relation that still points at the entity being deleted
+type Reference struct {
+	Relation string `json:"relation"`
+	Count    int64  `json:"count"`
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[reflect.Type][]Dependent)
+)
+
+// Register declares relations that must be empty before a parent can be
+// deleted. Modules usually call it from init():
+//
+//	integrity.Register(&entity.User{},
+//		integrity.Dependent{Relation: "orders", Model: &entity.Order{}, ForeignKey: "user_id"},
+//	)
+func Register(parent interface{}, dependents ...Dependent) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	key := modelType(parent)
+	registry[key] = append(registry[key], dependents...)
+}
+
+// Dependents returns the relations registered for parent's type
+func Dependents(parent interface{}) []Dependent {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return append([]Dependent(nil), registry[modelType(parent)]...)
+}
+
+// References counts the rows of each relation registered for record's type
+// that point at its primary key. Only relations with at least one row are
+// returned, sorted by name. Soft-deleted rows are not counted.
+func References(ctx context.Context, db *gorm.DB, record interface{}) ([]Reference, error) {
+	dependents := Dependents(record)
+	if len(dependents) == 0 {
+		return nil, nil
+	}
+
+	id, err := primaryKey(ctx, db, record)
+	if err != nil {
+		return nil, err
+	}
+
+	var references []Reference
+	for _, dependent := range dependents {
+		tx := db.WithContext(ctx).Model(dependent.Model).Where(fmt.Sprintf("%s = ?", dependent.ForeignKey), id)
+		if dependent.Scope != nil {
+			tx = dependent.Scope(tx)
+		}
+
+		var count int64
+		if err := tx.Count(&count).Error; err != nil {
+			return nil, errors.WrapDatabase(err, fmt.Sprintf("Failed to count %s references", dependent.Relation))
+		}
+		if count > 0 {
+			references = append(references, Reference{Relation: dependent.Relation, Count: count})
+		}
+	}
+
+	sort.Slice(references, func(i, j int) bool { return references[i].Relation < references[j].Relation })
+	return references, nil
+}
+
+// CheckDelete returns a 409 DELETE_RESTRICTED error listing the relations that
+// still reference record, or nil when it can be deleted. Call it before the
+// delete so clients get the blocking references instead of a raw foreign key
+// constraint error.
+func CheckDelete(ctx context.Context, db *gorm.DB, record interface{}) error {
+	references, err := References(ctx, db, record)
+	if err != nil {
+		return err
+	}
+	if len(references) == 0 {
+		return nil
+	}
+
+	return errors.DeleteRestricted(map[string]interface{}{"references": references})
+}
+
+// primaryKey reads the primary key value of a loaded record
+func primaryKey(ctx context.Context, db *gorm.DB, record interface{}) (interface{}, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(record); err != nil {
+		return nil, fmt.Errorf("failed to parse %T: %w", record, err)
+	}
+	field := stmt.Schema.PrioritizedPrimaryField
+	if field == nil {
+		return nil, fmt.Errorf("%T has no primary key", record)
+	}
+
+	id, zero := field.ValueOf(ctx, reflect.Indirect(reflect.ValueOf(record)))
+	if zero {
+		return nil, fmt.Errorf("%T has no primary key value", record)
+	}
+	return id, nil
+}
+
+func modelType(model interface{}) reflect.Type {
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,60 @@
+package integrity
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"flex-service/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+// CheckUnique returns a 409 CONFLICT error naming the columns whose value in
+// record another row already has, or nil. Soft-deleted rows don't count, like
+// the unique indexes make:migration creates for "unique" fields, so a deleted
+// row never blocks re-creating it. The record itself is excluded by its
+// primary key when it has one, so updates can be checked too.
+func CheckUnique(ctx context.Context, db *gorm.DB, record interface{}, columns ...string) error {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(record); err != nil {
+		return fmt.Errorf("failed to parse %T: %w", record, err)
+	}
+	value := reflect.Indirect(reflect.ValueOf(record))
+
+	var taken []string
+	for _, column := range columns {
+		field := stmt.Schema.LookUpField(column)
+		if field == nil {
+			return fmt.Errorf("%T has no column %s", record, column)
+		}
+		fieldValue, zero := field.ValueOf(ctx, value)
+		if zero && field.FieldType.Kind() == reflect.Ptr {
+			continue
+		}
+
+		// A fresh model, so GORM doesn't add the record's own primary key
+		tx := db.WithContext(ctx).Model(reflect.New(stmt.Schema.ModelType).Interface()).
+			Where(fmt.Sprintf("%s = ?", field.DBName), fieldValue)
+		if pk := stmt.Schema.PrioritizedPrimaryField; pk != nil {
+			if id, zero := pk.ValueOf(ctx, value); !zero {
+				tx = tx.Where(fmt.Sprintf("%s <> ?", pk.DBName), id)
+			}
+		}
+
+		var count int64
+		if err := tx.Count(&count).Error; err != nil {
+			return errors.WrapDatabase(err, fmt.Sprintf("Failed to check %s", field.DBName))
+		}
+		if count > 0 {
+			taken = append(taken, field.DBName)
+		}
+	}
+	if len(taken) == 0 {
+		return nil
+	}
+
+	return errors.Conflict(fmt.Sprintf("%s already exists", strings.Join(taken, ", "))).
+		WithDetails(map[string]interface{}{"fields": taken})
+}
@@ -0,0 +1,88 @@
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// Listener errors
+var (
+	ErrNoSystemdListeners = errors.New("no systemd socket-activated listeners (LISTEN_FDS not set for this process)")
+	ErrSystemdNameMissing = errors.New("systemd listener with the requested name not found")
+	ErrInvalidSocketMode  = errors.New("invalid unix socket mode")
+	ErrSocketInUse        = errors.New("unix socket is already in use")
+)
+
+// Config selects where the server listens. Systemd activation wins over Socket, which wins over Address.
+type Config struct {
+	Address     string // TCP host:port
+	Socket      string // Unix domain socket path
+	SocketMode  string // Octal permissions for Socket, e.g. "0660"
+	Systemd     bool   // Inherit a socket passed by systemd (LISTEN_FDS)
+	SystemdName string // FileDescriptorName= of the socket to use; empty = the first one
+}
+
+// Listen opens the listener described by cfg
+func Listen(cfg Config) (net.Listener, error) {
+	switch {
+	case cfg.Systemd:
+		return systemdListener(cfg.SystemdName)
+	case cfg.Socket != "":
+		return ListenUnix(cfg.Socket, cfg.SocketMode)
+	default:
+		return net.Listen("tcp", cfg.Address)
+	}
+}
+
+// Describe returns a human-readable address for logs
+func (c Config) Describe() string {
+	switch {
+	case c.Systemd && c.SystemdName != "":
+		return "systemd:" + c.SystemdName
+	case c.Systemd:
+		return "systemd"
+	case c.Socket != "":
+		return "unix:" + c.Socket
+	}
+	return c.Address
+}
+
+// ListenUnix listens on a Unix domain socket, replacing a stale socket file left by
+// a previous run and applying mode (octal, empty keeps the umask default)
+func ListenUnix(path, mode string) (net.Listener, error) {
+	var perm os.FileMode
+	if mode != "" {
+		parsed, err := strconv.ParseUint(mode, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidSocketMode, mode)
+		}
+		perm = os.FileMode(parsed)
+	}
+
+	// Only remove sockets nobody is accepting on; a live one means another instance is running
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%w: %s", ErrSocketInUse, path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if perm != 0 {
+		if err := os.Chmod(path, perm); err != nil {
+			ln.Close()
+			return nil, fmt.Errorf("failed to chmod socket %s: %w", path, err)
+		}
+	}
+	return ln, nil
+}
@@ -0,0 +1,88 @@
+package listener
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd (SD_LISTEN_FDS_START)
+const listenFDsStart = 3
+
+// Activated is a socket passed in by systemd
+type Activated struct {
+	Name     string // FileDescriptorName= of the socket unit, or "fd<N>" when unnamed
+	Listener net.Listener
+}
+
+// SystemdListeners returns the sockets passed by systemd socket activation in fd order.
+// The LISTEN_* variables are unset so child processes don't inherit them.
+func SystemdListeners() ([]Activated, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, ErrNoSystemdListeners
+	}
+
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, ErrNoSystemdListeners
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	activated := make([]Activated, 0, count)
+	for i := 0; i < count; i++ {
+		fd := listenFDsStart + i
+
+		name := fmt.Sprintf("fd%d", fd)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+
+		file := os.NewFile(uintptr(fd), name)
+		ln, err := net.FileListener(file)
+		file.Close() // FileListener dups the descriptor
+		if err != nil {
+			closeActivated(activated)
+			return nil, fmt.Errorf("systemd fd %d (%s) is not a listening socket: %w", fd, name, err)
+		}
+		activated = append(activated, Activated{Name: name, Listener: ln})
+	}
+
+	return activated, nil
+}
+
+// systemdListener picks one activated socket by name, or the first when name is empty,
+// and closes the rest
+func systemdListener(name string) (net.Listener, error) {
+	activated, err := SystemdListeners()
+	if err != nil {
+		return nil, err
+	}
+
+	var selected net.Listener
+	for _, a := range activated {
+		if selected == nil && (name == "" || a.Name == name) {
+			selected = a.Listener
+			continue
+		}
+		a.Listener.Close()
+	}
+
+	if selected == nil {
+		return nil, fmt.Errorf("%w: %s", ErrSystemdNameMissing, name)
+	}
+	return selected, nil
+}
+
+func closeActivated(activated []Activated) {
+	for _, a := range activated {
+		a.Listener.Close()
+	}
+}
@@ -0,0 +1,288 @@
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment passed from the old process to the new one during an upgrade
+const (
+	envUpgradeFDNames = "FLEX_UPGRADE_FDNAMES" // listener names, in fd order from 3
+	envUpgradeReadyFD = "FLEX_UPGRADE_READY_FD"
+)
+
+// Upgrade errors
+var (
+	ErrUpgradeInProgress = errors.New("upgrade already in progress")
+	ErrUpgradeTimeout    = errors.New("new process did not become ready in time")
+	ErrUpgradeFailed     = errors.New("new process exited before becoming ready")
+	ErrNotFileListener   = errors.New("listener cannot be passed to another process")
+)
+
+// fileListener is implemented by *net.TCPListener and *net.UnixListener
+type fileListener interface {
+	File() (*os.File, error)
+}
+
+// UpgradeConfig configures an Upgrader
+type UpgradeConfig struct {
+	PIDFile      string        // Rewritten by the process that is currently serving
+	ReadyTimeout time.Duration // How long the new process has to call Ready (default 30s)
+}
+
+// Upgrader hands listening sockets to a freshly started copy of the binary so it can
+// take over without refusing connections, while the old process drains and exits:
+//
+//	ln, _ := upgrader.Listen("http", cfg.Server.Listener())
+//	go server.Serve(ln)
+//	upgrader.Ready()
+//	// on SIGHUP: upgrader.Upgrade()
+//	<-upgrader.Exit() // new process is serving; shut down gracefully
+type Upgrader struct {
+	config UpgradeConfig
+
+	mu        sync.Mutex
+	inherited map[string]net.Listener
+	active    map[string]net.Listener
+	order     []string
+	upgrading bool
+	exit      chan struct{}
+	exitOnce  sync.Once
+}
+
+// NewUpgrader creates an upgrader, picking up listeners passed by a parent process
+func NewUpgrader(cfg UpgradeConfig) (*Upgrader, error) {
+	if cfg.ReadyTimeout <= 0 {
+		cfg.ReadyTimeout = 30 * time.Second
+	}
+
+	u := &Upgrader{
+		config:    cfg,
+		inherited: make(map[string]net.Listener),
+		active:    make(map[string]net.Listener),
+		exit:      make(chan struct{}),
+	}
+
+	names := os.Getenv(envUpgradeFDNames)
+	if names == "" {
+		return u, nil
+	}
+	os.Unsetenv(envUpgradeFDNames)
+
+	for i, name := range strings.Split(names, ":") {
+		fd := listenFDsStart + i
+		file := os.NewFile(uintptr(fd), name)
+		ln, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			u.closeInherited()
+			return nil, fmt.Errorf("inherited fd %d (%s) is not a listening socket: %w", fd, name, err)
+		}
+		u.inherited[name] = ln
+	}
+
+	return u, nil
+}
+
+// HasParent reports whether this process was started by an upgrade
+func (u *Upgrader) HasParent() bool {
+	return os.Getenv(envUpgradeReadyFD) != ""
+}
+
+// Listen returns the listener inherited under name, or opens a new one from cfg.
+// The listener is passed on to the next process on Upgrade.
+func (u *Upgrader) Listen(name string, cfg Config) (net.Listener, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	ln, ok := u.inherited[name]
+	if ok {
+		delete(u.inherited, name)
+	} else {
+		var err error
+		if ln, err = Listen(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, exists := u.active[name]; !exists {
+		u.order = append(u.order, name)
+	}
+	u.active[name] = ln
+	return ln, nil
+}
+
+// Ready closes inherited listeners that weren't claimed, writes the PID file and,
+// when started by an upgrade, tells the old process it can stop serving
+func (u *Upgrader) Ready() error {
+	u.mu.Lock()
+	u.closeInherited()
+	u.mu.Unlock()
+
+	if u.config.PIDFile != "" {
+		if err := writePIDFile(u.config.PIDFile); err != nil {
+			return err
+		}
+	}
+
+	value := os.Getenv(envUpgradeReadyFD)
+	if value == "" {
+		return nil
+	}
+	os.Unsetenv(envUpgradeReadyFD)
+
+	fd, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", envUpgradeReadyFD, err)
+	}
+
+	pipe := os.NewFile(uintptr(fd), "upgrade-ready")
+	defer pipe.Close()
+	_, err = pipe.Write([]byte{1})
+	return err
+}
+
+// Upgrade starts a new copy of the executable with the current listeners and waits
+// for it to call Ready. On success Exit is closed; on failure this process keeps serving.
+func (u *Upgrader) Upgrade() error {
+	u.mu.Lock()
+	if u.upgrading {
+		u.mu.Unlock()
+		return ErrUpgradeInProgress
+	}
+	u.upgrading = true
+	u.mu.Unlock()
+
+	defer func() {
+		u.mu.Lock()
+		u.upgrading = false
+		u.mu.Unlock()
+	}()
+
+	files, names, err := u.files()
+	if err != nil {
+		return err
+	}
+	defer closeFiles(files)
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer readyR.Close()
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = append(files, readyW)
+	cmd.Env = append(os.Environ(),
+		envUpgradeFDNames+"="+strings.Join(names, ":"),
+		envUpgradeReadyFD+"="+strconv.Itoa(listenFDsStart+len(files)),
+	)
+
+	if err := cmd.Start(); err != nil {
+		readyW.Close()
+		return fmt.Errorf("failed to start new process: %w", err)
+	}
+	readyW.Close() // Only the child holds the write end now
+
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		if _, err := readyR.Read(buf); err != nil {
+			ready <- ErrUpgradeFailed // EOF: the child exited or closed the pipe without writing
+			return
+		}
+		ready <- nil
+	}()
+
+	select {
+	case err := <-ready:
+		if err != nil {
+			cmd.Wait()
+			return err
+		}
+	case <-time.After(u.config.ReadyTimeout):
+		cmd.Process.Kill()
+		cmd.Wait()
+		return ErrUpgradeTimeout
+	}
+
+	// The child owns the sockets now; don't remove Unix socket files on shutdown
+	u.mu.Lock()
+	for _, ln := range u.active {
+		if unix, ok := ln.(*net.UnixListener); ok {
+			unix.SetUnlinkOnClose(false)
+		}
+	}
+	u.mu.Unlock()
+
+	// Release the child; it is reparented once this process exits
+	cmd.Process.Release()
+
+	u.exitOnce.Do(func() { close(u.exit) })
+	return nil
+}
+
+// Exit is closed once a new process has taken over the listeners
+func (u *Upgrader) Exit() <-chan struct{} {
+	return u.exit
+}
+
+// files duplicates the active listeners' descriptors in registration order
+func (u *Upgrader) files() ([]*os.File, []string, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	files := make([]*os.File, 0, len(u.order))
+	for _, name := range u.order {
+		fl, ok := u.active[name].(fileListener)
+		if !ok {
+			closeFiles(files)
+			return nil, nil, fmt.Errorf("%w: %s", ErrNotFileListener, name)
+		}
+		file, err := fl.File()
+		if err != nil {
+			closeFiles(files)
+			return nil, nil, fmt.Errorf("failed to duplicate listener %s: %w", name, err)
+		}
+		files = append(files, file)
+	}
+	return files, append([]string(nil), u.order...), nil
+}
+
+// closeInherited closes inherited listeners nobody asked for; callers hold u.mu
+func (u *Upgrader) closeInherited() {
+	for name, ln := range u.inherited {
+		ln.Close()
+		delete(u.inherited, name)
+	}
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+// writePIDFile atomically replaces the PID file so supervisors track the new process
+func writePIDFile(path string) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
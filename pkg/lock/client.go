@@ -0,0 +1,16 @@
+package lock
+
+import (
+	"flex-service/config"
+	"flex-service/pkg/cache"
+)
+
+// NewLocker creates a new Redis-backed locker from configuration
+func NewLocker(cfg *config.RedisConfig, lockConfig *LockConfig) (Locker, error) {
+	client, err := cache.NewRedisClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRedisLocker(client, lockConfig), nil
+}
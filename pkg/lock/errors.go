@@ -0,0 +1,15 @@
+package lock
+
+import "errors"
+
+// Lock-related errors
+var (
+	// ErrLockHeld indicates that the lock is owned by another holder
+	ErrLockHeld = errors.New("lock is held by another process")
+
+	// ErrLockNotHeld indicates that the lock expired or is owned by someone else
+	ErrLockNotHeld = errors.New("lock is not held")
+
+	// ErrInvalidKey indicates that the lock key is empty
+	ErrInvalidKey = errors.New("invalid lock key")
+)
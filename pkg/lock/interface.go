@@ -0,0 +1,55 @@
+package lock
+
+import (
+	"context"
+	"time"
+)
+
+// Locker defines the interface for distributed locks
+type Locker interface {
+	// Acquire obtains the lock for key or returns ErrLockHeld when another holder owns it
+	Acquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error)
+
+	// Extend resets the TTL of a lock that is still owned by the caller
+	Extend(ctx context.Context, lock *Lock, ttl time.Duration) error
+
+	// Release releases a lock that is still owned by the caller
+	Release(ctx context.Context, lock *Lock) error
+
+	// Holder returns information about the current holder of key
+	Holder(ctx context.Context, key string) (*Holder, error)
+
+	// Close closes the underlying connection
+	Close() error
+}
+
+// Holder describes the process that currently owns a lock
+type Holder struct {
+	Token      string    `json:"token"`
+	Host       string    `json:"host"`
+	PID        int       `json:"pid"`
+	Command    string    `json:"command,omitempty"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+// Lock represents an acquired lock
+type Lock struct {
+	Key    string
+	Holder Holder
+
+	value string // serialized holder used for compare-and-delete
+}
+
+// LockConfig holds lock configuration
+type LockConfig struct {
+	KeyPrefix  string
+	DefaultTTL time.Duration
+}
+
+// DefaultLockConfig returns default lock configuration
+func DefaultLockConfig() *LockConfig {
+	return &LockConfig{
+		KeyPrefix:  "flex-service:lock:",
+		DefaultTTL: 10 * time.Minute,
+	}
+}
@@ -0,0 +1,148 @@
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"flex-service/pkg/utils"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// releaseScript deletes the key only if it still holds our value
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// extendScript resets the TTL only if the key still holds our value
+var extendScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// RedisLocker implements Locker interface using Redis SET NX
+type RedisLocker struct {
+	client *redis.Client
+	config *LockConfig
+}
+
+// NewRedisLocker creates a new Redis-backed locker
+func NewRedisLocker(client *redis.Client, config *LockConfig) Locker {
+	if config == nil {
+		config = DefaultLockConfig()
+	}
+	return &RedisLocker{
+		client: client,
+		config: config,
+	}
+}
+
+// Acquire obtains the lock for key
+func (r *RedisLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	if key == "" {
+		return nil, ErrInvalidKey
+	}
+	if ttl <= 0 {
+		ttl = r.config.DefaultTTL
+	}
+
+	token, err := utils.GenerateRandomString(16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate lock token: %w", err)
+	}
+
+	host, _ := os.Hostname()
+	holder := Holder{
+		Token:      token,
+		Host:       host,
+		PID:        os.Getpid(),
+		Command:    strings.Join(os.Args, " "),
+		AcquiredAt: time.Now().UTC(),
+	}
+
+	value, err := json.Marshal(holder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal lock holder: %w", err)
+	}
+
+	ok, err := r.client.SetNX(ctx, r.buildKey(key), value, ttl).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", key, err)
+	}
+	if !ok {
+		return nil, ErrLockHeld
+	}
+
+	return &Lock{
+		Key:    key,
+		Holder: holder,
+		value:  string(value),
+	}, nil
+}
+
+// Extend resets the TTL of a lock that is still owned by the caller
+func (r *RedisLocker) Extend(ctx context.Context, lock *Lock, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = r.config.DefaultTTL
+	}
+
+	result, err := extendScript.Run(ctx, r.client, []string{r.buildKey(lock.Key)}, lock.value, ttl.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("failed to extend lock %s: %w", lock.Key, err)
+	}
+	if result == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// Release releases a lock that is still owned by the caller
+func (r *RedisLocker) Release(ctx context.Context, lock *Lock) error {
+	result, err := releaseScript.Run(ctx, r.client, []string{r.buildKey(lock.Key)}, lock.value).Int()
+	if err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", lock.Key, err)
+	}
+	if result == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// Holder returns information about the current holder of key
+func (r *RedisLocker) Holder(ctx context.Context, key string) (*Holder, error) {
+	value, err := r.client.Get(ctx, r.buildKey(key)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrLockNotHeld
+		}
+		return nil, fmt.Errorf("failed to get lock holder %s: %w", key, err)
+	}
+
+	var holder Holder
+	if err := json.Unmarshal([]byte(value), &holder); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal lock holder %s: %w", key, err)
+	}
+	return &holder, nil
+}
+
+// Close closes the Redis connection
+func (r *RedisLocker) Close() error {
+	return r.client.Close()
+}
+
+// buildKey creates a full key with prefix
+func (r *RedisLocker) buildKey(key string) string {
+	if r.config.KeyPrefix == "" {
+		return key
+	}
+	return r.config.KeyPrefix + key
+}
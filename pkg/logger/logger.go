@@ -4,13 +4,18 @@ import (
 	"context"
 	"os"
 
+	"flex-service/pkg/requestctx"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 var Logger *zap.Logger
 
-func Init(level, format string) error {
+// level is shared by every logger built by Init, so SetLevel applies at once
+var level = zap.NewAtomicLevelAt(zap.InfoLevel)
+
+func Init(levelName, format string) error {
 	var config zap.Config
 
 	switch format {
@@ -22,18 +27,8 @@ func Init(level, format string) error {
 	}
 
 	// Set log level
-	switch level {
-	case "debug":
-		config.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
-	case "info":
-		config.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
-	case "warn":
-		config.Level = zap.NewAtomicLevelAt(zap.WarnLevel)
-	case "error":
-		config.Level = zap.NewAtomicLevelAt(zap.ErrorLevel)
-	default:
-		config.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
-	}
+	SetLevel(levelName)
+	config.Level = level
 
 	// Set output paths
 	config.OutputPaths = []string{"stdout"}
@@ -48,6 +43,20 @@ func Init(level, format string) error {
 	return nil
 }
 
+// SetLevel changes the level of the running logger; unknown names mean info
+func SetLevel(name string) {
+	switch name {
+	case "debug":
+		level.SetLevel(zap.DebugLevel)
+	case "warn":
+		level.SetLevel(zap.WarnLevel)
+	case "error":
+		level.SetLevel(zap.ErrorLevel)
+	default:
+		level.SetLevel(zap.InfoLevel)
+	}
+}
+
 func Info(msg string, fields ...zap.Field) {
 	Logger.Info(msg, fields...)
 }
@@ -101,33 +110,27 @@ func WithContext(ctx context.Context) *zap.Logger {
 		fields = append(fields, zap.String(FieldRequestID, requestID))
 	}
 
-	if traceID := getTraceIDFromContext(ctx); traceID != "" {
+	traceID, spanID := traceFromContext(ctx)
+	if traceID != "" {
 		fields = append(fields, zap.String(FieldTraceID, traceID))
 	}
+	if spanID != "" {
+		fields = append(fields, zap.String(FieldSpanID, spanID))
+	}
 
 	return Logger.With(fields...)
 }
 
 // Helper functions to extract values from context
 func getUserIDFromContext(ctx context.Context) string {
-	if userID, ok := ctx.Value("user_id").(string); ok {
-		return userID
+	if user, ok := requestctx.UserFrom(ctx); ok {
+		return user.Subject
 	}
 	return ""
 }
 
 func getRequestIDFromContext(ctx context.Context) string {
-	if requestID, ok := ctx.Value("request_id").(string); ok {
-		return requestID
-	}
-	return ""
-}
-
-func getTraceIDFromContext(ctx context.Context) string {
-	if traceID, ok := ctx.Value("trace_id").(string); ok {
-		return traceID
-	}
-	return ""
+	return requestctx.RequestID(ctx)
 }
 
 // Structured logging helpers for common patterns
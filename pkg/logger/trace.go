@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"flex-service/pkg/requestctx"
+)
+
+// FieldSpanID is the span ID field; FieldTraceID is the trace ID. The names match
+// the OpenTelemetry log data model, so Loki/Tempo derived fields find them.
+const FieldSpanID = "span_id"
+
+// TraceparentHeader is the W3C Trace Context header
+const TraceparentHeader = "traceparent"
+
+// TraceExtractor returns the trace and span IDs of a context as lowercase hex
+type TraceExtractor func(ctx context.Context) (traceID, spanID string)
+
+var (
+	traceMu        sync.RWMutex
+	traceExtractor TraceExtractor = RequestTrace
+)
+
+// SetTraceExtractor changes where WithContext reads trace and span IDs. The
+// default, RequestTrace, reads the IDs set by middleware.Tracing. With the
+// OpenTelemetry SDK, read the active span instead:
+//
+//	logger.SetTraceExtractor(func(ctx context.Context) (string, string) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		if !sc.IsValid() {
+//			return logger.RequestTrace(ctx)
+//		}
+//		return sc.TraceID().String(), sc.SpanID().String()
+//	})
+func SetTraceExtractor(extractor TraceExtractor) {
+	if extractor == nil {
+		extractor = RequestTrace
+	}
+	traceMu.Lock()
+	traceExtractor = extractor
+	traceMu.Unlock()
+}
+
+// RequestTrace reads the trace and span IDs stored in requestctx
+func RequestTrace(ctx context.Context) (string, string) {
+	return requestctx.TraceID(ctx), requestctx.SpanID(ctx)
+}
+
+// Trace returns the trace and span IDs of ctx from the configured extractor,
+// e.g. to propagate them on outbound requests
+func Trace(ctx context.Context) (string, string) {
+	return traceFromContext(ctx)
+}
+
+func traceFromContext(ctx context.Context) (string, string) {
+	traceMu.RLock()
+	extractor := traceExtractor
+	traceMu.RUnlock()
+	return extractor(ctx)
+}
+
+// NewTraceID returns a random 16-byte trace ID as 32 hex characters
+func NewTraceID() string {
+	return randomHex(16)
+}
+
+// NewSpanID returns a random 8-byte span ID as 16 hex characters
+func NewSpanID() string {
+	return randomHex(8)
+}
+
+// ParseTraceparent reads a W3C traceparent header
+// ("00-<trace-id>-<parent-id>-<flags>"). All-zero IDs and malformed values are
+// rejected.
+func ParseTraceparent(header string) (traceID, parentID string, sampled bool, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return "", "", false, false
+	}
+	// Version 00 has exactly four parts; later versions may append more
+	if parts[0] == "00" && len(parts) != 4 {
+		return "", "", false, false
+	}
+
+	traceID, parentID, flags := parts[1], parts[2], parts[3]
+	if !isHexID(traceID, 32) || !isHexID(parentID, 16) || !isHex(flags) || len(flags) != 2 {
+		return "", "", false, false
+	}
+
+	b, _ := hex.DecodeString(flags)
+	return traceID, parentID, b[0]&0x01 == 1, true
+}
+
+// FormatTraceparent builds a version 00 W3C traceparent header
+func FormatTraceparent(traceID, spanID string, sampled bool) string {
+	flags := "00"
+	if sampled {
+		flags = "01"
+	}
+	return "00-" + traceID + "-" + spanID + "-" + flags
+}
+
+// isHexID reports whether s is n lowercase hex characters and not all zeros
+func isHexID(s string, n int) bool {
+	return len(s) == n && isHex(s) && strings.Trim(s, "0") != ""
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return strings.Repeat("0", n*2)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,184 @@
+package maintenance
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"flex-service/pkg/logger"
+	"flex-service/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// BypassHeader and BypassCookie carry the bypass secret. A request with the
+// secret in the BypassCookie query parameter gets the cookie, so a browser
+// can keep using the app.
+const (
+	BypassHeader = "X-Maintenance-Bypass"
+	BypassCookie = "maintenance_bypass"
+)
+
+// DefaultMessage is returned while down when State.Message is empty
+const DefaultMessage = "Service is down for maintenance"
+
+// State describes a maintenance window, stored by artisan down
+type State struct {
+	Message string `json:"message,omitempty"`
+	// RetryAfter is sent as the Retry-After header, in seconds; 0 omits it
+	RetryAfter int `json:"retry_after,omitempty"`
+	// SecretHash is the SHA-256 of the bypass secret; the secret itself is
+	// only shown by artisan down
+	SecretHash string `json:"secret_hash,omitempty"`
+	// AllowedIPs are IPs and CIDRs that keep full access
+	AllowedIPs []string  `json:"allowed_ips,omitempty"`
+	Since      time.Time `json:"since"`
+}
+
+// HashSecret returns the SecretHash of a bypass secret
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Store persists the maintenance state
+type Store interface {
+	// Get returns the current state, or nil when the app is up
+	Get(ctx context.Context) (*State, error)
+	// Down stores state, taking the app down
+	Down(ctx context.Context, state *State) error
+	// Up removes the state
+	Up(ctx context.Context) error
+}
+
+// Mode answers requests with 503 while the store holds a state. The state is
+// read at most once per refresh interval, so every instance follows artisan
+// down and up within it without a store round trip per request.
+type Mode struct {
+	store   Store
+	refresh time.Duration
+
+	mu      sync.Mutex
+	state   *State
+	allowed []*net.IPNet
+	checked time.Time
+}
+
+// New creates a maintenance mode reading store every refresh
+func New(store Store, refresh time.Duration) *Mode {
+	if refresh <= 0 {
+		refresh = 2 * time.Second
+	}
+	return &Mode{store: store, refresh: refresh}
+}
+
+// Current returns the state and its allowed networks, or nil when the app is
+// up. When the store fails the last known state is kept.
+func (m *Mode) Current(ctx context.Context) (*State, []*net.IPNet) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if time.Since(m.checked) < m.refresh {
+		return m.state, m.allowed
+	}
+	m.checked = time.Now()
+
+	state, err := m.store.Get(ctx)
+	if err != nil {
+		logger.Warn("Failed to read maintenance state", zap.Error(err))
+		return m.state, m.allowed
+	}
+	if (state == nil) != (m.state == nil) {
+		logger.Info("Maintenance mode changed", zap.Bool("down", state != nil))
+	}
+	m.state, m.allowed = state, nil
+	if state != nil {
+		m.allowed = parseNetworks(state.AllowedIPs)
+	}
+	return m.state, m.allowed
+}
+
+// Middleware returns 503 with Retry-After while the app is down, except for
+// allowed IPs (c.ClientIP, so configure trusted proxies) and requests with the
+// bypass secret
+func (m *Mode) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state, allowed := m.Current(c.Request.Context())
+		if state == nil || allowedIP(allowed, c.ClientIP()) || m.bypass(c, state) {
+			c.Next()
+			return
+		}
+
+		if state.RetryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(state.RetryAfter))
+		}
+		message := state.Message
+		if message == "" {
+			message = DefaultMessage
+		}
+		response.Error(c, http.StatusServiceUnavailable, "MAINTENANCE", message, nil)
+		c.Abort()
+	}
+}
+
+// bypass reports whether the request carries the secret, setting the bypass
+// cookie when it came in the query
+func (m *Mode) bypass(c *gin.Context, state *State) bool {
+	if state.SecretHash == "" {
+		return false
+	}
+
+	if secret := c.Query(BypassCookie); secret != "" && matches(state, secret) {
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(BypassCookie, secret, int((12 * time.Hour).Seconds()), "/", "", c.Request.TLS != nil, true)
+		return true
+	}
+	if matches(state, c.GetHeader(BypassHeader)) {
+		return true
+	}
+	cookie, err := c.Cookie(BypassCookie)
+	return err == nil && matches(state, cookie)
+}
+
+func matches(state *State, secret string) bool {
+	return secret != "" && subtle.ConstantTimeCompare([]byte(HashSecret(secret)), []byte(state.SecretHash)) == 1
+}
+
+// parseNetworks parses IPs and CIDRs, skipping invalid entries
+func parseNetworks(entries []string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, entry := range entries {
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
+func allowedIP(networks []*net.IPNet, clientIP string) bool {
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// NewStore returns the store for driver: redis, which needs client, or file
+func NewStore(driver, file string, client *redis.Client) (Store, error) {
+	switch driver {
+	case "redis":
+		if client == nil {
+			return nil, fmt.Errorf("MAINTENANCE_DRIVER=redis requires Redis")
+		}
+		return NewRedisStore(client, ""), nil
+	case "file":
+		return NewFileStore(file), nil
+	default:
+		return nil, fmt.Errorf("unsupported MAINTENANCE_DRIVER %q", driver)
+	}
+}
+
+// RedisStore keeps the state as JSON under one key, shared by every instance
+type RedisStore struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisStore creates a Redis store; key defaults to "maintenance"
+func NewRedisStore(client *redis.Client, key string) *RedisStore {
+	if key == "" {
+		key = "maintenance"
+	}
+	return &RedisStore{client: client, key: key}
+}
+
+// Get reads the state
+func (r *RedisStore) Get(ctx context.Context) (*State, error) {
+	data, err := r.client.Get(ctx, r.key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read maintenance state: %w", err)
+	}
+	return decode(data)
+}
+
+// Down stores the state without expiry
+func (r *RedisStore) Down(ctx context.Context, state *State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := r.client.Set(ctx, r.key, data, 0).Err(); err != nil {
+		return fmt.Errorf("failed to store maintenance state: %w", err)
+	}
+	return nil
+}
+
+// Up deletes the state
+func (r *RedisStore) Up(ctx context.Context) error {
+	if err := r.client.Del(ctx, r.key).Err(); err != nil {
+		return fmt.Errorf("failed to remove maintenance state: %w", err)
+	}
+	return nil
+}
+
+// FileStore keeps the state in a JSON file, for a single host or a shared
+// volume
+type FileStore struct {
+	path string
+}
+
+// NewFileStore creates a file store at path
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Get reads the file; a missing file means the app is up
+func (f *FileStore) Get(ctx context.Context) (*State, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read maintenance file: %w", err)
+	}
+	return decode(data)
+}
+
+// Down writes the file, replacing it atomically
+func (f *FileStore) Down(ctx context.Context, state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return fmt.Errorf("failed to create maintenance directory: %w", err)
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write maintenance file: %w", err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return fmt.Errorf("failed to write maintenance file: %w", err)
+	}
+	return nil
+}
+
+// Up removes the file
+func (f *FileStore) Up(ctx context.Context) error {
+	if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove maintenance file: %w", err)
+	}
+	return nil
+}
+
+func decode(data []byte) (*State, error) {
+	state := &State{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to decode maintenance state: %w", err)
+	}
+	return state, nil
+}
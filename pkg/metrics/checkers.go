@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"flex-service/pkg/cache"
+
+	"gorm.io/gorm"
+)
+
+// HealthCheckFunc adapts a function returning an error to a HealthChecker
+type HealthCheckFunc struct {
+	CheckName string
+	Fn        func(ctx context.Context) error
+}
+
+// Name returns the check name
+func (f HealthCheckFunc) Name() string {
+	return f.CheckName
+}
+
+// Check is healthy when Fn returns nil
+func (f HealthCheckFunc) Check(ctx context.Context) CheckResult {
+	if err := f.Fn(ctx); err != nil {
+		return Unhealthy(err)
+	}
+	return Healthy(nil)
+}
+
+// DatabaseHealthChecker pings the database and reports its connection pool
+type DatabaseHealthChecker struct {
+	DB *gorm.DB
+}
+
+// Name returns "database"
+func (c DatabaseHealthChecker) Name() string {
+	return "database"
+}
+
+// Check pings the database
+func (c DatabaseHealthChecker) Check(ctx context.Context) CheckResult {
+	sqlDB, err := c.DB.DB()
+	if err != nil {
+		return Unhealthy(err)
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return Unhealthy(err)
+	}
+
+	stats := sqlDB.Stats()
+	return Healthy(map[string]interface{}{
+		"open_connections": stats.OpenConnections,
+		"in_use":           stats.InUse,
+		"idle":             stats.Idle,
+		"wait_count":       stats.WaitCount,
+	})
+}
+
+// CacheHealthChecker pings the cache
+type CacheHealthChecker struct {
+	Cache cache.Cache
+}
+
+// Name returns "cache"
+func (c CacheHealthChecker) Name() string {
+	return "cache"
+}
+
+// Check pings the cache
+func (c CacheHealthChecker) Check(ctx context.Context) CheckResult {
+	if err := c.Cache.Ping(ctx); err != nil {
+		return Unhealthy(err)
+	}
+	return Healthy(nil)
+}
+
+// DiskSpaceHealthChecker reports the filesystem holding Path as degraded once
+// its usage reaches WarningPercent and unhealthy at CriticalPercent. A zero
+// threshold is disabled.
+type DiskSpaceHealthChecker struct {
+	Path            string
+	WarningPercent  float64
+	CriticalPercent float64
+}
+
+// Name returns "disk"
+func (c DiskSpaceHealthChecker) Name() string {
+	return "disk"
+}
+
+// Check compares the disk usage with the thresholds
+func (c DiskSpaceHealthChecker) Check(ctx context.Context) CheckResult {
+	path := c.Path
+	if path == "" {
+		path = "."
+	}
+	stats, err := DiskUsage(path)
+	if err != nil {
+		return Unhealthy(fmt.Errorf("disk usage of %s: %w", path, err))
+	}
+
+	result := Healthy(map[string]interface{}{
+		"path":         stats.Path,
+		"total_bytes":  stats.TotalBytes,
+		"free_bytes":   stats.FreeBytes,
+		"used_percent": math.Round(stats.UsedPercent*10) / 10,
+	})
+	switch {
+	case c.CriticalPercent > 0 && stats.UsedPercent >= c.CriticalPercent:
+		result.Status = StatusUnhealthy
+		result.Message = fmt.Sprintf("disk usage %.1f%% reached critical threshold %.0f%%", stats.UsedPercent, c.CriticalPercent)
+	case c.WarningPercent > 0 && stats.UsedPercent >= c.WarningPercent:
+		result.Status = StatusDegraded
+		result.Message = fmt.Sprintf("disk usage %.1f%% reached warning threshold %.0f%%", stats.UsedPercent, c.WarningPercent)
+	}
+	return result
+}
@@ -0,0 +1,7 @@
+//go:build !unix
+
+package metrics
+
+func diskUsage(path string) (DiskStats, error) {
+	return DiskStats{}, ErrNotSupported
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package metrics
+
+import "syscall"
+
+func diskUsage(path string) (DiskStats, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return DiskStats{}, err
+	}
+
+	// Field types differ between platforms
+	blockSize := uint64(st.Bsize)
+	total := uint64(st.Blocks) * blockSize
+	free := uint64(st.Bavail) * blockSize
+	used := total - uint64(st.Bfree)*blockSize
+
+	stats := DiskStats{Path: path, TotalBytes: total, FreeBytes: free, UsedBytes: used}
+	// Like df: used out of what users can use, so reserved blocks count as full
+	if used+free > 0 {
+		stats.UsedPercent = 100 * float64(used) / float64(used+free)
+	}
+	return stats, nil
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProbeConfig maps health statuses to HTTP codes for readiness and health
+// endpoints. Kubernetes treats 200-399 as passing.
+type ProbeConfig struct {
+	DegradedCode  int // default 200, so a degraded instance keeps serving
+	UnhealthyCode int // default 503
+}
+
+func (c ProbeConfig) code(status HealthStatus) int {
+	switch status {
+	case StatusHealthy:
+		return http.StatusOK
+	case StatusDegraded:
+		if c.DegradedCode > 0 {
+			return c.DegradedCode
+		}
+		return http.StatusOK
+	}
+	if c.UnhealthyCode > 0 {
+		return c.UnhealthyCode
+	}
+	return http.StatusServiceUnavailable
+}
+
+// Probes serves the Kubernetes liveness and readiness endpoints
+type Probes struct {
+	health   *Health
+	config   ProbeConfig
+	started  time.Time
+	draining atomic.Bool
+}
+
+// NewProbes creates probes running the checks of health
+func NewProbes(health *Health, config ProbeConfig) *Probes {
+	return &Probes{health: health, config: config, started: time.Now()}
+}
+
+// Drain makes readiness fail so load balancers stop sending traffic before
+// the server shuts down
+func (p *Probes) Drain() {
+	p.draining.Store(true)
+}
+
+// Live answers as long as the process can serve requests. It runs no checks,
+// so a failing dependency doesn't get the instance restarted.
+func (p *Probes) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":         StatusHealthy,
+		"uptime_seconds": int64(time.Since(p.started).Seconds()),
+	})
+}
+
+// Ready runs every check and answers with the overall status only
+func (p *Probes) Ready(c *gin.Context) {
+	if p.draining.Load() {
+		c.JSON(p.config.code(StatusUnhealthy), gin.H{"status": StatusUnhealthy, "message": "shutting down"})
+		return
+	}
+	report := p.health.Run(c.Request.Context())
+	c.JSON(p.config.code(report.Status), gin.H{"status": report.Status})
+}
+
+// Health runs every check and answers with the detail of each
+func (p *Probes) Health(c *gin.Context) {
+	report := p.health.Run(c.Request.Context())
+	if p.draining.Load() {
+		report.Status = StatusUnhealthy
+	}
+	c.JSON(p.config.code(report.Status), report)
+}
+
+// Register mounts /livez, /readyz and /healthz
+func (p *Probes) Register(router gin.IRouter) {
+	router.GET("/livez", p.Live)
+	router.GET("/readyz", p.Ready)
+	router.GET("/healthz", p.Health)
+}
@@ -0,0 +1,193 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HealthStatus is the outcome of a health check
+type HealthStatus string
+
+const (
+	StatusHealthy   HealthStatus = "healthy"
+	StatusDegraded  HealthStatus = "degraded"  // working, but needs attention
+	StatusUnhealthy HealthStatus = "unhealthy" // not working
+)
+
+// severity orders statuses from best to worst
+func (s HealthStatus) severity() int {
+	switch s {
+	case StatusHealthy:
+		return 0
+	case StatusDegraded:
+		return 1
+	}
+	return 2
+}
+
+// CheckResult is what a HealthChecker reports
+type CheckResult struct {
+	Status  HealthStatus           `json:"status"`
+	Message string                 `json:"message,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Healthy returns a healthy result with optional details
+func Healthy(details map[string]interface{}) CheckResult {
+	return CheckResult{Status: StatusHealthy, Details: details}
+}
+
+// Unhealthy returns an unhealthy result for err
+func Unhealthy(err error) CheckResult {
+	return CheckResult{Status: StatusUnhealthy, Message: err.Error()}
+}
+
+// HealthChecker checks one dependency or resource. Check should return
+// promptly once ctx is done.
+type HealthChecker interface {
+	Name() string
+	Check(ctx context.Context) CheckResult
+}
+
+// Check registers a checker
+type Check struct {
+	Checker HealthChecker
+	// Critical checks make the report unhealthy when they fail; others only
+	// degrade it
+	Critical bool
+	Timeout  time.Duration // 0 uses the registry default
+}
+
+// CheckReport is the result of one check in a HealthReport
+type CheckReport struct {
+	CheckResult
+	Critical   bool    `json:"critical"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// HealthReport is the outcome of running every registered check
+type HealthReport struct {
+	Status    HealthStatus           `json:"status"`
+	Checks    map[string]CheckReport `json:"checks"`
+	CheckedAt time.Time              `json:"checked_at"`
+}
+
+// Health runs registered health checks concurrently
+type Health struct {
+	mu      sync.RWMutex
+	checks  []Check
+	timeout time.Duration
+}
+
+// NewHealth creates a registry whose checks time out after timeout unless
+// they set their own
+func NewHealth(timeout time.Duration) *Health {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &Health{timeout: timeout}
+}
+
+// Register adds a check, replacing one with the same name
+func (h *Health) Register(check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, existing := range h.checks {
+		if existing.Checker.Name() == check.Checker.Name() {
+			h.checks[i] = check
+			return
+		}
+	}
+	h.checks = append(h.checks, check)
+}
+
+// Names returns the registered check names in order
+func (h *Health) Names() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	names := make([]string, 0, len(h.checks))
+	for _, check := range h.checks {
+		names = append(names, check.Checker.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run runs every check, each within its timeout. The report is unhealthy
+// when a critical check isn't healthy, degraded when any other check isn't.
+func (h *Health) Run(ctx context.Context) HealthReport {
+	h.mu.RLock()
+	checks := append([]Check(nil), h.checks...)
+	h.mu.RUnlock()
+
+	reports := make([]CheckReport, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			reports[i] = h.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := HealthReport{
+		Status:    StatusHealthy,
+		Checks:    make(map[string]CheckReport, len(checks)),
+		CheckedAt: time.Now(),
+	}
+	for i, check := range checks {
+		result := reports[i]
+		report.Checks[check.Checker.Name()] = result
+
+		status := result.Status
+		if !check.Critical && status == StatusUnhealthy {
+			status = StatusDegraded
+		}
+		if status.severity() > report.Status.severity() {
+			report.Status = status
+		}
+	}
+	return report
+}
+
+// run runs one check, reporting it unhealthy when it doesn't return in time
+// or panics
+func (h *Health) run(ctx context.Context, check Check) CheckReport {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = h.timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan CheckResult, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- CheckResult{Status: StatusUnhealthy, Message: fmt.Sprintf("check panicked: %v", r)}
+			}
+		}()
+		done <- check.Checker.Check(ctx)
+	}()
+
+	var result CheckResult
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result = CheckResult{Status: StatusUnhealthy, Message: fmt.Sprintf("timed out after %s", timeout)}
+	}
+	if result.Status == "" {
+		result.Status = StatusHealthy
+	}
+
+	return CheckReport{
+		CheckResult: result,
+		Critical:    check.Critical,
+		DurationMs:  float64(time.Since(start).Microseconds()) / 1000,
+	}
+}
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"runtime"
+	"sync"
+	"time"
+
+	"flex-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// ErrNotSupported is returned for system metrics the platform doesn't provide
+var ErrNotSupported = errors.New("not supported on this platform")
+
+// DiskStats is the usage of the filesystem holding a path
+type DiskStats struct {
+	Path        string  `json:"path"`
+	TotalBytes  uint64  `json:"total_bytes"`
+	FreeBytes   uint64  `json:"free_bytes"` // available to unprivileged users
+	UsedBytes   uint64  `json:"used_bytes"`
+	UsedPercent float64 `json:"used_percent"`
+}
+
+// DiskUsage returns the usage of the filesystem holding path
+func DiskUsage(path string) (DiskStats, error) {
+	return diskUsage(path)
+}
+
+// SystemMetrics is a snapshot of the host and the Go runtime. Fields the
+// platform doesn't provide stay zero.
+type SystemMetrics struct {
+	CPUCount   int     `json:"cpu_count"`
+	CPUPercent float64 `json:"cpu_percent"` // all CPUs, since the previous sample
+	Load1      float64 `json:"load_1"`
+	Load5      float64 `json:"load_5"`
+	Load15     float64 `json:"load_15"`
+
+	MemoryTotalBytes     uint64 `json:"memory_total_bytes"`
+	MemoryAvailableBytes uint64 `json:"memory_available_bytes"`
+
+	Disk DiskStats `json:"disk"`
+
+	Goroutines     int    `json:"goroutines"`
+	HeapAllocBytes uint64 `json:"heap_alloc_bytes"`
+
+	CollectedAt time.Time `json:"collected_at"`
+}
+
+// cpuTimes are cumulative CPU times in clock ticks
+type cpuTimes struct {
+	total, idle uint64
+}
+
+// SystemCollector samples SystemMetrics periodically. CPU usage is measured
+// between two samples, so it is zero until the second one.
+type SystemCollector struct {
+	diskPath string
+
+	mu       sync.RWMutex
+	latest   SystemMetrics
+	previous cpuTimes
+}
+
+// NewSystemCollector creates a collector reporting the disk holding diskPath
+func NewSystemCollector(diskPath string) *SystemCollector {
+	if diskPath == "" {
+		diskPath = "."
+	}
+	return &SystemCollector{diskPath: diskPath}
+}
+
+// Collect takes a sample and returns it
+func (c *SystemCollector) Collect() SystemMetrics {
+	m := SystemMetrics{
+		CPUCount:    runtime.NumCPU(),
+		Goroutines:  runtime.NumGoroutine(),
+		CollectedAt: time.Now(),
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	m.HeapAllocBytes = mem.HeapAlloc
+
+	if load, err := readLoadAverage(); err == nil {
+		m.Load1, m.Load5, m.Load15 = load[0], load[1], load[2]
+	}
+	if total, available, err := readMemory(); err == nil {
+		m.MemoryTotalBytes, m.MemoryAvailableBytes = total, available
+	}
+	if disk, err := diskUsage(c.diskPath); err == nil {
+		m.Disk = disk
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if times, err := readCPUTimes(); err == nil {
+		if c.previous.total > 0 && times.total > c.previous.total {
+			busy := (times.total - c.previous.total) - (times.idle - c.previous.idle)
+			m.CPUPercent = 100 * float64(busy) / float64(times.total-c.previous.total)
+		}
+		c.previous = times
+	}
+	c.latest = m
+	return m
+}
+
+// Snapshot returns the latest sample
+func (c *SystemCollector) Snapshot() SystemMetrics {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.latest
+}
+
+// Run samples every interval until ctx is done
+func (c *SystemCollector) Run(ctx context.Context, interval time.Duration) {
+	c.Collect()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Collect()
+		}
+	}
+}
+
+// Publish exposes the latest sample as "system" at /debug/vars when expvar is
+// mounted. Only one collector can be published.
+func (c *SystemCollector) Publish() {
+	if expvar.Get("system") != nil {
+		logger.Warn("System metrics already published")
+		return
+	}
+	expvar.Publish("system", expvar.Func(func() interface{} {
+		return c.Snapshot()
+	}))
+	logger.Debug("System metrics published", zap.String("disk_path", c.diskPath))
+}
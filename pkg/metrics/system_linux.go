@@ -0,0 +1,95 @@
+//go:build linux
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readCPUTimes reads the aggregate "cpu" line of /proc/stat
+func readCPUTimes() (cpuTimes, error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return cpuTimes{}, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+
+		var times cpuTimes
+		// user nice system idle iowait irq softirq steal; guest time is
+		// already part of user and nice
+		for i, field := range fields[1:min(len(fields), 9)] {
+			value, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return cpuTimes{}, fmt.Errorf("invalid /proc/stat: %w", err)
+			}
+			times.total += value
+			if i == 3 || i == 4 { // idle, iowait
+				times.idle += value
+			}
+		}
+		return times, nil
+	}
+	return cpuTimes{}, fmt.Errorf("no cpu line in /proc/stat")
+}
+
+// readLoadAverage reads the 1, 5 and 15 minute load averages
+func readLoadAverage() ([3]float64, error) {
+	var load [3]float64
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return load, err
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return load, fmt.Errorf("invalid /proc/loadavg")
+	}
+	for i := range load {
+		if load[i], err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return load, fmt.Errorf("invalid /proc/loadavg: %w", err)
+		}
+	}
+	return load, nil
+}
+
+// readMemory reads MemTotal and MemAvailable from /proc/meminfo
+func readMemory() (total, available uint64, err error) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			total = value * 1024
+		case "MemAvailable:":
+			available = value * 1024
+		}
+	}
+	if total == 0 {
+		return 0, 0, fmt.Errorf("no MemTotal in /proc/meminfo")
+	}
+	return total, available, scanner.Err()
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package metrics
+
+func readCPUTimes() (cpuTimes, error) {
+	return cpuTimes{}, ErrNotSupported
+}
+
+func readLoadAverage() ([3]float64, error) {
+	return [3]float64{}, ErrNotSupported
+}
+
+func readMemory() (uint64, uint64, error) {
+	return 0, 0, ErrNotSupported
+}
@@ -1,6 +1,8 @@
 package migration
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -34,7 +36,8 @@ type MigrationRecord struct {
 	ID          uint   `gorm:"primaryKey"`
 	Version     string `gorm:"type:varchar(255);uniqueIndex;not null"`
 	Description string `gorm:"type:varchar(500);not null"`
-	AppliedAt   string `gorm:"not null"` // Use string for cross-database compatibility
+	AppliedAt   string `gorm:"not null"`         // Use string for cross-database compatibility
+	Checksum    string `gorm:"type:varchar(64)"` // SHA-256 of the source when applied, see Checksum
 }
 
 // TableName returns the table name for GORM
@@ -44,14 +47,16 @@ func (MigrationRecord) TableName() string {
 
 // MigrationConfig configuration for migration engine
 type MigrationConfig struct {
-	TableName string // Custom migration table name (default: "migrations")
-	AutoRun   bool   // Auto run migrations on startup
+	TableName   string        // Custom migration table name (default: "migrations")
+	AutoRun     bool          // Auto run migrations on startup
+	LockTimeout time.Duration // How long to wait for another process's migrations (default: 5m)
 }
 
 // DefaultMigrationConfig returns default configuration
 func DefaultMigrationConfig() *MigrationConfig {
 	return &MigrationConfig{
-		TableName: "migrations",
-		AutoRun:   false,
+		TableName:   "migrations",
+		AutoRun:     false,
+		LockTimeout: DefaultLockTimeout,
 	}
 }
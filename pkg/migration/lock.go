@@ -0,0 +1,163 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"flex-service/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// DefaultLockTimeout is how long a manager waits for the migrations of
+// another process to finish
+const DefaultLockTimeout = 5 * time.Minute
+
+// lockPollInterval is how often a held migration lock is tried again
+const lockPollInterval = time.Second
+
+// ErrLockTimeout is returned when the migration lock stays held for the
+// whole LockTimeout
+var ErrLockTimeout = errors.New("timed out waiting for the migration lock")
+
+// withLock runs fn while holding a database-wide lock: pg_advisory_lock on
+// PostgreSQL, GET_LOCK on MySQL and a lock file next to the SQLite database.
+// Instances that start together therefore run their migrations one after the
+// other, and the later ones find nothing pending.
+func (m *Manager) withLock(fn func() error) error {
+	timeout := m.config.LockTimeout
+	if timeout <= 0 {
+		timeout = DefaultLockTimeout
+	}
+
+	switch m.db.Dialector.Name() {
+	case "postgres":
+		// Advisory locks belong to a session, so they are taken and released
+		// on one connection while the migrations use the pool
+		return m.db.Connection(func(conn *gorm.DB) error {
+			return withPostgresLock(conn, m.lockName(), timeout, fn)
+		})
+	case "mysql":
+		return m.db.Connection(func(conn *gorm.DB) error {
+			return withMySQLLock(conn, m.lockName(), timeout, fn)
+		})
+	case "sqlite":
+		return withSQLiteLock(m.db, timeout, fn)
+	default:
+		return fn()
+	}
+}
+
+func (m *Manager) lockName() string {
+	return "migrations:" + m.config.TableName
+}
+
+// waitForLock calls try until it acquires the lock or timeout passes
+func waitForLock(timeout time.Duration, try func() (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+	logged := false
+	for {
+		acquired, err := try()
+		if err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		if acquired {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w after %s", ErrLockTimeout, timeout)
+		}
+		if !logged {
+			logger.Info("Waiting for migrations running in another process", zap.Duration("timeout", timeout))
+			logged = true
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+func withPostgresLock(conn *gorm.DB, name string, timeout time.Duration, fn func() error) error {
+	// pg advisory locks are keyed by a bigint, scoped to the database
+	hash := fnv.New64a()
+	hash.Write([]byte(name))
+	key := int64(hash.Sum64())
+
+	err := waitForLock(timeout, func() (bool, error) {
+		var acquired bool
+		err := conn.Raw("SELECT pg_try_advisory_lock(?)", key).Scan(&acquired).Error
+		return acquired, err
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := conn.Exec("SELECT pg_advisory_unlock(?)", key).Error; err != nil {
+			logger.Warn("Failed to release migration lock", zap.Error(err))
+		}
+	}()
+
+	return fn()
+}
+
+func withMySQLLock(conn *gorm.DB, name string, timeout time.Duration, fn func() error) error {
+	// GET_LOCK names are server-wide and at most 64 characters
+	var database string
+	if err := conn.Raw("SELECT DATABASE()").Scan(&database).Error; err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	name = database + ":" + name
+	if len(name) > 64 {
+		name = name[:64]
+	}
+
+	err := waitForLock(timeout, func() (bool, error) {
+		var acquired *int
+		err := conn.Raw("SELECT GET_LOCK(?, 0)", name).Scan(&acquired).Error
+		return acquired != nil && *acquired == 1, err
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := conn.Exec("SELECT RELEASE_LOCK(?)", name).Error; err != nil {
+			logger.Warn("Failed to release migration lock", zap.Error(err))
+		}
+	}()
+
+	return fn()
+}
+
+// sqliteDatabase is a row of PRAGMA database_list
+type sqliteDatabase struct {
+	Seq  int
+	Name string
+	File string
+}
+
+func withSQLiteLock(db *gorm.DB, timeout time.Duration, fn func() error) error {
+	var databases []sqliteDatabase
+	if err := db.Raw("PRAGMA database_list").Scan(&databases).Error; err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+
+	// In-memory databases belong to this process alone
+	path := ""
+	for _, database := range databases {
+		if database.Name == "main" {
+			path = database.File
+		}
+	}
+	if path == "" {
+		return fn()
+	}
+
+	unlock, err := lockFile(path+".migrate.lock", timeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return fn()
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package migration
+
+import "time"
+
+// lockFile is a no-op where flock isn't available; SQLite migrations then
+// rely on not being started twice
+func lockFile(path string, timeout time.Duration) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,43 @@
+//go:build unix
+
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"flex-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// lockFile takes an exclusive flock on path, which the kernel releases
+// if the process dies
+func lockFile(path string, timeout time.Duration) (func(), error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+
+	err = waitForLock(timeout, func() (bool, error) {
+		err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return func() {
+		if err := syscall.Flock(int(file.Fd()), syscall.LOCK_UN); err != nil {
+			logger.Warn("Failed to release migration lock", zap.String("path", path), zap.Error(err))
+		}
+		file.Close()
+	}, nil
+}
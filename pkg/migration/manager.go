@@ -52,8 +52,13 @@ func (m *Manager) GetRegisteredMigrations() []Migration {
 	return migrations
 }
 
-// RunMigrations runs all pending migrations
+// RunMigrations runs all pending migrations. It holds the migration lock, so
+// concurrent runs against one database wait for each other.
 func (m *Manager) RunMigrations() error {
+	return m.withLock(m.runPendingMigrations)
+}
+
+func (m *Manager) runPendingMigrations() error {
 	// Create migrations table if not exists
 	if err := m.ensureMigrationsTable(); err != nil {
 		return fmt.Errorf("failed to create migrations table: %w", err)
@@ -101,8 +106,13 @@ func (m *Manager) RunMigrations() error {
 	return nil
 }
 
-// RollbackMigrations rolls back specified number of migrations
+// RollbackMigrations rolls back specified number of migrations, holding the
+// migration lock like RunMigrations
 func (m *Manager) RollbackMigrations(count string) error {
+	return m.withLock(func() error { return m.rollbackMigrations(count) })
+}
+
+func (m *Manager) rollbackMigrations(count string) error {
 	countInt, err := strconv.Atoi(count)
 	if err != nil && count != "all" {
 		return fmt.Errorf("invalid count value: %w", err)
@@ -245,6 +255,33 @@ func (m *Manager) IsMigrationApplied(version string) (bool, error) {
 	return count > 0, err
 }
 
+// MarkApplied records a migration as applied without running it, for
+// baseline migrations of tables that already exist. It returns false when the
+// version was recorded already.
+func (m *Manager) MarkApplied(version, description string) (bool, error) {
+	if err := m.ensureMigrationsTable(); err != nil {
+		return false, fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	applied, err := m.IsMigrationApplied(version)
+	if err != nil || applied {
+		return false, err
+	}
+
+	record := MigrationRecord{
+		Version:     version,
+		Description: description,
+		AppliedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+	if migration, exists := m.migrations[version]; exists {
+		record.Checksum = Checksum(migration)
+	}
+	if err := m.db.Create(&record).Error; err != nil {
+		return false, fmt.Errorf("failed to record migration: %w", err)
+	}
+	return true, nil
+}
+
 // Private methods
 
 func (m *Manager) ensureMigrationsTable() error {
@@ -269,6 +306,7 @@ func (m *Manager) runSingleMigration(migration Migration) error {
 		Version:     migration.Version(),
 		Description: migration.Description(),
 		AppliedAt:   time.Now().UTC().Format(time.RFC3339),
+		Checksum:    Checksum(migration),
 	}
 
 	if err := tx.Create(&record).Error; err != nil {
@@ -1,6 +1,7 @@
 package migration
 
 import (
+	"io/fs"
 	"sync"
 
 	"gorm.io/gorm"
@@ -9,6 +10,8 @@ import (
 // GlobalRegistry เป็น global registry สำหรับ migrations
 type GlobalRegistry struct {
 	migrations []Migration
+	models     []interface{}
+	sources    fs.FS
 	mu         sync.RWMutex
 }
 
@@ -35,6 +38,33 @@ func GetRegisteredMigrations() []Migration {
 	return migrations
 }
 
+// RegisterModels registers the GORM models migrate:verify compares with the
+// live schema (called from the entity package's init())
+func RegisterModels(models ...interface{}) {
+	globalRegistry.mu.Lock()
+	defer globalRegistry.mu.Unlock()
+	globalRegistry.models = append(globalRegistry.models, models...)
+}
+
+// GetRegisteredModels returns all globally registered models
+func GetRegisteredModels() []interface{} {
+	globalRegistry.mu.RLock()
+	defer globalRegistry.mu.RUnlock()
+
+	models := make([]interface{}, len(globalRegistry.models))
+	copy(models, globalRegistry.models)
+	return models
+}
+
+// UseSources sets the migration source files checksums are computed from,
+// usually an embed.FS of the migrations package. A migration's source is
+// the file named after its version, as make:migration names it.
+func UseSources(sources fs.FS) {
+	globalRegistry.mu.Lock()
+	defer globalRegistry.mu.Unlock()
+	globalRegistry.sources = sources
+}
+
 // ClearRegistry clears the global registry (useful for testing)
 func ClearRegistry() {
 	globalRegistry.mu.Lock()
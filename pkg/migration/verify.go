@@ -0,0 +1,178 @@
+package migration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Checksum returns the SHA-256 of a migration's source file, or "" when no
+// sources are set or the file isn't among them
+func Checksum(migration Migration) string {
+	globalRegistry.mu.RLock()
+	sources := globalRegistry.sources
+	globalRegistry.mu.RUnlock()
+	if sources == nil {
+		return ""
+	}
+
+	source, err := fs.ReadFile(sources, migration.Version()+".go")
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(source)
+	return hex.EncodeToString(sum[:])
+}
+
+// Drift is a difference between a model and the live schema
+type Drift struct {
+	Table   string
+	Column  string // empty for the table itself
+	Problem string
+}
+
+// Verification is the outcome of Verify
+type Verification struct {
+	// Pending migrations will run on the next migrate
+	Pending []string
+	// Modified migrations were edited after they were applied
+	Modified []string
+	// Missing migrations are applied but no longer in the code
+	Missing []string
+	// Unverified migrations were applied without a checksum, before checksums
+	// were recorded or without their source
+	Unverified []string
+	// Drift lists the models that don't match the live schema
+	Drift []Drift
+}
+
+// OK reports whether nothing was modified, missing or drifted. Pending and
+// unverified migrations don't fail a verification.
+func (v *Verification) OK() bool {
+	return len(v.Modified) == 0 && len(v.Missing) == 0 && len(v.Drift) == 0
+}
+
+// Verify checks the applied migrations against their recorded checksums and
+// the given GORM models against the live schema: missing tables, columns and
+// indexes, columns of another kind of type, and columns NOT NULL in the model
+// but nullable in the database.
+func (m *Manager) Verify(models []interface{}) (*Verification, error) {
+	if err := m.ensureMigrationsTable(); err != nil {
+		return nil, fmt.Errorf("failed to create migrations table: %w", err)
+	}
+	appliedRecords, err := m.GetAppliedMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	verification := &Verification{}
+	applied := make(map[string]bool, len(appliedRecords))
+	for _, record := range appliedRecords {
+		applied[record.Version] = true
+
+		migration, exists := m.migrations[record.Version]
+		switch {
+		case !exists:
+			verification.Missing = append(verification.Missing, record.Version)
+		case record.Checksum == "":
+			verification.Unverified = append(verification.Unverified, record.Version)
+		case Checksum(migration) == "":
+			verification.Unverified = append(verification.Unverified, record.Version)
+		case Checksum(migration) != record.Checksum:
+			verification.Modified = append(verification.Modified, record.Version)
+		}
+	}
+	for _, migration := range m.GetRegisteredMigrations() {
+		if !applied[migration.Version()] {
+			verification.Pending = append(verification.Pending, migration.Version())
+		}
+	}
+
+	for _, model := range models {
+		drift, err := m.modelDrift(model)
+		if err != nil {
+			return nil, err
+		}
+		verification.Drift = append(verification.Drift, drift...)
+	}
+	return verification, nil
+}
+
+// modelDrift compares one model with its table
+func (m *Manager) modelDrift(model interface{}) ([]Drift, error) {
+	stmt := &gorm.Statement{DB: m.db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, fmt.Errorf("failed to parse model %T: %w", model, err)
+	}
+	table := stmt.Schema.Table
+
+	migrator := m.db.Migrator()
+	if !migrator.HasTable(table) {
+		return []Drift{{Table: table, Problem: "table does not exist"}}, nil
+	}
+	columnTypes, err := migrator.ColumnTypes(model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	columns := make(map[string]gorm.ColumnType, len(columnTypes))
+	for _, columnType := range columnTypes {
+		columns[strings.ToLower(columnType.Name())] = columnType
+	}
+
+	var drift []Drift
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName == "" || field.IgnoreMigration {
+			continue
+		}
+		column, exists := columns[strings.ToLower(field.DBName)]
+		if !exists {
+			drift = append(drift, Drift{Table: table, Column: field.DBName, Problem: "column does not exist"})
+			continue
+		}
+
+		modelKind := typeKind(string(field.DataType))
+		columnKind := typeKind(column.DatabaseTypeName())
+		if modelKind != "" && columnKind != "" && modelKind != columnKind {
+			drift = append(drift, Drift{Table: table, Column: field.DBName,
+				Problem: fmt.Sprintf("is %s in the database, %s in the model", column.DatabaseTypeName(), field.DataType)})
+		}
+		if nullable, ok := column.Nullable(); ok && nullable && field.NotNull && !field.PrimaryKey {
+			drift = append(drift, Drift{Table: table, Column: field.DBName, Problem: "is nullable in the database, NOT NULL in the model"})
+		}
+	}
+
+	for _, index := range stmt.Schema.ParseIndexes() {
+		if !migrator.HasIndex(model, index.Name) {
+			drift = append(drift, Drift{Table: table, Problem: fmt.Sprintf("index %s does not exist", index.Name)})
+		}
+	}
+	return drift, nil
+}
+
+// typeKind groups model and database types coarsely, so only a column of
+// another kind (a number stored as text, say) counts as drift. It returns ""
+// for types it doesn't know.
+func typeKind(dataType string) string {
+	dataType = strings.ToLower(dataType)
+	switch {
+	case strings.Contains(dataType, "json"):
+		return "json"
+	case strings.Contains(dataType, "time"), strings.Contains(dataType, "date"):
+		return "time"
+	case strings.Contains(dataType, "char"), strings.Contains(dataType, "text"), strings.Contains(dataType, "string"),
+		strings.Contains(dataType, "uuid"), strings.Contains(dataType, "enum"), strings.Contains(dataType, "clob"):
+		return "text"
+	case strings.Contains(dataType, "blob"), strings.Contains(dataType, "bytea"), strings.Contains(dataType, "binary"),
+		dataType == "bytes":
+		return "bytes"
+	case strings.Contains(dataType, "int"), strings.Contains(dataType, "serial"), strings.Contains(dataType, "bool"),
+		strings.Contains(dataType, "numeric"), strings.Contains(dataType, "decimal"), strings.Contains(dataType, "float"),
+		strings.Contains(dataType, "double"), strings.Contains(dataType, "real"), strings.Contains(dataType, "bit"):
+		return "number"
+	}
+	return ""
+}
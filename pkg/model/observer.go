@@ -0,0 +1,69 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Event is a change to a record that observers are called for
+type Event string
+
+// Events
+const (
+	Created Event = "created"
+	Updated Event = "updated"
+	Deleted Event = "deleted" // soft and permanent deletes
+)
+
+// observer is an Observe callback with its record type erased
+type observer func(tx *gorm.DB, record interface{}) error
+
+// Observers holds callbacks per entity type and event. Install it on a
+// *gorm.DB with db.Use; every create, update and delete through that DB then
+// calls the callbacks registered for the statement's model.
+type Observers struct {
+	mu        sync.RWMutex
+	observers map[reflect.Type]map[Event][]observer
+}
+
+// NewObservers creates an empty registry
+func NewObservers() *Observers {
+	return &Observers{observers: make(map[reflect.Type]map[Event][]observer)}
+}
+
+// Observe registers fn for an event on entity T. It runs after GORM's own
+// After* hooks, in the statement's transaction: an error rolls the write back
+// and is returned by it. tx is a fresh session on that transaction, so
+// queries made with it see the change.
+//
+//	model.Observe(container.Observers, model.Updated, func(tx *gorm.DB, user *entity.User) error {
+//		return usecase.InvalidateUserCache(tx.Statement.Context, user.ID)
+//	})
+//
+// For updates and deletes by condition, e.g. db.Model(&entity.User{}).Where(...),
+// the record holds only the columns the statement set.
+func Observe[T any](o *Observers, event Event, fn func(tx *gorm.DB, record *T) error) {
+	key := reflect.TypeOf((*T)(nil)).Elem()
+	if key.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("model: cannot observe %s, entities are structs", key))
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.observers[key] == nil {
+		o.observers[key] = make(map[Event][]observer)
+	}
+	o.observers[key][event] = append(o.observers[key][event], func(tx *gorm.DB, record interface{}) error {
+		return fn(tx, record.(*T))
+	})
+}
+
+// get returns the observers of an event on a model type
+func (o *Observers) get(modelType reflect.Type, event Event) []observer {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.observers[modelType][event]
+}
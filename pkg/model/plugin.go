@@ -0,0 +1,94 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+// commit is GORM's last callback of a write; observers run before it so they
+// share the transaction
+const commit = "gorm:commit_or_rollback_transaction"
+
+// Name implements gorm.Plugin
+func (o *Observers) Name() string {
+	return "model:observers"
+}
+
+// Initialize implements gorm.Plugin by registering the callbacks that call
+// the observers
+func (o *Observers) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().After("gorm:after_create").Before(commit).
+		Register("model:created", o.callback(Created)); err != nil {
+		return fmt.Errorf("failed to register created observers: %w", err)
+	}
+	if err := callbacks.Update().After("gorm:after_update").Before(commit).
+		Register("model:updated", o.callback(Updated)); err != nil {
+		return fmt.Errorf("failed to register updated observers: %w", err)
+	}
+	if err := callbacks.Delete().After("gorm:after_delete").Before(commit).
+		Register("model:deleted", o.callback(Deleted)); err != nil {
+		return fmt.Errorf("failed to register deleted observers: %w", err)
+	}
+	return nil
+}
+
+// callback calls the observers of an event for each record a statement
+// wrote. Failed statements, statements that matched no row and sessions with
+// SkipHooks are skipped, like GORM's own hooks.
+func (o *Observers) callback(event Event) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil || db.RowsAffected == 0 || db.Statement.Schema == nil || db.Statement.SkipHooks {
+			return
+		}
+		observers := o.get(db.Statement.Schema.ModelType, event)
+		if len(observers) == 0 {
+			return
+		}
+
+		tx := db.Session(&gorm.Session{NewDB: true})
+		eachRecord(db.Statement.ReflectValue, func(record interface{}) bool {
+			for _, observer := range observers {
+				if err := observer(tx, record); err != nil {
+					_ = db.AddError(err)
+					return false
+				}
+			}
+			return true
+		})
+	}
+}
+
+// eachRecord calls fn with a pointer to the statement's record, or to each of
+// its records for batch writes, until fn returns false
+func eachRecord(value reflect.Value, fn func(record interface{}) bool) {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if !fn(pointer(value.Index(i))) {
+				return
+			}
+		}
+	case reflect.Struct:
+		fn(pointer(value))
+	}
+}
+
+// pointer returns a *T for a T or *T value, copying values that aren't
+// addressable
+func pointer(value reflect.Value) interface{} {
+	for value.Kind() == reflect.Ptr && value.Elem().Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	if value.Kind() == reflect.Ptr {
+		return value.Interface()
+	}
+	if value.CanAddr() {
+		return value.Addr().Interface()
+	}
+	copied := reflect.New(value.Type())
+	copied.Elem().Set(value)
+	return copied.Interface()
+}
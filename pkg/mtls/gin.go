@@ -0,0 +1,63 @@
+package mtls
+
+import (
+	"net/http"
+
+	"flex-service/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware stores the verified client identity on the gin context ("client_identity")
+// and the request context. Requests without a verified certificate pass through untouched.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if identity, ok := IdentityFromRequest(c.Request); ok {
+			c.Set("client_identity", identity)
+			c.Request = c.Request.WithContext(WithIdentity(c.Request.Context(), identity))
+		}
+		c.Next()
+	}
+}
+
+// Require rejects requests without a verified client certificate, and, when patterns are
+// given, certificates whose SANs/CN match none of them
+func Require(patterns ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := IdentityFromRequest(c.Request)
+		if !ok {
+			response.Error(c, http.StatusUnauthorized, "CLIENT_CERTIFICATE_REQUIRED", "A verified client certificate is required", nil)
+			c.Abort()
+			return
+		}
+
+		if len(patterns) > 0 && !matchesAny(identity, patterns) {
+			response.Error(c, http.StatusForbidden, "CLIENT_NOT_ALLOWED", "Client certificate is not allowed", nil)
+			c.Abort()
+			return
+		}
+
+		c.Set("client_identity", identity)
+		c.Request = c.Request.WithContext(WithIdentity(c.Request.Context(), identity))
+		c.Next()
+	}
+}
+
+// ClientIdentity returns the identity set by Middleware or Require
+func ClientIdentity(c *gin.Context) (*Identity, bool) {
+	value, exists := c.Get("client_identity")
+	if !exists {
+		return nil, false
+	}
+	identity, ok := value.(*Identity)
+	return identity, ok
+}
+
+func matchesAny(identity *Identity, patterns []string) bool {
+	for _, pattern := range patterns {
+		if identity.Matches(pattern) {
+			return true
+		}
+	}
+	return false
+}
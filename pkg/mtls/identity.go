@@ -0,0 +1,96 @@
+package mtls
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// Identity is the caller identity taken from a verified client certificate
+type Identity struct {
+	CommonName   string   `json:"common_name"`
+	DNSNames     []string `json:"dns_names,omitempty"`
+	URIs         []string `json:"uris,omitempty"`
+	Emails       []string `json:"emails,omitempty"`
+	SPIFFEID     string   `json:"spiffe_id,omitempty"`
+	SerialNumber string   `json:"serial_number"`
+	Fingerprint  string   `json:"fingerprint"` // SHA-256 of the DER certificate
+}
+
+// Name returns the most specific identifier: SPIFFE ID, first URI SAN, first DNS SAN, then CN
+func (i *Identity) Name() string {
+	switch {
+	case i.SPIFFEID != "":
+		return i.SPIFFEID
+	case len(i.URIs) > 0:
+		return i.URIs[0]
+	case len(i.DNSNames) > 0:
+		return i.DNSNames[0]
+	default:
+		return i.CommonName
+	}
+}
+
+// Matches reports whether any SAN or the CN equals pattern. A trailing "*" matches
+// by prefix, e.g. "spiffe://prod.internal/ns/billing/*".
+func (i *Identity) Matches(pattern string) bool {
+	candidates := append([]string{i.CommonName}, i.DNSNames...)
+	candidates = append(candidates, i.URIs...)
+	candidates = append(candidates, i.Emails...)
+
+	prefix, wildcard := strings.CutSuffix(pattern, "*")
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		if candidate == pattern || (wildcard && strings.HasPrefix(candidate, prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IdentityFromCertificate extracts SANs and subject from a certificate
+func IdentityFromCertificate(cert *x509.Certificate) *Identity {
+	sum := sha256.Sum256(cert.Raw)
+	identity := &Identity{
+		CommonName:   cert.Subject.CommonName,
+		DNSNames:     cert.DNSNames,
+		Emails:       cert.EmailAddresses,
+		SerialNumber: cert.SerialNumber.String(),
+		Fingerprint:  hex.EncodeToString(sum[:]),
+	}
+
+	for _, uri := range cert.URIs {
+		identity.URIs = append(identity.URIs, uri.String())
+		if uri.Scheme == "spiffe" && identity.SPIFFEID == "" {
+			identity.SPIFFEID = uri.String()
+		}
+	}
+	return identity
+}
+
+// IdentityFromRequest returns the identity of a client certificate that was verified
+// against the client CA bundle. Unverified certificates (ClientAuth request/require) are ignored.
+func IdentityFromRequest(r *http.Request) (*Identity, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	return IdentityFromCertificate(r.TLS.VerifiedChains[0][0]), true
+}
+
+type contextKey struct{}
+
+// WithIdentity stores the identity in a context
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, identity)
+}
+
+// FromContext returns the client identity stored by Middleware
+func FromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(contextKey{}).(*Identity)
+	return identity, ok
+}
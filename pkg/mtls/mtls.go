@@ -0,0 +1,119 @@
+package mtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var (
+	ErrMissingCertificate = errors.New("TLS certificate and key files are required")
+	ErrMissingClientCA    = errors.New("client CA bundle is required to verify client certificates")
+	ErrInvalidClientAuth  = errors.New("invalid client auth mode")
+	ErrInvalidMinVersion  = errors.New("invalid minimum TLS version")
+	ErrNoCertificates     = errors.New("no certificates found in CA bundle")
+)
+
+// Config describes the server certificate and how client certificates are checked
+type Config struct {
+	CertFile      string
+	KeyFile       string
+	ClientCAFiles []string // PEM bundles of CAs allowed to issue client certificates
+	ClientAuth    string   // none, request, require, verify_if_given, require_and_verify
+	MinVersion    string   // 1.2 or 1.3
+}
+
+// Enabled reports whether TLS is configured
+func (c Config) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+var clientAuthModes = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify_if_given":    tls.VerifyClientCertIfGiven,
+	"require_and_verify": tls.RequireAndVerifyClientCert,
+}
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ServerConfig builds a *tls.Config for http.Server. With a client CA bundle and no
+// explicit mode, client certificates are required and verified (mutual TLS).
+func ServerConfig(cfg Config) (*tls.Config, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, ErrMissingCertificate
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
+	if err := ConfigureClientAuth(tlsConfig, cfg); err != nil {
+		return nil, err
+	}
+
+	return tlsConfig, nil
+}
+
+// ConfigureClientAuth applies the client certificate mode, client CAs and minimum
+// version from cfg to an existing *tls.Config (e.g. one from autocert)
+func ConfigureClientAuth(tlsConfig *tls.Config, cfg Config) error {
+	mode := strings.ToLower(cfg.ClientAuth)
+	if mode == "" {
+		mode = "none"
+		if len(cfg.ClientCAFiles) > 0 {
+			mode = "require_and_verify"
+		}
+	}
+	clientAuth, ok := clientAuthModes[mode]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrInvalidClientAuth, cfg.ClientAuth)
+	}
+
+	minVersion := uint16(tls.VersionTLS12)
+	if cfg.MinVersion != "" {
+		if minVersion, ok = tlsVersions[cfg.MinVersion]; !ok {
+			return fmt.Errorf("%w: %s", ErrInvalidMinVersion, cfg.MinVersion)
+		}
+	}
+
+	tlsConfig.ClientAuth = clientAuth
+	tlsConfig.MinVersion = minVersion
+
+	if clientAuth == tls.VerifyClientCertIfGiven || clientAuth == tls.RequireAndVerifyClientCert {
+		if len(cfg.ClientCAFiles) == 0 {
+			return ErrMissingClientCA
+		}
+		pool, err := LoadCAPool(cfg.ClientCAFiles...)
+		if err != nil {
+			return err
+		}
+		tlsConfig.ClientCAs = pool
+	}
+
+	return nil
+}
+
+// LoadCAPool reads one or more PEM bundles into a certificate pool
+func LoadCAPool(files ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, file := range files {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle %s: %w", file, err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: %s", ErrNoCertificates, file)
+		}
+	}
+	return pool, nil
+}
@@ -0,0 +1,50 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"flex-service/pkg/email"
+)
+
+// EmailChannel sends notifications with pkg/email. The route is the recipient
+// address, used when ToEmail leaves To empty.
+type EmailChannel struct {
+	mailer *email.Mailer
+}
+
+// NewEmailChannel creates an email channel on top of a mailer
+func NewEmailChannel(mailer *email.Mailer) *EmailChannel {
+	return &EmailChannel{mailer: mailer}
+}
+
+// Message implements Channel; templates are rendered here so queued jobs carry the final body
+func (c *EmailChannel) Message(route string, notifiable Notifiable, notification Notification) (interface{}, error) {
+	n, ok := notification.(EmailNotification)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedChannel, ChannelEmail)
+	}
+
+	msg, err := n.ToEmail(notifiable)
+	if err != nil {
+		return nil, err
+	}
+	if len(msg.To) == 0 {
+		if route == "" {
+			return nil, fmt.Errorf("%w: %s", ErrNoRoute, ChannelEmail)
+		}
+		msg.To = []string{route}
+	}
+
+	return c.mailer.Build(email.Raw(msg))
+}
+
+// Deliver implements Channel
+func (c *EmailChannel) Deliver(ctx context.Context, route string, message json.RawMessage) error {
+	var msg email.Message
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return fmt.Errorf("invalid email message: %w", err)
+	}
+	return c.mailer.SendMessage(ctx, &msg)
+}
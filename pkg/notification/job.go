@@ -0,0 +1,65 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"flex-service/pkg/logger"
+	"flex-service/pkg/queue"
+
+	"go.uber.org/zap"
+)
+
+// JobTypeNotification is the queue job type for queued notifications
+const JobTypeNotification = queue.JobTypeNotification
+
+// jobPayload stores one channel delivery. The message is kept as plain JSON values
+// so the payload looks the same before and after a round trip through Redis.
+func jobPayload(channel, route string, message json.RawMessage) map[string]interface{} {
+	var decoded interface{}
+	json.Unmarshal(message, &decoded)
+
+	return map[string]interface{}{
+		"channel": channel,
+		"route":   route,
+		"message": decoded,
+	}
+}
+
+// JobHandler delivers notifications queued with the Queued option:
+//
+//	worker.RegisterHandler(queue.JobTypeNotification, notification.JobHandler(container.Notifier))
+func JobHandler(n *Notifier) queue.Handler {
+	return queue.HandlerFunc(func(ctx context.Context, job *queue.Job) *queue.JobResult {
+		name, _ := job.Payload["channel"].(string)
+		route, _ := job.Payload["route"].(string)
+
+		channel, err := n.channel(name)
+		if err != nil {
+			return &queue.JobResult{Success: false, Error: err.Error()}
+		}
+
+		raw, err := json.Marshal(job.Payload["message"])
+		if err != nil {
+			return &queue.JobResult{Success: false, Error: fmt.Sprintf("invalid notification message: %v", err)}
+		}
+
+		if err := channel.Deliver(ctx, route, raw); err != nil {
+			logger.Error("Queued notification delivery failed",
+				zap.String("job_id", job.ID),
+				zap.String("channel", name),
+				zap.Error(err))
+			return &queue.JobResult{Success: false, Error: err.Error()}
+		}
+
+		return &queue.JobResult{
+			Success: true,
+			Data: map[string]interface{}{
+				"channel":      name,
+				"delivered_at": time.Now(),
+			},
+		}
+	})
+}
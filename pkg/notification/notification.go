@@ -0,0 +1,104 @@
+package notification
+
+import (
+	"errors"
+
+	"flex-service/pkg/email"
+)
+
+// Built-in channel names
+const (
+	ChannelEmail   = "email"
+	ChannelSMS     = "sms"
+	ChannelSlack   = "slack"
+	ChannelPush    = "push"
+	ChannelWebhook = "webhook"
+)
+
+// Notification errors
+var (
+	ErrUnknownChannel     = errors.New("notification channel is not registered")
+	ErrNoRoute            = errors.New("notifiable has no route for channel")
+	ErrUnsupportedChannel = errors.New("notification does not support channel")
+	ErrQueueNotConfigured = errors.New("notification queue is not configured")
+	ErrDeliveryFailed     = errors.New("notification delivery failed")
+)
+
+// Notifiable is anything that can receive notifications, usually a user.
+// RouteNotificationFor returns the address for a channel: an email address,
+// phone number, device token, Slack or webhook URL, or "" when it has none.
+type Notifiable interface {
+	RouteNotificationFor(channel string) string
+}
+
+// Routes is an ad-hoc Notifiable for recipients that aren't models:
+//
+//	notification.Routes{notification.ChannelSlack: webhookURL}
+type Routes map[string]string
+
+// RouteNotificationFor implements Notifiable
+func (r Routes) RouteNotificationFor(channel string) string {
+	return r[channel]
+}
+
+// Notification declares the channels it is sent through. It must also implement
+// the To<Channel> method of every channel it lists (EmailNotification, SMSNotification, ...).
+type Notification interface {
+	Via(notifiable Notifiable) []string
+}
+
+// EmailNotification builds the email form of a notification
+type EmailNotification interface {
+	ToEmail(notifiable Notifiable) (*email.Message, error)
+}
+
+// SMSNotification builds the SMS form of a notification
+type SMSNotification interface {
+	ToSMS(notifiable Notifiable) (*SMSMessage, error)
+}
+
+// SlackNotification builds the Slack form of a notification
+type SlackNotification interface {
+	ToSlack(notifiable Notifiable) (*SlackMessage, error)
+}
+
+// PushNotification builds the push form of a notification
+type PushNotification interface {
+	ToPush(notifiable Notifiable) (*PushMessage, error)
+}
+
+// WebhookNotification builds the webhook form of a notification
+type WebhookNotification interface {
+	ToWebhook(notifiable Notifiable) (*WebhookMessage, error)
+}
+
+// SMSMessage is a text message
+type SMSMessage struct {
+	Body string `json:"body"`
+	From string `json:"from,omitempty"` // Overrides the configured sender
+}
+
+// SlackMessage is an incoming-webhook message
+type SlackMessage struct {
+	Text      string                   `json:"text"`
+	Blocks    []map[string]interface{} `json:"blocks,omitempty"`
+	Channel   string                   `json:"channel,omitempty"`
+	Username  string                   `json:"username,omitempty"`
+	IconEmoji string                   `json:"icon_emoji,omitempty"`
+}
+
+// PushMessage is a mobile push notification
+type PushMessage struct {
+	Title    string            `json:"title"`
+	Body     string            `json:"body"`
+	Image    string            `json:"image,omitempty"`
+	Data     map[string]string `json:"data,omitempty"`
+	Priority string            `json:"priority,omitempty"` // "high" or "normal"
+}
+
+// WebhookMessage is a JSON payload posted to the route URL
+type WebhookMessage struct {
+	Event   string            `json:"event"`
+	Data    interface{}       `json:"data"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
@@ -0,0 +1,152 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"flex-service/pkg/logger"
+	"flex-service/pkg/queue"
+
+	"go.uber.org/zap"
+)
+
+// Channel delivers notifications of one kind. Message runs when the notification is
+// sent and Deliver runs either right away or later in a queue worker, so everything
+// Deliver needs must survive the JSON round trip.
+type Channel interface {
+	// Message builds this channel's message for route from the notification
+	Message(route string, notifiable Notifiable, notification Notification) (interface{}, error)
+
+	// Deliver sends a message produced by Message, in its JSON form
+	Deliver(ctx context.Context, route string, message json.RawMessage) error
+}
+
+// Notifier sends notifications through registered channels
+type Notifier struct {
+	mu         sync.RWMutex
+	channels   map[string]Channel
+	dispatcher *queue.JobDispatcher
+}
+
+// NewNotifier creates a notifier with no channels
+func NewNotifier() *Notifier {
+	return &Notifier{channels: make(map[string]Channel)}
+}
+
+// Register adds or replaces a channel
+func (n *Notifier) Register(name string, channel Channel) *Notifier {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.channels[name] = channel
+	return n
+}
+
+// Channels returns the registered channel names
+func (n *Notifier) Channels() []string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	names := make([]string, 0, len(n.channels))
+	for name := range n.channels {
+		names = append(names, name)
+	}
+	return names
+}
+
+// WithQueue enables the Queued option by dispatching through pkg/queue
+func (n *Notifier) WithQueue(dispatcher *queue.JobDispatcher) *Notifier {
+	n.dispatcher = dispatcher
+	return n
+}
+
+// sendOptions collects per-send options
+type sendOptions struct {
+	queued bool
+	job    *queue.JobOptions
+}
+
+// Option changes how Send delivers a notification
+type Option func(*sendOptions)
+
+// Queued builds the channel messages now and delivers each one from a queue worker,
+// so a failing channel is retried without resending the others
+func Queued(opts ...*queue.JobOptions) Option {
+	return func(o *sendOptions) {
+		o.queued = true
+		if len(opts) > 0 {
+			o.job = opts[0]
+		}
+	}
+}
+
+// Send delivers the notification to every notifiable through the channels from Via.
+// A failing channel doesn't stop the others; all failures are returned together.
+func (n *Notifier) Send(ctx context.Context, notification Notification, notifiables []Notifiable, opts ...Option) error {
+	options := &sendOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.queued && n.dispatcher == nil {
+		return ErrQueueNotConfigured
+	}
+
+	var errs []error
+	for _, notifiable := range notifiables {
+		for _, name := range notification.Via(notifiable) {
+			if err := n.sendVia(ctx, name, notifiable, notification, options); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SendTo is Send for a single notifiable
+func (n *Notifier) SendTo(ctx context.Context, notifiable Notifiable, notification Notification, opts ...Option) error {
+	return n.Send(ctx, notification, []Notifiable{notifiable}, opts...)
+}
+
+func (n *Notifier) sendVia(ctx context.Context, name string, notifiable Notifiable, notification Notification, options *sendOptions) error {
+	channel, err := n.channel(name)
+	if err != nil {
+		return err
+	}
+
+	route := notifiable.RouteNotificationFor(name)
+	message, err := channel.Message(route, notifiable, notification)
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s message: %w", name, err)
+	}
+
+	if options.queued {
+		return n.dispatcher.Dispatch(JobTypeNotification, jobPayload(name, route, raw), options.job)
+	}
+
+	if err := channel.Deliver(ctx, route, raw); err != nil {
+		logger.Error("Notification delivery failed",
+			zap.String("channel", name),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (n *Notifier) channel(name string) (Channel, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	channel, ok := n.channels[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChannel, name)
+	}
+	return channel, nil
+}
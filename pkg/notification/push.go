@@ -0,0 +1,194 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"flex-service/config"
+	"flex-service/pkg/httpclient"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	fcmScope    = "https://www.googleapis.com/auth/firebase.messaging"
+	fcmEndpoint = "https://fcm.googleapis.com/v1/projects/%s/messages:send"
+)
+
+// serviceAccount is the subset of a Google service account key file FCM needs
+type serviceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// PushChannel sends push notifications through the FCM HTTP v1 API. The route is
+// the device registration token.
+type PushChannel struct {
+	account serviceAccount
+	key     *rsa.PrivateKey
+	client  *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewPushChannel creates a push channel from the service account in cfg.FCMCredentialsFile
+func NewPushChannel(cfg *config.NotificationConfig) (*PushChannel, error) {
+	data, err := os.ReadFile(cfg.FCMCredentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fcm credentials: %w", err)
+	}
+
+	var account serviceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("invalid fcm credentials: %w", err)
+	}
+	if account.ProjectID == "" || account.ClientEmail == "" {
+		return nil, fmt.Errorf("fcm credentials need project_id and client_email")
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid fcm private key: %w", err)
+	}
+
+	return &PushChannel{
+		account: account,
+		key:     key,
+		client:  httpclient.New(httpclient.Config{Timeout: cfg.Timeout, Name: "push"}),
+	}, nil
+}
+
+// Message implements Channel
+func (c *PushChannel) Message(route string, notifiable Notifiable, notification Notification) (interface{}, error) {
+	n, ok := notification.(PushNotification)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedChannel, ChannelPush)
+	}
+	if route == "" {
+		return nil, fmt.Errorf("%w: %s", ErrNoRoute, ChannelPush)
+	}
+	return n.ToPush(notifiable)
+}
+
+// Deliver implements Channel
+func (c *PushChannel) Deliver(ctx context.Context, route string, message json.RawMessage) error {
+	var msg PushMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return fmt.Errorf("invalid push message: %w", err)
+	}
+
+	token, err := c.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(fcmRequest(route, &msg))
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf(fcmEndpoint, url.PathEscape(c.account.ProjectID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	return do(c.client, req)
+}
+
+// fcmRequest maps a PushMessage onto the FCM v1 message format
+func fcmRequest(deviceToken string, msg *PushMessage) map[string]interface{} {
+	notification := map[string]interface{}{
+		"title": msg.Title,
+		"body":  msg.Body,
+	}
+	if msg.Image != "" {
+		notification["image"] = msg.Image
+	}
+
+	message := map[string]interface{}{
+		"token":        deviceToken,
+		"notification": notification,
+	}
+	if len(msg.Data) > 0 {
+		message["data"] = msg.Data
+	}
+	if msg.Priority != "" {
+		message["android"] = map[string]interface{}{"priority": strings.ToUpper(msg.Priority)}
+	}
+
+	return map[string]interface{}{"message": message}
+}
+
+// token returns a cached OAuth2 access token, exchanging a signed JWT for a new
+// one shortly before the current one expires
+func (c *PushChannel) token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.accessToken != "" && time.Until(c.expiresAt) > time.Minute {
+		return c.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   c.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   c.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(c.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign fcm assertion: %w", err)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: fcm token: %v", ErrDeliveryFailed, err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+		Error       string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("%w: fcm token: %v", ErrDeliveryFailed, err)
+	}
+	if resp.StatusCode != http.StatusOK || result.AccessToken == "" {
+		return "", fmt.Errorf("%w: fcm token: %s %s", ErrDeliveryFailed, resp.Status, result.Error)
+	}
+
+	c.accessToken = result.AccessToken
+	c.expiresAt = now.Add(time.Duration(result.ExpiresIn) * time.Second)
+	return c.accessToken, nil
+}
@@ -0,0 +1,54 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"flex-service/config"
+	"flex-service/pkg/httpclient"
+)
+
+// SlackChannel posts to Slack incoming webhooks. The route is the webhook URL;
+// notifiables without one fall back to the configured default.
+type SlackChannel struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlackChannel creates a Slack channel from the notification config
+func NewSlackChannel(cfg *config.NotificationConfig) *SlackChannel {
+	return &SlackChannel{
+		webhookURL: cfg.SlackWebhookURL,
+		client:     httpclient.New(httpclient.Config{Timeout: cfg.Timeout, Name: "slack"}),
+	}
+}
+
+// Message implements Channel
+func (c *SlackChannel) Message(route string, notifiable Notifiable, notification Notification) (interface{}, error) {
+	n, ok := notification.(SlackNotification)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedChannel, ChannelSlack)
+	}
+	if route == "" && c.webhookURL == "" {
+		return nil, fmt.Errorf("%w: %s", ErrNoRoute, ChannelSlack)
+	}
+	return n.ToSlack(notifiable)
+}
+
+// Deliver implements Channel
+func (c *SlackChannel) Deliver(ctx context.Context, route string, message json.RawMessage) error {
+	if route == "" {
+		route = c.webhookURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, route, bytes.NewReader(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return do(c.client, req)
+}
@@ -0,0 +1,102 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"flex-service/config"
+	"flex-service/pkg/httpclient"
+)
+
+// SMSChannel sends text messages through the Twilio Messages API or any provider
+// that speaks it. The route is the recipient's phone number in E.164 form.
+type SMSChannel struct {
+	accountSID string
+	authToken  string
+	from       string
+	baseURL    string
+	client     *http.Client
+}
+
+// NewSMSChannel creates an SMS channel from the notification config
+func NewSMSChannel(cfg *config.NotificationConfig) (*SMSChannel, error) {
+	if cfg.SMSAccountSID == "" || cfg.SMSAuthToken == "" {
+		return nil, fmt.Errorf("sms account sid and auth token are required")
+	}
+
+	baseURL := cfg.SMSBaseURL
+	if baseURL == "" {
+		baseURL = "https://api.twilio.com"
+	}
+
+	return &SMSChannel{
+		accountSID: cfg.SMSAccountSID,
+		authToken:  cfg.SMSAuthToken,
+		from:       cfg.SMSFrom,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		client:     httpclient.New(httpclient.Config{Timeout: cfg.Timeout, Name: "sms"}),
+	}, nil
+}
+
+// Message implements Channel
+func (c *SMSChannel) Message(route string, notifiable Notifiable, notification Notification) (interface{}, error) {
+	n, ok := notification.(SMSNotification)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedChannel, ChannelSMS)
+	}
+	if route == "" {
+		return nil, fmt.Errorf("%w: %s", ErrNoRoute, ChannelSMS)
+	}
+
+	msg, err := n.ToSMS(notifiable)
+	if err != nil {
+		return nil, err
+	}
+	if msg.From == "" {
+		msg.From = c.from
+	}
+	return msg, nil
+}
+
+// Deliver implements Channel
+func (c *SMSChannel) Deliver(ctx context.Context, route string, message json.RawMessage) error {
+	var msg SMSMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return fmt.Errorf("invalid sms message: %w", err)
+	}
+
+	form := url.Values{}
+	form.Set("To", route)
+	form.Set("From", msg.From)
+	form.Set("Body", msg.Body)
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", c.baseURL, url.PathEscape(c.accountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.accountSID, c.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return do(c.client, req)
+}
+
+// do sends req and turns a non-2xx response into an error with the response body
+func do(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDeliveryFailed, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%w: %s: %s", ErrDeliveryFailed, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
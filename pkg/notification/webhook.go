@@ -0,0 +1,74 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"flex-service/config"
+	"flex-service/pkg/httpclient"
+	"flex-service/pkg/signing"
+)
+
+// WebhookChannel posts a JSON envelope to the notifiable's webhook URL. With a
+// signer the request is signed so receivers can verify it with pkg/signing.
+type WebhookChannel struct {
+	client *http.Client
+}
+
+// NewWebhookChannel creates a webhook channel; signer may be nil
+func NewWebhookChannel(cfg *config.NotificationConfig, signer *signing.Signer) *WebhookChannel {
+	return &WebhookChannel{
+		client: httpclient.New(httpclient.Config{Timeout: cfg.Timeout, Name: "notification.webhook", Signer: signer}),
+	}
+}
+
+// webhookEnvelope is the request body sent to receivers
+type webhookEnvelope struct {
+	Event     string      `json:"event"`
+	Data      interface{} `json:"data"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// Message implements Channel
+func (c *WebhookChannel) Message(route string, notifiable Notifiable, notification Notification) (interface{}, error) {
+	n, ok := notification.(WebhookNotification)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedChannel, ChannelWebhook)
+	}
+	if route == "" {
+		return nil, fmt.Errorf("%w: %s", ErrNoRoute, ChannelWebhook)
+	}
+	return n.ToWebhook(notifiable)
+}
+
+// Deliver implements Channel
+func (c *WebhookChannel) Deliver(ctx context.Context, route string, message json.RawMessage) error {
+	var msg WebhookMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return fmt.Errorf("invalid webhook message: %w", err)
+	}
+
+	body, err := json.Marshal(webhookEnvelope{
+		Event:     msg.Event,
+		Data:      msg.Data,
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, route, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range msg.Headers {
+		req.Header.Set(name, value)
+	}
+
+	return do(c.client, req)
+}
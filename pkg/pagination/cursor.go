@@ -0,0 +1,108 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Cursor directions
+const (
+	DirectionNext = "next"
+	DirectionPrev = "prev"
+)
+
+// ErrInvalidCursor indicates that the cursor token could not be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points at the last seen value of the ordering column
+type Cursor struct {
+	Value     interface{} `json:"v"`
+	Direction string      `json:"d"`
+}
+
+// EncodeCursor encodes a cursor as an opaque URL-safe token
+func EncodeCursor(value interface{}, direction string) string {
+	data, err := json.Marshal(Cursor{Value: value, Direction: direction})
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes an opaque cursor token
+func DecodeCursor(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Value == nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Direction != DirectionNext && cursor.Direction != DirectionPrev {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// CursorPaginate returns a GORM scope applying keyset pagination on column.
+// One extra row is fetched so CursorPage can tell whether more rows exist.
+// column must be a trusted identifier, never user input.
+func CursorPaginate(p *Params, column string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if p.cursor == nil {
+			return db.Order(column + " ASC").Limit(p.Limit + 1)
+		}
+
+		if p.cursor.Direction == DirectionPrev {
+			return db.Where(fmt.Sprintf("%s < ?", column), p.cursor.Value).
+				Order(column + " DESC").
+				Limit(p.Limit + 1)
+		}
+
+		return db.Where(fmt.Sprintf("%s > ?", column), p.cursor.Value).
+			Order(column + " ASC").
+			Limit(p.Limit + 1)
+	}
+}
+
+// CursorPage trims the extra row fetched by CursorPaginate, restores ascending
+// order for backward pages and builds the pagination metadata.
+func CursorPage[T any](items []T, p *Params, key func(T) interface{}) ([]T, *Meta) {
+	hasMore := len(items) > p.Limit
+	if hasMore {
+		items = items[:p.Limit]
+	}
+
+	backward := p.cursor != nil && p.cursor.Direction == DirectionPrev
+	if backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	meta := &Meta{Limit: p.Limit}
+	if len(items) == 0 {
+		return items, meta
+	}
+
+	first, last := key(items[0]), key(items[len(items)-1])
+
+	// Moving forward there is a next page when we over-fetched; moving
+	// backward there always is one (the page we came from).
+	if (!backward && hasMore) || backward {
+		meta.NextCursor = EncodeCursor(last, DirectionNext)
+		meta.HasNext = true
+	}
+	if (backward && hasMore) || (!backward && p.cursor != nil) {
+		meta.PrevCursor = EncodeCursor(first, DirectionPrev)
+		meta.HasPrevious = true
+	}
+
+	return items, meta
+}
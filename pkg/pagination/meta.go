@@ -0,0 +1,49 @@
+package pagination
+
+import "flex-service/pkg/response"
+
+// Meta represents pagination metadata for both offset and cursor pagination
+type Meta struct {
+	Page        int    `json:"page,omitempty"`
+	Limit       int    `json:"limit"`
+	Total       int64  `json:"total"`
+	TotalPages  int    `json:"total_pages,omitempty"`
+	NextCursor  string `json:"next_cursor,omitempty"`
+	PrevCursor  string `json:"prev_cursor,omitempty"`
+	HasNext     bool   `json:"has_next"`
+	HasPrevious bool   `json:"has_previous"`
+}
+
+// NewOffsetMeta creates metadata for offset pagination
+func NewOffsetMeta(p *Params, total int64) *Meta {
+	totalPages := int((total + int64(p.Limit) - 1) / int64(p.Limit))
+
+	return &Meta{
+		Page:        p.Page,
+		Limit:       p.Limit,
+		Total:       total,
+		TotalPages:  totalPages,
+		HasNext:     p.Page < totalPages,
+		HasPrevious: p.Page > 1,
+	}
+}
+
+// WithTotal sets the total row count (optional for cursor pagination)
+func (m *Meta) WithTotal(total int64) *Meta {
+	m.Total = total
+	return m
+}
+
+// ToResponseMeta converts pagination metadata into the response envelope meta
+func (m *Meta) ToResponseMeta() *response.Meta {
+	return &response.Meta{
+		Page:        m.Page,
+		Limit:       m.Limit,
+		Total:       m.Total,
+		TotalPages:  m.TotalPages,
+		NextCursor:  m.NextCursor,
+		PrevCursor:  m.PrevCursor,
+		HasNext:     m.HasNext,
+		HasPrevious: m.HasPrevious,
+	}
+}
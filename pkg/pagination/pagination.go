@@ -0,0 +1,82 @@
+package pagination
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultPage  = 1
+	DefaultLimit = 10
+	MaxLimit     = 100
+)
+
+// Params holds pagination parameters bound from the query string.
+// Offset pagination uses Page/Limit, cursor pagination uses Cursor/Limit.
+type Params struct {
+	Page   int    `form:"page" json:"page"`
+	Limit  int    `form:"limit" json:"limit"`
+	Cursor string `form:"cursor" json:"cursor,omitempty"`
+
+	cursor *Cursor
+}
+
+// Bind binds and normalizes pagination params from the request query
+func Bind(c *gin.Context) (*Params, error) {
+	var p Params
+	if err := c.ShouldBindQuery(&p); err != nil {
+		return nil, err
+	}
+
+	if err := p.Normalize(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Normalize applies defaults and limits and decodes the cursor token if present
+func (p *Params) Normalize() error {
+	if p.Page <= 0 {
+		p.Page = DefaultPage
+	}
+	if p.Limit <= 0 {
+		p.Limit = DefaultLimit
+	}
+	if p.Limit > MaxLimit {
+		p.Limit = MaxLimit
+	}
+
+	if p.Cursor != "" {
+		cursor, err := DecodeCursor(p.Cursor)
+		if err != nil {
+			return err
+		}
+		p.cursor = cursor
+	}
+	return nil
+}
+
+// Offset returns the row offset for offset pagination
+func (p *Params) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// IsCursor reports whether the request uses cursor pagination
+func (p *Params) IsCursor() bool {
+	return p.cursor != nil
+}
+
+// Paginate returns a GORM scope applying offset pagination.
+// Usage: db.Scopes(pagination.Paginate(p)).Find(&items)
+func Paginate(p *Params) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Offset(p.Offset()).Limit(p.Limit)
+	}
+}
+
+// Count counts the rows matched by query without pagination applied
+func Count(query *gorm.DB, model interface{}) (int64, error) {
+	var total int64
+	err := query.Session(&gorm.Session{}).Model(model).Count(&total).Error
+	return total, err
+}
@@ -0,0 +1,185 @@
+// Package password checks new passwords against a policy: length, character
+// classes, a deny list, the user's own details and, optionally, known breaches.
+package password
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"flex-service/pkg/i18n"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/validator"
+
+	"go.uber.org/zap"
+)
+
+// Field is the field named in the validation errors
+const Field = "password"
+
+// Rules reported in validator.FieldError.Rule, translated from validation.<rule>
+const (
+	RuleMinLength = "password_min"
+	RuleMaxLength = "password_max"
+	RuleUpper     = "password_upper"
+	RuleLower     = "password_lower"
+	RuleDigit     = "password_digit"
+	RuleSymbol    = "password_symbol"
+	RuleDenied    = "password_denied"
+	RulePersonal  = "password_personal"
+	RuleBreached  = "password_breached"
+)
+
+// bcryptMaxBytes is the longest password bcrypt hashes; it rejects longer ones
+const bcryptMaxBytes = 72
+
+// minPersonalLength is the shortest user detail a password may not contain,
+// so short names don't reject unrelated passwords
+const minPersonalLength = 4
+
+// CommonPasswords are always denied, whatever the policy's DenyList
+var CommonPasswords = []string{
+	"123456", "12345678", "123456789", "1234567890", "1q2w3e4r", "111111", "000000",
+	"password", "password1", "password123", "passw0rd", "p@ssw0rd", "qwerty", "qwerty123",
+	"qwertyuiop", "abc123", "abcd1234", "iloveyou", "letmein", "welcome", "welcome1",
+	"admin", "admin123", "administrator", "changeme", "monkey", "dragon", "sunshine",
+	"football", "baseball", "superman", "trustno1", "master", "secret", "login",
+}
+
+// BreachChecker tells how often a password appeared in known data breaches
+type BreachChecker interface {
+	Breaches(ctx context.Context, password string) (int, error)
+}
+
+// Policy is what a new password must satisfy. The zero value only applies the
+// bcrypt length limit and CommonPasswords.
+type Policy struct {
+	MinLength     int // in characters
+	MaxLength     int // in bytes; 0 or more than 72 means 72, bcrypt's limit
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool // anything but letters, digits and spaces
+	// DenyList holds further passwords refused case-insensitively
+	DenyList []string
+	// Breaches, when set, refuses passwords seen in at least BreachThreshold
+	// breaches (default 1). A failing check lets the password through.
+	Breaches        BreachChecker
+	BreachThreshold int
+}
+
+// Validate checks password, returning every rule it breaks with a message
+// in the locale of ctx, or nil. personal are details of the user, such as
+// the username and email, the password must not contain.
+func (p *Policy) Validate(ctx context.Context, password string, personal ...string) validator.ValidationErrors {
+	locale := i18n.FromContext(ctx)
+	var errs validator.ValidationErrors
+	fail := func(rule string, param string) {
+		errs = append(errs, validator.FieldError{
+			Field:   Field,
+			Rule:    rule,
+			Param:   param,
+			Message: i18n.T(locale, "validation."+rule, i18n.Args{"field": Field, "param": param}),
+		})
+	}
+
+	if p.MinLength > 0 && utf8.RuneCountInString(password) < p.MinLength {
+		fail(RuleMinLength, strconv.Itoa(p.MinLength))
+	}
+	if maxLength := p.maxLength(); len(password) > maxLength {
+		fail(RuleMaxLength, strconv.Itoa(maxLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r) && !unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		fail(RuleUpper, "")
+	}
+	if p.RequireLower && !lower {
+		fail(RuleLower, "")
+	}
+	if p.RequireDigit && !digit {
+		fail(RuleDigit, "")
+	}
+	if p.RequireSymbol && !symbol {
+		fail(RuleSymbol, "")
+	}
+
+	if p.denied(password) {
+		fail(RuleDenied, "")
+	}
+	if containsPersonal(password, personal) {
+		fail(RulePersonal, "")
+	}
+
+	// Only worth a request when the password passes everything else
+	if len(errs) == 0 && p.breached(ctx, password) {
+		fail(RuleBreached, "")
+	}
+	return errs
+}
+
+func (p *Policy) maxLength() int {
+	if p.MaxLength <= 0 || p.MaxLength > bcryptMaxBytes {
+		return bcryptMaxBytes
+	}
+	return p.MaxLength
+}
+
+// denied reports whether password is a common or deny-listed one
+func (p *Policy) denied(password string) bool {
+	for _, list := range [][]string{CommonPasswords, p.DenyList} {
+		for _, deniedPassword := range list {
+			if strings.EqualFold(password, deniedPassword) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// breached asks the breach checker, letting the password through when it fails
+func (p *Policy) breached(ctx context.Context, password string) bool {
+	if p.Breaches == nil {
+		return false
+	}
+	count, err := p.Breaches.Breaches(ctx, password)
+	if err != nil {
+		logger.Warn("Password breach check failed", zap.Error(err))
+		return false
+	}
+	threshold := p.BreachThreshold
+	if threshold <= 0 {
+		threshold = 1
+	}
+	return count >= threshold
+}
+
+// containsPersonal reports whether password contains one of the details, or
+// the local part of an email among them
+func containsPersonal(password string, personal []string) bool {
+	lowered := strings.ToLower(password)
+	for _, detail := range personal {
+		detail = strings.ToLower(strings.TrimSpace(detail))
+		if local, _, found := strings.Cut(detail, "@"); found {
+			detail = local
+		}
+		if utf8.RuneCountInString(detail) >= minPersonalLength && strings.Contains(lowered, detail) {
+			return true
+		}
+	}
+	return false
+}
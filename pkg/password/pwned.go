@@ -0,0 +1,75 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"flex-service/pkg/httpclient"
+)
+
+// DefaultPwnedURL is the Have I Been Pwned range API
+const DefaultPwnedURL = "https://api.pwnedpasswords.com/range/"
+
+// PwnedChecker checks passwords against Have I Been Pwned with k-anonymity:
+// only the first 5 hex characters of the password's SHA-1 leave the process,
+// and the matching suffix is looked up in the returned range.
+type PwnedChecker struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewPwnedChecker creates a checker whose calls, retries included, take at
+// most timeout. baseURL defaults to DefaultPwnedURL.
+func NewPwnedChecker(baseURL string, timeout time.Duration) *PwnedChecker {
+	if baseURL == "" {
+		baseURL = DefaultPwnedURL
+	}
+	return &PwnedChecker{
+		client: httpclient.New(httpclient.Config{
+			Timeout: timeout,
+			Name:    "pwnedpasswords",
+			Retry:   httpclient.DefaultRetryPolicy(),
+		}),
+		baseURL: strings.TrimSuffix(baseURL, "/") + "/",
+	}
+}
+
+// Breaches returns how often password appeared in breaches, 0 if never
+func (c *PwnedChecker) Breaches(ctx context.Context, password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+prefix, nil)
+	if err != nil {
+		return 0, err
+	}
+	// Pads the response so its size doesn't hint at the prefix
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("pwned passwords range API returned %d", resp.StatusCode)
+	}
+
+	// One "SUFFIX:COUNT" per line; padding entries have a count of 0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		candidate, count, found := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if found && strings.EqualFold(candidate, suffix) {
+			return strconv.Atoi(count)
+		}
+	}
+	return 0, scanner.Err()
+}
@@ -0,0 +1,344 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Operator represents a filter comparison operator
+type Operator string
+
+const (
+	OpEq   Operator = "eq"
+	OpNe   Operator = "ne"
+	OpGt   Operator = "gt"
+	OpGte  Operator = "gte"
+	OpLt   Operator = "lt"
+	OpLte  Operator = "lte"
+	OpLike Operator = "like"
+	OpIn   Operator = "in"
+	OpNull Operator = "null"
+)
+
+// sqlOperators maps operators to their SQL form
+var sqlOperators = map[Operator]string{
+	OpEq:   "=",
+	OpNe:   "<>",
+	OpGt:   ">",
+	OpGte:  ">=",
+	OpLt:   "<",
+	OpLte:  "<=",
+	OpLike: "LIKE",
+	OpIn:   "IN",
+}
+
+// Query-related errors
+var (
+	ErrFieldNotFilterable = errors.New("field is not filterable")
+	ErrFieldNotSortable   = errors.New("field is not sortable")
+	ErrFieldNotSelectable = errors.New("field is not selectable")
+	ErrInvalidOperator    = errors.New("invalid filter operator")
+	ErrTooManyFilters     = errors.New("too many filters")
+	ErrTrashedNotAllowed  = errors.New("trashed records cannot be queried")
+	ErrInvalidTrashed     = errors.New("invalid trashed filter")
+	ErrSearchNotAllowed   = errors.New("full-text search is not available")
+	ErrNotIncludable      = errors.New("relation is not includable")
+)
+
+// Trashed selects soft-deleted rows, which GORM excludes by default
+type Trashed string
+
+const (
+	TrashedExclude Trashed = ""     // live rows only
+	TrashedWith    Trashed = "with" // ?with_trashed=1: live and deleted rows
+	TrashedOnly    Trashed = "only" // ?only_trashed=1: deleted rows only
+)
+
+// filterKey matches filter[field] and filter[field][op]
+var filterKey = regexp.MustCompile(`^filter\[([a-zA-Z0-9_]+)\](?:\[([a-z]+)\])?$`)
+
+// Filter represents a single WHERE condition
+type Filter struct {
+	Field    string   `json:"field"`
+	Operator Operator `json:"operator"`
+	Values   []string `json:"values"`
+}
+
+// Sort represents a single ORDER BY clause
+type Sort struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc"`
+}
+
+// Options is the per-entity allowlist of columns that may be queried.
+// Column names are interpolated into SQL, so only trusted identifiers belong here.
+type Options struct {
+	Filterable  []string
+	Sortable    []string
+	Selectable  []string
+	DefaultSort string // e.g. "-created_at"
+	MaxFilters  int
+	// Trashable allows ?with_trashed and ?only_trashed, for entities with a
+	// gorm.DeletedAt deleted_at column
+	Trashable bool
+	// Searchable allows ?search, for entities registered with pkg/search
+	Searchable bool
+	// Includable maps the relations ?include may preload to their association
+	// fields, e.g. {"tags": "Tags"}
+	Includable map[string]string
+}
+
+// Query holds parsed search, filter, sort and sparse fieldset parameters
+type Query struct {
+	Search  string   `json:"search,omitempty"`
+	Filters []Filter `json:"filters"`
+	Sorts   []Sort   `json:"sorts"`
+	Fields  []string `json:"fields"`
+	Include []string `json:"include,omitempty"`
+	Trashed Trashed  `json:"trashed,omitempty"`
+
+	// preloads are the association fields of Include
+	preloads []string
+}
+
+// Bind parses query params from the request against the allowlist
+func Bind(c *gin.Context, opts Options) (*Query, error) {
+	return Parse(c.Request.URL.Query(), opts)
+}
+
+// Parse parses ?search=phone&filter[status]=active&filter[age][gte]=18&sort=-created_at&fields=id,name&include=tags&with_trashed=1
+func Parse(values url.Values, opts Options) (*Query, error) {
+	q := &Query{Search: strings.TrimSpace(values.Get("search"))}
+	if q.Search != "" && !opts.Searchable {
+		return nil, ErrSearchNotAllowed
+	}
+
+	// Iterate keys in order so the generated SQL is stable
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		vals := values[key]
+		match := filterKey.FindStringSubmatch(key)
+		if match == nil {
+			continue
+		}
+
+		field := match[1]
+		if !contains(opts.Filterable, field) {
+			return nil, fmt.Errorf("%w: %s", ErrFieldNotFilterable, field)
+		}
+
+		op := OpEq
+		if match[2] != "" {
+			op = Operator(match[2])
+		}
+		if _, ok := sqlOperators[op]; !ok && op != OpNull {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidOperator, match[2])
+		}
+
+		value := ""
+		if len(vals) > 0 {
+			value = vals[len(vals)-1]
+		}
+
+		filter := Filter{Field: field, Operator: op, Values: []string{value}}
+		if op == OpIn {
+			filter.Values = splitList(value)
+		}
+		q.Filters = append(q.Filters, filter)
+	}
+
+	if opts.MaxFilters > 0 && len(q.Filters) > opts.MaxFilters {
+		return nil, ErrTooManyFilters
+	}
+
+	// Searches without a sort keep the order of relevance
+	sortParam := values.Get("sort")
+	if sortParam == "" && q.Search == "" {
+		sortParam = opts.DefaultSort
+	}
+	for _, item := range splitList(sortParam) {
+		s := Sort{Field: item}
+		if strings.HasPrefix(item, "-") {
+			s = Sort{Field: item[1:], Desc: true}
+		}
+		if !contains(opts.Sortable, s.Field) {
+			return nil, fmt.Errorf("%w: %s", ErrFieldNotSortable, s.Field)
+		}
+		q.Sorts = append(q.Sorts, s)
+	}
+
+	for _, field := range splitList(values.Get("fields")) {
+		if !contains(opts.Selectable, field) {
+			return nil, fmt.Errorf("%w: %s", ErrFieldNotSelectable, field)
+		}
+		q.Fields = append(q.Fields, field)
+	}
+
+	for _, name := range splitList(values.Get("include")) {
+		association, ok := opts.Includable[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNotIncludable, name)
+		}
+		q.Include = append(q.Include, name)
+		q.preloads = append(q.preloads, association)
+	}
+
+	trashed, err := parseTrashed(values)
+	if err != nil {
+		return nil, err
+	}
+	if trashed != TrashedExclude && !opts.Trashable {
+		return nil, ErrTrashedNotAllowed
+	}
+	q.Trashed = trashed
+
+	return q, nil
+}
+
+// parseTrashed reads ?with_trashed and ?only_trashed, each 1/true or 0/false
+func parseTrashed(values url.Values) (Trashed, error) {
+	trashed := TrashedExclude
+	for _, param := range []struct {
+		name    string
+		trashed Trashed
+	}{{"with_trashed", TrashedWith}, {"only_trashed", TrashedOnly}} {
+		value := values.Get(param.name)
+		if value == "" {
+			continue
+		}
+		set, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s=%s", ErrInvalidTrashed, param.name, value)
+		}
+		if !set {
+			continue
+		}
+		if trashed != TrashedExclude {
+			return "", fmt.Errorf("%w: use only one of with_trashed and only_trashed", ErrInvalidTrashed)
+		}
+		trashed = param.trashed
+	}
+	return trashed, nil
+}
+
+// Scope returns a GORM scope applying filters, sorts, selected fields and
+// included relations.
+// Usage: db.Scopes(q.Scope()).Find(&items)
+func (q *Query) Scope() func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if q == nil {
+			return db
+		}
+		db = q.ApplySorts(q.ApplyFilters(db)).Select(q.selectFields())
+		for _, association := range q.preloads {
+			db = db.Preload(association)
+		}
+		return db
+	}
+}
+
+// ApplyFilters applies only the WHERE conditions (useful for Count queries),
+// including which soft-deleted rows to return
+func (q *Query) ApplyFilters(db *gorm.DB) *gorm.DB {
+	if q == nil {
+		return db
+	}
+	switch q.Trashed {
+	case TrashedWith:
+		db = db.Unscoped()
+	case TrashedOnly:
+		db = db.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	for _, f := range q.Filters {
+		switch f.Operator {
+		case OpIn:
+			db = db.Where(fmt.Sprintf("%s IN ?", f.Field), f.Values)
+		case OpNull:
+			if f.Values[0] == "false" {
+				db = db.Where(fmt.Sprintf("%s IS NOT NULL", f.Field))
+			} else {
+				db = db.Where(fmt.Sprintf("%s IS NULL", f.Field))
+			}
+		case OpLike:
+			db = db.Where(fmt.Sprintf("%s LIKE ?", f.Field), "%"+f.Values[0]+"%")
+		default:
+			db = db.Where(fmt.Sprintf("%s %s ?", f.Field, sqlOperators[f.Operator]), f.Values[0])
+		}
+	}
+	return db
+}
+
+// ApplySorts applies only the ORDER BY clauses
+func (q *Query) ApplySorts(db *gorm.DB) *gorm.DB {
+	if q == nil {
+		return db
+	}
+	for _, s := range q.Sorts {
+		if s.Desc {
+			db = db.Order(s.Field + " DESC")
+		} else {
+			db = db.Order(s.Field + " ASC")
+		}
+	}
+	return db
+}
+
+// HasFilter reports whether a filter on field was requested
+func (q *Query) HasFilter(field string) bool {
+	for _, f := range q.Filters {
+		if f.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// ResponseFields returns the fields to serialize: the sparse fieldset and the
+// included relations, or nil for every field
+func (q *Query) ResponseFields() []string {
+	if q == nil || len(q.Fields) == 0 {
+		return nil
+	}
+	return append(q.Fields[:len(q.Fields):len(q.Fields)], q.Include...)
+}
+
+// selectFields returns every column when relations are included, since
+// preloads match them on key columns ?fields may leave out
+func (q *Query) selectFields() interface{} {
+	if len(q.Fields) == 0 || len(q.Include) > 0 {
+		return "*"
+	}
+	return q.Fields
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
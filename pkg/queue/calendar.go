@@ -0,0 +1,174 @@
+package queue
+
+import (
+	"fmt"
+	"time"
+
+	appTime "flex-service/pkg/time"
+)
+
+// Window is a recurring blackout period, e.g. peak hours during which heavy
+// jobs must not run. Start and End are "HH:MM" in the schedule's location; an
+// End at or before Start spans midnight ("22:00"-"02:00"), and equal values
+// black out the whole day.
+type Window struct {
+	Days  []time.Weekday // Days the window starts on; empty means every day
+	Start string
+	End   string
+}
+
+// Calendar describes business days: everything except weekends and holidays
+type Calendar struct {
+	Weekend  []time.Weekday // Defaults to Saturday and Sunday
+	Holidays []string       // Dates as "2006-01-02"
+}
+
+// IsBusinessDay reports whether t falls on a business day in t's location
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	weekend := c.Weekend
+	if weekend == nil {
+		weekend = []time.Weekday{time.Saturday, time.Sunday}
+	}
+	for _, day := range weekend {
+		if t.Weekday() == day {
+			return false
+		}
+	}
+
+	date := t.Format("2006-01-02")
+	for _, holiday := range c.Holidays {
+		if holiday == date {
+			return false
+		}
+	}
+	return true
+}
+
+// Schedule controls when jobs of one type may run. Jobs popped outside the
+// schedule go back to the delayed queue until Next allows them, without using
+// up an attempt.
+type Schedule struct {
+	Blackouts []Window
+	// Calendar, when set, restricts jobs to business days
+	Calendar *Calendar
+	// Location the windows and calendar are read in; defaults to the app timezone
+	Location *time.Location
+}
+
+// Validate checks the window times and holiday dates
+func (s *Schedule) Validate() error {
+	for i, w := range s.Blackouts {
+		if _, err := clockMinutes(w.Start); err != nil {
+			return fmt.Errorf("blackout %d: start: %w", i, err)
+		}
+		if _, err := clockMinutes(w.End); err != nil {
+			return fmt.Errorf("blackout %d: end: %w", i, err)
+		}
+	}
+	if s.Calendar != nil {
+		for _, holiday := range s.Calendar.Holidays {
+			if _, err := time.Parse("2006-01-02", holiday); err != nil {
+				return fmt.Errorf("invalid holiday %q: %w", holiday, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Next returns the earliest time at or after t at which a job may run. ok is
+// false when the schedule never allows a run, e.g. every day is blacked out.
+func (s *Schedule) Next(t time.Time) (next time.Time, ok bool) {
+	loc := s.Location
+	if loc == nil {
+		loc = appTime.GetLocation()
+	}
+	next = t.In(loc)
+
+	// Each step moves past a blackout or a non-business day, so two years of
+	// steps means nothing is ever allowed
+	for i := 0; i < 2*366*(len(s.Blackouts)+1); i++ {
+		if s.Calendar != nil && !s.Calendar.IsBusinessDay(next) {
+			next = startOfDay(next, 1)
+			continue
+		}
+		end, blocked := s.blackoutEnd(next)
+		if !blocked {
+			return next, true
+		}
+		next = end
+	}
+	return time.Time{}, false
+}
+
+// Allows reports whether a job may run at t
+func (s *Schedule) Allows(t time.Time) bool {
+	next, ok := s.Next(t)
+	return ok && !next.After(t)
+}
+
+// blackoutEnd returns when the latest-ending window that contains t ends
+func (s *Schedule) blackoutEnd(t time.Time) (time.Time, bool) {
+	var (
+		end     time.Time
+		blocked bool
+	)
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range s.Blackouts {
+		start, err := clockMinutes(w.Start)
+		if err != nil {
+			continue
+		}
+		stop, err := clockMinutes(w.End)
+		if err != nil {
+			continue
+		}
+
+		var until time.Time
+		switch {
+		case start < stop:
+			if w.startsOn(t.Weekday()) && minute >= start && minute < stop {
+				until = atMinute(t, 0, stop)
+			}
+		case minute >= start && w.startsOn(t.Weekday()):
+			// Started today and ends tomorrow
+			until = atMinute(t, 1, stop)
+		case minute < stop && w.startsOn(t.AddDate(0, 0, -1).Weekday()):
+			// Started yesterday and ends today
+			until = atMinute(t, 0, stop)
+		}
+
+		if !until.IsZero() && until.After(end) {
+			end, blocked = until, true
+		}
+	}
+	return end, blocked
+}
+
+func (w Window) startsOn(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// clockMinutes parses "HH:MM" into minutes since midnight
+func clockMinutes(clock string) (int, error) {
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", clock)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+func atMinute(t time.Time, days, minute int) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()+days, minute/60, minute%60, 0, 0, t.Location())
+}
+
+func startOfDay(t time.Time, days int) time.Time {
+	return atMinute(t, days, 0)
+}
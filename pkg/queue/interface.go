@@ -18,6 +18,8 @@ type Job struct {
 	ProcessedAt *time.Time             `json:"processed_at,omitempty"`
 	FailedAt    *time.Time             `json:"failed_at,omitempty"`
 	Error       string                 `json:"error,omitempty"`
+	// IgnoreSchedule runs the job even during a blackout of its type's Schedule
+	IgnoreSchedule bool `json:"ignore_schedule,omitempty"`
 }
 
 // JobStatus represents the status of a job
@@ -75,6 +77,10 @@ type Queue interface {
 	// GetJobStatus returns the current status of a job
 	GetJobStatus(jobID string) (JobStatus, error)
 
+	// Defer moves a popped job back to the delayed queue until the given time
+	// without counting an attempt
+	Defer(jobID string, until time.Time) error
+
 	// CancelJob cancels a pending job
 	CancelJob(jobID string) error
 
@@ -99,6 +105,12 @@ type Worker interface {
 	// Stop stops the worker gracefully
 	Stop() error
 
+	// Pause stops taking new jobs and waits for in-flight jobs to finish
+	Pause(ctx context.Context) error
+
+	// Resume starts taking jobs again after Pause
+	Resume()
+
 	// IsRunning returns whether the worker is currently running
 	IsRunning() bool
 }
@@ -121,6 +133,8 @@ type JobOptions struct {
 	Priority    int           `json:"priority"`
 	Delay       time.Duration `json:"delay"`
 	Queue       string        `json:"queue"` // Queue name for multiple queues
+	// IgnoreSchedule bypasses blackout windows and the business calendar
+	IgnoreSchedule bool `json:"ignore_schedule"`
 }
 
 // Manager defines the interface for queue management
@@ -0,0 +1,185 @@
+package queue
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+var (
+	// ErrPayloadDecryption is returned for sealed payloads that can't be
+	// opened, e.g. after the app key changed
+	ErrPayloadDecryption = errors.New("failed to decrypt job payload")
+	// ErrMissingReference is returned when a PII field is set but the field
+	// it is re-fetched by is not, so the job can't be queued without the PII
+	ErrMissingReference = errors.New("PII field has no reference to re-fetch it by")
+)
+
+// sealedVersion prefixes sealed payloads so the format can change
+const sealedVersion = "v1:"
+
+// PayloadEncryptor seals job payloads with AES-256-GCM before they are
+// written to Redis. The key is derived from the application key, and the job
+// ID is authenticated with the payload so sealed payloads can't be swapped
+// between jobs.
+type PayloadEncryptor struct {
+	aead cipher.AEAD
+}
+
+// NewPayloadEncryptor derives the payload key from appKey, e.g. ENCRYPT_KEY
+func NewPayloadEncryptor(appKey string) (*PayloadEncryptor, error) {
+	if appKey == "" {
+		return nil, fmt.Errorf("queue payload encryption requires an app key")
+	}
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(appKey), nil, []byte("flex-service queue payload")), key); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &PayloadEncryptor{aead: aead}, nil
+}
+
+// Seal encrypts the payload of job
+func (e *PayloadEncryptor) Seal(jobID string, payload map[string]interface{}) (string, error) {
+	plaintext, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := e.aead.Seal(nonce, nonce, plaintext, []byte(jobID))
+	return sealedVersion + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a payload sealed for the job
+func (e *PayloadEncryptor) Open(jobID, sealed string) (map[string]interface{}, error) {
+	encoded, ok := strings.CutPrefix(sealed, sealedVersion)
+	if !ok {
+		return nil, ErrPayloadDecryption
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < e.aead.NonceSize() {
+		return nil, ErrPayloadDecryption
+	}
+
+	nonce, ciphertext := data[:e.aead.NonceSize()], data[e.aead.NonceSize():]
+	plaintext, err := e.aead.Open(nil, nonce, ciphertext, []byte(jobID))
+	if err != nil {
+		return nil, ErrPayloadDecryption
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(plaintext, &payload); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	return payload, nil
+}
+
+// Resolver re-fetches a scrubbed value from its reference, e.g. a user's
+// email from the user ID
+type Resolver func(ctx context.Context, ref interface{}) (interface{}, error)
+
+// PIIField declares a payload field that is never stored in the queue. Ref
+// names the payload field it is re-fetched by, which is kept.
+type PIIField struct {
+	Field   string // e.g. "to"
+	Ref     string // e.g. "user_id"
+	Resolve Resolver
+}
+
+// scrubbedMarker replaces a scrubbed value in stored payloads
+const scrubbedMarker = "$pii"
+
+// Scrubber minimizes the PII kept in queued jobs: declared fields are
+// replaced by a marker when a job is pushed and re-fetched through their
+// resolver right before the job is handled
+type Scrubber struct {
+	mu     sync.RWMutex
+	fields map[string][]PIIField
+}
+
+// NewScrubber creates a scrubber with no PII fields
+func NewScrubber() *Scrubber {
+	return &Scrubber{fields: make(map[string][]PIIField)}
+}
+
+// Register declares the PII fields of a job type
+func (s *Scrubber) Register(jobType string, fields ...PIIField) *Scrubber {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fields[jobType] = append(s.fields[jobType], fields...)
+	return s
+}
+
+// Scrub returns a copy of the payload without the job type's PII fields
+func (s *Scrubber) Scrub(jobType string, payload map[string]interface{}) (map[string]interface{}, error) {
+	s.mu.RLock()
+	fields := s.fields[jobType]
+	s.mu.RUnlock()
+	if len(fields) == 0 {
+		return payload, nil
+	}
+
+	scrubbed := make(map[string]interface{}, len(payload))
+	for key, value := range payload {
+		scrubbed[key] = value
+	}
+	for _, field := range fields {
+		if _, ok := scrubbed[field.Field]; !ok {
+			continue
+		}
+		if ref, ok := payload[field.Ref]; !ok || ref == nil {
+			return nil, fmt.Errorf("%w: %s.%s needs %s", ErrMissingReference, jobType, field.Field, field.Ref)
+		}
+		scrubbed[field.Field] = map[string]interface{}{scrubbedMarker: field.Ref}
+	}
+	return scrubbed, nil
+}
+
+// Restore re-fetches the scrubbed fields of a job's payload in place
+func (s *Scrubber) Restore(ctx context.Context, job *Job) error {
+	s.mu.RLock()
+	fields := s.fields[job.Type]
+	s.mu.RUnlock()
+
+	for _, field := range fields {
+		marker, ok := job.Payload[field.Field].(map[string]interface{})
+		if !ok || marker[scrubbedMarker] == nil {
+			continue
+		}
+		value, err := field.Resolve(ctx, job.Payload[field.Ref])
+		if err != nil {
+			return fmt.Errorf("failed to re-fetch %s.%s: %w", job.Type, field.Field, err)
+		}
+		job.Payload[field.Field] = value
+	}
+	return nil
+}
+
+// PayloadRestorer is implemented by queues that scrub payloads; workers call
+// it before handing a job to its handler
+type PayloadRestorer interface {
+	RestorePayload(ctx context.Context, job *Job) error
+}
@@ -17,6 +17,8 @@ type RedisQueue struct {
 	prefix      string
 	retryDelays []time.Duration
 	maxRetries  int
+	encryptor   *PayloadEncryptor
+	scrubber    *Scrubber
 }
 
 // RedisQueueConfig holds configuration for Redis queue
@@ -29,6 +31,10 @@ type RedisQueueConfig struct {
 	MaxRetries   int
 	RetryDelays  []time.Duration
 	Prefix       string
+	// Encryptor seals payloads at rest; nil stores them in plain JSON
+	Encryptor *PayloadEncryptor
+	// Scrubber keeps PII fields out of stored payloads
+	Scrubber *Scrubber
 }
 
 // NewRedisQueue creates a new Redis-based queue
@@ -76,6 +82,8 @@ func NewRedisQueue(name string, config *RedisQueueConfig) (*RedisQueue, error) {
 		prefix:      prefix,
 		retryDelays: retryDelays,
 		maxRetries:  maxRetries,
+		encryptor:   config.Encryptor,
+		scrubber:    config.Scrubber,
 	}, nil
 }
 
@@ -99,10 +107,18 @@ func (rq *RedisQueue) PushDelayed(job *Job, delay time.Duration) error {
 		job.MaxAttempts = rq.maxRetries
 	}
 
-	// Serialize job
-	jobData, err := json.Marshal(job)
+	// Serialize job, without its PII fields
+	stored := *job
+	if rq.scrubber != nil {
+		payload, err := rq.scrubber.Scrub(job.Type, job.Payload)
+		if err != nil {
+			return err
+		}
+		stored.Payload = payload
+	}
+	jobData, err := rq.marshalJob(&stored)
 	if err != nil {
-		return fmt.Errorf("failed to marshal job: %w", err)
+		return err
 	}
 
 	// Store job data
@@ -257,6 +273,22 @@ func (rq *RedisQueue) Nack(jobID string, jobErr error) error {
 	return rq.updateJob(job)
 }
 
+// Defer moves a popped job back to the delayed queue until the given time
+func (rq *RedisQueue) Defer(jobID string, until time.Time) error {
+	ctx := context.Background()
+
+	pipe := rq.client.TxPipeline()
+	pipe.SRem(ctx, rq.processingKey(), jobID)
+	pipe.ZAdd(ctx, rq.delayedKey(), redis.Z{
+		Score:  float64(until.Unix()),
+		Member: jobID,
+	})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to defer job: %w", err)
+	}
+	return nil
+}
+
 // GetJob retrieves a job by ID
 func (rq *RedisQueue) GetJob(jobID string) (*Job, error) {
 	ctx := context.Background()
@@ -271,12 +303,15 @@ func (rq *RedisQueue) GetJob(jobID string) (*Job, error) {
 		return nil, fmt.Errorf("failed to get job: %w", result.Err())
 	}
 
-	var job Job
-	if err := json.Unmarshal([]byte(result.Val()), &job); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
-	}
+	return rq.unmarshalJob([]byte(result.Val()))
+}
 
-	return &job, nil
+// RestorePayload re-fetches the PII fields the scrubber removed
+func (rq *RedisQueue) RestorePayload(ctx context.Context, job *Job) error {
+	if rq.scrubber == nil {
+		return nil
+	}
+	return rq.scrubber.Restore(ctx, job)
 }
 
 // GetJobStatus returns the current status of a job
@@ -440,15 +475,63 @@ func (rq *RedisQueue) moveDelayedJobs() error {
 func (rq *RedisQueue) updateJob(job *Job) error {
 	ctx := context.Background()
 
-	jobData, err := json.Marshal(job)
+	jobData, err := rq.marshalJob(job)
 	if err != nil {
-		return fmt.Errorf("failed to marshal job: %w", err)
+		return err
 	}
 
 	jobKey := rq.jobKey(job.ID)
 	return rq.client.Set(ctx, jobKey, jobData, 0).Err()
 }
 
+// storedJob is a job as written to Redis. With an encryptor its payload is
+// kept sealed in SealedPayload instead of Payload.
+type storedJob struct {
+	*Job
+	SealedPayload string `json:"sealed_payload,omitempty"`
+}
+
+func (rq *RedisQueue) marshalJob(job *Job) ([]byte, error) {
+	stored := storedJob{Job: job}
+	if rq.encryptor != nil {
+		sealed, err := rq.encryptor.Seal(job.ID, job.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt job payload: %w", err)
+		}
+		withoutPayload := *job
+		withoutPayload.Payload = nil
+		stored = storedJob{Job: &withoutPayload, SealedPayload: sealed}
+	}
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job: %w", err)
+	}
+	return data, nil
+}
+
+// unmarshalJob reads plain and sealed jobs alike, so encryption can be
+// turned on with jobs already queued
+func (rq *RedisQueue) unmarshalJob(data []byte) (*Job, error) {
+	stored := storedJob{Job: &Job{}}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+	if stored.SealedPayload == "" {
+		return stored.Job, nil
+	}
+
+	if rq.encryptor == nil {
+		return nil, fmt.Errorf("%w: job %s is encrypted but no encryptor is configured", ErrPayloadDecryption, stored.ID)
+	}
+	payload, err := rq.encryptor.Open(stored.ID, stored.SealedPayload)
+	if err != nil {
+		return nil, fmt.Errorf("job %s: %w", stored.ID, err)
+	}
+	stored.Job.Payload = payload
+	return stored.Job, nil
+}
+
 // Key generation methods
 func (rq *RedisQueue) queueKey() string {
 	return fmt.Sprintf("%s:%s:queue", rq.prefix, rq.name)
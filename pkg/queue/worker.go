@@ -1,12 +1,17 @@
 package queue
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"flex-service/pkg/httpclient"
 	"flex-service/pkg/logger"
 
 	"go.uber.org/zap"
@@ -24,6 +29,11 @@ type RedisWorker struct {
 	cancel     context.CancelFunc
 	wg         sync.WaitGroup
 	logger     *zap.Logger
+	paused     atomic.Bool
+	inFlight   atomic.Int64
+	schedules  map[string]*Schedule
+	// ignoreSchedules runs every job immediately, e.g. during an incident
+	ignoreSchedules atomic.Bool
 }
 
 // WorkerConfig holds configuration for Redis worker
@@ -31,6 +41,8 @@ type WorkerConfig struct {
 	NumWorkers int           // Number of concurrent workers
 	PollTime   time.Duration // How often to poll for jobs
 	Logger     *zap.Logger   // Logger instance
+	// Schedules restricts when jobs of a type run, keyed by job type
+	Schedules map[string]*Schedule
 }
 
 // NewRedisWorker creates a new Redis-based worker
@@ -54,12 +66,46 @@ func NewRedisWorker(queue Queue, config *WorkerConfig) *RedisWorker {
 		workerLogger = logger.Logger // Use default logger
 	}
 
-	return &RedisWorker{
+	w := &RedisWorker{
 		queue:      queue,
 		handlers:   make(map[string]Handler),
 		numWorkers: numWorkers,
 		pollTime:   pollTime,
 		logger:     workerLogger,
+		schedules:  make(map[string]*Schedule),
+	}
+	for jobType, schedule := range config.Schedules {
+		if err := w.SetSchedule(jobType, schedule); err != nil {
+			workerLogger.Error("Invalid job schedule ignored", zap.String("job_type", jobType), zap.Error(err))
+		}
+	}
+	return w
+}
+
+// SetSchedule restricts when jobs of jobType run; a nil schedule removes it
+func (w *RedisWorker) SetSchedule(jobType string, schedule *Schedule) error {
+	if schedule != nil {
+		if err := schedule.Validate(); err != nil {
+			return err
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if schedule == nil {
+		delete(w.schedules, jobType)
+		return nil
+	}
+	w.schedules[jobType] = schedule
+	return nil
+}
+
+// IgnoreSchedules turns every schedule off (true) or back on (false) without
+// removing them, so deferred jobs can be forced through
+func (w *RedisWorker) IgnoreSchedules(ignore bool) {
+	if w.ignoreSchedules.Swap(ignore) != ignore {
+		w.logger.Info("Job schedules override changed", zap.Bool("ignore_schedules", ignore))
 	}
 }
 
@@ -131,6 +177,38 @@ func (w *RedisWorker) Stop() error {
 	return nil
 }
 
+// Pause stops taking new jobs and waits until in-flight jobs finish or ctx is done.
+// Jobs stay in the queue for another worker (e.g. the process taking over after an upgrade).
+func (w *RedisWorker) Pause(ctx context.Context) error {
+	if !w.paused.Swap(true) {
+		w.logger.Info("Pausing worker")
+	}
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for w.inFlight.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// Resume starts taking jobs again after Pause
+func (w *RedisWorker) Resume() {
+	if w.paused.Swap(false) {
+		w.logger.Info("Resuming worker")
+	}
+}
+
+// IsPaused returns whether the worker is paused
+func (w *RedisWorker) IsPaused() bool {
+	return w.paused.Load()
+}
+
 // IsRunning returns whether the worker is currently running
 func (w *RedisWorker) IsRunning() bool {
 	w.mu.RLock()
@@ -161,6 +239,14 @@ func (w *RedisWorker) workerLoop(workerID int) {
 
 // processNextJob processes the next available job
 func (w *RedisWorker) processNextJob(workerLogger *zap.Logger) {
+	// Count before checking paused so Pause never misses a job that is about to start
+	w.inFlight.Add(1)
+	defer w.inFlight.Add(-1)
+
+	if w.paused.Load() {
+		return
+	}
+
 	// Get next job
 	job, err := w.queue.Pop()
 	if err != nil {
@@ -186,6 +272,15 @@ func (w *RedisWorker) processJob(job *Job, workerLogger *zap.Logger) {
 		zap.Int("max_attempts", job.MaxAttempts),
 	)
 
+	if until, deferred := w.deferUntil(job); deferred {
+		if err := w.queue.Defer(job.ID, until); err != nil {
+			jobLogger.Error("Failed to defer job", zap.Error(err))
+			return
+		}
+		jobLogger.Info("Job deferred by schedule", zap.Time("until", until))
+		return
+	}
+
 	jobLogger.Info("Processing job")
 	startTime := time.Now()
 
@@ -220,6 +315,18 @@ func (w *RedisWorker) processJob(job *Job, workerLogger *zap.Logger) {
 	jobCtx, cancel := context.WithTimeout(w.ctx, 5*time.Minute)
 	defer cancel()
 
+	// Re-fetch the PII fields kept out of the queue
+	if restorer, ok := w.queue.(PayloadRestorer); ok {
+		if err := restorer.RestorePayload(jobCtx, job); err != nil {
+			jobLogger.Error("Failed to restore job payload", zap.Error(err))
+
+			if nackErr := w.queue.Nack(job.ID, err); nackErr != nil {
+				jobLogger.Error("Failed to nack job", zap.Error(nackErr))
+			}
+			return
+		}
+	}
+
 	// Process the job
 	result := handler.Handle(jobCtx, job)
 
@@ -247,6 +354,28 @@ func (w *RedisWorker) processJob(job *Job, workerLogger *zap.Logger) {
 	}
 }
 
+// deferUntil returns when a job may run if its type's schedule blocks it now.
+// A schedule that never allows a run defers the job by a day so it is not lost.
+func (w *RedisWorker) deferUntil(job *Job) (time.Time, bool) {
+	if job.IgnoreSchedule || w.ignoreSchedules.Load() {
+		return time.Time{}, false
+	}
+
+	w.mu.RLock()
+	schedule, exists := w.schedules[job.Type]
+	w.mu.RUnlock()
+	if !exists {
+		return time.Time{}, false
+	}
+
+	now := time.Now()
+	next, ok := schedule.Next(now)
+	if !ok {
+		return now.Add(24 * time.Hour), true
+	}
+	return next, next.After(now)
+}
+
 // JobDispatcher helps with job creation and dispatching
 type JobDispatcher struct {
 	queue Queue
@@ -276,6 +405,7 @@ func (jd *JobDispatcher) Dispatch(jobType string, payload map[string]interface{}
 		}
 		job.Priority = opt.Priority
 		job.Delay = opt.Delay
+		job.IgnoreSchedule = opt.IgnoreSchedule
 	}
 
 	if job.Delay > 0 {
@@ -304,6 +434,7 @@ func (jd *JobDispatcher) DispatchDelayed(jobType string, payload map[string]inte
 			job.MaxAttempts = opt.MaxAttempts
 		}
 		job.Priority = opt.Priority
+		job.IgnoreSchedule = opt.IgnoreSchedule
 	}
 
 	return jd.queue.PushDelayed(job, delay)
@@ -320,11 +451,21 @@ const (
 	JobTypeCleanup           = "cleanup"
 	JobTypeWebhook           = "webhook"
 	JobTypeBackup            = "backup"
+	JobTypeNotification      = "notification"
+	JobTypeAudit             = "audit"
+	JobTypeSearchSync        = "search_sync"
 )
 
 // Helper function to create job handlers
 
-// EmailJobHandler creates a handler for email jobs
+// EmailSender is the plain-text send method of mail.Mailer
+type EmailSender interface {
+	SendEmail(to []string, subject, body string, attachments []string) error
+}
+
+// EmailJobHandler creates a handler for simple email jobs (to, subject, body).
+// mailer must implement EmailSender (e.g. *mail.Mailer); pkg/email's JobHandler
+// handles templated emails with attachments.
 func EmailJobHandler(mailer interface{}) Handler {
 	return HandlerFunc(func(ctx context.Context, job *Job) *JobResult {
 		// Extract email data from job payload
@@ -339,8 +480,20 @@ func EmailJobHandler(mailer interface{}) Handler {
 			}
 		}
 
-		// Here you would use your actual mailer
-		// For example: mailer.SendEmail([]string{to}, subject, body, nil)
+		sender, ok := mailer.(EmailSender)
+		if !ok {
+			return &JobResult{
+				Success: false,
+				Error:   fmt.Sprintf("email job handler mailer %T cannot send email", mailer),
+			}
+		}
+
+		if err := sender.SendEmail([]string{to}, subject, body, nil); err != nil {
+			return &JobResult{
+				Success: false,
+				Error:   err.Error(),
+			}
+		}
 
 		logger.Info("Email job processed",
 			zap.String("job_id", job.ID),
@@ -359,7 +512,13 @@ func EmailJobHandler(mailer interface{}) Handler {
 	})
 }
 
-// WebhookJobHandler creates a handler for webhook jobs
+// webhookClient sends ad-hoc webhook jobs. It doesn't retry by itself since
+// failed jobs are retried by the queue.
+var webhookClient = httpclient.New(httpclient.Config{Timeout: 10 * time.Second, Name: "queue.webhook"})
+
+// WebhookJobHandler creates a handler for ad-hoc webhook jobs (url, method, data, headers).
+// The data is sent as JSON and any non-2xx response fails the job so the queue retries it.
+// Registered endpoints with signing and delivery logs are handled by pkg/webhook.
 func WebhookJobHandler() Handler {
 	return HandlerFunc(func(ctx context.Context, job *Job) *JobResult {
 		url, _ := job.Payload["url"].(string)
@@ -377,22 +536,46 @@ func WebhookJobHandler() Handler {
 			method = "POST"
 		}
 
+		body, err := json.Marshal(data)
+		if err != nil {
+			return &JobResult{Success: false, Error: fmt.Sprintf("invalid webhook data: %v", err)}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+		if err != nil {
+			return &JobResult{Success: false, Error: err.Error()}
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if headers, ok := job.Payload["headers"].(map[string]interface{}); ok {
+			for name, value := range headers {
+				req.Header.Set(name, fmt.Sprint(value))
+			}
+		}
+
+		resp, err := webhookClient.Do(req)
+		if err != nil {
+			return &JobResult{Success: false, Error: err.Error()}
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return &JobResult{Success: false, Error: fmt.Sprintf("webhook responded %s", resp.Status)}
+		}
+
 		logger.Info("Webhook job processed",
 			zap.String("job_id", job.ID),
 			zap.String("url", url),
 			zap.String("method", method),
+			zap.Int("status", resp.StatusCode),
 		)
 
-		// Here you would make the actual HTTP request
-		// For example: http.Post(url, "application/json", bytes.NewBuffer(jsonData))
-
 		return &JobResult{
 			Success: true,
 			Data: map[string]interface{}{
 				"url":     url,
 				"method":  method,
+				"status":  resp.StatusCode,
 				"sent_at": time.Now(),
-				"payload": data,
 			},
 		}
 	})
@@ -0,0 +1,91 @@
+package rate_limit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"flex-service/pkg/cache"
+)
+
+// Algorithm selects how a policy counts requests in its window
+type Algorithm string
+
+const (
+	// FixedWindow counts requests in consecutive windows, so a client can
+	// send up to twice the limit across a window boundary
+	FixedWindow Algorithm = "fixed_window"
+	// SlidingWindow weights the previous window's count by how much of it
+	// still overlaps the last Window, smoothing out boundary bursts
+	SlidingWindow Algorithm = "sliding_window"
+)
+
+// verdict is the outcome of counting one request
+type verdict struct {
+	allowed   bool
+	remaining int
+	reset     time.Duration
+}
+
+// count records one request under key and reports whether it is within limit
+func count(ctx context.Context, store cache.Cache, algorithm Algorithm, key string, limit int, window time.Duration) (verdict, error) {
+	if algorithm == SlidingWindow {
+		return slidingWindow(ctx, store, key, limit, window)
+	}
+	return fixedWindow(ctx, store, key, limit, window)
+}
+
+func fixedWindow(ctx context.Context, store cache.Cache, key string, limit int, window time.Duration) (verdict, error) {
+	n, err := store.Incr(ctx, key)
+	if err != nil {
+		return verdict{}, err
+	}
+	if n == 1 {
+		if err := store.Expire(ctx, key, window); err != nil {
+			return verdict{}, err
+		}
+	}
+
+	reset, err := store.TTL(ctx, key)
+	if err != nil || reset < 0 {
+		reset = window
+	}
+	return verdict{
+		allowed:   n <= int64(limit),
+		remaining: max(limit-int(n), 0),
+		reset:     reset,
+	}, nil
+}
+
+func slidingWindow(ctx context.Context, store cache.Cache, key string, limit int, window time.Duration) (verdict, error) {
+	now := time.Now().UnixNano()
+	index := now / int64(window)
+	elapsed := time.Duration(now - index*int64(window))
+
+	current := fmt.Sprintf("%s:%d", key, index)
+	n, err := store.Incr(ctx, current)
+	if err != nil {
+		return verdict{}, err
+	}
+	if n == 1 {
+		// Kept for a second window, while it is the previous one
+		if err := store.Expire(ctx, current, 2*window); err != nil {
+			return verdict{}, err
+		}
+	}
+
+	var previous int64
+	if raw, err := store.Get(ctx, fmt.Sprintf("%s:%d", key, index-1)); err == nil {
+		previous, _ = strconv.ParseInt(raw, 10, 64)
+	}
+
+	overlap := 1 - float64(elapsed)/float64(window)
+	estimate := int(math.Ceil(float64(previous)*overlap)) + int(n)
+	return verdict{
+		allowed:   estimate <= limit,
+		remaining: max(limit-estimate, 0),
+		reset:     window - elapsed,
+	}, nil
+}
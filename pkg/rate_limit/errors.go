@@ -0,0 +1,8 @@
+package rate_limit
+
+import "errors"
+
+var (
+	// ErrInvalidPolicy is wrapped by every policy validation error
+	ErrInvalidPolicy = errors.New("invalid rate limit policy")
+)
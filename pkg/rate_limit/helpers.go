@@ -1,5 +1,15 @@
 package rate_limit
 
+import (
+	"strconv"
+	"strings"
+
+	"flex-service/pkg/i18n"
+	"flex-service/pkg/requestctx"
+
+	"github.com/gin-gonic/gin"
+)
+
 // mergeConfig merges custom config with instance config
 func (r *rateLimit) mergeConfig(customConfig *RateLimitConfig) *RateLimitConfig {
 	merged := &RateLimitConfig{}
@@ -11,7 +21,10 @@ func (r *rateLimit) mergeConfig(customConfig *RateLimitConfig) *RateLimitConfig
 		merged.KeyGenerator = r.config.KeyGenerator
 		merged.Skip = r.config.Skip
 		merged.Message = r.config.Message
+		merged.MessageKey = r.config.MessageKey
+		merged.MessageArgs = r.config.MessageArgs
 		merged.OnRateLimited = r.config.OnRateLimited
+		merged.Quota = r.config.Quota
 	}
 
 	// Override with custom config if provided
@@ -30,11 +43,61 @@ func (r *rateLimit) mergeConfig(customConfig *RateLimitConfig) *RateLimitConfig
 		}
 		if customConfig.Message != "" {
 			merged.Message = customConfig.Message
+			merged.MessageKey = customConfig.MessageKey
+			merged.MessageArgs = customConfig.MessageArgs
 		}
 		if customConfig.OnRateLimited != nil {
 			merged.OnRateLimited = customConfig.OnRateLimited
 		}
+		if customConfig.Quota != nil {
+			merged.Quota = customConfig.Quota
+		}
 	}
 
 	return merged
 }
+
+// quota returns the Quota of principal-keyed limiters, which applies the
+// instance's Quotas to authenticated requests
+func (r *rateLimit) quota() func(c *gin.Context, limit int) int {
+	if r.config == nil || r.config.Quotas == nil {
+		return nil
+	}
+	quotas := r.config.Quotas
+	return func(c *gin.Context, limit int) int {
+		principal, ok := requestctx.CurrentPrincipal(c)
+		if !ok {
+			return limit
+		}
+		return quotas.Limit(c.Request.Context(), principal, limit)
+	}
+}
+
+// withLimit returns config with its message reporting limit, when a quota
+// changed it
+func withLimit(config *RateLimitConfig, limit int) *RateLimitConfig {
+	if limit == config.Limit || config.MessageArgs["limit"] == nil {
+		return config
+	}
+	adjusted := *config
+	adjusted.MessageArgs = make(map[string]interface{}, len(config.MessageArgs))
+	for k, v := range config.MessageArgs {
+		adjusted.MessageArgs[k] = v
+	}
+	adjusted.MessageArgs["limit"] = limit
+	adjusted.Message = strings.Replace(config.Message, strconv.Itoa(config.Limit), strconv.Itoa(limit), 1)
+	return &adjusted
+}
+
+// localizedMessage translates the configured message with the request locale,
+// falling back to the plain Message when no key is set or the key is unknown
+func localizedMessage(c *gin.Context, config *RateLimitConfig) string {
+	if config.MessageKey == "" {
+		return config.Message
+	}
+	message, ok := i18n.Lookup(i18n.Locale(c), config.MessageKey)
+	if !ok {
+		return config.Message
+	}
+	return i18n.Format(message, config.MessageArgs)
+}
@@ -19,8 +19,18 @@ type RateLimitConfig struct {
 	Skip func(c *gin.Context) bool
 	// Custom error message
 	Message string
+	// i18n key for Message, translated with the request locale (e.g. "rate_limit.ip")
+	MessageKey string
+	// Placeholder values for MessageKey
+	MessageArgs map[string]interface{}
 	// Custom error handler
 	OnRateLimited func(c *gin.Context, limit int, window time.Duration)
+	// Quota adjusts Limit for the request, e.g. by the caller's plan;
+	// returning Unlimited skips the check
+	Quota func(c *gin.Context, limit int) int
+	// Quotas raises the limits of UserRateLimit and APIKeyRateLimit by the
+	// principal's plan and roles (instance config only)
+	Quotas *Quotas
 }
 
 // DefaultRateLimitConfig returns default rate limiting configuration
@@ -0,0 +1,266 @@
+package rate_limit
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"flex-service/pkg/cache"
+	"flex-service/pkg/i18n"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/requestctx"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// appliedContextKey holds the names of the policies already counted for a
+// request, so the middleware can run more than once in a chain
+const appliedContextKey = "rate_limit.policies"
+
+// PolicyConfig configures the policy middleware
+type PolicyConfig struct {
+	// Skip bypasses every policy for certain requests
+	Skip func(c *gin.Context) bool
+	// Plan returns the subscription plan of the caller (default: the
+	// principal's Plan, looked up by Quotas when it has none)
+	Plan func(c *gin.Context, principal requestctx.Principal) string
+	// Quotas scales the limit of user and api_key policies without a plans
+	// entry for the caller's plan or roles
+	Quotas *Quotas
+}
+
+// Policies applies the declared rate limit policies to the routes they match
+type Policies struct {
+	cache  cache.Cache
+	file   []Policy
+	store  PolicyStore
+	config PolicyConfig
+	// current is the compiled policy list, swapped whole on Reload
+	current atomic.Pointer[[]*compiledPolicy]
+}
+
+// NewPolicies creates the policy middleware from the policies file and an
+// optional store, which Reload reads. Nothing is limited without a cache.
+func NewPolicies(cache cache.Cache, file []Policy, store PolicyStore, config *PolicyConfig) (*Policies, error) {
+	p := &Policies{cache: cache, file: file, store: store}
+	if config != nil {
+		p.config = *config
+	}
+	if p.config.Plan == nil {
+		p.config.Plan = func(c *gin.Context, principal requestctx.Principal) string {
+			if p.config.Quotas != nil {
+				return p.config.Quotas.plan(c.Request.Context(), principal)
+			}
+			return principal.Plan
+		}
+	}
+
+	compiled := make([]*compiledPolicy, 0, len(file))
+	for _, policy := range file {
+		c, err := compile(policy)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, c)
+	}
+	p.current.Store(&compiled)
+	return p, nil
+}
+
+// Reload reads the store again. Invalid stored policies are logged and
+// skipped, so one bad row can't remove every limit.
+func (p *Policies) Reload(ctx context.Context) error {
+	if p.store == nil {
+		return nil
+	}
+	stored, err := p.store.Policies(ctx)
+	if err != nil {
+		return err
+	}
+
+	byName := make(map[string]int)
+	var compiled []*compiledPolicy
+	for _, policy := range append(append([]Policy(nil), p.file...), stored...) {
+		c, err := compile(policy)
+		if err != nil {
+			logger.Warn("Skipping invalid rate limit policy", zap.Error(err))
+			continue
+		}
+		if i, exists := byName[c.Name]; exists {
+			compiled[i] = c
+			continue
+		}
+		byName[c.Name] = len(compiled)
+		compiled = append(compiled, c)
+	}
+	p.current.Store(&compiled)
+	return nil
+}
+
+// Start reloads the stored policies every interval until ctx is cancelled
+func (p *Policies) Start(ctx context.Context, interval time.Duration) {
+	if p.store == nil || interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := p.Reload(ctx); err != nil {
+					logger.Warn("Failed to reload rate limit policies", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// Match returns the policies that apply to a route template
+func (p *Policies) Match(method, path string) []Policy {
+	var matched []Policy
+	for _, policy := range *p.current.Load() {
+		if policy.matches(method, path) {
+			matched = append(matched, policy.Policy)
+		}
+	}
+	return matched
+}
+
+// Middleware counts the request against every policy matching its route.
+// It is meant to run twice: globally, where it applies the ip, endpoint and
+// global policies, and after authentication, where it applies the user and
+// api_key ones. Each policy is counted once per request wherever it runs;
+// user and api_key policies never apply to unauthenticated requests.
+func (p *Policies) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if p.cache == nil || (p.config.Skip != nil && p.config.Skip(c)) {
+			return
+		}
+
+		path := c.FullPath()
+		if path == "" {
+			return
+		}
+
+		applied, _ := c.Get(appliedContextKey)
+		done, _ := applied.(map[string]bool)
+		if done == nil {
+			done = make(map[string]bool)
+			c.Set(appliedContextKey, done)
+		}
+
+		principal, authenticated := requestctx.CurrentPrincipal(c)
+		var (
+			tightest *verdict
+			limit    int
+			tiers    []string
+		)
+		for _, policy := range *p.current.Load() {
+			if done[policy.Name] || !policy.matches(c.Request.Method, path) {
+				continue
+			}
+			if policy.needsPrincipal() && !authenticated {
+				continue
+			}
+			done[policy.Name] = true
+
+			policyLimit := policy.Limit
+			if policy.needsPrincipal() {
+				if tiers == nil {
+					tiers = p.tiers(c, principal)
+				}
+				policyLimit = p.limitFor(policy, tiers)
+			}
+			if policyLimit == Unlimited {
+				continue
+			}
+
+			key := policy.cacheKey(c, principal)
+			result, err := count(c.Request.Context(), p.cache, policy.Algorithm, key, policyLimit, policy.window)
+			if err != nil {
+				// Don't block requests when the cache is unavailable
+				logger.Warn("Rate limit cache error, allowing request",
+					zap.Error(err),
+					zap.String("policy", policy.Name))
+				continue
+			}
+
+			if !result.allowed {
+				p.reject(c, policy, policyLimit, result)
+				return
+			}
+			if tightest == nil || result.remaining < tightest.remaining {
+				tightest, limit = &result, policyLimit
+			}
+		}
+
+		if tightest != nil {
+			c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(tightest.remaining))
+			c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(tightest.reset).Unix(), 10))
+		}
+	}
+}
+
+// tiers returns the plan and roles of the principal
+func (p *Policies) tiers(c *gin.Context, principal requestctx.Principal) []string {
+	tiers := make([]string, 0, len(principal.Roles)+1)
+	if plan := p.config.Plan(c, principal); plan != "" {
+		tiers = append(tiers, plan)
+	}
+	return append(tiers, principal.Roles...)
+}
+
+// limitFor returns the limit of a user or api_key policy for a principal
+// with tiers: the most generous plans entry, else the policy limit scaled by
+// Quotas
+func (p *Policies) limitFor(policy *compiledPolicy, tiers []string) int {
+	if limit, ok := policy.limitFor(tiers); ok {
+		return limit
+	}
+	if p.config.Quotas != nil {
+		return p.config.Quotas.Apply(tiers, policy.Limit)
+	}
+	return policy.Limit
+}
+
+func (p *Policies) reject(c *gin.Context, policy *compiledPolicy, limit int, result verdict) {
+	locale := i18n.Locale(c)
+	c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+	c.Header("X-RateLimit-Remaining", "0")
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(result.reset).Unix(), 10))
+
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       i18n.T(locale, "rate_limit.exceeded", nil),
+		"message":     i18n.T(locale, "rate_limit."+string(policy.Key), i18n.Args{"limit": limit, "window": policy.window}),
+		"retry_after": int(result.reset.Seconds()),
+		"policy":      policy.Name,
+	})
+	c.Abort()
+}
+
+// cacheKey returns the counter key of the request under the policy
+func (p *compiledPolicy) cacheKey(c *gin.Context, principal requestctx.Principal) string {
+	key := "rate_limit:policy:" + p.Name + ":"
+	switch p.Key {
+	case KeyUser:
+		return key + principal.Type + ":" + principal.Subject
+	case KeyAPIKey:
+		if principal.IsAPIKey() {
+			return key + "apikey:" + principal.APIKeyID
+		}
+		return key + principal.Type + ":" + principal.Subject
+	case KeyEndpoint:
+		return key + c.Request.Method + ":" + c.FullPath() + ":ip:" + c.ClientIP()
+	case KeyGlobal:
+		return key + "global"
+	default:
+		return key + "ip:" + c.ClientIP()
+	}
+}
@@ -0,0 +1,179 @@
+package rate_limit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// KeyType selects what a policy counts requests by
+type KeyType string
+
+const (
+	KeyIP       KeyType = "ip"
+	KeyUser     KeyType = "user"     // the authenticated principal
+	KeyAPIKey   KeyType = "api_key"  // the API key, or the principal when it used a token
+	KeyEndpoint KeyType = "endpoint" // the matched route and client IP
+	KeyGlobal   KeyType = "global"   // every request matching the policy
+)
+
+// Unlimited as a plan limit exempts principals on the plan from the policy
+const Unlimited = -1
+
+// Policy declares a limit for every route matching Route. Policies come from
+// the RATELIMIT_POLICIES_FILE JSON array and the tb_rate_limit_policy table;
+// a table row replaces the file policy of the same name.
+type Policy struct {
+	ID   int    `json:"-" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"type:varchar(100);not null;uniqueIndex"`
+	// Route is "METHOD /path", or "/path" for every method. Paths are gin
+	// route templates such as /api/v1/drafts/:id, and a trailing * matches
+	// by prefix.
+	Route     string    `json:"route" gorm:"type:varchar(255);not null"`
+	Key       KeyType   `json:"key" gorm:"type:varchar(20);not null"`
+	Limit     int       `json:"limit" gorm:"not null"`
+	Window    string    `json:"window" gorm:"type:varchar(20);not null"` // e.g. "1m", "1h"
+	Algorithm Algorithm `json:"algorithm,omitempty" gorm:"type:varchar(20);not null;default:fixed_window"`
+	// Plans overrides Limit of user and api_key policies for principals on a
+	// subscription plan or with a role, e.g. {"free": 60, "pro": 600,
+	// "enterprise": -1}; the most generous entry applies
+	Plans     map[string]int `json:"plans,omitempty" gorm:"serializer:json;type:text"`
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for GORM
+func (Policy) TableName() string {
+	return "tb_rate_limit_policy"
+}
+
+// PolicyStore provides the policies kept outside the policies file
+type PolicyStore interface {
+	Policies(ctx context.Context) ([]Policy, error)
+}
+
+// LoadPolicyFile reads a JSON array of policies
+func LoadPolicyFile(path string) ([]Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rate limit policies file: %w", err)
+	}
+
+	var policies []Policy
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("failed to parse rate limit policies file: %w", err)
+	}
+	for i := range policies {
+		if _, err := compile(policies[i]); err != nil {
+			return nil, err
+		}
+	}
+	return policies, nil
+}
+
+// compiledPolicy is a validated policy with its route and window parsed
+type compiledPolicy struct {
+	Policy
+	method string // "" for every method
+	path   string
+	prefix bool
+	window time.Duration
+}
+
+func compile(p Policy) (*compiledPolicy, error) {
+	fail := func(format string, args ...interface{}) (*compiledPolicy, error) {
+		return nil, fmt.Errorf("%w: %s: %s", ErrInvalidPolicy, p.Name, fmt.Sprintf(format, args...))
+	}
+
+	if p.Name == "" {
+		return fail("name is required")
+	}
+
+	compiled := &compiledPolicy{Policy: p}
+	route := strings.Fields(p.Route)
+	switch len(route) {
+	case 1:
+		compiled.path = route[0]
+	case 2:
+		compiled.method = strings.ToUpper(route[0])
+		compiled.path = route[1]
+		if compiled.method == "*" {
+			compiled.method = ""
+		}
+	default:
+		return fail("route must be \"METHOD /path\" or \"/path\"")
+	}
+	if !strings.HasPrefix(compiled.path, "/") {
+		return fail("route path must start with /")
+	}
+	compiled.path, compiled.prefix = strings.CutSuffix(compiled.path, "*")
+
+	switch p.Key {
+	case KeyIP, KeyUser, KeyAPIKey, KeyEndpoint, KeyGlobal:
+	default:
+		return fail("unknown key %q", p.Key)
+	}
+	switch p.Algorithm {
+	case "":
+		compiled.Algorithm = FixedWindow
+	case FixedWindow, SlidingWindow:
+	default:
+		return fail("unknown algorithm %q", p.Algorithm)
+	}
+
+	if p.Limit < 1 {
+		return fail("limit must be at least 1")
+	}
+	for plan, limit := range p.Plans {
+		if limit < 1 && limit != Unlimited {
+			return fail("limit of plan %q must be at least 1, or %d for unlimited", plan, Unlimited)
+		}
+	}
+
+	window, err := time.ParseDuration(p.Window)
+	if err != nil || window <= 0 {
+		return fail("invalid window %q", p.Window)
+	}
+	compiled.window = window
+
+	return compiled, nil
+}
+
+// matches reports whether the policy applies to a route template
+func (p *compiledPolicy) matches(method, path string) bool {
+	if p.method != "" && p.method != method {
+		return false
+	}
+	if p.prefix {
+		return strings.HasPrefix(path, p.path)
+	}
+	return path == p.path
+}
+
+// needsPrincipal reports whether the policy keys on who is calling, so it is
+// only applied once authentication has run
+func (p *compiledPolicy) needsPrincipal() bool {
+	return p.Key == KeyUser || p.Key == KeyAPIKey
+}
+
+// limitFor returns the most generous Plans entry for a principal on the plans
+// or with the roles in tiers, and whether there was one
+func (p *compiledPolicy) limitFor(tiers []string) (int, bool) {
+	limit, found := 0, false
+	for _, tier := range tiers {
+		l, ok := p.Plans[tier]
+		if !ok {
+			continue
+		}
+		if l == Unlimited {
+			return Unlimited, true
+		}
+		if !found || l > limit {
+			limit, found = l, true
+		}
+	}
+	return limit, found
+}
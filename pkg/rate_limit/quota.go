@@ -0,0 +1,149 @@
+package rate_limit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"flex-service/pkg/cache"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/requestctx"
+
+	"go.uber.org/zap"
+)
+
+// DefaultQuotaCacheTTL is how long looked up plans are cached
+const DefaultQuotaCacheTTL = 5 * time.Minute
+
+// QuotaConfig configures the limits of authenticated principals by plan and role
+type QuotaConfig struct {
+	// Tiers multiply the limits of principals on a plan or with a role, e.g.
+	// {"pro": 5, "enterprise": Unlimited, "admin": 10}. The most generous
+	// tier of a principal applies.
+	Tiers map[string]float64
+	// Default multiplies the limits of principals without a tier (default 1)
+	Default float64
+	// Plan looks up the plan of principals that don't carry one, e.g. users
+	// authenticated by token, from billing. Results are cached for CacheTTL.
+	Plan func(ctx context.Context, principal requestctx.Principal) (string, error)
+	// CacheTTL is how long looked up plans are cached (default 5m)
+	CacheTTL time.Duration
+}
+
+// Quotas resolves the limits of authenticated principals from their plan and
+// roles, so UserRateLimit, APIKeyRateLimit and user and api_key policies give
+// premium customers higher limits on the same routes
+type Quotas struct {
+	cache  cache.Cache
+	config QuotaConfig
+}
+
+// NewQuotas creates the quota resolver. Without a cache looked up plans
+// aren't cached.
+func NewQuotas(cache cache.Cache, config *QuotaConfig) *Quotas {
+	q := &Quotas{cache: cache}
+	if config != nil {
+		q.config = *config
+	}
+	if q.config.Default == 0 {
+		q.config.Default = 1
+	}
+	if q.config.CacheTTL <= 0 {
+		q.config.CacheTTL = DefaultQuotaCacheTTL
+	}
+	return q
+}
+
+// ParseTiers converts tier multipliers read from configuration, such as
+// {"pro": "5", "enterprise": "unlimited"}
+func ParseTiers(values map[string]string) (map[string]float64, error) {
+	tiers := make(map[string]float64, len(values))
+	for name, value := range values {
+		if strings.EqualFold(value, "unlimited") || value == strconv.Itoa(Unlimited) {
+			tiers[name] = Unlimited
+			continue
+		}
+		multiplier, err := strconv.ParseFloat(value, 64)
+		if err != nil || multiplier <= 0 {
+			return nil, fmt.Errorf("invalid rate limit quota for %q: %q", name, value)
+		}
+		tiers[name] = multiplier
+	}
+	return tiers, nil
+}
+
+// Tiers returns the plan and roles of a principal. A plan lookup that fails
+// is logged and ignored, so the principal keeps its role and default limits.
+func (q *Quotas) Tiers(ctx context.Context, principal requestctx.Principal) []string {
+	tiers := make([]string, 0, len(principal.Roles)+1)
+	if plan := q.plan(ctx, principal); plan != "" {
+		tiers = append(tiers, plan)
+	}
+	return append(tiers, principal.Roles...)
+}
+
+// Limit returns base adjusted for the principal's tiers, or Unlimited
+func (q *Quotas) Limit(ctx context.Context, principal requestctx.Principal, base int) int {
+	return q.Apply(q.Tiers(ctx, principal), base)
+}
+
+// Apply multiplies base by the most generous of tiers, or by Default when
+// none of them is configured. The result is at least 1, or Unlimited.
+func (q *Quotas) Apply(tiers []string, base int) int {
+	multiplier, found := 0.0, false
+	for _, tier := range tiers {
+		m, ok := q.config.Tiers[tier]
+		if !ok {
+			continue
+		}
+		if m == Unlimited {
+			return Unlimited
+		}
+		if !found || m > multiplier {
+			multiplier, found = m, true
+		}
+	}
+	if !found {
+		multiplier = q.config.Default
+	}
+	if multiplier == Unlimited {
+		return Unlimited
+	}
+
+	limit := int(math.Round(float64(base) * multiplier))
+	if limit < 1 {
+		return 1
+	}
+	return limit
+}
+
+// plan returns the principal's plan, looking it up when it carries none
+func (q *Quotas) plan(ctx context.Context, principal requestctx.Principal) string {
+	if principal.Plan != "" || q.config.Plan == nil {
+		return principal.Plan
+	}
+	lookup := func() (string, error) {
+		return q.config.Plan(ctx, principal)
+	}
+
+	var (
+		plan string
+		err  error
+	)
+	if q.cache == nil {
+		plan, err = lookup()
+	} else {
+		key := "rate_limit:plan:" + principal.Type + ":" + principal.Subject
+		plan, err = cache.Remember(ctx, q.cache, key, q.config.CacheTTL, lookup)
+	}
+	if err != nil {
+		logger.Warn("Failed to look up rate limit plan",
+			zap.String("principal", principal.Type+":"+principal.Subject),
+			zap.Error(err))
+		return ""
+	}
+	return plan
+}
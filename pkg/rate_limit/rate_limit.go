@@ -2,13 +2,17 @@ package rate_limit
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"flex-service/pkg/cache"
+	"flex-service/pkg/i18n"
 	"flex-service/pkg/logger"
+	"flex-service/pkg/requestctx"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -20,6 +24,7 @@ func NewRateLimit(cache cache.Cache, cfg *RateLimitConfig) (RateLimit, error) {
 		rateLimitConfig.Limit = cfg.Limit
 		rateLimitConfig.Window = cfg.Window
 		rateLimitConfig.Skip = cfg.Skip
+		rateLimitConfig.Quotas = cfg.Quotas
 	}
 
 	return &rateLimit{
@@ -50,6 +55,15 @@ func (r *rateLimit) RateLimitMiddleware(cache cache.Cache, config *RateLimitConf
 			return
 		}
 
+		limit := mergedConfig.Limit
+		if mergedConfig.Quota != nil {
+			limit = mergedConfig.Quota(c, limit)
+		}
+		if limit == Unlimited {
+			c.Next()
+			return
+		}
+
 		// Generate cache key
 		key := mergedConfig.KeyGenerator(c)
 		ctx := context.Background()
@@ -75,7 +89,7 @@ func (r *rateLimit) RateLimitMiddleware(cache cache.Cache, config *RateLimitConf
 		}
 
 		// Check if limit exceeded
-		if count > int64(mergedConfig.Limit) {
+		if count > int64(limit) {
 			// Get TTL for rate limit reset time
 			ttl, err := cache.TTL(ctx, key)
 			if err != nil {
@@ -83,20 +97,20 @@ func (r *rateLimit) RateLimitMiddleware(cache cache.Cache, config *RateLimitConf
 			}
 
 			// Set rate limit headers
-			c.Header("X-RateLimit-Limit", strconv.Itoa(mergedConfig.Limit))
+			c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
 			c.Header("X-RateLimit-Remaining", "0")
 			c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(ttl).Unix(), 10))
 
 			// Call custom handler if provided
 			if mergedConfig.OnRateLimited != nil {
-				mergedConfig.OnRateLimited(c, mergedConfig.Limit, mergedConfig.Window)
+				mergedConfig.OnRateLimited(c, limit, mergedConfig.Window)
 				return
 			}
 
 			// Default response
 			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":       "Rate limit exceeded",
-				"message":     mergedConfig.Message,
+				"error":       i18n.T(i18n.Locale(c), "rate_limit.exceeded", nil),
+				"message":     localizedMessage(c, withLimit(mergedConfig, limit)),
 				"retry_after": int(ttl.Seconds()),
 			})
 			c.Abort()
@@ -104,12 +118,12 @@ func (r *rateLimit) RateLimitMiddleware(cache cache.Cache, config *RateLimitConf
 		}
 
 		// Set rate limit headers
-		remaining := mergedConfig.Limit - int(count)
+		remaining := limit - int(count)
 		if remaining < 0 {
 			remaining = 0
 		}
 
-		c.Header("X-RateLimit-Limit", strconv.Itoa(mergedConfig.Limit))
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
 		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
 
 		// Get TTL for reset time
@@ -130,7 +144,9 @@ func (r *rateLimit) IPRateLimit(cache cache.Cache, limit int, window time.Durati
 		KeyGenerator: func(c *gin.Context) string {
 			return "rate_limit:ip:" + c.ClientIP()
 		},
-		Message: fmt.Sprintf("Rate limit exceeded. Maximum %d requests per %v allowed.", limit, window),
+		Message:     fmt.Sprintf("Rate limit exceeded. Maximum %d requests per %v allowed.", limit, window),
+		MessageKey:  "rate_limit.ip",
+		MessageArgs: map[string]interface{}{"limit": limit, "window": window},
 	}
 	return r.RateLimitMiddleware(cache, config)
 }
@@ -140,16 +156,19 @@ func (r *rateLimit) UserRateLimit(cache cache.Cache, limit int, window time.Dura
 	config := &RateLimitConfig{
 		Limit:  limit,
 		Window: window,
+		Quota:  r.quota(),
 		KeyGenerator: func(c *gin.Context) string {
-			// Try to get user ID from context (set by auth middleware)
-			userID, exists := c.Get("user_id")
+			// Try to get the principal from context (set by auth middleware)
+			principal, exists := requestctx.CurrentPrincipal(c)
 			if !exists {
-				// Fallback to IP-based if no user ID
+				// Fallback to IP-based if no principal
 				return "rate_limit:ip:" + c.ClientIP()
 			}
-			return "rate_limit:user:" + fmt.Sprintf("%v", userID)
+			return "rate_limit:" + principal.Type + ":" + principal.Subject
 		},
-		Message: fmt.Sprintf("Rate limit exceeded. Maximum %d requests per %v allowed per user.", limit, window),
+		Message:     fmt.Sprintf("Rate limit exceeded. Maximum %d requests per %v allowed per user.", limit, window),
+		MessageKey:  "rate_limit.user",
+		MessageArgs: map[string]interface{}{"limit": limit, "window": window},
 	}
 	return r.RateLimitMiddleware(cache, config)
 }
@@ -159,7 +178,14 @@ func (r *rateLimit) APIKeyRateLimit(cache cache.Cache, limit int, window time.Du
 	config := &RateLimitConfig{
 		Limit:  limit,
 		Window: window,
+		Quota:  r.quota(),
 		KeyGenerator: func(c *gin.Context) string {
+			// After middleware.APIKeyAuthenticate, key on the key ID so rotation
+			// doesn't reset the limit
+			if principal, ok := requestctx.CurrentPrincipal(c); ok && principal.IsAPIKey() {
+				return "rate_limit:apikey:" + principal.APIKeyID
+			}
+
 			apiKey := c.GetHeader("X-API-Key")
 			if apiKey == "" {
 				apiKey = c.Query("api_key")
@@ -168,9 +194,13 @@ func (r *rateLimit) APIKeyRateLimit(cache cache.Cache, limit int, window time.Du
 				// Fallback to IP-based if no API key
 				return "rate_limit:ip:" + c.ClientIP()
 			}
-			return "rate_limit:apikey:" + apiKey
+			// Hashed so keys don't end up in cache key names
+			sum := sha256.Sum256([]byte(apiKey))
+			return "rate_limit:apikey:" + hex.EncodeToString(sum[:16])
 		},
-		Message: fmt.Sprintf("Rate limit exceeded. Maximum %d requests per %v allowed per API key.", limit, window),
+		Message:     fmt.Sprintf("Rate limit exceeded. Maximum %d requests per %v allowed per API key.", limit, window),
+		MessageKey:  "rate_limit.api_key",
+		MessageArgs: map[string]interface{}{"limit": limit, "window": window},
 	}
 	return r.RateLimitMiddleware(cache, config)
 }
@@ -184,7 +214,9 @@ func (r *rateLimit) EndpointRateLimit(cache cache.Cache, limit int, window time.
 			endpoint := c.Request.Method + ":" + c.FullPath()
 			return "rate_limit:endpoint:" + endpoint + ":ip:" + c.ClientIP()
 		},
-		Message: fmt.Sprintf("Rate limit exceeded for this endpoint. Maximum %d requests per %v allowed.", limit, window),
+		Message:     fmt.Sprintf("Rate limit exceeded for this endpoint. Maximum %d requests per %v allowed.", limit, window),
+		MessageKey:  "rate_limit.endpoint",
+		MessageArgs: map[string]interface{}{"limit": limit, "window": window},
 	}
 	return r.RateLimitMiddleware(cache, config)
 }
@@ -197,7 +229,9 @@ func (r *rateLimit) GlobalRateLimit(cache cache.Cache, limit int, window time.Du
 		KeyGenerator: func(c *gin.Context) string {
 			return "rate_limit:global"
 		},
-		Message: fmt.Sprintf("Global rate limit exceeded. Maximum %d requests per %v allowed across all users.", limit, window),
+		Message:     fmt.Sprintf("Global rate limit exceeded. Maximum %d requests per %v allowed across all users.", limit, window),
+		MessageKey:  "rate_limit.global",
+		MessageArgs: map[string]interface{}{"limit": limit, "window": window},
 	}
 	return r.RateLimitMiddleware(cache, config)
 }
@@ -227,12 +261,14 @@ func (r *rateLimit) LoginRateLimit(cache cache.Cache, limit int, window time.Dur
 
 			return "rate_limit:login:" + username + ":ip:" + c.ClientIP()
 		},
-		Message: fmt.Sprintf("Too many login attempts. Please try again in %v.", window),
+		Message:     fmt.Sprintf("Too many login attempts. Please try again in %v.", window),
+		MessageKey:  "rate_limit.login",
+		MessageArgs: map[string]interface{}{"window": window},
 		OnRateLimited: func(c *gin.Context, limit int, window time.Duration) {
 			// Custom response for login rate limiting
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"error":          "TOO_MANY_LOGIN_ATTEMPTS",
-				"message":        fmt.Sprintf("Too many login attempts. Please try again in %v.", window),
+				"message":        i18n.T(i18n.Locale(c), "rate_limit.login", i18n.Args{"window": window}),
 				"retry_after":    int(window.Seconds()),
 				"account_locked": true,
 			})
@@ -251,11 +287,13 @@ func (r *rateLimit) RegisterRateLimit(cache cache.Cache, limit int, window time.
 			// Use IP for registration attempts
 			return "rate_limit:register:ip:" + c.ClientIP()
 		},
-		Message: fmt.Sprintf("Too many registration attempts. Please try again in %v.", window),
+		Message:     fmt.Sprintf("Too many registration attempts. Please try again in %v.", window),
+		MessageKey:  "rate_limit.register",
+		MessageArgs: map[string]interface{}{"window": window},
 		OnRateLimited: func(c *gin.Context, limit int, window time.Duration) {
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"error":       "TOO_MANY_REGISTRATION_ATTEMPTS",
-				"message":     fmt.Sprintf("Too many registration attempts. Please try again in %v.", window),
+				"message":     i18n.T(i18n.Locale(c), "rate_limit.register", i18n.Args{"window": window}),
 				"retry_after": int(window.Seconds()),
 			})
 			c.Abort()
@@ -287,11 +325,13 @@ func (r *rateLimit) PasswordResetRateLimit(cache cache.Cache, limit int, window
 
 			return "rate_limit:password_reset:" + email + ":ip:" + c.ClientIP()
 		},
-		Message: fmt.Sprintf("Too many password reset attempts. Please try again in %v.", window),
+		Message:     fmt.Sprintf("Too many password reset attempts. Please try again in %v.", window),
+		MessageKey:  "rate_limit.password_reset",
+		MessageArgs: map[string]interface{}{"window": window},
 		OnRateLimited: func(c *gin.Context, limit int, window time.Duration) {
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"error":       "TOO_MANY_PASSWORD_RESET_ATTEMPTS",
-				"message":     fmt.Sprintf("Too many password reset attempts. Please try again in %v.", window),
+				"message":     i18n.T(i18n.Locale(c), "rate_limit.password_reset", i18n.Args{"window": window}),
 				"retry_after": int(window.Seconds()),
 			})
 			c.Abort()
@@ -0,0 +1,26 @@
+package rate_limit
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// GormPolicyStore reads policies from tb_rate_limit_policy
+type GormPolicyStore struct {
+	db *gorm.DB
+}
+
+// NewGormPolicyStore creates a GORM-backed policy store
+func NewGormPolicyStore(db *gorm.DB) *GormPolicyStore {
+	return &GormPolicyStore{db: db}
+}
+
+// Policies returns every stored policy
+func (s *GormPolicyStore) Policies(ctx context.Context) ([]Policy, error) {
+	var policies []Policy
+	if err := s.db.WithContext(ctx).Order("id").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
@@ -0,0 +1,91 @@
+package request
+
+import (
+	"net/http"
+
+	"flex-service/pkg/errors"
+	"flex-service/pkg/i18n"
+	"flex-service/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrInvalidRequest is the code for payloads that can't be decoded
+const ErrInvalidRequest = "INVALID_REQUEST"
+
+// Bind decodes URI params, query string and JSON body (in that order, so the body wins)
+// into a new T, then sanitizes and validates it with the request locale.
+// Errors are AppErrors ready for c.Error:
+//
+//	req, err := request.Bind[entity.CreateProductRequest](c)
+//	if err != nil {
+//		c.Error(err)
+//		return
+//	}
+func Bind[T any](c *gin.Context) (*T, error) {
+	req := new(T)
+
+	if len(c.Params) > 0 {
+		if err := c.ShouldBindUri(req); err != nil {
+			return nil, invalid(err)
+		}
+	}
+	if c.Request.URL.RawQuery != "" {
+		if err := c.ShouldBindQuery(req); err != nil {
+			return nil, invalid(err)
+		}
+	}
+	if hasBody(c.Request) {
+		if err := c.ShouldBindJSON(req); err != nil {
+			return nil, invalid(err)
+		}
+	}
+
+	return req, Validate(c, req)
+}
+
+// BindJSON decodes only the JSON body into a new T and validates it
+func BindJSON[T any](c *gin.Context) (*T, error) {
+	req := new(T)
+	if err := c.ShouldBindJSON(req); err != nil {
+		return nil, invalid(err)
+	}
+	return req, Validate(c, req)
+}
+
+// BindQuery decodes only the query string (form tags) into a new T and validates it
+func BindQuery[T any](c *gin.Context) (*T, error) {
+	req := new(T)
+	if err := c.ShouldBindQuery(req); err != nil {
+		return nil, invalid(err)
+	}
+	return req, Validate(c, req)
+}
+
+// BindURI decodes only path params (uri tags) into a new T and validates it
+func BindURI[T any](c *gin.Context) (*T, error) {
+	req := new(T)
+	if err := c.ShouldBindUri(req); err != nil {
+		return nil, invalid(err)
+	}
+	return req, Validate(c, req)
+}
+
+// Validate sanitizes and validates an already bound request. Failures are a
+// VALIDATION_ERROR AppError wrapping validator.ValidationErrors, which the
+// ErrorHandler middleware renders with response.ValidationErrors.
+func Validate(c *gin.Context, req interface{}) error {
+	if errs := validator.Validate(req, i18n.Locale(c)); errs != nil {
+		return errors.Wrap(errs, errors.ErrValidation, "Validation failed", http.StatusBadRequest)
+	}
+	return nil
+}
+
+func invalid(err error) error {
+	return errors.Wrap(err, ErrInvalidRequest, "Invalid request format", http.StatusBadRequest)
+}
+
+// hasBody reports whether the request carries a body worth decoding
+func hasBody(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+}
@@ -0,0 +1,189 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"flex-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VersionHeader is the request header clients send their payload version in.
+// ?api_version= works too, for clients that can't set headers.
+const VersionHeader = "API-Version"
+
+// ErrUnsupportedVersion is the code for versions a schema doesn't know
+const ErrUnsupportedVersion = "UNSUPPORTED_API_VERSION"
+
+const versionContextKey = "request.api_version"
+
+// Payload is a decoded JSON body being upgraded from one version to the next
+type Payload map[string]interface{}
+
+// Rename moves a field to a new name. Missing fields are ignored.
+func (p Payload) Rename(from, to string) {
+	if v, ok := p[from]; ok {
+		delete(p, from)
+		p[to] = v
+	}
+}
+
+// Upgrade rewrites a payload of one version into the shape of the next
+type Upgrade func(p Payload) error
+
+// Schema declares the versions of one endpoint's payload. Each upgrade turns
+// version v into v+1; versions without one kept the same shape.
+//
+//	var createProductSchema = request.NewSchema(3).
+//		Upgrade(1, func(p request.Payload) error { p.Rename("title", "name"); return nil }).
+//		Upgrade(2, splitPrice)
+type Schema struct {
+	current  int
+	oldest   int
+	upgrades map[int]Upgrade
+}
+
+// NewSchema returns a schema whose latest version is current. Versions 1 to
+// current are accepted until Since raises the oldest one.
+func NewSchema(current int) *Schema {
+	if current < 1 {
+		panic("request: schema version must be at least 1")
+	}
+	return &Schema{current: current, oldest: 1, upgrades: map[int]Upgrade{}}
+}
+
+// Upgrade registers the migration from version to version+1
+func (s *Schema) Upgrade(version int, fn Upgrade) *Schema {
+	if version < 1 || version >= s.current {
+		panic(fmt.Sprintf("request: upgrade from version %d is outside 1..%d", version, s.current-1))
+	}
+	s.upgrades[version] = fn
+	return s
+}
+
+// Since drops support for versions older than oldest, which are then rejected
+func (s *Schema) Since(oldest int) *Schema {
+	if oldest < 1 || oldest > s.current {
+		panic(fmt.Sprintf("request: oldest version %d is outside 1..%d", oldest, s.current))
+	}
+	s.oldest = oldest
+	return s
+}
+
+// Current returns the latest version
+func (s *Schema) Current() int {
+	return s.current
+}
+
+// Migrate upgrades a payload from version to the current version, in order
+func (s *Schema) Migrate(p Payload, version int) error {
+	if version < s.oldest || version > s.current {
+		return unsupportedVersion(s, version)
+	}
+
+	steps := make([]int, 0, len(s.upgrades))
+	for v := range s.upgrades {
+		if v >= version {
+			steps = append(steps, v)
+		}
+	}
+	sort.Ints(steps)
+
+	for _, v := range steps {
+		if err := s.upgrades[v](p); err != nil {
+			return errors.Wrap(err, ErrInvalidRequest, fmt.Sprintf("Cannot upgrade request from version %d", v), http.StatusBadRequest)
+		}
+	}
+	return nil
+}
+
+// BindVersioned is Bind for endpoints whose JSON body changed shape over time.
+// The body is upgraded from the client's version (VersionHeader, the current
+// one when absent) to the latest before binding, so handlers only see the
+// current T. Only the body is migrated; URI and query params bind as-is.
+func BindVersioned[T any](c *gin.Context, schema *Schema) (*T, error) {
+	version, err := requestedVersion(c, schema)
+	if err != nil {
+		return nil, err
+	}
+	c.Set(versionContextKey, version)
+
+	if version != schema.current && hasBody(c.Request) {
+		if err := migrateBody(c.Request, schema, version); err != nil {
+			return nil, err
+		}
+	}
+
+	return Bind[T](c)
+}
+
+// Version returns the payload version the client sent, as resolved by
+// BindVersioned. Use it to shape responses for older clients.
+func Version(c *gin.Context) (int, bool) {
+	v, ok := c.Get(versionContextKey)
+	if !ok {
+		return 0, false
+	}
+	version, ok := v.(int)
+	return version, ok
+}
+
+func requestedVersion(c *gin.Context, schema *Schema) (int, error) {
+	raw := strings.TrimSpace(c.GetHeader(VersionHeader))
+	if raw == "" {
+		raw = c.Query("api_version")
+	}
+	if raw == "" {
+		return schema.current, nil
+	}
+
+	version, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(raw), "v"))
+	if err != nil || version < schema.oldest || version > schema.current {
+		return 0, unsupportedVersion(schema, raw)
+	}
+	return version, nil
+}
+
+// migrateBody replaces the request body with the upgraded payload
+func migrateBody(r *http.Request, schema *Schema, version int) error {
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return invalid(err)
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return invalid(err)
+	}
+	if payload == nil {
+		payload = Payload{}
+	}
+
+	if err := schema.Migrate(payload, version); err != nil {
+		return err
+	}
+
+	upgraded, err := json.Marshal(payload)
+	if err != nil {
+		return invalid(err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(upgraded))
+	r.ContentLength = int64(len(upgraded))
+	return nil
+}
+
+func unsupportedVersion(schema *Schema, version interface{}) error {
+	return errors.New(
+		fmt.Sprintf("Unsupported API version %v, expected %d to %d", version, schema.oldest, schema.current),
+		ErrUnsupportedVersion,
+		http.StatusBadRequest,
+	)
+}
@@ -0,0 +1,67 @@
+package requestctx
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Values are kept on the request context rather than gin's string-keyed map, so
+// usecases and the logger see them through c.Request.Context() too.
+
+// update replaces the request context of c
+func update(c *gin.Context, ctx context.Context) {
+	c.Request = c.Request.WithContext(ctx)
+}
+
+// SetPrincipal stores the principal for the rest of the request
+func SetPrincipal(c *gin.Context, principal Principal) {
+	update(c, WithPrincipal(c.Request.Context(), principal))
+}
+
+// CurrentPrincipal returns the principal stored by an auth middleware
+func CurrentPrincipal(c *gin.Context) (Principal, bool) {
+	return PrincipalFrom(c.Request.Context())
+}
+
+// SetUser stores the authenticated user for the rest of the request
+//
+// Deprecated: use SetPrincipal.
+func SetUser(c *gin.Context, user User) {
+	SetPrincipal(c, user)
+}
+
+// CurrentUser returns the principal when it is a user
+func CurrentUser(c *gin.Context) (User, bool) {
+	return UserFrom(c.Request.Context())
+}
+
+// CurrentUserID returns the ID of the authenticated user
+func CurrentUserID(c *gin.Context) (int, bool) {
+	return UserID(c.Request.Context())
+}
+
+// SetTenantID stores the tenant for the rest of the request
+func SetTenantID(c *gin.Context, tenantID string) {
+	update(c, WithTenantID(c.Request.Context(), tenantID))
+}
+
+// SetRequestID stores the request ID for the rest of the request
+func SetRequestID(c *gin.Context, requestID string) {
+	update(c, WithRequestID(c.Request.Context(), requestID))
+}
+
+// SetTrace stores the trace and span IDs for the rest of the request
+func SetTrace(c *gin.Context, traceID, spanID string) {
+	update(c, WithSpanID(WithTraceID(c.Request.Context(), traceID), spanID))
+}
+
+// SetServiceKeyID stores the verified signing key ID for the rest of the request
+func SetServiceKeyID(c *gin.Context, keyID string) {
+	update(c, WithServiceKeyID(c.Request.Context(), keyID))
+}
+
+// SetClient stores the client IP and user agent for the rest of the request
+func SetClient(c *gin.Context, client Client) {
+	update(c, WithClient(c.Request.Context(), client))
+}
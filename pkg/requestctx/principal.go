@@ -0,0 +1,121 @@
+package requestctx
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// Principal types
+const (
+	PrincipalUser    = "user"
+	PrincipalService = "service"
+)
+
+// Principal is whoever is making the request: a user authenticated by JWT or a
+// service that signed the request. Every auth middleware produces one, so handlers
+// and policies don't depend on which middleware ran.
+type Principal struct {
+	Type        string   `json:"type"`
+	Subject     string   `json:"subject"`      // Stable string form: the user ID or the service key ID
+	ID          int      `json:"id,omitempty"` // Numeric user ID; 0 for services
+	UUID        string   `json:"uuid,omitempty"`
+	Email       string   `json:"email,omitempty"`
+	TenantID    string   `json:"tenant_id,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// Scopes limit a principal authenticated with a scoped token to these
+	// permissions, whatever its roles grant. Empty means unrestricted.
+	Scopes []string `json:"scopes,omitempty"`
+	// APIKeyID is set when a user authenticated with an API key instead of a token
+	APIKeyID string `json:"api_key_id,omitempty"`
+	// Plan is the subscription plan of the API key, used by tiered rate limits
+	Plan string `json:"plan,omitempty"`
+}
+
+// NewUserPrincipal creates the principal for an authenticated user
+func NewUserPrincipal(id int, uuid, email string) Principal {
+	return Principal{
+		Type:    PrincipalUser,
+		Subject: strconv.Itoa(id),
+		ID:      id,
+		UUID:    uuid,
+		Email:   email,
+	}
+}
+
+// NewServicePrincipal creates the principal for a service identified by its signing key
+func NewServicePrincipal(keyID string) Principal {
+	return Principal{
+		Type:    PrincipalService,
+		Subject: keyID,
+	}
+}
+
+// IsUser reports whether the principal is an end user
+func (p Principal) IsUser() bool {
+	return p.Type == PrincipalUser
+}
+
+// IsService reports whether the principal is another service
+func (p Principal) IsService() bool {
+	return p.Type == PrincipalService
+}
+
+// HasRole reports whether the principal has the role
+func (p Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether the principal was granted permission, directly,
+// through "*", or through a "prefix.*" or "prefix:*" wildcard such as "orders.*"
+func (p Principal) HasPermission(permission string) bool {
+	return p.HasScope(permission) && matchAny(p.Permissions, permission)
+}
+
+func matchAny(grants []string, permission string) bool {
+	for _, granted := range grants {
+		switch {
+		case granted == "*" || granted == permission:
+			return true
+		case (strings.HasSuffix(granted, ".*") || strings.HasSuffix(granted, ":*")) && strings.HasPrefix(permission, strings.TrimSuffix(granted, "*")):
+			return true
+		}
+	}
+	return false
+}
+
+// IsAPIKey reports whether the principal authenticated with an API key
+func (p Principal) IsAPIKey() bool {
+	return p.APIKeyID != ""
+}
+
+// IsScoped reports whether the principal came from a scoped token or API key
+func (p Principal) IsScoped() bool {
+	return len(p.Scopes) > 0
+}
+
+// HasScope reports whether a scoped principal may use permission. Unscoped
+// principals have every scope.
+func (p Principal) HasScope(permission string) bool {
+	if !p.IsScoped() {
+		return true
+	}
+	return matchAny(p.Scopes, permission)
+}
+
+// WithPrincipal stores the principal in a context
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// PrincipalFrom returns the principal of the request
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey).(Principal)
+	return principal, ok
+}
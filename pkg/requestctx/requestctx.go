@@ -0,0 +1,126 @@
+package requestctx
+
+import (
+	"context"
+)
+
+// key is unexported so only this package can read or write these values
+type key int
+
+const (
+	principalKey key = iota
+	tenantIDKey
+	requestIDKey
+	traceIDKey
+	serviceKeyIDKey
+	spanIDKey
+	clientKey
+)
+
+// User is the previous name of Principal, kept for existing callers
+//
+// Deprecated: use Principal.
+type User = Principal
+
+// WithUser stores the authenticated user in a context
+//
+// Deprecated: use WithPrincipal.
+func WithUser(ctx context.Context, user User) context.Context {
+	return WithPrincipal(ctx, user)
+}
+
+// UserFrom returns the principal when it is a user
+func UserFrom(ctx context.Context) (User, bool) {
+	principal, ok := PrincipalFrom(ctx)
+	if !ok || !principal.IsUser() {
+		return Principal{}, false
+	}
+	return principal, true
+}
+
+// UserID returns the authenticated user's ID
+func UserID(ctx context.Context) (int, bool) {
+	user, ok := UserFrom(ctx)
+	return user.ID, ok
+}
+
+// Roles returns the principal's roles
+func Roles(ctx context.Context) []string {
+	principal, _ := PrincipalFrom(ctx)
+	return principal.Roles
+}
+
+// WithTenantID stores the tenant of the request
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantIDKey, tenantID)
+}
+
+// TenantID returns the tenant set with WithTenantID, or else the principal's tenant
+func TenantID(ctx context.Context) (string, bool) {
+	if tenantID, ok := ctx.Value(tenantIDKey).(string); ok && tenantID != "" {
+		return tenantID, true
+	}
+	principal, _ := PrincipalFrom(ctx)
+	return principal.TenantID, principal.TenantID != ""
+}
+
+// WithRequestID stores the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the request ID, or ""
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// WithTraceID stores the trace ID
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey, traceID)
+}
+
+// TraceID returns the trace ID, or ""
+func TraceID(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey).(string)
+	return traceID
+}
+
+// WithSpanID stores the ID of the span handling the request
+func WithSpanID(ctx context.Context, spanID string) context.Context {
+	return context.WithValue(ctx, spanIDKey, spanID)
+}
+
+// SpanID returns the span ID, or ""
+func SpanID(ctx context.Context) string {
+	spanID, _ := ctx.Value(spanIDKey).(string)
+	return spanID
+}
+
+// WithServiceKeyID stores the ID of the key that signed a service-to-service request
+func WithServiceKeyID(ctx context.Context, keyID string) context.Context {
+	return context.WithValue(ctx, serviceKeyIDKey, keyID)
+}
+
+// ServiceKeyID returns the ID of the key that signed the request
+func ServiceKeyID(ctx context.Context) (string, bool) {
+	keyID, ok := ctx.Value(serviceKeyIDKey).(string)
+	return keyID, ok && keyID != ""
+}
+
+// Client describes where a request came from
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// WithClient stores the client of the request
+func WithClient(ctx context.Context, client Client) context.Context {
+	return context.WithValue(ctx, clientKey, client)
+}
+
+// ClientFrom returns the client stored by middleware.ClientInfo
+func ClientFrom(ctx context.Context) (Client, bool) {
+	client, ok := ctx.Value(clientKey).(Client)
+	return client, ok
+}
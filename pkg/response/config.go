@@ -0,0 +1,39 @@
+package response
+
+// ErrorFormat selects how error responses are rendered
+type ErrorFormat string
+
+const (
+	// ErrorFormatEnvelope renders errors inside the standard Response envelope
+	ErrorFormatEnvelope ErrorFormat = "envelope"
+	// ErrorFormatProblem renders errors as RFC 7807 application/problem+json
+	ErrorFormatProblem ErrorFormat = "problem"
+)
+
+// Config controls response rendering
+type Config struct {
+	ErrorFormat        ErrorFormat
+	ProblemTypeBaseURL string // Prefix for problem "type" URIs, e.g. https://api.example.com/problems/
+}
+
+// DefaultConfig returns the default response configuration
+func DefaultConfig() Config {
+	return Config{
+		ErrorFormat: ErrorFormatEnvelope,
+	}
+}
+
+var current = DefaultConfig()
+
+// Configure sets the response configuration. Call once at startup before serving requests.
+func Configure(cfg Config) {
+	if cfg.ErrorFormat != ErrorFormatProblem {
+		cfg.ErrorFormat = ErrorFormatEnvelope
+	}
+	current = cfg
+}
+
+// UseProblemDetails reports whether errors are rendered as RFC 7807 problems
+func UseProblemDetails() bool {
+	return current.ErrorFormat == ErrorFormatProblem
+}
@@ -0,0 +1,84 @@
+package response
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Links represents navigation links for paginated collections
+type Links struct {
+	Self  string `json:"self"`
+	First string `json:"first,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Last  string `json:"last,omitempty"`
+}
+
+// Paginated sends a collection with meta and navigation links built from the request URL
+func Paginated(c *gin.Context, message string, data interface{}, meta *Meta) {
+	c.JSON(http.StatusOK, Response{
+		StatusCode: http.StatusOK,
+		Message:    message,
+		Data:       data,
+		Meta:       meta,
+		Links:      BuildLinks(c.Request.URL, meta),
+		Timestamp:  time.Now().UTC(),
+	})
+}
+
+// BuildLinks builds self/first/prev/next/last links for page or cursor metadata
+func BuildLinks(u *url.URL, meta *Meta) *Links {
+	links := &Links{Self: u.RequestURI()}
+	if meta == nil {
+		return links
+	}
+
+	// Cursor pagination
+	if meta.NextCursor != "" || meta.PrevCursor != "" {
+		if meta.HasNext && meta.NextCursor != "" {
+			links.Next = withParams(u, map[string]string{"cursor": meta.NextCursor}, "page")
+		}
+		if meta.HasPrevious && meta.PrevCursor != "" {
+			links.Prev = withParams(u, map[string]string{"cursor": meta.PrevCursor}, "page")
+		}
+		return links
+	}
+
+	// Offset pagination
+	if meta.Page <= 0 {
+		return links
+	}
+	page := func(n int) string {
+		return withParams(u, map[string]string{"page": strconv.Itoa(n)}, "cursor")
+	}
+
+	links.First = page(1)
+	if meta.HasPrevious {
+		links.Prev = page(meta.Page - 1)
+	}
+	if meta.HasNext {
+		links.Next = page(meta.Page + 1)
+	}
+	if meta.TotalPages > 0 {
+		links.Last = page(meta.TotalPages)
+	}
+
+	return links
+}
+
+// withParams returns the request URI with params set and remove deleted
+func withParams(u *url.URL, params map[string]string, remove string) string {
+	values := u.Query()
+	for key, value := range params {
+		values.Set(key, value)
+	}
+	values.Del(remove)
+
+	next := *u
+	next.RawQuery = values.Encode()
+	return next.RequestURI()
+}
@@ -0,0 +1,69 @@
+package response
+
+import (
+	"net/http"
+	"strings"
+
+	"flex-service/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+)
+
+// ProblemContentType is the RFC 7807 media type
+const ProblemContentType = "application/problem+json"
+
+// Problem represents an RFC 7807 problem details object
+type Problem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	Code     string            `json:"code,omitempty"`
+	Details  interface{}       `json:"details,omitempty"`
+	Fields   map[string]string `json:"fields,omitempty"`
+	Errors   []ErrorInfo       `json:"errors,omitempty"`
+
+	Violations validator.ValidationErrors `json:"violations,omitempty"`
+}
+
+// NewProblem builds a problem from an error code and message
+func NewProblem(c *gin.Context, statusCode int, code, message string) *Problem {
+	return &Problem{
+		Type:     problemType(code),
+		Title:    http.StatusText(statusCode),
+		Status:   statusCode,
+		Detail:   message,
+		Instance: c.Request.URL.Path,
+		Code:     code,
+	}
+}
+
+// WriteProblem sends a problem+json response
+func WriteProblem(c *gin.Context, problem *Problem) {
+	c.Render(problem.Status, problemRender{problem})
+}
+
+// problemType returns the type URI for a code, or about:blank when no base URL is configured
+func problemType(code string) string {
+	if current.ProblemTypeBaseURL == "" || code == "" {
+		return "about:blank"
+	}
+	slug := strings.ReplaceAll(strings.ToLower(code), "_", "-")
+	return strings.TrimSuffix(current.ProblemTypeBaseURL, "/") + "/" + slug
+}
+
+// problemRender writes JSON while keeping the problem+json content type
+type problemRender struct {
+	problem *Problem
+}
+
+func (r problemRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return render.WriteJSON(w, r.problem)
+}
+
+func (r problemRender) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", ProblemContentType)
+}
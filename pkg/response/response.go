@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"time"
 
+	"flex-service/pkg/validator"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -13,7 +15,9 @@ type Response struct {
 	Message    string      `json:"message"`
 	Data       interface{} `json:"data,omitempty"`
 	Error      *ErrorInfo  `json:"error,omitempty"`
+	Errors     []ErrorInfo `json:"errors,omitempty"`
 	Meta       *Meta       `json:"meta,omitempty"`
+	Links      *Links      `json:"links,omitempty"`
 	Timestamp  time.Time   `json:"timestamp"`
 }
 
@@ -21,18 +25,24 @@ type Response struct {
 type ErrorInfo struct {
 	Code    string            `json:"code"`
 	Message string            `json:"message"`
+	Field   string            `json:"field,omitempty"`
 	Details interface{}       `json:"details,omitempty"`
 	Fields  map[string]string `json:"fields,omitempty"`
+
+	// Violations lists every failed rule for validation errors
+	Violations validator.ValidationErrors `json:"violations,omitempty"`
 }
 
 // Meta represents pagination and additional metadata
 type Meta struct {
-	Page        int   `json:"page,omitempty"`
-	Limit       int   `json:"limit,omitempty"`
-	Total       int64 `json:"total,omitempty"`
-	TotalPages  int   `json:"total_pages,omitempty"`
-	HasNext     bool  `json:"has_next,omitempty"`
-	HasPrevious bool  `json:"has_previous,omitempty"`
+	Page        int    `json:"page,omitempty"`
+	Limit       int    `json:"limit,omitempty"`
+	Total       int64  `json:"total,omitempty"`
+	TotalPages  int    `json:"total_pages,omitempty"`
+	NextCursor  string `json:"next_cursor,omitempty"`
+	PrevCursor  string `json:"prev_cursor,omitempty"`
+	HasNext     bool   `json:"has_next,omitempty"`
+	HasPrevious bool   `json:"has_previous,omitempty"`
 }
 
 // Success sends a successful response
@@ -58,6 +68,13 @@ func SuccessWithMeta(c *gin.Context, statusCode int, message string, data interf
 
 // Error sends an error response
 func Error(c *gin.Context, statusCode int, code, message string, details interface{}) {
+	if UseProblemDetails() {
+		problem := NewProblem(c, statusCode, code, message)
+		problem.Details = details
+		WriteProblem(c, problem)
+		return
+	}
+
 	c.JSON(statusCode, Response{
 		StatusCode: statusCode,
 		Message:    "Request failed",
@@ -70,20 +87,68 @@ func Error(c *gin.Context, statusCode int, code, message string, details interfa
 	})
 }
 
+// Errors sends an error response carrying several errors
+func Errors(c *gin.Context, statusCode int, message string, errs []ErrorInfo) {
+	if UseProblemDetails() {
+		problem := NewProblem(c, statusCode, "", message)
+		problem.Errors = errs
+		WriteProblem(c, problem)
+		return
+	}
+
+	c.JSON(statusCode, Response{
+		StatusCode: statusCode,
+		Message:    message,
+		Errors:     errs,
+		Timestamp:  time.Now().UTC(),
+	})
+}
+
 // ValidationError sends a validation error response
 func ValidationError(c *gin.Context, message string, fields map[string]string) {
+	writeValidation(c, message, fields, nil)
+}
+
+// ValidationErrors sends a validation error with structured violations
+// (field, rule, param, message) alongside the field -> message map
+func ValidationErrors(c *gin.Context, message string, errs validator.ValidationErrors) {
+	writeValidation(c, message, errs.Map(), errs)
+}
+
+// writeValidation renders validation errors the same way for both helpers
+func writeValidation(c *gin.Context, message string, fields map[string]string, violations validator.ValidationErrors) {
+	if UseProblemDetails() {
+		problem := NewProblem(c, http.StatusBadRequest, "VALIDATION_ERROR", message)
+		problem.Fields = fields
+		problem.Violations = violations
+		WriteProblem(c, problem)
+		return
+	}
+
 	c.JSON(http.StatusBadRequest, Response{
 		StatusCode: http.StatusBadRequest,
 		Message:    "Validation failed",
 		Error: &ErrorInfo{
-			Code:    "VALIDATION_ERROR",
-			Message: message,
-			Fields:  fields,
+			Code:       "VALIDATION_ERROR",
+			Message:    message,
+			Fields:     fields,
+			Violations: violations,
 		},
 		Timestamp: time.Now().UTC(),
 	})
 }
 
+// Partial sends a 207 response for batch operations where some items failed
+func Partial(c *gin.Context, message string, data interface{}, errs []ErrorInfo) {
+	c.JSON(http.StatusMultiStatus, Response{
+		StatusCode: http.StatusMultiStatus,
+		Message:    message,
+		Data:       data,
+		Errors:     errs,
+		Timestamp:  time.Now().UTC(),
+	})
+}
+
 // Pagination creates pagination metadata
 func Pagination(page, limit int, total int64) *Meta {
 	if limit <= 0 {
@@ -0,0 +1,69 @@
+package routes
+
+import "sort"
+
+// Route profiles group routes that are compiled in but only mounted when
+// their profile is enabled
+const (
+	ProfileAdmin        = "admin"        // user, role and session administration
+	ProfileDebug        = "debug"        // expvar and pprof, unauthenticated
+	ProfileExperimental = "experimental" // features whose API may still change
+)
+
+// KnownProfiles lists the built-in profiles
+var KnownProfiles = []string{ProfileAdmin, ProfileDebug, ProfileExperimental}
+
+// DefaultProfiles are enabled when none are configured: every built-in
+// profile in development, only admin in production
+func DefaultProfiles(env string) []string {
+	if env == "production" {
+		return []string{ProfileAdmin}
+	}
+	return append([]string(nil), KnownProfiles...)
+}
+
+// Profiles is the set of enabled route profiles
+type Profiles struct {
+	enabled map[string]bool
+}
+
+// NewProfiles enables the named profiles
+func NewProfiles(names ...string) *Profiles {
+	p := &Profiles{enabled: make(map[string]bool, len(names))}
+	for _, name := range names {
+		if name != "" {
+			p.enabled[name] = true
+		}
+	}
+	return p
+}
+
+// Enabled reports whether routes of profile are mounted. Routes without a
+// profile always are, and so is everything when p is nil.
+func (p *Profiles) Enabled(profile string) bool {
+	return p == nil || profile == "" || p.enabled[profile]
+}
+
+// Active returns the enabled profiles, sorted
+func (p *Profiles) Active() []string {
+	if p == nil {
+		return nil
+	}
+	names := make([]string, 0, len(p.enabled))
+	for name := range p.enabled {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Inactive returns the known profiles that are not enabled
+func (p *Profiles) Inactive() []string {
+	var names []string
+	for _, name := range KnownProfiles {
+		if !p.Enabled(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
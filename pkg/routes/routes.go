@@ -0,0 +1,344 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrInvalidManifest is wrapped by every validation error from Load
+var ErrInvalidManifest = errors.New("invalid route manifest")
+
+// Route declares one endpoint of a module
+type Route struct {
+	Method string `json:"method"`
+	// Path is relative to the manifest prefix; "" is the prefix itself
+	Path string `json:"path"`
+	// Handler is the name of a method on the module's handler, e.g. "List"
+	Handler string `json:"handler"`
+	// Middleware refs run after the manifest's, e.g. "rate.user:10,1m"
+	Middleware []string `json:"middleware,omitempty"`
+	// Permission is required of the principal, checked after the middleware
+	Permission string `json:"permission,omitempty"`
+	Summary    string `json:"summary,omitempty"`
+}
+
+// Manifest declares the routes of a module in one place, so the router, route
+// listings, API docs and permission audits all read the same source
+type Manifest struct {
+	Module string `json:"module"`
+	Prefix string `json:"prefix"`
+	// Middleware refs applied to every route of the module
+	Middleware []string `json:"middleware,omitempty"`
+	// Permission is required by every route that declares none of its own
+	Permission string `json:"permission,omitempty"`
+	// Profile mounts the module only while the profile is enabled, e.g.
+	// ProfileAdmin; empty mounts it always
+	Profile string  `json:"profile,omitempty"`
+	Routes  []Route `json:"routes"`
+}
+
+// Definition is a route as loaded: the full path, the resolved handler name and
+// every middleware ref in the order it runs
+type Definition struct {
+	Module     string   `json:"module"`
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Handler    string   `json:"handler"`
+	Middleware []string `json:"middleware"`
+	Permission string   `json:"permission,omitempty"`
+	Public     bool     `json:"public"`
+	Summary    string   `json:"summary,omitempty"`
+}
+
+// MiddlewareFactory builds a middleware from the arguments of its ref. "rate.user:10,1m"
+// calls the "rate.user" factory with "10" and "1m".
+type MiddlewareFactory func(args ...string) (gin.HandlerFunc, error)
+
+// PermissionGuard returns middleware that requires the permissions, such as
+// auth.PermissionChecker.Require
+type PermissionGuard func(permissions ...string) gin.HandlerFunc
+
+// Registry resolves the refs in manifests to handlers and middleware
+type Registry struct {
+	handlers      map[string]interface{}
+	middleware    map[string]MiddlewareFactory
+	authenticates map[string]bool
+	late          map[string]bool
+	attached      []attachment
+	guard         PermissionGuard
+	profiles      *Profiles
+	definitions   []Definition
+	skipped       []string
+}
+
+// NewRegistry creates a registry. guard enforces Route.Permission; it may be
+// nil when no manifest declares permissions.
+func NewRegistry(guard PermissionGuard) *Registry {
+	return &Registry{
+		handlers:      make(map[string]interface{}),
+		middleware:    make(map[string]MiddlewareFactory),
+		authenticates: make(map[string]bool),
+		late:          make(map[string]bool),
+		guard:         guard,
+	}
+}
+
+// Handler registers the handler of a module. Route.Handler names its methods,
+// which must have the signature func(*gin.Context).
+func (r *Registry) Handler(module string, handler interface{}) *Registry {
+	r.handlers[module] = handler
+	return r
+}
+
+// Middleware registers a middleware factory under name
+func (r *Registry) Middleware(name string, factory MiddlewareFactory) *Registry {
+	r.middleware[name] = factory
+	return r
+}
+
+// Authenticator registers a middleware that sets the principal. Routes with a
+// permission must run one, and routes without one are listed as public.
+func (r *Registry) Authenticator(name string, factory MiddlewareFactory) *Registry {
+	r.authenticates[name] = true
+	return r.Middleware(name, factory)
+}
+
+// Late registers a middleware that runs after the permission guard, right
+// before the handler, wherever its ref is listed. Response caching is one: a
+// cached response must not reach a principal who lost the permission.
+func (r *Registry) Late(name string, factory MiddlewareFactory) *Registry {
+	r.late[name] = true
+	return r.Middleware(name, factory)
+}
+
+// Profiles sets the enabled route profiles. Manifests of other profiles are
+// skipped by Load; without Profiles every manifest is loaded.
+func (r *Registry) Profiles(profiles *Profiles) *Registry {
+	r.profiles = profiles
+	return r
+}
+
+// attachment is a middleware added to every route by Attach
+type attachment struct {
+	name    string
+	handler gin.HandlerFunc
+}
+
+// Attach adds a middleware to every route, after its own middleware refs
+// (so after authentication) and before the permission guard. It is listed
+// under name in Definition.Middleware. Rate limit policies, which match
+// routes by their path, are attached this way.
+func (r *Registry) Attach(name string, handler gin.HandlerFunc) *Registry {
+	r.attached = append(r.attached, attachment{name: name, handler: handler})
+	return r
+}
+
+// Load validates the manifests and mounts their routes on group. Nothing is
+// mounted unless every manifest is valid; the error lists every problem found.
+func (r *Registry) Load(group *gin.RouterGroup, manifests ...Manifest) error {
+	type mounted struct {
+		definition Definition
+		chain      []gin.HandlerFunc
+	}
+
+	var (
+		problems []string
+		routes   []mounted
+		seen     = make(map[string]string)
+	)
+	var skipped []string
+	for _, manifest := range manifests {
+		if !r.profiles.Enabled(manifest.Profile) {
+			skipped = append(skipped, manifest.Module)
+			continue
+		}
+		for _, route := range manifest.Routes {
+			definition, chain, errs := r.resolve(group.BasePath(), manifest, route)
+			if len(errs) > 0 {
+				problems = append(problems, errs...)
+				continue
+			}
+
+			key := definition.Method + " " + definition.Path
+			if other, exists := seen[key]; exists {
+				problems = append(problems, fmt.Sprintf("%s: %s is already declared by %s", manifest.Module, key, other))
+				continue
+			}
+			seen[key] = manifest.Module
+			routes = append(routes, mounted{definition, chain})
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w:\n  %s", ErrInvalidManifest, strings.Join(problems, "\n  "))
+	}
+
+	base := group.BasePath()
+	for _, route := range routes {
+		relative := strings.TrimPrefix(route.definition.Path, strings.TrimSuffix(base, "/"))
+		group.Handle(route.definition.Method, relative, route.chain...)
+		r.definitions = append(r.definitions, route.definition)
+	}
+	r.skipped = append(r.skipped, skipped...)
+	return nil
+}
+
+// Skipped returns the modules Load skipped because their profile is disabled
+func (r *Registry) Skipped() []string {
+	return append([]string(nil), r.skipped...)
+}
+
+// Definitions returns every loaded route sorted by path and method
+func (r *Registry) Definitions() []Definition {
+	definitions := append([]Definition(nil), r.definitions...)
+	sort.SliceStable(definitions, func(i, j int) bool {
+		if definitions[i].Path != definitions[j].Path {
+			return definitions[i].Path < definitions[j].Path
+		}
+		return definitions[i].Method < definitions[j].Method
+	})
+	return definitions
+}
+
+// resolve validates one route and builds its handler chain
+func (r *Registry) resolve(base string, manifest Manifest, route Route) (Definition, []gin.HandlerFunc, []string) {
+	var errs []string
+	fail := func(format string, args ...interface{}) {
+		label := fmt.Sprintf("%s: %s %s", manifest.Module, route.Method, joinPath(manifest.Prefix, route.Path))
+		errs = append(errs, label+": "+fmt.Sprintf(format, args...))
+	}
+
+	permission := route.Permission
+	if permission == "" {
+		permission = manifest.Permission
+	}
+
+	definition := Definition{
+		Module:     manifest.Module,
+		Method:     strings.ToUpper(route.Method),
+		Path:       joinPath(base, joinPath(manifest.Prefix, route.Path)),
+		Permission: permission,
+		Summary:    route.Summary,
+		Public:     true,
+	}
+
+	if !validMethod(definition.Method) {
+		fail("unknown method")
+	}
+	if manifest.Prefix != "" && !strings.HasPrefix(manifest.Prefix, "/") {
+		fail("prefix must start with /")
+	}
+	if route.Path != "" && !strings.HasPrefix(route.Path, "/") {
+		fail("path must be empty or start with /")
+	}
+
+	handler, name, err := r.handler(manifest.Module, route.Handler)
+	if err != nil {
+		fail("%v", err)
+	}
+	definition.Handler = name
+
+	var chain, late []gin.HandlerFunc
+	var lateRefs []string
+	for _, ref := range append(append([]string(nil), manifest.Middleware...), route.Middleware...) {
+		name, args := parseRef(ref)
+		factory, ok := r.middleware[name]
+		if !ok {
+			fail("unknown middleware %q", name)
+			continue
+		}
+		mw, err := factory(args...)
+		if err != nil {
+			fail("middleware %q: %v", ref, err)
+			continue
+		}
+		if r.late[name] {
+			late = append(late, mw)
+			lateRefs = append(lateRefs, ref)
+			continue
+		}
+		if r.authenticates[name] {
+			definition.Public = false
+		}
+		chain = append(chain, mw)
+		definition.Middleware = append(definition.Middleware, ref)
+	}
+	for _, a := range r.attached {
+		chain = append(chain, a.handler)
+		definition.Middleware = append(definition.Middleware, a.name)
+	}
+	definition.Middleware = append(definition.Middleware, lateRefs...)
+
+	if permission != "" {
+		switch {
+		case r.guard == nil:
+			fail("permission %q declared but no permission guard is registered", permission)
+		case definition.Public:
+			fail("permission %q requires an authenticating middleware", permission)
+		default:
+			chain = append(chain, r.guard(permission))
+		}
+	}
+
+	chain = append(chain, late...)
+	return definition, append(chain, handler), errs
+}
+
+// handler finds the method named ref on the module's handler
+func (r *Registry) handler(module, ref string) (gin.HandlerFunc, string, error) {
+	h, ok := r.handlers[module]
+	if !ok {
+		return nil, ref, fmt.Errorf("no handler registered for module %q", module)
+	}
+
+	value := reflect.ValueOf(h)
+	typ := reflect.Indirect(value).Type()
+	name := typ.Name() + "." + ref
+	if ref == "" {
+		return nil, name, fmt.Errorf("handler is required")
+	}
+
+	method := value.MethodByName(ref)
+	if !method.IsValid() {
+		return nil, name, fmt.Errorf("handler %s does not exist", name)
+	}
+	fn, ok := method.Interface().(func(*gin.Context))
+	if !ok {
+		return nil, name, fmt.Errorf("handler %s must be func(*gin.Context)", name)
+	}
+	return fn, name, nil
+}
+
+// parseRef splits "rate.user:10,1m" into "rate.user" and ["10", "1m"]
+func parseRef(ref string) (string, []string) {
+	name, raw, found := strings.Cut(strings.TrimSpace(ref), ":")
+	if !found || raw == "" {
+		return name, nil
+	}
+	args := strings.Split(raw, ",")
+	for i := range args {
+		args[i] = strings.TrimSpace(args[i])
+	}
+	return name, args
+}
+
+func joinPath(base, path string) string {
+	if path == "" {
+		return base
+	}
+	return strings.TrimSuffix(base, "/") + path
+}
+
+func validMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
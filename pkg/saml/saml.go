@@ -0,0 +1,441 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+)
+
+// SAML 2.0 namespaces and identifiers
+const (
+	NSAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+	NSProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	NSMetadata  = "urn:oasis:names:tc:SAML:2.0:metadata"
+
+	BindingHTTPPost     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	BindingHTTPRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+
+	NameIDFormatUnspecified = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+	NameIDFormatEmail       = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+
+	statusSuccess      = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	confirmationBearer = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+)
+
+// SAML errors
+var (
+	ErrMalformed          = errors.New("malformed SAML message")
+	ErrUnsigned           = errors.New("SAML message is not signed")
+	ErrInvalidSignature   = errors.New("invalid SAML signature")
+	ErrEncryptedAssertion = errors.New("encrypted SAML assertions are not supported")
+	ErrInvalidResponse    = errors.New("invalid SAML response")
+	ErrExpired            = errors.New("SAML assertion is expired or not yet valid")
+	ErrUnsolicited        = errors.New("IdP-initiated SAML login is disabled")
+	ErrInvalidMetadata    = errors.New("invalid IdP metadata")
+)
+
+// DefaultClockSkew is how far IdP and SP clocks may drift
+const DefaultClockSkew = 2 * time.Minute
+
+// IdPMetadata is what the SP needs to know about the identity provider
+type IdPMetadata struct {
+	EntityID     string
+	SSOURL       string // HTTP-Redirect SingleSignOnService location
+	Certificates []*x509.Certificate
+}
+
+// Config configures a service provider
+type Config struct {
+	EntityID          string // SP entity ID, usually the metadata URL
+	ACSURL            string // Assertion Consumer Service URL (HTTP-POST)
+	IdP               *IdPMetadata
+	NameIDFormat      string // Default NameIDFormatUnspecified
+	AllowIdPInitiated bool   // Accept responses the SP did not request
+	ClockSkew         time.Duration
+}
+
+// ServiceProvider implements SP-initiated (HTTP-Redirect) and IdP-initiated
+// (HTTP-POST) web browser SSO
+type ServiceProvider struct {
+	cfg Config
+	now func() time.Time
+}
+
+// NewServiceProvider creates a service provider
+func NewServiceProvider(cfg Config) (*ServiceProvider, error) {
+	if cfg.EntityID == "" || cfg.ACSURL == "" {
+		return nil, fmt.Errorf("saml: entity ID and ACS URL are required")
+	}
+	if cfg.IdP == nil || cfg.IdP.EntityID == "" || len(cfg.IdP.Certificates) == 0 {
+		return nil, fmt.Errorf("saml: IdP entity ID and signing certificate are required")
+	}
+	if cfg.NameIDFormat == "" {
+		cfg.NameIDFormat = NameIDFormatUnspecified
+	}
+	if cfg.ClockSkew <= 0 {
+		cfg.ClockSkew = DefaultClockSkew
+	}
+	return &ServiceProvider{cfg: cfg, now: time.Now}, nil
+}
+
+// Assertion is a verified SAML assertion
+type Assertion struct {
+	ID           string
+	Issuer       string
+	NameID       string
+	NameIDFormat string
+	SessionIndex string
+	InResponseTo string // AuthnRequest ID; empty for IdP-initiated logins
+	NotOnOrAfter time.Time
+	Attributes   map[string][]string // By Name and, when present, FriendlyName
+}
+
+// Attribute returns the first value of an attribute
+func (a *Assertion) Attribute(name string) string {
+	if values := a.Attributes[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Metadata returns the SP metadata document to register with the IdP
+func (sp *ServiceProvider) Metadata() ([]byte, error) {
+	type acs struct {
+		Binding  string `xml:"Binding,attr"`
+		Location string `xml:"Location,attr"`
+		Index    int    `xml:"index,attr"`
+	}
+	type descriptor struct {
+		XMLName                    xml.Name `xml:"md:SPSSODescriptor"`
+		AuthnRequestsSigned        bool     `xml:"AuthnRequestsSigned,attr"`
+		WantAssertionsSigned       bool     `xml:"WantAssertionsSigned,attr"`
+		ProtocolSupportEnumeration string   `xml:"protocolSupportEnumeration,attr"`
+		NameIDFormat               string   `xml:"md:NameIDFormat"`
+		AssertionConsumerService   acs      `xml:"md:AssertionConsumerService"`
+	}
+	type entity struct {
+		XMLName    xml.Name   `xml:"md:EntityDescriptor"`
+		XMLNS      string     `xml:"xmlns:md,attr"`
+		EntityID   string     `xml:"entityID,attr"`
+		Descriptor descriptor `xml:"md:SPSSODescriptor"`
+	}
+
+	body, err := xml.MarshalIndent(entity{
+		XMLNS:    NSMetadata,
+		EntityID: sp.cfg.EntityID,
+		Descriptor: descriptor{
+			WantAssertionsSigned:       true,
+			ProtocolSupportEnumeration: NSProtocol,
+			NameIDFormat:               sp.cfg.NameIDFormat,
+			AssertionConsumerService:   acs{Binding: BindingHTTPPost, Location: sp.cfg.ACSURL, Index: 0},
+		},
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// AuthnRequestURL starts an SP-initiated login. It returns the IdP URL to
+// redirect the browser to and the request ID, which the caller must keep to
+// match the response's InResponseTo.
+func (sp *ServiceProvider) AuthnRequestURL(relayState string) (string, string, error) {
+	if sp.cfg.IdP.SSOURL == "" {
+		return "", "", fmt.Errorf("saml: IdP has no HTTP-Redirect SSO URL")
+	}
+
+	id, err := newID()
+	if err != nil {
+		return "", "", err
+	}
+
+	type nameIDPolicy struct {
+		Format      string `xml:"Format,attr"`
+		AllowCreate bool   `xml:"AllowCreate,attr"`
+	}
+	type authnRequest struct {
+		XMLName                     xml.Name     `xml:"samlp:AuthnRequest"`
+		XMLNSP                      string       `xml:"xmlns:samlp,attr"`
+		XMLNS                       string       `xml:"xmlns:saml,attr"`
+		ID                          string       `xml:"ID,attr"`
+		Version                     string       `xml:"Version,attr"`
+		IssueInstant                string       `xml:"IssueInstant,attr"`
+		Destination                 string       `xml:"Destination,attr"`
+		AssertionConsumerServiceURL string       `xml:"AssertionConsumerServiceURL,attr"`
+		ProtocolBinding             string       `xml:"ProtocolBinding,attr"`
+		Issuer                      string       `xml:"saml:Issuer"`
+		NameIDPolicy                nameIDPolicy `xml:"samlp:NameIDPolicy"`
+	}
+
+	body, err := xml.Marshal(authnRequest{
+		XMLNSP:                      NSProtocol,
+		XMLNS:                       NSAssertion,
+		ID:                          id,
+		Version:                     "2.0",
+		IssueInstant:                sp.now().UTC().Format(time.RFC3339),
+		Destination:                 sp.cfg.IdP.SSOURL,
+		AssertionConsumerServiceURL: sp.cfg.ACSURL,
+		ProtocolBinding:             BindingHTTPPost,
+		Issuer:                      sp.cfg.EntityID,
+		NameIDPolicy:                nameIDPolicy{Format: sp.cfg.NameIDFormat, AllowCreate: true},
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	// HTTP-Redirect binding: raw DEFLATE, then base64
+	var deflated bytes.Buffer
+	w, _ := flate.NewWriter(&deflated, flate.BestCompression)
+	w.Write(body)
+	w.Close()
+
+	redirect, err := url.Parse(sp.cfg.IdP.SSOURL)
+	if err != nil {
+		return "", "", err
+	}
+	q := redirect.Query()
+	q.Set("SAMLRequest", base64.StdEncoding.EncodeToString(deflated.Bytes()))
+	if relayState != "" {
+		q.Set("RelayState", relayState)
+	}
+	redirect.RawQuery = q.Encode()
+
+	return redirect.String(), id, nil
+}
+
+// ParseResponse verifies a base64 SAMLResponse posted to the ACS URL and returns
+// its assertion. The response or the assertion must be signed by the IdP.
+// Callers must still check InResponseTo against their pending requests and
+// reject assertion IDs they have already seen.
+func (sp *ServiceProvider) ParseResponse(encoded string) (*Assertion, error) {
+	data, err := decodeBase64(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid base64", ErrMalformed)
+	}
+
+	response, err := parseXML(data)
+	if err != nil {
+		return nil, err
+	}
+	if !response.is(NSProtocol, "Response") {
+		return nil, fmt.Errorf("%w: not a Response", ErrInvalidResponse)
+	}
+
+	// A present but invalid signature always fails
+	responseSigned := false
+	if signature(response) != nil {
+		if err := verifySignature(response, sp.cfg.IdP.Certificates); err != nil {
+			return nil, err
+		}
+		responseSigned = true
+	}
+
+	if destination := response.attr("Destination"); destination != "" && destination != sp.cfg.ACSURL {
+		return nil, fmt.Errorf("%w: wrong destination", ErrInvalidResponse)
+	}
+	if issuer := response.child(NSAssertion, "Issuer"); issuer != nil && issuer.text() != sp.cfg.IdP.EntityID {
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidResponse)
+	}
+	status := response.child(NSProtocol, "Status").child(NSProtocol, "StatusCode").attr("Value")
+	if status != statusSuccess {
+		return nil, fmt.Errorf("%w: status %s", ErrInvalidResponse, status)
+	}
+
+	if len(response.elements(NSAssertion, "EncryptedAssertion")) > 0 {
+		return nil, ErrEncryptedAssertion
+	}
+	assertions := response.elements(NSAssertion, "Assertion")
+	if len(assertions) != 1 {
+		return nil, fmt.Errorf("%w: expected one assertion", ErrInvalidResponse)
+	}
+	assertion := assertions[0]
+
+	if signature(assertion) != nil || !responseSigned {
+		if err := verifySignature(assertion, sp.cfg.IdP.Certificates); err != nil {
+			return nil, err
+		}
+	}
+
+	return sp.readAssertion(assertion, response.attr("InResponseTo"))
+}
+
+// readAssertion validates the conditions of a verified assertion and reads it
+func (sp *ServiceProvider) readAssertion(el *element, inResponseTo string) (*Assertion, error) {
+	now := sp.now()
+	skew := sp.cfg.ClockSkew
+
+	if el.child(NSAssertion, "Issuer").text() != sp.cfg.IdP.EntityID {
+		return nil, fmt.Errorf("%w: unexpected assertion issuer", ErrInvalidResponse)
+	}
+
+	conditions := el.child(NSAssertion, "Conditions")
+	if conditions != nil {
+		if t, ok := parseTime(conditions.attr("NotBefore")); ok && now.Add(skew).Before(t) {
+			return nil, ErrExpired
+		}
+		if t, ok := parseTime(conditions.attr("NotOnOrAfter")); ok && !now.Add(-skew).Before(t) {
+			return nil, ErrExpired
+		}
+		for _, restriction := range conditions.elements(NSAssertion, "AudienceRestriction") {
+			if !hasAudience(restriction, sp.cfg.EntityID) {
+				return nil, fmt.Errorf("%w: SP is not in the audience", ErrInvalidResponse)
+			}
+		}
+	}
+
+	subject := el.child(NSAssertion, "Subject")
+	nameID := subject.child(NSAssertion, "NameID")
+	if nameID.text() == "" {
+		return nil, fmt.Errorf("%w: missing NameID", ErrInvalidResponse)
+	}
+
+	// Bearer confirmation ties the assertion to this ACS and request
+	var confirmed bool
+	var notOnOrAfter time.Time
+	for _, confirmation := range subject.elements(NSAssertion, "SubjectConfirmation") {
+		if confirmation.attr("Method") != confirmationBearer {
+			continue
+		}
+		data := confirmation.child(NSAssertion, "SubjectConfirmationData")
+		if recipient := data.attr("Recipient"); recipient != "" && recipient != sp.cfg.ACSURL {
+			continue
+		}
+		if data.attr("InResponseTo") != inResponseTo {
+			continue
+		}
+		t, ok := parseTime(data.attr("NotOnOrAfter"))
+		if !ok || !now.Add(-skew).Before(t) {
+			continue
+		}
+		confirmed, notOnOrAfter = true, t
+		break
+	}
+	if !confirmed {
+		return nil, fmt.Errorf("%w: no valid bearer subject confirmation", ErrInvalidResponse)
+	}
+
+	if inResponseTo == "" && !sp.cfg.AllowIdPInitiated {
+		return nil, ErrUnsolicited
+	}
+
+	assertion := &Assertion{
+		ID:           el.attr("ID"),
+		Issuer:       sp.cfg.IdP.EntityID,
+		NameID:       nameID.text(),
+		NameIDFormat: nameID.attr("Format"),
+		SessionIndex: el.child(NSAssertion, "AuthnStatement").attr("SessionIndex"),
+		InResponseTo: inResponseTo,
+		NotOnOrAfter: notOnOrAfter,
+		Attributes:   make(map[string][]string),
+	}
+	for _, statement := range el.elements(NSAssertion, "AttributeStatement") {
+		for _, attribute := range statement.elements(NSAssertion, "Attribute") {
+			var values []string
+			for _, value := range attribute.elements(NSAssertion, "AttributeValue") {
+				values = append(values, value.text())
+			}
+			for _, name := range []string{attribute.attr("Name"), attribute.attr("FriendlyName")} {
+				if name != "" {
+					assertion.Attributes[name] = append(assertion.Attributes[name], values...)
+				}
+			}
+		}
+	}
+	return assertion, nil
+}
+
+func hasAudience(restriction *element, entityID string) bool {
+	for _, audience := range restriction.elements(NSAssertion, "Audience") {
+		if audience.text() == entityID {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadIdPMetadata reads the IdP metadata XML published by the identity provider
+func LoadIdPMetadata(path string) (*IdPMetadata, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IdP metadata: %w", err)
+	}
+	return ParseIdPMetadata(data)
+}
+
+// ParseIdPMetadata reads the entity ID, HTTP-Redirect SSO URL and signing
+// certificates from an EntityDescriptor (or the first one of an EntitiesDescriptor)
+func ParseIdPMetadata(data []byte) (*IdPMetadata, error) {
+	root, err := parseXML(data)
+	if err != nil {
+		return nil, err
+	}
+	entity := root
+	if root.is(NSMetadata, "EntitiesDescriptor") {
+		entity = root.child(NSMetadata, "EntityDescriptor")
+	}
+	if !entity.is(NSMetadata, "EntityDescriptor") {
+		return nil, fmt.Errorf("%w: no EntityDescriptor", ErrInvalidMetadata)
+	}
+
+	descriptor := entity.child(NSMetadata, "IDPSSODescriptor")
+	if descriptor == nil {
+		return nil, fmt.Errorf("%w: no IDPSSODescriptor", ErrInvalidMetadata)
+	}
+
+	metadata := &IdPMetadata{EntityID: entity.attr("entityID")}
+	for _, service := range descriptor.elements(NSMetadata, "SingleSignOnService") {
+		if service.attr("Binding") == BindingHTTPRedirect {
+			metadata.SSOURL = service.attr("Location")
+			break
+		}
+	}
+	for _, key := range descriptor.elements(NSMetadata, "KeyDescriptor") {
+		if use := key.attr("use"); use != "" && use != "signing" {
+			continue
+		}
+		for _, data := range key.child(nsDSig, "KeyInfo").elements(nsDSig, "X509Data") {
+			for _, encoded := range data.elements(nsDSig, "X509Certificate") {
+				der, err := decodeBase64(encoded.text())
+				if err != nil {
+					return nil, fmt.Errorf("%w: invalid certificate", ErrInvalidMetadata)
+				}
+				cert, err := x509.ParseCertificate(der)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
+				}
+				metadata.Certificates = append(metadata.Certificates, cert)
+			}
+		}
+	}
+
+	if metadata.EntityID == "" || len(metadata.Certificates) == 0 {
+		return nil, fmt.Errorf("%w: entity ID and signing certificate are required", ErrInvalidMetadata)
+	}
+	return metadata, nil
+}
+
+func parseTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	return t, err == nil
+}
+
+// newID returns a SAML ID; IDs must not start with a digit
+func newID() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "id-" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,143 @@
+package saml
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	// Register the digests used by supported signature methods
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// XML Signature namespaces and algorithms. Only exclusive canonicalization
+// and SHA-256/512 are accepted; SHA-1 signatures are rejected.
+const (
+	nsDSig     = "http://www.w3.org/2000/09/xmldsig#"
+	nsExcC14N  = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	algExcC14N = "http://www.w3.org/2001/10/xml-exc-c14n#"
+
+	algEnvelopedSignature = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+)
+
+var signatureMethods = map[string]crypto.Hash{
+	"http://www.w3.org/2001/04/xmldsig-more#rsa-sha256": crypto.SHA256,
+	"http://www.w3.org/2001/04/xmldsig-more#rsa-sha512": crypto.SHA512,
+}
+
+var digestMethods = map[string]crypto.Hash{
+	"http://www.w3.org/2001/04/xmlenc#sha256": crypto.SHA256,
+	"http://www.w3.org/2001/04/xmlenc#sha512": crypto.SHA512,
+}
+
+// signature returns the enveloped ds:Signature of e, or nil
+func signature(e *element) *element {
+	return e.child(nsDSig, "Signature")
+}
+
+// verifySignature checks the enveloped signature of e against the IdP
+// certificates. The signature must reference e itself by ID, so a signed element
+// can't be moved elsewhere in the document (signature wrapping).
+func verifySignature(e *element, certs []*x509.Certificate) error {
+	sig := signature(e)
+	if sig == nil {
+		return ErrUnsigned
+	}
+	if len(e.elements(nsDSig, "Signature")) > 1 {
+		return fmt.Errorf("%w: multiple signatures", ErrInvalidSignature)
+	}
+
+	signedInfo := sig.child(nsDSig, "SignedInfo")
+	if signedInfo == nil {
+		return fmt.Errorf("%w: missing SignedInfo", ErrInvalidSignature)
+	}
+
+	method := signedInfo.child(nsDSig, "CanonicalizationMethod")
+	if method == nil || method.attr("Algorithm") != algExcC14N {
+		return fmt.Errorf("%w: unsupported canonicalization", ErrInvalidSignature)
+	}
+	signedInfoPrefixes := inclusivePrefixes(method)
+
+	hash, ok := signatureMethods[signedInfo.child(nsDSig, "SignatureMethod").attr("Algorithm")]
+	if !ok {
+		return fmt.Errorf("%w: unsupported signature method", ErrInvalidSignature)
+	}
+
+	references := signedInfo.elements(nsDSig, "Reference")
+	if len(references) != 1 {
+		return fmt.Errorf("%w: expected one reference", ErrInvalidSignature)
+	}
+	reference := references[0]
+
+	id := e.attr("ID")
+	if id == "" || reference.attr("URI") != "#"+id {
+		return fmt.Errorf("%w: reference does not point at the signed element", ErrInvalidSignature)
+	}
+
+	// Transforms must be enveloped-signature followed by exclusive c14n
+	var referencePrefixes []string
+	var enveloped, canonical bool
+	for _, transform := range reference.child(nsDSig, "Transforms").elements(nsDSig, "Transform") {
+		switch transform.attr("Algorithm") {
+		case algEnvelopedSignature:
+			enveloped = true
+		case algExcC14N:
+			canonical = true
+			referencePrefixes = inclusivePrefixes(transform)
+		default:
+			return fmt.Errorf("%w: unsupported transform", ErrInvalidSignature)
+		}
+	}
+	if !enveloped || !canonical {
+		return fmt.Errorf("%w: unsupported transforms", ErrInvalidSignature)
+	}
+
+	digestHash, ok := digestMethods[reference.child(nsDSig, "DigestMethod").attr("Algorithm")]
+	if !ok {
+		return fmt.Errorf("%w: unsupported digest method", ErrInvalidSignature)
+	}
+	expectedDigest, err := decodeBase64(reference.child(nsDSig, "DigestValue").text())
+	if err != nil {
+		return fmt.Errorf("%w: invalid digest value", ErrInvalidSignature)
+	}
+
+	h := digestHash.New()
+	h.Write(canonicalize(e, referencePrefixes, sig))
+	if subtle.ConstantTimeCompare(h.Sum(nil), expectedDigest) != 1 {
+		return fmt.Errorf("%w: digest mismatch", ErrInvalidSignature)
+	}
+
+	signatureValue, err := decodeBase64(sig.child(nsDSig, "SignatureValue").text())
+	if err != nil {
+		return fmt.Errorf("%w: invalid signature value", ErrInvalidSignature)
+	}
+
+	h = hash.New()
+	h.Write(canonicalize(signedInfo, signedInfoPrefixes, nil))
+	digest := h.Sum(nil)
+
+	for _, cert := range certs {
+		key, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			continue
+		}
+		if rsa.VerifyPKCS1v15(key, hash, digest, signatureValue) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: signature does not match any IdP certificate", ErrInvalidSignature)
+}
+
+// inclusivePrefixes reads the InclusiveNamespaces PrefixList of a c14n method
+func inclusivePrefixes(method *element) []string {
+	return strings.Fields(method.child(nsExcC14N, "InclusiveNamespaces").attr("PrefixList"))
+}
+
+// decodeBase64 decodes base64 that may be wrapped across lines
+func decodeBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
@@ -0,0 +1,281 @@
+package saml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// element is a minimal DOM. Prefixes are kept as written (RawToken) because
+// signature verification has to canonicalize the exact namespace declarations.
+type element struct {
+	prefix   string
+	local    string
+	attrs    []xml.Attr // Name.Space holds the prefix; includes xmlns declarations
+	children []interface{}
+	parent   *element
+}
+
+const nsXML = "http://www.w3.org/XML/1998/namespace"
+
+// parseXML builds the tree for a document. DTDs are rejected.
+func parseXML(data []byte) (*element, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+
+	var root, cur *element
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			el := &element{
+				prefix: t.Name.Space,
+				local:  t.Name.Local,
+				attrs:  append([]xml.Attr(nil), t.Attr...),
+				parent: cur,
+			}
+			if cur != nil {
+				cur.children = append(cur.children, el)
+			} else if root == nil {
+				root = el
+			} else {
+				return nil, fmt.Errorf("%w: multiple root elements", ErrMalformed)
+			}
+			cur = el
+		case xml.EndElement:
+			if cur == nil || t.Name.Space != cur.prefix || t.Name.Local != cur.local {
+				return nil, fmt.Errorf("%w: unexpected end element %s", ErrMalformed, t.Name.Local)
+			}
+			cur = cur.parent
+		case xml.CharData:
+			if cur != nil {
+				cur.children = append(cur.children, string(t))
+			}
+		case xml.Directive:
+			return nil, fmt.Errorf("%w: DTDs are not allowed", ErrMalformed)
+		}
+	}
+
+	if root == nil || cur != nil {
+		return nil, fmt.Errorf("%w: incomplete document", ErrMalformed)
+	}
+	return root, nil
+}
+
+// lookup resolves a prefix ("" for the default namespace) in scope at e
+func (e *element) lookup(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return nsXML, true
+	}
+	for el := e; el != nil; el = el.parent {
+		for _, a := range el.attrs {
+			if prefix == "" && a.Name.Space == "" && a.Name.Local == "xmlns" {
+				return a.Value, true
+			}
+			if prefix != "" && a.Name.Space == "xmlns" && a.Name.Local == prefix {
+				return a.Value, true
+			}
+		}
+	}
+	return "", prefix == ""
+}
+
+func (e *element) namespace() string {
+	uri, _ := e.lookup(e.prefix)
+	return uri
+}
+
+func (e *element) is(namespace, local string) bool {
+	return e != nil && e.local == local && e.namespace() == namespace
+}
+
+// attr returns an unprefixed attribute
+func (e *element) attr(name string) string {
+	if e == nil {
+		return ""
+	}
+	for _, a := range e.attrs {
+		if a.Name.Space == "" && a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// elements returns the child elements named namespace:local
+func (e *element) elements(namespace, local string) []*element {
+	if e == nil {
+		return nil
+	}
+	var found []*element
+	for _, child := range e.children {
+		if el, ok := child.(*element); ok && el.is(namespace, local) {
+			found = append(found, el)
+		}
+	}
+	return found
+}
+
+// child returns the first child element named namespace:local, or nil
+func (e *element) child(namespace, local string) *element {
+	if e == nil {
+		return nil
+	}
+	if found := e.elements(namespace, local); len(found) > 0 {
+		return found[0]
+	}
+	return nil
+}
+
+// text returns the element's character data, trimmed
+func (e *element) text() string {
+	if e == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, child := range e.children {
+		if s, ok := child.(string); ok {
+			sb.WriteString(s)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func isNamespaceDecl(a xml.Attr) bool {
+	return a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns")
+}
+
+// canonicalize serializes e with Exclusive XML Canonicalization 1.0 without
+// comments (http://www.w3.org/2001/10/xml-exc-c14n#). inclusive is the
+// InclusiveNamespaces PrefixList ("#default" for the default namespace).
+// exclude, if set, is left out, as the enveloped-signature transform requires.
+func canonicalize(e *element, inclusive []string, exclude *element) []byte {
+	c := &canonicalizer{exclude: exclude}
+	for _, prefix := range inclusive {
+		if prefix == "#default" {
+			prefix = ""
+		}
+		c.inclusive = append(c.inclusive, prefix)
+	}
+	c.element(e, map[string]string{"": ""})
+	return c.buf.Bytes()
+}
+
+type canonicalizer struct {
+	buf       bytes.Buffer
+	inclusive []string
+	exclude   *element
+}
+
+type canonicalAttr struct {
+	namespace string
+	name      string // qualified name as written
+	local     string
+	value     string
+}
+
+func (c *canonicalizer) element(e *element, rendered map[string]string) {
+	// Namespaces are output where visibly utilized, unless an output ancestor
+	// already declared the same value
+	needed := []string{e.prefix}
+	for _, a := range e.attrs {
+		if !isNamespaceDecl(a) && a.Name.Space != "" {
+			needed = append(needed, a.Name.Space)
+		}
+	}
+	needed = append(needed, c.inclusive...)
+
+	scope := rendered
+	var decls []canonicalAttr
+	seen := make(map[string]bool)
+	for _, prefix := range needed {
+		if seen[prefix] || prefix == "xml" {
+			continue
+		}
+		seen[prefix] = true
+
+		uri, ok := e.lookup(prefix)
+		if !ok {
+			continue
+		}
+		if current, ok := scope[prefix]; ok && current == uri {
+			continue
+		}
+		if prefix != "" && uri == "" {
+			continue
+		}
+
+		if len(decls) == 0 {
+			scope = make(map[string]string, len(rendered)+1)
+			for k, v := range rendered {
+				scope[k] = v
+			}
+		}
+		scope[prefix] = uri
+		name := "xmlns"
+		if prefix != "" {
+			name = "xmlns:" + prefix
+		}
+		decls = append(decls, canonicalAttr{local: prefix, name: name, value: uri})
+	}
+	sort.Slice(decls, func(i, j int) bool { return decls[i].local < decls[j].local })
+
+	var attrs []canonicalAttr
+	for _, a := range e.attrs {
+		if isNamespaceDecl(a) {
+			continue
+		}
+		attr := canonicalAttr{name: a.Name.Local, local: a.Name.Local, value: a.Value}
+		if a.Name.Space != "" {
+			attr.namespace, _ = e.lookup(a.Name.Space)
+			attr.name = a.Name.Space + ":" + a.Name.Local
+		}
+		attrs = append(attrs, attr)
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		if attrs[i].namespace != attrs[j].namespace {
+			return attrs[i].namespace < attrs[j].namespace
+		}
+		return attrs[i].local < attrs[j].local
+	})
+
+	name := e.local
+	if e.prefix != "" {
+		name = e.prefix + ":" + e.local
+	}
+
+	c.buf.WriteString("<" + name)
+	for _, a := range append(decls, attrs...) {
+		c.buf.WriteString(" " + a.name + `="`)
+		c.buf.WriteString(attrEscaper.Replace(a.value))
+		c.buf.WriteString(`"`)
+	}
+	c.buf.WriteString(">")
+
+	for _, child := range e.children {
+		switch v := child.(type) {
+		case *element:
+			if v != c.exclude {
+				c.element(v, scope)
+			}
+		case string:
+			c.buf.WriteString(textEscaper.Replace(v))
+		}
+	}
+
+	c.buf.WriteString("</" + name + ">")
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
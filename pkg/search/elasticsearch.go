@@ -0,0 +1,228 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// keywordLimit is the longest string kept as a keyword for filters and sorts;
+// longer ones are only matched as text
+const keywordLimit = 8191
+
+// Elasticsearch is the Driver of an Elasticsearch (or OpenSearch) cluster.
+// Strings are mapped as keywords, for exact filters and sorts, with a "text"
+// subfield the full-text query matches. Index names are lowercased.
+type Elasticsearch struct {
+	api api
+
+	mu         sync.RWMutex
+	searchable map[string][]string // Settings.Searchable by index
+}
+
+// NewElasticsearch creates a driver for the cluster at baseURL, e.g.
+// http://localhost:9200, authenticating with an API key when set
+func NewElasticsearch(baseURL, apiKey string, timeout time.Duration) *Elasticsearch {
+	authorization := ""
+	if apiKey != "" {
+		authorization = "ApiKey " + apiKey
+	}
+	return &Elasticsearch{
+		api:        newAPI("elasticsearch", baseURL, authorization, timeout),
+		searchable: make(map[string][]string),
+	}
+}
+
+// EnsureIndex implements Driver. Filterable and sortable attributes need no
+// settings, since every string is also a keyword.
+func (es *Elasticsearch) EnsureIndex(ctx context.Context, index string, settings Settings) error {
+	mappings := map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"dynamic_templates": []interface{}{
+				map[string]interface{}{
+					"strings": map[string]interface{}{
+						"match_mapping_type": "string",
+						"mapping": map[string]interface{}{
+							"type":         "keyword",
+							"ignore_above": keywordLimit,
+							"fields":       map[string]interface{}{"text": map[string]string{"type": "text"}},
+						},
+					},
+				},
+			},
+		},
+	}
+	err := es.api.do(ctx, http.MethodPut, "/"+es.index(index), "application/json", mappings, nil)
+	if err != nil && !(hasStatus(err, http.StatusBadRequest) && strings.Contains(err.Error(), "resource_already_exists_exception")) {
+		return err
+	}
+
+	es.mu.Lock()
+	es.searchable[es.index(index)] = settings.Searchable
+	es.mu.Unlock()
+	return nil
+}
+
+// Upsert implements Driver
+func (es *Elasticsearch) Upsert(ctx context.Context, index string, docs []Document) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, doc := range docs {
+		action := map[string]interface{}{"index": map[string]interface{}{"_index": es.index(index), "_id": doc["id"]}}
+		if err := encoder.Encode(action); err != nil {
+			return err
+		}
+		if err := encoder.Encode(doc); err != nil {
+			return err
+		}
+	}
+	return es.bulk(ctx, body.Bytes())
+}
+
+// Delete implements Driver
+func (es *Elasticsearch) Delete(ctx context.Context, index string, ids []string) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, id := range ids {
+		action := map[string]interface{}{"delete": map[string]interface{}{"_index": es.index(index), "_id": id}}
+		if err := encoder.Encode(action); err != nil {
+			return err
+		}
+	}
+	return es.bulk(ctx, body.Bytes())
+}
+
+// bulk sends a _bulk request, which answers 200 even when items failed
+func (es *Elasticsearch) bulk(ctx context.Context, body []byte) error {
+	var resp struct {
+		Errors bool                        `json:"errors"`
+		Items  []map[string]bulkItemResult `json:"items"`
+	}
+	if err := es.api.do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", body, &resp); err != nil {
+		return err
+	}
+	if !resp.Errors {
+		return nil
+	}
+	for _, item := range resp.Items {
+		for _, result := range item {
+			if result.Error != nil {
+				return &APIError{Service: "elasticsearch", Status: http.StatusOK, Body: result.Error.Type + ": " + result.Error.Reason}
+			}
+		}
+	}
+	return nil
+}
+
+type bulkItemResult struct {
+	Error *bulkError `json:"error"`
+}
+
+type bulkError struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+// Search implements Driver
+func (es *Elasticsearch) Search(ctx context.Context, index string, q Query) (*Result, error) {
+	name := es.index(index)
+
+	var must interface{} = map[string]interface{}{"match_all": map[string]interface{}{}}
+	if q.Text != "" {
+		must = map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":     q.Text,
+				"fields":    es.textFields(name),
+				"fuzziness": "AUTO",
+			},
+		}
+	}
+
+	fields := make([]string, 0, len(q.Filters))
+	for field := range q.Filters {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	filters := make([]interface{}, len(fields))
+	for i, field := range fields {
+		filters[i] = map[string]interface{}{"term": map[string]interface{}{field: q.Filters[field]}}
+	}
+
+	body := map[string]interface{}{
+		"from":             q.Offset,
+		"size":             q.limit(),
+		"track_total_hits": true,
+		"query":            map[string]interface{}{"bool": map[string]interface{}{"must": must, "filter": filters}},
+	}
+	if len(q.Sort) > 0 {
+		sorts := make([]interface{}, len(q.Sort))
+		for i, item := range q.Sort {
+			field, desc := sortField(item)
+			order := "asc"
+			if desc {
+				order = "desc"
+			}
+			sorts[i] = map[string]interface{}{field: map[string]string{"order": order}}
+		}
+		body["sort"] = sorts
+	}
+
+	var resp struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				ID     string   `json:"_id"`
+				Source Document `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := es.api.do(ctx, http.MethodPost, "/"+url.PathEscape(name)+"/_search", "application/json", body, &resp); err != nil {
+		return nil, err
+	}
+
+	result := &Result{Hits: make([]Hit, len(resp.Hits.Hits)), Total: resp.Hits.Total.Value}
+	for i, hit := range resp.Hits.Hits {
+		result.Hits[i] = Hit{ID: hit.ID, Document: hit.Source}
+	}
+	return result, nil
+}
+
+// DropIndex implements Driver
+func (es *Elasticsearch) DropIndex(ctx context.Context, index string) error {
+	err := es.api.do(ctx, http.MethodDelete, "/"+url.PathEscape(es.index(index)), "", nil, nil)
+	if hasStatus(err, http.StatusNotFound) {
+		return nil
+	}
+	return err
+}
+
+// textFields returns the text subfields the query matches, the first
+// searchable attribute boosted most
+func (es *Elasticsearch) textFields(index string) []string {
+	es.mu.RLock()
+	searchable := es.searchable[index]
+	es.mu.RUnlock()
+
+	if len(searchable) == 0 {
+		return []string{"*.text"}
+	}
+	fields := make([]string, len(searchable))
+	for i, attribute := range searchable {
+		fields[i] = attribute + ".text^" + strconv.Itoa(len(searchable)-i)
+	}
+	return fields
+}
+
+// index returns the index name Elasticsearch accepts
+func (es *Elasticsearch) index(name string) string {
+	return strings.ToLower(name)
+}
@@ -0,0 +1,357 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"flex-service/pkg/logger"
+	"flex-service/pkg/model"
+	"flex-service/pkg/queue"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Config configures an Engine
+type Config struct {
+	// IndexPrefix is prepended to index names, so environments can share a server
+	IndexPrefix string
+	// BatchSize is how many records Reindex loads and sends at once (default 500)
+	BatchSize int
+	// QueueDelay holds queued sync jobs back so the write has committed before
+	// the worker reads the record (default 2s)
+	QueueDelay time.Duration
+}
+
+// registration is how the engine reads and indexes one entity type
+type registration struct {
+	index    string
+	settings Settings
+	// key returns the key of a record or a pointer to one, false when it is
+	// unset
+	key func(ctx context.Context, record interface{}) (string, bool)
+	// parse converts a document ID to a value of the key column
+	parse func(id string) (interface{}, error)
+	// load returns the record with the key, nil when there is none
+	load func(ctx context.Context, db *gorm.DB, id string) (Searchable, error)
+	// each calls fn with every record, size at a time
+	each func(ctx context.Context, db *gorm.DB, size int, fn func([]Searchable) error) error
+}
+
+// Engine indexes registered entities and searches their indexes
+type Engine struct {
+	driver     Driver
+	db         *gorm.DB
+	config     Config
+	dispatcher *queue.JobDispatcher
+
+	mu            sync.RWMutex
+	registrations map[string]*registration
+	// ready holds the indexes created with their settings by this process
+	ready sync.Map
+}
+
+// defaultEngine is used by Default, e.g. in generated repositories
+var defaultEngine atomic.Pointer[Engine]
+
+// SetDefault sets the engine returned by Default
+func SetDefault(e *Engine) {
+	defaultEngine.Store(e)
+}
+
+// Default returns the engine set with SetDefault, nil when search is disabled
+func Default() *Engine {
+	return defaultEngine.Load()
+}
+
+// NewEngine creates an engine reading records from db. Without WithQueue,
+// indexes are updated in the writing request.
+func NewEngine(driver Driver, db *gorm.DB, cfg Config) *Engine {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.QueueDelay <= 0 {
+		cfg.QueueDelay = 2 * time.Second
+	}
+	return &Engine{
+		driver:        driver,
+		db:            db,
+		config:        cfg,
+		registrations: make(map[string]*registration),
+	}
+}
+
+// WithQueue updates indexes from queue workers instead. Register JobHandler
+// for queue.JobTypeSearchSync on the worker.
+func (e *Engine) WithQueue(dispatcher *queue.JobDispatcher) *Engine {
+	e.dispatcher = dispatcher
+	return e
+}
+
+// Register makes entity T searchable: its index is updated whenever a T is
+// created, updated or deleted through a DB with observers installed, and
+// rebuilt by Reindex. Index failures are logged and never fail the write.
+//
+//	search.Register[entity.Product](container.Search, container.Observers, entity.ProductSearchSettings)
+func Register[T Searchable](e *Engine, observers *model.Observers, settings Settings) error {
+	var zero T
+	entitySchema, err := schema.Parse(&zero, &sync.Map{}, e.db.NamingStrategy)
+	if err != nil {
+		return fmt.Errorf("failed to register %T for search: %w", zero, err)
+	}
+	keyField := entitySchema.PrioritizedPrimaryField
+	if settings.Key != "" {
+		keyField = entitySchema.LookUpField(settings.Key)
+	}
+	if keyField == nil {
+		return fmt.Errorf("failed to register %T for search: it has no key column %q", zero, settings.Key)
+	}
+
+	reg := &registration{
+		index:    zero.SearchIndex(),
+		settings: settings,
+		key: func(ctx context.Context, record interface{}) (string, bool) {
+			value, isZero := keyField.ValueOf(ctx, reflect.Indirect(reflect.ValueOf(record)))
+			if isZero {
+				return "", false
+			}
+			return fmt.Sprint(value), true
+		},
+		parse: func(id string) (interface{}, error) {
+			return parseKey(keyField, id)
+		},
+		load: func(ctx context.Context, db *gorm.DB, id string) (Searchable, error) {
+			key, err := parseKey(keyField, id)
+			if err != nil {
+				return nil, err
+			}
+			// Not WithContext: on an observer's session it would copy the
+			// statement of the write
+			var records []T
+			if err := db.Session(&gorm.Session{NewDB: true, Context: ctx}).Where(keyField.DBName+" = ?", key).Limit(1).Find(&records).Error; err != nil {
+				return nil, err
+			}
+			if len(records) == 0 {
+				return nil, nil
+			}
+			return records[0], nil
+		},
+		each: func(ctx context.Context, db *gorm.DB, size int, fn func([]Searchable) error) error {
+			var batch []T
+			return db.WithContext(ctx).FindInBatches(&batch, size, func(tx *gorm.DB, _ int) error {
+				records := make([]Searchable, len(batch))
+				for i, record := range batch {
+					records[i] = record
+				}
+				return fn(records)
+			}).Error
+		},
+	}
+
+	e.mu.Lock()
+	if _, exists := e.registrations[reg.index]; exists {
+		e.mu.Unlock()
+		return fmt.Errorf("search index %s is already registered", reg.index)
+	}
+	e.registrations[reg.index] = reg
+	e.mu.Unlock()
+
+	observe := func(tx *gorm.DB, record *T) error {
+		e.changed(tx, reg, record)
+		return nil
+	}
+	model.Observe(observers, model.Created, observe)
+	model.Observe(observers, model.Updated, observe)
+	model.Observe(observers, model.Deleted, observe)
+	return nil
+}
+
+// Indexes returns the registered index names, without the prefix
+func (e *Engine) Indexes() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	indexes := make([]string, 0, len(e.registrations))
+	for index := range e.registrations {
+		indexes = append(indexes, index)
+	}
+	sort.Strings(indexes)
+	return indexes
+}
+
+// Search queries a registered index
+func (e *Engine) Search(ctx context.Context, index string, q Query) (*Result, error) {
+	reg, err := e.registration(index)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.ensure(ctx, reg); err != nil {
+		return nil, err
+	}
+	q.Limit = q.limit()
+	result, err := e.driver.Search(ctx, e.name(index), q)
+	if err != nil {
+		return nil, err
+	}
+
+	// Documents left from an earlier key type match no row
+	result.keys = make([]interface{}, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		if key, err := reg.parse(hit.ID); err == nil {
+			result.keys = append(result.keys, key)
+		}
+	}
+	return result, nil
+}
+
+// Sync indexes the record of index with the key id as db has it, or
+// removes it from the index when it is gone or not to be searched
+func (e *Engine) Sync(ctx context.Context, db *gorm.DB, index, id string) error {
+	reg, err := e.registration(index)
+	if err != nil {
+		return err
+	}
+	record, err := reg.load(ctx, db, id)
+	if err != nil {
+		return fmt.Errorf("failed to load %s %s: %w", index, id, err)
+	}
+	if err := e.ensure(ctx, reg); err != nil {
+		return err
+	}
+
+	if record == nil || !shouldSearch(record) {
+		return e.driver.Delete(ctx, e.name(index), []string{id})
+	}
+	return e.driver.Upsert(ctx, e.name(index), []Document{document(id, record)})
+}
+
+// Reindex rebuilds an index from the database and returns how many documents
+// it holds. Searches find fewer documents until it completes.
+func (e *Engine) Reindex(ctx context.Context, index string) (int, error) {
+	reg, err := e.registration(index)
+	if err != nil {
+		return 0, err
+	}
+
+	name := e.name(index)
+	if err := e.driver.DropIndex(ctx, name); err != nil {
+		return 0, err
+	}
+	e.ready.Delete(index)
+	if err := e.ensure(ctx, reg); err != nil {
+		return 0, err
+	}
+
+	indexed := 0
+	err = reg.each(ctx, e.db, e.config.BatchSize, func(records []Searchable) error {
+		docs := make([]Document, 0, len(records))
+		for _, record := range records {
+			id, ok := reg.key(ctx, record)
+			if !ok || !shouldSearch(record) {
+				continue
+			}
+			docs = append(docs, document(id, record))
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+		if err := e.driver.Upsert(ctx, name, docs); err != nil {
+			return err
+		}
+		indexed += len(docs)
+		return nil
+	})
+	return indexed, err
+}
+
+// changed updates the index after a write, from a queue job when there is a
+// queue and otherwise in the write's transaction
+func (e *Engine) changed(tx *gorm.DB, reg *registration, record interface{}) {
+	ctx := tx.Statement.Context
+	id, ok := reg.key(ctx, record)
+	if !ok {
+		// Writes by condition carry no key; Reindex picks them up
+		logger.Debug("Search index not updated for a write without a key", zap.String("index", reg.index))
+		return
+	}
+
+	if e.dispatcher != nil {
+		payload := map[string]interface{}{"index": reg.index, "id": id}
+		if err := e.dispatcher.DispatchDelayed(queue.JobTypeSearchSync, payload, e.config.QueueDelay); err != nil {
+			logger.Warn("Failed to queue search index update",
+				zap.String("index", reg.index),
+				zap.String("id", id),
+				zap.Error(err))
+		}
+		return
+	}
+
+	if err := e.Sync(ctx, tx, reg.index, id); err != nil {
+		logger.Warn("Failed to update search index",
+			zap.String("index", reg.index),
+			zap.String("id", id),
+			zap.Error(err))
+	}
+}
+
+// ensure creates the index with its settings once per process
+func (e *Engine) ensure(ctx context.Context, reg *registration) error {
+	if _, ok := e.ready.Load(reg.index); ok {
+		return nil
+	}
+	if err := e.driver.EnsureIndex(ctx, e.name(reg.index), reg.settings); err != nil {
+		return err
+	}
+	e.ready.Store(reg.index, struct{}{})
+	return nil
+}
+
+func (e *Engine) registration(index string) (*registration, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	reg, ok := e.registrations[index]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownIndex, index)
+	}
+	return reg, nil
+}
+
+// name returns the index name on the server
+func (e *Engine) name(index string) string {
+	return e.config.IndexPrefix + index
+}
+
+// document returns the record's document with its ID
+func document(id string, record Searchable) Document {
+	doc := Document{}
+	for field, value := range record.SearchDocument() {
+		doc[field] = value
+	}
+	doc["id"] = id
+	return doc
+}
+
+// parseKey converts a document ID to the type of an integer key column, so
+// it compares with the column on every database
+func parseKey(field *schema.Field, id string) (interface{}, error) {
+	switch field.IndirectFieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(id, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(id, 10, 64)
+	}
+	return id, nil
+}
+
+func shouldSearch(record Searchable) bool {
+	if conditional, ok := record.(Conditional); ok {
+		return conditional.ShouldSearch()
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"flex-service/pkg/httpclient"
+)
+
+// maxErrorBody is how much of a failed response is kept in the error
+const maxErrorBody = 1024
+
+// APIError is a response of the search server with an unexpected status
+type APIError struct {
+	Service string
+	Status  int
+	Body    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s returned %d: %s", e.Service, e.Status, e.Body)
+}
+
+// hasStatus reports whether err is an APIError with the status
+func hasStatus(err error, status int) bool {
+	apiErr, ok := err.(*APIError)
+	return ok && apiErr.Status == status
+}
+
+// api calls a search server's JSON API
+type api struct {
+	client        *http.Client
+	service       string
+	baseURL       string
+	authorization string
+}
+
+func newAPI(service, baseURL, authorization string, timeout time.Duration) api {
+	return api{
+		client: httpclient.New(httpclient.Config{
+			Timeout: timeout,
+			Name:    service,
+			Retry:   httpclient.DefaultRetryPolicy(),
+		}),
+		service:       service,
+		baseURL:       strings.TrimSuffix(baseURL, "/"),
+		authorization: authorization,
+	}
+}
+
+// do sends body, JSON-encoded unless it is already []byte, and decodes a
+// 2xx response into out when given
+func (a api) do(ctx context.Context, method, path, contentType string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		reader = bytes.NewReader(b)
+	default:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if reader != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if a.authorization != "" {
+		req.Header.Set("Authorization", a.authorization)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return &APIError{Service: a.service, Status: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,34 @@
+package search
+
+import (
+	"context"
+	"fmt"
+
+	"flex-service/pkg/queue"
+)
+
+// JobHandler updates the documents of records written while the engine had a
+// queue:
+//
+//	worker.RegisterHandler(queue.JobTypeSearchSync, search.JobHandler(container.Search))
+//
+// The record is read when the job runs, so a job queued before a later write
+// indexes the latest version.
+func JobHandler(e *Engine) queue.Handler {
+	return queue.HandlerFunc(func(ctx context.Context, job *queue.Job) *queue.JobResult {
+		index, _ := job.Payload["index"].(string)
+		id, _ := job.Payload["id"].(string)
+		if index == "" || id == "" {
+			return &queue.JobResult{Success: false, Error: fmt.Sprintf("search job %s has no index or id", job.ID)}
+		}
+
+		if err := e.Sync(ctx, e.db, index, id); err != nil {
+			return &queue.JobResult{Success: false, Error: err.Error()}
+		}
+
+		return &queue.JobResult{
+			Success: true,
+			Data:    map[string]interface{}{"index": index, "id": id},
+		}
+	})
+}
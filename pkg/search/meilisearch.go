@@ -0,0 +1,138 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Meilisearch is the Driver of a Meilisearch server. Its writes are tasks
+// the server applies in order, shortly after they are accepted.
+type Meilisearch struct {
+	api api
+}
+
+// NewMeilisearch creates a driver for the server at baseURL, e.g.
+// http://localhost:7700, authenticating with apiKey when set
+func NewMeilisearch(baseURL, apiKey string, timeout time.Duration) *Meilisearch {
+	authorization := ""
+	if apiKey != "" {
+		authorization = "Bearer " + apiKey
+	}
+	return &Meilisearch{api: newAPI("meilisearch", baseURL, authorization, timeout)}
+}
+
+// EnsureIndex implements Driver. Creating an index that exists fails in its
+// task, not here, so it is always requested.
+func (m *Meilisearch) EnsureIndex(ctx context.Context, index string, settings Settings) error {
+	create := map[string]string{"uid": index, "primaryKey": "id"}
+	if err := m.api.do(ctx, http.MethodPost, "/indexes", "application/json", create, nil); err != nil {
+		return err
+	}
+
+	searchable := settings.Searchable
+	if len(searchable) == 0 {
+		searchable = []string{"*"}
+	}
+	update := map[string][]string{
+		"searchableAttributes": searchable,
+		"filterableAttributes": nonNil(settings.Filterable),
+		"sortableAttributes":   nonNil(settings.Sortable),
+	}
+	return m.api.do(ctx, http.MethodPatch, "/indexes/"+url.PathEscape(index)+"/settings", "application/json", update, nil)
+}
+
+// Upsert implements Driver
+func (m *Meilisearch) Upsert(ctx context.Context, index string, docs []Document) error {
+	return m.api.do(ctx, http.MethodPost, "/indexes/"+url.PathEscape(index)+"/documents?primaryKey=id", "application/json", docs, nil)
+}
+
+// Delete implements Driver
+func (m *Meilisearch) Delete(ctx context.Context, index string, ids []string) error {
+	return m.api.do(ctx, http.MethodPost, "/indexes/"+url.PathEscape(index)+"/documents/delete-batch", "application/json", ids, nil)
+}
+
+// Search implements Driver
+func (m *Meilisearch) Search(ctx context.Context, index string, q Query) (*Result, error) {
+	body := map[string]interface{}{
+		"q":      q.Text,
+		"offset": q.Offset,
+		"limit":  q.limit(),
+	}
+	if filter := meiliFilter(q.Filters); filter != "" {
+		body["filter"] = filter
+	}
+	if len(q.Sort) > 0 {
+		sorts := make([]string, len(q.Sort))
+		for i, item := range q.Sort {
+			field, desc := sortField(item)
+			sorts[i] = field + ":asc"
+			if desc {
+				sorts[i] = field + ":desc"
+			}
+		}
+		body["sort"] = sorts
+	}
+
+	var resp struct {
+		Hits               []Document `json:"hits"`
+		EstimatedTotalHits int64      `json:"estimatedTotalHits"`
+	}
+	if err := m.api.do(ctx, http.MethodPost, "/indexes/"+url.PathEscape(index)+"/search", "application/json", body, &resp); err != nil {
+		return nil, err
+	}
+
+	result := &Result{Hits: make([]Hit, len(resp.Hits)), Total: resp.EstimatedTotalHits}
+	for i, doc := range resp.Hits {
+		result.Hits[i] = Hit{ID: fmt.Sprint(doc["id"]), Document: doc}
+	}
+	return result, nil
+}
+
+// DropIndex implements Driver
+func (m *Meilisearch) DropIndex(ctx context.Context, index string) error {
+	err := m.api.do(ctx, http.MethodDelete, "/indexes/"+url.PathEscape(index), "", nil, nil)
+	if hasStatus(err, http.StatusNotFound) {
+		return nil
+	}
+	return err
+}
+
+// meiliFilter returns filters as a Meilisearch filter expression
+func meiliFilter(filters map[string]interface{}) string {
+	fields := make([]string, 0, len(filters))
+	for field := range filters {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	conditions := make([]string, len(fields))
+	for i, field := range fields {
+		conditions[i] = field + " = " + meiliValue(filters[field])
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// meiliValue quotes strings and leaves numbers and booleans as they are
+func meiliValue(value interface{}) string {
+	switch v := value.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(fmt.Sprint(value))
+	return `"` + escaped + `"`
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,191 @@
+// Package search keeps full-text indexes of entities in Meilisearch or
+// Elasticsearch and queries them. Entities opt in by implementing Searchable;
+// Register keeps their index in sync through model observers, directly or
+// through queue jobs.
+package search
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Drivers
+const (
+	DriverMeilisearch   = "meilisearch"
+	DriverElasticsearch = "elasticsearch"
+)
+
+// Defaults
+const (
+	DefaultLimit = 20
+	// MaxHits is the most hits a query returns, e.g. the candidates a list
+	// endpoint filters and pages in SQL
+	MaxHits = 1000
+)
+
+// ErrUnknownIndex is returned for indexes no entity was registered for
+var ErrUnknownIndex = errors.New("unknown search index")
+
+// Document is what an index holds of one record. Its "id" is set to the
+// record's key, see Settings.Key.
+type Document map[string]interface{}
+
+// Searchable is an entity kept in a search index:
+//
+//	func (Product) SearchIndex() string { return "products" }
+//
+//	func (p Product) SearchDocument() search.Document {
+//		return search.Document{"name": p.Name, "description": p.Description, "price": p.Price}
+//	}
+type Searchable interface {
+	// SearchIndex names the index, without Config.IndexPrefix
+	SearchIndex() string
+	// SearchDocument returns the fields to index
+	SearchDocument() Document
+}
+
+// Conditional is a Searchable that is only indexed while ShouldSearch is true,
+// e.g. published posts
+type Conditional interface {
+	ShouldSearch() bool
+}
+
+// Settings are the attributes of an index's documents used for matching,
+// filtering and sorting
+type Settings struct {
+	// Key is the column whose value becomes the document "id", e.g. "uuid"
+	// for entities addressed by UUID. Empty means the primary key.
+	Key string
+	// Searchable attributes are matched against the text, in order of
+	// importance. Empty means every attribute.
+	Searchable []string
+	Filterable []string
+	Sortable   []string
+}
+
+// Query is a full-text query
+type Query struct {
+	Text string
+	// Filters keep documents whose attribute equals the value; attributes
+	// must be Filterable
+	Filters map[string]interface{}
+	// Sort lists Sortable attributes, "-" first for descending. Empty sorts
+	// by relevance.
+	Sort   []string
+	Offset int
+	Limit  int // DefaultLimit when 0, at most MaxHits
+}
+
+// Hit is a matching document
+type Hit struct {
+	ID       string   `json:"id"`
+	Document Document `json:"document"`
+}
+
+// Result holds the hits of a query in order of relevance, or of Sort
+type Result struct {
+	Hits  []Hit `json:"hits"`
+	Total int64 `json:"total"` // estimated by Meilisearch beyond MaxHits
+
+	// keys are the hit IDs as values of the key column, set by Engine.Search
+	keys []interface{}
+}
+
+// Driver is a search server. The engine calls EnsureIndex before it first
+// writes or searches an index.
+type Driver interface {
+	// EnsureIndex creates the index when missing and applies settings
+	EnsureIndex(ctx context.Context, index string, settings Settings) error
+	// Upsert adds documents or replaces them by their "id"
+	Upsert(ctx context.Context, index string, docs []Document) error
+	// Delete removes documents; unknown IDs are ignored
+	Delete(ctx context.Context, index string, ids []string) error
+	Search(ctx context.Context, index string, q Query) (*Result, error)
+	// DropIndex deletes the index and its documents; a missing index is no error
+	DropIndex(ctx context.Context, index string) error
+}
+
+// IDs returns the IDs of the hits in order
+func (r *Result) IDs() []string {
+	ids := make([]string, len(r.Hits))
+	for i, hit := range r.Hits {
+		ids[i] = hit.ID
+	}
+	return ids
+}
+
+// Filter restricts a query to the hits, e.g. to count them. column is the
+// key column of Settings, which is interpolated into SQL.
+func (r *Result) Filter(column string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		keys := r.values()
+		if len(keys) == 0 {
+			return db.Where("1 = 0")
+		}
+		return db.Where(column+" IN ?", keys)
+	}
+}
+
+// Scope restricts a query to the hits like Filter and orders them by rank,
+// unless sorts added before it already order the query.
+//
+//	db.Scopes(result.Scope("id")).Find(&products)
+func (r *Result) Scope(column string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		keys := r.values()
+		if len(keys) == 0 {
+			return db.Where("1 = 0")
+		}
+		// An ORDER BY expression would replace the columns already sorted by
+		if _, sorted := db.Statement.Clauses["ORDER BY"]; sorted {
+			return db.Where(column+" IN ?", keys)
+		}
+
+		var rank strings.Builder
+		rank.WriteString("CASE " + column)
+		for i := range keys {
+			rank.WriteString(" WHEN ? THEN " + strconv.Itoa(i))
+		}
+		rank.WriteString(" END")
+
+		return db.Where(column+" IN ?", keys).
+			Order(clause.OrderBy{Expression: clause.Expr{SQL: rank.String(), Vars: keys, WithoutParentheses: true}})
+	}
+}
+
+// values returns the keys of the hits, or their IDs for results not from
+// Engine.Search
+func (r *Result) values() []interface{} {
+	if r.keys != nil {
+		return r.keys
+	}
+	keys := make([]interface{}, len(r.Hits))
+	for i, hit := range r.Hits {
+		keys[i] = hit.ID
+	}
+	return keys
+}
+
+// limit returns the query's limit within 1 and MaxHits
+func (q Query) limit() int {
+	switch {
+	case q.Limit <= 0:
+		return DefaultLimit
+	case q.Limit > MaxHits:
+		return MaxHits
+	}
+	return q.Limit
+}
+
+// sortField splits "-price" into "price" and descending
+func sortField(sort string) (string, bool) {
+	if strings.HasPrefix(sort, "-") {
+		return sort[1:], true
+	}
+	return sort, false
+}
@@ -0,0 +1,164 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSConfig configures the AWS Secrets Manager provider
+type AWSConfig struct {
+	Region       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string // for temporary credentials, optional
+	Endpoint     string // defaults to https://secretsmanager.<region>.amazonaws.com
+	Timeout      time.Duration
+}
+
+const (
+	awsAlgorithm  = "AWS4-HMAC-SHA256"
+	awsTimeFormat = "20060102T150405Z"
+	awsDateFormat = "20060102"
+	awsService    = "secretsmanager"
+)
+
+// AWS reads secrets from AWS Secrets Manager with signed (SigV4) requests:
+// secret://aws/prod/db#password reads key password of the JSON secret
+// prod/db, and secret://aws/prod/api-key the whole secret string
+type AWS struct {
+	config AWSConfig
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewAWS creates the AWS Secrets Manager provider
+func NewAWS(cfg AWSConfig) (*AWS, error) {
+	if cfg.Region == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("aws region, access key and secret key are required")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://" + awsService + "." + cfg.Region + ".amazonaws.com"
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &AWS{config: cfg, client: &http.Client{Timeout: cfg.Timeout}, now: time.Now}, nil
+}
+
+// Get implements Provider
+func (a *AWS) Get(ctx context.Context, path, field string) (string, error) {
+	payload, _ := json.Marshal(map[string]string{"SecretId": path})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(a.config.Endpoint, "/")+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	if a.config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", a.config.SessionToken)
+	}
+	a.sign(req, payload)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("secrets manager request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(body, &failure)
+		if strings.HasSuffix(failure.Type, "ResourceNotFoundException") {
+			return "", fmt.Errorf("%w: aws %s", ErrNotFound, path)
+		}
+		return "", fmt.Errorf("secrets manager returned %d: %s %s", resp.StatusCode, failure.Type, failure.Message)
+	}
+
+	var secret struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", fmt.Errorf("invalid secrets manager response: %w", err)
+	}
+	if field == "" {
+		return secret.SecretString, nil
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(secret.SecretString), &values); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object, drop #%s: %w", path, field, err)
+	}
+	return selectField(values, field)
+}
+
+// sign adds SigV4 headers covering the host, the x-amz-* headers and the payload
+func (a *AWS) sign(req *http.Request, payload []byte) {
+	now := a.now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format(awsTimeFormat))
+
+	payloadHash := sha256.Sum256(payload)
+	names := []string{"content-type", "host"}
+	headers := map[string]string{
+		"content-type": req.Header.Get("Content-Type"),
+		"host":         req.URL.Host,
+	}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") {
+			names = append(names, lower)
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		"/",
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonical))
+
+	scope := now.Format(awsDateFormat) + "/" + a.config.Region + "/" + awsService + "/aws4_request"
+	stringToSign := strings.Join([]string{awsAlgorithm, now.Format(awsTimeFormat), scope, hex.EncodeToString(canonicalHash[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+a.config.SecretKey), now.Format(awsDateFormat))
+	key = hmacSHA256(key, a.config.Region)
+	key = hmacSHA256(key, awsService)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		awsAlgorithm, a.config.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
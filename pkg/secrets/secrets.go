@@ -0,0 +1,239 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scheme prefixes values that are references to a secret, e.g.
+// secret://vault/app/db#password or secret://aws/prod/db#password
+const Scheme = "secret://"
+
+// Secret errors
+var (
+	ErrInvalidRef      = errors.New("invalid secret reference")
+	ErrUnknownProvider = errors.New("unknown secret provider")
+	ErrNotFound        = errors.New("secret not found")
+)
+
+// Provider reads secrets from a backend
+type Provider interface {
+	// Get returns the secret at path; field selects one key of a secret
+	// holding several, and may be empty for single-value secrets
+	Get(ctx context.Context, path, field string) (string, error)
+}
+
+// Ref is a parsed secret:// reference
+type Ref struct {
+	Provider string
+	Path     string
+	Field    string
+}
+
+// IsRef reports whether value is a secret:// reference
+func IsRef(value string) bool {
+	return strings.HasPrefix(value, Scheme)
+}
+
+// ParseRef parses secret://<provider>/<path>[#field]
+func ParseRef(value string) (Ref, error) {
+	if !IsRef(value) {
+		return Ref{}, fmt.Errorf("%w: %q does not start with %s", ErrInvalidRef, value, Scheme)
+	}
+
+	rest, field, _ := strings.Cut(strings.TrimPrefix(value, Scheme), "#")
+	provider, path, _ := strings.Cut(rest, "/")
+	if provider == "" || path == "" {
+		return Ref{}, fmt.Errorf("%w: %q, expected %s<provider>/<path>[#field]", ErrInvalidRef, value, Scheme)
+	}
+	return Ref{Provider: provider, Path: path, Field: field}, nil
+}
+
+// String formats the reference back to secret://<provider>/<path>[#field]
+func (r Ref) String() string {
+	s := Scheme + r.Provider + "/" + r.Path
+	if r.Field != "" {
+		s += "#" + r.Field
+	}
+	return s
+}
+
+// ChangeFunc is called with the new value when a secret changes on Refresh
+type ChangeFunc func(ref, value string)
+
+// Resolver resolves secret:// references through named providers, caching
+// values for a TTL so reloading the configuration does not hit the backends
+type Resolver struct {
+	ttl       time.Duration
+	mu        sync.Mutex
+	providers map[string]Provider
+	cache     map[string]cached
+	callbacks map[string][]ChangeFunc
+}
+
+type cached struct {
+	value     string
+	fetchedAt time.Time
+}
+
+// NewResolver creates a resolver caching values for ttl; 0 caches them until
+// the next Refresh
+func NewResolver(ttl time.Duration) *Resolver {
+	return &Resolver{
+		ttl:       ttl,
+		providers: make(map[string]Provider),
+		cache:     make(map[string]cached),
+		callbacks: make(map[string][]ChangeFunc),
+	}
+}
+
+// Register makes a provider available as secret://<name>/...
+func (r *Resolver) Register(name string, provider Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[name] = provider
+}
+
+// Resolve returns value unchanged unless it is a secret:// reference, which
+// is read from the cache or its provider. When the provider fails, an expired
+// cached value is returned rather than none.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	if !IsRef(value) {
+		return value, nil
+	}
+
+	r.mu.Lock()
+	entry, ok := r.cache[value]
+	r.mu.Unlock()
+	if ok && (r.ttl == 0 || time.Since(entry.fetchedAt) < r.ttl) {
+		return entry.value, nil
+	}
+
+	secret, err := r.fetch(ctx, value)
+	if err != nil {
+		if ok {
+			return entry.value, nil
+		}
+		return "", err
+	}
+	r.store(value, secret)
+	return secret, nil
+}
+
+// OnChange calls fn when the secret behind ref changes on Refresh, e.g. to
+// reconnect with a rotated database password
+func (r *Resolver) OnChange(ref string, fn ChangeFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.callbacks[ref] = append(r.callbacks[ref], fn)
+}
+
+// Refs returns every reference resolved so far
+func (r *Resolver) Refs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	refs := make([]string, 0, len(r.cache))
+	for ref := range r.cache {
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// Refresh reads every resolved reference again and calls the OnChange
+// callbacks of those whose value changed. Failed reads keep the old value.
+func (r *Resolver) Refresh(ctx context.Context) error {
+	var errs []error
+	for _, ref := range r.Refs() {
+		secret, err := r.fetch(ctx, ref)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !r.store(ref, secret) {
+			continue
+		}
+
+		r.mu.Lock()
+		callbacks := append([]ChangeFunc{}, r.callbacks[ref]...)
+		r.mu.Unlock()
+		for _, fn := range callbacks {
+			fn(ref, secret)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Watch calls Refresh every interval until ctx is done, passing failures to
+// onError
+func (r *Resolver) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Refresh(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+func (r *Resolver) fetch(ctx context.Context, value string) (string, error) {
+	ref, err := ParseRef(value)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	provider, ok := r.providers[ref.Provider]
+	r.mu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("%w %q in %s", ErrUnknownProvider, ref.Provider, value)
+	}
+
+	secret, err := provider.Get(ctx, ref.Path, ref.Field)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", value, err)
+	}
+	return secret, nil
+}
+
+// store caches value and reports whether it replaced a different one
+func (r *Resolver) store(ref, value string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, existed := r.cache[ref]
+	r.cache[ref] = cached{value: value, fetchedAt: time.Now()}
+	return existed && old.value != value
+}
+
+// selectField returns field from a secret holding several values. Without a
+// field, a secret with a single value returns it.
+func selectField(values map[string]interface{}, field string) (string, error) {
+	if field == "" {
+		if len(values) != 1 {
+			return "", fmt.Errorf("secret has %d values, select one with #field", len(values))
+		}
+		for name := range values {
+			field = name
+		}
+	}
+
+	value, ok := values[field]
+	if !ok {
+		return "", fmt.Errorf("%w: no field %q", ErrNotFound, field)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(value), nil
+}
@@ -0,0 +1,78 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VaultConfig configures the Vault provider
+type VaultConfig struct {
+	Address   string // e.g. https://vault.internal:8200
+	Token     string
+	Mount     string // KV v2 mount, default "secret"
+	Namespace string // Vault Enterprise namespace, optional
+	Timeout   time.Duration
+}
+
+// Vault reads secrets from a KV version 2 engine:
+// secret://vault/app/db#password reads field password of <mount>/app/db
+type Vault struct {
+	config VaultConfig
+	client *http.Client
+}
+
+// NewVault creates the Vault provider
+func NewVault(cfg VaultConfig) (*Vault, error) {
+	if cfg.Address == "" || cfg.Token == "" {
+		return nil, fmt.Errorf("vault address and token are required")
+	}
+	if cfg.Mount == "" {
+		cfg.Mount = "secret"
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &Vault{config: cfg, client: &http.Client{Timeout: cfg.Timeout}}, nil
+}
+
+// Get implements Provider
+func (v *Vault) Get(ctx context.Context, path, field string) (string, error) {
+	url := strings.TrimSuffix(v.config.Address, "/") + "/v1/" + v.config.Mount + "/data/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.config.Token)
+	if v.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.config.Namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", fmt.Errorf("%w: vault %s", ErrNotFound, path)
+	case resp.StatusCode != http.StatusOK:
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("vault returned %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid vault response: %w", err)
+	}
+	return selectField(body.Data.Data, field)
+}
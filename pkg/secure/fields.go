@@ -0,0 +1,148 @@
+package secure
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"flex-service/config"
+)
+
+// fieldPrefix starts every encrypted field value: "enc:v<version>:<base64>"
+const fieldPrefix = "enc:v"
+
+// FieldCipher encrypts model fields with AES-256-GCM. Each value records the
+// version of the key that encrypted it, so values written with a previous key
+// stay readable until secure:rotate-key re-encrypts them with the current one.
+type FieldCipher struct {
+	version int
+	keys    map[int]cipher.AEAD
+}
+
+// NewFieldCipher creates the field cipher from ENCRYPTION_KEY, its version and
+// the previous keys still accepted for decryption
+func NewFieldCipher(cfg *config.SecureConfig) (*FieldCipher, error) {
+	if cfg.Key == "" {
+		return nil, ErrInvalidKey
+	}
+	if cfg.KeyVersion < 1 {
+		return nil, fmt.Errorf("invalid encryption key version %d", cfg.KeyVersion)
+	}
+
+	f := &FieldCipher{version: cfg.KeyVersion, keys: make(map[int]cipher.AEAD)}
+	for v, key := range cfg.PreviousKeys {
+		version, err := strconv.Atoi(strings.TrimPrefix(v, "v"))
+		if err != nil || version < 1 || key == "" {
+			return nil, fmt.Errorf("invalid previous encryption key %q", v)
+		}
+		if version == cfg.KeyVersion {
+			return nil, fmt.Errorf("previous encryption key %q has the current key version", v)
+		}
+		if f.keys[version], err = newFieldAEAD(key); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if f.keys[cfg.KeyVersion], err = newFieldAEAD(cfg.Key); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// newFieldAEAD derives a 256-bit field key from a configured key of any
+// length, separate from the key Encrypt uses
+func newFieldAEAD(key string) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte("flex-service field encryption"))
+
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// Version returns the version of the current key
+func (f *FieldCipher) Version() int {
+	return f.version
+}
+
+// Encrypt encrypts plaintext with the current key
+func (f *FieldCipher) Encrypt(plaintext []byte) (string, error) {
+	aead := f.keys[f.version]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+	return fieldPrefix + strconv.Itoa(f.version) + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value written by Encrypt with the current or a previous key
+func (f *FieldCipher) Decrypt(value string) ([]byte, error) {
+	version, payload, ok := parseField(value)
+	if !ok {
+		return nil, ErrInvalidCiphertext
+	}
+	aead, ok := f.keys[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key version %d", ErrDecryptionFailed, version)
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(payload)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+// NeedsRotation reports whether value isn't encrypted with the current key:
+// it was written with a previous key, or before the column was encrypted
+func (f *FieldCipher) NeedsRotation(value string) bool {
+	version, _, ok := parseField(value)
+	return !ok || version != f.version
+}
+
+// open returns the plaintext of a stored value. Values that aren't encrypted
+// are returned as they are, so columns can be encrypted before their existing
+// rows are rotated.
+func (f *FieldCipher) open(value string) ([]byte, error) {
+	if _, _, ok := parseField(value); !ok {
+		return []byte(value), nil
+	}
+	return f.Decrypt(value)
+}
+
+// IsEncrypted reports whether value was written by a FieldCipher
+func IsEncrypted(value string) bool {
+	_, _, ok := parseField(value)
+	return ok
+}
+
+func parseField(value string) (version int, payload string, ok bool) {
+	rest, found := strings.CutPrefix(value, fieldPrefix)
+	if !found {
+		return 0, "", false
+	}
+	v, payload, found := strings.Cut(rest, ":")
+	if !found {
+		return 0, "", false
+	}
+	version, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, "", false
+	}
+	return version, payload, true
+}
@@ -0,0 +1,101 @@
+package secure
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// DefaultRotateBatchSize is how many rows Rotate reads at a time
+const DefaultRotateBatchSize = 500
+
+// RotateOptions selects the encrypted columns Rotate re-encrypts
+type RotateOptions struct {
+	Table      string
+	PrimaryKey string // default "id"
+	Columns    []string
+	BatchSize  int // default DefaultRotateBatchSize
+}
+
+// Rotate re-encrypts with the current key every value of the columns that
+// isn't encrypted with it: values written with a previous key, and plaintext
+// from before the columns were encrypted. It walks the table in primary key
+// order, soft deleted rows included, updating each row on its own, so it can
+// be stopped and run again. It returns the number of rows updated.
+func (f *FieldCipher) Rotate(ctx context.Context, db *gorm.DB, opts RotateOptions) (int, error) {
+	if opts.Table == "" || len(opts.Columns) == 0 {
+		return 0, fmt.Errorf("rotate needs a table and its encrypted columns")
+	}
+	if opts.PrimaryKey == "" {
+		opts.PrimaryKey = "id"
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultRotateBatchSize
+	}
+
+	db = db.WithContext(ctx)
+	selected := append([]string{opts.PrimaryKey}, opts.Columns...)
+	var (
+		last    interface{}
+		updated int
+	)
+	for {
+		query := db.Table(opts.Table).Select(selected).Order(opts.PrimaryKey).Limit(opts.BatchSize)
+		if last != nil {
+			query = query.Where(opts.PrimaryKey+" > ?", last)
+		}
+		var rows []map[string]interface{}
+		if err := query.Find(&rows).Error; err != nil {
+			return updated, fmt.Errorf("failed to read %s: %w", opts.Table, err)
+		}
+
+		for _, row := range rows {
+			last = row[opts.PrimaryKey]
+			changes, err := f.rotateRow(row, opts.Columns)
+			if err != nil {
+				return updated, fmt.Errorf("failed to rotate %s %s=%v: %w", opts.Table, opts.PrimaryKey, last, err)
+			}
+			if len(changes) == 0 {
+				continue
+			}
+			if err := db.Table(opts.Table).Where(opts.PrimaryKey+" = ?", last).UpdateColumns(changes).Error; err != nil {
+				return updated, fmt.Errorf("failed to update %s %s=%v: %w", opts.Table, opts.PrimaryKey, last, err)
+			}
+			updated++
+		}
+		if len(rows) < opts.BatchSize {
+			return updated, nil
+		}
+	}
+}
+
+// rotateRow returns the re-encrypted values of the row's columns that need it
+func (f *FieldCipher) rotateRow(row map[string]interface{}, columns []string) (map[string]interface{}, error) {
+	changes := make(map[string]interface{})
+	for _, column := range columns {
+		var stored string
+		switch v := row[column].(type) {
+		case nil:
+			continue
+		case []byte:
+			stored = string(v)
+		case string:
+			stored = v
+		default:
+			return nil, fmt.Errorf("column %s holds %T, not text", column, v)
+		}
+		if !f.NeedsRotation(stored) {
+			continue
+		}
+
+		plaintext, err := f.open(stored)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", column, err)
+		}
+		if changes[column], err = f.Encrypt(plaintext); err != nil {
+			return nil, fmt.Errorf("column %s: %w", column, err)
+		}
+	}
+	return changes, nil
+}
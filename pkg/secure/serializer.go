@@ -0,0 +1,129 @@
+package secure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName is the GORM serializer of encrypted fields:
+//
+//	Phone *string `json:"phone" gorm:"type:text;serializer:encrypted"`
+const SerializerName = "encrypted"
+
+// ErrNoFieldCipher is returned for encrypted fields until RegisterSerializer
+// is called
+var ErrNoFieldCipher = errors.New("encrypted fields need secure.RegisterSerializer")
+
+// fieldCipher is the cipher of the encrypted serializer. GORM keeps the
+// serializer of an entity once it parsed it, so the serializer is registered
+// once and reads the cipher at each use.
+var fieldCipher atomic.Pointer[FieldCipher]
+
+func init() {
+	schema.RegisterSerializer(SerializerName, EncryptedSerializer{})
+}
+
+// EncryptedSerializer encrypts fields at rest with the registered FieldCipher.
+// Strings and byte slices are encrypted as they are, other types as JSON.
+type EncryptedSerializer struct{}
+
+// RegisterSerializer makes `serializer:encrypted` fields use cipher
+func RegisterSerializer(cipher *FieldCipher) {
+	fieldCipher.Store(cipher)
+}
+
+func currentFieldCipher() (*FieldCipher, error) {
+	cipher := fieldCipher.Load()
+	if cipher == nil {
+		return nil, ErrNoFieldCipher
+	}
+	return cipher, nil
+}
+
+// Scan implements schema.SerializerInterface
+func (s EncryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	if dbValue == nil {
+		field.ReflectValueOf(ctx, dst).Set(reflect.Zero(field.FieldType))
+		return nil
+	}
+
+	var stored string
+	switch v := dbValue.(type) {
+	case []byte:
+		stored = string(v)
+	case string:
+		stored = v
+	default:
+		return fmt.Errorf("failed to decrypt %s: unsupported value %T", field.Name, dbValue)
+	}
+	cipher, err := currentFieldCipher()
+	if err != nil {
+		return err
+	}
+	plaintext, err := cipher.open(stored)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s: %w", field.Name, err)
+	}
+
+	value := reflect.New(field.FieldType).Elem()
+	target := value
+	if target.Kind() == reflect.Ptr {
+		target.Set(reflect.New(target.Type().Elem()))
+		target = target.Elem()
+	}
+	switch {
+	case target.Kind() == reflect.String:
+		target.SetString(string(plaintext))
+	case target.Kind() == reflect.Slice && target.Type().Elem().Kind() == reflect.Uint8:
+		target.SetBytes(plaintext)
+	default:
+		if err := json.Unmarshal(plaintext, target.Addr().Interface()); err != nil {
+			return fmt.Errorf("failed to decode %s: %w", field.Name, err)
+		}
+	}
+	field.ReflectValueOf(ctx, dst).Set(value)
+	return nil
+}
+
+// Value implements schema.SerializerValuerInterface. Nil pointers, slices and
+// maps are stored as NULL.
+func (s EncryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value := reflect.ValueOf(fieldValue)
+	if !value.IsValid() {
+		return nil, nil
+	}
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+		if value.IsNil() {
+			return nil, nil
+		}
+	}
+	if value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+
+	var plaintext []byte
+	switch {
+	case value.Kind() == reflect.String:
+		plaintext = []byte(value.String())
+	case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8:
+		plaintext = value.Bytes()
+	default:
+		encoded, err := json.Marshal(value.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", field.Name, err)
+		}
+		plaintext = encoded
+	}
+	cipher, err := currentFieldCipher()
+	if err != nil {
+		return nil, err
+	}
+	return cipher.Encrypt(plaintext)
+}
@@ -0,0 +1,282 @@
+// Package serializer turns entities into response maps, so handlers decide
+// what leaves the service instead of whatever the json tags happen to expose.
+// Fields are named after their json tags; a visible tag restricts a field to
+// principals with one of the listed roles:
+//
+//	Email string `json:"email" visible:"admin,support"`
+//
+// and ?fields=id,name trims each record to the listed fields.
+package serializer
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+
+	"flex-service/pkg/requestctx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TagName is the struct tag listing the roles that may see a field
+const TagName = "visible"
+
+// field is how one struct field is serialized
+type field struct {
+	index     []int
+	name      string
+	omitEmpty bool
+	roles     []string
+	depth     int
+}
+
+// fieldsByType caches the fields of each struct type
+var fieldsByType sync.Map // reflect.Type -> []field
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Serialize returns v as it should be sent to the principal of ctx: structs
+// become maps without the fields its roles may not see, at any depth, and
+// slices become slices of those maps. fields, when given, keeps only those
+// top-level fields of each record; unknown names are ignored.
+//
+// Values that marshal themselves, such as time.Time, are kept as they are.
+func Serialize(ctx context.Context, v interface{}, fields ...string) interface{} {
+	s := &serializer{roles: requestctx.Roles(ctx)}
+	return s.value(reflect.ValueOf(v), fields)
+}
+
+// Fields returns the sparse fieldset of ?fields=id,name, or nil. Use the
+// parsed query's ResponseFields instead when the handler binds a query.Query.
+func Fields(c *gin.Context) []string {
+	var fields []string
+	for _, name := range strings.Split(c.Query("fields"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+type serializer struct {
+	roles []string
+}
+
+// value serializes v; only top-level records are trimmed to fields
+func (s *serializer) value(v reflect.Value, fields []string) interface{} {
+	// Exported fields of unexported embedded structs can't be read
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	if marshaler, ok := marshalerOf(v); ok {
+		return marshaler
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return s.value(v.Elem(), fields)
+	case reflect.Struct:
+		return s.object(v, fields)
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		fallthrough
+	case reflect.Array:
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = s.value(v.Index(i), fields)
+		}
+		return items
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		object := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			object[iter.Key().String()] = s.value(iter.Value(), nil)
+		}
+		return object
+	default:
+		return v.Interface()
+	}
+}
+
+// object serializes a struct into a map keyed by json names
+func (s *serializer) object(v reflect.Value, fields []string) map[string]interface{} {
+	object := make(map[string]interface{})
+	for _, f := range typeFields(v.Type()) {
+		if len(fields) > 0 && !contains(fields, f.name) {
+			continue
+		}
+		if !s.canSee(f) {
+			continue
+		}
+		value, ok := fieldValue(v, f.index)
+		if !ok || (f.omitEmpty && isEmpty(value)) {
+			continue
+		}
+		object[f.name] = s.value(value, nil)
+	}
+	return object
+}
+
+// canSee reports whether the principal has one of the roles of the field
+func (s *serializer) canSee(f field) bool {
+	if len(f.roles) == 0 {
+		return true
+	}
+	for _, role := range f.roles {
+		if contains(s.roles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeFields returns the fields of a struct type the way encoding/json names
+// them, with embedded structs flattened
+func typeFields(t reflect.Type) []field {
+	if cached, ok := fieldsByType.Load(t); ok {
+		return cached.([]field)
+	}
+
+	var fields []field
+	collectFields(t, nil, 0, &fields)
+
+	// Like encoding/json, the shallowest field wins a name
+	byName := make(map[string]int, len(fields))
+	unique := fields[:0]
+	for _, f := range fields {
+		if i, ok := byName[f.name]; ok {
+			if f.depth < unique[i].depth {
+				unique[i] = f
+			}
+			continue
+		}
+		byName[f.name] = len(unique)
+		unique = append(unique, f)
+	}
+
+	fieldsByType.Store(t, unique)
+	return unique
+}
+
+func collectFields(t reflect.Type, index []int, depth int, fields *[]field) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		if sf.Anonymous && name == "" {
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				collectFields(embedded, fieldIndex, depth+1, fields)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		f := field{index: fieldIndex, name: name, depth: depth}
+		for _, option := range strings.Split(options, ",") {
+			if option == "omitempty" {
+				f.omitEmpty = true
+			}
+		}
+		for _, role := range strings.Split(sf.Tag.Get(TagName), ",") {
+			if role = strings.TrimSpace(role); role != "" {
+				f.roles = append(f.roles, role)
+			}
+		}
+		*fields = append(*fields, f)
+	}
+}
+
+// fieldValue follows index through embedded pointers; false when one is nil
+func fieldValue(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, step := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(step)
+	}
+	return v, true
+}
+
+// marshalerOf returns v as encoding/json would marshal it when it has a
+// marshaler, keeping the address for pointer receivers
+func marshalerOf(v reflect.Value) (interface{}, bool) {
+	t := v.Type()
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil, false
+		}
+		return v.Interface(), true
+	}
+	if v.CanAddr() {
+		pt := reflect.PointerTo(t)
+		if pt.Implements(jsonMarshalerType) || pt.Implements(textMarshalerType) {
+			return v.Addr().Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// isEmpty matches encoding/json's omitempty
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
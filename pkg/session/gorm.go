@@ -0,0 +1,103 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Record is a session row of GormStore
+type Record struct {
+	ID        string    `gorm:"type:varchar(64);primaryKey"`
+	Data      string    `gorm:"type:text;not null"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for GORM
+func (Record) TableName() string {
+	return "tb_session"
+}
+
+// GormStore keeps sessions in the tb_session table, for deployments without
+// Redis. Call Purge periodically to delete expired rows.
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore creates a GORM-backed store
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+// Load reads an unexpired session
+func (g *GormStore) Load(ctx context.Context, id string) (*Session, error) {
+	var record Record
+	err := g.db.WithContext(ctx).Where("id = ? AND expires_at > ?", id, time.Now()).First(&record).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+
+	s := &Session{}
+	if err := json.Unmarshal([]byte(record.Data), s); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", err)
+	}
+	s.ID = id
+	if s.Values == nil {
+		s.Values = make(map[string]interface{})
+	}
+	return s, nil
+}
+
+// Save inserts or updates a session with ttl
+func (g *GormStore) Save(ctx context.Context, s *Session, ttl time.Duration) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	record := &Record{ID: s.ID, Data: string(data), ExpiresAt: time.Now().Add(ttl)}
+	err = g.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"data", "expires_at", "updated_at"}),
+	}).Create(record).Error
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a session
+func (g *GormStore) Delete(ctx context.Context, id string) error {
+	if err := g.db.WithContext(ctx).Delete(&Record{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// Count returns the number of unexpired sessions
+func (g *GormStore) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := g.db.WithContext(ctx).Model(&Record{}).Where("expires_at > ?", time.Now()).Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count sessions: %w", err)
+	}
+	return count, nil
+}
+
+// Purge deletes expired sessions and returns how many were removed
+func (g *GormStore) Purge(ctx context.Context) (int64, error) {
+	result := g.db.WithContext(ctx).Where("expires_at <= ?", time.Now()).Delete(&Record{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge sessions: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,200 @@
+package session
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"flex-service/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// contextKey is the gin context key of the current session
+const contextKey = "session"
+
+// Codec encrypts and authenticates the session ID stored in the cookie.
+// *secure.Secure (SecureConfig.Key) implements it.
+type Codec interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
+
+// Config configures the session cookie
+type Config struct {
+	CookieName string        // Defaults to "session"
+	Path       string        // Defaults to "/"
+	Domain     string        // Empty means the request host
+	Lifetime   time.Duration // Idle lifetime, extended on every request; defaults to 2h
+	Secure     bool          // HTTPS only
+	SameSite   http.SameSite // Defaults to Lax
+}
+
+// ParseSameSite converts "lax", "strict" or "none" to http.SameSite, defaulting to Lax
+func ParseSameSite(value string) http.SameSite {
+	switch strings.ToLower(value) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	}
+	return http.SameSiteLaxMode
+}
+
+// Manager loads and saves sessions for gin requests
+type Manager struct {
+	store  Store
+	codec  Codec
+	config Config
+}
+
+// NewManager creates a session manager
+func NewManager(store Store, codec Codec, config Config) *Manager {
+	if config.CookieName == "" {
+		config.CookieName = "session"
+	}
+	if config.Path == "" {
+		config.Path = "/"
+	}
+	if config.Lifetime <= 0 {
+		config.Lifetime = 2 * time.Hour
+	}
+	if config.SameSite == 0 {
+		config.SameSite = http.SameSiteLaxMode
+	}
+	return &Manager{store: store, codec: codec, config: config}
+}
+
+// Store returns the session store
+func (m *Manager) Store() Store {
+	return m.store
+}
+
+// Middleware loads the session named by the cookie, or starts a new one, and
+// saves it just before the response is written. A new session is only saved
+// (and its cookie set) once something is stored in it.
+func (m *Manager) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s := m.load(c)
+		c.Set(contextKey, s)
+
+		w := &sessionWriter{ResponseWriter: c.Writer}
+		w.commit = func() { m.commit(c, w.ResponseWriter, s) }
+		c.Writer = w
+
+		c.Next()
+		w.commitOnce()
+	}
+}
+
+// Current returns the request's session, or nil without Manager.Middleware
+func Current(c *gin.Context) *Session {
+	if value, exists := c.Get(contextKey); exists {
+		if s, ok := value.(*Session); ok {
+			return s
+		}
+	}
+	return nil
+}
+
+func (m *Manager) load(c *gin.Context) *Session {
+	cookie, err := c.Cookie(m.config.CookieName)
+	if err != nil || cookie == "" {
+		return New()
+	}
+	id, err := m.codec.Decrypt(cookie)
+	if err != nil {
+		return New()
+	}
+
+	s, err := m.store.Load(c.Request.Context(), id)
+	if err != nil {
+		if err != ErrNotFound {
+			logger.WithContext(c.Request.Context()).Warn("Failed to load session", zap.Error(err))
+		}
+		return New()
+	}
+	return s
+}
+
+func (m *Manager) commit(c *gin.Context, w http.ResponseWriter, s *Session) {
+	ctx := c.Request.Context()
+	log := logger.WithContext(ctx)
+
+	if s.previousID != "" {
+		if err := m.store.Delete(ctx, s.previousID); err != nil {
+			log.Warn("Failed to delete regenerated session", zap.Error(err))
+		}
+	}
+
+	if s.destroyed {
+		if !s.isNew {
+			if err := m.store.Delete(ctx, s.ID); err != nil {
+				log.Warn("Failed to delete session", zap.Error(err))
+			}
+		}
+		http.SetCookie(w, m.cookie("", -1))
+		return
+	}
+
+	if s.isNew && !s.modified {
+		return
+	}
+	if err := m.store.Save(ctx, s, m.config.Lifetime); err != nil {
+		log.Error("Failed to save session", zap.Error(err))
+		return
+	}
+	value, err := m.codec.Encrypt(s.ID)
+	if err != nil {
+		log.Error("Failed to encrypt session cookie", zap.Error(err))
+		return
+	}
+	http.SetCookie(w, m.cookie(value, int(m.config.Lifetime.Seconds())))
+}
+
+func (m *Manager) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     m.config.CookieName,
+		Value:    value,
+		Path:     m.config.Path,
+		Domain:   m.config.Domain,
+		MaxAge:   maxAge,
+		Secure:   m.config.Secure,
+		HttpOnly: true,
+		SameSite: m.config.SameSite,
+	}
+}
+
+// sessionWriter saves the session before the first byte of the response, the
+// last moment the cookie can still be set
+type sessionWriter struct {
+	gin.ResponseWriter
+	commit func()
+	once   sync.Once
+}
+
+func (w *sessionWriter) commitOnce() {
+	w.once.Do(w.commit)
+}
+
+func (w *sessionWriter) WriteHeaderNow() {
+	w.commitOnce()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *sessionWriter) Write(data []byte) (int, error) {
+	w.commitOnce()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *sessionWriter) WriteString(s string) (int, error) {
+	w.commitOnce()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *sessionWriter) Flush() {
+	w.commitOnce()
+	w.ResponseWriter.Flush()
+}
@@ -0,0 +1,90 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps sessions in process memory, for tests and single-instance
+// development. Sessions are lost on restart and not shared between instances.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]memoryEntry
+	sweptAt  time.Time
+}
+
+type memoryEntry struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates an in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]memoryEntry)}
+}
+
+// Load returns a copy of a session, so changes apply only once saved
+func (m *MemoryStore) Load(ctx context.Context, id string) (*Session, error) {
+	m.mu.Lock()
+	entry, ok := m.sessions[id]
+	m.mu.Unlock()
+	if !ok || !entry.expiresAt.After(time.Now()) {
+		return nil, ErrNotFound
+	}
+
+	s := &Session{}
+	if err := json.Unmarshal(entry.data, s); err != nil {
+		return nil, err
+	}
+	s.ID = id
+	if s.Values == nil {
+		s.Values = make(map[string]interface{})
+	}
+	return s, nil
+}
+
+// Save stores a session with ttl. Expired sessions are swept at most once a minute.
+func (m *MemoryStore) Save(ctx context.Context, s *Session, ttl time.Duration) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.sessions[s.ID] = memoryEntry{data: data, expiresAt: now.Add(ttl)}
+	if now.Sub(m.sweptAt) >= time.Minute {
+		m.sweep(now)
+	}
+	return nil
+}
+
+// Delete removes a session
+func (m *MemoryStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	delete(m.sessions, id)
+	m.mu.Unlock()
+	return nil
+}
+
+// Count sweeps expired sessions and returns the rest
+func (m *MemoryStore) Count(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(time.Now())
+	return int64(len(m.sessions)), nil
+}
+
+func (m *MemoryStore) sweep(now time.Time) {
+	for id, entry := range m.sessions {
+		if !entry.expiresAt.After(now) {
+			delete(m.sessions, id)
+		}
+	}
+	m.sweptAt = now
+}
@@ -0,0 +1,108 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+)
+
+// Session is the server-side state of one browser session. Values are stored
+// as JSON, so numbers read back as float64.
+type Session struct {
+	ID        string                   `json:"-"`
+	Values    map[string]interface{}   `json:"values"`
+	Flashes   map[string][]interface{} `json:"flashes,omitempty"`
+	CreatedAt time.Time                `json:"created_at"`
+
+	previousID string // Deleted on save after Regenerate
+	isNew      bool
+	modified   bool
+	destroyed  bool
+}
+
+// New creates an empty session with a random ID
+func New() *Session {
+	return &Session{
+		ID:        newID(),
+		Values:    make(map[string]interface{}),
+		CreatedAt: time.Now(),
+		isNew:     true,
+	}
+}
+
+// Get returns a value
+func (s *Session) Get(key string) (interface{}, bool) {
+	value, ok := s.Values[key]
+	return value, ok
+}
+
+// GetString returns a string value, or "" when missing or not a string
+func (s *Session) GetString(key string) string {
+	value, _ := s.Values[key].(string)
+	return value
+}
+
+// Set stores a value
+func (s *Session) Set(key string, value interface{}) {
+	s.Values[key] = value
+	s.modified = true
+}
+
+// Delete removes a value
+func (s *Session) Delete(key string) {
+	if _, ok := s.Values[key]; ok {
+		delete(s.Values, key)
+		s.modified = true
+	}
+}
+
+// Flash stores a value for the next request only, e.g. a "saved" notice shown
+// after a redirect
+func (s *Session) Flash(key string, value interface{}) {
+	if s.Flashes == nil {
+		s.Flashes = make(map[string][]interface{})
+	}
+	s.Flashes[key] = append(s.Flashes[key], value)
+	s.modified = true
+}
+
+// PullFlashes returns and removes the flash values of key
+func (s *Session) PullFlashes(key string) []interface{} {
+	values, ok := s.Flashes[key]
+	if !ok {
+		return nil
+	}
+	delete(s.Flashes, key)
+	s.modified = true
+	return values
+}
+
+// Regenerate moves the session to a new ID, keeping its values. Call it after
+// login or a privilege change so a session ID planted before can't be reused.
+func (s *Session) Regenerate() {
+	if s.previousID == "" && !s.isNew {
+		s.previousID = s.ID
+	}
+	s.ID = newID()
+	s.modified = true
+}
+
+// Destroy deletes the session and its cookie at the end of the request, e.g. on logout
+func (s *Session) Destroy() {
+	s.Values = make(map[string]interface{})
+	s.Flashes = nil
+	s.destroyed = true
+}
+
+// IsNew reports whether the session was created by this request
+func (s *Session) IsNew() bool {
+	return s.isNew
+}
+
+func newID() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic("session: failed to generate ID: " + err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,105 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrNotFound is returned by Store.Load for a missing or expired session
+var ErrNotFound = errors.New("session not found")
+
+// Store persists sessions by ID
+type Store interface {
+	// Load returns the session, or ErrNotFound
+	Load(ctx context.Context, id string) (*Session, error)
+	// Save stores the session until ttl passes without another Save
+	Save(ctx context.Context, s *Session, ttl time.Duration) error
+	Delete(ctx context.Context, id string) error
+	// Count returns the number of unexpired sessions
+	Count(ctx context.Context) (int64, error)
+}
+
+// RedisStore keeps each session as a JSON string under <prefix><id>. The
+// sorted set <prefix>index scores every ID with its expiry so Count doesn't
+// have to scan the keyspace.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore creates a Redis store; prefix defaults to "session:"
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	if prefix == "" {
+		prefix = "session:"
+	}
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Load reads a session
+func (r *RedisStore) Load(ctx context.Context, id string) (*Session, error) {
+	data, err := r.client.Get(ctx, r.prefix+id).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+
+	s := &Session{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", err)
+	}
+	s.ID = id
+	if s.Values == nil {
+		s.Values = make(map[string]interface{})
+	}
+	return s, nil
+}
+
+// Save writes a session with ttl
+func (r *RedisStore) Save(ctx context.Context, s *Session, ttl time.Duration) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, r.prefix+s.ID, data, ttl)
+	pipe.ZAdd(ctx, r.indexKey(), &redis.Z{Score: float64(time.Now().Add(ttl).Unix()), Member: s.ID})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a session
+func (r *RedisStore) Delete(ctx context.Context, id string) error {
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, r.prefix+id)
+	pipe.ZRem(ctx, r.indexKey(), id)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// Count drops expired IDs from the index and returns the rest
+func (r *RedisStore) Count(ctx context.Context) (int64, error) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	pipe := r.client.TxPipeline()
+	pipe.ZRemRangeByScore(ctx, r.indexKey(), "-inf", now)
+	count := pipe.ZCard(ctx, r.indexKey())
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to count sessions: %w", err)
+	}
+	return count.Val(), nil
+}
+
+func (r *RedisStore) indexKey() string {
+	return r.prefix + "index"
+}
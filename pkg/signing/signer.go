@@ -0,0 +1,85 @@
+package signing
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Signer signs outbound requests with a single key
+type Signer struct {
+	key *Key
+	now func() time.Time
+}
+
+// NewSigner creates a signer. The key must hold a secret (HMAC) or private key (Ed25519).
+func NewSigner(key *Key) (*Signer, error) {
+	if key == nil || key.ID == "" {
+		return nil, ErrInvalidKey
+	}
+	if _, err := key.sign(nil); err != nil {
+		return nil, err
+	}
+	return &Signer{key: key, now: time.Now}, nil
+}
+
+// KeyID returns the ID sent in X-Signature-Key-Id
+func (s *Signer) KeyID() string {
+	return s.key.ID
+}
+
+// Sign adds the signature headers to the request. The body is read and restored.
+func (s *Signer) Sign(r *http.Request) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+
+	signature, err := s.key.sign(canonicalString(r, timestamp, nonce, body))
+	if err != nil {
+		return err
+	}
+
+	r.Header.Set(HeaderKeyID, s.key.ID)
+	r.Header.Set(HeaderTimestamp, timestamp)
+	r.Header.Set(HeaderNonce, nonce)
+	r.Header.Set(HeaderSignature, base64.StdEncoding.EncodeToString(signature))
+	return nil
+}
+
+// Transport is an http.RoundTripper that signs every request before sending it
+type Transport struct {
+	Base   http.RoundTripper
+	Signer *Signer
+}
+
+// RoundTrip signs a clone of the request and delegates to Base
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	clone := r.Clone(r.Context())
+	if err := t.Signer.Sign(clone); err != nil {
+		return nil, err
+	}
+	return base.RoundTrip(clone)
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,171 @@
+package signing
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Supported algorithms
+const (
+	AlgHMACSHA256 = "hmac-sha256"
+	AlgEd25519    = "ed25519"
+)
+
+// Signature headers
+const (
+	HeaderKeyID     = "X-Signature-Key-Id"
+	HeaderTimestamp = "X-Signature-Timestamp"
+	HeaderNonce     = "X-Signature-Nonce"
+	HeaderSignature = "X-Signature"
+)
+
+var (
+	ErrMissingSignature     = errors.New("request signature is missing")
+	ErrUnknownKey           = errors.New("unknown signing key")
+	ErrInvalidSignature     = errors.New("request signature is invalid")
+	ErrExpiredSignature     = errors.New("request signature timestamp is outside the allowed window")
+	ErrReplayedNonce        = errors.New("request signature nonce was already used")
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrInvalidKey           = errors.New("invalid signing key")
+)
+
+// Key is a named signing or verification key.
+// HMAC keys use Secret on both sides; Ed25519 signers need PrivateKey, verifiers PublicKey.
+type Key struct {
+	ID         string
+	Algorithm  string
+	Secret     []byte
+	PrivateKey ed25519.PrivateKey
+	PublicKey  ed25519.PublicKey
+}
+
+// ParseKey decodes a base64 key. For ed25519, a 32-byte value is a public key
+// (or a seed when private is true) and a 64-byte value is a full private key.
+func ParseKey(id, algorithm, encoded string, private bool) (*Key, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(raw) == 0 {
+		return nil, fmt.Errorf("%w: %s is not valid base64", ErrInvalidKey, id)
+	}
+
+	key := &Key{ID: id, Algorithm: algorithm}
+
+	switch algorithm {
+	case AlgHMACSHA256:
+		if len(raw) < 32 {
+			return nil, fmt.Errorf("%w: %s must be at least 32 bytes", ErrInvalidKey, id)
+		}
+		key.Secret = raw
+	case AlgEd25519:
+		switch {
+		case len(raw) == ed25519.PrivateKeySize:
+			key.PrivateKey = ed25519.PrivateKey(raw)
+		case len(raw) == ed25519.SeedSize && private:
+			key.PrivateKey = ed25519.NewKeyFromSeed(raw)
+		case len(raw) == ed25519.PublicKeySize:
+			key.PublicKey = ed25519.PublicKey(raw)
+		default:
+			return nil, fmt.Errorf("%w: %s has an invalid ed25519 key length", ErrInvalidKey, id)
+		}
+		if key.PrivateKey != nil {
+			key.PublicKey = key.PrivateKey.Public().(ed25519.PublicKey)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+
+	return key, nil
+}
+
+// ParseKeyList parses "id:algorithm:base64key" entries separated by commas,
+// as used by SIGNING_TRUSTED_KEYS
+func ParseKeyList(list string) ([]*Key, error) {
+	var keys []*Key
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("%w: expected id:algorithm:key, got %q", ErrInvalidKey, entry)
+		}
+
+		key, err := ParseKey(parts[0], parts[1], parts[2], false)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// canonicalString is what gets signed:
+// METHOD \n /path?query \n timestamp \n nonce \n hex(sha256(body))
+func canonicalString(r *http.Request, timestamp, nonce string, body []byte) []byte {
+	sum := sha256.Sum256(body)
+	return []byte(strings.Join([]string{
+		strings.ToUpper(r.Method),
+		r.URL.RequestURI(),
+		timestamp,
+		nonce,
+		hex.EncodeToString(sum[:]),
+	}, "\n"))
+}
+
+func (k *Key) sign(message []byte) ([]byte, error) {
+	switch k.Algorithm {
+	case AlgHMACSHA256:
+		mac := hmac.New(sha256.New, k.Secret)
+		mac.Write(message)
+		return mac.Sum(nil), nil
+	case AlgEd25519:
+		if k.PrivateKey == nil {
+			return nil, fmt.Errorf("%w: %s has no private key", ErrInvalidKey, k.ID)
+		}
+		return ed25519.Sign(k.PrivateKey, message), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, k.Algorithm)
+	}
+}
+
+func (k *Key) verify(message, signature []byte) bool {
+	switch k.Algorithm {
+	case AlgHMACSHA256:
+		mac := hmac.New(sha256.New, k.Secret)
+		mac.Write(message)
+		return hmac.Equal(mac.Sum(nil), signature)
+	case AlgEd25519:
+		return k.PublicKey != nil && ed25519.Verify(k.PublicKey, message, signature)
+	default:
+		return false
+	}
+}
+
+// readBody reads the request body and puts an identical reader back
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
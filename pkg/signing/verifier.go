@@ -0,0 +1,127 @@
+package signing
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"flex-service/pkg/cache"
+)
+
+// DefaultMaxSkew is how far a signature timestamp may drift from the server clock
+const DefaultMaxSkew = 5 * time.Minute
+
+// Verifier checks inbound signatures against a set of trusted keys
+type Verifier struct {
+	mu      sync.RWMutex
+	keys    map[string]*Key
+	maxSkew time.Duration
+	nonces  cache.Cache
+	now     func() time.Time
+}
+
+// NewVerifier creates a verifier trusting the given keys
+func NewVerifier(maxSkew time.Duration, keys ...*Key) *Verifier {
+	if maxSkew <= 0 {
+		maxSkew = DefaultMaxSkew
+	}
+
+	v := &Verifier{
+		keys:    make(map[string]*Key, len(keys)),
+		maxSkew: maxSkew,
+		now:     time.Now,
+	}
+	for _, key := range keys {
+		v.AddKey(key)
+	}
+	return v
+}
+
+// AddKey trusts a key, replacing any key with the same ID (used for rotation)
+func (v *Verifier) AddKey(key *Key) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.keys[key.ID] = key
+}
+
+// RemoveKey stops trusting a key
+func (v *Verifier) RemoveKey(id string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.keys, id)
+}
+
+// WithNonceCache rejects nonces seen within the skew window. Without a cache only
+// the timestamp window limits replays.
+func (v *Verifier) WithNonceCache(c cache.Cache) *Verifier {
+	v.nonces = c
+	return v
+}
+
+// Verify checks the request signature and returns the key that signed it.
+// The body is read and restored so handlers can still bind it.
+func (v *Verifier) Verify(r *http.Request) (*Key, error) {
+	keyID := r.Header.Get(HeaderKeyID)
+	timestamp := r.Header.Get(HeaderTimestamp)
+	nonce := r.Header.Get(HeaderNonce)
+	encoded := r.Header.Get(HeaderSignature)
+	if keyID == "" || timestamp == "" || nonce == "" || encoded == "" {
+		return nil, ErrMissingSignature
+	}
+
+	v.mu.RLock()
+	key, ok := v.keys[keyID]
+	v.mu.RUnlock()
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	if skew := v.now().Sub(time.Unix(unix, 0)); skew > v.maxSkew || skew < -v.maxSkew {
+		return nil, ErrExpiredSignature
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !key.verify(canonicalString(r, timestamp, nonce, body), signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	if err := v.checkNonce(r.Context(), keyID, nonce); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (v *Verifier) checkNonce(ctx context.Context, keyID, nonce string) error {
+	if v.nonces == nil {
+		return nil
+	}
+
+	cacheKey := "signing:nonce:" + keyID + ":" + nonce
+	count, err := v.nonces.Incr(ctx, cacheKey)
+	if err != nil {
+		// Cache outage shouldn't take internal traffic down; the timestamp window still applies
+		return nil
+	}
+	if count == 1 {
+		_ = v.nonces.Expire(ctx, cacheKey, 2*v.maxSkew)
+		return nil
+	}
+	return ErrReplayedNonce
+}
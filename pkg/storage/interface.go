@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	ErrNotFound      = errors.New("file not found")
+	ErrInvalidPath   = errors.New("invalid file path")
+	ErrUnknownDriver = errors.New("unknown storage driver")
+	ErrInvalidConfig = errors.New("invalid storage configuration")
+	ErrInvalidURL    = errors.New("temporary URL is invalid or expired")
+)
+
+// Filesystem is implemented by every storage driver
+type Filesystem interface {
+	// Put stores the content at path, replacing any existing file
+	Put(ctx context.Context, path string, body io.Reader, opts *PutOptions) error
+
+	// Get opens the file at path. Callers must close the reader.
+	Get(ctx context.Context, path string) (io.ReadCloser, error)
+
+	// Delete removes the file at path. Missing files are not an error.
+	Delete(ctx context.Context, path string) error
+
+	// Exists reports whether a file exists at path
+	Exists(ctx context.Context, path string) (bool, error)
+
+	// URL returns the permanent public URL of path
+	URL(path string) string
+
+	// TemporaryURL returns a signed URL for path that stops working after expiry
+	TemporaryURL(ctx context.Context, path string, expiry time.Duration) (string, error)
+}
+
+// PutOptions holds optional metadata for Put
+type PutOptions struct {
+	ContentType string
+	// Public makes the file readable through URL without signing (S3 public-read ACL)
+	Public bool
+}
@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalConfig configures the local disk driver
+type LocalConfig struct {
+	Root    string // Directory holding the files, e.g. ./storage
+	BaseURL string // URL the files are served from, e.g. http://localhost:8080/storage
+	// SigningKey signs temporary URLs; required for TemporaryURL
+	SigningKey string
+	// Public serves every file without a signature
+	Public bool
+}
+
+// Local stores files on the local disk
+type Local struct {
+	config LocalConfig
+}
+
+// NewLocal creates the local driver, creating the root directory if needed
+func NewLocal(cfg LocalConfig) (*Local, error) {
+	if cfg.Root == "" {
+		return nil, fmt.Errorf("%w: local root is required", ErrInvalidConfig)
+	}
+	if err := os.MkdirAll(cfg.Root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage root: %w", err)
+	}
+
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	return &Local{config: cfg}, nil
+}
+
+// Put writes the file atomically via a temp file in the same directory
+func (l *Local) Put(ctx context.Context, p string, body io.Reader, opts *PutOptions) error {
+	full, err := l.fullPath(p)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(full), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), full)
+}
+
+// Get opens the file
+func (l *Local) Get(ctx context.Context, p string) (io.ReadCloser, error) {
+	full, err := l.fullPath(p)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(full)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, p)
+	}
+	return file, err
+}
+
+// Delete removes the file
+func (l *Local) Delete(ctx context.Context, p string) error {
+	full, err := l.fullPath(p)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(full); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Exists checks the file on disk
+func (l *Local) Exists(ctx context.Context, p string) (bool, error) {
+	full, err := l.fullPath(p)
+	if err != nil {
+		return false, err
+	}
+
+	info, err := os.Stat(full)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !info.IsDir(), nil
+}
+
+// URL returns BaseURL/path
+func (l *Local) URL(p string) string {
+	cleaned, err := CleanPath(p)
+	if err != nil {
+		return ""
+	}
+	return l.config.BaseURL + "/" + (&url.URL{Path: cleaned}).EscapedPath()
+}
+
+// TemporaryURL returns URL(path)?expires=<unix>&signature=<hmac>, served by ServeLocal
+func (l *Local) TemporaryURL(ctx context.Context, p string, expiry time.Duration) (string, error) {
+	if l.config.SigningKey == "" {
+		return "", fmt.Errorf("%w: local signing key is required for temporary URLs", ErrInvalidConfig)
+	}
+
+	cleaned, err := CleanPath(p)
+	if err != nil {
+		return "", err
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	query := url.Values{
+		"expires":   {expires},
+		"signature": {l.sign(cleaned, expires)},
+	}
+	return l.URL(cleaned) + "?" + query.Encode(), nil
+}
+
+// VerifyTemporaryURL checks the expires/signature pair produced by TemporaryURL
+func (l *Local) VerifyTemporaryURL(p, expires, signature string) error {
+	if l.config.SigningKey == "" {
+		return ErrInvalidURL
+	}
+
+	cleaned, err := CleanPath(p)
+	if err != nil {
+		return err
+	}
+
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return ErrInvalidURL
+	}
+	if !hmac.Equal([]byte(l.sign(cleaned, expires)), []byte(signature)) {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+// Public reports whether files are served without signatures
+func (l *Local) Public() bool {
+	return l.config.Public
+}
+
+// Root returns the root directory
+func (l *Local) Root() string {
+	return l.config.Root
+}
+
+func (l *Local) sign(p, expires string) string {
+	mac := hmac.New(sha256.New, []byte(l.config.SigningKey))
+	mac.Write([]byte(p + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (l *Local) fullPath(p string) (string, error) {
+	cleaned, err := CleanPath(p)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.config.Root, filepath.FromSlash(cleaned)), nil
+}
@@ -0,0 +1,391 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Config configures the S3 driver. Works with AWS S3 and S3-compatible servers (MinIO, R2, Spaces).
+type S3Config struct {
+	Endpoint  string // e.g. http://minio:9000; defaults to https://s3.<region>.amazonaws.com
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle uses endpoint/bucket/key instead of bucket.endpoint/key (required by MinIO)
+	PathStyle bool
+	// PublicURL is the base for URL(), e.g. a CDN in front of the bucket
+	PublicURL string
+	Timeout   time.Duration
+}
+
+const (
+	s3Algorithm      = "AWS4-HMAC-SHA256"
+	s3UnsignedBody   = "UNSIGNED-PAYLOAD"
+	s3TimeFormat     = "20060102T150405Z"
+	s3DateFormat     = "20060102"
+	s3MaxPresignTime = 7 * 24 * time.Hour
+)
+
+// S3 stores files in an S3 bucket using signed (SigV4) REST requests
+type S3 struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3 creates the S3 driver
+func NewS3(cfg S3Config) (*S3, error) {
+	if cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("%w: s3 bucket, access key and secret key are required", ErrInvalidConfig)
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 60 * time.Second
+	}
+
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("%w: invalid s3 endpoint %q", ErrInvalidConfig, cfg.Endpoint)
+	}
+
+	return &S3{
+		config:   cfg,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		now:      time.Now,
+	}, nil
+}
+
+// Put uploads the object. The body is buffered when its size isn't known, since S3 requires Content-Length.
+func (s *S3) Put(ctx context.Context, p string, body io.Reader, opts *PutOptions) error {
+	key, err := CleanPath(p)
+	if err != nil {
+		return err
+	}
+
+	size, body, err := contentLength(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+
+	if opts != nil {
+		if opts.ContentType != "" {
+			req.Header.Set("Content-Type", opts.ContentType)
+		}
+		if opts.Public {
+			req.Header.Set("X-Amz-Acl", "public-read")
+		}
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get downloads the object
+func (s *S3) Get(ctx context.Context, p string) (io.ReadCloser, error) {
+	key, err := CleanPath(p)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete removes the object
+func (s *S3) Delete(ctx context.Context, p string) error {
+	key, err := CleanPath(p)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	if resp != nil {
+		resp.Body.Close()
+	}
+	return nil
+}
+
+// Exists issues a HEAD request
+func (s *S3) Exists(ctx context.Context, p string) (bool, error) {
+	key, err := CleanPath(p)
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.objectURL(key).String(), nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := s.do(req)
+	if isNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+// URL returns PublicURL/key, or the bucket URL when no public URL is configured
+func (s *S3) URL(p string) string {
+	key, err := CleanPath(p)
+	if err != nil {
+		return ""
+	}
+	if s.config.PublicURL != "" {
+		return strings.TrimSuffix(s.config.PublicURL, "/") + "/" + uriEncode(key, false)
+	}
+	return s.objectURL(key).String()
+}
+
+// TemporaryURL returns a presigned GET URL (max 7 days)
+func (s *S3) TemporaryURL(ctx context.Context, p string, expiry time.Duration) (string, error) {
+	key, err := CleanPath(p)
+	if err != nil {
+		return "", err
+	}
+	if expiry <= 0 || expiry > s3MaxPresignTime {
+		return "", fmt.Errorf("%w: s3 temporary URLs must expire within 7 days", ErrInvalidConfig)
+	}
+
+	now := s.now().UTC()
+	u := s.objectURL(key)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", s.config.AccessKey+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	u.RawQuery = canonicalQuery(query)
+
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		u.RawQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		s3UnsignedBody,
+	}, "\n")
+
+	u.RawQuery += "&X-Amz-Signature=" + s.signature(now, canonical)
+	return u.String(), nil
+}
+
+// do signs and sends the request, turning non-2xx responses into errors
+func (s *S3) do(req *http.Request) (*http.Response, error) {
+	s.sign(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 request failed: %w", err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, req.URL.Path)
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, fmt.Errorf("s3 %s %s returned %d: %s", req.Method, req.URL.Path, resp.StatusCode, bytes.TrimSpace(detail))
+}
+
+// sign adds SigV4 headers, leaving the payload unsigned (the transport is TLS)
+func (s *S3) sign(req *http.Request) {
+	now := s.now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedBody)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		s3UnsignedBody,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.config.AccessKey, s.scope(now), signedHeaders, s.signature(now, canonical)))
+}
+
+func (s *S3) signature(now time.Time, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		now.Format(s3TimeFormat),
+		s.scope(now),
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), now.Format(s3DateFormat))
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func (s *S3) scope(now time.Time) string {
+	return now.Format(s3DateFormat) + "/" + s.config.Region + "/s3/aws4_request"
+}
+
+func (s *S3) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	objectPath := "/" + s.config.Bucket + "/" + key
+	if !s.config.PathStyle {
+		u.Host = s.config.Bucket + "." + u.Host
+		objectPath = "/" + key
+	}
+
+	u.Path = strings.TrimSuffix(s.endpoint.Path, "/") + objectPath
+	u.RawPath = uriEncode(u.Path, false)
+	return &u
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query params sorted by key as SigV4 requires
+func canonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		vals := append([]string(nil), values[key]...)
+		sort.Strings(vals)
+		for _, value := range vals {
+			parts = append(parts, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything except RFC 3986 unreserved characters (and "/" unless encodeSlash)
+func uriEncode(value string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// contentLength returns the body size, buffering readers that can't report it
+func contentLength(body io.Reader) (int64, io.Reader, error) {
+	switch b := body.(type) {
+	case *bytes.Reader:
+		return int64(b.Len()), b, nil
+	case *bytes.Buffer:
+		return int64(b.Len()), b, nil
+	case *strings.Reader:
+		return int64(b.Len()), b, nil
+	case io.ReadSeeker:
+		current, err := b.Seek(0, io.SeekCurrent)
+		if err != nil {
+			break
+		}
+		end, err := b.Seek(0, io.SeekEnd)
+		if err != nil {
+			break
+		}
+		if _, err := b.Seek(current, io.SeekStart); err != nil {
+			return 0, nil, err
+		}
+		return end - current, b, nil
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read upload body: %w", err)
+	}
+	return int64(len(data)), bytes.NewReader(data), nil
+}
+
+func isNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
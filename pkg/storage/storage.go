@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Config selects and configures the storage driver
+type Config struct {
+	Driver string // local, s3, or a driver added with RegisterDriver
+	Local  LocalConfig
+	S3     S3Config
+}
+
+// DriverFactory creates a Filesystem from the config
+type DriverFactory func(cfg Config) (Filesystem, error)
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]DriverFactory{
+		"local": func(cfg Config) (Filesystem, error) { return NewLocal(cfg.Local) },
+		"s3":    func(cfg Config) (Filesystem, error) { return NewS3(cfg.S3) },
+	}
+)
+
+// RegisterDriver adds a driver usable via STORAGE_DRIVER. Call it from init().
+func RegisterDriver(name string, factory DriverFactory) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	drivers[name] = factory
+}
+
+// Drivers returns the registered driver names, sorted
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates the configured driver
+func New(cfg Config) (Filesystem, error) {
+	driver := cfg.Driver
+	if driver == "" {
+		driver = "local"
+	}
+
+	driversMu.RLock()
+	factory, ok := drivers[driver]
+	driversMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDriver, driver)
+	}
+	return factory(cfg)
+}
+
+// CleanPath normalizes a storage path and rejects traversal outside the root
+func CleanPath(p string) (string, error) {
+	p = strings.TrimPrefix(strings.ReplaceAll(p, "\\", "/"), "/")
+	if p == "" {
+		return "", ErrInvalidPath
+	}
+
+	cleaned := path.Clean(p)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%w: %s", ErrInvalidPath, p)
+	}
+	return cleaned, nil
+}
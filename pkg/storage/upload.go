@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	appErrors "flex-service/pkg/errors"
+	"flex-service/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrFileRequired    = errors.New("file is required")
+	ErrFileTooLarge    = errors.New("file is too large")
+	ErrFileTypeInvalid = errors.New("file type is not allowed")
+)
+
+// UploadOptions controls how an uploaded file is validated and stored
+type UploadOptions struct {
+	Field        string   // form field name (default "file")
+	Directory    string   // target directory, e.g. "avatars"; supports {yyyy}/{mm}/{dd}
+	MaxSize      int64    // bytes, default 10MB
+	AllowedTypes []string // sniffed MIME types, e.g. "image/png" or "image/*"; empty allows all
+	Public       bool
+}
+
+// UploadedFile describes a stored upload
+type UploadedFile struct {
+	Path         string `json:"path"`
+	URL          string `json:"url"`
+	OriginalName string `json:"original_name"`
+	ContentType  string `json:"content_type"`
+	Size         int64  `json:"size"`
+}
+
+const defaultMaxUploadSize = 10 << 20
+
+// Store validates the multipart file in opts.Field and saves it under a random name
+func Store(c *gin.Context, fs Filesystem, opts UploadOptions) (*UploadedFile, error) {
+	if opts.Field == "" {
+		opts.Field = "file"
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = defaultMaxUploadSize
+	}
+
+	// Bound the whole request body, leaving room for the other multipart parts
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, opts.MaxSize+1<<20)
+
+	header, err := c.FormFile(opts.Field)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, ErrFileTooLarge
+		}
+		return nil, ErrFileRequired
+	}
+	if header.Size > opts.MaxSize {
+		return nil, ErrFileTooLarge
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open upload: %w", err)
+	}
+	defer file.Close()
+
+	contentType, err := sniff(file)
+	if err != nil {
+		return nil, err
+	}
+	if !typeAllowed(contentType, opts.AllowedTypes) {
+		return nil, fmt.Errorf("%w: %s", ErrFileTypeInvalid, contentType)
+	}
+
+	target := path.Join(expandDirectory(opts.Directory), uuid.NewString()+strings.ToLower(filepath.Ext(header.Filename)))
+	if err := fs.Put(c.Request.Context(), target, file, &PutOptions{ContentType: contentType, Public: opts.Public}); err != nil {
+		return nil, fmt.Errorf("failed to store upload: %w", err)
+	}
+
+	return &UploadedFile{
+		Path:         target,
+		URL:          fs.URL(target),
+		OriginalName: filepath.Base(header.Filename),
+		ContentType:  contentType,
+		Size:         header.Size,
+	}, nil
+}
+
+// Upload is middleware that stores the file before the handler runs and exposes it as
+// c.Get("uploaded_file"). Validation failures respond with 400/413 and abort.
+func Upload(fs Filesystem, opts UploadOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uploaded, err := Store(c, fs, opts)
+		if err != nil {
+			c.Error(UploadError(err))
+			c.Abort()
+			return
+		}
+
+		c.Set("uploaded_file", uploaded)
+		c.Next()
+	}
+}
+
+// UploadedFileFromContext returns the file stored by Upload
+func UploadedFileFromContext(c *gin.Context) (*UploadedFile, bool) {
+	value, exists := c.Get("uploaded_file")
+	if !exists {
+		return nil, false
+	}
+	uploaded, ok := value.(*UploadedFile)
+	return uploaded, ok
+}
+
+// ServeLocal serves files of the local driver. Unless the disk is public, requests
+// need the expires/signature query produced by TemporaryURL.
+// Mount with a wildcard param: router.GET("/storage/*path", storage.ServeLocal(local))
+func ServeLocal(local *Local) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := c.Param("path")
+
+		if !local.Public() {
+			if err := local.VerifyTemporaryURL(p, c.Query("expires"), c.Query("signature")); err != nil {
+				response.Error(c, http.StatusForbidden, "INVALID_SIGNATURE", err.Error(), nil)
+				return
+			}
+		}
+
+		full, err := local.fullPath(p)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "INVALID_PATH", err.Error(), nil)
+			return
+		}
+		if ok, _ := local.Exists(c.Request.Context(), p); !ok {
+			response.Error(c, http.StatusNotFound, "NOT_FOUND", "File not found", nil)
+			return
+		}
+
+		c.File(full)
+	}
+}
+
+// UploadError maps Store failures to AppErrors for the ErrorHandler middleware
+func UploadError(err error) error {
+	switch {
+	case errors.Is(err, ErrFileRequired), errors.Is(err, ErrFileTypeInvalid):
+		return appErrors.Wrap(err, appErrors.ErrValidation, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrFileTooLarge):
+		return appErrors.Wrap(err, "FILE_TOO_LARGE", err.Error(), http.StatusRequestEntityTooLarge)
+	default:
+		return appErrors.WrapInternal(err, "Failed to store file")
+	}
+}
+
+// sniff detects the content type from the first 512 bytes and rewinds the file
+func sniff(file multipart.File) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", fmt.Errorf("failed to read upload: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	contentType := http.DetectContentType(head[:n])
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return contentType, nil
+}
+
+func typeAllowed(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, pattern := range allowed {
+		if pattern == contentType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func expandDirectory(dir string) string {
+	now := time.Now()
+	return strings.NewReplacer(
+		"{yyyy}", now.Format("2006"),
+		"{mm}", now.Format("01"),
+		"{dd}", now.Format("02"),
+	).Replace(dir)
+}
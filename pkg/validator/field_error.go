@@ -0,0 +1,39 @@
+package validator
+
+import "strings"
+
+// FieldError is a single failed rule on a field
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors is the structured result of Validate. It is nil when validation passes.
+type ValidationErrors []FieldError
+
+// Error implements error
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Map returns field -> message, keeping the first message per field
+// (the shape returned by ValidateStruct)
+func (e ValidationErrors) Map() map[string]string {
+	if len(e) == 0 {
+		return nil
+	}
+
+	fields := make(map[string]string, len(e))
+	for _, fe := range e {
+		if _, exists := fields[fe.Field]; !exists {
+			fields[fe.Field] = fe.Message
+		}
+	}
+	return fields
+}
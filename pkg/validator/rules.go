@@ -0,0 +1,88 @@
+package validator
+
+import (
+	"regexp"
+	"strings"
+
+	"flex-service/pkg/i18n"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	thaiMobilePattern   = regexp.MustCompile(`^0[689][0-9]{8}$`)
+	thaiLandlinePattern = regexp.MustCompile(`^0[2-7][0-9]{7}$`)
+	thaiIDPattern       = regexp.MustCompile(`^[0-9]{13}$`)
+)
+
+// registerBuiltinRules registers the rules shipped with flex-service
+func registerBuiltinRules() {
+	rules := map[string]validator.Func{
+		"phone_th":         isThaiPhone,
+		"thai_national_id": isThaiNationalID,
+	}
+	for tag, fn := range rules {
+		if err := validate.RegisterValidation(tag, fn); err != nil {
+			panic("validator: failed to register " + tag + ": " + err.Error())
+		}
+	}
+}
+
+// RegisterRule adds a custom field rule usable in validate tags, e.g.
+//
+//	validator.RegisterRule("sku", func(fl validator.FieldLevel) bool { ... }, "{field} must be a valid SKU")
+//
+// message becomes the English text of validation.<tag> unless a bundle already defines it;
+// {field} and {param} are available. Register rules at startup, before validating.
+func RegisterRule(tag string, fn validator.Func, message string) error {
+	if err := validate.RegisterValidation(tag, fn); err != nil {
+		return err
+	}
+	RegisterMessage(tag, message)
+	return nil
+}
+
+// RegisterStructRule adds a cross-field rule for the given struct types. Report failures with
+// sl.ReportError(value, "json_field", "StructField", "rule_tag", "param"); the message comes
+// from validation.<rule_tag>, so pair custom tags with RegisterMessage.
+func RegisterStructRule(fn validator.StructLevelFunc, types ...interface{}) {
+	validate.RegisterStructValidation(fn, types...)
+}
+
+// RegisterMessage sets the default English message for a rule tag unless one exists
+func RegisterMessage(tag, message string) {
+	if message == "" {
+		return
+	}
+
+	bundle := i18n.Default()
+	key := "validation." + tag
+	if _, exists := bundle.Lookup(bundle.DefaultLocale(), key); exists {
+		return
+	}
+	bundle.AddMessages(i18n.DefaultLocale, map[string]string{key: message})
+}
+
+// isThaiPhone accepts Thai mobile (08x/09x/06x, 10 digits) and landline (0[2-7], 9 digits)
+// numbers, with optional +66 prefix, spaces or dashes
+func isThaiPhone(fl validator.FieldLevel) bool {
+	phone := strings.NewReplacer(" ", "", "-", "").Replace(fl.Field().String())
+	if strings.HasPrefix(phone, "+66") {
+		phone = "0" + phone[3:]
+	}
+	return thaiMobilePattern.MatchString(phone) || thaiLandlinePattern.MatchString(phone)
+}
+
+// isThaiNationalID validates a 13-digit Thai national ID including its check digit
+func isThaiNationalID(fl validator.FieldLevel) bool {
+	id := strings.ReplaceAll(fl.Field().String(), "-", "")
+	if !thaiIDPattern.MatchString(id) {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < 12; i++ {
+		sum += int(id[i]-'0') * (13 - i)
+	}
+	return (11-sum%11)%10 == int(id[12]-'0')
+}
@@ -5,6 +5,8 @@ import (
 	"reflect"
 	"strings"
 
+	"flex-service/pkg/i18n"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/microcosm-cc/bluemonday"
 )
@@ -33,6 +35,8 @@ func init() {
 		}
 		return name
 	})
+
+	registerBuiltinRules()
 }
 
 // NewXSSSanitizer creates a new XSS sanitizer with different policies
@@ -152,13 +156,23 @@ func (s *XSSSanitizer) SanitizeStruct(v interface{}) error {
 	return nil
 }
 
-// ValidateStruct validates a struct and returns formatted errors
+// ValidateStruct validates a struct and returns formatted errors in the default locale
 func ValidateStruct(s interface{}) map[string]string {
+	return Validate(s, i18n.Default().DefaultLocale()).Map()
+}
+
+// ValidateStructWithLocale validates a struct and returns field -> message translated
+// into locale, e.g. validator.ValidateStructWithLocale(&req, i18n.Locale(c))
+func ValidateStructWithLocale(s interface{}, locale string) map[string]string {
+	return Validate(s, locale).Map()
+}
+
+// Validate sanitizes and validates a struct, returning every failed rule with its
+// field, rule, param and message translated from the validation.<rule> keys of pkg/i18n
+func Validate(s interface{}, locale string) ValidationErrors {
 	// First sanitize based on struct tags
 	if err := sanitizer.SanitizeStruct(s); err != nil {
-		return map[string]string{
-			"sanitization_error": err.Error(),
-		}
+		return ValidationErrors{{Field: "sanitization_error", Rule: "sanitize", Message: err.Error()}}
 	}
 
 	// Then validate
@@ -167,35 +181,33 @@ func ValidateStruct(s interface{}) map[string]string {
 		return nil
 	}
 
-	errors := make(map[string]string)
-
-	for _, err := range err.(validator.ValidationErrors) {
-		field := err.Field()
-		tag := err.Tag()
-
-		switch tag {
-		case "required":
-			errors[field] = fmt.Sprintf("%s is required", field)
-		case "email":
-			errors[field] = fmt.Sprintf("%s must be a valid email", field)
-		case "min":
-			errors[field] = fmt.Sprintf("%s must be at least %s characters", field, err.Param())
-		case "max":
-			errors[field] = fmt.Sprintf("%s must be at most %s characters", field, err.Param())
-		case "gte":
-			errors[field] = fmt.Sprintf("%s must be greater than or equal to %s", field, err.Param())
-		case "lte":
-			errors[field] = fmt.Sprintf("%s must be less than or equal to %s", field, err.Param())
-		case "datetime":
-			errors[field] = fmt.Sprintf("%s must be in format %s", field, err.Param())
-		default:
-			errors[field] = fmt.Sprintf("%s is invalid", field)
-		}
+	validationErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return ValidationErrors{{Field: "validation_error", Rule: "invalid", Message: err.Error()}}
+	}
+
+	errors := make(ValidationErrors, 0, len(validationErrors))
+	for _, err := range validationErrors {
+		errors = append(errors, FieldError{
+			Field:   err.Field(),
+			Rule:    err.Tag(),
+			Param:   err.Param(),
+			Message: message(locale, err.Tag(), err.Field(), err.Param()),
+		})
 	}
 
 	return errors
 }
 
+// message translates validation.<rule>, falling back to validation.invalid
+func message(locale, rule, field, param string) string {
+	text, ok := i18n.Lookup(locale, "validation."+rule)
+	if !ok {
+		text, _ = i18n.Lookup(locale, "validation.invalid")
+	}
+	return i18n.Format(text, i18n.Args{"field": field, "param": param})
+}
+
 // GetValidator returns the validator instance
 func GetValidator() *validator.Validate {
 	return validate
@@ -0,0 +1,17 @@
+package webhook
+
+import "errors"
+
+// Webhook errors
+var (
+	ErrEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidEndpoint  = errors.New("invalid webhook endpoint")
+	ErrEndpointInactive = errors.New("webhook endpoint is inactive")
+
+	ErrMissingSignature = errors.New("webhook signature is missing")
+	ErrInvalidSignature = errors.New("webhook signature is invalid")
+	ErrSignatureExpired = errors.New("webhook timestamp is outside the tolerance")
+	ErrReplayed         = errors.New("webhook was already received")
+	ErrBodyTooLarge     = errors.New("webhook body is too large")
+)
@@ -0,0 +1,246 @@
+package webhook
+
+import (
+	"net/http"
+	"strconv"
+
+	"flex-service/pkg/errors"
+	"flex-service/pkg/pagination"
+	"flex-service/pkg/request"
+	"flex-service/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateEndpointRequest registers an endpoint
+type CreateEndpointRequest struct {
+	OwnerType   string   `json:"owner_type" validate:"required,oneof=tenant user"`
+	OwnerID     string   `json:"owner_id" validate:"required,max=64"`
+	URL         string   `json:"url" validate:"required,url,max=500"`
+	Events      []string `json:"events" validate:"required,min=1,dive,required,max=100"`
+	Description string   `json:"description" validate:"max=255"`
+	Active      *bool    `json:"active"`
+}
+
+// UpdateEndpointRequest changes an endpoint; omitted fields are kept
+type UpdateEndpointRequest struct {
+	URL         string   `json:"url" validate:"omitempty,url,max=500"`
+	Events      []string `json:"events" validate:"omitempty,dive,required,max=100"`
+	Description string   `json:"description" validate:"max=255"`
+	Active      *bool    `json:"active"`
+}
+
+// EndpointWithSecret is returned when a secret is created or rotated
+type EndpointWithSecret struct {
+	*Endpoint
+	Secret string `json:"secret"`
+}
+
+// Handler exposes endpoint management and delivery logs over HTTP for admins
+type Handler struct {
+	manager *Manager
+}
+
+// NewHandler creates a webhook admin HTTP handler
+func NewHandler(manager *Manager) *Handler {
+	return &Handler{manager: manager}
+}
+
+// ListEndpoints returns endpoints: GET /webhooks/endpoints?owner_type=tenant&owner_id=42
+func (h *Handler) ListEndpoints(c *gin.Context) {
+	p, err := pagination.Bind(c)
+	if err != nil {
+		c.Error(errors.WrapBadRequest(err, "Invalid pagination parameters"))
+		return
+	}
+
+	var owner *Owner
+	if ownerType := c.Query("owner_type"); ownerType != "" {
+		owner = &Owner{Type: ownerType, ID: c.Query("owner_id")}
+	}
+
+	endpoints, total, err := h.manager.ListEndpoints(c.Request.Context(), owner, p.Offset(), p.Limit)
+	if err != nil {
+		c.Error(toAppError(err))
+		return
+	}
+
+	response.Paginated(c, "Webhook endpoints retrieved", endpoints, pagination.NewOffsetMeta(p, total).ToResponseMeta())
+}
+
+// CreateEndpoint registers an endpoint and returns its secret once: POST /webhooks/endpoints
+func (h *Handler) CreateEndpoint(c *gin.Context) {
+	req, err := request.Bind[CreateEndpointRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	endpoint, secret, err := h.manager.CreateEndpoint(c.Request.Context(), Owner{Type: req.OwnerType, ID: req.OwnerID}, EndpointInput{
+		URL:         req.URL,
+		Events:      req.Events,
+		Description: req.Description,
+		Active:      req.Active,
+	})
+	if err != nil {
+		c.Error(toAppError(err))
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Webhook endpoint created", EndpointWithSecret{Endpoint: endpoint, Secret: secret})
+}
+
+// GetEndpoint returns an endpoint: GET /webhooks/endpoints/:id
+func (h *Handler) GetEndpoint(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	endpoint, err := h.manager.GetEndpoint(c.Request.Context(), id)
+	if err != nil {
+		c.Error(toAppError(err))
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Webhook endpoint retrieved", endpoint)
+}
+
+// UpdateEndpoint changes an endpoint: PUT /webhooks/endpoints/:id
+func (h *Handler) UpdateEndpoint(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	req, err := request.Bind[UpdateEndpointRequest](c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	endpoint, err := h.manager.UpdateEndpoint(c.Request.Context(), id, EndpointInput{
+		URL:         req.URL,
+		Events:      req.Events,
+		Description: req.Description,
+		Active:      req.Active,
+	})
+	if err != nil {
+		c.Error(toAppError(err))
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Webhook endpoint updated", endpoint)
+}
+
+// DeleteEndpoint removes an endpoint: DELETE /webhooks/endpoints/:id
+func (h *Handler) DeleteEndpoint(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.manager.DeleteEndpoint(c.Request.Context(), id); err != nil {
+		c.Error(toAppError(err))
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Webhook endpoint deleted", nil)
+}
+
+// RotateSecret issues a new signing secret: POST /webhooks/endpoints/:id/rotate-secret
+func (h *Handler) RotateSecret(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	secret, err := h.manager.RotateSecret(c.Request.Context(), id)
+	if err != nil {
+		c.Error(toAppError(err))
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Webhook secret rotated", gin.H{"secret": secret})
+}
+
+// ListDeliveries returns an endpoint's delivery log: GET /webhooks/endpoints/:id/deliveries?status=failed
+func (h *Handler) ListDeliveries(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	p, err := pagination.Bind(c)
+	if err != nil {
+		c.Error(errors.WrapBadRequest(err, "Invalid pagination parameters"))
+		return
+	}
+
+	filter := DeliveryFilter{
+		EndpointID: id,
+		Status:     DeliveryStatus(c.Query("status")),
+		Event:      c.Query("event"),
+	}
+	deliveries, total, err := h.manager.ListDeliveries(c.Request.Context(), filter, p.Offset(), p.Limit)
+	if err != nil {
+		c.Error(toAppError(err))
+		return
+	}
+
+	response.Paginated(c, "Webhook deliveries retrieved", deliveries, pagination.NewOffsetMeta(p, total).ToResponseMeta())
+}
+
+// GetDelivery returns one delivery log: GET /webhooks/deliveries/:id
+func (h *Handler) GetDelivery(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.manager.GetDelivery(c.Request.Context(), id)
+	if err != nil {
+		c.Error(toAppError(err))
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Webhook delivery retrieved", delivery)
+}
+
+// Redeliver sends a delivery's payload again: POST /webhooks/deliveries/:id/redeliver
+func (h *Handler) Redeliver(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.manager.Redeliver(c.Request.Context(), id)
+	if err != nil {
+		c.Error(toAppError(err))
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, "Webhook redelivery scheduled", delivery)
+}
+
+func idParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.Error(errors.BadRequest("Invalid id"))
+		return 0, false
+	}
+	return id, true
+}
+
+func toAppError(err error) error {
+	switch {
+	case errors.Is(err, ErrEndpointNotFound):
+		return errors.NotFound("Webhook endpoint not found")
+	case errors.Is(err, ErrDeliveryNotFound):
+		return errors.NotFound("Webhook delivery not found")
+	case errors.Is(err, ErrInvalidEndpoint):
+		return errors.WrapBadRequest(err, err.Error())
+	default:
+		return errors.WrapInternal(err, "Webhook operation failed")
+	}
+}
@@ -0,0 +1,194 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signature describes a verified incoming webhook
+type Signature struct {
+	ID        string    // Replay key: the provider's delivery ID, or the signature itself
+	Timestamp time.Time // Signing time; zero for schemes without one
+}
+
+// Verifier checks one provider's signature scheme against the raw request body
+type Verifier interface {
+	Verify(header http.Header, body []byte) (*Signature, error)
+}
+
+// VerifierFunc adapts a function to Verifier
+type VerifierFunc func(header http.Header, body []byte) (*Signature, error)
+
+// Verify implements Verifier
+func (fn VerifierFunc) Verify(header http.Header, body []byte) (*Signature, error) {
+	return fn(header, body)
+}
+
+// HMACConfig describes a "header carries HMAC(secret, body)" scheme
+type HMACConfig struct {
+	Secret   string
+	Header   string           // e.g. "X-Signature"
+	Prefix   string           // Stripped before decoding, e.g. "sha256="
+	Hash     func() hash.Hash // Default sha256.New
+	Encoding string           // "hex" (default) or "base64"
+	IDHeader string           // Optional delivery ID header used for replay protection
+}
+
+// SHA1 is for legacy providers that still sign with HMAC-SHA1 (e.g. X-Hub-Signature)
+var SHA1 = sha1.New
+
+// HMAC verifies a plain HMAC of the body. Most providers without a dedicated
+// verifier fit this shape.
+func HMAC(cfg HMACConfig) Verifier {
+	if cfg.Hash == nil {
+		cfg.Hash = sha256.New
+	}
+
+	return VerifierFunc(func(header http.Header, body []byte) (*Signature, error) {
+		value := header.Get(cfg.Header)
+		if value == "" {
+			return nil, ErrMissingSignature
+		}
+		if cfg.Prefix != "" {
+			if !strings.HasPrefix(value, cfg.Prefix) {
+				return nil, ErrInvalidSignature
+			}
+			value = strings.TrimPrefix(value, cfg.Prefix)
+		}
+
+		expected := computeHMAC(cfg.Hash, cfg.Secret, body)
+		if !equalEncoded(value, expected, cfg.Encoding) {
+			return nil, ErrInvalidSignature
+		}
+
+		id := header.Get(cfg.IDHeader)
+		if cfg.IDHeader == "" || id == "" {
+			id = value
+		}
+		return &Signature{ID: id}, nil
+	})
+}
+
+// GitHub verifies X-Hub-Signature-256 and uses X-GitHub-Delivery for replay protection
+func GitHub(secret string) Verifier {
+	return HMAC(HMACConfig{
+		Secret:   secret,
+		Header:   "X-Hub-Signature-256",
+		Prefix:   "sha256=",
+		IDHeader: "X-GitHub-Delivery",
+	})
+}
+
+// LINE verifies X-Line-Signature (base64 HMAC-SHA256 with the channel secret)
+func LINE(channelSecret string) Verifier {
+	return HMAC(HMACConfig{
+		Secret:   channelSecret,
+		Header:   "X-Line-Signature",
+		Encoding: "base64",
+	})
+}
+
+// Stripe verifies the Stripe-Signature header ("t=<unix>,v1=<hex>[,v1=...]").
+// Any v1 signature may match, which keeps verification working while a secret is rolled.
+func Stripe(secret string) Verifier {
+	return VerifierFunc(func(header http.Header, body []byte) (*Signature, error) {
+		value := header.Get("Stripe-Signature")
+		if value == "" {
+			return nil, ErrMissingSignature
+		}
+
+		var timestamp string
+		var signatures []string
+		for _, part := range strings.Split(value, ",") {
+			k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+			if !ok {
+				continue
+			}
+			switch k {
+			case "t":
+				timestamp = v
+			case "v1":
+				signatures = append(signatures, v)
+			}
+		}
+		if timestamp == "" || len(signatures) == 0 {
+			return nil, ErrInvalidSignature
+		}
+
+		return verifyTimestamped(sha256.New, secret, timestamp, body, signatures)
+	})
+}
+
+// Native verifies webhooks sent by this service's Manager (X-Webhook-Timestamp and
+// X-Webhook-Signature), for receivers that are themselves built on flex-service
+func Native(secret string) Verifier {
+	return VerifierFunc(func(header http.Header, body []byte) (*Signature, error) {
+		timestamp := header.Get(HeaderTimestamp)
+		value := header.Get(HeaderSignature)
+		if timestamp == "" || value == "" {
+			return nil, ErrMissingSignature
+		}
+		if !strings.HasPrefix(value, "v1=") {
+			return nil, ErrInvalidSignature
+		}
+
+		signature, err := verifyTimestamped(sha256.New, secret, timestamp, body, []string{strings.TrimPrefix(value, "v1=")})
+		if err != nil {
+			return nil, err
+		}
+		if delivery := header.Get(HeaderDelivery); delivery != "" {
+			signature.ID = delivery + ":" + timestamp
+		}
+		return signature, nil
+	})
+}
+
+// verifyTimestamped checks HMAC(secret, "<timestamp>.<body>") against hex signatures
+func verifyTimestamped(h func() hash.Hash, secret, timestamp string, body []byte, signatures []string) (*Signature, error) {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	mac := hmac.New(h, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	for _, signature := range signatures {
+		if equalEncoded(signature, expected, "hex") {
+			return &Signature{ID: timestamp + ":" + signature, Timestamp: time.Unix(unix, 0)}, nil
+		}
+	}
+	return nil, ErrInvalidSignature
+}
+
+func computeHMAC(h func() hash.Hash, secret string, body []byte) []byte {
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// equalEncoded decodes value and compares it with expected in constant time
+func equalEncoded(value string, expected []byte, encoding string) bool {
+	var decoded []byte
+	var err error
+	if encoding == "base64" {
+		decoded, err = base64.StdEncoding.DecodeString(value)
+	} else {
+		decoded, err = hex.DecodeString(value)
+	}
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(decoded, expected)
+}
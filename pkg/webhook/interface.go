@@ -0,0 +1,113 @@
+package webhook
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Owner types an endpoint can belong to
+const (
+	OwnerTenant = "tenant"
+	OwnerUser   = "user"
+)
+
+// DeliveryStatus is the state of one delivery
+type DeliveryStatus string
+
+const (
+	StatusPending   DeliveryStatus = "pending"
+	StatusRetrying  DeliveryStatus = "retrying"
+	StatusSucceeded DeliveryStatus = "succeeded"
+	StatusFailed    DeliveryStatus = "failed"
+)
+
+// Owner identifies whose endpoints receive an event
+type Owner struct {
+	Type string `json:"owner_type"`
+	ID   string `json:"owner_id"`
+}
+
+// Endpoint is a registered URL that receives events
+type Endpoint struct {
+	ID          int       `json:"id" gorm:"primaryKey"`
+	OwnerType   string    `json:"owner_type" gorm:"type:varchar(20);not null;index:idx_webhook_endpoint_owner"`
+	OwnerID     string    `json:"owner_id" gorm:"type:varchar(64);not null;index:idx_webhook_endpoint_owner"`
+	URL         string    `json:"url" gorm:"type:varchar(500);not null"`
+	Secret      string    `json:"-" gorm:"type:varchar(100);not null"`
+	Events      string    `json:"events" gorm:"type:varchar(1000);not null"` // Comma separated: "order.created,invoice.*" or "*"
+	Description string    `json:"description,omitempty" gorm:"type:varchar(255)"`
+	Active      bool      `json:"active" gorm:"not null;default:true"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for GORM
+func (Endpoint) TableName() string {
+	return "tb_webhook_endpoint"
+}
+
+// Owner returns the endpoint's owner
+func (e *Endpoint) Owner() Owner {
+	return Owner{Type: e.OwnerType, ID: e.OwnerID}
+}
+
+// Subscribes reports whether the endpoint wants event. Patterns are exact names,
+// "*" for everything, or a "prefix.*" wildcard.
+func (e *Endpoint) Subscribes(event string) bool {
+	for _, pattern := range strings.Split(e.Events, ",") {
+		pattern = strings.TrimSpace(pattern)
+		switch {
+		case pattern == "*" || pattern == event:
+			return true
+		case strings.HasSuffix(pattern, ".*") && strings.HasPrefix(event, strings.TrimSuffix(pattern, "*")):
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery is the log of sending one event to one endpoint
+type Delivery struct {
+	ID             int            `json:"id" gorm:"primaryKey"`
+	EndpointID     int            `json:"endpoint_id" gorm:"not null;index"`
+	Event          string         `json:"event" gorm:"type:varchar(100);not null;index"`
+	Payload        string         `json:"payload" gorm:"type:text;not null"` // Exact body that is signed and sent
+	Status         DeliveryStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	Attempts       int            `json:"attempts" gorm:"not null;default:0"`
+	ResponseStatus int            `json:"response_status,omitempty"`
+	ResponseBody   string         `json:"response_body,omitempty" gorm:"type:text"`
+	Error          string         `json:"error,omitempty" gorm:"type:text"`
+	DurationMs     int64          `json:"duration_ms,omitempty"`
+	NextRetryAt    *time.Time     `json:"next_retry_at,omitempty"`
+	DeliveredAt    *time.Time     `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for GORM
+func (Delivery) TableName() string {
+	return "tb_webhook_delivery"
+}
+
+// DeliveryFilter narrows ListDeliveries
+type DeliveryFilter struct {
+	EndpointID int
+	Status     DeliveryStatus
+	Event      string
+}
+
+// Store persists endpoints and delivery logs
+type Store interface {
+	CreateEndpoint(ctx context.Context, endpoint *Endpoint) error
+	UpdateEndpoint(ctx context.Context, endpoint *Endpoint) error
+	DeleteEndpoint(ctx context.Context, id int) error
+	GetEndpoint(ctx context.Context, id int) (*Endpoint, error)
+	ListEndpoints(ctx context.Context, owner *Owner, offset, limit int) ([]Endpoint, int64, error)
+	ActiveEndpoints(ctx context.Context, owner Owner) ([]Endpoint, error)
+
+	CreateDelivery(ctx context.Context, delivery *Delivery) error
+	UpdateDelivery(ctx context.Context, delivery *Delivery) error
+	GetDelivery(ctx context.Context, id int) (*Delivery, error)
+	ListDeliveries(ctx context.Context, filter DeliveryFilter, offset, limit int) ([]Delivery, int64, error)
+}
@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"context"
+
+	"flex-service/pkg/queue"
+)
+
+// jobPayload references a delivery log; the payload itself stays in the database
+func jobPayload(deliveryID int) map[string]interface{} {
+	return map[string]interface{}{"delivery_id": deliveryID}
+}
+
+// deliveryID reads the delivery ID back; it is a float64 after a round trip through Redis
+func deliveryID(payload map[string]interface{}) (int, bool) {
+	switch id := payload["delivery_id"].(type) {
+	case int:
+		return id, true
+	case float64:
+		return int(id), true
+	}
+	return 0, false
+}
+
+// JobHandler runs delivery attempts queued by Publish and Redeliver:
+//
+//	worker.RegisterHandler(queue.JobTypeWebhook, webhook.JobHandler(container.Webhooks))
+//
+// Failed attempts are retried by the manager with its own backoff, so the job only
+// fails when the delivery log can't be read or written. Ad-hoc jobs with a "url"
+// instead of a "delivery_id" are passed to queue.WebhookJobHandler.
+func JobHandler(m *Manager) queue.Handler {
+	adhoc := queue.WebhookJobHandler()
+
+	return queue.HandlerFunc(func(ctx context.Context, job *queue.Job) *queue.JobResult {
+		id, ok := deliveryID(job.Payload)
+		if !ok {
+			return adhoc.Handle(ctx, job)
+		}
+
+		delivery, err := m.Deliver(ctx, id)
+		if err != nil {
+			return &queue.JobResult{Success: false, Error: err.Error()}
+		}
+
+		return &queue.JobResult{
+			Success: true,
+			Data: map[string]interface{}{
+				"delivery_id":     delivery.ID,
+				"status":          delivery.Status,
+				"attempts":        delivery.Attempts,
+				"response_status": delivery.ResponseStatus,
+			},
+		}
+	})
+}
@@ -0,0 +1,424 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"flex-service/pkg/httpclient"
+	"flex-service/pkg/logger"
+	"flex-service/pkg/queue"
+
+	"go.uber.org/zap"
+)
+
+// maxResponseBody is how much of a receiver's response is kept in the delivery log
+const maxResponseBody = 2048
+
+// Config configures delivery and retries
+type Config struct {
+	MaxAttempts int           // Attempts per delivery, including the first (default 6)
+	RetryBase   time.Duration // Delay before the first retry, doubled after each one (default 30s)
+	RetryMax    time.Duration // Upper bound for the retry delay (default 6h)
+	Timeout     time.Duration // Per-request timeout (default 10s)
+	UserAgent   string
+}
+
+// Manager registers endpoints, publishes events to them and keeps delivery logs
+type Manager struct {
+	store      Store
+	client     *http.Client
+	dispatcher *queue.JobDispatcher
+	config     Config
+}
+
+// NewManager creates a manager. Without WithQueue, events are delivered in the
+// caller's goroutine and failed deliveries are not retried.
+func NewManager(store Store, cfg Config) *Manager {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 6
+	}
+	if cfg.RetryBase <= 0 {
+		cfg.RetryBase = 30 * time.Second
+	}
+	if cfg.RetryMax <= 0 {
+		cfg.RetryMax = 6 * time.Hour
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = "flex-service-webhooks/1.0"
+	}
+
+	return &Manager{
+		store:  store,
+		client: httpclient.New(httpclient.Config{Timeout: cfg.Timeout, Name: "webhook"}),
+		config: cfg,
+	}
+}
+
+// WithQueue delivers events from queue workers and enables retries with backoff
+func (m *Manager) WithQueue(dispatcher *queue.JobDispatcher) *Manager {
+	m.dispatcher = dispatcher
+	return m
+}
+
+// EndpointInput holds the fields for creating or updating an endpoint
+type EndpointInput struct {
+	URL         string
+	Events      []string
+	Description string
+	Active      *bool // nil keeps the current value (active on create)
+}
+
+// CreateEndpoint registers an endpoint for owner with a new signing secret.
+// The secret is returned once here; it is never serialised afterwards.
+func (m *Manager) CreateEndpoint(ctx context.Context, owner Owner, input EndpointInput) (*Endpoint, string, error) {
+	if err := validateOwner(owner); err != nil {
+		return nil, "", err
+	}
+	if err := validateEndpoint(input.URL, input.Events); err != nil {
+		return nil, "", err
+	}
+
+	secret, err := NewSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	endpoint := &Endpoint{
+		OwnerType:   owner.Type,
+		OwnerID:     owner.ID,
+		URL:         input.URL,
+		Secret:      secret,
+		Events:      strings.Join(input.Events, ","),
+		Description: input.Description,
+		Active:      input.Active == nil || *input.Active,
+	}
+	if err := m.store.CreateEndpoint(ctx, endpoint); err != nil {
+		return nil, "", err
+	}
+
+	logger.Info("Webhook endpoint created",
+		zap.Int("endpoint_id", endpoint.ID),
+		zap.String("owner_type", owner.Type),
+		zap.String("owner_id", owner.ID))
+	return endpoint, secret, nil
+}
+
+// UpdateEndpoint changes an endpoint's URL, events, description or active flag.
+// Empty URL and Events keep the current values.
+func (m *Manager) UpdateEndpoint(ctx context.Context, id int, input EndpointInput) (*Endpoint, error) {
+	endpoint, err := m.store.GetEndpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.URL != "" {
+		endpoint.URL = input.URL
+	}
+	if len(input.Events) > 0 {
+		endpoint.Events = strings.Join(input.Events, ",")
+	}
+	if input.Description != "" {
+		endpoint.Description = input.Description
+	}
+	if input.Active != nil {
+		endpoint.Active = *input.Active
+	}
+
+	if err := validateEndpoint(endpoint.URL, strings.Split(endpoint.Events, ",")); err != nil {
+		return nil, err
+	}
+	if err := m.store.UpdateEndpoint(ctx, endpoint); err != nil {
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+// RotateSecret replaces an endpoint's signing secret and returns the new one
+func (m *Manager) RotateSecret(ctx context.Context, id int) (string, error) {
+	endpoint, err := m.store.GetEndpoint(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := NewSecret()
+	if err != nil {
+		return "", err
+	}
+	endpoint.Secret = secret
+
+	if err := m.store.UpdateEndpoint(ctx, endpoint); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// DeleteEndpoint removes an endpoint; its pending retries fail when they run
+func (m *Manager) DeleteEndpoint(ctx context.Context, id int) error {
+	return m.store.DeleteEndpoint(ctx, id)
+}
+
+// GetEndpoint returns an endpoint
+func (m *Manager) GetEndpoint(ctx context.Context, id int) (*Endpoint, error) {
+	return m.store.GetEndpoint(ctx, id)
+}
+
+// ListEndpoints returns a page of endpoints; owner may be nil for all owners
+func (m *Manager) ListEndpoints(ctx context.Context, owner *Owner, offset, limit int) ([]Endpoint, int64, error) {
+	return m.store.ListEndpoints(ctx, owner, offset, limit)
+}
+
+// GetDelivery returns a delivery log
+func (m *Manager) GetDelivery(ctx context.Context, id int) (*Delivery, error) {
+	return m.store.GetDelivery(ctx, id)
+}
+
+// ListDeliveries returns a page of delivery logs
+func (m *Manager) ListDeliveries(ctx context.Context, filter DeliveryFilter, offset, limit int) ([]Delivery, int64, error) {
+	return m.store.ListDeliveries(ctx, filter, offset, limit)
+}
+
+// envelope is the body every endpoint receives
+type envelope struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Publish sends event to every active endpoint of owner subscribed to it. Each
+// endpoint gets its own delivery log; all of them share the event ID so receivers
+// can de-duplicate retries and redeliveries.
+func (m *Manager) Publish(ctx context.Context, owner Owner, event string, data interface{}) ([]Delivery, error) {
+	endpoints, err := m.store.ActiveEndpoints(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	eventID, err := newEventID()
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(envelope{
+		ID:        eventID,
+		Event:     event,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	var deliveries []Delivery
+	for _, endpoint := range endpoints {
+		if !endpoint.Subscribes(event) {
+			continue
+		}
+
+		delivery := &Delivery{
+			EndpointID: endpoint.ID,
+			Event:      event,
+			Payload:    string(body),
+			Status:     StatusPending,
+		}
+		if err := m.store.CreateDelivery(ctx, delivery); err != nil {
+			return deliveries, err
+		}
+		if delivery, err = m.schedule(ctx, delivery); err != nil {
+			return deliveries, err
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver sends a logged delivery's payload again as a new delivery
+func (m *Manager) Redeliver(ctx context.Context, id int) (*Delivery, error) {
+	original, err := m.store.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.store.GetEndpoint(ctx, original.EndpointID); err != nil {
+		return nil, err
+	}
+
+	delivery := &Delivery{
+		EndpointID: original.EndpointID,
+		Event:      original.Event,
+		Payload:    original.Payload,
+		Status:     StatusPending,
+	}
+	if err := m.store.CreateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return m.schedule(ctx, delivery)
+}
+
+// schedule queues the first attempt, or makes it now without a queue
+func (m *Manager) schedule(ctx context.Context, delivery *Delivery) (*Delivery, error) {
+	if m.dispatcher == nil {
+		return m.Deliver(ctx, delivery.ID)
+	}
+	if err := m.dispatcher.Dispatch(queue.JobTypeWebhook, jobPayload(delivery.ID)); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Deliver makes one attempt of a delivery and records the outcome. A failed attempt
+// schedules the next one with exponential backoff until MaxAttempts is reached.
+// The returned error is only set when the log itself could not be read or written.
+func (m *Manager) Deliver(ctx context.Context, id int) (*Delivery, error) {
+	delivery, err := m.store.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Status == StatusSucceeded || delivery.Status == StatusFailed {
+		return delivery, nil
+	}
+
+	endpoint, err := m.store.GetEndpoint(ctx, delivery.EndpointID)
+	switch {
+	case errors.Is(err, ErrEndpointNotFound):
+		return m.finish(ctx, delivery, StatusFailed, ErrEndpointNotFound.Error())
+	case err != nil:
+		return nil, err
+	case !endpoint.Active:
+		return m.finish(ctx, delivery, StatusFailed, ErrEndpointInactive.Error())
+	}
+
+	attemptErr := m.attempt(ctx, endpoint, delivery)
+	delivery.Attempts++
+
+	if attemptErr == nil {
+		now := time.Now()
+		delivery.DeliveredAt = &now
+		return m.finish(ctx, delivery, StatusSucceeded, "")
+	}
+
+	logger.Warn("Webhook delivery attempt failed",
+		zap.Int("delivery_id", delivery.ID),
+		zap.Int("endpoint_id", endpoint.ID),
+		zap.Int("attempt", delivery.Attempts),
+		zap.Error(attemptErr))
+
+	if delivery.Attempts >= m.config.MaxAttempts || m.dispatcher == nil {
+		return m.finish(ctx, delivery, StatusFailed, attemptErr.Error())
+	}
+
+	delay := m.backoff(delivery.Attempts)
+	next := time.Now().Add(delay)
+	delivery.NextRetryAt = &next
+	if _, err := m.finish(ctx, delivery, StatusRetrying, attemptErr.Error()); err != nil {
+		return nil, err
+	}
+
+	if err := m.dispatcher.DispatchDelayed(queue.JobTypeWebhook, jobPayload(delivery.ID), delay); err != nil {
+		return nil, fmt.Errorf("failed to schedule webhook retry: %w", err)
+	}
+	return delivery, nil
+}
+
+// finish stores the delivery's new status
+func (m *Manager) finish(ctx context.Context, delivery *Delivery, status DeliveryStatus, errMsg string) (*Delivery, error) {
+	delivery.Status = status
+	delivery.Error = errMsg
+	if status != StatusRetrying {
+		delivery.NextRetryAt = nil
+	}
+	if err := m.store.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// attempt posts the payload to the endpoint and records the response on delivery
+func (m *Manager) attempt(ctx context.Context, endpoint *Endpoint, delivery *Delivery) error {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", m.config.UserAgent)
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, strconv.Itoa(delivery.ID))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(endpoint.Secret, timestamp, body))
+
+	start := time.Now()
+	resp, err := m.client.Do(req)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.ResponseStatus = 0
+		delivery.ResponseBody = ""
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	delivery.ResponseStatus = resp.StatusCode
+	delivery.ResponseBody = string(respBody)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint responded %s", resp.Status)
+	}
+	return nil
+}
+
+// backoff returns the delay after the given number of failed attempts
+func (m *Manager) backoff(attempts int) time.Duration {
+	delay := m.config.RetryBase
+	for i := 1; i < attempts && delay < m.config.RetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, m.config.RetryMax)
+}
+
+func validateOwner(owner Owner) error {
+	if owner.Type != OwnerTenant && owner.Type != OwnerUser {
+		return fmt.Errorf("%w: owner type must be %q or %q", ErrInvalidEndpoint, OwnerTenant, OwnerUser)
+	}
+	if owner.ID == "" {
+		return fmt.Errorf("%w: owner id is required", ErrInvalidEndpoint)
+	}
+	return nil
+}
+
+func validateEndpoint(rawURL string, events []string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidEndpoint)
+	}
+
+	for _, event := range events {
+		if strings.TrimSpace(event) == "" {
+			return fmt.Errorf("%w: event names cannot be empty", ErrInvalidEndpoint)
+		}
+	}
+	if len(events) == 0 {
+		return fmt.Errorf("%w: at least one event is required", ErrInvalidEndpoint)
+	}
+	return nil
+}
+
+func newEventID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "evt_" + hex.EncodeToString(b), nil
+}
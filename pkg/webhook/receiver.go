@@ -0,0 +1,142 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"flex-service/pkg/cache"
+)
+
+// Receiver defaults
+const (
+	DefaultTolerance   = 5 * time.Minute
+	DefaultMaxBodySize = 1 << 20 // 1MB
+)
+
+// Receiver verifies incoming webhooks from one provider: signature, timestamp
+// tolerance and replays. Use it through middleware.IncomingWebhook.
+type Receiver struct {
+	name        string
+	verifier    Verifier
+	tolerance   time.Duration
+	maxBodySize int64
+	nonces      cache.Cache
+	now         func() time.Time
+}
+
+// NewReceiver creates a receiver. name scopes replay keys, so two providers can
+// send the same delivery ID.
+func NewReceiver(name string, verifier Verifier) *Receiver {
+	return &Receiver{
+		name:        name,
+		verifier:    verifier,
+		tolerance:   DefaultTolerance,
+		maxBodySize: DefaultMaxBodySize,
+		now:         time.Now,
+	}
+}
+
+// WithTolerance sets how old a signed timestamp may be
+func (r *Receiver) WithTolerance(d time.Duration) *Receiver {
+	if d > 0 {
+		r.tolerance = d
+	}
+	return r
+}
+
+// WithMaxBodySize limits how much of the body is read
+func (r *Receiver) WithMaxBodySize(n int64) *Receiver {
+	if n > 0 {
+		r.maxBodySize = n
+	}
+	return r
+}
+
+// WithNonceCache rejects webhooks already received. Without a cache only the
+// timestamp tolerance limits replays.
+func (r *Receiver) WithNonceCache(c cache.Cache) *Receiver {
+	r.nonces = c
+	return r
+}
+
+// Verify reads the raw body, restores it for the handler and checks it. The raw
+// body is returned so handlers can decode exactly the bytes that were signed.
+func (r *Receiver) Verify(req *http.Request) ([]byte, *Signature, error) {
+	body, err := io.ReadAll(io.LimitReader(req.Body, r.maxBodySize+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if int64(len(body)) > r.maxBodySize {
+		return nil, nil, ErrBodyTooLarge
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	signature, err := r.verifier.Verify(req.Header, body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !signature.Timestamp.IsZero() {
+		if skew := r.now().Sub(signature.Timestamp); skew > r.tolerance || skew < -r.tolerance {
+			return nil, nil, ErrSignatureExpired
+		}
+	}
+
+	if err := r.checkNonce(req.Context(), signature.ID); err != nil {
+		return nil, nil, err
+	}
+
+	return body, signature, nil
+}
+
+// Release forgets a webhook so the provider's retry is accepted again. Call it
+// when the handler failed to process the webhook.
+func (r *Receiver) Release(ctx context.Context, signature *Signature) {
+	if r.nonces == nil || signature == nil {
+		return
+	}
+	_ = r.nonces.Del(ctx, r.nonceKey(signature.ID))
+}
+
+func (r *Receiver) checkNonce(ctx context.Context, id string) error {
+	if r.nonces == nil || id == "" {
+		return nil
+	}
+
+	cacheKey := r.nonceKey(id)
+	count, err := r.nonces.Incr(ctx, cacheKey)
+	if err != nil {
+		// A cache outage shouldn't drop provider traffic; the signature still applies
+		return nil
+	}
+	if count == 1 {
+		// Schemes without a timestamp can be replayed at any time, so remember them longer
+		ttl := 2 * r.tolerance
+		if ttl < 24*time.Hour {
+			ttl = 24 * time.Hour
+		}
+		_ = r.nonces.Expire(ctx, cacheKey, ttl)
+		return nil
+	}
+	return ErrReplayed
+}
+
+func (r *Receiver) nonceKey(id string) string {
+	return "webhook:incoming:" + r.name + ":" + id
+}
+
+type rawBodyKey struct{}
+
+// WithRawBody stores the verified raw body in a context
+func WithRawBody(ctx context.Context, body []byte) context.Context {
+	return context.WithValue(ctx, rawBodyKey{}, body)
+}
+
+// RawBody returns the raw body stored by middleware.IncomingWebhook
+func RawBody(ctx context.Context) ([]byte, bool) {
+	body, ok := ctx.Value(rawBodyKey{}).([]byte)
+	return body, ok
+}
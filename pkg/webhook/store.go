@@ -0,0 +1,124 @@
+package webhook
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// GormStore persists endpoints and deliveries with GORM
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore creates a GORM-backed store
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+// CreateEndpoint inserts an endpoint
+func (s *GormStore) CreateEndpoint(ctx context.Context, endpoint *Endpoint) error {
+	return s.db.WithContext(ctx).Create(endpoint).Error
+}
+
+// UpdateEndpoint saves every field of an endpoint
+func (s *GormStore) UpdateEndpoint(ctx context.Context, endpoint *Endpoint) error {
+	return s.db.WithContext(ctx).Save(endpoint).Error
+}
+
+// DeleteEndpoint removes an endpoint; its delivery logs are kept
+func (s *GormStore) DeleteEndpoint(ctx context.Context, id int) error {
+	result := s.db.WithContext(ctx).Delete(&Endpoint{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrEndpointNotFound
+	}
+	return nil
+}
+
+// GetEndpoint returns an endpoint by ID
+func (s *GormStore) GetEndpoint(ctx context.Context, id int) (*Endpoint, error) {
+	var endpoint Endpoint
+	err := s.db.WithContext(ctx).First(&endpoint, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrEndpointNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+// ListEndpoints returns a page of endpoints, optionally for one owner
+func (s *GormStore) ListEndpoints(ctx context.Context, owner *Owner, offset, limit int) ([]Endpoint, int64, error) {
+	query := s.db.WithContext(ctx).Model(&Endpoint{})
+	if owner != nil {
+		query = query.Where("owner_type = ? AND owner_id = ?", owner.Type, owner.ID)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var endpoints []Endpoint
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&endpoints).Error
+	return endpoints, total, err
+}
+
+// ActiveEndpoints returns the owner's active endpoints
+func (s *GormStore) ActiveEndpoints(ctx context.Context, owner Owner) ([]Endpoint, error) {
+	var endpoints []Endpoint
+	err := s.db.WithContext(ctx).
+		Where("owner_type = ? AND owner_id = ? AND active = ?", owner.Type, owner.ID, true).
+		Find(&endpoints).Error
+	return endpoints, err
+}
+
+// CreateDelivery inserts a delivery log
+func (s *GormStore) CreateDelivery(ctx context.Context, delivery *Delivery) error {
+	return s.db.WithContext(ctx).Create(delivery).Error
+}
+
+// UpdateDelivery saves every field of a delivery log
+func (s *GormStore) UpdateDelivery(ctx context.Context, delivery *Delivery) error {
+	return s.db.WithContext(ctx).Save(delivery).Error
+}
+
+// GetDelivery returns a delivery log by ID
+func (s *GormStore) GetDelivery(ctx context.Context, id int) (*Delivery, error) {
+	var delivery Delivery
+	err := s.db.WithContext(ctx).First(&delivery, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries returns a page of delivery logs, newest first
+func (s *GormStore) ListDeliveries(ctx context.Context, filter DeliveryFilter, offset, limit int) ([]Delivery, int64, error) {
+	query := s.db.WithContext(ctx).Model(&Delivery{})
+	if filter.EndpointID != 0 {
+		query = query.Where("endpoint_id = ?", filter.EndpointID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Event != "" {
+		query = query.Where("event = ?", filter.Event)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []Delivery
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&deliveries).Error
+	return deliveries, total, err
+}